@@ -3,10 +3,14 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"sync"
 
 	"github.com/joakimcarlsson/ai/agent/team"
 	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/schema"
 	"github.com/joakimcarlsson/ai/session"
 	"github.com/joakimcarlsson/ai/tokens"
 	"github.com/joakimcarlsson/ai/tool"
@@ -17,23 +21,44 @@ import (
 type Agent struct {
 	llm                  llm.LLM
 	memoryLLM            llm.LLM
+	toolsMu              sync.RWMutex
 	tools                []tool.BaseTool
+	delegationTools      []tool.BaseTool
+	toolChoice           *llm.ToolChoice
 	toolsets             []tool.Toolset
 	systemPrompt         string
+	promptLayers         []string
+	promptSeparator      *string
+	examples             []message.Message
 	maxIterations        int
 	autoExecute          bool
 	memory               memory.Store
 	memoryID             string
 	autoExtract          bool
 	autoDedup            bool
+	dedup                memory.DedupConfig
+	conflictResolution   bool
+	memoryFailClosed     bool
+	extractInterval      int
+	extractMu            sync.Mutex
+	extractPending       int
+	extractRunning       []chan struct{}
+	memoryTools          *memoryToolsConfig
 	session              session.Session
+	sessionStore         session.Store
+	sessionID            string
 	contextStrategy      tokens.Strategy
 	reserveTokens        int64
 	maxContextTokens     int64
+	contextCounter       contextCounter
 	parallelTools        bool
 	maxParallelTools     int
+	stateMu              sync.RWMutex
 	state                map[string]any
+	persistState         bool
+	trackUsage           bool
 	instructionProvider  func(ctx context.Context, state map[string]any) (string, error)
+	dynamicInstructions  InstructionProvider
 	handoffs             []HandoffConfig
 	taskManager          *TaskManager
 	hooks                []Hooks
@@ -41,6 +66,18 @@ type Agent struct {
 	team                 *team.Team
 	coordinatorMode      bool
 	teammateTemplates    map[string]*Agent
+	logger               *slog.Logger
+	knowledge            *knowledgeBase
+	toolResultLimit      *toolResultLimit
+	userRateLimit        *userRateLimiter
+	middleware           []AgentMiddleware
+	retryOnEmpty         int
+	webSearch            *llm.WebSearch
+	systemCache          *systemPromptCache
+	reasoningEffort      *llm.ReasoningEffort
+	verbosity            *llm.Verbosity
+	providerUserID       bool
+	outputSchema         *schema.StructuredOutputInfo
 }
 
 func (a *Agent) getMemoryLLM() llm.LLM {
@@ -68,26 +105,45 @@ func New(llmClient llm.LLM, opts ...Option) *Agent {
 		maxIterations: 0,
 		autoExecute:   true,
 		parallelTools: true,
+		logger:        slog.New(slog.DiscardHandler),
 	}
 
 	for _, opt := range opts {
 		opt(a)
 	}
 
+	if a.persistState {
+		a.loadPersistedState()
+	}
+
 	return a
 }
 
+// getToolsWithContext returns the tools offered on the next model call. A
+// tool whose name is already taken by an earlier tool or toolset is renamed
+// with [tool.Deduplicate], under its toolset's name when it has one, so the
+// model never sees two tools with the same name.
 func (a *Agent) getToolsWithContext(ctx context.Context) []tool.BaseTool {
+	a.toolsMu.RLock()
 	allTools := make([]tool.BaseTool, len(a.tools))
 	copy(allTools, a.tools)
+	a.toolsMu.RUnlock()
+
+	allTools = append(allTools, a.delegationTools...)
 
+	taken := make(map[string]bool, len(allTools))
+	allTools = tool.Deduplicate(allTools, "", taken)
 	for _, ts := range a.toolsets {
-		allTools = append(allTools, ts.Tools(ctx)...)
+		allTools = append(
+			allTools,
+			tool.Deduplicate(ts.Tools(ctx), ts.Name(), taken)...,
+		)
 	}
 
-	if a.memory != nil && !a.autoExtract && a.memoryID != "" {
-		memoryTools := memory.Tools(a.memory, a.memoryID)
-		allTools = append(allTools, memoryTools...)
+	allTools = append(allTools, a.memoryToolList(ctx)...)
+
+	if a.knowledge != nil && !a.knowledge.config.autoRetrieve {
+		allTools = append(allTools, &searchKnowledgeTool{kb: a.knowledge})
 	}
 
 	if a.taskManager != nil {
@@ -110,6 +166,65 @@ func (a *Agent) getToolsWithContext(ctx context.Context) []tool.BaseTool {
 	return allTools
 }
 
+// AddTool registers t for subsequent LLM calls, replacing any tool already
+// registered under the same name. It is safe to call while a Chat is in
+// flight: the running turn keeps the tool set it started with and the next
+// turn sees the change.
+func (a *Agent) AddTool(t tool.BaseTool) {
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	name := t.Info().Name
+	for i, existing := range a.tools {
+		if existing.Info().Name == name {
+			a.tools[i] = t
+			return
+		}
+	}
+	a.tools = append(a.tools, t)
+}
+
+// RemoveTool unregisters the tool with the given name and reports whether it
+// was registered. Only tools added with [WithTools], [Agent.AddTool], or
+// [Agent.SetTools] can be removed. Like AddTool it takes effect from the
+// next turn.
+func (a *Agent) RemoveTool(name string) bool {
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	for i, existing := range a.tools {
+		if existing.Info().Name == name {
+			a.tools = append(a.tools[:i:i], a.tools[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetTools replaces every tool added with [WithTools] or [Agent.AddTool].
+// Tools contributed by toolsets, sub-agents, handoffs, fan-out, memory, and
+// teams are unaffected. Like AddTool it takes effect from the next turn.
+func (a *Agent) SetTools(tools ...tool.BaseTool) {
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	a.tools = append([]tool.BaseTool(nil), tools...)
+}
+
+// toolChoiceContext returns ctx carrying choice for the first model call of a
+// run (turns == 0). Later calls use the vendor default so a forced tool call
+// is not repeated on every iteration.
+func toolChoiceContext(
+	ctx context.Context,
+	choice *llm.ToolChoice,
+	turns int,
+) context.Context {
+	if choice == nil || turns > 0 {
+		return ctx
+	}
+	return llm.ContextWithToolChoice(ctx, *choice)
+}
+
 // ParseToolInput parses a JSON tool input string into the specified type.
 // This is a helper function for implementing tool.BaseTool.Run().
 func ParseToolInput[T any](input string) (T, error) {
@@ -16,11 +16,30 @@ import (
 // If memory is configured, relevant memories are injected into the context.
 // If a session is configured, the conversation history is persisted.
 // If handoffs are configured, the active agent may change mid-conversation.
+//
+// The session is not a parameter: it is set on the agent with [WithSession],
+// so an agent holds one conversation. To serve several, create an agent per
+// conversation with the same options and a different session ID.
+//
+// Middleware registered with [WithMiddleware] wraps the whole call.
 func (a *Agent) Chat(
 	ctx context.Context,
 	userMessage string,
 	opts ...ChatOption,
 ) (*ChatResponse, error) {
+	ctx = withStoredResponse(callContext(ctx, opts))
+	return chainMiddleware(a.middleware, a.chat)(ctx, userMessage, opts...)
+}
+
+func (a *Agent) chat(
+	ctx context.Context,
+	userMessage string,
+	opts ...ChatOption,
+) (*ChatResponse, error) {
+	if err := a.checkUserRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
 	cfg := applyChatOptions(opts)
 	startTime := time.Now()
 	taskID, agentName, branch := a.hookContext(ctx)
@@ -29,6 +48,7 @@ func (a *Agent) Chat(
 	defer func() {
 		span.End()
 	}()
+	ctx = withStateAgent(ctx, a)
 
 	runBeforeRun(ctx, a.hooks, RunContext{
 		AgentName: agentName,
@@ -101,12 +121,15 @@ func (a *Agent) Chat(
 		return resp, nil
 	}
 
-	messages, err := a.buildMessages(ctx, userMessage)
+	messages, sources, err := a.buildMessages(ctx, userMessage)
 	if err != nil {
 		return nil, err
 	}
 
 	resp, err := a.runLoop(ctx, messages, cfg)
+	if resp != nil {
+		resp.Sources = sources
+	}
 
 	if err == nil {
 		aaResult, aaErr := runAfterAgent(ctx, a.hooks, LifecycleContext{
@@ -155,6 +178,7 @@ func (a *Agent) Continue(
 	toolResults []message.ToolResult,
 	opts ...ChatOption,
 ) (*ChatResponse, error) {
+	ctx = callContext(ctx, opts)
 	if a.session == nil {
 		return nil, fmt.Errorf(
 			"agent: Continue requires a session to restore conversation state",
@@ -165,6 +189,9 @@ func (a *Agent) Continue(
 			"agent: Continue requires at least one tool result",
 		)
 	}
+	if err := a.checkUserRateLimit(ctx); err != nil {
+		return nil, err
+	}
 
 	cfg := applyChatOptions(opts)
 	startTime := time.Now()
@@ -174,6 +201,7 @@ func (a *Agent) Continue(
 	defer func() {
 		span.End()
 	}()
+	ctx = withStateAgent(ctx, a)
 
 	runBeforeRun(ctx, a.hooks, RunContext{
 		AgentName: agentName,
@@ -242,7 +270,7 @@ func (a *Agent) Continue(
 	}
 	messages = append(messages, toolMsg)
 
-	if err := a.session.AddMessages(
+	if err := a.sessionFor(ctx).AddMessages(
 		ctx,
 		[]message.Message{toolMsg},
 	); err != nil {
@@ -297,7 +325,9 @@ func (a *Agent) runLoop(
 	startTime := time.Now()
 	var totalUsage llm.TokenUsage
 	var totalToolCalls int
+	var allToolResults []ToolExecutionResult
 	var turns int
+	var emptyRetries int
 
 	activeAgent := a
 	iteration := 0
@@ -307,9 +337,15 @@ func (a *Agent) runLoop(
 		maxIter = cfg.maxIterations
 	}
 
+	toolChoice := cfg.toolChoice
+	if toolChoice == nil {
+		toolChoice = a.toolChoice
+	}
+
 	for {
 		turnStart := time.Now()
-		allTools := activeAgent.getToolsWithContext(ctx)
+		available := activeAgent.getToolsWithContext(ctx)
+		allTools := available
 
 		taskID, agentName, branch := activeAgent.hookContext(ctx)
 		mcResult, err := runPreModelCall(
@@ -331,7 +367,27 @@ func (a *Agent) runLoop(
 			allTools = mcResult.Tools
 		}
 
-		resp, err := activeAgent.llm.SendMessages(ctx, messages, allTools)
+		messages, err = activeAgent.fitLiveContext(
+			ctx,
+			messages,
+			allTools,
+			turns > 0,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		modelCtx, err := activeAgent.modelContext(
+			ctx,
+			toolChoice,
+			turns,
+			allTools,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := activeAgent.sendMessages(modelCtx, messages, allTools)
 
 		mrResult, hookErr := runPostModelCall(
 			ctx,
@@ -373,10 +429,21 @@ func (a *Agent) runLoop(
 
 		turns++
 		totalUsage.Add(resp.Usage)
+		a.recordUsage(ctx, activeAgent.llm, resp.Usage)
+
+		empty := isEmptyResponse(
+			resp.FinishReason,
+			resp.Content,
+			resp.ToolCalls,
+		)
+		if empty && emptyRetries < activeAgent.retryOnEmpty {
+			emptyRetries++
+			continue
+		}
 
 		if len(resp.ToolCalls) == 0 || !activeAgent.autoExecute ||
 			(maxIter > 0 && iteration >= maxIter) {
-			if activeAgent.session != nil {
+			if sess := activeAgent.sessionFor(ctx); sess != nil && !empty {
 				assistantMsg := message.NewAssistantMessage()
 				assistantMsg.Model = activeAgent.llm.Model().ID
 				if resp.Content != "" {
@@ -385,34 +452,42 @@ func (a *Agent) runLoop(
 				if resp.Reasoning != "" {
 					assistantMsg.AppendReasoningContent(resp.Reasoning)
 				}
+				assistantMsg.AddCitations(resp.Citations)
 				if len(resp.ToolCalls) > 0 && !activeAgent.autoExecute {
 					assistantMsg.AppendToolCalls(resp.ToolCalls)
 				}
 				if resp.Content != "" || resp.Reasoning != "" ||
 					len(resp.ToolCalls) > 0 && !activeAgent.autoExecute {
-					if err := activeAgent.session.AddMessages(
+					if err := sess.AddMessages(
 						ctx,
 						[]message.Message{assistantMsg},
 					); err != nil {
 						return nil, err
 					}
+					recordStoredResponse(
+						ctx,
+						sess,
+						assistantMsg,
+					)
 				}
 			}
 
-			if activeAgent.autoExtract && activeAgent.session != nil {
-				go activeAgent.extractAndStoreMemories(context.Background())
-			}
+			activeAgent.scheduleExtraction(ctx)
 
 			chatResp := &ChatResponse{
 				Content:            resp.Content,
 				Reasoning:          resp.Reasoning,
 				ToolCalls:          resp.ToolCalls,
+				ToolResults:        allToolResults,
 				Usage:              totalUsage,
 				FinishReason:       resp.FinishReason,
 				ProviderResponseID: resp.ProviderResponseID,
 				TotalToolCalls:     totalToolCalls,
 				TotalDuration:      time.Since(startTime),
 				TotalTurns:         turns,
+				Empty:              empty,
+				Citations:          resp.Citations,
+				StructuredOutput:   activeAgent.structuredOutputOf(resp),
 			}
 			if activeAgent != a {
 				chatResp.AgentName = findAgentName(a, activeAgent)
@@ -430,10 +505,12 @@ func (a *Agent) runLoop(
 		if resp.Reasoning != "" {
 			assistantMsg.AppendReasoningContent(resp.Reasoning)
 		}
+		assistantMsg.AddCitations(resp.Citations)
 		assistantMsg.AppendToolCalls(resp.ToolCalls)
 		messages = append(messages, assistantMsg)
 
-		toolResults := activeAgent.executeTools(ctx, resp.ToolCalls)
+		toolResults := activeAgent.executeTools(ctx, available, resp.ToolCalls)
+		allToolResults = append(allToolResults, toolResults...)
 
 		toolMsg := message.Message{
 			Role:      message.Tool,
@@ -446,12 +523,14 @@ func (a *Agent) runLoop(
 				Name:       result.ToolName,
 				Content:    result.Output,
 				IsError:    result.IsError,
+				MIMEType:   result.MIMEType,
+				Images:     result.Images,
 			})
 		}
 		messages = append(messages, toolMsg)
 
-		if activeAgent.session != nil {
-			if err := activeAgent.session.AddMessages(
+		if sess := activeAgent.sessionFor(ctx); sess != nil {
+			if err := sess.AddMessages(
 				ctx,
 				[]message.Message{assistantMsg, toolMsg},
 			); err != nil {
@@ -463,9 +542,11 @@ func (a *Agent) runLoop(
 			resp.ToolCalls,
 			activeAgent.handoffs,
 		); handoff != nil {
+			previous := activeAgent
 			activeAgent = handoff.Agent
 			messages, err = rebuildMessagesForHandoff(
 				ctx,
+				previous,
 				activeAgent,
 				messages,
 			)
@@ -1,10 +1,18 @@
 package agent
 
+import (
+	"context"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+)
+
 // ChatOption is a functional option for per-call overrides on Chat() and ChatStream().
 type ChatOption func(*chatConfig)
 
 type chatConfig struct {
 	maxIterations int // 0 = use agent default
+	toolChoice    *llm.ToolChoice
+	userID        string
 }
 
 func applyChatOptions(opts []ChatOption) chatConfig {
@@ -22,3 +30,33 @@ func WithMaxTurns(n int) ChatOption {
 		c.maxIterations = n
 	}
 }
+
+// WithCallToolChoice overrides the agent's WithToolChoice setting for this
+// call. Like the agent option it applies to the first model call of the run.
+func WithCallToolChoice(choice llm.ToolChoice) ChatOption {
+	return func(c *chatConfig) {
+		c.toolChoice = &choice
+	}
+}
+
+// WithCallUserID identifies the end user this call is made for, the same as
+// passing a context from [WithUserID]: memory is kept under the ID when
+// [WithMemory] was given an empty one, [WithUserRateLimit] counts the call
+// against it, and providers receive it when the agent was built with
+// [WithProviderUserID].
+//
+//	resp, err := a.Chat(ctx, input, agent.WithCallUserID("alice"))
+func WithCallUserID(userID string) ChatOption {
+	return func(c *chatConfig) {
+		c.userID = userID
+	}
+}
+
+// callContext returns ctx carrying the user ID set with [WithCallUserID],
+// so everything that reads [UserIDFromContext] during the call sees it.
+func callContext(ctx context.Context, opts []ChatOption) context.Context {
+	if cfg := applyChatOptions(opts); cfg.userID != "" {
+		return WithUserID(ctx, cfg.userID)
+	}
+	return ctx
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/tokens"
+)
+
+// ErrCompactBudget is returned by [CompactSession] when no budget was set with
+// [CompactMaxTokens] and the strategy only trims messages, as truncate and
+// sliding do. Without a budget such a strategy would drop nearly the whole
+// history.
+var ErrCompactBudget = errors.New(
+	"agent: compacting with a trimming strategy requires CompactMaxTokens",
+)
+
+// CompactOption configures [CompactSession].
+type CompactOption func(*compactConfig)
+
+type compactConfig struct {
+	archive   session.Session
+	maxTokens int64
+}
+
+// CompactArchive copies the session's original messages to archive before
+// they are rewritten, so the full history stays available for auditing.
+// Nothing is written to the session when the copy fails.
+//
+//	archive, _ := store.Create(ctx, "user-123-archive-2025-06")
+//	agent.CompactSession(ctx, sess, strategy, agent.CompactArchive(archive))
+func CompactArchive(archive session.Session) CompactOption {
+	return func(c *compactConfig) { c.archive = archive }
+}
+
+// CompactMaxTokens sets the token budget the strategy fits the history into.
+// The default of zero compacts as much as the strategy allows, which for the
+// summarize strategy is everything but the messages it keeps recent. It is
+// required for strategies that only trim, such as truncate and sliding; see
+// [ErrCompactBudget].
+func CompactMaxTokens(n int64) CompactOption {
+	return func(c *compactConfig) { c.maxTokens = n }
+}
+
+// CompactResult reports what [CompactSession] did.
+type CompactResult struct {
+	// MessagesBefore is the number of messages stored before compaction.
+	MessagesBefore int
+	// MessagesAfter is the number of messages stored afterwards. It equals
+	// MessagesBefore when the strategy left the history as it was.
+	MessagesAfter int
+}
+
+// CompactSession permanently compacts a stored session: it runs strategy over
+// the session's history and replaces the stored messages with the result, so
+// later loads are smaller and faster. Unlike [WithContextStrategy], which
+// trims what is sent on each call and keeps the full history in storage, this
+// is a maintenance operation that rewrites the store. The stored history is
+// replaced with [session.ReplaceMessages], atomically for every built-in
+// store, so a failed write leaves the original messages in place.
+//
+// With the summarize strategy the older turns are replaced by a single
+// [message.Summary] message followed by the recent ones. Agents that load the
+// session later should use a summarize context strategy too, since that is
+// what turns the summary into context for the model.
+//
+//	sess, _ := store.Load(ctx, "user-123")
+//	res, err := agent.CompactSession(
+//		ctx,
+//		sess,
+//		summarize.Strategy(client, summarize.KeepRecent(10)),
+//	)
+func CompactSession(
+	ctx context.Context,
+	sess session.Session,
+	strategy tokens.Strategy,
+	opts ...CompactOption,
+) (*CompactResult, error) {
+	var cfg compactConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	msgs, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	result := &CompactResult{
+		MessagesBefore: len(msgs),
+		MessagesAfter:  len(msgs),
+	}
+	if len(msgs) == 0 {
+		return result, nil
+	}
+
+	counter, err := tokens.NewCounter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token counter: %w", err)
+	}
+	fit, err := strategy.Fit(ctx, tokens.StrategyInput{
+		Messages:  msgs,
+		Counter:   counter,
+		MaxTokens: cfg.maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("context strategy failed: %w", err)
+	}
+	if cfg.maxTokens == 0 && fit.SessionUpdate == nil {
+		return nil, ErrCompactBudget
+	}
+
+	compacted := compactedHistory(msgs, fit)
+	if len(compacted) == len(msgs) {
+		return result, nil
+	}
+
+	if cfg.archive != nil {
+		if err := cfg.archive.AddMessages(ctx, msgs); err != nil {
+			return nil, fmt.Errorf("failed to archive session: %w", err)
+		}
+	}
+	if err := session.ReplaceMessages(ctx, sess, compacted); err != nil {
+		return nil, fmt.Errorf("failed to save compacted session: %w", err)
+	}
+	result.MessagesAfter = len(compacted)
+	return result, nil
+}
+
+// compactedHistory returns the messages to store in place of msgs. When the
+// strategy asked for a session update, as the summarize strategy does, the
+// update is applied and everything before the newest summary is dropped.
+// Otherwise the strategy's trimmed messages are stored, with the summaries it
+// reports converting for the model turned back into [message.Summary]
+// messages.
+func compactedHistory(
+	msgs []message.Message,
+	fit *tokens.StrategyResult,
+) []message.Message {
+	if update := fit.SessionUpdate; update != nil {
+		keep := max(len(msgs)-update.PopCount, 0)
+		history := append(slices.Clone(msgs[:keep]), update.AddMessages...)
+		return fromLastSummary(history)
+	}
+
+	out := slices.Clone(fit.Messages)
+	for _, i := range fit.Summaries {
+		if i >= 0 && i < len(out) {
+			out[i].Role = message.Summary
+		}
+	}
+	return out
+}
+
+// fromLastSummary keeps the system messages and everything from the newest
+// summary on.
+func fromLastSummary(msgs []message.Message) []message.Message {
+	last := -1
+	for i, m := range msgs {
+		if m.Role == message.Summary {
+			last = i
+		}
+	}
+	if last <= 0 {
+		return msgs
+	}
+	var out []message.Message
+	for _, m := range msgs[:last] {
+		if m.Role == message.System {
+			out = append(out, m)
+		}
+	}
+	return append(out, msgs[last:]...)
+}
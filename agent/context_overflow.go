@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tokens"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// ErrContextOverflow is returned by Chat, ChatStream, and the Continue
+// methods when no context strategy is configured and the assembled messages,
+// system prompt, and tool definitions for a model call exceed the context
+// limit. The count comes from the LLM client when it implements
+// [tokens.TokenCounter], and from the local tokenizer otherwise. A local
+// count is only an estimate of the provider's, so an estimate up to 10% over
+// the limit is logged and the call is sent. The wrapping error reports the
+// counted tokens, the limit, and the overflow.
+var ErrContextOverflow = errors.New("agent: context overflow")
+
+const (
+	defaultResponseReserve = 4096
+	responseReserveMargin  = 1024
+	maxReserveFraction     = 4
+	// estimateTolerance is the share of the limit, in percent, a local
+	// estimate may exceed it by before the call fails.
+	estimateTolerance = 10
+)
+
+type contextCounter struct {
+	once    sync.Once
+	counter *tokens.Counter
+	err     error
+}
+
+func (c *contextCounter) get() (*tokens.Counter, error) {
+	c.once.Do(func() {
+		c.counter, c.err = tokens.NewCounter()
+	})
+	return c.counter, c.err
+}
+
+// tokenCounter returns the counter model calls are measured with: the LLM
+// client's own when it, or a client it decorates, implements
+// [tokens.TokenCounter], and else the local tokenizer. exact reports whether
+// the count comes from the client.
+func (a *Agent) tokenCounter() (
+	counter tokens.TokenCounter,
+	exact bool,
+	err error,
+) {
+	client := a.llm
+	for client != nil {
+		if c, ok := client.(tokens.TokenCounter); ok {
+			return c, true, nil
+		}
+		w, ok := client.(interface{ Unwrap() llm.LLM })
+		if !ok {
+			break
+		}
+		client = w.Unwrap()
+	}
+	local, err := a.contextCounter.get()
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"failed to create token counter: %w",
+			err,
+		)
+	}
+	return local, false, nil
+}
+
+// contextLimit returns the token limit a single model call must stay under:
+// the limit passed to [WithContextStrategy], capped at the model's context
+// window, or else the window minus [Agent.responseReserve]. It is zero when
+// neither is known.
+func (a *Agent) contextLimit() int64 {
+	window := a.llm.Model().ContextWindow
+	if a.maxContextTokens > 0 {
+		if window > 0 {
+			return min(a.maxContextTokens, window)
+		}
+		return a.maxContextTokens
+	}
+	if window <= 0 {
+		return 0
+	}
+	return window - a.responseReserve(window)
+}
+
+// responseReserve returns the tokens kept free in a context window of the
+// given size for the model's output: the tokens set with
+// [WithResponseReserve], or else the max tokens the LLM client was configured
+// with, plus a safety margin for token count drift between the local
+// tokenizer and the provider's. It never exceeds a quarter of the window, so
+// a model whose output limit matches its window still has room for input.
+func (a *Agent) responseReserve(window int64) int64 {
+	reserve := a.reserveTokens
+	if reserve == 0 {
+		reserve = llm.ConfiguredMaxTokens(a.llm)
+	}
+	if reserve == 0 {
+		reserve = defaultResponseReserve
+	} else {
+		reserve += responseReserveMargin
+	}
+	return min(reserve, window/maxReserveFraction)
+}
+
+// fitLiveContext measures the messages and tools about to be sent to the
+// model. Without a context strategy an over-limit call fails with
+// [ErrContextOverflow], unless the count is a local estimate within
+// estimateTolerance of the limit, which is logged and sent. With one, the
+// strategy has already fitted the turn's first call, but tool results added
+// during the run have not been seen by it, so later calls (refit) that are
+// over the limit are fitted again.
+func (a *Agent) fitLiveContext(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	refit bool,
+) ([]message.Message, error) {
+	limit := a.contextLimit()
+	if limit == 0 || (a.contextStrategy != nil && !refit) {
+		return messages, nil
+	}
+	counter, exact, err := a.tokenCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := countContext(ctx, counter, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	if total <= limit {
+		return messages, nil
+	}
+	if a.contextStrategy == nil {
+		if exact || total > limit+limit*estimateTolerance/100 {
+			return nil, contextOverflow(total, limit)
+		}
+		a.logger.WarnContext(ctx, "estimated context over limit",
+			slog.Int64("tokens", total),
+			slog.Int64("limit", limit),
+		)
+		return messages, nil
+	}
+
+	maxTokens, err := a.contextBudget(ctx, counter)
+	if err != nil {
+		return nil, err
+	}
+	live := a.removeExamples(messages)
+	result, err := a.contextStrategy.Fit(ctx, tokens.StrategyInput{
+		Messages:     live,
+		SystemPrompt: systemPromptOf(live),
+		Tools:        tools,
+		Counter:      counter,
+		MaxTokens:    maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("context strategy failed: %w", err)
+	}
+	if err := a.applySessionUpdate(ctx, result.SessionUpdate); err != nil {
+		return nil, err
+	}
+	a.logContextFit(ctx, len(live), len(result.Messages), maxTokens)
+
+	fitted := a.insertExamples(
+		a.dropOrphanedToolResults(ctx, result.Messages),
+	)
+	total, err = countContext(ctx, counter, fitted, tools)
+	if err != nil {
+		return nil, err
+	}
+	if total > limit {
+		a.logger.WarnContext(ctx, "context still over limit after strategy",
+			slog.Int64("tokens", total),
+			slog.Int64("limit", limit),
+		)
+	}
+	return fitted, nil
+}
+
+func countContext(
+	ctx context.Context,
+	counter tokens.TokenCounter,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (int64, error) {
+	count, err := counter.CountTokens(ctx, tokens.CountOptions{
+		Messages:     messages,
+		SystemPrompt: systemPromptOf(messages),
+		Tools:        tools,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count context tokens: %w", err)
+	}
+	return count.TotalTokens, nil
+}
+
+func contextOverflow(total, limit int64) error {
+	return fmt.Errorf(
+		"%w: %d tokens exceed the %d token limit by %d",
+		ErrContextOverflow,
+		total,
+		limit,
+		total-limit,
+	)
+}
+
+func systemPromptOf(messages []message.Message) string {
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role != message.System {
+			break
+		}
+		parts = append(parts, msg.Content().Text)
+	}
+	return strings.Join(parts, "\n")
+}
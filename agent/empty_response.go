@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// WithRetryOnEmpty calls the model again, up to n times, when it ends a turn
+// normally with no tool calls and no content beyond whitespace. Providers
+// occasionally return such a response with a normal stop reason; a retry with
+// the same messages usually succeeds. A response stopped by the token limit or
+// a content filter is not empty and is never retried. Each retry counts
+// toward TotalTurns and Usage.
+//
+// A turn that is still empty once the retries are used up is returned with
+// [ChatResponse.Empty] set, and ChatStream emits [types.EventEmptyResponse]
+// before completing. Empty turns are never stored in the session. The default
+// of 0 reports empty responses without retrying.
+func WithRetryOnEmpty(n int) Option {
+	return func(a *Agent) {
+		a.retryOnEmpty = max(n, 0)
+	}
+}
+
+func isEmptyResponse(
+	finishReason message.FinishReason,
+	content string,
+	toolCalls []message.ToolCall,
+) bool {
+	return finishReason == message.FinishReasonEndTurn &&
+		len(toolCalls) == 0 &&
+		strings.TrimSpace(content) == ""
+}
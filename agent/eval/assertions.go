@@ -0,0 +1,184 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Contains asserts that the response content contains substr.
+func Contains(substr string) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		if !strings.Contains(out.Response.Content, substr) {
+			return fmt.Errorf(
+				"response does not contain %q: %q",
+				substr,
+				out.Response.Content,
+			)
+		}
+		return nil
+	}
+}
+
+// NotContains asserts that the response content does not contain substr.
+func NotContains(substr string) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		if strings.Contains(out.Response.Content, substr) {
+			return fmt.Errorf(
+				"response contains %q: %q",
+				substr,
+				out.Response.Content,
+			)
+		}
+		return nil
+	}
+}
+
+// Matches asserts that the response content matches the regular expression
+// pattern. It panics if pattern does not compile.
+func Matches(pattern string) Assertion {
+	re := regexp.MustCompile(pattern)
+	return func(_ context.Context, out Outcome) error {
+		if !re.MatchString(out.Response.Content) {
+			return fmt.Errorf(
+				"response does not match %q: %q",
+				pattern,
+				out.Response.Content,
+			)
+		}
+		return nil
+	}
+}
+
+// CalledTool asserts that the agent called the tool name at least once.
+func CalledTool(name string) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		for _, call := range out.ToolCalls() {
+			if call.Name == name {
+				return nil
+			}
+		}
+		return fmt.Errorf(
+			"tool %s was not called (called: %s)",
+			name,
+			toolNames(out),
+		)
+	}
+}
+
+// NotCalledTool asserts that the agent never called the tool name.
+func NotCalledTool(name string) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		for _, call := range out.ToolCalls() {
+			if call.Name == name {
+				return fmt.Errorf(
+					"tool %s was called with %s",
+					name,
+					call.Input,
+				)
+			}
+		}
+		return nil
+	}
+}
+
+// CalledToolWith asserts that the agent called the tool name with the
+// top-level argument arg equal to want. Values are compared as JSON, so want
+// may be any value that marshals to the expected argument, such as a string,
+// number, or map.
+func CalledToolWith(name, arg string, want any) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		wantJSON, err := normalizeJSON(want)
+		if err != nil {
+			return fmt.Errorf("encode expected %s: %w", arg, err)
+		}
+		var seen []string
+		for _, call := range out.ToolCalls() {
+			if call.Name != name {
+				continue
+			}
+			var args map[string]any
+			if err := json.Unmarshal([]byte(call.Input), &args); err != nil {
+				seen = append(seen, call.Input)
+				continue
+			}
+			if reflect.DeepEqual(args[arg], wantJSON) {
+				return nil
+			}
+			seen = append(seen, call.Input)
+		}
+		if len(seen) == 0 {
+			return fmt.Errorf("tool %s was not called", name)
+		}
+		return fmt.Errorf(
+			"tool %s was not called with %s=%v (inputs: %s)",
+			name,
+			arg,
+			want,
+			strings.Join(seen, ", "),
+		)
+	}
+}
+
+// CalledToolsInOrder asserts that the agent called the named tools in this
+// order. Other calls may come before, between, or after them.
+func CalledToolsInOrder(names ...string) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		next := 0
+		for _, call := range out.ToolCalls() {
+			if next < len(names) && call.Name == names[next] {
+				next++
+			}
+		}
+		if next < len(names) {
+			return fmt.Errorf(
+				"tools not called in order %s (called: %s)",
+				strings.Join(names, ", "),
+				toolNames(out),
+			)
+		}
+		return nil
+	}
+}
+
+// ToolCallCount asserts that the agent made exactly n tool calls.
+func ToolCallCount(n int) Assertion {
+	return func(_ context.Context, out Outcome) error {
+		if got := len(out.ToolCalls()); got != n {
+			return fmt.Errorf(
+				"made %d tool calls, want %d (called: %s)",
+				got,
+				n,
+				toolNames(out),
+			)
+		}
+		return nil
+	}
+}
+
+func toolNames(out Outcome) string {
+	calls := out.ToolCalls()
+	if len(calls) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(calls))
+	for _, call := range calls {
+		names = append(names, call.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
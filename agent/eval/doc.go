@@ -0,0 +1,28 @@
+// Package eval regression-tests agent behavior.
+//
+// A [Case] sends one input to an agent and checks the outcome with
+// [Assertion]s: which tools were called and with what arguments, what the
+// response says, and optionally how an LLM judge scores it. [Run] executes a
+// suite and returns a [Report]; [RunT] runs each case as a subtest so a suite
+// can live in an ordinary Go test and fail CI.
+//
+// Pair it with the llm/fake package to script the model's replies, so the
+// suite checks the agent's tool wiring and hooks deterministically:
+//
+//	client := fake.NewLLM(fake.WithResponses(
+//		fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+//		fake.Text("It is sunny in Paris."),
+//	))
+//	a := agent.New(client, agent.WithTools(weatherTool))
+//
+//	eval.RunT(t, a, []eval.Case{{
+//		Name:  "weather",
+//		Input: "What's the weather in Paris?",
+//		Assert: []eval.Assertion{
+//			eval.CalledToolWith("get_weather", "city", "Paris"),
+//			eval.Contains("sunny"),
+//		},
+//	}})
+//
+// Against a real provider, add [Judge] to score open-ended answers.
+package eval
@@ -0,0 +1,178 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// Case is one evaluation scenario: an input sent to an agent and the
+// assertions its outcome must satisfy.
+type Case struct {
+	// Name identifies the case in reports and subtest names.
+	Name string
+	// Input is the user message sent with [agent.Agent.Chat].
+	Input string
+	// Agent runs this case instead of the suite's agent, for cases that need
+	// their own scripted client or options.
+	Agent *agent.Agent
+	// Assert lists the checks applied to the outcome. Every assertion runs,
+	// so one report shows all of a case's failures.
+	Assert []Assertion
+}
+
+// Outcome is what an agent produced for a case, passed to each assertion.
+type Outcome struct {
+	// Input is the case input.
+	Input string
+	// Response is the agent's response.
+	Response *agent.ChatResponse
+}
+
+// ToolCalls returns the tool calls the agent made, in order: every executed
+// call followed by any left pending in the final response.
+func (o Outcome) ToolCalls() []message.ToolCall {
+	calls := make(
+		[]message.ToolCall,
+		0,
+		len(o.Response.ToolResults)+len(o.Response.ToolCalls),
+	)
+	for _, r := range o.Response.ToolResults {
+		calls = append(calls, message.ToolCall{
+			ID:    r.ToolCallID,
+			Name:  r.ToolName,
+			Input: r.Input,
+		})
+	}
+	return append(calls, o.Response.ToolCalls...)
+}
+
+// Assertion checks one aspect of an outcome and returns an error describing
+// the mismatch, or nil when it holds.
+type Assertion func(ctx context.Context, out Outcome) error
+
+// CaseResult is the result of running one case.
+type CaseResult struct {
+	// Name is the case name.
+	Name string
+	// Response is the agent's response, nil when Err is set.
+	Response *agent.ChatResponse
+	// Err is set when the agent returned an error; assertions are skipped.
+	Err error
+	// Failures holds the errors of the assertions that did not hold.
+	Failures []error
+	// Duration is how long the agent took to respond.
+	Duration time.Duration
+}
+
+// Passed reports whether the agent responded and every assertion held.
+func (r CaseResult) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Error joins the run error and assertion failures, or returns nil when the
+// case passed.
+func (r CaseResult) Error() error {
+	if r.Err != nil {
+		return fmt.Errorf("agent: %w", r.Err)
+	}
+	return errors.Join(r.Failures...)
+}
+
+// Report collects the results of a suite.
+type Report struct {
+	Results []CaseResult
+}
+
+// Passed returns the number of cases that passed.
+func (r *Report) Passed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of cases that failed.
+func (r *Report) Failed() int {
+	return len(r.Results) - r.Passed()
+}
+
+// OK reports whether every case passed.
+func (r *Report) OK() bool {
+	return r.Failed() == 0
+}
+
+// String renders one PASS or FAIL line per case followed by a summary.
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		if res.Passed() {
+			fmt.Fprintf(&b, "PASS %s (%s)\n", res.Name, res.Duration)
+			continue
+		}
+		fmt.Fprintf(&b, "FAIL %s (%s)\n", res.Name, res.Duration)
+		for _, line := range strings.Split(res.Error().Error(), "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	fmt.Fprintf(&b, "%d/%d passed", r.Passed(), len(r.Results))
+	return b.String()
+}
+
+// Run sends each case to its agent, or to a when the case has none, and
+// checks the assertions. Cases run in order; an agent with a session carries
+// history from one case to the next, so give such cases their own agents.
+func Run(ctx context.Context, a *agent.Agent, cases []Case) *Report {
+	report := &Report{Results: make([]CaseResult, 0, len(cases))}
+	for _, c := range cases {
+		report.Results = append(report.Results, RunCase(ctx, a, c))
+	}
+	return report
+}
+
+// RunCase runs a single case. See [Run].
+func RunCase(ctx context.Context, a *agent.Agent, c Case) CaseResult {
+	if c.Agent != nil {
+		a = c.Agent
+	}
+	result := CaseResult{Name: c.Name}
+	start := time.Now()
+	resp, err := a.Chat(ctx, c.Input)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Response = resp
+
+	out := Outcome{Input: c.Input, Response: resp}
+	for _, assert := range c.Assert {
+		if err := assert(ctx, out); err != nil {
+			result.Failures = append(result.Failures, err)
+		}
+	}
+	return result
+}
+
+// RunT runs each case as a subtest of t named after the case, failing the
+// subtest with every assertion failure.
+func RunT(t *testing.T, a *agent.Agent, cases []Case) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			result := RunCase(t.Context(), a, c)
+			if err := result.Error(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
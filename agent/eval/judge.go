@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/schema"
+)
+
+const judgePrompt = `You grade an AI assistant's response against a ` +
+	`criterion. Score how well the response meets it from 0 (not at all) ` +
+	`to 1 (fully). Reply with JSON: {"score": <number between 0 and 1>, ` +
+	`"reason": "<one sentence>"}.`
+
+// Verdict is an LLM judge's assessment of a response.
+type Verdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+var verdictSchema = schema.Object().
+	Field("score", schema.Number().Desc("Criterion fit from 0 to 1")).
+	Field("reason", schema.String().Desc("One sentence explaining the score")).
+	Required("score", "reason").
+	Output("verdict", "Assessment of the response against the criterion")
+
+// Judge asserts that client, acting as a judge, scores the response at least
+// minScore (between 0 and 1) against criterion, such as "politely declines
+// to give medical advice". The judge sees the case input and the response.
+// Structured output is used when the client supports it.
+func Judge(client llm.LLM, criterion string, minScore float64) Assertion {
+	return func(ctx context.Context, out Outcome) error {
+		verdict, err := Grade(ctx, client, criterion, out)
+		if err != nil {
+			return err
+		}
+		if verdict.Score < minScore {
+			return fmt.Errorf(
+				"judge scored %.2f, want at least %.2f for %q: %s",
+				verdict.Score,
+				minScore,
+				criterion,
+				verdict.Reason,
+			)
+		}
+		return nil
+	}
+}
+
+// Grade asks client to score out against criterion and returns its verdict.
+// It is the building block of [Judge] for callers that want to record scores
+// rather than assert a threshold.
+func Grade(
+	ctx context.Context,
+	client llm.LLM,
+	criterion string,
+	out Outcome,
+) (Verdict, error) {
+	messages := []message.Message{
+		message.NewSystemMessage(judgePrompt),
+		message.NewUserMessage(fmt.Sprintf(
+			"Criterion: %s\n\nUser input:\n%s\n\nResponse:\n%s",
+			criterion,
+			out.Input,
+			out.Response.Content,
+		)),
+	}
+
+	var resp *llm.Response
+	var err error
+	if client.SupportsStructuredOutput() {
+		resp, err = client.SendMessagesWithStructuredOutput(
+			ctx,
+			messages,
+			nil,
+			verdictSchema,
+		)
+	} else {
+		resp, err = client.SendMessages(ctx, messages, nil)
+	}
+	if err != nil {
+		return Verdict{}, fmt.Errorf("judge: %w", err)
+	}
+
+	raw := resp.Content
+	if resp.StructuredOutput != nil {
+		raw = *resp.StructuredOutput
+	}
+	var verdict Verdict
+	if err := schema.Parse(raw, &verdict, schema.WithAutoRepair()); err != nil {
+		return Verdict{}, fmt.Errorf("judge: parse verdict %q: %w", raw, err)
+	}
+	return verdict, nil
+}
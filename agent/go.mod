@@ -6,7 +6,11 @@ require (
 	github.com/joakimcarlsson/ai/llm v0.5.0
 	github.com/joakimcarlsson/ai/memory v0.2.5
 	github.com/joakimcarlsson/ai/message v0.4.0
+	github.com/joakimcarlsson/ai/metrics v0.1.0
+	github.com/joakimcarlsson/ai/moderation v0.0.0-00010101000000-000000000000
 	github.com/joakimcarlsson/ai/prompt v0.1.0
+	github.com/joakimcarlsson/ai/rerankers v0.2.1
+	github.com/joakimcarlsson/ai/schema v0.2.0
 	github.com/joakimcarlsson/ai/session v0.1.3
 	github.com/joakimcarlsson/ai/tokens v0.2.4
 	github.com/joakimcarlsson/ai/tool v0.1.2
@@ -24,7 +28,6 @@ require (
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
 	github.com/joakimcarlsson/ai/embeddings v0.2.3 // indirect
 	github.com/joakimcarlsson/ai/model v0.6.0 // indirect
-	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
 	github.com/modelcontextprotocol/go-sdk v1.6.1 // indirect
 	github.com/segmentio/asm v1.2.1 // indirect
 	github.com/segmentio/encoding v0.5.4 // indirect
@@ -57,8 +60,11 @@ replace (
 	github.com/joakimcarlsson/ai/llm => ../llm
 	github.com/joakimcarlsson/ai/memory => ../memory
 	github.com/joakimcarlsson/ai/message => ../message
+	github.com/joakimcarlsson/ai/metrics => ../metrics
+	github.com/joakimcarlsson/ai/moderation => ../moderation
 	github.com/joakimcarlsson/ai/model => ../model
 	github.com/joakimcarlsson/ai/prompt => ../prompt
+	github.com/joakimcarlsson/ai/rerankers => ../rerankers
 	github.com/joakimcarlsson/ai/schema => ../schema
 	github.com/joakimcarlsson/ai/session => ../session
 	github.com/joakimcarlsson/ai/tokens => ../tokens
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/tool"
@@ -84,13 +85,21 @@ func isHandoffTool(name string, handoffs []HandoffConfig) *HandoffConfig {
 
 func rebuildMessagesForHandoff(
 	ctx context.Context,
+	oldAgent *Agent,
 	newAgent *Agent,
 	messages []message.Message,
 ) ([]message.Message, error) {
+	messages = oldAgent.removeExamples(messages)
+
 	systemPrompt, err := newAgent.resolveSystemPrompt(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve system prompt: %w", err)
 	}
+	dynamic, err := newAgent.dynamicContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt = strings.TrimLeft(systemPrompt+dynamic, "\n")
 
 	var rebuilt []message.Message
 
@@ -106,7 +115,7 @@ func rebuildMessagesForHandoff(
 		}
 	}
 
-	return rebuilt, nil
+	return newAgent.insertExamples(rebuilt), nil
 }
 
 func findAgentName(root *Agent, active *Agent) string {
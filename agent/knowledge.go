@@ -0,0 +1,328 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/rerankers"
+	"github.com/joakimcarlsson/ai/tokens"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// DefaultKnowledgeCorpus is the owner ID searched by [WithKnowledgeBase] when
+// [KnowledgeCorpus] is not set. Store documents under this ID.
+const DefaultKnowledgeCorpus = "knowledge"
+
+const (
+	defaultKnowledgeTopK       = 5
+	defaultKnowledgeCandidates = 20
+)
+
+// KnowledgeOption configures [WithKnowledgeBase].
+type KnowledgeOption func(*knowledgeConfig)
+
+type knowledgeConfig struct {
+	corpusID     string
+	reranker     rerankers.Reranker
+	topK         int
+	candidates   int
+	minScore     float64
+	maxTokens    int
+	autoRetrieve bool
+}
+
+// KnowledgeCorpus sets the owner ID the corpus is stored under.
+// Defaults to [DefaultKnowledgeCorpus].
+func KnowledgeCorpus(id string) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.corpusID = id }
+}
+
+// KnowledgeReranker reranks search candidates with r before applying the
+// relevance threshold and top-k limit. Scores then come from the reranker.
+func KnowledgeReranker(r rerankers.Reranker) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.reranker = r }
+}
+
+// KnowledgeTopK sets the maximum number of chunks returned per search.
+// Defaults to 5.
+func KnowledgeTopK(n int) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.topK = n }
+}
+
+// KnowledgeCandidates sets how many chunks are fetched from the store before
+// reranking. Only used with [KnowledgeReranker]. Defaults to 20.
+func KnowledgeCandidates(n int) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.candidates = n }
+}
+
+// KnowledgeMinScore drops chunks scoring below threshold.
+func KnowledgeMinScore(threshold float64) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.minScore = threshold }
+}
+
+// KnowledgeMaxTokens caps the total tokens of chunks returned per search.
+// Chunks are added in relevance order until the next one would exceed the
+// budget. Zero means no cap.
+func KnowledgeMaxTokens(n int) KnowledgeOption {
+	return func(c *knowledgeConfig) { c.maxTokens = n }
+}
+
+// KnowledgeAutoRetrieve searches the corpus with each user message and
+// injects the results into the system prompt, instead of exposing the
+// search_knowledge tool to the LLM. The injected entries are reported in
+// [ChatResponse.Sources].
+func KnowledgeAutoRetrieve() KnowledgeOption {
+	return func(c *knowledgeConfig) { c.autoRetrieve = true }
+}
+
+// WithKnowledgeBase gives the agent read access to a shared document corpus
+// held in store, separate from per-user memory. By default the LLM gets a
+// search_knowledge tool; with [KnowledgeAutoRetrieve] relevant chunks are
+// injected into the system prompt before each turn instead.
+//
+// Documents are ordinary memory entries stored under the corpus ID:
+//
+//	for _, chunk := range chunks {
+//		store.Store(ctx, agent.DefaultKnowledgeCorpus, chunk.Text,
+//			map[string]any{"source": chunk.Source})
+//	}
+//
+//	agent.New(client,
+//		agent.WithKnowledgeBase(store,
+//			agent.KnowledgeReranker(reranker),
+//			agent.KnowledgeMinScore(0.3),
+//			agent.KnowledgeMaxTokens(2000),
+//		),
+//	)
+func WithKnowledgeBase(
+	store memory.Store,
+	opts ...KnowledgeOption,
+) Option {
+	return func(a *Agent) {
+		if store == nil {
+			return
+		}
+		config := knowledgeConfig{
+			corpusID:   DefaultKnowledgeCorpus,
+			topK:       defaultKnowledgeTopK,
+			candidates: defaultKnowledgeCandidates,
+		}
+		for _, opt := range opts {
+			opt(&config)
+		}
+		a.knowledge = &knowledgeBase{store: store, config: config}
+	}
+}
+
+// WithAutoRetrieve searches store with every user message and prepends the k
+// most relevant entries to the system prompt, the always-on RAG pattern. It is
+// shorthand for [WithKnowledgeBase] with [KnowledgeTopK] and
+// [KnowledgeAutoRetrieve], and accepts the same options: use
+// [KnowledgeMinScore] to inject only sufficiently similar entries. The
+// injected entries are returned in [ChatResponse.Sources] for citation.
+//
+//	agent.New(client,
+//		agent.WithAutoRetrieve(store, 3, agent.KnowledgeMinScore(0.75)),
+//	)
+func WithAutoRetrieve(
+	store memory.Store,
+	k int,
+	opts ...KnowledgeOption,
+) Option {
+	return WithKnowledgeBase(
+		store,
+		append(
+			[]KnowledgeOption{KnowledgeTopK(k), KnowledgeAutoRetrieve()},
+			opts...,
+		)...,
+	)
+}
+
+type knowledgeBase struct {
+	store  memory.Store
+	config knowledgeConfig
+
+	tokenizerOnce sync.Once
+	tokenizer     *tokens.BPETokenizer
+	tokenizerErr  error
+}
+
+func (kb *knowledgeBase) retrieve(
+	ctx context.Context,
+	query string,
+) ([]memory.Entry, error) {
+	limit := kb.config.topK
+	if kb.config.reranker != nil && kb.config.candidates > limit {
+		limit = kb.config.candidates
+	}
+
+	entries, err := kb.store.Search(ctx, kb.config.corpusID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search knowledge base: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	if kb.config.reranker != nil {
+		entries, err = kb.rerank(ctx, query, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var results []memory.Entry
+	for _, entry := range entries {
+		if kb.config.minScore > 0 && entry.Score < kb.config.minScore {
+			continue
+		}
+		results = append(results, entry)
+		if kb.config.topK > 0 && len(results) >= kb.config.topK {
+			break
+		}
+	}
+
+	return kb.fitBudget(results)
+}
+
+func (kb *knowledgeBase) rerank(
+	ctx context.Context,
+	query string,
+	entries []memory.Entry,
+) ([]memory.Entry, error) {
+	docs := make([]string, len(entries))
+	for i, entry := range entries {
+		docs[i] = entry.Content
+	}
+
+	resp, err := kb.config.reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank knowledge: %w", err)
+	}
+
+	reranked := make([]memory.Entry, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		if result.Index < 0 || result.Index >= len(entries) {
+			continue
+		}
+		entry := entries[result.Index]
+		entry.Score = result.RelevanceScore
+		reranked = append(reranked, entry)
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	return reranked, nil
+}
+
+func (kb *knowledgeBase) fitBudget(
+	entries []memory.Entry,
+) ([]memory.Entry, error) {
+	if kb.config.maxTokens <= 0 || len(entries) == 0 {
+		return entries, nil
+	}
+
+	kb.tokenizerOnce.Do(func() {
+		kb.tokenizer, kb.tokenizerErr = tokens.NewBPETokenizer()
+	})
+	if kb.tokenizerErr != nil {
+		return nil, fmt.Errorf(
+			"failed to create tokenizer: %w",
+			kb.tokenizerErr,
+		)
+	}
+
+	used := 0
+	for i, entry := range entries {
+		used += kb.tokenizer.Count(formatKnowledgeEntry(entry))
+		if used > kb.config.maxTokens {
+			return entries[:i], nil
+		}
+	}
+	return entries, nil
+}
+
+func formatKnowledgeEntry(entry memory.Entry) string {
+	if source, ok := entry.Metadata["source"].(string); ok && source != "" {
+		return fmt.Sprintf("- [%s] %s", source, entry.Content)
+	}
+	return "- " + entry.Content
+}
+
+func formatKnowledge(entries []memory.Entry) string {
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = formatKnowledgeEntry(entry)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *Agent) knowledgeContext(
+	ctx context.Context,
+	query string,
+) (string, []memory.Entry) {
+	if a.knowledge == nil || !a.knowledge.config.autoRetrieve {
+		return "", nil
+	}
+
+	entries, err := a.knowledge.retrieve(ctx, query)
+	if err != nil {
+		a.logger.WarnContext(ctx, "knowledge retrieval failed",
+			slog.String("error", err.Error()),
+		)
+		return "", nil
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	return "\n\nRelevant knowledge base excerpts:\n" +
+		formatKnowledge(entries), entries
+}
+
+type searchKnowledgeTool struct {
+	kb *knowledgeBase
+}
+
+func (t *searchKnowledgeTool) Info() tool.Info {
+	return tool.Info{
+		Name:        "search_knowledge",
+		Description: "Search the shared knowledge base for documents relevant to a query. Use before answering questions about topics the documentation or reference material may cover.",
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "What to search for in the knowledge base",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t *searchKnowledgeTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+
+	entries, err := t.kb.retrieve(ctx, input.Query)
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+	if len(entries) == 0 {
+		return tool.NewTextResponse("No relevant documents found"), nil
+	}
+
+	return tool.NewTextResponse(formatKnowledge(entries)), nil
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ErrNoPendingToolCalls is returned by [Agent.SubmitToolResults] when the
+// session is not paused on tool calls.
+var ErrNoPendingToolCalls = errors.New("agent: no pending tool calls")
+
+// ToolResultMismatchError is returned by [Agent.SubmitToolResults] when the
+// submitted results do not answer exactly the pending tool calls. Nothing is
+// written to the session.
+type ToolResultMismatchError struct {
+	// Missing lists pending tool call IDs that have no result.
+	Missing []string
+	// Unknown lists result IDs that match no pending tool call.
+	Unknown []string
+	// Duplicate lists tool call IDs answered more than once.
+	Duplicate []string
+}
+
+func (e *ToolResultMismatchError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Duplicate) > 0 {
+		parts = append(parts, "duplicate "+strings.Join(e.Duplicate, ", "))
+	}
+	return "agent: tool results do not match pending tool calls: " +
+		strings.Join(parts, "; ")
+}
+
+// WithManualToolExecution makes Chat and ChatStream return as soon as the
+// model requests tools instead of running them, so the caller can execute
+// them elsewhere, such as in another process or after human review. It is
+// shorthand for WithAutoExecute(false).
+//
+// The pause/resume contract:
+//
+//   - The run ends with the requested calls in [ChatResponse.ToolCalls] and
+//     FinishReason tool_use. The assistant message carrying the calls is
+//     stored in the session, which must be configured with [WithSession].
+//   - Any agent built on the same session store and ID, in this process or
+//     another, can read the calls back with [Agent.PendingToolCalls].
+//   - The caller answers every pending call in one [Agent.SubmitToolResults]
+//     or [Agent.SubmitToolResultsStream] call, which stores the results and
+//     resumes the loop. A result with IsError set tells the model the call
+//     failed.
+//   - Until the results are submitted the session is paused: starting a new
+//     Chat leaves the calls unanswered, which most providers reject.
+func WithManualToolExecution() Option {
+	return WithAutoExecute(false)
+}
+
+// PendingToolCalls returns the tool calls the session is paused on: those in
+// the last assistant message that have no result yet. It returns nil when
+// the last turn requested no tools or all of them have been answered.
+func (a *Agent) PendingToolCalls(
+	ctx context.Context,
+) ([]message.ToolCall, error) {
+	if a.session == nil {
+		return nil, fmt.Errorf(
+			"agent: PendingToolCalls requires a session",
+		)
+	}
+	msgs, err := a.session.GetMessages(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	answered := make(map[string]bool)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		switch msg.Role {
+		case message.Tool:
+			for _, r := range msg.ToolResults() {
+				answered[r.ToolCallID] = true
+			}
+			continue
+		case message.Assistant:
+			var pending []message.ToolCall
+			for _, tc := range msg.ToolCalls() {
+				if !answered[tc.ID] {
+					pending = append(pending, tc)
+				}
+			}
+			return pending, nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// SubmitToolResults answers the pending tool calls and resumes the agent
+// loop, as [Agent.Continue] does, after checking the results against
+// [Agent.PendingToolCalls]. It returns [ErrNoPendingToolCalls] when there is
+// nothing to answer and a [*ToolResultMismatchError] unless every pending
+// call has exactly one result. Results without a Name take the name of
+// their call. See [WithManualToolExecution] for the full contract.
+func (a *Agent) SubmitToolResults(
+	ctx context.Context,
+	results []message.ToolResult,
+	opts ...ChatOption,
+) (*ChatResponse, error) {
+	results, err := a.matchPendingToolCalls(ctx, results)
+	if err != nil {
+		return nil, err
+	}
+	return a.Continue(ctx, results, opts...)
+}
+
+// SubmitToolResultsStream is the streaming variant of
+// [Agent.SubmitToolResults]. Validation errors are sent as a single
+// [types.EventError] event.
+func (a *Agent) SubmitToolResultsStream(
+	ctx context.Context,
+	results []message.ToolResult,
+	opts ...ChatOption,
+) <-chan ChatEvent {
+	results, err := a.matchPendingToolCalls(ctx, results)
+	if err != nil {
+		eventChan := make(chan ChatEvent, 1)
+		eventChan <- ChatEvent{Type: types.EventError, Error: err}
+		close(eventChan)
+		return eventChan
+	}
+	return a.ContinueStream(ctx, results, opts...)
+}
+
+func (a *Agent) matchPendingToolCalls(
+	ctx context.Context,
+	results []message.ToolResult,
+) ([]message.ToolResult, error) {
+	pending, err := a.PendingToolCalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, ErrNoPendingToolCalls
+	}
+
+	names := make(map[string]string, len(pending))
+	for _, tc := range pending {
+		names[tc.ID] = tc.Name
+	}
+	var mismatch ToolResultMismatchError
+	seen := make(map[string]bool, len(results))
+	matched := make([]message.ToolResult, 0, len(results))
+	for _, r := range results {
+		name, ok := names[r.ToolCallID]
+		switch {
+		case !ok:
+			mismatch.Unknown = append(mismatch.Unknown, r.ToolCallID)
+		case seen[r.ToolCallID]:
+			mismatch.Duplicate = append(mismatch.Duplicate, r.ToolCallID)
+		default:
+			seen[r.ToolCallID] = true
+			if r.Name == "" {
+				r.Name = name
+			}
+			matched = append(matched, r)
+		}
+	}
+	for _, tc := range pending {
+		if !seen[tc.ID] && !slices.Contains(mismatch.Duplicate, tc.ID) {
+			mismatch.Missing = append(mismatch.Missing, tc.ID)
+		}
+	}
+	if len(mismatch.Missing) > 0 || len(mismatch.Unknown) > 0 ||
+		len(mismatch.Duplicate) > 0 {
+		return nil, &mismatch
+	}
+	return matched, nil
+}
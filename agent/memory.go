@@ -2,63 +2,252 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/joakimcarlsson/ai/memory"
 )
 
-func (a *Agent) extractAndStoreMemories(ctx context.Context) error {
-	if a.memory == nil || !a.autoExtract || a.memoryID == "" ||
-		a.session == nil {
+// ErrSharedSessionMemory is returned when a request names a user, with
+// [WithUserID] or [WithCallUserID], to an agent that keeps memory per user
+// but has one session for every request. Memories extracted from that
+// session would mix every user's turns, so give [WithMemory] the user's ID
+// instead.
+var ErrSharedSessionMemory = errors.New(
+	"agent: per-user memory cannot share the agent's session",
+)
+
+// memoryOwner returns the ID memories are kept under for a request: the id
+// given to [WithMemory], or the user ID set with [WithUserID] on ctx when that
+// id is empty and the agent has no session.
+func (a *Agent) memoryOwner(ctx context.Context) string {
+	if a.memoryID != "" {
+		return a.memoryID
+	}
+	if a.session != nil {
+		return ""
+	}
+	id, _ := UserIDFromContext(ctx)
+	return id
+}
+
+// checkMemoryOwner returns [ErrSharedSessionMemory] when ctx names a user
+// whose memory would be built from the agent's shared session.
+func (a *Agent) checkMemoryOwner(ctx context.Context) error {
+	if a.memory == nil || a.memoryID != "" || a.session == nil {
+		return nil
+	}
+	if _, ok := UserIDFromContext(ctx); ok {
+		return ErrSharedSessionMemory
+	}
+	return nil
+}
+
+// ExtractMemories extracts facts from the agent's session and stores them in
+// its memory right away, without waiting for the interval set with
+// [memory.WithExtractInterval]. Call it when a session ends so the turns
+// since the last extraction are not lost. It does nothing when no turns have
+// completed since then, or when the agent has no memory or session.
+func (a *Agent) ExtractMemories(ctx context.Context) error {
+	owner := a.memoryOwner(ctx)
+	if owner == "" {
+		return nil
+	}
+	a.extractMu.Lock()
+	pending := a.extractPending
+	a.extractPending = 0
+	a.extractMu.Unlock()
+	if pending == 0 {
+		return nil
+	}
+	return a.extractAndStoreMemories(ctx, owner)
+}
+
+// scheduleExtraction counts a completed turn and starts extraction in the
+// background once the extract interval is reached, or straight away when ctx
+// was returned by [memory.ExtractNow]. A regenerated or edited turn is only
+// counted once its history is saved, so extraction never reads the session
+// while it still holds the discarded turn.
+func (a *Agent) scheduleExtraction(ctx context.Context) {
+	if rw := rewindFromContext(ctx); rw != nil && rw.agent == a {
+		rw.extractCtx = ctx
+		return
+	}
+	owner := a.memoryOwner(ctx)
+	if a.memory == nil || owner == "" || a.session == nil {
+		return
+	}
+	a.extractMu.Lock()
+	a.extractPending++
+	due := a.autoExtract && (a.extractPending >= a.extractInterval ||
+		memory.ExtractNowFromContext(ctx))
+	if due {
+		a.extractPending = 0
+	}
+	a.extractMu.Unlock()
+	if due {
+		a.extractInBackground(owner)
+	}
+}
+
+// extractInBackground runs extraction in a goroutine with its own context,
+// so it outlives the turn, and tracks it for [Agent.FlushMemories].
+func (a *Agent) extractInBackground(owner string) {
+	done := make(chan struct{})
+	a.extractMu.Lock()
+	a.extractRunning = append(a.extractRunning, done)
+	a.extractMu.Unlock()
+	go func() {
+		defer func() {
+			a.extractMu.Lock()
+			a.extractRunning = slices.DeleteFunc(
+				a.extractRunning,
+				func(c chan struct{}) bool { return c == done },
+			)
+			a.extractMu.Unlock()
+			close(done)
+		}()
+		_ = a.extractAndStoreMemories(context.Background(), owner)
+	}()
+}
+
+// FlushMemories waits for background extractions to finish and then
+// extracts any turns still pending under [memory.WithExtractInterval], so
+// no facts are lost when the application shuts down. It returns early with
+// the context's error if ctx is done first.
+//
+//	defer func() {
+//		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//		defer cancel()
+//		_ = a.FlushMemories(ctx)
+//	}()
+func (a *Agent) FlushMemories(ctx context.Context) error {
+	a.extractMu.Lock()
+	running := slices.Clone(a.extractRunning)
+	a.extractMu.Unlock()
+	for _, done := range running {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return a.ExtractMemories(ctx)
+}
+
+func (a *Agent) extractAndStoreMemories(
+	ctx context.Context,
+	owner string,
+) error {
+	if a.memory == nil || owner == "" || a.session == nil {
 		return nil
 	}
 
+	start := time.Now()
+
 	messages, err := a.session.GetMessages(ctx, nil)
 	if err != nil {
+		a.logger.WarnContext(ctx, "memory extraction failed",
+			slog.String("memory_id", owner),
+			slog.String("error", err.Error()),
+		)
 		return err
 	}
 
 	facts, err := memory.ExtractFacts(ctx, a.getMemoryLLM(), messages)
 	if err != nil {
+		a.logger.WarnContext(ctx, "memory extraction failed",
+			slog.String("memory_id", owner),
+			slog.String("error", err.Error()),
+		)
 		return err
 	}
 
+	stored := 0
 	for _, fact := range facts {
 		metadata := map[string]any{
 			"source":     "auto_extract",
 			"created_at": time.Now().Format(time.RFC3339),
 		}
-		var storeErr error
-		if a.autoDedup {
-			storeErr = a.storeWithDedup(ctx, fact, metadata)
-		} else {
-			storeErr = a.memory.Store(ctx, a.memoryID, fact, metadata)
+		var resolution *memory.Resolution
+		if a.conflictResolution {
+			res, err := memory.ResolveConflicts(
+				ctx,
+				a.memory,
+				a.getMemoryLLM(),
+				owner,
+				fact,
+				metadata,
+			)
+			if err != nil {
+				a.logger.WarnContext(ctx, "memory conflict resolution failed",
+					slog.String("memory_id", owner),
+					slog.String("error", err.Error()),
+				)
+			}
+			resolution = res
+			metadata = res.Metadata
+		}
+		kept, handled, err := a.storeWithDedup(ctx, owner, fact, metadata)
+		if err != nil {
+			a.logger.WarnContext(ctx, "failed to store memory",
+				slog.String("memory_id", owner),
+				slog.String("error", err.Error()),
+			)
+			continue
 		}
-		if storeErr != nil {
+		stored++
+		if resolution == nil || !kept {
 			continue
 		}
+		if err := resolution.Apply(ctx, a.memory, handled...); err != nil {
+			a.logger.WarnContext(ctx, "failed to delete superseded memories",
+				slog.String("memory_id", owner),
+				slog.String("error", err.Error()),
+			)
+		}
 	}
 
+	a.logger.DebugContext(ctx, "memories extracted",
+		slog.String("memory_id", owner),
+		slog.Int("facts", len(facts)),
+		slog.Int("stored", stored),
+		slog.Duration("duration", time.Since(start)),
+	)
+
 	return nil
 }
 
+// storeWithDedup stores fact, first deduplicating it against the nearest
+// memories when [memory.AutoDedup] is set. It reports whether the fact was
+// kept, by a new memory or an update, and the IDs of the memories the
+// deduplication updated or deleted.
 func (a *Agent) storeWithDedup(
 	ctx context.Context,
+	owner string,
 	fact string,
 	metadata map[string]any,
-) error {
-	if !a.autoDedup || a.memory == nil || a.memoryID == "" {
-		return a.memory.Store(ctx, a.memoryID, fact, metadata)
+) (kept bool, handled []string, err error) {
+	if !a.autoDedup {
+		return true, nil, a.memory.Store(ctx, owner, fact, metadata)
 	}
 
-	existing, err := a.memory.Search(ctx, a.memoryID, fact, 5)
+	existing, err := a.memory.Search(ctx, owner, fact, 5)
 	if err != nil {
-		return a.memory.Store(ctx, a.memoryID, fact, metadata)
+		return true, nil, a.memory.Store(ctx, owner, fact, metadata)
 	}
 
-	result, err := memory.Deduplicate(ctx, a.getMemoryLLM(), fact, existing)
+	result, err := memory.DeduplicateWith(
+		ctx,
+		a.getMemoryLLM(),
+		fact,
+		existing,
+		a.dedup,
+	)
 	if err != nil {
-		return a.memory.Store(ctx, a.memoryID, fact, metadata)
+		return true, nil, a.memory.Store(ctx, owner, fact, metadata)
 	}
 
 	for _, decision := range result.Decisions {
@@ -66,12 +255,13 @@ func (a *Agent) storeWithDedup(
 		case memory.DedupEventAdd:
 			if err := a.memory.Store(
 				ctx,
-				a.memoryID,
+				owner,
 				decision.Text,
 				metadata,
 			); err != nil {
-				return err
+				return false, nil, err
 			}
+			kept = true
 		case memory.DedupEventUpdate:
 			if err := a.memory.Update(
 				ctx,
@@ -79,15 +269,18 @@ func (a *Agent) storeWithDedup(
 				decision.Text,
 				metadata,
 			); err != nil {
-				return err
+				return false, nil, err
 			}
+			kept = true
+			handled = append(handled, decision.MemoryID)
 		case memory.DedupEventDelete:
 			if err := a.memory.Delete(ctx, decision.MemoryID); err != nil {
-				return err
+				return false, nil, err
 			}
+			handled = append(handled, decision.MemoryID)
 		case memory.DedupEventNone:
 		}
 	}
 
-	return nil
+	return kept, handled, nil
 }
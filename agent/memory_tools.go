@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"slices"
+
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// Memory tool names accepted by [MemoryToolSelection] and
+// [MemoryToolDescription].
+const (
+	MemoryToolStore   = "store_memory"
+	MemoryToolRecall  = "recall_memories"
+	MemoryToolReplace = "replace_memory"
+	MemoryToolDelete  = "delete_memory"
+	MemoryToolList    = "list_memories"
+	MemoryToolCount   = "count_memories"
+	MemoryToolSearch  = "search_memories"
+)
+
+// MemoryToolOption configures [WithMemoryTools].
+type MemoryToolOption func(*memoryToolsConfig)
+
+type memoryToolsConfig struct {
+	names        []string
+	descriptions map[string]string
+}
+
+// MemoryToolSelection exposes only the named memory tools. Without it, all
+// seven tools are exposed.
+func MemoryToolSelection(names ...string) MemoryToolOption {
+	return func(c *memoryToolsConfig) { c.names = names }
+}
+
+// MemoryToolDescription replaces the description the LLM sees for the named
+// memory tool. Descriptions drive when the model reaches for a tool, so tune
+// them to your application's vocabulary.
+func MemoryToolDescription(name, description string) MemoryToolOption {
+	return func(c *memoryToolsConfig) {
+		if c.descriptions == nil {
+			c.descriptions = make(map[string]string)
+		}
+		c.descriptions[name] = description
+	}
+}
+
+// WithMemoryTools chooses the memory tools given to the LLM, replacing the
+// four store, recall, replace, and delete tools [WithMemory] adds when
+// auto-extraction is off. Besides those four, list_memories,
+// count_memories, and search_memories let the model inspect memory with
+// metadata filters; see [memory.QueryTools]. The selected tools are exposed
+// even with [memory.AutoExtract], and only when [WithMemory] is also set.
+//
+//	agent.New(client,
+//		agent.WithMemory("user-123", store, memory.AutoExtract()),
+//		agent.WithMemoryTools(
+//			agent.MemoryToolSelection(
+//				agent.MemoryToolList,
+//				agent.MemoryToolSearch,
+//			),
+//			agent.MemoryToolDescription(
+//				agent.MemoryToolSearch,
+//				"Look up what the customer told us before, by topic.",
+//			),
+//		),
+//	)
+func WithMemoryTools(opts ...MemoryToolOption) Option {
+	return func(a *Agent) {
+		config := &memoryToolsConfig{}
+		for _, opt := range opts {
+			opt(config)
+		}
+		a.memoryTools = config
+	}
+}
+
+func (a *Agent) memoryToolList(ctx context.Context) []tool.BaseTool {
+	owner := a.memoryOwner(ctx)
+	if a.memory == nil || owner == "" {
+		return nil
+	}
+	if a.memoryTools == nil {
+		if a.autoExtract {
+			return nil
+		}
+		return memory.Tools(a.memory, owner)
+	}
+
+	all := append(
+		memory.Tools(a.memory, owner),
+		memory.QueryTools(a.memory, owner)...,
+	)
+	var selected []tool.BaseTool
+	for _, t := range all {
+		name := t.Info().Name
+		if a.memoryTools.names != nil &&
+			!slices.Contains(a.memoryTools.names, name) {
+			continue
+		}
+		if desc, ok := a.memoryTools.descriptions[name]; ok {
+			t = &describedTool{BaseTool: t, description: desc}
+		}
+		selected = append(selected, t)
+	}
+	return selected
+}
+
+type describedTool struct {
+	tool.BaseTool
+	description string
+}
+
+func (t *describedTool) Info() tool.Info {
+	info := t.BaseTool.Info()
+	info.Description = t.description
+	return info
+}
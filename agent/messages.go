@@ -3,7 +3,10 @@ package agent
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
 
+	"github.com/joakimcarlsson/ai/memory"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/prompt"
 	"github.com/joakimcarlsson/ai/tokens"
@@ -16,7 +19,8 @@ func (a *Agent) BuildContextMessages(
 	ctx context.Context,
 	userMessage string,
 ) ([]message.Message, error) {
-	return a.buildMessages(ctx, userMessage)
+	messages, _, err := a.buildMessages(ctx, userMessage)
+	return messages, err
 }
 
 // PeekContextMessages returns what messages would be sent to the LLM without modifying state.
@@ -31,12 +35,16 @@ func (a *Agent) PeekContextMessages(
 		return nil, fmt.Errorf("failed to resolve system prompt: %w", err)
 	}
 
-	if systemPrompt != "" {
-		sysMsg := message.NewSystemMessage(systemPrompt)
-		sysMsg.Model = a.llm.Model().ID
-		messages = append(messages, sysMsg)
+	turnContext, err := a.dynamicContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	messages = append(
+		messages,
+		a.systemMessages(systemPrompt, turnContext, false)...,
+	)
+
 	if a.session != nil {
 		sessionMessages, err := a.session.GetMessages(ctx, nil)
 		if err != nil {
@@ -50,23 +58,19 @@ func (a *Agent) PeekContextMessages(
 	messages = append(messages, userMsg)
 
 	if a.contextStrategy != nil {
-		counter, err := tokens.NewCounter()
+		counter, _, err := a.tokenCounter()
 		if err != nil {
 			return nil, err
 		}
 
-		maxTokens := a.maxContextTokens
-		if maxTokens == 0 {
-			reserveTokens := a.reserveTokens
-			if reserveTokens == 0 {
-				reserveTokens = 4096
-			}
-			maxTokens = a.llm.Model().ContextWindow - reserveTokens
+		maxTokens, err := a.contextBudget(ctx, counter)
+		if err != nil {
+			return nil, err
 		}
 
 		result, err := a.contextStrategy.Fit(ctx, tokens.StrategyInput{
 			Messages:     messages,
-			SystemPrompt: systemPrompt,
+			SystemPrompt: systemPrompt + turnContext,
 			Tools:        a.getToolsWithContext(ctx),
 			Counter:      counter,
 			MaxTokens:    maxTokens,
@@ -75,126 +79,194 @@ func (a *Agent) PeekContextMessages(
 			return nil, err
 		}
 
+		a.logContextFit(ctx, len(messages), len(result.Messages), maxTokens)
 		messages = result.Messages
 	}
 
-	return messages, nil
+	return a.insertExamples(a.dropOrphanedToolResults(ctx, messages)), nil
 }
 
 func (a *Agent) resolveSystemPrompt(ctx context.Context) (string, error) {
+	base, err := a.basePrompt(ctx)
+	if err != nil || len(a.promptLayers) == 0 {
+		return base, err
+	}
+
+	parts := make([]string, 0, len(a.promptLayers)+1)
+	if base != "" {
+		parts = append(parts, base)
+	}
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	for i, layer := range a.promptLayers {
+		rendered, err := prompt.Process(layer, a.state)
+		if err != nil {
+			return "", fmt.Errorf("system prompt layer %d: %w", i, err)
+		}
+		if rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+
+	separator := "\n\n"
+	if a.promptSeparator != nil {
+		separator = *a.promptSeparator
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// basePrompt renders the prompt the layers from [WithSystemPromptLayer] are
+// added to: the instruction provider's, or the one from [WithSystemPrompt].
+func (a *Agent) basePrompt(ctx context.Context) (string, error) {
 	if a.instructionProvider != nil {
-		return a.instructionProvider(ctx, a.state)
+		return a.instructionProvider(ctx, a.State())
 	}
 
 	if a.systemPrompt == "" {
 		return "", nil
 	}
 
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
 	return prompt.Process(a.systemPrompt, a.state)
 }
 
 func (a *Agent) buildMessages(
 	ctx context.Context,
 	userMessage string,
-) ([]message.Message, error) {
+) ([]message.Message, []memory.Entry, error) {
 	var messages []message.Message
 
 	systemPrompt, err := a.resolveSystemPrompt(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve system prompt: %w", err)
+		return nil, nil, fmt.Errorf("failed to resolve system prompt: %w", err)
 	}
 
-	if a.memory != nil && a.memoryID != "" {
-		memories, err := a.memory.Search(ctx, a.memoryID, userMessage, 5)
-		if err == nil && len(memories) > 0 {
-			var memoryContext string
-			for _, m := range memories {
-				memoryContext += "- " + m.Content + "\n"
-			}
-			systemPrompt = systemPrompt + "\n\nRelevant memories about this user:\n" + memoryContext
-		}
+	turnContext, err := a.dynamicContext(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	userMsg := message.NewUserMessage(userMessage)
-	userMsg.Model = a.llm.Model().ID
+	memories, err := a.memoryContext(ctx, userMessage)
+	if err != nil {
+		return nil, nil, err
+	}
+	turnContext += memories
+
+	knowledge, sources := a.knowledgeContext(ctx, userMessage)
+	turnContext += knowledge
+
+	userMsg := a.userMessage(ctx, userMessage)
 
+	sess := a.sessionFor(ctx)
 	var sessionMessages []message.Message
-	if a.session != nil {
+	if sess != nil {
 		var err error
-		sessionMessages, err = a.session.GetMessages(ctx, nil)
+		sessionMessages, err = sess.GetMessages(ctx, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	if systemPrompt != "" {
-		sysMsg := message.NewSystemMessage(systemPrompt)
-		sysMsg.Model = a.llm.Model().ID
-		messages = append(messages, sysMsg)
-	}
-
+	messages = append(
+		messages,
+		a.systemMessages(systemPrompt, turnContext, true)...,
+	)
 	messages = append(messages, sessionMessages...)
 	messages = append(messages, userMsg)
 
-	if a.session != nil {
-		if err := a.session.AddMessages(
+	if sess != nil {
+		if err := sess.AddMessages(
 			ctx,
 			[]message.Message{userMsg},
 		); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	if a.contextStrategy != nil {
-		counter, err := tokens.NewCounter()
+		counter, _, err := a.tokenCounter()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create token counter: %w", err)
+			return nil, nil, err
 		}
 
-		maxTokens := a.maxContextTokens
-		if maxTokens == 0 {
-			reserveTokens := a.reserveTokens
-			if reserveTokens == 0 {
-				reserveTokens = 4096
-			}
-			maxTokens = a.llm.Model().ContextWindow - reserveTokens
+		maxTokens, err := a.contextBudget(ctx, counter)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		result, err := a.contextStrategy.Fit(ctx, tokens.StrategyInput{
 			Messages:     messages,
-			SystemPrompt: systemPrompt,
+			SystemPrompt: systemPrompt + turnContext,
 			Tools:        a.getToolsWithContext(ctx),
 			Counter:      counter,
 			MaxTokens:    maxTokens,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("context strategy failed: %w", err)
+			return nil, nil, fmt.Errorf("context strategy failed: %w", err)
 		}
 
-		if result.SessionUpdate != nil && a.session != nil {
-			for range result.SessionUpdate.PopCount {
-				if _, err := a.session.PopMessage(ctx); err != nil {
-					return nil, fmt.Errorf("failed to pop message: %w", err)
-				}
-			}
-
-			if len(result.SessionUpdate.AddMessages) > 0 {
-				if err := a.session.AddMessages(
-					ctx,
-					result.SessionUpdate.AddMessages,
-				); err != nil {
-					return nil, fmt.Errorf(
-						"failed to save session update: %w",
-						err,
-					)
-				}
-			}
+		if err := a.applySessionUpdate(ctx, result.SessionUpdate); err != nil {
+			return nil, nil, err
 		}
 
+		a.logContextFit(ctx, len(messages), len(result.Messages), maxTokens)
 		messages = result.Messages
 	}
 
-	return messages, nil
+	messages = a.dropOrphanedToolResults(ctx, messages)
+	return a.insertExamples(messages), sources, nil
+}
+
+// dynamicContext returns the output of the provider set with
+// [WithDynamicInstructions], formatted to follow the system prompt.
+func (a *Agent) dynamicContext(ctx context.Context) (string, error) {
+	if a.dynamicInstructions == nil {
+		return "", nil
+	}
+	instructions, err := a.dynamicInstructions(ctx, a.State())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve dynamic instructions: %w", err)
+	}
+	if instructions == "" {
+		return "", nil
+	}
+	return "\n\n" + instructions, nil
+}
+
+// memoryContext returns the memories relevant to userMessage, formatted for
+// the system prompt. When the store fails the agent carries on without
+// memories, unless it was configured with [memory.WithFailClosed].
+func (a *Agent) memoryContext(
+	ctx context.Context,
+	userMessage string,
+) (string, error) {
+	if err := a.checkMemoryOwner(ctx); err != nil {
+		return "", err
+	}
+	owner := a.memoryOwner(ctx)
+	if a.memory == nil || owner == "" {
+		return "", nil
+	}
+	memories, err := a.memory.Search(ctx, owner, userMessage, 5)
+	if err != nil {
+		if a.memoryFailClosed {
+			return "", fmt.Errorf("failed to recall memories: %w", err)
+		}
+		a.logger.WarnContext(ctx, "memory recall failed, continuing without",
+			slog.String("memory_id", owner),
+			slog.String("error", err.Error()),
+		)
+		return "", nil
+	}
+	if len(memories) == 0 {
+		return "", nil
+	}
+	var memoryContext string
+	for _, m := range memories {
+		memoryContext += "- " + m.Content + "\n"
+	}
+	return "\n\nRelevant memories about this user:\n" + memoryContext, nil
 }
 
 func (a *Agent) buildContinueMessages(
@@ -208,39 +280,38 @@ func (a *Agent) buildContinueMessages(
 	}
 
 	var sessionMessages []message.Message
-	if a.session != nil {
-		sessionMessages, err = a.session.GetMessages(ctx, nil)
+	if sess := a.sessionFor(ctx); sess != nil {
+		sessionMessages, err = sess.GetMessages(ctx, nil)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if systemPrompt != "" {
-		sysMsg := message.NewSystemMessage(systemPrompt)
-		sysMsg.Model = a.llm.Model().ID
-		messages = append(messages, sysMsg)
+	turnContext, err := a.dynamicContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	messages = append(
+		messages,
+		a.systemMessages(systemPrompt, turnContext, true)...,
+	)
 	messages = append(messages, sessionMessages...)
 
 	if a.contextStrategy != nil {
-		counter, err := tokens.NewCounter()
+		counter, _, err := a.tokenCounter()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create token counter: %w", err)
+			return nil, err
 		}
 
-		maxTokens := a.maxContextTokens
-		if maxTokens == 0 {
-			reserveTokens := a.reserveTokens
-			if reserveTokens == 0 {
-				reserveTokens = 4096
-			}
-			maxTokens = a.llm.Model().ContextWindow - reserveTokens
+		maxTokens, err := a.contextBudget(ctx, counter)
+		if err != nil {
+			return nil, err
 		}
 
 		result, err := a.contextStrategy.Fit(ctx, tokens.StrategyInput{
 			Messages:     messages,
-			SystemPrompt: systemPrompt,
+			SystemPrompt: systemPrompt + turnContext,
 			Tools:        a.getToolsWithContext(ctx),
 			Counter:      counter,
 			MaxTokens:    maxTokens,
@@ -249,28 +320,108 @@ func (a *Agent) buildContinueMessages(
 			return nil, fmt.Errorf("context strategy failed: %w", err)
 		}
 
-		if result.SessionUpdate != nil && a.session != nil {
-			for range result.SessionUpdate.PopCount {
-				if _, err := a.session.PopMessage(ctx); err != nil {
-					return nil, fmt.Errorf("failed to pop message: %w", err)
-				}
-			}
-
-			if len(result.SessionUpdate.AddMessages) > 0 {
-				if err := a.session.AddMessages(
-					ctx,
-					result.SessionUpdate.AddMessages,
-				); err != nil {
-					return nil, fmt.Errorf(
-						"failed to save session update: %w",
-						err,
-					)
-				}
-			}
+		if err := a.applySessionUpdate(ctx, result.SessionUpdate); err != nil {
+			return nil, err
 		}
 
+		a.logContextFit(ctx, len(messages), len(result.Messages), maxTokens)
 		messages = result.Messages
 	}
 
-	return messages, nil
+	return a.insertExamples(a.dropOrphanedToolResults(ctx, messages)), nil
+}
+
+// applySessionUpdate persists the session changes a context strategy asked
+// for, such as replacing trimmed history with a summary.
+func (a *Agent) applySessionUpdate(
+	ctx context.Context,
+	update *tokens.SessionUpdate,
+) error {
+	sess := a.sessionFor(ctx)
+	if update == nil || sess == nil {
+		return nil
+	}
+	for range update.PopCount {
+		if _, err := sess.PopMessage(ctx); err != nil {
+			return fmt.Errorf("failed to pop message: %w", err)
+		}
+	}
+	if len(update.AddMessages) > 0 {
+		if err := sess.AddMessages(ctx, update.AddMessages); err != nil {
+			return fmt.Errorf("failed to save session update: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Agent) logContextFit(
+	ctx context.Context,
+	before, after int,
+	maxTokens int64,
+) {
+	if before == after {
+		return
+	}
+	a.logger.DebugContext(ctx, "context trimmed",
+		slog.Int("messages_before", before),
+		slog.Int("messages_after", after),
+		slog.Int64("max_tokens", maxTokens),
+	)
+}
+
+// contextBudget returns the token limit the context strategy fits the live
+// conversation into. Few-shot examples are sent on every call, so their
+// tokens are taken off the context limit.
+func (a *Agent) contextBudget(
+	ctx context.Context,
+	counter tokens.TokenCounter,
+) (int64, error) {
+	maxTokens := a.contextLimit()
+
+	if len(a.examples) == 0 {
+		return maxTokens, nil
+	}
+	count, err := counter.CountTokens(ctx, tokens.CountOptions{
+		Messages: a.examples,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count example tokens: %w", err)
+	}
+	return maxTokens - count.MessageTokens, nil
+}
+
+// insertExamples places the agent's few-shot examples after the leading
+// system messages and before the conversation.
+func (a *Agent) insertExamples(
+	messages []message.Message,
+) []message.Message {
+	if len(a.examples) == 0 {
+		return messages
+	}
+	i := 0
+	for i < len(messages) && messages[i].Role == message.System {
+		i++
+	}
+	out := make([]message.Message, 0, len(messages)+len(a.examples))
+	out = append(out, messages[:i]...)
+	out = append(out, a.examples...)
+	return append(out, messages[i:]...)
+}
+
+// removeExamples drops the agent's few-shot examples from messages built by
+// [Agent.insertExamples].
+func (a *Agent) removeExamples(
+	messages []message.Message,
+) []message.Message {
+	if len(a.examples) == 0 {
+		return messages
+	}
+	i := 0
+	for i < len(messages) && messages[i].Role == message.System {
+		i++
+	}
+	end := min(i+len(a.examples), len(messages))
+	out := make([]message.Message, 0, len(messages)-(end-i))
+	out = append(out, messages[:i]...)
+	return append(out, messages[end:]...)
 }
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/joakimcarlsson/ai/metrics"
+)
+
+func newMetricsHooks(collector metrics.Collector) Hooks {
+	return Hooks{
+		PostToolUse: func(_ context.Context, tc PostToolUseContext) (PostToolUseResult, error) {
+			status := metrics.StatusOK
+			if tc.IsError {
+				status = metrics.StatusError
+				collector.IncCounter(metrics.ToolErrors, 1, metrics.Labels{
+					metrics.LabelTool: tc.ToolName,
+				})
+			}
+			labels := metrics.Labels{
+				metrics.LabelTool:   tc.ToolName,
+				metrics.LabelStatus: status,
+			}
+			collector.IncCounter(metrics.ToolExecutions, 1, labels)
+			collector.ObserveHistogram(
+				metrics.ToolDuration,
+				tc.Duration.Seconds(),
+				labels,
+			)
+			return PostToolUseResult{Action: HookAllow}, nil
+		},
+		AfterRun: func(_ context.Context, rc RunContext) {
+			status := metrics.StatusOK
+			if rc.Error != nil {
+				status = metrics.StatusError
+			}
+			labels := metrics.Labels{
+				metrics.LabelAgent:  rc.AgentName,
+				metrics.LabelStatus: status,
+			}
+			collector.IncCounter(metrics.AgentRuns, 1, labels)
+			collector.ObserveHistogram(
+				metrics.AgentRunDuration,
+				rc.Duration.Seconds(),
+				labels,
+			)
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ChatFunc runs one agent turn, the signature of [Agent.Chat].
+type ChatFunc func(
+	ctx context.Context,
+	userMessage string,
+	opts ...ChatOption,
+) (*ChatResponse, error)
+
+// AgentMiddleware wraps a whole agent turn, including every model call and
+// tool loop, for cross-cutting concerns such as auth, logging, rate limiting,
+// and moderation. A middleware may change the context, message, or options
+// before calling next, inspect or replace the response after it, or return
+// without calling next to reject the turn.
+//
+// The same middleware wraps [Agent.ChatStream]. There, next runs the stream
+// and returns its final response once it completes, while intermediate
+// events such as content deltas and tool calls are forwarded to the caller
+// as they happen. The stream's closing complete or error event carries
+// whatever the middleware chain returns instead of what the agent produced.
+type AgentMiddleware func(next ChatFunc) ChatFunc
+
+// WithMiddleware adds middleware around Chat and ChatStream, and the methods
+// built on them such as Regenerate. Middleware runs in the order given,
+// across calls: the first registered is the outermost, so it sees the call
+// first and the result last. Hooks, rate limits from [WithUserRateLimit],
+// and everything else the agent does run inside the innermost middleware.
+func WithMiddleware(mw ...AgentMiddleware) Option {
+	return func(a *Agent) {
+		a.middleware = append(a.middleware, mw...)
+	}
+}
+
+func chainMiddleware(mw []AgentMiddleware, final ChatFunc) ChatFunc {
+	next := final
+	for i := len(mw) - 1; i >= 0; i-- {
+		next = mw[i](next)
+	}
+	return next
+}
+
+func (a *Agent) streamWithMiddleware(
+	ctx context.Context,
+	userMessage string,
+	opts []ChatOption,
+) <-chan ChatEvent {
+	ctx = withStoredResponse(ctx)
+	eventChan := make(chan ChatEvent)
+
+	go func() {
+		defer close(eventChan)
+
+		final := func(
+			ctx context.Context,
+			userMessage string,
+			opts ...ChatOption,
+		) (*ChatResponse, error) {
+			var resp *ChatResponse
+			var err error
+			for evt := range a.chatStream(ctx, userMessage, opts...) {
+				switch evt.Type {
+				case types.EventComplete:
+					resp, err = evt.Response, nil
+				case types.EventError:
+					resp, err = nil, evt.Error
+				default:
+					eventChan <- evt
+				}
+			}
+			if resp == nil && err == nil {
+				err = fmt.Errorf("agent: stream ended without a response")
+			}
+			return resp, err
+		}
+
+		resp, err := chainMiddleware(a.middleware, final)(
+			ctx,
+			userMessage,
+			opts...,
+		)
+		if err != nil {
+			eventChan <- ChatEvent{Type: types.EventError, Error: err}
+			return
+		}
+		eventChan <- ChatEvent{Type: types.EventComplete, Response: resp}
+	}()
+
+	return eventChan
+}
@@ -0,0 +1,268 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/moderation"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+// DefaultModerationRefusal is the response [WithModeration] returns for a
+// flagged turn when [ModerationRefusal] is not set.
+const DefaultModerationRefusal = "Sorry, I can't help with that."
+
+// ModerationFunc checks a piece of text and returns a non-nil error to
+// reject it, for example after calling a moderation API.
+type ModerationFunc func(ctx context.Context, text string) error
+
+// ModerationError is returned by Chat, or as the stream's error event, when
+// [ModerationMiddleware] rejects a turn.
+type ModerationError struct {
+	// Output is true when the agent's response was rejected and false when
+	// the user message was.
+	Output bool
+	// Err is the error returned by the [ModerationFunc].
+	Err error
+}
+
+func (e *ModerationError) Error() string {
+	what := "user message"
+	if e.Output {
+		what = "response"
+	}
+	return fmt.Sprintf("agent: %s rejected by moderation: %v", what, e.Err)
+}
+
+func (e *ModerationError) Unwrap() error { return e.Err }
+
+// ModerationMiddleware returns middleware that checks the user message with
+// check before the turn runs, rejecting it without an LLM call, and checks
+// the final response content afterwards, withholding the response if it is
+// rejected. Both rejections are returned as a [*ModerationError]. A rejected
+// response is also removed from the session, so it is not sent to the model
+// on the next turn.
+//
+// With ChatStream the response check runs once the stream completes, after
+// its content deltas have already been delivered, so a streaming UI should
+// discard the streamed text when the stream ends in a ModerationError.
+func ModerationMiddleware(check ModerationFunc) AgentMiddleware {
+	return moderate(check, true, nil)
+}
+
+// ModerationOption configures [WithModeration].
+type ModerationOption func(*moderationConfig)
+
+type moderationConfig struct {
+	refusal      string
+	screenOutput bool
+}
+
+// ModerationRefusal sets the response text returned in place of a flagged
+// turn. Defaults to [DefaultModerationRefusal].
+func ModerationRefusal(message string) ModerationOption {
+	return func(c *moderationConfig) { c.refusal = message }
+}
+
+// ModerationScreenOutput also checks the agent's final response, replacing
+// it with the refusal when flagged, both in the returned [ChatResponse] and
+// in the session. With ChatStream its content deltas have already been
+// delivered by then.
+func ModerationScreenOutput() ModerationOption {
+	return func(c *moderationConfig) { c.screenOutput = true }
+}
+
+// WithModeration screens each user message with moderator before the turn
+// runs. A flagged message is not sent to the LLM or added to the session;
+// the turn instead returns a [ChatResponse] whose Content is the refusal and
+// whose Moderation holds the flagged categories. A moderator error fails the
+// turn with a [*ModerationError]. It is [ModerationMiddleware] answering with
+// the refusal instead of an error, installed in order with [WithMiddleware].
+//
+//	agent.New(client,
+//		agent.WithModeration(
+//			moderationopenai.NewModerator(
+//				moderationopenai.WithAPIKey(key),
+//			),
+//			agent.ModerationRefusal("I can't discuss that here."),
+//			agent.ModerationScreenOutput(),
+//		),
+//	)
+func WithModeration(
+	moderator moderation.Moderator,
+	opts ...ModerationOption,
+) Option {
+	return func(a *Agent) {
+		if moderator == nil {
+			return
+		}
+		cfg := moderationConfig{refusal: DefaultModerationRefusal}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		a.middleware = append(a.middleware, moderate(
+			moderatorCheck(moderator),
+			cfg.screenOutput,
+			func(err *ModerationError) *ChatResponse {
+				var flagged *flaggedError
+				if !errors.As(err.Err, &flagged) {
+					return nil
+				}
+				return &ChatResponse{
+					Content:      cfg.refusal,
+					FinishReason: message.FinishReasonEndTurn,
+					Moderation:   flagged.result,
+				}
+			},
+		))
+	}
+}
+
+// flaggedError is the rejection of a [moderation.Moderator] check.
+type flaggedError struct {
+	result *moderation.Result
+}
+
+func (e *flaggedError) Error() string {
+	return "flagged as " + strings.Join(e.result.Categories, ", ")
+}
+
+func moderatorCheck(moderator moderation.Moderator) ModerationFunc {
+	return func(ctx context.Context, text string) error {
+		result, err := moderator.Moderate(ctx, text)
+		if err != nil {
+			return err
+		}
+		if result.Flagged {
+			return &flaggedError{result: result}
+		}
+		return nil
+	}
+}
+
+// moderate is the middleware behind [ModerationMiddleware] and
+// [WithModeration]. A rejection is returned as a *ModerationError unless
+// refuse turns it into the response to return instead. A rejected response
+// is withdrawn from the session, replaced by the refusal if there is one.
+func moderate(
+	check ModerationFunc,
+	screenOutput bool,
+	refuse func(*ModerationError) *ChatResponse,
+) AgentMiddleware {
+	rejected := func(err *ModerationError) (*ChatResponse, error) {
+		if refuse != nil {
+			if resp := refuse(err); resp != nil {
+				return resp, nil
+			}
+		}
+		return nil, err
+	}
+	return func(next ChatFunc) ChatFunc {
+		return func(
+			ctx context.Context,
+			userMessage string,
+			opts ...ChatOption,
+		) (*ChatResponse, error) {
+			if err := check(ctx, userMessage); err != nil {
+				return rejected(&ModerationError{Err: err})
+			}
+			resp, err := next(ctx, userMessage, opts...)
+			if err != nil || resp == nil || !screenOutput {
+				return resp, err
+			}
+			err = check(ctx, resp.Content)
+			if err == nil {
+				return resp, nil
+			}
+			refusal, modErr := rejected(
+				&ModerationError{Output: true, Err: err},
+			)
+			if err := withdrawResponse(ctx, refusal); err != nil {
+				return nil, fmt.Errorf(
+					"agent: withdraw moderated response: %w",
+					err,
+				)
+			}
+			if refusal == nil {
+				return nil, modErr
+			}
+			resp.Content = refusal.Content
+			resp.Reasoning = ""
+			resp.Moderation = refusal.Moderation
+			return resp, nil
+		}
+	}
+}
+
+type storedResponseKey struct{}
+
+// storedResponse records the session message holding the final response of
+// a turn, so moderation can withdraw it after the turn.
+type storedResponse struct {
+	mu      sync.Mutex
+	session session.Session
+	msg     message.Message
+}
+
+// withStoredResponse returns ctx carrying a record for the response the
+// turn run with it stores.
+func withStoredResponse(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storedResponseKey{}, &storedResponse{})
+}
+
+// recordStoredResponse notes that msg, the final response of the turn run
+// with ctx, was added to sess.
+func recordStoredResponse(
+	ctx context.Context,
+	sess session.Session,
+	msg message.Message,
+) {
+	rec, _ := ctx.Value(storedResponseKey{}).(*storedResponse)
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.session, rec.msg = sess, msg
+}
+
+// withdrawResponse removes the response stored by the turn run with ctx
+// from its session, adding refusal in its place when not nil. It does
+// nothing if the response is no longer the session's last message.
+func withdrawResponse(ctx context.Context, refusal *ChatResponse) error {
+	rec, _ := ctx.Value(storedResponseKey{}).(*storedResponse)
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	sess := rec.session
+	if sess == nil {
+		return nil
+	}
+
+	limit := 1
+	last, err := sess.GetMessages(ctx, &limit)
+	if err != nil {
+		return err
+	}
+	if len(last) != 1 || last[0].Role != message.Assistant ||
+		last[0].CreatedAt != rec.msg.CreatedAt {
+		return nil
+	}
+	if _, err := sess.PopMessage(ctx); err != nil {
+		return err
+	}
+	rec.session = nil
+	if refusal == nil {
+		return nil
+	}
+	msg := message.NewAssistantMessage()
+	msg.Model = rec.msg.Model
+	msg.AppendContent(refusal.Content)
+	return sess.AddMessages(ctx, []message.Message{msg})
+}
@@ -2,8 +2,12 @@ package agent
 
 import (
 	"context"
+	"log/slog"
 
+	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/metrics"
 	"github.com/joakimcarlsson/ai/session"
 	"github.com/joakimcarlsson/ai/tokens"
 	"github.com/joakimcarlsson/ai/tool"
@@ -13,12 +17,66 @@ import (
 type Option func(*Agent)
 
 // WithSystemPrompt sets the system prompt that defines the agent's behavior and personality.
+// It replaces any prompt set before it; use [WithSystemPromptLayer] to add to it.
 func WithSystemPrompt(prompt string) Option {
 	return func(a *Agent) {
 		a.systemPrompt = prompt
 	}
 }
 
+// WithSystemPromptLayer appends a layer to the system prompt, such as a team
+// policy or a user's preferences on top of a base persona. Layers follow the
+// prompt from [WithSystemPrompt] or [WithInstructionProvider] in the order
+// they were added, joined by the separator from [WithSystemPromptSeparator].
+// Each layer is a template rendered against the agent's state, and layers
+// that render empty are left out.
+//
+//	agent.New(client,
+//		agent.WithSystemPrompt("You are a support assistant."),
+//		agent.WithSystemPromptLayer(teamPolicy),
+//		agent.WithSystemPromptLayer("Answer in {{.language}}."),
+//	)
+func WithSystemPromptLayer(layer string) Option {
+	return func(a *Agent) {
+		a.promptLayers = append(a.promptLayers, layer)
+	}
+}
+
+// WithSystemPromptSeparator sets the text placed between system prompt
+// layers. The default is a blank line.
+func WithSystemPromptSeparator(separator string) Option {
+	return func(a *Agent) {
+		a.promptSeparator = &separator
+	}
+}
+
+// WithExamples sets few-shot example turns, usually alternating user and
+// assistant messages. They are sent after the system prompt and before the
+// conversation on every LLM call, are never written to the session, and
+// their tokens are deducted from the context strategy's budget so the live
+// conversation is trimmed to make room for them.
+func WithExamples(examples []message.Message) Option {
+	return func(a *Agent) {
+		a.examples = examples
+	}
+}
+
+// WithToolChoice controls whether and which tool the model may call on the
+// first model call of each Chat or ChatStream: auto, none, required, or a
+// specific tool. Later iterations of the same run revert to the provider
+// default so the model can answer once the tool results are in. Supported by
+// the Anthropic, OpenAI, and Gemini vendor packages (and those built on them).
+//
+//	agent.WithToolChoice(llm.ToolChoice{
+//	    Mode: llm.ToolChoiceSpecific,
+//	    Name: "extract_invoice",
+//	})
+func WithToolChoice(choice llm.ToolChoice) Option {
+	return func(a *Agent) {
+		a.toolChoice = &choice
+	}
+}
+
 // WithTools adds tools that the agent can use during conversations.
 // Tools are executed automatically when the LLM requests them (unless WithAutoExecute is false).
 func WithTools(tools ...tool.BaseTool) Option {
@@ -30,6 +88,11 @@ func WithTools(tools ...tool.BaseTool) Option {
 // WithToolsets adds toolsets to the agent. Toolsets group tools under a name and support
 // dynamic filtering — tools are resolved per-call via Toolset.Tools(ctx), not at creation time.
 // Toolsets compose: a toolset can contain individual tools and other toolsets.
+//
+// A toolset tool whose name clashes with an agent tool or an earlier toolset
+// is offered as toolsetname_toolname, or with a numeric suffix if that is
+// taken too; calls under the new name reach the original tool. Wrap a
+// toolset with [tool.NamespacedToolset] to prefix all of its tools up front.
 func WithToolsets(toolsets ...tool.Toolset) Option {
 	return func(a *Agent) {
 		a.toolsets = append(a.toolsets, toolsets...)
@@ -53,10 +116,17 @@ func WithAutoExecute(auto bool) Option {
 }
 
 // WithMemory sets the memory store for cross-conversation fact storage.
-// The id parameter identifies the memory owner (e.g., user ID).
+// The id parameter identifies the memory owner (e.g., user ID). Pass an
+// empty id to keep memories per request under the user ID set with
+// [WithUserID], so one agent can serve many users. Such an agent cannot
+// have a [WithSession] session, which would hold every user's turns;
+// requests naming a user then fail with [ErrSharedSessionMemory].
 // When set, the agent automatically injects relevant memories into the system prompt.
 // Use memory.AutoExtract() to enable automatic fact extraction from conversations.
 // Use memory.AutoDedup() to enable LLM-based memory deduplication.
+// Use memory.WithConflictResolution() to replace contradicted memories.
+// Use memory.WithFailClosed() to fail the turn when memories cannot be
+// recalled instead of continuing without them.
 // Use memory.LLM() to set a separate LLM for memory operations.
 func WithMemory(
 	id string,
@@ -69,6 +139,10 @@ func WithMemory(
 		cfg := memory.Apply(opts...)
 		a.autoExtract = cfg.AutoExtract
 		a.autoDedup = cfg.AutoDedup
+		a.dedup = cfg.Dedup
+		a.conflictResolution = cfg.ConflictResolution
+		a.memoryFailClosed = cfg.FailClosed
+		a.extractInterval = cfg.ExtractInterval
 		if cfg.LLM != nil {
 			a.memoryLLM = cfg.LLM
 		}
@@ -83,6 +157,7 @@ func WithSession(id string, store session.Store) Option {
 		if store == nil {
 			return
 		}
+		a.sessionStore, a.sessionID = store, id
 		ctx := context.Background()
 		exists, err := store.Exists(ctx, id)
 		if err != nil {
@@ -100,7 +175,10 @@ func WithSession(id string, store session.Store) Option {
 // When the conversation exceeds the token limit, the strategy trims messages to fit.
 //
 // The maxContextTokens parameter sets the maximum tokens allowed for the conversation.
-// When the conversation exceeds this limit, the strategy is applied.
+// When the conversation exceeds this limit, the strategy is applied. The limit
+// is used as given, capped only at the model's context window, so leave room
+// for the response in it. Pass 0 to use the window minus the response
+// reserve; see [WithResponseReserve].
 //
 // Example with truncation:
 //
@@ -123,6 +201,42 @@ func WithContextStrategy(
 	}
 }
 
+// WithResponseReserve keeps room in the model's context window for its
+// response when the context limit is derived from the window, that is when
+// no limit is passed to [WithContextStrategy]. The agent adds a safety margin
+// to tokens and fits the conversation into the window minus the reserve, so a
+// full history never leaves the response without space. The reserve is
+// capped at a quarter of the window.
+//
+// Without it the reserve is the max_tokens configured on the LLM client plus
+// the margin, or 4096 tokens when the client does not set one.
+func WithResponseReserve(tokens int64) Option {
+	return func(a *Agent) {
+		a.reserveTokens = tokens
+	}
+}
+
+// WithCachedSystemPrompt marks the agent's system prompt for provider-side
+// prompt caching, so repeated calls with the same prompt are billed at the
+// cheaper cached-input rate. See [WithSystemPrompt] and
+// [WithInstructionProvider] for how the prompt is produced.
+//
+// The rendered prompt is sent as its own system message with a cache
+// breakpoint. Retrieved memories and knowledge change from turn to turn, so
+// they follow in a separate, uncached system message. The prompt is only
+// marked while it is stable: when a template or instruction provider renders
+// it differently from the previous call, that call skips the breakpoint
+// rather than paying to write a cache entry that will not be read again.
+//
+// Providers without prompt caching ignore the hint. Cache hits show up as
+// CacheReadTokens in the usage, and [session.Usage] reports the resulting
+// CacheSavings when usage tracking is enabled.
+func WithCachedSystemPrompt() Option {
+	return func(a *Agent) {
+		a.systemCache = &systemPromptCache{}
+	}
+}
+
 // WithSequentialToolExecution disables parallel tool execution.
 // By default, tools are executed in parallel for better performance.
 // Use this option when tools have dependencies on each other or when
@@ -153,18 +267,65 @@ func WithState(state map[string]any) Option {
 	}
 }
 
+// WithPersistentState saves the agent's state to the session store configured
+// with [WithSession] and restores it when the agent is created, so values set
+// with [Agent.SetState] or [SetStateValue] survive process restarts. Restored
+// values take precedence over those passed to [WithState], which act as
+// defaults. State is stored as JSON in a reserved record next to the session
+// (see [session.StateSuffix]). Without a session this option has no effect.
+func WithPersistentState() Option {
+	return func(a *Agent) {
+		a.persistState = true
+	}
+}
+
+// WithUsageTracking records the token usage and estimated cost of every model
+// call in the session store configured with [WithSession], so the cumulative
+// spend of a conversation can be read with [Agent.Usage] or
+// [session.LoadUsage] for billing and quotas. Cost is estimated from the
+// model catalog with [llm.EstimateCost]. Usage is stored in a reserved record
+// next to the session (see [session.UsageSuffix]). Without a session this
+// option has no effect.
+func WithUsageTracking() Option {
+	return func(a *Agent) {
+		a.trackUsage = true
+	}
+}
+
 // InstructionProvider is a function that generates the system prompt dynamically.
 type InstructionProvider func(ctx context.Context, state map[string]any) (string, error)
 
 // WithInstructionProvider sets a dynamic instruction provider that generates the system
 // prompt at runtime. When set, this takes precedence over the static system prompt.
-// The provider receives the current context and state map.
+// Layers added with [WithSystemPromptLayer] still follow the provider's prompt.
+// The provider receives the current context and a snapshot of the state map,
+// so changes made by tools through [StateFromContext] are visible on the next
+// turn.
 func WithInstructionProvider(provider InstructionProvider) Option {
 	return func(a *Agent) {
 		a.instructionProvider = provider
 	}
 }
 
+// WithDynamicInstructions adds instructions generated on every turn after the
+// static system prompt, for the common split of fixed rules plus context that
+// changes per turn. Unlike [WithInstructionProvider], the provider's output
+// does not replace the prompt; the system prompt is composed in this order:
+//
+//  1. the prompt from [WithSystemPrompt], or [WithInstructionProvider] when set
+//  2. the layers from [WithSystemPromptLayer]
+//  3. the dynamic instructions
+//  4. memories and knowledge retrieved for the turn
+//
+// With [WithCachedSystemPrompt] the dynamic instructions are sent with the
+// memories in the uncached system message, so they do not invalidate the
+// cached prompt. Empty output adds nothing.
+func WithDynamicInstructions(provider InstructionProvider) Option {
+	return func(a *Agent) {
+		a.dynamicInstructions = provider
+	}
+}
+
 // WithSubAgents registers child agents that the parent agent can invoke as tools.
 // Each sub-agent appears as a callable tool to the LLM. When invoked, the sub-agent
 // runs its own Chat() loop with a fresh context window and returns the result.
@@ -184,7 +345,7 @@ func WithSubAgents(configs ...SubAgentConfig) Option {
 			if len(a.hooks) > 0 && len(cfg.Agent.hooks) == 0 {
 				cfg.Agent.hooks = a.hooks
 			}
-			a.tools = append(a.tools, newSubAgentTool(cfg))
+			a.delegationTools = append(a.delegationTools, newSubAgentTool(cfg))
 		}
 		if a.taskManager == nil {
 			a.taskManager = newTaskManager()
@@ -207,6 +368,49 @@ func WithHooks(hooks ...Hooks) Option {
 	}
 }
 
+// WithLogger sets the structured logger used for debug and warning records at
+// key points of a run: LLM call start and end, tool invocations, context
+// trimming, and memory extraction. The agent's LLM client is wrapped with
+// [llm.WithLogger]. By default, all records are discarded.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Agent) {
+		if logger == nil {
+			return
+		}
+		a.logger = logger
+		a.llm = llm.WithLogger(a.llm, logger)
+	}
+}
+
+// WithMetrics reports agent runs, tool executions, and LLM request metrics to
+// collector. The agent's LLM client is wrapped with [llm.WithMetrics], so do not
+// also wrap the client passed to New or LLM requests are counted twice.
+// See the metrics package for the metric names and labels that are reported.
+func WithMetrics(collector metrics.Collector) Option {
+	return func(a *Agent) {
+		if collector == nil {
+			return
+		}
+		a.llm = llm.WithMetrics(a.llm, collector)
+		WithHooks(newMetricsHooks(collector))(a)
+	}
+}
+
+// WithToolCallIDGenerator replaces the provider-assigned IDs of tool calls the
+// agent receives with IDs from gen, so session history and tool results carry
+// stable IDs in golden-file tests. The agent's LLM client is wrapped with
+// [llm.WithToolCallIDs]. Without it the provider's IDs are kept.
+//
+//	agent.New(client, agent.WithToolCallIDGenerator(llm.SequentialIDs("call_")))
+func WithToolCallIDGenerator(gen llm.IDGenerator) Option {
+	return func(a *Agent) {
+		if gen == nil {
+			return
+		}
+		a.llm = llm.WithToolCallIDs(a.llm, gen)
+	}
+}
+
 // WithHandoffs registers peer agents that this agent can transfer control to.
 // When the LLM calls a transfer tool, the conversation continues with the new agent.
 // The new agent inherits the full message history but uses its own system prompt and tools.
@@ -216,7 +420,7 @@ func WithHandoffs(configs ...HandoffConfig) Option {
 	return func(a *Agent) {
 		a.handoffs = append(a.handoffs, configs...)
 		for _, cfg := range configs {
-			a.tools = append(a.tools, newHandoffTool(cfg))
+			a.delegationTools = append(a.delegationTools, newHandoffTool(cfg))
 		}
 	}
 }
@@ -249,7 +453,7 @@ func WithConfirmationProvider(provider ConfirmationProvider) Option {
 func WithFanOut(configs ...FanOutConfig) Option {
 	return func(a *Agent) {
 		for _, cfg := range configs {
-			a.tools = append(a.tools, newFanOutTool(cfg))
+			a.delegationTools = append(a.delegationTools, newFanOutTool(cfg))
 		}
 	}
 }
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// ErrStructuredOutputUnsupported is returned when [WithOutputSchema] is set on
+// an agent whose model cannot produce structured output.
+var ErrStructuredOutputUnsupported = errors.New(
+	"agent: model does not support structured output",
+)
+
+// WithOutputSchema makes the agent's final answer a JSON document matching
+// output, while the model can still call tools on the way there. Every model
+// call of a run is sent with both the tools and the schema; the provider lets
+// the model call tools freely and constrains the reply that ends the run to
+// the schema. That reply is returned in [ChatResponse].StructuredOutput, for
+// example to be decoded with [schema.Parse].
+//
+// Runs fail with [ErrStructuredOutputUnsupported] when the model does not
+// support structured output, or when tools are sent to a model that cannot
+// combine them with a schema, such as Gemini 2.x. A run that stops with tool
+// calls pending, such as at the iteration limit, has no structured output.
+func WithOutputSchema(output *schema.StructuredOutputInfo) Option {
+	return func(a *Agent) {
+		a.outputSchema = output
+	}
+}
+
+func (a *Agent) validateOutputSchema(tools []tool.BaseTool) error {
+	if a.outputSchema == nil {
+		return nil
+	}
+	if !a.llm.SupportsStructuredOutput() {
+		return fmt.Errorf(
+			"%w: %s",
+			ErrStructuredOutputUnsupported,
+			a.llm.Model().ID,
+		)
+	}
+	if len(tools) > 0 && a.llm.Model().SchemaExcludesTools {
+		return fmt.Errorf(
+			"%w: %s cannot combine an output schema with tools",
+			ErrStructuredOutputUnsupported,
+			a.llm.Model().ID,
+		)
+	}
+	return nil
+}
+
+// sendMessages makes one model call of a run, constrained to the output
+// schema when one is set.
+func (a *Agent) sendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	if a.outputSchema == nil {
+		return a.llm.SendMessages(ctx, messages, tools)
+	}
+	return a.llm.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		a.outputSchema,
+	)
+}
+
+// streamResponse is the streaming variant of sendMessages.
+func (a *Agent) streamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan llm.Event {
+	if a.outputSchema == nil {
+		return a.llm.StreamResponse(ctx, messages, tools)
+	}
+	return a.llm.StreamResponseWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		a.outputSchema,
+	)
+}
+
+// structuredOutputOf returns the structured output of the response that
+// ended a run, or nil when the agent has no output schema or the run ended
+// with tool calls pending.
+func (a *Agent) structuredOutputOf(resp *llm.Response) *string {
+	if a.outputSchema == nil || resp == nil || len(resp.ToolCalls) > 0 {
+		return nil
+	}
+	if resp.StructuredOutput != nil {
+		return resp.StructuredOutput
+	}
+	content := resp.Content
+	return &content
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type userIDKey struct{}
+
+// WithUserID returns a context identifying the end user a request is made for.
+// Agents configured with [WithUserRateLimit] count requests per user ID. The
+// ID stays inside the agent unless it was built with [WithProviderUserID].
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// WithProviderUserID passes the user ID from [WithUserID] or
+// [WithCallUserID] on to the provider with every model call, through
+// [llm.ContextWithUser], for providers that use it for abuse monitoring. The
+// ID then leaves your system, so use an opaque ID such as a hash rather than
+// an email address or name.
+func WithProviderUserID() Option {
+	return func(a *Agent) {
+		a.providerUserID = true
+	}
+}
+
+// UserIDFromContext returns the user ID set with [WithUserID].
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RateLimitError is returned by Chat, ChatStream, and the Continue methods
+// when a user has used up their requests for the current window. No LLM call
+// is made and nothing is written to the session.
+type RateLimitError struct {
+	// UserID is the user whose limit was reached.
+	UserID string
+	// Limit is the number of requests allowed per minute.
+	Limit int
+	// RetryAfter is how long until the user's oldest counted request leaves
+	// the window and another request is allowed.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf(
+		"agent: user %q exceeded %d requests per minute, retry after %s",
+		e.UserID,
+		e.Limit,
+		e.RetryAfter.Round(time.Millisecond),
+	)
+}
+
+// WithUserRateLimit limits each end user to requestsPerMin requests over any
+// sliding one-minute window. The user is identified by [WithUserID] on the
+// request context, falling back to the memory owner ID from [WithMemory];
+// requests with neither are not limited. A request over the limit fails with
+// a [*RateLimitError] before any hook runs or the LLM is called. Counts are
+// kept in memory per agent, so they are not shared across processes.
+func WithUserRateLimit(requestsPerMin int) Option {
+	return func(a *Agent) {
+		a.userRateLimit = nil
+		if requestsPerMin > 0 {
+			a.userRateLimit = &userRateLimiter{
+				limit:    requestsPerMin,
+				window:   time.Minute,
+				requests: make(map[string][]time.Time),
+			}
+		}
+	}
+}
+
+type userRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	requests  map[string][]time.Time
+	lastSweep time.Time
+}
+
+func (l *userRateLimiter) allow(userID string, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	if now.Sub(l.lastSweep) >= l.window {
+		for id, times := range l.requests {
+			if !times[len(times)-1].After(cutoff) {
+				delete(l.requests, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	times := l.requests[userID]
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = times[i:]
+
+	if len(times) >= l.limit {
+		l.requests[userID] = times
+		return &RateLimitError{
+			UserID:     userID,
+			Limit:      l.limit,
+			RetryAfter: times[0].Sub(cutoff),
+		}
+	}
+	l.requests[userID] = append(times, now)
+	return nil
+}
+
+func (a *Agent) checkUserRateLimit(ctx context.Context) error {
+	if a.userRateLimit == nil {
+		return nil
+	}
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		userID = a.memoryID
+	}
+	if userID == "" {
+		return nil
+	}
+	return a.userRateLimit.allow(userID, time.Now())
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// ErrReasoningUnsupported is returned when [WithReasoningEffort] is set on an
+// agent whose model does not support it.
+var ErrReasoningUnsupported = errors.New(
+	"agent: model does not support reasoning effort",
+)
+
+// ErrVerbosityUnsupported is returned when [WithVerbosity] is set on an agent
+// whose model does not accept a verbosity setting.
+var ErrVerbosityUnsupported = errors.New(
+	"agent: model does not support verbosity",
+)
+
+// WithReasoningEffort sets the reasoning effort of every model call the agent
+// makes, overriding the effort configured on the LLM client. OpenAI maps it
+// to reasoning_effort, Anthropic to its effort setting, and Gemini to the
+// thinking level of the same name. Calls fail with [ErrReasoningUnsupported]
+// when the model cannot reason or, like Gemini 2.x, takes a thinking budget
+// instead (see [llm.SupportsReasoningEffort]).
+func WithReasoningEffort(level llm.ReasoningEffort) Option {
+	return func(a *Agent) {
+		a.reasoningEffort = &level
+	}
+}
+
+// WithVerbosity sets how long and detailed the model's answers should be on
+// every model call the agent makes. Only OpenAI's GPT-5 models accept it;
+// calls with other models fail with [ErrVerbosityUnsupported].
+func WithVerbosity(level llm.Verbosity) Option {
+	return func(a *Agent) {
+		a.verbosity = &level
+	}
+}
+
+// modelContext returns the context for one model call of a run: ctx carrying
+// the tool choice (see [toolChoiceContext]), web search, reasoning effort,
+// verbosity, and provider user ID configured on the agent. It fails when the
+// agent's model does not support the reasoning settings, or the output schema
+// together with tools.
+func (a *Agent) modelContext(
+	ctx context.Context,
+	choice *llm.ToolChoice,
+	turns int,
+	tools []tool.BaseTool,
+) (context.Context, error) {
+	if err := a.validateReasoning(); err != nil {
+		return nil, err
+	}
+	if err := a.validateOutputSchema(tools); err != nil {
+		return nil, err
+	}
+	ctx = a.webSearchContext(toolChoiceContext(ctx, choice, turns))
+	if a.reasoningEffort != nil {
+		ctx = llm.ContextWithReasoningEffort(ctx, *a.reasoningEffort)
+	}
+	if a.verbosity != nil {
+		ctx = llm.ContextWithVerbosity(ctx, *a.verbosity)
+	}
+	if id, ok := UserIDFromContext(ctx); ok && a.providerUserID {
+		ctx = llm.ContextWithUser(ctx, id)
+	}
+	return ctx, nil
+}
+
+func (a *Agent) validateReasoning() error {
+	m := a.llm.Model()
+	if a.reasoningEffort != nil {
+		if err := a.reasoningEffort.Validate(); err != nil {
+			return err
+		}
+		if !llm.SupportsReasoningEffort(m) {
+			return fmt.Errorf("%w: %s", ErrReasoningUnsupported, m.ID)
+		}
+	}
+	if a.verbosity != nil {
+		if err := a.verbosity.Validate(); err != nil {
+			return err
+		}
+		if !llm.SupportsVerbosity(m) {
+			return fmt.Errorf("%w: %s", ErrVerbosityUnsupported, m.ID)
+		}
+	}
+	return nil
+}
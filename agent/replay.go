@@ -0,0 +1,338 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// Regenerate discards the latest turn and runs it again: the last user
+// message in the session is resubmitted, with its attachments, as a fresh
+// Chat call that sees only the history before it. Use it for a chat UI's
+// "regenerate" button. Requires a session to be configured.
+//
+// The session is only rewritten once the new turn succeeds, replacing the
+// discarded turn with the new one, atomically when the session implements
+// [session.Replacer]. If the turn fails the session is left as it was.
+// Memories already extracted from the discarded turn are not rolled back.
+func (a *Agent) Regenerate(
+	ctx context.Context,
+	opts ...ChatOption,
+) (*ChatResponse, error) {
+	ctx, rw, err := a.rewindLastUserTurn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.Chat(ctx, rw.user.Content().Text, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := rw.save(ctx); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RegenerateStream is the streaming variant of [Agent.Regenerate].
+func (a *Agent) RegenerateStream(
+	ctx context.Context,
+	opts ...ChatOption,
+) <-chan ChatEvent {
+	ctx, rw, err := a.rewindLastUserTurn(ctx)
+	if err != nil {
+		return errorEvent(err)
+	}
+	return rw.saveOnComplete(
+		ctx,
+		a.ChatStream(ctx, rw.user.Content().Text, opts...),
+	)
+}
+
+// EditAndResubmit replaces the user message at messageIndex in the session
+// history with newContent and runs the conversation from there as a fresh
+// Chat call that sees only the history before that message. messageIndex
+// indexes the messages returned by the session's GetMessages and must point
+// at a user message. Requires a session to be configured.
+//
+// Like [Agent.Regenerate], the message and everything after it are only
+// replaced once the new turn succeeds. Memories already extracted from the
+// discarded turns are not rolled back.
+func (a *Agent) EditAndResubmit(
+	ctx context.Context,
+	messageIndex int,
+	newContent string,
+	opts ...ChatOption,
+) (*ChatResponse, error) {
+	ctx, rw, err := a.rewindTo(ctx, messageIndex)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.Chat(ctx, newContent, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := rw.save(ctx); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EditAndResubmitStream is the streaming variant of [Agent.EditAndResubmit].
+func (a *Agent) EditAndResubmitStream(
+	ctx context.Context,
+	messageIndex int,
+	newContent string,
+	opts ...ChatOption,
+) <-chan ChatEvent {
+	ctx, rw, err := a.rewindTo(ctx, messageIndex)
+	if err != nil {
+		return errorEvent(err)
+	}
+	return rw.saveOnComplete(ctx, a.ChatStream(ctx, newContent, opts...))
+}
+
+func (a *Agent) rewindLastUserTurn(
+	ctx context.Context,
+) (context.Context, *rewind, error) {
+	if a.session == nil {
+		return nil, nil, fmt.Errorf(
+			"agent: Regenerate requires a session to restore conversation state",
+		)
+	}
+	messages, err := a.session.GetMessages(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to load session messages: %w",
+			err,
+		)
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == message.User {
+			ctx, rw := a.rewound(ctx, messages[:i], &messages[i])
+			return ctx, rw, nil
+		}
+	}
+	return nil, nil, fmt.Errorf(
+		"agent: Regenerate found no user message in session",
+	)
+}
+
+func (a *Agent) rewindTo(
+	ctx context.Context,
+	messageIndex int,
+) (context.Context, *rewind, error) {
+	if a.session == nil {
+		return nil, nil, fmt.Errorf(
+			"agent: EditAndResubmit requires a session to restore conversation state",
+		)
+	}
+	messages, err := a.session.GetMessages(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to load session messages: %w",
+			err,
+		)
+	}
+	if messageIndex < 0 || messageIndex >= len(messages) {
+		return nil, nil, fmt.Errorf(
+			"agent: message index %d out of range for session with %d messages",
+			messageIndex,
+			len(messages),
+		)
+	}
+	if role := messages[messageIndex].Role; role != message.User {
+		return nil, nil, fmt.Errorf(
+			"agent: message %d is a %s message, not a user message",
+			messageIndex,
+			role,
+		)
+	}
+	ctx, rw := a.rewound(ctx, messages[:messageIndex], nil)
+	return ctx, rw, nil
+}
+
+// rewound returns ctx carrying a [rewind] of the agent's session to kept.
+// user is the message to resubmit for a regenerated turn, and nil for an
+// edited one.
+func (a *Agent) rewound(
+	ctx context.Context,
+	kept []message.Message,
+	user *message.Message,
+) (context.Context, *rewind) {
+	rw := &rewind{
+		agent: a,
+		view: &rewoundSession{
+			Session:  a.session,
+			messages: slices.Clone(kept),
+		},
+		user: user,
+	}
+	return context.WithValue(ctx, rewindKey{}, rw), rw
+}
+
+type rewindKey struct{}
+
+// rewind is a regenerated or edited turn in progress. The turn runs against
+// view, which holds the history before the rewound message and collects the
+// new turn's messages, and save writes view back to the agent's session
+// once the turn succeeds.
+type rewind struct {
+	agent *Agent
+	view  *rewoundSession
+	user  *message.Message
+	// extractCtx is the context of the turn's deferred memory extraction,
+	// scheduled by save; nil when the turn scheduled none.
+	extractCtx context.Context
+}
+
+func rewindFromContext(ctx context.Context) *rewind {
+	rw, _ := ctx.Value(rewindKey{}).(*rewind)
+	return rw
+}
+
+// sessionFor returns the session a's turn reads and writes: the rewound view
+// while a regenerates or edits a turn, and a's session otherwise.
+func (a *Agent) sessionFor(ctx context.Context) session.Session {
+	if rw := rewindFromContext(ctx); rw != nil && rw.agent == a {
+		return rw.view
+	}
+	return a.session
+}
+
+// userMessage returns the message for a turn's userMessage. A regenerated
+// turn resubmits the rewound message with its text replaced, keeping its
+// images and files.
+func (a *Agent) userMessage(
+	ctx context.Context,
+	userMessage string,
+) message.Message {
+	msg := message.NewUserMessage(userMessage)
+	if rw := rewindFromContext(ctx); rw != nil && rw.agent == a &&
+		rw.user != nil {
+		msg.Parts = slices.Clone(rw.user.Parts)
+		replaced := false
+		for i, part := range msg.Parts {
+			if _, ok := part.(message.TextContent); ok && !replaced {
+				msg.Parts[i] = message.TextContent{Text: userMessage}
+				replaced = true
+			}
+		}
+		if !replaced && userMessage != "" {
+			msg.Parts = append(
+				[]message.ContentPart{message.TextContent{Text: userMessage}},
+				msg.Parts...,
+			)
+		}
+	}
+	msg.Model = a.llm.Model().ID
+	return msg
+}
+
+// saveOnComplete forwards events, saving the rewound history before the
+// closing complete event. A failed save turns that event into an error.
+func (rw *rewind) saveOnComplete(
+	ctx context.Context,
+	events <-chan ChatEvent,
+) <-chan ChatEvent {
+	out := make(chan ChatEvent)
+	go func() {
+		defer close(out)
+		for evt := range events {
+			if evt.Type == types.EventComplete {
+				if err := rw.save(ctx); err != nil {
+					evt = ChatEvent{Type: types.EventError, Error: err}
+				}
+			}
+			out <- evt
+		}
+	}()
+	return out
+}
+
+// save replaces the agent's session with the rewound history, then
+// schedules the memory extraction the turn deferred.
+func (rw *rewind) save(ctx context.Context) error {
+	if err := session.ReplaceMessages(
+		ctx,
+		rw.agent.session,
+		rw.view.snapshot(),
+	); err != nil {
+		return fmt.Errorf("failed to save rewound session: %w", err)
+	}
+	if rw.extractCtx != nil {
+		rw.agent.scheduleExtraction(
+			context.WithValue(rw.extractCtx, rewindKey{}, (*rewind)(nil)),
+		)
+	}
+	return nil
+}
+
+// rewoundSession is a session holding its messages in memory, standing in
+// for the agent's session while a rewound turn runs.
+type rewoundSession struct {
+	session.Session
+
+	mu       sync.Mutex
+	messages []message.Message
+}
+
+func (s *rewoundSession) GetMessages(
+	_ context.Context,
+	limit *int,
+) ([]message.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := 0
+	if limit != nil && *limit < len(s.messages) {
+		start = len(s.messages) - max(*limit, 0)
+	}
+	return slices.Clone(s.messages[start:]), nil
+}
+
+func (s *rewoundSession) AddMessages(
+	_ context.Context,
+	msgs []message.Message,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msgs...)
+	return nil
+}
+
+func (s *rewoundSession) PopMessage(
+	context.Context,
+) (*message.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) == 0 {
+		return nil, nil
+	}
+	msg := s.messages[len(s.messages)-1]
+	s.messages = s.messages[:len(s.messages)-1]
+	return &msg, nil
+}
+
+func (s *rewoundSession) Clear(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	return nil
+}
+
+func (s *rewoundSession) snapshot() []message.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+func errorEvent(err error) <-chan ChatEvent {
+	eventChan := make(chan ChatEvent, 1)
+	eventChan <- ChatEvent{Type: types.EventError, Error: err}
+	close(eventChan)
+	return eventChan
+}
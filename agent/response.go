@@ -5,7 +5,9 @@ import (
 
 	"github.com/joakimcarlsson/ai/agent/team"
 	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/memory"
 	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/moderation"
 	"github.com/joakimcarlsson/ai/tool"
 	"github.com/joakimcarlsson/ai/types"
 )
@@ -38,6 +40,25 @@ type ChatResponse struct {
 	TotalDuration time.Duration
 	// TotalTurns is the number of LLM round-trips (API calls) made during the conversation.
 	TotalTurns int
+	// Sources lists the knowledge base entries injected into the context by automatic
+	// retrieval, in relevance order, so the response can cite them.
+	Sources []memory.Entry
+	// Moderation is set when [WithModeration] flagged the user message or
+	// the response, in which case Content holds the refusal.
+	Moderation *moderation.Result
+	// Citations lists the sources the model cited in Content, as returned by
+	// providers that support citations. It is nil for other providers.
+	Citations []message.Citation
+	// Empty reports that the model ended the conversation normally, with
+	// [message.FinishReasonEndTurn], no tool calls and no content beyond
+	// whitespace, after any retries configured with [WithRetryOnEmpty]. It
+	// distinguishes a blank reply from a response that was cut short or
+	// filtered.
+	Empty bool
+	// StructuredOutput is the final answer as a JSON document matching the
+	// schema set with [WithOutputSchema]. It is nil without a schema and
+	// when the run ended with tool calls pending.
+	StructuredOutput *string
 }
 
 // ToolExecutionResult captures the outcome of a single tool invocation.
@@ -50,6 +71,13 @@ type ToolExecutionResult struct {
 	Input string
 	// Output is the tool's text response.
 	Output string
+	// MIMEType is the media type of Output, such as "application/json" for
+	// tool.NewJSONResponse results. Empty means plain text.
+	MIMEType string
+	// Images holds image output from tool.NewImageResponse,
+	// tool.NewMultiPartResponse, or tool.NewFileResponse with an image MIME
+	// type.
+	Images []message.BinaryContent
 	// IsError indicates whether the tool execution resulted in an error.
 	IsError bool
 	// Duration is the wall-clock time the tool execution took.
@@ -74,8 +102,14 @@ type ChatEvent struct {
 	Error error
 	// AgentName is set on EventHandoff events to indicate the target agent.
 	AgentName string
+	// ToolProgress is set on EventToolProgress events with the update a
+	// running tool reported; ToolCall identifies the call.
+	ToolProgress *tool.Progress
 	// ConfirmationRequest is set on EventConfirmationRequired events with the details of the pending request.
 	ConfirmationRequest *tool.ConfirmationRequest
 	// TeamMessage is set on EventTeamMessage events with the message details.
 	TeamMessage *team.Message
+	// Usage is set on EventUsageUpdate events with the running token totals
+	// for the whole run, including earlier model calls.
+	Usage *llm.TokenUsage
 }
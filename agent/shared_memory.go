@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"slices"
+
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// sharedMemoryPrefix namespaces the tools added by [WithSharedMemory], so they
+// never collide with the agent's own memory tools.
+const sharedMemoryPrefix = "shared"
+
+// sharedMemoryReadTools are the memory tools that only read.
+var sharedMemoryReadTools = []string{
+	MemoryToolRecall,
+	MemoryToolList,
+	MemoryToolCount,
+	MemoryToolSearch,
+}
+
+// WithSharedMemory gives the agent tools over the long-term memory another
+// agent keeps for userID in store, so one agent can curate knowledge that
+// others consume. It is independent of the agent's own [WithMemory].
+//
+// The tools are the memory tools prefixed with "shared_", such as
+// shared_recall_memories. With readOnly set only recall_memories,
+// list_memories, count_memories, and search_memories are exposed; otherwise
+// store_memory, replace_memory, and delete_memory are added and write to the
+// other agent's memory. Sharing several memories adds a set of tools for
+// each, renamed as described on [WithToolsets].
+//
+//	curator := agent.New(client, agent.WithMemory("team", store))
+//	reader := agent.New(client,
+//		agent.WithSharedMemory(store, "team", true),
+//	)
+func WithSharedMemory(
+	store memory.Store,
+	userID string,
+	readOnly bool,
+) Option {
+	return func(a *Agent) {
+		a.toolsets = append(
+			a.toolsets,
+			sharedMemoryToolset(store, userID, readOnly),
+		)
+	}
+}
+
+func sharedMemoryToolset(
+	store memory.Store,
+	userID string,
+	readOnly bool,
+) tool.Toolset {
+	all := append(
+		memory.Tools(store, userID),
+		memory.QueryTools(store, userID)...,
+	)
+	var selected []tool.BaseTool
+	for _, t := range all {
+		info := t.Info()
+		if readOnly && !slices.Contains(sharedMemoryReadTools, info.Name) {
+			continue
+		}
+		selected = append(selected, &describedTool{
+			BaseTool: t,
+			description: "Shared memory kept by another agent. " +
+				info.Description,
+		})
+	}
+	return tool.NamespacedToolset(
+		sharedMemoryPrefix,
+		tool.NewToolset(sharedMemoryPrefix, selected...),
+	)
+}
@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/joakimcarlsson/ai/session"
+)
+
+type stateAgentKey struct{}
+
+// withStateAgent returns ctx carrying a, so tools and hooks running during
+// the turn can read and update its state.
+func withStateAgent(ctx context.Context, a *Agent) context.Context {
+	return context.WithValue(ctx, stateAgentKey{}, a)
+}
+
+// StateHandle reads and updates the state of the agent running the current
+// turn from inside a tool or hook. Obtain one with [StateFromContext].
+//
+// A handle is safe for concurrent use: tools running in parallel may share
+// it, and every call takes the agent's state lock. Values themselves are not
+// copied, so treat stored maps and slices as immutable and replace them with
+// Set instead of modifying them in place. Use [StateHandle.Update] for
+// read-modify-write changes such as counters, which Get followed by Set would
+// race on.
+type StateHandle struct {
+	agent *Agent
+}
+
+// StateFromContext returns a handle to the state of the agent running the
+// current turn. ok is false when ctx does not come from an agent run.
+func StateFromContext(ctx context.Context) (*StateHandle, bool) {
+	a, _ := ctx.Value(stateAgentKey{}).(*Agent)
+	if a == nil {
+		return nil, false
+	}
+	return &StateHandle{agent: a}, true
+}
+
+// Get returns the value stored under key.
+func (h *StateHandle) Get(key string) (any, bool) {
+	return h.agent.StateValue(key)
+}
+
+// Snapshot returns a copy of the whole state.
+func (h *StateHandle) Snapshot() map[string]any {
+	return h.agent.State()
+}
+
+// Set stores value under key. See [Agent.SetState].
+func (h *StateHandle) Set(ctx context.Context, key string, value any) error {
+	return h.agent.SetState(ctx, key, value)
+}
+
+// Delete removes key. See [Agent.DeleteState].
+func (h *StateHandle) Delete(ctx context.Context, key string) error {
+	return h.agent.DeleteState(ctx, key)
+}
+
+// Update applies fn to the state while holding the state lock. See
+// [Agent.UpdateState].
+func (h *StateHandle) Update(
+	ctx context.Context,
+	fn func(state map[string]any),
+) error {
+	return h.agent.UpdateState(ctx, fn)
+}
+
+// StateValue returns a value from the state of the agent running the current
+// turn. It is shorthand for [StateFromContext] followed by
+// [StateHandle.Get]; ok is false when the key is unset or ctx does not come
+// from an agent run.
+func StateValue(ctx context.Context, key string) (any, bool) {
+	h, ok := StateFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return h.Get(key)
+}
+
+// SetStateValue sets a value in the state of the agent running the current
+// turn, persisting it when [WithPersistentState] is enabled. It is shorthand
+// for [StateFromContext] followed by [StateHandle.Set] and returns an error
+// when ctx does not come from an agent run.
+func SetStateValue(ctx context.Context, key string, value any) error {
+	h, ok := StateFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("agent: SetStateValue requires an agent run context")
+	}
+	return h.Set(ctx, key, value)
+}
+
+// State returns a copy of the agent's state.
+func (a *Agent) State() map[string]any {
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	return maps.Clone(a.state)
+}
+
+// StateValue returns the value stored under key in the agent's state.
+func (a *Agent) StateValue(key string) (any, bool) {
+	a.stateMu.RLock()
+	defer a.stateMu.RUnlock()
+	value, ok := a.state[key]
+	return value, ok
+}
+
+// SetState stores value under key in the agent's state, making it available
+// to the system prompt template on the next turn. With
+// [WithPersistentState] the state is saved to the session store before
+// SetState returns.
+func (a *Agent) SetState(ctx context.Context, key string, value any) error {
+	return a.UpdateState(ctx, func(state map[string]any) {
+		state[key] = value
+	})
+}
+
+// DeleteState removes key from the agent's state, persisting the change like
+// [Agent.SetState].
+func (a *Agent) DeleteState(ctx context.Context, key string) error {
+	return a.UpdateState(ctx, func(state map[string]any) {
+		delete(state, key)
+	})
+}
+
+// UpdateState applies fn to the agent's state while holding the state lock,
+// then persists the result like [Agent.SetState]. fn may read and modify the
+// map freely but must not retain it or call other state methods, which would
+// deadlock.
+func (a *Agent) UpdateState(
+	ctx context.Context,
+	fn func(state map[string]any),
+) error {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	if a.state == nil {
+		a.state = make(map[string]any)
+	}
+	fn(a.state)
+	if !a.persistState || a.sessionStore == nil {
+		return nil
+	}
+	if err := session.SaveState(
+		ctx,
+		a.sessionStore,
+		a.sessionID,
+		a.state,
+	); err != nil {
+		return fmt.Errorf("agent: save state: %w", err)
+	}
+	return nil
+}
+
+// loadPersistedState merges the state saved in the session store over the
+// initial state. Failures are logged and leave the initial state in place.
+func (a *Agent) loadPersistedState() {
+	if a.sessionStore == nil {
+		return
+	}
+	saved, err := session.LoadState(
+		context.Background(),
+		a.sessionStore,
+		a.sessionID,
+	)
+	if err != nil {
+		a.logger.Warn("failed to load persisted agent state",
+			slog.String("session_id", a.sessionID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if len(saved) == 0 {
+		return
+	}
+	if a.state == nil {
+		a.state = make(map[string]any, len(saved))
+	}
+	maps.Copy(a.state, saved)
+}
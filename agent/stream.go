@@ -15,21 +15,42 @@ import (
 // ChatStream sends a message to the agent and returns a channel of streaming events.
 // Events include content deltas, tool calls, handoff notifications, and the final response.
 // The channel is closed when the response is complete or an error occurs.
+//
+// Middleware registered with [WithMiddleware] wraps the whole call; see
+// [AgentMiddleware] for how it sees a stream.
 func (a *Agent) ChatStream(
 	ctx context.Context,
 	userMessage string,
 	opts ...ChatOption,
+) <-chan ChatEvent {
+	ctx = callContext(ctx, opts)
+	if len(a.middleware) == 0 {
+		return a.chatStream(ctx, userMessage, opts...)
+	}
+	return a.streamWithMiddleware(ctx, userMessage, opts)
+}
+
+func (a *Agent) chatStream(
+	ctx context.Context,
+	userMessage string,
+	opts ...ChatOption,
 ) <-chan ChatEvent {
 	eventChan := make(chan ChatEvent)
 
 	go func() {
 		defer close(eventChan)
 
+		if err := a.checkUserRateLimit(ctx); err != nil {
+			eventChan <- ChatEvent{Type: types.EventError, Error: err}
+			return
+		}
+
 		startTime := time.Now()
 		taskID, agentName, branch := a.hookContext(ctx)
 
 		ctx, span := tracing.StartAgentSpan(ctx, agentName)
 		defer span.End()
+		ctx = withStateAgent(ctx, a)
 
 		runBeforeRun(ctx, a.hooks, RunContext{
 			AgentName: agentName,
@@ -121,7 +142,7 @@ func (a *Agent) ChatStream(
 			return
 		}
 
-		messages, err := a.buildMessages(ctx, userMessage)
+		messages, sources, err := a.buildMessages(ctx, userMessage)
 		if err != nil {
 			tracing.SetError(span, err)
 			eventChan <- ChatEvent{Type: types.EventError, Error: err}
@@ -130,6 +151,9 @@ func (a *Agent) ChatStream(
 
 		cfg := applyChatOptions(opts)
 		resp, loopErr := a.runLoopStream(ctx, messages, cfg, eventChan)
+		if resp != nil {
+			resp.Sources = sources
+		}
 
 		if loopErr == nil && resp != nil {
 			aaResult, aaErr := runAfterAgent(ctx, a.hooks, LifecycleContext{
@@ -194,6 +218,7 @@ func (a *Agent) ContinueStream(
 	toolResults []message.ToolResult,
 	opts ...ChatOption,
 ) <-chan ChatEvent {
+	ctx = callContext(ctx, opts)
 	eventChan := make(chan ChatEvent)
 
 	go func() {
@@ -213,12 +238,17 @@ func (a *Agent) ContinueStream(
 			}
 			return
 		}
+		if err := a.checkUserRateLimit(ctx); err != nil {
+			eventChan <- ChatEvent{Type: types.EventError, Error: err}
+			return
+		}
 
 		startTime := time.Now()
 		taskID, agentName, branch := a.hookContext(ctx)
 
 		ctx, span := tracing.StartAgentSpan(ctx, agentName)
 		defer span.End()
+		ctx = withStateAgent(ctx, a)
 
 		runBeforeRun(ctx, a.hooks, RunContext{
 			AgentName: agentName,
@@ -299,7 +329,7 @@ func (a *Agent) ContinueStream(
 		}
 		messages = append(messages, toolMsg)
 
-		if err := a.session.AddMessages(
+		if err := a.sessionFor(ctx).AddMessages(
 			ctx,
 			[]message.Message{toolMsg},
 		); err != nil {
@@ -374,7 +404,9 @@ func (a *Agent) runLoopStream(
 	startTime := time.Now()
 	var totalUsage llm.TokenUsage
 	var totalToolCalls int
+	var allToolResults []ToolExecutionResult
 	var turns int
+	var emptyRetries int
 
 	activeAgent := a
 	iteration := 0
@@ -384,6 +416,11 @@ func (a *Agent) runLoopStream(
 		maxIter = cfg.maxIterations
 	}
 
+	toolChoice := cfg.toolChoice
+	if toolChoice == nil {
+		toolChoice = a.toolChoice
+	}
+
 	for {
 		var fullContent string
 		var fullReasoning string
@@ -392,7 +429,8 @@ func (a *Agent) runLoopStream(
 		seenToolStarts := make(map[string]bool)
 
 		turnStart := time.Now()
-		allTools := activeAgent.getToolsWithContext(ctx)
+		available := activeAgent.getToolsWithContext(ctx)
+		allTools := available
 
 		taskID, agentName, branch := activeAgent.hookContext(ctx)
 		mcResult, hookErr := runPreModelCall(
@@ -415,10 +453,32 @@ func (a *Agent) runLoopStream(
 			allTools = mcResult.Tools
 		}
 
+		fitted, err := activeAgent.fitLiveContext(
+			ctx,
+			messages,
+			allTools,
+			turns > 0,
+		)
+		if err != nil {
+			eventChan <- ChatEvent{Type: types.EventError, Error: err}
+			return nil, err
+		}
+		messages = fitted
+
 		var streamErr error
 		var streamRecovered bool
 
-		for event := range activeAgent.llm.StreamResponse(ctx, messages, allTools) {
+		modelCtx, err := activeAgent.modelContext(
+			ctx,
+			toolChoice,
+			turns,
+			allTools,
+		)
+		if err != nil {
+			eventChan <- ChatEvent{Type: types.EventError, Error: err}
+			return nil, err
+		}
+		for event := range activeAgent.streamResponse(modelCtx, messages, allTools) {
 			switch event.Type {
 			case types.EventContentDelta:
 				fullContent += event.Content
@@ -435,6 +495,12 @@ func (a *Agent) runLoopStream(
 					}
 					eventChan <- ChatEvent{Type: event.Type, ToolCall: event.ToolCall}
 				}
+			case types.EventUsageUpdate:
+				if event.Usage != nil {
+					running := totalUsage
+					running.Add(*event.Usage)
+					eventChan <- ChatEvent{Type: types.EventUsageUpdate, Usage: &running}
+				}
 			case types.EventComplete:
 				if event.Response != nil {
 					finalResponse = event.Response
@@ -479,6 +545,7 @@ func (a *Agent) runLoopStream(
 		turns++
 		if finalResponse != nil {
 			totalUsage.Add(finalResponse.Usage)
+			a.recordUsage(ctx, activeAgent.llm, finalResponse.Usage)
 			if !streamRecovered {
 				mrResult, hookErr := runPostModelCall(
 					ctx,
@@ -509,9 +576,22 @@ func (a *Agent) runLoopStream(
 			fullReasoning = finalResponse.Reasoning
 		}
 
+		var citations []message.Citation
+		var finishReason message.FinishReason
+		if finalResponse != nil {
+			citations = finalResponse.Citations
+			finishReason = finalResponse.FinishReason
+		}
+
+		empty := isEmptyResponse(finishReason, fullContent, toolCalls)
+		if empty && emptyRetries < activeAgent.retryOnEmpty {
+			emptyRetries++
+			continue
+		}
+
 		if len(toolCalls) == 0 || !activeAgent.autoExecute ||
 			(maxIter > 0 && iteration >= maxIter) {
-			if activeAgent.session != nil {
+			if sess := activeAgent.sessionFor(ctx); sess != nil && !empty {
 				assistantMsg := message.NewAssistantMessage()
 				assistantMsg.Model = activeAgent.llm.Model().ID
 				if fullContent != "" {
@@ -520,26 +600,30 @@ func (a *Agent) runLoopStream(
 				if fullReasoning != "" {
 					assistantMsg.AppendReasoningContent(fullReasoning)
 				}
+				assistantMsg.AddCitations(citations)
 				if len(toolCalls) > 0 && !activeAgent.autoExecute {
 					assistantMsg.AppendToolCalls(toolCalls)
 				}
 				if fullContent != "" || fullReasoning != "" ||
 					len(toolCalls) > 0 && !activeAgent.autoExecute {
-					_ = activeAgent.session.AddMessages(
+					err := sess.AddMessages(
 						ctx,
 						[]message.Message{assistantMsg},
 					)
+					if err == nil {
+						recordStoredResponse(
+							ctx,
+							sess,
+							assistantMsg,
+						)
+					}
 				}
 			}
 
-			if activeAgent.autoExtract && activeAgent.session != nil {
-				go activeAgent.extractAndStoreMemories(context.Background())
-			}
+			activeAgent.scheduleExtraction(ctx)
 
-			var finishReason message.FinishReason
 			var providerResponseID string
 			if finalResponse != nil {
-				finishReason = finalResponse.FinishReason
 				providerResponseID = finalResponse.ProviderResponseID
 			}
 
@@ -547,16 +631,25 @@ func (a *Agent) runLoopStream(
 				Content:            fullContent,
 				Reasoning:          fullReasoning,
 				ToolCalls:          toolCalls,
+				ToolResults:        allToolResults,
 				Usage:              totalUsage,
 				FinishReason:       finishReason,
 				ProviderResponseID: providerResponseID,
 				TotalToolCalls:     totalToolCalls,
 				TotalDuration:      time.Since(startTime),
 				TotalTurns:         turns,
+				Empty:              empty,
+				Citations:          citations,
+				StructuredOutput: activeAgent.structuredOutputOf(
+					finalResponse,
+				),
 			}
 			if activeAgent != a {
 				chatResp.AgentName = findAgentName(a, activeAgent)
 			}
+			if empty {
+				eventChan <- ChatEvent{Type: types.EventEmptyResponse}
+			}
 
 			return chatResp, nil
 		}
@@ -571,6 +664,7 @@ func (a *Agent) runLoopStream(
 		if fullReasoning != "" {
 			assistantMsg.AppendReasoningContent(fullReasoning)
 		}
+		assistantMsg.AddCitations(citations)
 		assistantMsg.AppendToolCalls(toolCalls)
 		messages = append(messages, assistantMsg)
 
@@ -584,7 +678,8 @@ func (a *Agent) runLoopStream(
 		}
 
 		execCtx := withConfirmationChan(ctx, eventChan)
-		toolResults := activeAgent.executeTools(execCtx, toolCalls)
+		toolResults := activeAgent.executeTools(execCtx, available, toolCalls)
+		allToolResults = append(allToolResults, toolResults...)
 
 		for _, result := range toolResults {
 			eventChan <- ChatEvent{
@@ -604,12 +699,14 @@ func (a *Agent) runLoopStream(
 				Name:       result.ToolName,
 				Content:    result.Output,
 				IsError:    result.IsError,
+				MIMEType:   result.MIMEType,
+				Images:     result.Images,
 			})
 		}
 		messages = append(messages, toolMsg)
 
-		if activeAgent.session != nil {
-			_ = activeAgent.session.AddMessages(
+		if sess := activeAgent.sessionFor(ctx); sess != nil {
+			_ = sess.AddMessages(
 				ctx,
 				[]message.Message{assistantMsg, toolMsg},
 			)
@@ -624,10 +721,12 @@ func (a *Agent) runLoopStream(
 				AgentName: handoff.Name,
 			}
 
+			previous := activeAgent
 			activeAgent = handoff.Agent
 			var err error
 			messages, err = rebuildMessagesForHandoff(
 				ctx,
+				previous,
 				activeAgent,
 				messages,
 			)
@@ -642,3 +741,24 @@ func (a *Agent) runLoopStream(
 		iteration++
 	}
 }
+
+// ThrottleStream re-times the EventContentDelta events of a ChatStream or
+// ContinueStream channel to a steady charsPerSec for a typewriter effect.
+// Other events are forwarded in order once the text before them has been
+// flushed. Cancelling ctx closes the returned channel. See
+// [types.ThrottleStream].
+func ThrottleStream(
+	ctx context.Context,
+	in <-chan ChatEvent,
+	charsPerSec int,
+) <-chan ChatEvent {
+	return types.ThrottleStream(ctx, in, charsPerSec,
+		func(evt ChatEvent) (string, bool) {
+			return evt.Content, evt.Type == types.EventContentDelta
+		},
+		func(evt ChatEvent, content string) ChatEvent {
+			evt.Content = content
+			return evt
+		},
+	)
+}
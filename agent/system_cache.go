@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// systemPromptCache remembers the last rendered system prompt so a prompt is
+// only marked for caching while it renders the same from call to call.
+type systemPromptCache struct {
+	mu       sync.Mutex
+	last     string
+	rendered bool
+}
+
+// hint returns the cache hint for prompt. When record is set, prompt becomes
+// the one later renders are compared against.
+func (c *systemPromptCache) hint(
+	prompt string,
+	record bool,
+) message.CacheHint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stable := !c.rendered || c.last == prompt
+	if record {
+		c.last = prompt
+		c.rendered = true
+	}
+	if stable {
+		return message.CacheBreakpoint
+	}
+	return message.CacheSkip
+}
+
+// systemMessages builds the system messages for a call from the rendered
+// prompt and the per-turn context (memories and knowledge) appended to it.
+// Without [WithCachedSystemPrompt] they are sent as a single message; with
+// it the prompt carries a cache hint and the per-turn context follows in an
+// uncached message of its own. record is false for calls that must not
+// change agent state, such as [Agent.PeekContextMessages].
+func (a *Agent) systemMessages(
+	prompt, turnContext string,
+	record bool,
+) []message.Message {
+	modelID := a.llm.Model().ID
+	if a.systemCache == nil {
+		content := prompt + turnContext
+		if prompt == "" {
+			content = strings.TrimLeft(turnContext, "\n")
+		}
+		if content == "" {
+			return nil
+		}
+		sysMsg := message.NewSystemMessage(content)
+		sysMsg.Model = modelID
+		return []message.Message{sysMsg}
+	}
+
+	var messages []message.Message
+	if prompt != "" {
+		sysMsg := message.NewSystemMessage(prompt)
+		sysMsg.Model = modelID
+		sysMsg.Cache = a.systemCache.hint(prompt, record)
+		messages = append(messages, sysMsg)
+	}
+	if turnContext = strings.TrimLeft(turnContext, "\n"); turnContext != "" {
+		sysMsg := message.NewSystemMessage(turnContext)
+		sysMsg.Model = modelID
+		sysMsg.Cache = message.CacheSkip
+		messages = append(messages, sysMsg)
+	}
+	return messages
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// dropOrphanedToolResults removes tool results that do not answer a tool
+// call made earlier in msgs. A context strategy that trims history, or a
+// session rebuilt by hand, can cut the assistant message that made a call
+// while keeping its result, and providers reject requests containing such
+// results. Tool messages left without any results are removed as well.
+func (a *Agent) dropOrphanedToolResults(
+	ctx context.Context,
+	msgs []message.Message,
+) []message.Message {
+	repaired, dropped := repairToolResults(msgs)
+	if dropped > 0 {
+		a.logger.WarnContext(ctx, "dropped orphaned tool results",
+			slog.Int("count", dropped),
+		)
+	}
+	return repaired
+}
+
+// repairToolResults returns msgs without orphaned tool results and the
+// number of results dropped. msgs is returned as is when none are orphaned.
+func repairToolResults(msgs []message.Message) ([]message.Message, int) {
+	called := make(map[string]bool)
+	var out []message.Message
+	dropped := 0
+	for i, msg := range msgs {
+		for _, tc := range msg.ToolCalls() {
+			called[tc.ID] = true
+		}
+		results := msg.ToolResults()
+		orphaned := 0
+		for _, tr := range results {
+			if !called[tr.ToolCallID] {
+				orphaned++
+			}
+		}
+		if orphaned == 0 {
+			if out != nil {
+				out = append(out, msg)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]message.Message, 0, len(msgs)), msgs[:i]...)
+		}
+		dropped += orphaned
+		if orphaned == len(results) && msg.Role == message.Tool {
+			continue
+		}
+		parts := make([]message.ContentPart, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			if tr, ok := part.(message.ToolResult); ok &&
+				!called[tr.ToolCallID] {
+				continue
+			}
+			parts = append(parts, part)
+		}
+		msg.Parts = parts
+		out = append(out, msg)
+	}
+	if out == nil {
+		return msgs, 0
+	}
+	return out, dropped
+}
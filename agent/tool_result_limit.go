@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tokens"
+)
+
+const toolResultSummaryPrompt = `Summarize the output of a tool call for ` +
+	`an AI agent that will continue its task with your summary instead of ` +
+	`the full output. Keep every fact, identifier, number, and error the ` +
+	`agent may need, drop repetition and boilerplate, and stay under %d ` +
+	`tokens. Reply with the summary only.`
+
+// ToolResultLimitOption configures [WithToolResultLimit].
+type ToolResultLimitOption func(*toolResultLimit)
+
+// ToolResultSummarizer summarizes oversized tool results with client instead
+// of cutting them off. When summarization fails, or the summary is itself
+// over the limit, the result is truncated as usual.
+func ToolResultSummarizer(client llm.LLM) ToolResultLimitOption {
+	return func(l *toolResultLimit) { l.summarizer = client }
+}
+
+// WithToolResultLimit caps each tool result fed back to the model at
+// maxTokens, counted with the tokens package's BPE tokenizer, so one tool
+// returning a large file or API response cannot overflow the context window.
+// Longer results keep their first maxTokens tokens followed by a
+// "[truncated N tokens]" marker, or are summarized with
+// [ToolResultSummarizer]. The limited output is also what
+// [ChatResponse.ToolResults] and the session record.
+func WithToolResultLimit(
+	maxTokens int,
+	opts ...ToolResultLimitOption,
+) Option {
+	return func(a *Agent) {
+		if maxTokens <= 0 {
+			return
+		}
+		limit := &toolResultLimit{maxTokens: maxTokens}
+		for _, opt := range opts {
+			opt(limit)
+		}
+		a.toolResultLimit = limit
+	}
+}
+
+type toolResultLimit struct {
+	maxTokens  int
+	summarizer llm.LLM
+
+	tokenizerOnce sync.Once
+	tokenizer     *tokens.BPETokenizer
+	tokenizerErr  error
+}
+
+// limitToolResult returns result.Output cut down to the configured limit.
+// Outputs within the limit are returned unchanged.
+func (a *Agent) limitToolResult(
+	ctx context.Context,
+	result ToolExecutionResult,
+) string {
+	l := a.toolResultLimit
+	if l == nil || len(result.Output) <= l.maxTokens {
+		return result.Output
+	}
+
+	l.tokenizerOnce.Do(func() {
+		l.tokenizer, l.tokenizerErr = tokens.NewBPETokenizer()
+	})
+	if l.tokenizerErr != nil {
+		a.logger.Warn("failed to create tokenizer for tool result limit",
+			slog.String("error", l.tokenizerErr.Error()),
+		)
+		return result.Output
+	}
+
+	ids := l.tokenizer.Encode(result.Output)
+	if len(ids) <= l.maxTokens {
+		return result.Output
+	}
+
+	if l.summarizer != nil {
+		summary, err := l.summarize(ctx, result, len(ids))
+		if err == nil {
+			return summary
+		}
+		a.logger.Warn("failed to summarize tool result, truncating",
+			slog.String("tool", result.ToolName),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	kept := strings.ToValidUTF8(l.tokenizer.Decode(ids[:l.maxTokens]), "")
+	return fmt.Sprintf(
+		"%s\n[truncated %d tokens]",
+		kept,
+		len(ids)-l.maxTokens,
+	)
+}
+
+func (l *toolResultLimit) summarize(
+	ctx context.Context,
+	result ToolExecutionResult,
+	total int,
+) (string, error) {
+	resp, err := l.summarizer.SendMessages(ctx, []message.Message{
+		message.NewSystemMessage(
+			fmt.Sprintf(toolResultSummaryPrompt, l.maxTokens),
+		),
+		message.NewUserMessage(fmt.Sprintf(
+			"Tool: %s\nInput: %s\n\nOutput:\n%s",
+			result.ToolName,
+			result.Input,
+			result.Output,
+		)),
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	summary := fmt.Sprintf(
+		"[summarized from %d tokens]\n%s",
+		total,
+		resp.Content,
+	)
+	if l.tokenizer.Count(summary) > l.maxTokens {
+		return "", fmt.Errorf(
+			"summary of %s exceeds %d tokens",
+			result.ToolName,
+			l.maxTokens,
+		)
+	}
+	return summary, nil
+}
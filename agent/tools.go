@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -118,6 +120,13 @@ func (a *Agent) executeSingleTool(
 		execCtx = tool.WithConfirmationHandler(execCtx, handler)
 	}
 
+	if eventChan := confirmationChanFromContext(ctx); eventChan != nil {
+		execCtx = tool.WithProgressEmitter(
+			execCtx,
+			progressEmitter(eventChan, tc),
+		)
+	}
+
 	start := time.Now()
 	resp, execErr := registry.Execute(execCtx, tool.Call{
 		ID:    tc.ID,
@@ -134,6 +143,8 @@ func (a *Agent) executeSingleTool(
 		Duration:   elapsed,
 	}
 
+	a.logToolExecution(ctx, result, execErr)
+
 	if execErr != nil {
 		if errors.Is(execErr, tool.ErrConfirmationRejected) {
 			result.Output = "Tool execution halted — confirmation rejected by user"
@@ -142,6 +153,8 @@ func (a *Agent) executeSingleTool(
 		}
 	} else {
 		result.Output = resp.Content
+		result.MIMEType = resp.MimeType
+		result.Images = responseImages(resp)
 	}
 
 	if result.IsError {
@@ -167,16 +180,18 @@ func (a *Agent) executeSingleTool(
 	if postResult.Action == HookModify {
 		result.Output = postResult.Output
 	}
+	result.Output = a.limitToolResult(ctx, result)
 
 	return result
 }
 
 func (a *Agent) executeTools(
 	ctx context.Context,
+	available []tool.BaseTool,
 	toolCalls []message.ToolCall,
 ) []ToolExecutionResult {
 	registry := tool.NewRegistry()
-	for _, t := range a.getToolsWithContext(ctx) {
+	for _, t := range available {
 		registry.Register(t)
 	}
 
@@ -223,3 +238,67 @@ func (a *Agent) executeTools(
 	wg.Wait()
 	return results
 }
+
+func (a *Agent) logToolExecution(
+	ctx context.Context,
+	result ToolExecutionResult,
+	execErr error,
+) {
+	attrs := []any{
+		slog.String("tool", result.ToolName),
+		slog.String("tool_call_id", result.ToolCallID),
+		slog.Duration("duration", result.Duration),
+		slog.Bool("is_error", result.IsError),
+	}
+	if execErr != nil {
+		attrs = append(attrs, slog.String("error", execErr.Error()))
+		a.logger.WarnContext(ctx, "tool execution failed", attrs...)
+		return
+	}
+	a.logger.DebugContext(ctx, "tool executed", attrs...)
+}
+
+// responseImages returns the images carried by a tool response: the image
+// parts of a multi-part response, or the data of an image or image-typed file
+// response. They are passed to providers that accept image tool results.
+func responseImages(resp tool.Response) []message.BinaryContent {
+	var images []message.BinaryContent
+	for _, part := range resp.Parts {
+		if isImage(part.Data, part.MimeType) {
+			images = append(images, message.BinaryContent{
+				MIMEType: part.MimeType,
+				Data:     part.Data,
+			})
+		}
+	}
+	if (resp.Type == tool.ResponseTypeImage ||
+		resp.Type == tool.ResponseTypeFile) &&
+		isImage(resp.Data, resp.MimeType) {
+		images = append(images, message.BinaryContent{
+			MIMEType: resp.MimeType,
+			Data:     resp.Data,
+		})
+	}
+	return images
+}
+
+func isImage(data []byte, mimeType string) bool {
+	return len(data) > 0 && strings.HasPrefix(mimeType, "image/")
+}
+
+func progressEmitter(
+	eventChan chan<- ChatEvent,
+	tc message.ToolCall,
+) tool.ProgressEmitter {
+	return tool.ProgressEmitterFunc(func(ctx context.Context, p tool.Progress) {
+		call := tc
+		select {
+		case eventChan <- ChatEvent{
+			Type:         types.EventToolProgress,
+			ToolCall:     &call,
+			ToolProgress: &p,
+		}:
+		case <-ctx.Done():
+		}
+	})
+}
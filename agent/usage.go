@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+var errNoUsageSession = errors.New("agent: usage tracking requires WithSession")
+
+// recordUsage appends the usage of one model call made with client to the
+// session's usage record. Failures are logged rather than failing the turn.
+func (a *Agent) recordUsage(
+	ctx context.Context,
+	client llm.LLM,
+	usage llm.TokenUsage,
+) {
+	if !a.trackUsage || a.sessionStore == nil {
+		return
+	}
+	m := client.Model()
+	if err := session.RecordUsage(ctx, a.sessionStore, a.sessionID, m.ID,
+		session.Usage{
+			InputTokens:         usage.InputTokens,
+			OutputTokens:        usage.OutputTokens,
+			CacheCreationTokens: usage.CacheCreationTokens,
+			CacheReadTokens:     usage.CacheReadTokens,
+			ReasoningTokens:     usage.ReasoningTokens,
+			Cost:                llm.EstimateCost(m, usage),
+			CacheSavings:        llm.EstimateCacheSavings(m, usage),
+		},
+	); err != nil {
+		a.logger.Warn("failed to record session usage",
+			slog.String("session_id", a.sessionID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// Usage returns the token usage and estimated cost summed over every model
+// call recorded for the agent's session with [WithUsageTracking], across
+// process restarts.
+func (a *Agent) Usage(ctx context.Context) (session.Usage, error) {
+	if a.sessionStore == nil {
+		return session.Usage{}, errNoUsageSession
+	}
+	return session.LoadUsage(ctx, a.sessionStore, a.sessionID)
+}
+
+// ResetUsage clears the usage recorded for the agent's session, e.g. at the
+// start of a new billing period. The conversation history is kept.
+func (a *Agent) ResetUsage(ctx context.Context) error {
+	if a.sessionStore == nil {
+		return errNoUsageSession
+	}
+	return session.ResetUsage(ctx, a.sessionStore, a.sessionID)
+}
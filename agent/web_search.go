@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"context"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+)
+
+// WithWebSearch enables the provider's native web search tool on every model
+// call the agent makes, so the model can search the web without an external
+// search API. Pass an [llm.WebSearch] to limit uses or domains. Anthropic and
+// the OpenAI Responses API client support it; other providers ignore it.
+// The sources the model cites are returned on [ChatResponse.Citations].
+func WithWebSearch(search ...llm.WebSearch) Option {
+	return func(a *Agent) {
+		var cfg llm.WebSearch
+		if len(search) > 0 {
+			cfg = search[0]
+		}
+		a.webSearch = &cfg
+	}
+}
+
+func (a *Agent) webSearchContext(ctx context.Context) context.Context {
+	if a.webSearch == nil {
+		return ctx
+	}
+	return llm.ContextWithWebSearch(ctx, *a.webSearch)
+}
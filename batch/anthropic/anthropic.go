@@ -15,6 +15,7 @@ import (
 	"github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
 	"github.com/joakimcarlsson/ai/tool"
 )
 
@@ -26,6 +27,11 @@ type Options struct {
 	progressCallback batch.ProgressCallback
 	pollInterval     time.Duration
 	timeout          *time.Duration
+	baseURL          string
+	extraHeaders     map[string]string
+	temperature      *float64
+	disableCache     bool
+	outputSchema     *schema.StructuredOutputInfo
 }
 
 // Option configures Options.
@@ -67,6 +73,42 @@ func WithTimeout(
 	return func(o *Options) { o.timeout = &timeout }
 }
 
+// WithBaseURL sets a custom API endpoint, such as a proxy in front of the
+// Anthropic API.
+func WithBaseURL(
+	baseURL string,
+) Option {
+	return func(o *Options) { o.baseURL = baseURL }
+}
+
+// WithExtraHeaders adds custom HTTP headers to batch API requests, such as
+// an anthropic-beta flag for a feature still in beta.
+func WithExtraHeaders(headers map[string]string) Option {
+	return func(o *Options) { o.extraHeaders = headers }
+}
+
+// WithTemperature controls randomness for every request in the batch.
+func WithTemperature(
+	temperature float64,
+) Option {
+	return func(o *Options) { o.temperature = &temperature }
+}
+
+// WithDisableCache disables prompt caching. By default the last system
+// message and the last tool of each request get a cache breakpoint, so a
+// prompt shared by the whole batch is billed at the cache-read rate on top
+// of the batch discount.
+func WithDisableCache() Option {
+	return func(o *Options) { o.disableCache = true }
+}
+
+// WithOutputSchema constrains every response in the batch to the JSON schema,
+// like SendMessagesWithStructuredOutput on the Anthropic LLM client. The JSON
+// is returned in each result's ChatResponse.StructuredOutput.
+func WithOutputSchema(outputSchema *schema.StructuredOutputInfo) Option {
+	return func(o *Options) { o.outputSchema = outputSchema }
+}
+
 // Processor implements [batch.Processor] against the Anthropic Message Batches API.
 type Processor struct {
 	options Options
@@ -87,6 +129,12 @@ func NewProcessor(opts ...Option) batch.Processor {
 	if options.apiKey != "" {
 		clientOpts = append(clientOpts, option.WithAPIKey(options.apiKey))
 	}
+	if options.baseURL != "" {
+		clientOpts = append(clientOpts, option.WithBaseURL(options.baseURL))
+	}
+	for key, value := range options.extraHeaders {
+		clientOpts = append(clientOpts, option.WithHeader(key, value))
+	}
 
 	return &Processor{
 		options: options,
@@ -120,32 +168,60 @@ func (p *Processor) Process(
 		results[i] = batch.Result{ID: r.ID, Index: i}
 	}
 
+	jobID, err := p.submitJob(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := p.pollUntilDone(ctx, jobID, len(requests))
+	if err != nil {
+		return nil, fmt.Errorf("batch: anthropic batch polling failed: %w", err)
+	}
+
+	resultFor := func(id string) *batch.Result {
+		idx, ok := idxMap[id]
+		if !ok {
+			return nil
+		}
+		return &results[idx]
+	}
+	if err := p.retrieveResults(ctx, job.ID, resultFor); err != nil {
+		return nil, fmt.Errorf(
+			"batch: failed to retrieve anthropic results: %w",
+			err,
+		)
+	}
+
+	completed, failed := 0, 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		} else if r.ChatResponse != nil {
+			completed++
+		}
+	}
+
+	return &batch.Response{
+		Results:   results,
+		Completed: completed,
+		Failed:    failed,
+		Total:     len(requests),
+	}, nil
+}
+
+// submitJob creates a message batch for requests and returns its ID.
+func (p *Processor) submitJob(
+	ctx context.Context,
+	requests []batch.Request,
+) (string, error) {
 	batchRequests := make(
 		[]anthropicsdk.MessageBatchNewParamsRequest,
 		len(requests),
 	)
 	for i, req := range requests {
-		msgs, system := convertMessagesToAnthropic(req.Messages)
-		tools := convertToolsToAnthropic(req.Tools)
-
-		params := anthropicsdk.MessageBatchNewParamsRequestParams{
-			MaxTokens: p.options.maxTokens,
-			Messages:  msgs,
-			Model:     anthropicsdk.Model(p.options.model.APIModel),
-			Tools:     tools,
-		}
-
-		if len(system) > 0 {
-			systemBlocks := make([]anthropicsdk.TextBlockParam, len(system))
-			for j, s := range system {
-				systemBlocks[j] = anthropicsdk.TextBlockParam{Text: s}
-			}
-			params.System = systemBlocks
-		}
-
 		batchRequests[i] = anthropicsdk.MessageBatchNewParamsRequest{
 			CustomID: req.ID,
-			Params:   params,
+			Params:   p.requestParams(req),
 		}
 	}
 
@@ -163,39 +239,114 @@ func (p *Processor) Process(
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf(
+		return "", fmt.Errorf(
 			"batch: failed to create anthropic batch: %w",
 			err,
 		)
 	}
+	return job.ID, nil
+}
+
+// requestParams converts req into the parameters of one message in the
+// batch, applying the processor's sampling, caching, and output options.
+func (p *Processor) requestParams(
+	req batch.Request,
+) anthropicsdk.MessageBatchNewParamsRequestParams {
+	msgs, system := convertMessagesToAnthropic(req.Messages)
+	tools := convertToolsToAnthropic(req.Tools)
+
+	params := anthropicsdk.MessageBatchNewParamsRequestParams{
+		MaxTokens: p.options.maxTokens,
+		Messages:  msgs,
+		Model:     anthropicsdk.Model(p.options.model.APIModel),
+		Tools:     tools,
+	}
+	if p.options.temperature != nil {
+		params.Temperature = anthropicsdk.Float(*p.options.temperature)
+	}
+	if p.options.outputSchema != nil {
+		params.OutputConfig = anthropicsdk.OutputConfigParam{
+			Format: anthropicsdk.JSONOutputFormatParam{
+				Schema: schema.CloseObjects(
+					p.options.outputSchema.JSONSchema(),
+				),
+			},
+		}
+	}
 
-	job, err = p.pollUntilDone(ctx, job.ID, len(requests))
+	if len(system) > 0 {
+		systemBlocks := make([]anthropicsdk.TextBlockParam, len(system))
+		for j, s := range system {
+			systemBlocks[j] = anthropicsdk.TextBlockParam{Text: s}
+		}
+		if !p.options.disableCache {
+			systemBlocks[len(system)-1].CacheControl = ephemeral()
+		}
+		params.System = systemBlocks
+	}
+	if len(tools) > 0 && !p.options.disableCache {
+		tools[len(tools)-1].OfTool.CacheControl = ephemeral()
+	}
+
+	return params
+}
+
+func ephemeral() anthropicsdk.CacheControlEphemeralParam {
+	return anthropicsdk.CacheControlEphemeralParam{Type: "ephemeral"}
+}
+
+// SubmitBatch creates a message batch for requests and returns its ID without
+// waiting for it to finish. Only chat requests are supported. Blank request
+// IDs are filled in with [batch.AssignIDs]. Implements [batch.Submitter].
+func (p *Processor) SubmitBatch(
+	ctx context.Context,
+	requests []batch.Request,
+) (string, error) {
+	if len(requests) == 0 {
+		return "", fmt.Errorf("batch: no requests to submit")
+	}
+	reqType, err := batch.SingleType(requests)
 	if err != nil {
-		return nil, fmt.Errorf("batch: anthropic batch polling failed: %w", err)
+		return "", err
+	}
+	if reqType != batch.RequestTypeChat {
+		return "", fmt.Errorf(
+			"batch: anthropic native batch only supports chat requests",
+		)
 	}
+	batch.AssignIDs(requests)
+	return p.submitJob(ctx, requests)
+}
 
-	if err := p.retrieveResults(ctx, job.ID, results, idxMap); err != nil {
+// GetBatchResults retrieves the results of a batch created with
+// [Processor.SubmitBatch]. It returns an error wrapping
+// [batch.ErrBatchPending] until processing has ended. Implements
+// [batch.Submitter].
+func (p *Processor) GetBatchResults(
+	ctx context.Context,
+	batchID string,
+) (*batch.Response, error) {
+	job, err := p.client.Messages.Batches.Get(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch: anthropic get %s: %w", batchID, err)
+	}
+	if job.ProcessingStatus != anthropicsdk.MessageBatchProcessingStatusEnded {
 		return nil, fmt.Errorf(
-			"batch: failed to retrieve anthropic results: %w",
-			err,
+			"%w: %s is %s",
+			batch.ErrBatchPending,
+			batchID,
+			job.ProcessingStatus,
 		)
 	}
 
-	completed, failed := 0, 0
-	for _, r := range results {
-		if r.Err != nil {
-			failed++
-		} else if r.ChatResponse != nil {
-			completed++
-		}
+	var collector batch.Collector
+	if err := p.retrieveResults(ctx, batchID, collector.Result); err != nil {
+		return nil, fmt.Errorf(
+			"batch: failed to retrieve anthropic results: %w",
+			err,
+		)
 	}
-
-	return &batch.Response{
-		Results:   results,
-		Completed: completed,
-		Failed:    failed,
-		Total:     len(requests),
-	}, nil
+	return collector.Response(), nil
 }
 
 func (p *Processor) pollUntilDone(
@@ -242,8 +393,7 @@ func (p *Processor) pollUntilDone(
 func (p *Processor) retrieveResults(
 	ctx context.Context,
 	batchID string,
-	results []batch.Result,
-	idxMap map[string]int,
+	resultFor func(customID string) *batch.Result,
 ) error {
 	stream := p.client.Messages.Batches.ResultsStreaming(ctx, batchID)
 	defer stream.Close()
@@ -251,24 +401,29 @@ func (p *Processor) retrieveResults(
 	for stream.Next() {
 		entry := stream.Current()
 
-		idx, ok := idxMap[entry.CustomID]
-		if !ok {
+		result := resultFor(entry.CustomID)
+		if result == nil {
 			continue
 		}
 
 		switch entry.Result.Type {
 		case "succeeded":
 			succeeded := entry.Result.AsSucceeded()
-			results[idx].ChatResponse = convertAnthropicMessage(
+			result.ChatResponse = convertAnthropicMessage(
 				succeeded.Message,
 			)
+			if p.options.outputSchema != nil {
+				content := result.ChatResponse.Content
+				result.ChatResponse.StructuredOutput = &content
+				result.ChatResponse.UsedNativeStructuredOutput = true
+			}
 		case "errored":
 			errored := entry.Result.AsErrored()
-			results[idx].Err = fmt.Errorf("%s", errored.Error.Error.Message)
+			result.Err = fmt.Errorf("%s", errored.Error.Error.Message)
 		case "canceled":
-			results[idx].Err = fmt.Errorf("request was canceled")
+			result.Err = fmt.Errorf("request was canceled")
 		case "expired":
-			results[idx].Err = fmt.Errorf("request expired")
+			result.Err = fmt.Errorf("request expired")
 		}
 	}
 
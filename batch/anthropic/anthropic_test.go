@@ -0,0 +1,175 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/batch"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+)
+
+const resultLine = `{"custom_id":"doc-1","result":{"type":"succeeded",` +
+	`"message":{"id":"msg_1","type":"message","role":"assistant",` +
+	`"model":"claude","content":[{"type":"text",` +
+	`"text":"{\"label\":\"invoice\"}"}],` +
+	`"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":5,` +
+	`"cache_read_input_tokens":8}}}}` + "\n"
+
+type batchServer struct {
+	body   map[string]any
+	header http.Header
+}
+
+func (s *batchServer) handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost &&
+		r.URL.Path == "/v1/messages/batches":
+		s.header = r.Header.Clone()
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &s.body)
+		_, _ = io.WriteString(w, `{"id":"msgbatch_1","type":"message_batch",`+
+			`"processing_status":"in_progress"}`)
+	case r.URL.Path == "/v1/messages/batches/msgbatch_1":
+		_, _ = io.WriteString(w, `{"id":"msgbatch_1","type":"message_batch",`+
+			`"processing_status":"ended"}`)
+	case r.URL.Path == "/v1/messages/batches/msgbatch_1/results":
+		w.Header().Set("Content-Type", "application/x-jsonl")
+		_, _ = io.WriteString(w, resultLine)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func classifyRequests() []batch.Request {
+	return []batch.Request{{
+		ID:   "doc-1",
+		Type: batch.RequestTypeChat,
+		Messages: []message.Message{
+			message.NewSystemMessage("Classify the document."),
+			message.NewUserMessage("Invoice #42, due in 30 days."),
+		},
+	}}
+}
+
+func firstParams(t *testing.T, body map[string]any) map[string]any {
+	t.Helper()
+	requests, _ := body["requests"].([]any)
+	if len(requests) != 1 {
+		t.Fatalf("requests = %v, want one", body["requests"])
+	}
+	params, _ := requests[0].(map[string]any)["params"].(map[string]any)
+	return params
+}
+
+// TestSubmitBatchRequest confirms each batched message carries the options
+// and cache breakpoints, and that no beta header is sent for the generally
+// available batch, caching, and structured output features.
+func TestSubmitBatchRequest(t *testing.T) {
+	srv := &batchServer{}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handler))
+	defer ts.Close()
+
+	proc := NewProcessor(
+		WithAPIKey("test-key"),
+		WithBaseURL(ts.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+		WithTemperature(0),
+		WithExtraHeaders(map[string]string{"X-Team": "docs"}),
+		WithOutputSchema(&schema.StructuredOutputInfo{
+			Name: "label",
+			Parameters: map[string]any{
+				"label": map[string]any{"type": "string"},
+			},
+			Required: []string{"label"},
+		}),
+	).(*Processor)
+
+	id, err := proc.SubmitBatch(context.Background(), classifyRequests())
+	if err != nil {
+		t.Fatalf("SubmitBatch: %v", err)
+	}
+	if id != "msgbatch_1" {
+		t.Errorf("batch id = %q", id)
+	}
+
+	if beta := srv.header.Get("anthropic-beta"); beta != "" {
+		t.Errorf("anthropic-beta = %q, want none", beta)
+	}
+	if got := srv.header.Get("X-Team"); got != "docs" {
+		t.Errorf("extra header = %q", got)
+	}
+
+	params := firstParams(t, srv.body)
+	if params["temperature"] != float64(0) {
+		t.Errorf("temperature = %v, want 0", params["temperature"])
+	}
+	system, _ := params["system"].([]any)
+	if len(system) != 1 ||
+		system[0].(map[string]any)["cache_control"] == nil {
+		t.Errorf("system prompt has no cache breakpoint: %v", system)
+	}
+	config, _ := params["output_config"].(map[string]any)
+	format, _ := config["format"].(map[string]any)
+	if format["type"] != "json_schema" || format["schema"] == nil {
+		t.Errorf("output_config.format = %v", format)
+	}
+}
+
+func TestDisableCache(t *testing.T) {
+	srv := &batchServer{}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handler))
+	defer ts.Close()
+
+	proc := NewProcessor(
+		WithBaseURL(ts.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+		WithDisableCache(),
+	).(*Processor)
+	if _, err := proc.SubmitBatch(
+		context.Background(),
+		classifyRequests(),
+	); err != nil {
+		t.Fatalf("SubmitBatch: %v", err)
+	}
+
+	system, _ := firstParams(t, srv.body)["system"].([]any)
+	if len(system) != 1 ||
+		system[0].(map[string]any)["cache_control"] != nil {
+		t.Errorf("cache breakpoint sent with caching disabled: %v", system)
+	}
+}
+
+func TestGetBatchResultsStructuredOutput(t *testing.T) {
+	srv := &batchServer{}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handler))
+	defer ts.Close()
+
+	proc := NewProcessor(
+		WithBaseURL(ts.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+		WithOutputSchema(&schema.StructuredOutputInfo{Name: "label"}),
+	).(*Processor)
+
+	resp, err := proc.GetBatchResults(context.Background(), "msgbatch_1")
+	if err != nil {
+		t.Fatalf("GetBatchResults: %v", err)
+	}
+	if resp.Completed != 1 || resp.Results[0].ID != "doc-1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	chat := resp.Results[0].ChatResponse
+	if chat.StructuredOutput == nil ||
+		*chat.StructuredOutput != `{"label":"invoice"}` {
+		t.Errorf("StructuredOutput = %v", chat.StructuredOutput)
+	}
+	if chat.Usage.CacheReadTokens != 8 {
+		t.Errorf("CacheReadTokens = %d, want 8", chat.Usage.CacheReadTokens)
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/joakimcarlsson/ai/llm v0.5.0
 	github.com/joakimcarlsson/ai/message v0.4.0
 	github.com/joakimcarlsson/ai/model v0.6.0
+	github.com/joakimcarlsson/ai/schema v0.2.0
 	github.com/joakimcarlsson/ai/tool v0.1.2
 )
 
@@ -23,7 +24,7 @@ require (
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
 	github.com/invopop/jsonschema v0.14.0 // indirect
 	github.com/joakimcarlsson/ai/embeddings v0.2.3 // indirect
-	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
 	github.com/joakimcarlsson/ai/types v0.1.0 // indirect
 	github.com/modelcontextprotocol/go-sdk v1.6.1 // indirect
@@ -66,6 +67,7 @@ replace (
 	github.com/joakimcarlsson/ai/embeddings => ../../embeddings
 	github.com/joakimcarlsson/ai/llm => ../../llm
 	github.com/joakimcarlsson/ai/message => ../../message
+	github.com/joakimcarlsson/ai/metrics => ../../metrics
 	github.com/joakimcarlsson/ai/model => ../../model
 	github.com/joakimcarlsson/ai/schema => ../../schema
 	github.com/joakimcarlsson/ai/tool => ../../tool
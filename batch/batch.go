@@ -41,6 +41,17 @@ var ErrNoLLMClient = errors.New("batch: no LLM client configured")
 // ErrNoEmbeddingClient is returned when an embedding request is submitted without an embedding client.
 var ErrNoEmbeddingClient = errors.New("batch: no embedding client configured")
 
+// ErrBatchPending is returned by [Submitter.GetBatchResults] while the job is
+// still running. Poll again later.
+var ErrBatchPending = errors.New("batch: job still in progress")
+
+// ErrMixedRequestTypes is returned by [Submitter.SubmitBatch] when requests
+// mix chat and embedding types, which provider batch APIs run as separate
+// jobs. Submit each type on its own.
+var ErrMixedRequestTypes = errors.New(
+	"batch: a submitted job must contain a single request type",
+)
+
 // RequestType identifies whether a batch request is a chat completion or embedding.
 type RequestType int
 
@@ -82,6 +93,17 @@ type Processor interface {
 	ProcessAsync(ctx context.Context, requests []Request) (<-chan Event, error)
 }
 
+// Submitter is implemented by processors backed by a provider batch API. It
+// splits [Processor.Process] into a submit step that returns as soon as the
+// job is accepted and a retrieval step, so a job submitted in the evening can
+// be collected the next morning, possibly by another process. Results carry
+// the request IDs given to SubmitBatch; Index is the position in the
+// provider's output, not in the submitted slice, so match results by ID.
+type Submitter interface {
+	SubmitBatch(ctx context.Context, requests []Request) (string, error)
+	GetBatchResults(ctx context.Context, batchID string) (*Response, error)
+}
+
 // EventType identifies the kind of event emitted during batch processing.
 type EventType string
 
@@ -122,6 +144,62 @@ func AssignIDs(requests []Request) {
 	}
 }
 
+// SingleType returns the type shared by every request, or
+// [ErrMixedRequestTypes]. Vendor implementations of [Submitter] call it before
+// submitting.
+func SingleType(requests []Request) (RequestType, error) {
+	if len(requests) == 0 {
+		return RequestTypeChat, nil
+	}
+	first := requests[0].Type
+	for _, r := range requests[1:] {
+		if r.Type != first {
+			return first, ErrMixedRequestTypes
+		}
+	}
+	return first, nil
+}
+
+// Collector assembles the results of a job retrieved by ID, when the original
+// requests are not at hand. Results are added in the order they are first
+// seen.
+type Collector struct {
+	results []Result
+	index   map[string]int
+}
+
+// Result returns the result for the request with the given ID, adding an
+// empty one when the ID has not been seen yet. The pointer is valid until the
+// next call.
+func (c *Collector) Result(id string) *Result {
+	if c.index == nil {
+		c.index = make(map[string]int)
+	}
+	i, ok := c.index[id]
+	if !ok {
+		i = len(c.results)
+		c.index[id] = i
+		c.results = append(c.results, Result{ID: id, Index: i})
+	}
+	return &c.results[i]
+}
+
+// Response returns the collected results with their counts.
+func (c *Collector) Response() *Response {
+	resp := &Response{Results: c.results, Total: len(c.results)}
+	if resp.Results == nil {
+		resp.Results = []Result{}
+	}
+	for _, r := range c.results {
+		if r.Err != nil {
+			resp.Failed++
+		} else if r.ChatResponse != nil || r.EmbedResponse != nil {
+			resp.Completed++
+		}
+	}
+	return resp
+}
+
 // SplitByType separates a slice of Requests into chat and embedding sub-slices.
 // Vendor implementations use this when their native batch APIs require
 // per-endpoint submission.
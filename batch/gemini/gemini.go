@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -195,6 +196,32 @@ func (p *Processor) processChatBatch(
 	results []batch.Result,
 	idxMap map[int]int,
 ) error {
+	jobName, err := p.submitChatJob(ctx, requests, len(results))
+	if err != nil {
+		return err
+	}
+
+	job, err := p.pollUntilDone(ctx, jobName, len(results))
+	if err != nil {
+		return err
+	}
+
+	collectChatResponses(job, func(i int) *batch.Result {
+		globalIdx, ok := idxMap[i]
+		if !ok {
+			return nil
+		}
+		return &results[globalIdx]
+	})
+
+	return nil
+}
+
+func (p *Processor) submitChatJob(
+	ctx context.Context,
+	requests []batch.Request,
+	total int,
+) (string, error) {
 	inlined := make([]*genai.InlinedRequest, len(requests))
 	for i, req := range requests {
 		contents, system := convertMessagesToGemini(req.Messages)
@@ -218,7 +245,7 @@ func (p *Processor) processChatBatch(
 
 	if p.options.progressCallback != nil {
 		p.options.progressCallback(batch.Progress{
-			Total:  len(results),
+			Total:  total,
 			Status: "submitting",
 		})
 	}
@@ -229,35 +256,105 @@ func (p *Processor) processChatBatch(
 		&genai.CreateBatchJobConfig{},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create batch job: %w", err)
+		return "", fmt.Errorf("failed to create batch job: %w", err)
 	}
+	return job.Name, nil
+}
 
-	job, err = p.pollUntilDone(ctx, job.Name, len(results))
+// collectChatResponses stores each inlined response in the result returned
+// by resultFor for its position; a nil result skips the response.
+func collectChatResponses(
+	job *genai.BatchJob,
+	resultFor func(i int) *batch.Result,
+) {
+	if job.Dest == nil {
+		return
+	}
+	for i, resp := range job.Dest.InlinedResponses {
+		result := resultFor(i)
+		if result == nil {
+			continue
+		}
+
+		if resp.Error != nil {
+			result.Err = fmt.Errorf("%s", resp.Error.Message)
+			continue
+		}
+
+		if resp.Response != nil {
+			result.ChatResponse = convertGeminiResponse(resp.Response)
+		}
+	}
+}
+
+// SubmitBatch creates a Gemini batch job for chat requests and returns its
+// name without waiting for it to finish. Gemini returns inlined responses in
+// submission order without the request IDs, so [Processor.GetBatchResults]
+// labels them req_0, req_1, ... by position; keep the submitted slice to map
+// explicit IDs back. Embedding requests are not supported, since their texts
+// are flattened into one response per text. Implements [batch.Submitter].
+func (p *Processor) SubmitBatch(
+	ctx context.Context,
+	requests []batch.Request,
+) (string, error) {
+	if len(requests) == 0 {
+		return "", fmt.Errorf("batch: no requests to submit")
+	}
+	reqType, err := batch.SingleType(requests)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if reqType != batch.RequestTypeChat {
+		return "", fmt.Errorf(
+			"batch: gemini native batch submit only supports chat requests",
+		)
 	}
+	batch.AssignIDs(requests)
 
-	if job.Dest != nil && len(job.Dest.InlinedResponses) > 0 {
-		for i, resp := range job.Dest.InlinedResponses {
-			globalIdx, ok := idxMap[i]
-			if !ok {
-				continue
-			}
+	name, err := p.submitChatJob(ctx, requests, len(requests))
+	if err != nil {
+		return "", fmt.Errorf("batch: gemini chat batch failed: %w", err)
+	}
+	return name, nil
+}
 
-			if resp.Error != nil {
-				results[globalIdx].Err = fmt.Errorf("%s", resp.Error.Message)
-				continue
-			}
+// GetBatchResults returns the results of a job created by
+// [Processor.SubmitBatch], or [batch.ErrBatchPending] while it is still
+// running. Results are identified by position; see [Processor.SubmitBatch].
+// Implements [batch.Submitter].
+func (p *Processor) GetBatchResults(
+	ctx context.Context,
+	batchID string,
+) (*batch.Response, error) {
+	job, err := p.client.Batches.Get(ctx, batchID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("batch: gemini get batch: %w", err)
+	}
 
-			if resp.Response != nil {
-				results[globalIdx].ChatResponse = convertGeminiResponse(
-					resp.Response,
-				)
-			}
+	switch job.State {
+	case genai.JobStateSucceeded, genai.JobStatePartiallySucceeded:
+	case genai.JobStateFailed:
+		msg := "batch job failed"
+		if job.Error != nil {
+			msg = job.Error.Message
 		}
+		return nil, fmt.Errorf("batch: gemini %s: %s", batchID, msg)
+	case genai.JobStateCancelled, genai.JobStateExpired:
+		return nil, fmt.Errorf("batch: gemini %s is %s", batchID, job.State)
+	default:
+		return nil, fmt.Errorf(
+			"%w: %s is %s",
+			batch.ErrBatchPending,
+			batchID,
+			job.State,
+		)
 	}
 
-	return nil
+	var collector batch.Collector
+	collectChatResponses(job, func(i int) *batch.Result {
+		return collector.Result("req_" + strconv.Itoa(i))
+	})
+	return collector.Response(), nil
 }
 
 func (p *Processor) processEmbeddingBatch(
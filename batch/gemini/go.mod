@@ -29,6 +29,7 @@ require (
 	github.com/googleapis/gax-go/v2 v2.22.0 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
 	github.com/joakimcarlsson/ai/types v0.1.0 // indirect
@@ -67,6 +68,7 @@ replace (
 	github.com/joakimcarlsson/ai/embeddings => ../../embeddings
 	github.com/joakimcarlsson/ai/llm => ../../llm
 	github.com/joakimcarlsson/ai/message => ../../message
+	github.com/joakimcarlsson/ai/metrics => ../../metrics
 	github.com/joakimcarlsson/ai/model => ../../model
 	github.com/joakimcarlsson/ai/schema => ../../schema
 	github.com/joakimcarlsson/ai/tool => ../../tool
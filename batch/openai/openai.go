@@ -211,6 +211,33 @@ func (p *Processor) processBatch(
 	results []batch.Result,
 	idxMap map[string]int,
 ) error {
+	jobID, err := p.submitJob(ctx, requests, endpoint, len(results))
+	if err != nil {
+		return err
+	}
+
+	job, err := p.pollUntilDone(ctx, jobID, len(results))
+	if err != nil {
+		return fmt.Errorf("batch polling failed: %w", err)
+	}
+
+	return p.collectJob(ctx, job, endpoint, func(id string) *batch.Result {
+		idx, ok := idxMap[id]
+		if !ok {
+			return nil
+		}
+		return &results[idx]
+	})
+}
+
+// submitJob uploads requests as a JSONL file and creates a batch job for
+// endpoint, returning the job ID.
+func (p *Processor) submitJob(
+	ctx context.Context,
+	requests []batch.Request,
+	endpoint openaisdk.BatchNewParamsEndpoint,
+	total int,
+) (string, error) {
 	apiModel := p.options.model.APIModel
 	if endpoint == openaisdk.BatchNewParamsEndpointV1Embeddings &&
 		p.options.embeddingModel.APIModel != "" {
@@ -219,12 +246,12 @@ func (p *Processor) processBatch(
 
 	jsonlData, err := p.buildJSONL(requests, endpoint, apiModel)
 	if err != nil {
-		return fmt.Errorf("failed to build JSONL: %w", err)
+		return "", fmt.Errorf("failed to build JSONL: %w", err)
 	}
 
 	if p.options.progressCallback != nil {
 		p.options.progressCallback(batch.Progress{
-			Total:  len(results),
+			Total:  total,
 			Status: "uploading",
 		})
 	}
@@ -234,7 +261,7 @@ func (p *Processor) processBatch(
 		Purpose: openaisdk.FilePurposeBatch,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upload batch file: %w", err)
+		return "", fmt.Errorf("failed to upload batch file: %w", err)
 	}
 
 	job, err := p.client.Batches.New(ctx, openaisdk.BatchNewParams{
@@ -243,33 +270,103 @@ func (p *Processor) processBatch(
 		CompletionWindow: openaisdk.BatchNewParamsCompletionWindow24h,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create batch: %w", err)
-	}
-
-	job, err = p.pollUntilDone(ctx, job.ID, len(results))
-	if err != nil {
-		return fmt.Errorf("batch polling failed: %w", err)
+		return "", fmt.Errorf("failed to create batch: %w", err)
 	}
+	return job.ID, nil
+}
 
+// collectJob downloads the output and error files of a finished job and
+// records each line on the result returned by resultFor, which may return
+// nil to skip an unknown ID.
+func (p *Processor) collectJob(
+	ctx context.Context,
+	job *openaisdk.Batch,
+	endpoint openaisdk.BatchNewParamsEndpoint,
+	resultFor func(customID string) *batch.Result,
+) error {
 	if job.OutputFileID != "" {
 		if err := p.parseOutputFile(
 			ctx,
 			job.OutputFileID,
 			endpoint,
-			results,
-			idxMap,
+			resultFor,
 		); err != nil {
 			return fmt.Errorf("failed to parse output file: %w", err)
 		}
 	}
 
 	if job.ErrorFileID != "" {
-		p.parseErrorFile(ctx, job.ErrorFileID, results, idxMap)
+		p.parseErrorFile(ctx, job.ErrorFileID, resultFor)
 	}
 
 	return nil
 }
 
+// SubmitBatch uploads requests as a batch job and returns its ID without
+// waiting for the job to finish. All requests must share one type. Blank
+// request IDs are filled in with [batch.AssignIDs]. Implements
+// [batch.Submitter].
+func (p *Processor) SubmitBatch(
+	ctx context.Context,
+	requests []batch.Request,
+) (string, error) {
+	if len(requests) == 0 {
+		return "", fmt.Errorf("batch: no requests to submit")
+	}
+	reqType, err := batch.SingleType(requests)
+	if err != nil {
+		return "", err
+	}
+	batch.AssignIDs(requests)
+
+	endpoint := openaisdk.BatchNewParamsEndpointV1ChatCompletions
+	if reqType == batch.RequestTypeEmbedding {
+		endpoint = openaisdk.BatchNewParamsEndpointV1Embeddings
+	}
+	jobID, err := p.submitJob(ctx, requests, endpoint, len(requests))
+	if err != nil {
+		return "", fmt.Errorf("batch: openai submit failed: %w", err)
+	}
+	return jobID, nil
+}
+
+// GetBatchResults retrieves the results of a job created with
+// [Processor.SubmitBatch]. It returns an error wrapping
+// [batch.ErrBatchPending] while the job is running. Expired and cancelled
+// jobs return the results that completed before the job stopped. Implements
+// [batch.Submitter].
+func (p *Processor) GetBatchResults(
+	ctx context.Context,
+	batchID string,
+) (*batch.Response, error) {
+	job, err := p.client.Batches.Get(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch: openai get %s: %w", batchID, err)
+	}
+
+	switch job.Status {
+	case openaisdk.BatchStatusCompleted,
+		openaisdk.BatchStatusExpired,
+		openaisdk.BatchStatusCancelled:
+	case openaisdk.BatchStatusFailed:
+		return nil, fmt.Errorf("batch: openai batch failed: %s", batchID)
+	default:
+		return nil, fmt.Errorf(
+			"%w: %s is %s",
+			batch.ErrBatchPending,
+			batchID,
+			job.Status,
+		)
+	}
+
+	var collector batch.Collector
+	endpoint := openaisdk.BatchNewParamsEndpoint(job.Endpoint)
+	if err := p.collectJob(ctx, job, endpoint, collector.Result); err != nil {
+		return nil, fmt.Errorf("batch: openai results %s: %w", batchID, err)
+	}
+	return collector.Response(), nil
+}
+
 func (p *Processor) buildJSONL(
 	requests []batch.Request,
 	endpoint openaisdk.BatchNewParamsEndpoint,
@@ -386,8 +483,7 @@ func (p *Processor) parseOutputFile(
 	ctx context.Context,
 	fileID string,
 	endpoint openaisdk.BatchNewParamsEndpoint,
-	results []batch.Result,
-	idxMap map[string]int,
+	resultFor func(customID string) *batch.Result,
 ) error {
 	resp, err := p.client.Files.Content(ctx, fileID)
 	if err != nil {
@@ -407,13 +503,13 @@ func (p *Processor) parseOutputFile(
 			continue
 		}
 
-		idx, ok := idxMap[line.CustomID]
-		if !ok {
+		result := resultFor(line.CustomID)
+		if result == nil {
 			continue
 		}
 
 		if line.Error != nil {
-			results[idx].Err = fmt.Errorf(
+			result.Err = fmt.Errorf(
 				"%s: %s",
 				line.Error.Code,
 				line.Error.Message,
@@ -422,7 +518,7 @@ func (p *Processor) parseOutputFile(
 		}
 
 		if line.Response.StatusCode != 200 {
-			results[idx].Err = fmt.Errorf(
+			result.Err = fmt.Errorf(
 				"request failed with status %d",
 				line.Response.StatusCode,
 			)
@@ -431,9 +527,9 @@ func (p *Processor) parseOutputFile(
 
 		switch endpoint {
 		case openaisdk.BatchNewParamsEndpointV1ChatCompletions:
-			results[idx].ChatResponse = parseChatCompletion(line.Response.Body)
+			result.ChatResponse = parseChatCompletion(line.Response.Body)
 		case openaisdk.BatchNewParamsEndpointV1Embeddings:
-			results[idx].EmbedResponse = parseEmbeddingResponse(
+			result.EmbedResponse = parseEmbeddingResponse(
 				line.Response.Body,
 			)
 		}
@@ -445,8 +541,7 @@ func (p *Processor) parseOutputFile(
 func (p *Processor) parseErrorFile(
 	ctx context.Context,
 	fileID string,
-	results []batch.Result,
-	idxMap map[string]int,
+	resultFor func(customID string) *batch.Result,
 ) {
 	resp, err := p.client.Files.Content(ctx, fileID)
 	if err != nil {
@@ -462,17 +557,26 @@ func (p *Processor) parseErrorFile(
 			continue
 		}
 
-		idx, ok := idxMap[line.CustomID]
-		if !ok {
+		result := resultFor(line.CustomID)
+		if result == nil {
 			continue
 		}
 
-		if results[idx].Err == nil && line.Error != nil {
-			results[idx].Err = fmt.Errorf(
+		if result.Err != nil {
+			continue
+		}
+		switch {
+		case line.Error != nil:
+			result.Err = fmt.Errorf(
 				"%s: %s",
 				line.Error.Code,
 				line.Error.Message,
 			)
+		case line.Response.StatusCode != 200:
+			result.Err = fmt.Errorf(
+				"request failed with status %d",
+				line.Response.StatusCode,
+			)
 		}
 	}
 }
@@ -0,0 +1,256 @@
+// Package completion provides a unified interface for plain text completion:
+// the model continues a prompt, with no chat roles and no suffix.
+//
+// Some models and servers only expose a completions-style endpoint, such as
+// instruct and base models behind OpenAI-compatible servers (vLLM,
+// llama.cpp, Ollama) or OpenAI's gpt-3.5-turbo-instruct. For chat use the llm
+// package, and for code completion around a cursor use the fim package.
+//
+// This package defines the [Completion] interface and the data types that
+// flow through it. Concrete vendor implementations live in subpackages
+// (completion/openai); each subpackage exports its own NewCompletion
+// constructor that returns a tracing-wrapped client implementing the
+// interface.
+//
+// Example usage:
+//
+//	import (
+//		"github.com/joakimcarlsson/ai/completion"
+//		"github.com/joakimcarlsson/ai/completion/openai"
+//	)
+//
+//	client := openai.NewCompletion(
+//		openai.WithBaseURL("http://localhost:8000/v1"),
+//		openai.WithModel(model.NewCustomModel(model.WithAPIModel("my-model"))),
+//	)
+//
+//	resp, err := client.Complete(ctx, completion.Request{
+//		Prompt: "Once upon a time",
+//	})
+package completion
+
+import (
+	"context"
+	"time"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tracing"
+)
+
+// FinishReason indicates why the model stopped generating tokens.
+type FinishReason string
+
+const (
+	// FinishReasonStop indicates the model completed naturally or hit a stop sequence.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonLength indicates the model hit the maximum token limit.
+	FinishReasonLength FinishReason = "length"
+	// FinishReasonUnknown indicates an unknown or unrecognized finish reason.
+	FinishReasonUnknown FinishReason = "unknown"
+)
+
+// Usage tracks the token consumption for a completion request.
+type Usage struct {
+	// InputTokens is the number of tokens in the prompt.
+	InputTokens int64
+	// OutputTokens is the number of tokens generated by the model.
+	OutputTokens int64
+}
+
+// Request contains the parameters for a completion request.
+type Request struct {
+	// Prompt is the text the model continues (required).
+	Prompt string
+	// MaxTokens limits the number of tokens to generate.
+	MaxTokens *int64
+	// Temperature controls randomness (0.0 = deterministic, higher = more random).
+	Temperature *float64
+	// TopP controls nucleus sampling probability mass.
+	TopP *float64
+	// Stop contains sequences that will halt generation when encountered.
+	Stop []string
+	// Seed enables deterministic generation where the provider supports it.
+	Seed *int64
+}
+
+// Response contains the result of a completion request.
+type Response struct {
+	// Content is the generated continuation of the prompt.
+	Content string
+	// Usage tracks token consumption for this request.
+	Usage Usage
+	// FinishReason indicates why the model stopped generating.
+	FinishReason FinishReason
+}
+
+// EventType identifies the type of streaming event.
+type EventType string
+
+const (
+	// EventContentDelta indicates a partial content update during streaming.
+	EventContentDelta EventType = "content_delta"
+	// EventComplete indicates the streaming response has completed.
+	EventComplete EventType = "complete"
+	// EventError indicates an error occurred during streaming.
+	EventError EventType = "error"
+)
+
+// Event represents a single event in a streaming completion response.
+type Event struct {
+	// Type identifies the kind of event.
+	Type EventType
+	// Content contains partial text for content delta events.
+	Content string
+	// Response contains the final response for completion events.
+	Response *Response
+	// Error contains error information for error events.
+	Error error
+}
+
+// Completion defines the interface for plain text completion.
+type Completion interface {
+	// Complete sends a completion request and returns the complete response.
+	Complete(ctx context.Context, req Request) (*Response, error)
+	// CompleteStream sends a completion request and returns a channel of
+	// streaming events.
+	CompleteStream(ctx context.Context, req Request) <-chan Event
+	// Model returns the model configuration being used.
+	Model() model.Model
+}
+
+// TracingAttrs are construction-time attributes vendor packages forward to the
+// [WithTracing] wrapper so they appear on every span produced for the wrapped
+// client.
+type TracingAttrs struct {
+	MaxTokens   int64
+	Temperature *float64
+	TopP        *float64
+}
+
+// WithTracing wraps a completion client so every call records OpenTelemetry
+// spans and metrics. The attrs are recorded as construction-time span
+// attributes.
+func WithTracing(inner Completion, attrs TracingAttrs) Completion {
+	return &tracingCompletion{inner: inner, attrs: attrs}
+}
+
+type tracingCompletion struct {
+	inner Completion
+	attrs TracingAttrs
+}
+
+func (t *tracingCompletion) Model() model.Model {
+	return t.inner.Model()
+}
+
+func (t *tracingCompletion) spanAttrs() []tracing.Attr {
+	var attrs []tracing.Attr
+	if t.attrs.MaxTokens > 0 {
+		attrs = append(
+			attrs,
+			tracing.AttrRequestMaxTokens.Int64(t.attrs.MaxTokens),
+		)
+	}
+	if t.attrs.Temperature != nil {
+		attrs = append(
+			attrs,
+			tracing.AttrRequestTemperature.Float64(*t.attrs.Temperature),
+		)
+	}
+	if t.attrs.TopP != nil {
+		attrs = append(attrs, tracing.AttrRequestTopP.Float64(*t.attrs.TopP))
+	}
+	return attrs
+}
+
+func (t *tracingCompletion) record(
+	ctx context.Context,
+	span tracing.Span,
+	start time.Time,
+	resp *Response,
+	err error,
+) {
+	m := t.inner.Model()
+	if err != nil {
+		tracing.SetError(span, err)
+		tracing.RecordMetrics(
+			ctx,
+			"text_completion",
+			m.APIModel,
+			string(m.Provider),
+			time.Since(start),
+			0,
+			0,
+			err,
+		)
+		return
+	}
+	tracing.SetResponseAttrs(span,
+		tracing.AttrUsageInputTokens.Int64(resp.Usage.InputTokens),
+		tracing.AttrUsageOutputTokens.Int64(resp.Usage.OutputTokens),
+		tracing.AttrResponseFinishReason.String(string(resp.FinishReason)),
+	)
+	tracing.RecordMetrics(
+		ctx,
+		"text_completion",
+		m.APIModel,
+		string(m.Provider),
+		time.Since(start),
+		resp.Usage.InputTokens,
+		resp.Usage.OutputTokens,
+		nil,
+	)
+}
+
+func (t *tracingCompletion) Complete(
+	ctx context.Context,
+	req Request,
+) (*Response, error) {
+	m := t.inner.Model()
+	start := time.Now()
+	ctx, span := tracing.StartCompletionSpan(
+		ctx,
+		m.APIModel,
+		string(m.Provider),
+		t.spanAttrs()...,
+	)
+	defer span.End()
+
+	resp, err := t.inner.Complete(ctx, req)
+	t.record(ctx, span, start, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *tracingCompletion) CompleteStream(
+	ctx context.Context,
+	req Request,
+) <-chan Event {
+	m := t.inner.Model()
+	start := time.Now()
+	ctx, span := tracing.StartCompletionSpan(
+		ctx,
+		m.APIModel,
+		string(m.Provider),
+		t.spanAttrs()...,
+	)
+
+	innerCh := t.inner.CompleteStream(ctx, req)
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		defer span.End()
+		for evt := range innerCh {
+			switch {
+			case evt.Type == EventComplete && evt.Response != nil:
+				t.record(ctx, span, start, evt.Response, nil)
+			case evt.Type == EventError && evt.Error != nil:
+				t.record(ctx, span, start, nil, evt.Error)
+			}
+			outCh <- evt
+		}
+	}()
+	return outCh
+}
@@ -0,0 +1,389 @@
+// Package openai provides an implementation of the [completion.Completion]
+// interface for OpenAI's legacy completions endpoint and the many servers
+// that expose a compatible /completions route, such as vLLM, llama.cpp,
+// Ollama, Together, and Fireworks.
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/ai/completion"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+const defaultBaseURL = "https://api.openai.com/v1"
+
+// Options configures the completion client.
+type Options struct {
+	apiKey           string
+	baseURL          string
+	model            model.Model
+	maxTokens        int64
+	temperature      *float64
+	topP             *float64
+	timeout          *time.Duration
+	frequencyPenalty *float64
+	presencePenalty  *float64
+	headers          map[string]string
+}
+
+// Option configures Options.
+type Option func(*Options)
+
+// WithAPIKey sets the API key sent as a bearer token. Leave it unset for
+// local servers that do not authenticate.
+func WithAPIKey(apiKey string) Option {
+	return func(o *Options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL sets the API base URL, such as "http://localhost:8000/v1" for
+// a local server. "/completions" is appended to it. Defaults to the OpenAI
+// API.
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithModel selects the completion model.
+func WithModel(m model.Model) Option {
+	return func(o *Options) {
+		o.model = m
+	}
+}
+
+// WithMaxTokens sets the default maximum number of tokens to generate.
+func WithMaxTokens(maxTokens int64) Option {
+	return func(o *Options) {
+		o.maxTokens = maxTokens
+	}
+}
+
+// WithTemperature sets the default sampling temperature (0.0 to 2.0).
+func WithTemperature(temperature float64) Option {
+	return func(o *Options) {
+		o.temperature = &temperature
+	}
+}
+
+// WithTopP sets the default nucleus sampling probability.
+func WithTopP(topP float64) Option {
+	return func(o *Options) {
+		o.topP = &topP
+	}
+}
+
+// WithTimeout sets the maximum duration to wait for a single request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.timeout = &timeout
+	}
+}
+
+// WithFrequencyPenalty sets the frequency penalty to reduce repetition (-2.0 to 2.0).
+func WithFrequencyPenalty(frequencyPenalty float64) Option {
+	return func(o *Options) {
+		o.frequencyPenalty = &frequencyPenalty
+	}
+}
+
+// WithPresencePenalty sets the presence penalty to encourage topic diversity (-2.0 to 2.0).
+func WithPresencePenalty(presencePenalty float64) Option {
+	return func(o *Options) {
+		o.presencePenalty = &presencePenalty
+	}
+}
+
+// WithHeaders sets extra HTTP headers sent with every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *Options) {
+		o.headers = headers
+	}
+}
+
+// Client implements [completion.Completion] against an OpenAI-compatible
+// completions endpoint.
+type Client struct {
+	options    Options
+	httpClient *http.Client
+}
+
+// NewCompletion constructs a completion client. The returned
+// [completion.Completion] is wrapped with [completion.WithTracing], so
+// callers always get tracing spans and metrics.
+func NewCompletion(opts ...Option) completion.Completion {
+	options := Options{baseURL: defaultBaseURL}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	timeout := 60 * time.Second
+	if options.timeout != nil {
+		timeout = *options.timeout
+	}
+
+	return completion.WithTracing(&Client{
+		options:    options,
+		httpClient: &http.Client{Timeout: timeout},
+	}, completion.TracingAttrs{
+		MaxTokens:   options.maxTokens,
+		Temperature: options.temperature,
+		TopP:        options.topP,
+	})
+}
+
+// Model returns the configured completion model.
+func (c *Client) Model() model.Model {
+	return c.options.model
+}
+
+type request struct {
+	Model            string         `json:"model"`
+	Prompt           string         `json:"prompt"`
+	MaxTokens        *int64         `json:"max_tokens,omitempty"`
+	Temperature      *float64       `json:"temperature,omitempty"`
+	TopP             *float64       `json:"top_p,omitempty"`
+	Stop             []string       `json:"stop,omitempty"`
+	Seed             *int64         `json:"seed,omitempty"`
+	FrequencyPenalty *float64       `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64       `json:"presence_penalty,omitempty"`
+	Stream           bool           `json:"stream"`
+	StreamOptions    *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type choice struct {
+	Index        int    `json:"index"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason"`
+}
+
+type usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+type response struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []choice `json:"choices"`
+	Usage   *usage   `json:"usage,omitempty"`
+}
+
+func (c *Client) buildRequest(req completion.Request, stream bool) request {
+	out := request{
+		Model:            c.options.model.APIModel,
+		Prompt:           req.Prompt,
+		Stop:             req.Stop,
+		Seed:             req.Seed,
+		FrequencyPenalty: c.options.frequencyPenalty,
+		PresencePenalty:  c.options.presencePenalty,
+		Stream:           stream,
+	}
+	if stream {
+		out.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+
+	if req.MaxTokens != nil {
+		out.MaxTokens = req.MaxTokens
+	} else if c.options.maxTokens > 0 {
+		out.MaxTokens = &c.options.maxTokens
+	}
+
+	if req.Temperature != nil {
+		out.Temperature = req.Temperature
+	} else if c.options.temperature != nil {
+		out.Temperature = c.options.temperature
+	}
+
+	if req.TopP != nil {
+		out.TopP = req.TopP
+	} else if c.options.topP != nil {
+		out.TopP = c.options.topP
+	}
+
+	return out
+}
+
+func mapFinishReason(reason string) completion.FinishReason {
+	switch reason {
+	case "stop":
+		return completion.FinishReasonStop
+	case "length":
+		return completion.FinishReasonLength
+	default:
+		return completion.FinishReasonUnknown
+	}
+}
+
+func mapUsage(u *usage) completion.Usage {
+	if u == nil {
+		return completion.Usage{}
+	}
+	return completion.Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+	}
+}
+
+func (c *Client) post(
+	ctx context.Context,
+	req completion.Request,
+	stream bool,
+) (*http.Response, error) {
+	if req.Prompt == "" {
+		return nil, errors.New("completion prompt is required")
+	}
+	body, err := json.Marshal(c.buildRequest(req, stream))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(c.options.baseURL, "/") + "/completions"
+	httpReq, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, url, bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.options.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.options.apiKey)
+	}
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	for k, v := range c.options.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf(
+			"openai completion api error (status %d): %s",
+			resp.StatusCode, string(bodyBytes),
+		)
+	}
+	return resp, nil
+}
+
+// Complete performs a non-streaming completion.
+func (c *Client) Complete(
+	ctx context.Context,
+	req completion.Request,
+) (*completion.Response, error) {
+	resp, err := c.post(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from openai completion")
+	}
+
+	return &completion.Response{
+		Content:      out.Choices[0].Text,
+		Usage:        mapUsage(out.Usage),
+		FinishReason: mapFinishReason(out.Choices[0].FinishReason),
+	}, nil
+}
+
+// CompleteStream performs a streaming completion via Server-Sent Events.
+func (c *Client) CompleteStream(
+	ctx context.Context,
+	req completion.Request,
+) <-chan completion.Event {
+	eventChan := make(chan completion.Event)
+
+	go func() {
+		defer close(eventChan)
+
+		resp, err := c.post(ctx, req, true)
+		if err != nil {
+			eventChan <- completion.Event{
+				Type:  completion.EventError,
+				Error: err,
+			}
+			return
+		}
+		defer resp.Body.Close()
+
+		streamSSE(resp.Body, eventChan)
+	}()
+
+	return eventChan
+}
+
+// streamSSE reads a Server-Sent Events body and emits completion events on
+// out, ending with a single complete or error event.
+func streamSSE(body io.Reader, out chan<- completion.Event) {
+	reader := bufio.NewReader(body)
+	var content strings.Builder
+	final := &completion.Response{}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		data = bytes.TrimSpace(data)
+		if ok && bytes.Equal(data, []byte("[DONE]")) {
+			break
+		}
+		var chunk response
+		if ok && json.Unmarshal(data, &chunk) == nil {
+			for _, ch := range chunk.Choices {
+				if ch.Text != "" {
+					content.WriteString(ch.Text)
+					out <- completion.Event{
+						Type:    completion.EventContentDelta,
+						Content: ch.Text,
+					}
+				}
+				if ch.FinishReason != "" {
+					final.FinishReason = mapFinishReason(ch.FinishReason)
+				}
+			}
+			if chunk.Usage != nil {
+				final.Usage = mapUsage(chunk.Usage)
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			out <- completion.Event{
+				Type:  completion.EventError,
+				Error: fmt.Errorf("error reading stream: %w", readErr),
+			}
+			return
+		}
+	}
+
+	final.Content = content.String()
+	out <- completion.Event{Type: completion.EventComplete, Response: final}
+}
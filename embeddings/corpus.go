@@ -0,0 +1,169 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Match is a document returned by [Corpus.Search].
+type Match struct {
+	// Index is the document's position in the slice given to [NewCorpus].
+	Index int
+	// Text is the document.
+	Text string
+	// Score is the cosine similarity between the document and the query,
+	// from -1 to 1 where higher is more similar.
+	Score float64
+}
+
+// Corpus is an in-memory set of documents embedded once, for similarity
+// search in prototypes and small datasets that do not need a vector store.
+// It is safe for concurrent searches.
+type Corpus struct {
+	embedder Embedding
+	docs     []string
+	vectors  [][]float32
+	config   corpusConfig
+}
+
+type corpusConfig struct {
+	batchSize     int
+	documentInput string
+	queryInput    string
+}
+
+// CorpusOption configures a [Corpus].
+type CorpusOption func(*corpusConfig)
+
+// WithBatchSize embeds the documents in requests of at most n texts, for
+// providers that limit how many inputs a request may carry. By default all
+// documents are embedded in one request.
+func WithBatchSize(n int) CorpusOption {
+	return func(c *corpusConfig) {
+		c.batchSize = n
+	}
+}
+
+// WithInputTypes sets the input type passed to the embedder for documents
+// and for queries, such as "document" and "query" for Voyage or
+// "search_document" and "search_query" for Cohere. By default none is sent.
+func WithInputTypes(document, query string) CorpusOption {
+	return func(c *corpusConfig) {
+		c.documentInput = document
+		c.queryInput = query
+	}
+}
+
+// NewCorpus embeds docs with embedder and returns a corpus to search them.
+//
+//	corpus, err := embeddings.NewCorpus(ctx, embedder, docs)
+//	matches, err := corpus.Search(ctx, "how do refunds work?", 3)
+func NewCorpus(
+	ctx context.Context,
+	embedder Embedding,
+	docs []string,
+	opts ...CorpusOption,
+) (*Corpus, error) {
+	c := &Corpus{embedder: embedder, docs: slices.Clone(docs)}
+	for _, opt := range opts {
+		opt(&c.config)
+	}
+	batch := c.config.batchSize
+	if batch <= 0 {
+		batch = len(docs)
+	}
+	c.vectors = make([][]float32, 0, len(docs))
+	for start := 0; start < len(docs); start += batch {
+		end := min(start+batch, len(docs))
+		vectors, err := c.embed(ctx, docs[start:end], c.config.documentInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed documents: %w", err)
+		}
+		c.vectors = append(c.vectors, vectors...)
+	}
+	return c, nil
+}
+
+// Len returns the number of documents in the corpus.
+func (c *Corpus) Len() int {
+	return len(c.docs)
+}
+
+// Search embeds query and returns the k documents most similar to it, best
+// first. A k of zero or less, or larger than the corpus, returns every
+// document.
+func (c *Corpus) Search(
+	ctx context.Context,
+	query string,
+	k int,
+) ([]Match, error) {
+	vectors, err := c.embed(ctx, []string{query}, c.config.queryInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	matches := make([]Match, len(c.docs))
+	for i, doc := range c.docs {
+		matches[i] = Match{
+			Index: i,
+			Text:  doc,
+			Score: CosineSimilarity(vectors[0], c.vectors[i]),
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if k > 0 && k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (c *Corpus) embed(
+	ctx context.Context,
+	texts []string,
+	inputType string,
+) ([][]float32, error) {
+	var types []string
+	if inputType != "" {
+		types = []string{inputType}
+	}
+	resp, err := c.embedder.GenerateEmbeddings(ctx, texts, types...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf(
+			"got %d embeddings for %d texts",
+			len(resp.Embeddings),
+			len(texts),
+		)
+	}
+	return resp.Embeddings, nil
+}
+
+// CosineSimilarity returns the cosine similarity of a and b, from -1 to 1
+// where 1 means they point the same way. It returns 0 when the vectors
+// differ in length or either is empty or all zeros.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -134,6 +134,10 @@ func (t *tracingEmbedding) Model() model.EmbeddingModel {
 	return t.inner.Model()
 }
 
+func (t *tracingEmbedding) Unwrap() Embedding {
+	return t.inner
+}
+
 func (t *tracingEmbedding) spanAttrs() []tracing.Attr {
 	var attrs []tracing.Attr
 	if t.attrs.Dimensions != nil {
@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/joakimcarlsson/ai/embeddings v0.2.3
 	github.com/joakimcarlsson/ai/model v0.6.0
+	github.com/joakimcarlsson/ai/tokens v0.2.4
 	github.com/openai/openai-go/v3 v3.41.0
 )
 
@@ -13,13 +14,20 @@ require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/jsonschema-go v0.4.3 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
+	github.com/joakimcarlsson/ai/message v0.4.0 // indirect
+	github.com/joakimcarlsson/ai/tool v0.1.2 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
+	github.com/modelcontextprotocol/go-sdk v1.6.1 // indirect
+	github.com/segmentio/asm v1.2.1 // indirect
+	github.com/segmentio/encoding v0.5.4 // indirect
 	github.com/tidwall/gjson v1.19.0 // indirect
 	github.com/tidwall/match v1.2.0 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/sjson v1.2.5 // indirect
+	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
 	go.opentelemetry.io/otel v1.44.0 // indirect
 	go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp v0.20.0 // indirect
@@ -34,6 +42,7 @@ require (
 	go.opentelemetry.io/otel/trace v1.44.0 // indirect
 	go.opentelemetry.io/proto/otlp v1.10.0 // indirect
 	golang.org/x/net v0.56.0 // indirect
+	golang.org/x/oauth2 v0.36.0 // indirect
 	golang.org/x/sys v0.46.0 // indirect
 	golang.org/x/text v0.38.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20260618152121-87f3d3e198d3 // indirect
@@ -44,6 +53,9 @@ require (
 
 replace (
 	github.com/joakimcarlsson/ai/embeddings => ../
+	github.com/joakimcarlsson/ai/message => ../../message
 	github.com/joakimcarlsson/ai/model => ../../model
+	github.com/joakimcarlsson/ai/tokens => ../../tokens
+	github.com/joakimcarlsson/ai/tool => ../../tool
 	github.com/joakimcarlsson/ai/tracing => ../../tracing
 )
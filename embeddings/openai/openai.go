@@ -4,10 +4,12 @@ package openai
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/joakimcarlsson/ai/embeddings"
 	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tokens"
 	openaisdk "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 )
@@ -55,6 +57,8 @@ func WithBatchSize(
 }
 
 // WithDimensions specifies the output dimensionality for embedding vectors.
+// The text-embedding-3 models accept any value up to the model's
+// EmbeddingDims; requests with other values fail before reaching the API.
 func WithDimensions(
 	dimensions int,
 ) Option {
@@ -105,6 +109,13 @@ func NewEmbedding(opts ...Option) embeddings.Embedding {
 func (c *Client) Model() model.EmbeddingModel { return c.options.model }
 
 // GenerateEmbeddings creates vector embeddings from text strings.
+//
+// OpenAI has no input type, so inputType is accepted for interface
+// compatibility and ignored. Texts are sent in batches of at most the
+// configured batch size, the model's MaxBatchSize, and MaxTokensPerBatch
+// tokens as counted by [Client.CountTokens]. Inputs longer than the model's
+// MaxInputTokens are sent as they are; wrap the client with
+// [embeddings.WithTruncationPolicy] to catch them first.
 func (c *Client) GenerateEmbeddings(
 	ctx context.Context,
 	texts []string,
@@ -117,22 +128,15 @@ func (c *Client) GenerateEmbeddings(
 			Model:      c.options.model.APIModel,
 		}, nil
 	}
-
-	batchSize := c.options.batchSize
-	if batchSize <= 0 {
-		batchSize = 2048
+	if err := c.validateDimensions(); err != nil {
+		return nil, err
 	}
 
 	var allEmbeddings [][]float32
 	var totalTokens int64
 
-	for i := 0; i < len(texts); i += batchSize {
-		end := i + batchSize
-		if end > len(texts) {
-			end = len(texts)
-		}
-
-		response, err := c.embedBatch(ctx, texts[i:end])
+	for _, batch := range c.batches(texts) {
+		response, err := c.embedBatch(ctx, batch)
 		if err != nil {
 			return nil, fmt.Errorf("failed to embed batch: %w", err)
 		}
@@ -148,6 +152,74 @@ func (c *Client) GenerateEmbeddings(
 	}, nil
 }
 
+// CountTokens returns the number of tokens in text under cl100k_base, the
+// tokenizer of OpenAI's embedding models. OpenAI-compatible servers running
+// other models tokenize differently, so for them the count is an estimate.
+func (c *Client) CountTokens(text string) int64 {
+	t, err := tokenizer()
+	if err != nil {
+		return embeddings.EstimateTokens(text)
+	}
+	return int64(t.Count(text))
+}
+
+var tokenizer = sync.OnceValues(tokens.NewBPETokenizer)
+
+func (c *Client) batches(texts []string) [][]string {
+	m := c.options.model
+	maxCount := c.options.batchSize
+	if maxCount <= 0 {
+		maxCount = 2048
+	}
+	if m.MaxBatchSize > 0 {
+		maxCount = min(maxCount, m.MaxBatchSize)
+	}
+
+	var out [][]string
+	start, batchTokens := 0, int64(0)
+	for i, text := range texts {
+		n := c.CountTokens(text)
+		full := i-start == maxCount ||
+			(m.MaxTokensPerBatch > 0 && i > start &&
+				batchTokens+n > m.MaxTokensPerBatch)
+		if full {
+			out = append(out, texts[start:i])
+			start, batchTokens = i, 0
+		}
+		batchTokens += n
+	}
+	return append(out, texts[start:])
+}
+
+// validateDimensions rejects a [WithDimensions] value the model cannot
+// produce. Models with a single supported size, such as
+// text-embedding-ada-002, do not accept the parameter, so it is only valid
+// there when it matches that size and is then left out of the request. The
+// text-embedding-3 models accept any size up to their native dimensions.
+func (c *Client) validateDimensions() error {
+	dims := c.options.dimensions
+	m := c.options.model
+	if dims == nil || m.EmbeddingDims == 0 {
+		return nil
+	}
+	if len(m.SupportedDimensions) == 1 &&
+		*dims != m.SupportedDimensions[0] {
+		return fmt.Errorf(
+			"openai: model %s does not support custom dimensions",
+			m.APIModel,
+		)
+	}
+	if *dims < 1 || *dims > m.EmbeddingDims {
+		return fmt.Errorf(
+			"openai: dimensions %d out of range 1-%d for model %s",
+			*dims,
+			m.EmbeddingDims,
+			m.APIModel,
+		)
+	}
+	return nil
+}
+
 func (c *Client) embedBatch(
 	ctx context.Context,
 	texts []string,
@@ -157,7 +229,8 @@ func (c *Client) embedBatch(
 		Input: openaisdk.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
 	}
 
-	if c.options.dimensions != nil {
+	if c.options.dimensions != nil &&
+		len(c.options.model.SupportedDimensions) != 1 {
 		params.Dimensions = openaisdk.Int(int64(*c.options.dimensions))
 	}
 	if c.options.user != "" {
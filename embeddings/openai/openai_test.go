@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func TestBatchesRespectCountAndTokenLimits(t *testing.T) {
+	m := model.OpenAIEmbeddingModels[model.TextEmbedding3Small]
+	m.MaxTokensPerBatch = 10
+	c := &Client{options: Options{
+		model:     m,
+		batchSize: 3,
+	}}
+
+	long := strings.Repeat(" word", 3)
+	got := c.batches([]string{
+		long, long, long, "d", "e", "f", "g", long, long, long, long,
+	})
+	want := []int{3, 3, 3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("batches = %v", got)
+	}
+	for i, b := range got {
+		if len(b) != want[i] {
+			t.Errorf("batch %d has %d texts, want %d", i, len(b), want[i])
+		}
+	}
+}
+
+func TestCountTokensUsesTokenizer(t *testing.T) {
+	c := &Client{}
+	if got := c.CountTokens(strings.Repeat(" word", 100)); got != 100 {
+		t.Errorf("tokens = %d, want 100", got)
+	}
+	if got := c.CountTokens(""); got != 0 {
+		t.Errorf("empty text has %d tokens", got)
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	tests := []struct {
+		model   model.ID
+		dims    int
+		wantErr bool
+	}{
+		{model.TextEmbedding3Small, 256, false},
+		{model.TextEmbedding3Small, 1536, false},
+		{model.TextEmbedding3Small, 2048, true},
+		{model.TextEmbedding3Large, 0, true},
+		{model.AdaEmbedding002, 1536, false},
+		{model.AdaEmbedding002, 512, true},
+	}
+	for _, tt := range tests {
+		c := &Client{options: Options{
+			model:      model.OpenAIEmbeddingModels[tt.model],
+			dimensions: &tt.dims,
+		}}
+		err := c.validateDimensions()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s with %d dims: err = %v", tt.model, tt.dims, err)
+		}
+	}
+}
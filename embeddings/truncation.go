@@ -0,0 +1,228 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// TruncationPolicy decides what [WithTruncationPolicy] does with inputs
+// longer than the model's MaxInputTokens, which providers either reject or
+// silently truncate.
+type TruncationPolicy int
+
+// Truncation policies.
+const (
+	// TruncationSilent sends over-length inputs without comment.
+	TruncationSilent TruncationPolicy = iota
+	// TruncationWarn logs a warning listing the over-length inputs and sends
+	// the request anyway.
+	TruncationWarn
+	// TruncationError fails the call with an [*InputTooLongError] without
+	// sending the request.
+	TruncationError
+)
+
+// EstimateTokens approximates the number of tokens in text at four bytes per
+// token. It is a cheap, provider-independent estimate that works well for
+// English prose; code and other languages usually take more tokens.
+// [WithTruncationPolicy] only falls back to it for clients that are not a
+// [TokenCounter].
+func EstimateTokens(text string) int64 {
+	return int64(len(text)+3) / 4
+}
+
+// TokenCounter is implemented by clients that count the tokens of a text
+// input with their model's tokenizer, such as the OpenAI client.
+type TokenCounter interface {
+	CountTokens(text string) int64
+}
+
+// countTokens returns the token counter of e, found by unwrapping decorators
+// such as [WithTracing], or [EstimateTokens] when e has none.
+func countTokens(e Embedding) func(string) int64 {
+	for e != nil {
+		if c, ok := e.(TokenCounter); ok {
+			return c.CountTokens
+		}
+		u, ok := e.(interface{ Unwrap() Embedding })
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	return EstimateTokens
+}
+
+// OverlongInput identifies one input estimated to exceed the model's limit.
+type OverlongInput struct {
+	// Index is the position of the text, or of the document for contextualized
+	// embeddings.
+	Index int
+	// Chunk is the position of the chunk within the document for
+	// contextualized embeddings, and zero otherwise.
+	Chunk int
+	// Tokens is the token count of the input.
+	Tokens int64
+}
+
+// InputTooLongError is returned under [TruncationError] when inputs exceed
+// the model's MaxInputTokens.
+type InputTooLongError struct {
+	// Model is the API identifier of the embedding model.
+	Model string
+	// MaxInputTokens is the model's per-input token limit.
+	MaxInputTokens int64
+	// Inputs lists the over-length inputs in order.
+	Inputs []OverlongInput
+
+	chunked bool
+}
+
+func (e *InputTooLongError) Error() string {
+	return fmt.Sprintf(
+		"embeddings: %d input(s) exceed the %d token limit of %s: %s",
+		len(e.Inputs),
+		e.MaxInputTokens,
+		e.Model,
+		describeOverlong(e.Inputs, e.chunked),
+	)
+}
+
+// WithTruncationPolicy wraps an Embedding client so text inputs are measured
+// against the model's MaxInputTokens before each request, and over-length
+// inputs are handled according to policy. Inputs are counted with the
+// client's tokenizer when it is a [TokenCounter], and with [EstimateTokens]
+// otherwise. Warnings go to logger, or to [slog.Default] when logger is nil.
+// Models without a MaxInputTokens are not checked, and neither are
+// multimodal inputs.
+func WithTruncationPolicy(
+	inner Embedding,
+	policy TruncationPolicy,
+	logger *slog.Logger,
+) Embedding {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &truncationEmbedding{
+		inner:  inner,
+		policy: policy,
+		logger: logger,
+		count:  countTokens(inner),
+	}
+}
+
+type truncationEmbedding struct {
+	inner  Embedding
+	policy TruncationPolicy
+	logger *slog.Logger
+	count  func(string) int64
+}
+
+func (t *truncationEmbedding) Model() model.EmbeddingModel {
+	return t.inner.Model()
+}
+
+func (t *truncationEmbedding) Unwrap() Embedding {
+	return t.inner
+}
+
+func (t *truncationEmbedding) GenerateEmbeddings(
+	ctx context.Context,
+	texts []string,
+	inputType ...string,
+) (*EmbeddingResponse, error) {
+	limit := t.inner.Model().MaxInputTokens
+	var over []OverlongInput
+	for i, text := range texts {
+		if n := t.count(text); limit > 0 && n > limit {
+			over = append(over, OverlongInput{Index: i, Tokens: n})
+		}
+	}
+	if err := t.check(ctx, over, false); err != nil {
+		return nil, err
+	}
+	return t.inner.GenerateEmbeddings(ctx, texts, inputType...)
+}
+
+func (t *truncationEmbedding) GenerateMultimodalEmbeddings(
+	ctx context.Context,
+	inputs []MultimodalInput,
+	inputType ...string,
+) (*EmbeddingResponse, error) {
+	return t.inner.GenerateMultimodalEmbeddings(ctx, inputs, inputType...)
+}
+
+func (t *truncationEmbedding) GenerateContextualizedEmbeddings(
+	ctx context.Context,
+	documentChunks [][]string,
+	inputType ...string,
+) (*ContextualizedEmbeddingResponse, error) {
+	limit := t.inner.Model().MaxInputTokens
+	var over []OverlongInput
+	for i, chunks := range documentChunks {
+		for j, chunk := range chunks {
+			if n := t.count(chunk); limit > 0 && n > limit {
+				over = append(
+					over,
+					OverlongInput{Index: i, Chunk: j, Tokens: n},
+				)
+			}
+		}
+	}
+	if err := t.check(ctx, over, true); err != nil {
+		return nil, err
+	}
+	return t.inner.GenerateContextualizedEmbeddings(
+		ctx,
+		documentChunks,
+		inputType...,
+	)
+}
+
+func (t *truncationEmbedding) check(
+	ctx context.Context,
+	over []OverlongInput,
+	chunked bool,
+) error {
+	if len(over) == 0 {
+		return nil
+	}
+	m := t.inner.Model()
+	switch t.policy {
+	case TruncationError:
+		return &InputTooLongError{
+			Model:          m.APIModel,
+			MaxInputTokens: m.MaxInputTokens,
+			Inputs:         over,
+			chunked:        chunked,
+		}
+	case TruncationWarn:
+		t.logger.WarnContext(ctx, "embedding inputs exceed the model's limit",
+			slog.String("model", m.APIModel),
+			slog.Int64("max_input_tokens", m.MaxInputTokens),
+			slog.String("inputs", describeOverlong(over, chunked)),
+		)
+	}
+	return nil
+}
+
+func describeOverlong(inputs []OverlongInput, chunked bool) string {
+	parts := make([]string, len(inputs))
+	for i, in := range inputs {
+		if chunked {
+			parts[i] = fmt.Sprintf(
+				"#%d.%d (~%d tokens)",
+				in.Index,
+				in.Chunk,
+				in.Tokens,
+			)
+			continue
+		}
+		parts[i] = fmt.Sprintf("#%d (~%d tokens)", in.Index, in.Tokens)
+	}
+	return strings.Join(parts, ", ")
+}
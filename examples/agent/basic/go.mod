@@ -5,7 +5,7 @@ go 1.25.0
 require (
 	github.com/joakimcarlsson/ai/agent v0.0.0-00010101000000-000000000000
 	github.com/joakimcarlsson/ai/llm/openai v0.0.0-00010101000000-000000000000
-	github.com/joakimcarlsson/ai/model v0.1.0
+	github.com/joakimcarlsson/ai/model v0.6.0
 )
 
 require (
@@ -16,16 +16,19 @@ require (
 	github.com/google/jsonschema-go v0.4.3 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
-	github.com/joakimcarlsson/ai/embeddings v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/llm v0.4.0 // indirect
-	github.com/joakimcarlsson/ai/memory v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/message v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/embeddings v0.2.3 // indirect
+	github.com/joakimcarlsson/ai/llm v0.5.0 // indirect
+	github.com/joakimcarlsson/ai/memory v0.2.5 // indirect
+	github.com/joakimcarlsson/ai/message v0.4.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/moderation v0.0.0-00010101000000-000000000000 // indirect
 	github.com/joakimcarlsson/ai/prompt v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/schema v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/session v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/tokens v0.2.0 // indirect
-	github.com/joakimcarlsson/ai/tool v0.1.1 // indirect
-	github.com/joakimcarlsson/ai/tracing v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/rerankers v0.2.1 // indirect
+	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
+	github.com/joakimcarlsson/ai/session v0.1.3 // indirect
+	github.com/joakimcarlsson/ai/tokens v0.2.4 // indirect
+	github.com/joakimcarlsson/ai/tool v0.1.2 // indirect
+	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
 	github.com/joakimcarlsson/ai/types v0.1.0 // indirect
 	github.com/modelcontextprotocol/go-sdk v1.6.1 // indirect
 	github.com/openai/openai-go/v3 v3.41.0 // indirect
@@ -72,6 +75,8 @@ replace (
 	github.com/joakimcarlsson/ai/llm/openai => ../../../llm/openai
 	github.com/joakimcarlsson/ai/memory => ../../../memory
 	github.com/joakimcarlsson/ai/message => ../../../message
+	github.com/joakimcarlsson/ai/metrics => ../../../metrics
+	github.com/joakimcarlsson/ai/moderation => ../../../moderation
 	github.com/joakimcarlsson/ai/model => ../../../model
 	github.com/joakimcarlsson/ai/prompt => ../../../prompt
 	github.com/joakimcarlsson/ai/rerankers => ../../../rerankers
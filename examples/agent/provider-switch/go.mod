@@ -50,7 +50,10 @@ require (
 	github.com/joakimcarlsson/ai/embeddings v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/memory v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/message v0.2.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/moderation v0.0.0-00010101000000-000000000000 // indirect
 	github.com/joakimcarlsson/ai/prompt v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/rerankers v0.2.1 // indirect
 	github.com/joakimcarlsson/ai/schema v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/session v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/tokens v0.2.0 // indirect
@@ -117,6 +120,8 @@ replace (
 	github.com/joakimcarlsson/ai/llm/openai => ../../../llm/openai
 	github.com/joakimcarlsson/ai/memory => ../../../memory
 	github.com/joakimcarlsson/ai/message => ../../../message
+	github.com/joakimcarlsson/ai/metrics => ../../../metrics
+	github.com/joakimcarlsson/ai/moderation => ../../../moderation
 	github.com/joakimcarlsson/ai/model => ../../../model
 	github.com/joakimcarlsson/ai/prompt => ../../../prompt
 	github.com/joakimcarlsson/ai/rerankers => ../../../rerankers
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool/functiontool"
+)
+
+type weatherArgs struct {
+	City string `json:"city" desc:"City to get weather for"`
+}
+
+type weatherReport struct {
+	City        string `json:"city"`
+	Conditions  string `json:"conditions"`
+	Temperature int    `json:"temperature"`
+}
+
+type tripAdvice struct {
+	City        string   `json:"city"`
+	Summary     string   `json:"summary"`
+	Temperature int      `json:"temperature"`
+	Pack        []string `json:"pack"`
+}
+
+func main() {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPENAI_API_KEY is required")
+	}
+
+	llmClient := llmopenai.NewLLM(
+		llmopenai.WithAPIKey(apiKey),
+		llmopenai.WithModel(model.OpenAIModels[model.GPT54Nano]),
+		llmopenai.WithMaxTokens(512),
+	)
+
+	advice := schema.Object().
+		Field("city", schema.String()).
+		Field("summary", schema.String().Desc("One sentence on the weather")).
+		Field("temperature", schema.Integer().Desc("Degrees Celsius")).
+		Field("pack", schema.Array(schema.String()).Desc("Things to bring")).
+		Required("city", "summary", "temperature", "pack").
+		Output("trip_advice", "Weather summary and packing advice")
+
+	planner := agent.New(llmClient,
+		agent.WithSystemPrompt(
+			"Check the weather with get_weather before giving advice.",
+		),
+		agent.WithTools(functiontool.New(
+			"get_weather",
+			"Get the current weather for a city.",
+			getWeather,
+		)),
+		agent.WithOutputSchema(advice),
+	)
+
+	resp, err := planner.Chat(
+		context.Background(),
+		"I'm visiting Stockholm tomorrow. What should I pack?",
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StructuredOutput == nil {
+		log.Fatalf("no structured output: %s", resp.Content)
+	}
+
+	var out tripAdvice
+	if err := schema.Parse(*resp.StructuredOutput, &out); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s, %d°C: %s\n", out.City, out.Temperature, out.Summary)
+	for _, item := range out.Pack {
+		fmt.Println("-", item)
+	}
+	fmt.Printf("(%d tool calls)\n", resp.TotalToolCalls)
+}
+
+func getWeather(args weatherArgs) (weatherReport, error) {
+	return weatherReport{
+		City:        args.City,
+		Conditions:  "light rain",
+		Temperature: 12,
+	}, nil
+}
@@ -3,9 +3,10 @@ module github.com/joakimcarlsson/ai/examples/llm/anthropic-stream
 go 1.25.0
 
 require (
+	github.com/joakimcarlsson/ai/llm v0.5.0
 	github.com/joakimcarlsson/ai/llm/anthropic v0.0.0-00010101000000-000000000000
-	github.com/joakimcarlsson/ai/message v0.1.0
-	github.com/joakimcarlsson/ai/model v0.3.0
+	github.com/joakimcarlsson/ai/message v0.4.0
+	github.com/joakimcarlsson/ai/model v0.6.0
 )
 
 require (
@@ -35,10 +36,10 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
 	github.com/invopop/jsonschema v0.14.0 // indirect
-	github.com/joakimcarlsson/ai/llm v0.4.0 // indirect
-	github.com/joakimcarlsson/ai/schema v0.1.0 // indirect
-	github.com/joakimcarlsson/ai/tool v0.1.1 // indirect
-	github.com/joakimcarlsson/ai/tracing v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
+	github.com/joakimcarlsson/ai/tool v0.1.2 // indirect
+	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
 	github.com/joakimcarlsson/ai/types v0.1.0 // indirect
 	github.com/modelcontextprotocol/go-sdk v1.6.1 // indirect
 	github.com/pb33f/ordered-map/v2 v2.3.1 // indirect
@@ -88,6 +89,7 @@ replace (
 	github.com/joakimcarlsson/ai/llm/openai => ../../../llm/openai
 	github.com/joakimcarlsson/ai/memory => ../../../memory
 	github.com/joakimcarlsson/ai/message => ../../../message
+	github.com/joakimcarlsson/ai/metrics => ../../../metrics
 	github.com/joakimcarlsson/ai/model => ../../../model
 	github.com/joakimcarlsson/ai/prompt => ../../../prompt
 	github.com/joakimcarlsson/ai/rerankers => ../../../rerankers
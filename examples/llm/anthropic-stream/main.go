@@ -2,10 +2,10 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 
+	"github.com/joakimcarlsson/ai/llm"
 	llmanthropic "github.com/joakimcarlsson/ai/llm/anthropic"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
@@ -29,13 +29,11 @@ func main() {
 		),
 	}, nil)
 
-	for event := range events {
-		if event.Error != nil {
-			log.Fatal(event.Error)
-		}
-		if event.Content != "" {
-			fmt.Print(event.Content)
-		}
+	if _, err := llm.RenderStream(
+		os.Stdout,
+		events,
+		llm.RenderMarkdown(),
+	); err != nil {
+		log.Fatal(err)
 	}
-	fmt.Println()
 }
@@ -15,7 +15,6 @@ import (
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
 	"github.com/joakimcarlsson/ai/schema"
-	"github.com/joakimcarlsson/ai/types"
 )
 
 type bugReport struct {
@@ -48,20 +47,12 @@ func main() {
 	)
 
 	fmt.Printf("[%s] streaming JSON: ", provider)
-	var finalResp *llm.Response
-	for event := range events {
-		switch event.Type {
-		case types.EventContentDelta:
-			fmt.Print(event.Content)
-		case types.EventComplete:
-			finalResp = event.Response
-		case types.EventError:
-			log.Fatal(event.Error)
-		}
+	finalResp, err := llm.RenderStream(os.Stdout, events)
+	if err != nil {
+		log.Fatal(err)
 	}
-	fmt.Println()
 
-	if finalResp == nil || finalResp.StructuredOutput == nil {
+	if finalResp.StructuredOutput == nil {
 		log.Fatal("model did not return final structured output")
 	}
 
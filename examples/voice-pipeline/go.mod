@@ -21,6 +21,7 @@ require (
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
 	github.com/joakimcarlsson/ai/llm v0.4.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/schema v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/tool v0.1.1 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.0 // indirect
@@ -62,6 +63,7 @@ replace (
 	github.com/joakimcarlsson/ai/llm => ../../llm
 	github.com/joakimcarlsson/ai/llm/openai => ../../llm/openai
 	github.com/joakimcarlsson/ai/message => ../../message
+	github.com/joakimcarlsson/ai/metrics => ../../metrics
 	github.com/joakimcarlsson/ai/model => ../../model
 	github.com/joakimcarlsson/ai/prompt => ../../prompt
 	github.com/joakimcarlsson/ai/schema => ../../schema
@@ -25,6 +25,7 @@ require (
 	github.com/joakimcarlsson/ai/llm v0.4.0 // indirect
 	github.com/joakimcarlsson/ai/memory v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/message v0.1.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/schema v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/session v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/stt v0.2.0 // indirect
@@ -71,6 +72,7 @@ replace (
 	github.com/joakimcarlsson/ai/llm/openai => ../../../llm/openai
 	github.com/joakimcarlsson/ai/memory => ../../../memory
 	github.com/joakimcarlsson/ai/message => ../../../message
+	github.com/joakimcarlsson/ai/metrics => ../../../metrics
 	github.com/joakimcarlsson/ai/model => ../../../model
 	github.com/joakimcarlsson/ai/prompt => ../../../prompt
 	github.com/joakimcarlsson/ai/schema => ../../../schema
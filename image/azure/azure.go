@@ -77,6 +77,7 @@ type Options struct {
 	outputFormat      OutputFormat
 	outputCompression *int
 	user              string
+	referenceImages   [][]byte
 }
 
 // Option configures Options.
@@ -159,6 +160,12 @@ func WithUser(user string) Option {
 	return func(o *Options) { o.user = user }
 }
 
+// WithReferenceImages supplies images the model should keep the style or
+// subject of. See [image/openai.WithReferenceImages].
+func WithReferenceImages(images ...[]byte) Option {
+	return func(o *Options) { o.referenceImages = images }
+}
+
 // Client implements [image.Generation] against Azure OpenAI by delegating
 // request handling to [image/openai].Client constructed with Azure-specific SDK
 // options.
@@ -256,6 +263,12 @@ func passthroughOptions(o Options) []imageopenai.Option {
 	if o.user != "" {
 		imageOpts = append(imageOpts, imageopenai.WithUser(o.user))
 	}
+	if len(o.referenceImages) > 0 {
+		imageOpts = append(
+			imageOpts,
+			imageopenai.WithReferenceImages(o.referenceImages...),
+		)
+	}
 	return imageOpts
 }
 
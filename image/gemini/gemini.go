@@ -5,7 +5,9 @@ package gemini
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/joakimcarlsson/ai/image"
@@ -74,6 +76,7 @@ type Options struct {
 	includeRAIReason         *bool
 	outputMIMEType           OutputMIMEType
 	outputCompressionQuality *int32
+	referenceImages          [][]byte
 }
 
 // Option configures Options.
@@ -171,6 +174,15 @@ func WithOutputCompressionQuality(quality int32) Option {
 	return func(o *Options) { o.outputCompressionQuality = &quality }
 }
 
+// WithReferenceImages supplies images the model should keep the style or
+// subject of, such as a character sheet reused across a series of prompts.
+// Gemini Image only; Imagen models return
+// [image.ErrReferenceImagesNotSupported]. The MIME type of each image is
+// detected from its contents.
+func WithReferenceImages(images ...[]byte) Option {
+	return func(o *Options) { o.referenceImages = images }
+}
+
 // Client implements [image.Generation] against the Google Gemini API.
 type Client struct {
 	options Options
@@ -258,12 +270,19 @@ func (c *Client) buildConfig() *genai.GenerateImagesConfig {
 	return config
 }
 
-// GenerateImage performs a non-streaming image generation request.
+// GenerateImage performs a non-streaming image generation request. When
+// reference images are configured the request goes through GenerateContent
+// with the images attached ahead of the prompt.
 func (c *Client) GenerateImage(
 	ctx context.Context,
 	prompt string,
 ) (*image.GenerationResponse, error) {
-	config := c.buildConfig()
+	if err := image.CheckReferenceImages(
+		c.options.model,
+		len(c.options.referenceImages),
+	); err != nil {
+		return nil, err
+	}
 
 	if c.options.timeout != nil {
 		var cancel context.CancelFunc
@@ -271,6 +290,12 @@ func (c *Client) GenerateImage(
 		defer cancel()
 	}
 
+	if len(c.options.referenceImages) > 0 {
+		return c.generateWithReferences(ctx, prompt)
+	}
+
+	config := c.buildConfig()
+
 	response, err := c.client.Models.GenerateImages(
 		ctx,
 		c.options.model.APIModel,
@@ -286,11 +311,18 @@ func (c *Client) GenerateImage(
 		0,
 		len(response.GeneratedImages),
 	)
+	var seed *int64
+	if config.Seed != nil {
+		s := int64(*config.Seed)
+		seed = &s
+	}
 	for _, img := range response.GeneratedImages {
 		results = append(results, image.GenerationResult{
 			ImageBase64: base64.StdEncoding.EncodeToString(
 				img.Image.ImageBytes,
 			),
+			RevisedPrompt: img.EnhancedPrompt,
+			Seed:          seed,
 		})
 	}
 
@@ -301,6 +333,77 @@ func (c *Client) GenerateImage(
 	}, nil
 }
 
+func (c *Client) generateWithReferences(
+	ctx context.Context,
+	prompt string,
+) (*image.GenerationResponse, error) {
+	parts := make([]*genai.Part, 0, len(c.options.referenceImages)+1)
+	for _, ref := range c.options.referenceImages {
+		parts = append(parts, &genai.Part{
+			InlineData: &genai.Blob{
+				MIMEType: http.DetectContentType(ref),
+				Data:     ref,
+			},
+		})
+	}
+	parts = append(parts, &genai.Part{Text: prompt})
+
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{"TEXT", "IMAGE"},
+	}
+	aspect := c.options.aspectRatio
+	if aspect == "" {
+		aspect = AspectRatio(c.options.model.DefaultAspectRatio)
+	}
+	if aspect != "" || c.options.imageSize != "" {
+		config.ImageConfig = &genai.ImageConfig{
+			AspectRatio: string(aspect),
+			ImageSize:   string(c.options.imageSize),
+		}
+	}
+
+	response, err := c.client.Models.GenerateContent(
+		ctx,
+		c.options.model.APIModel,
+		[]*genai.Content{{Role: "user", Parts: parts}},
+		config,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate image: %w", err)
+	}
+
+	var results []image.GenerationResult
+	for _, candidate := range response.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData == nil || len(part.InlineData.Data) == 0 {
+				continue
+			}
+			results = append(results, image.GenerationResult{
+				ImageBase64: base64.StdEncoding.EncodeToString(
+					part.InlineData.Data,
+				),
+			})
+		}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("failed to generate image: no image returned")
+	}
+
+	var usage image.GenerationUsage
+	if response.UsageMetadata != nil {
+		usage.PromptTokens = int64(response.UsageMetadata.PromptTokenCount)
+	}
+
+	return &image.GenerationResponse{
+		Images: results,
+		Usage:  usage,
+		Model:  c.options.model.APIModel,
+	}, nil
+}
+
 // GenerateImageStreaming returns [image.ErrStreamingNotSupported]; the Gemini
 // API does not currently expose streaming image generation.
 func (c *Client) GenerateImageStreaming(
@@ -64,6 +64,9 @@ type GenerationResult struct {
 	ImageBase64 string
 	// RevisedPrompt contains the prompt that was actually used to generate the image.
 	RevisedPrompt string
+	// Seed is the random seed the image was generated with, or nil when the
+	// vendor does not report one. Reuse it to reproduce the result.
+	Seed *int64
 }
 
 // GenerationResponse contains the generated images and metadata from an image generation request.
@@ -108,6 +111,38 @@ var ErrStreamingNotSupported = errors.New(
 	"streaming not supported by this model",
 )
 
+// ErrReferenceImagesNotSupported is returned when reference images are
+// configured for a model that does not accept them, or when more are supplied
+// than the model allows. See model.ImageGenerationModel.MaxReferenceImages.
+var ErrReferenceImagesNotSupported = errors.New(
+	"reference images not supported by this model",
+)
+
+// CheckReferenceImages returns [ErrReferenceImagesNotSupported] when m cannot
+// take the given number of reference images. Vendor packages call it before
+// sending a request.
+func CheckReferenceImages(m model.ImageGenerationModel, count int) error {
+	switch {
+	case count == 0:
+		return nil
+	case m.MaxReferenceImages == 0:
+		return fmt.Errorf(
+			"%w: %s",
+			ErrReferenceImagesNotSupported,
+			m.APIModel,
+		)
+	case count > m.MaxReferenceImages:
+		return fmt.Errorf(
+			"%w: %s accepts at most %d, got %d",
+			ErrReferenceImagesNotSupported,
+			m.APIModel,
+			m.MaxReferenceImages,
+			count,
+		)
+	}
+	return nil
+}
+
 // Generation defines the interface for generating images from text prompts.
 type Generation interface {
 	// GenerateImage creates one or more images from a text prompt. All vendor
@@ -3,8 +3,11 @@
 package openai
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/joakimcarlsson/ai/image"
@@ -91,6 +94,7 @@ type Options struct {
 	outputFormat      OutputFormat
 	outputCompression *int
 	user              string
+	referenceImages   [][]byte
 }
 
 // Option configures Options.
@@ -167,6 +171,14 @@ func WithUser(user string) Option {
 	return func(o *Options) { o.user = user }
 }
 
+// WithReferenceImages supplies images the model should keep the style or
+// subject of, such as a character sheet reused across a series of prompts.
+// Requests are sent to the image edits endpoint with the images attached.
+// Streaming is not available with reference images.
+func WithReferenceImages(images ...[]byte) Option {
+	return func(o *Options) { o.referenceImages = images }
+}
+
 // Client implements [image.Generation] against the OpenAI image generation API.
 type Client struct {
 	options Options
@@ -275,12 +287,68 @@ func (c *Client) buildParams(prompt string) openaisdk.ImageGenerateParams {
 	return params
 }
 
-// GenerateImage performs a non-streaming image generation request.
+func (c *Client) buildEditParams(prompt string) openaisdk.ImageEditParams {
+	generate := c.buildParams(prompt)
+	files := make([]io.Reader, len(c.options.referenceImages))
+	for i, ref := range c.options.referenceImages {
+		mimeType := http.DetectContentType(ref)
+		files[i] = openaisdk.File(
+			bytes.NewReader(ref),
+			fmt.Sprintf("reference-%d.%s", i+1, extensionFor(mimeType)),
+			mimeType,
+		)
+	}
+
+	params := openaisdk.ImageEditParams{
+		Image:  openaisdk.ImageEditParamsImageUnion{OfFileArray: files},
+		Prompt: prompt,
+		Model:  generate.Model,
+		N:      generate.N,
+		User:   generate.User,
+	}
+	params.OutputCompression = generate.OutputCompression
+	if generate.Size != "" {
+		params.Size = openaisdk.ImageEditParamsSize(generate.Size)
+	}
+	if generate.Quality != "" {
+		params.Quality = openaisdk.ImageEditParamsQuality(generate.Quality)
+	}
+	if generate.Background != "" {
+		params.Background = openaisdk.ImageEditParamsBackground(
+			generate.Background,
+		)
+	}
+	if generate.OutputFormat != "" {
+		params.OutputFormat = openaisdk.ImageEditParamsOutputFormat(
+			generate.OutputFormat,
+		)
+	}
+	return params
+}
+
+func extensionFor(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/webp":
+		return "webp"
+	}
+	return "png"
+}
+
+// GenerateImage performs a non-streaming image generation request. When
+// reference images are configured the request goes to the image edits
+// endpoint with the images attached.
 func (c *Client) GenerateImage(
 	ctx context.Context,
 	prompt string,
 ) (*image.GenerationResponse, error) {
-	params := c.buildParams(prompt)
+	if err := image.CheckReferenceImages(
+		c.options.model,
+		len(c.options.referenceImages),
+	); err != nil {
+		return nil, err
+	}
 
 	if c.options.timeout != nil {
 		var cancel context.CancelFunc
@@ -288,7 +356,13 @@ func (c *Client) GenerateImage(
 		defer cancel()
 	}
 
-	response, err := c.client.Images.Generate(ctx, params)
+	var response *openaisdk.ImagesResponse
+	var err error
+	if len(c.options.referenceImages) > 0 {
+		response, err = c.client.Images.Edit(ctx, c.buildEditParams(prompt))
+	} else {
+		response, err = c.client.Images.Generate(ctx, c.buildParams(prompt))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate image: %w", err)
 	}
@@ -325,6 +399,12 @@ func (c *Client) GenerateImageStreaming(
 	if !c.options.model.SupportsStreaming {
 		return image.ErrStreamingNotSupported
 	}
+	if len(c.options.referenceImages) > 0 {
+		return fmt.Errorf(
+			"%w: reference images require GenerateImage",
+			image.ErrStreamingNotSupported,
+		)
+	}
 
 	params := c.buildParams(prompt)
 	params.PartialImages = openaisdk.Int(
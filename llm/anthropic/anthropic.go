@@ -6,12 +6,15 @@
 package anthropic
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -52,8 +55,12 @@ type Options struct {
 	disableCache    bool
 	reasoningEffort *ReasoningEffort
 	toolChoice      *llm.ToolChoice
+	user            string
 	builtinTools    []anthropicsdk.ToolUnionParam
 	httpClient      *http.Client
+	baseURL         string
+	fileCache       *llm.FileCache
+	citations       bool
 }
 
 // Option configures Options.
@@ -69,6 +76,21 @@ func WithAPIKey(
 // WithModel selects the LLM model.
 func WithModel(m model.Model) Option { return func(o *Options) { o.model = m } }
 
+// WithFileCache uploads images in messages to the Anthropic Files API once
+// and references them by file ID afterwards, instead of sending the base64
+// data with every request. The cache remembers uploads by content hash, so an
+// image that stays in the conversation history is uploaded only on its first
+// turn. It has no effect on Bedrock.
+func WithFileCache(cache *llm.FileCache) Option {
+	return func(o *Options) { o.fileCache = cache }
+}
+
+// WithBaseURL sets a custom API endpoint, such as a proxy in front of the
+// Anthropic API.
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) { o.baseURL = baseURL }
+}
+
 // WithMaxTokens sets the maximum number of tokens to generate.
 func WithMaxTokens(
 	maxTokens int64,
@@ -126,6 +148,14 @@ func WithReasoningEffort(effort ReasoningEffort) Option {
 	return func(o *Options) { o.reasoningEffort = &effort }
 }
 
+// WithUser sets the end-user ID sent as metadata.user_id, which Anthropic
+// uses to detect abuse by individual users of an application. Use an opaque
+// ID such as a hash. [llm.ContextWithUser] overrides it per call. Anthropic
+// has no free-form request metadata, so [llm.ContextWithMetadata] is ignored.
+func WithUser(userID string) Option {
+	return func(o *Options) { o.user = userID }
+}
+
 // WithToolChoice controls whether and which tool the model may call. It maps to
 // Anthropic's tool_choice field: {"type":"auto"} / {"type":"none"} /
 // {"type":"any"} / {"type":"tool","name":...}. The field is emitted only when
@@ -134,6 +164,14 @@ func WithToolChoice(choice llm.ToolChoice) Option {
 	return func(o *Options) { o.toolChoice = &choice }
 }
 
+// WithCitations enables citations on the documents attached to user messages
+// as [message.FileRef] parts, so the model cites the passages it draws on.
+// Citations are returned on [llm.Response].Citations. Web search results are
+// always cited and need no option.
+func WithCitations() Option {
+	return func(o *Options) { o.citations = true }
+}
+
 // WebSearchConfig configures the Anthropic server-side web_search tool.
 type WebSearchConfig struct {
 	MaxUses        int64
@@ -231,16 +269,30 @@ type Client struct {
 
 // NewLLM constructs an Anthropic LLM client. The returned [llm.LLM] is wrapped
 // with [llm.WithTracing], so callers always get tracing spans and metrics.
+// Settings not passed as options are read from AI_ANTHROPIC_API_KEY,
+// AI_ANTHROPIC_BASE_URL and AI_ANTHROPIC_MODEL.
 func NewLLM(opts ...Option) llm.LLM {
 	options := Options{}
 	for _, o := range opts {
 		o(&options)
 	}
+	applyEnv(&options)
 
 	clientOpts := []option.RequestOption{}
 	if options.apiKey != "" {
 		clientOpts = append(clientOpts, option.WithAPIKey(options.apiKey))
 	}
+	if options.baseURL != "" {
+		clientOpts = append(clientOpts, option.WithBaseURL(options.baseURL))
+	}
+	if options.useBedrock {
+		options.fileCache = nil
+	} else {
+		clientOpts = append(
+			clientOpts,
+			option.WithHeaderAdd("anthropic-beta", filesAPIBeta),
+		)
+	}
 	if options.useBedrock {
 		clientOpts = append(
 			clientOpts,
@@ -264,6 +316,142 @@ func NewLLM(opts ...Option) llm.LLM {
 	})
 }
 
+// filesAPIBeta is the beta flag required to upload and reference files.
+const filesAPIBeta = "files-api-2025-04-14"
+
+// uploadFile uploads data to the Files API for [llm.FileCache].
+func (c *Client) uploadFile(
+	ctx context.Context,
+	mimeType string,
+	data []byte,
+) (string, error) {
+	file, err := c.client.Beta.Files.Upload(
+		ctx,
+		anthropicsdk.BetaFileUploadParams{
+			File: anthropicsdk.File(bytes.NewReader(data), "upload", mimeType),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("anthropic: upload file: %w", wrapError(err))
+	}
+	return file.ID, nil
+}
+
+// UploadFile stores data with the Anthropic Files API and returns a reference
+// to attach with [message.Message.AddFileRef]. Images are sent as image
+// blocks and other types, such as PDFs and plain text, as document blocks.
+// Files are kept until deleted with [Client.DeleteFile]. Uploads are not
+// available on Bedrock.
+func (c *Client) UploadFile(
+	ctx context.Context,
+	data []byte,
+	mimeType string,
+) (message.FileRef, error) {
+	if c.options.useBedrock {
+		return message.FileRef{}, errBedrockFiles
+	}
+	file, err := c.client.Beta.Files.Upload(
+		ctx,
+		anthropicsdk.BetaFileUploadParams{
+			File: anthropicsdk.File(bytes.NewReader(data), "upload", mimeType),
+		},
+	)
+	if err != nil {
+		return message.FileRef{}, fmt.Errorf(
+			"anthropic: upload file: %w",
+			wrapError(err),
+		)
+	}
+	return message.FileRef{
+		Provider:  model.ProviderAnthropic,
+		ID:        file.ID,
+		MIMEType:  mimeType,
+		Name:      file.Filename,
+		SizeBytes: file.SizeBytes,
+	}, nil
+}
+
+// DeleteFile removes a file uploaded with [Client.UploadFile].
+func (c *Client) DeleteFile(ctx context.Context, ref message.FileRef) error {
+	if c.options.useBedrock {
+		return errBedrockFiles
+	}
+	_, err := c.client.Beta.Files.Delete(
+		ctx,
+		ref.ID,
+		anthropicsdk.BetaFileDeleteParams{},
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"anthropic: delete file %s: %w",
+			ref.ID,
+			wrapError(err),
+		)
+	}
+	return nil
+}
+
+var errBedrockFiles = errors.New(
+	"anthropic: file uploads are not supported on Bedrock",
+)
+
+// fileRefBlock returns an image or document block referencing an uploaded
+// file, chosen by its MIME type. citations enables citations on documents.
+func fileRefBlock(
+	ref message.FileRef,
+	citations bool,
+) anthropicsdk.ContentBlockParamUnion {
+	if strings.HasPrefix(ref.MIMEType, "image/") {
+		return fileImageBlock(ref.ID)
+	}
+	block := map[string]any{
+		"type": "document",
+		"source": map[string]any{
+			"type":    "file",
+			"file_id": ref.ID,
+		},
+	}
+	if citations {
+		block["citations"] = map[string]any{"enabled": true}
+	}
+	document := param.Override[anthropicsdk.DocumentBlockParam](block)
+	return anthropicsdk.ContentBlockParamUnion{OfDocument: &document}
+}
+
+// fileImageBlock returns an image block referencing an uploaded file. The
+// SDK's non-beta message types have no file source, so the block is built
+// from raw JSON.
+func fileImageBlock(fileID string) anthropicsdk.ContentBlockParamUnion {
+	image := param.Override[anthropicsdk.ImageBlockParam](map[string]any{
+		"type": "image",
+		"source": map[string]any{
+			"type":    "file",
+			"file_id": fileID,
+		},
+	})
+	return anthropicsdk.ContentBlockParamUnion{OfImage: &image}
+}
+
+// applyEnv fills in the API key, base URL and model from the
+// AI_ANTHROPIC_* environment variables when they were not set by options.
+// Bedrock clients authenticate with AWS credentials and are left untouched.
+func applyEnv(o *Options) {
+	if o.useBedrock {
+		return
+	}
+	if o.apiKey == "" {
+		o.apiKey = llm.Env(model.ProviderAnthropic, llm.EnvAPIKey)
+	}
+	if o.baseURL == "" {
+		o.baseURL = llm.Env(model.ProviderAnthropic, llm.EnvBaseURL)
+	}
+	if o.model.ID == "" && o.model.APIModel == "" {
+		if m, ok := llm.ModelFromEnv(model.ProviderAnthropic); ok {
+			o.model = m
+		}
+	}
+}
+
 // Model returns the configured LLM model.
 func (c *Client) Model() model.Model { return c.options.model }
 
@@ -274,7 +462,10 @@ func (c *Client) SupportsStructuredOutput() bool {
 
 func (c *Client) convertMessages(
 	messages []message.Message,
-) (anthropicMessages []anthropicsdk.MessageParam, systemMessages []string) {
+) (
+	anthropicMessages []anthropicsdk.MessageParam,
+	systemMessages []message.Message,
+) {
 	for i, msg := range messages {
 		cache := false
 		if i == len(messages)-1 && !c.options.disableCache {
@@ -282,7 +473,7 @@ func (c *Client) convertMessages(
 		}
 		switch msg.Role {
 		case message.System:
-			systemMessages = append(systemMessages, msg.Content().String())
+			systemMessages = append(systemMessages, msg)
 		case message.User:
 			content := anthropicsdk.NewTextBlock(msg.Content().String())
 			if cache {
@@ -294,6 +485,13 @@ func (c *Client) convertMessages(
 			contentBlocks = append(contentBlocks, content)
 
 			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.FileID != "" {
+					contentBlocks = append(
+						contentBlocks,
+						fileImageBlock(binaryContent.FileID),
+					)
+					continue
+				}
 				base64Image := binaryContent.String(model.ProviderAnthropic)
 				imageBlock := anthropicsdk.NewImageBlockBase64(
 					binaryContent.MIMEType,
@@ -312,6 +510,13 @@ func (c *Client) convertMessages(
 				contentBlocks = append(contentBlocks, imageBlock)
 			}
 
+			for _, ref := range msg.FileRefs() {
+				contentBlocks = append(
+					contentBlocks,
+					fileRefBlock(ref, c.options.citations),
+				)
+			}
+
 			anthropicMessages = append(
 				anthropicMessages,
 				anthropicsdk.NewUserMessage(contentBlocks...),
@@ -356,11 +561,7 @@ func (c *Client) convertMessages(
 				len(msg.ToolResults()),
 			)
 			for i, toolResult := range msg.ToolResults() {
-				results[i] = anthropicsdk.NewToolResultBlock(
-					toolResult.ToolCallID,
-					toolResult.Content,
-					toolResult.IsError,
-				)
+				results[i] = toolResultBlock(toolResult)
 			}
 			anthropicMessages = append(
 				anthropicMessages,
@@ -371,6 +572,38 @@ func (c *Client) convertMessages(
 	return
 }
 
+// toolResultBlock converts a tool result to a tool_result block, attaching its
+// images as image content. The text block is omitted when an image result has
+// no text, since Anthropic rejects empty text blocks.
+func toolResultBlock(
+	toolResult message.ToolResult,
+) anthropicsdk.ContentBlockParamUnion {
+	block := anthropicsdk.NewToolResultBlock(
+		toolResult.ToolCallID,
+		toolResult.Content,
+		toolResult.IsError,
+	)
+	if len(toolResult.Images) == 0 {
+		return block
+	}
+	if toolResult.Content == "" {
+		block.OfToolResult.Content = nil
+	}
+	for _, img := range toolResult.Images {
+		image := anthropicsdk.NewImageBlockBase64(
+			img.MIMEType,
+			img.String(model.ProviderAnthropic),
+		)
+		block.OfToolResult.Content = append(
+			block.OfToolResult.Content,
+			anthropicsdk.ToolResultBlockParamContentUnion{
+				OfImage: image.OfImage,
+			},
+		)
+	}
+	return block
+}
+
 func (c *Client) convertTools(
 	tools []tool.BaseTool,
 ) []anthropicsdk.ToolUnionParam {
@@ -452,7 +685,7 @@ func usesLegacyExtendedThinking(apiModel string) bool {
 func (c *Client) preparedMessages(
 	messages []anthropicsdk.MessageParam,
 	tools []anthropicsdk.ToolUnionParam,
-	systemMessages []string,
+	systemMessages []message.Message,
 ) anthropicsdk.MessageNewParams {
 	var thinkingParam anthropicsdk.ThinkingConfigParamUnion
 	var outputConfig anthropicsdk.OutputConfigParam
@@ -519,11 +752,19 @@ func (c *Client) preparedMessages(
 		params.ToolChoice = toolChoiceParam(*c.options.toolChoice)
 	}
 
+	if c.options.user != "" {
+		params.Metadata = anthropicsdk.MetadataParam{
+			UserID: anthropicsdk.String(c.options.user),
+		}
+	}
+
 	if len(systemMessages) > 0 {
 		systemBlocks := make([]anthropicsdk.TextBlockParam, len(systemMessages))
 		for i, sysMsg := range systemMessages {
-			block := anthropicsdk.TextBlockParam{Text: sysMsg}
-			if i == len(systemMessages)-1 && !c.options.disableCache {
+			block := anthropicsdk.TextBlockParam{
+				Text: sysMsg.Content().String(),
+			}
+			if c.cacheSystemBlock(systemMessages, i) {
 				block.CacheControl = anthropicsdk.CacheControlEphemeralParam{
 					Type: "ephemeral",
 				}
@@ -536,6 +777,95 @@ func (c *Client) preparedMessages(
 	return params
 }
 
+// cacheSystemBlock reports whether system message i gets a cache breakpoint.
+// Messages marked [message.CacheBreakpoint] always do. When none is marked,
+// the last system message does unless it is marked [message.CacheSkip].
+func (c *Client) cacheSystemBlock(
+	systemMessages []message.Message,
+	i int,
+) bool {
+	if c.options.disableCache {
+		return false
+	}
+	switch systemMessages[i].Cache {
+	case message.CacheBreakpoint:
+		return true
+	case message.CacheSkip:
+		return false
+	}
+	if i != len(systemMessages)-1 {
+		return false
+	}
+	for _, msg := range systemMessages {
+		if msg.Cache == message.CacheBreakpoint {
+			return false
+		}
+	}
+	return true
+}
+
+// forRequest returns c, or a copy of c using the tool choice, end-user ID,
+// web search, and reasoning effort carried by ctx (see
+// [llm.ContextWithToolChoice], [llm.ContextWithUser],
+// [llm.ContextWithWebSearch], and [llm.ContextWithReasoningEffort]) when any
+// is set.
+func (c *Client) forRequest(ctx context.Context) *Client {
+	choice, hasChoice := llm.ToolChoiceFromContext(ctx)
+	user, hasUser := llm.UserFromContext(ctx)
+	search, hasSearch := llm.WebSearchFromContext(ctx)
+	hasSearch = hasSearch && !c.hasWebSearch()
+	effort, hasEffort := llm.ReasoningEffortFromContext(ctx)
+	if !hasChoice && !hasUser && !hasSearch && !hasEffort {
+		return c
+	}
+	clone := *c
+	if hasChoice {
+		clone.options.toolChoice = &choice
+	}
+	if hasUser {
+		clone.options.user = user
+	}
+	if hasEffort {
+		e := ReasoningEffort(effort)
+		clone.options.reasoningEffort = &e
+	}
+	if hasSearch {
+		clone.options.builtinTools = slices.Clone(c.options.builtinTools)
+		WithWebSearch(webSearchConfig(search))(&clone.options)
+	}
+	return &clone
+}
+
+// hasWebSearch reports whether the client was configured with
+// [WithWebSearch].
+func (c *Client) hasWebSearch() bool {
+	for _, t := range c.options.builtinTools {
+		if t.OfWebSearchTool20250305 != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// webSearchConfig maps a vendor-neutral [llm.WebSearch] onto
+// [WebSearchConfig].
+func webSearchConfig(search llm.WebSearch) WebSearchConfig {
+	cfg := WebSearchConfig{
+		MaxUses:        int64(search.MaxUses),
+		AllowedDomains: search.AllowedDomains,
+		BlockedDomains: search.BlockedDomains,
+	}
+	if loc := search.Location; loc != nil {
+		cfg.UserLocation = &WebSearchUserLocation{
+			City:     loc.City,
+			Country:  loc.Country,
+			Region:   loc.Region,
+			Timezone: loc.Timezone,
+		}
+	}
+	return cfg
+}
+
 // validateToolChoice rejects a malformed tool choice before a request is sent.
 func (c *Client) validateToolChoice() error {
 	if c.options.toolChoice == nil {
@@ -560,9 +890,21 @@ func (c *Client) SendMessages(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	if err := llm.RejectVideo(model.ProviderAnthropic, messages); err != nil {
+		return nil, err
+	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return nil, err
+	}
 	anthropicMessages, systemMessages := c.convertMessages(messages)
 	preparedMessages := c.preparedMessages(
 		anthropicMessages, c.convertTools(tools), systemMessages,
@@ -585,7 +927,7 @@ func (c *Client) SendMessages(
 				return nil, wrapError(err)
 			}
 
-			content, meta := c.extractContent(*anthropicResponse)
+			content, citations, meta := c.extractContent(*anthropicResponse)
 			resp := &llm.Response{
 				Content:   content,
 				ToolCalls: c.toolCalls(*anthropicResponse),
@@ -594,6 +936,7 @@ func (c *Client) SendMessages(
 					string(anthropicResponse.StopReason),
 				),
 				ProviderMetadata: meta,
+				Citations:        citations,
 			}
 			applyResponseHeaders(resp, raw)
 			return resp, nil
@@ -617,9 +960,21 @@ func (c *Client) StreamResponse(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return errorEvent(err)
 	}
+	if err := llm.RejectVideo(model.ProviderAnthropic, messages); err != nil {
+		return errorEvent(err)
+	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return errorEvent(err)
+	}
 	anthropicMessages, systemMessages := c.convertMessages(messages)
 	preparedMessages := c.preparedMessages(
 		anthropicMessages, c.convertTools(tools), systemMessages,
@@ -660,6 +1015,10 @@ func (c *Client) runStream(
 		}
 
 		switch event := event.AsAny().(type) {
+		case anthropicsdk.MessageStartEvent, anthropicsdk.MessageDeltaEvent:
+			usage := c.usage(accumulatedMessage)
+			eventChan <- llm.Event{Type: types.EventUsageUpdate, Usage: &usage}
+
 		case anthropicsdk.ContentBlockStartEvent:
 			currentBlockType = event.ContentBlock.Type
 			switch event.ContentBlock.Type {
@@ -719,7 +1078,7 @@ func (c *Client) runStream(
 			currentToolCallID = ""
 
 		case anthropicsdk.MessageStopEvent:
-			content, meta := c.extractContent(accumulatedMessage)
+			content, citations, meta := c.extractContent(accumulatedMessage)
 			resp := &llm.Response{
 				Content:   content,
 				ToolCalls: c.toolCalls(accumulatedMessage),
@@ -728,6 +1087,7 @@ func (c *Client) runStream(
 					string(accumulatedMessage.StopReason),
 				),
 				ProviderMetadata: meta,
+				Citations:        citations,
 			}
 			applyResponseHeaders(resp, raw)
 			if structured {
@@ -748,13 +1108,21 @@ func (c *Client) runStream(
 // assistant text plus any provider metadata from server-side built-in tools.
 func (c *Client) extractContent(
 	msg anthropicsdk.Message,
-) (string, map[string]any) {
+) (string, []message.Citation, map[string]any) {
 	var content string
+	var citations []message.Citation
 	var searchResults []map[string]any
 	for _, block := range msg.Content {
 		switch v := block.AsAny().(type) {
 		case anthropicsdk.TextBlock:
+			start := len(content)
 			content += v.Text
+			for _, cit := range v.Citations {
+				citations = append(
+					citations,
+					convertCitation(cit, start, len(content)),
+				)
+			}
 		case anthropicsdk.WebSearchToolResultBlock:
 			results := v.Content.AsWebSearchResultBlockArray()
 			for _, r := range results {
@@ -772,7 +1140,45 @@ func (c *Client) extractContent(
 	if len(searchResults) > 0 {
 		meta = map[string]any{"anthropic.web_search_results": searchResults}
 	}
-	return content, meta
+	return content, citations, meta
+}
+
+// convertCitation maps an Anthropic text citation onto [message.Citation].
+// start and end are the offsets of the cited text block within the
+// concatenated response content.
+func convertCitation(
+	cit anthropicsdk.TextCitationUnion,
+	start, end int,
+) message.Citation {
+	out := message.Citation{
+		Type:          cit.Type,
+		Title:         cit.DocumentTitle,
+		URL:           cit.URL,
+		CitedText:     cit.CitedText,
+		DocumentIndex: int(cit.DocumentIndex),
+		Start:         start,
+		End:           end,
+	}
+	if out.Title == "" {
+		out.Title = cit.Title
+	}
+	switch cit.Type {
+	case "char_location":
+		out.SourceStart = int(cit.StartCharIndex)
+		out.SourceEnd = int(cit.EndCharIndex)
+	case "page_location":
+		out.SourceStart = int(cit.StartPageNumber)
+		out.SourceEnd = int(cit.EndPageNumber)
+	case "content_block_location":
+		out.SourceStart = int(cit.StartBlockIndex)
+		out.SourceEnd = int(cit.EndBlockIndex)
+	case "search_result_location":
+		out.URL = cit.Source
+		out.DocumentIndex = int(cit.SearchResultIndex)
+		out.SourceStart = int(cit.StartBlockIndex)
+		out.SourceEnd = int(cit.EndBlockIndex)
+	}
+	return out
 }
 
 func (c *Client) toolCalls(msg anthropicsdk.Message) []message.ToolCall {
@@ -803,16 +1209,10 @@ func (c *Client) usage(msg anthropicsdk.Message) llm.TokenUsage {
 func (c *Client) buildOutputConfig(
 	outputSchema *schema.StructuredOutputInfo,
 ) anthropicsdk.OutputConfigParam {
-	schemaMap := map[string]any{
-		"type":                 "object",
-		"properties":           outputSchema.Parameters,
-		"additionalProperties": false,
-	}
-	if len(outputSchema.Required) > 0 {
-		schemaMap["required"] = outputSchema.Required
-	}
 	return anthropicsdk.OutputConfigParam{
-		Format: anthropicsdk.JSONOutputFormatParam{Schema: schemaMap},
+		Format: anthropicsdk.JSONOutputFormatParam{
+			Schema: schema.CloseObjects(outputSchema.JSONSchema()),
+		},
 	}
 }
 
@@ -823,9 +1223,21 @@ func (c *Client) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	if err := llm.RejectVideo(model.ProviderAnthropic, messages); err != nil {
+		return nil, err
+	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return nil, err
+	}
 	anthropicMessages, systemMessages := c.convertMessages(messages)
 	preparedMessages := c.preparedMessages(
 		anthropicMessages, c.convertTools(tools), systemMessages,
@@ -849,7 +1261,7 @@ func (c *Client) SendMessagesWithStructuredOutput(
 				return nil, wrapError(err)
 			}
 
-			content, meta := c.extractContent(*anthropicResponse)
+			content, citations, meta := c.extractContent(*anthropicResponse)
 			resp := &llm.Response{
 				Content:   content,
 				ToolCalls: c.toolCalls(*anthropicResponse),
@@ -860,6 +1272,7 @@ func (c *Client) SendMessagesWithStructuredOutput(
 				StructuredOutput:           &content,
 				UsedNativeStructuredOutput: true,
 				ProviderMetadata:           meta,
+				Citations:                  citations,
 			}
 			applyResponseHeaders(resp, raw)
 			return resp, nil
@@ -874,9 +1287,21 @@ func (c *Client) StreamResponseWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return errorEvent(err)
 	}
+	if err := llm.RejectVideo(model.ProviderAnthropic, messages); err != nil {
+		return errorEvent(err)
+	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return errorEvent(err)
+	}
 	anthropicMessages, systemMessages := c.convertMessages(messages)
 	preparedMessages := c.preparedMessages(
 		anthropicMessages, c.convertTools(tools), systemMessages,
@@ -7,12 +7,16 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	anthropicsdk "github.com/anthropics/anthropic-sdk-go"
 	"github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
 	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
 )
 
 // stubTool is a no-op BaseTool used to populate the request's tools slice so
@@ -183,3 +187,541 @@ func TestWithHTTPClientTransportUsed(t *testing.T) {
 		t.Error("injected transport was not used for the request")
 	}
 }
+
+// TestToolResultBlockWithImage confirms image tool results are sent as image
+// content inside the tool_result block, without an empty text block.
+func TestToolResultBlockWithImage(t *testing.T) {
+	block := toolResultBlock(message.ToolResult{
+		ToolCallID: "call_1",
+		Images: []message.BinaryContent{
+			{MIMEType: "image/png", Data: []byte("png")},
+		},
+	})
+
+	raw, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("marshal block: %v", err)
+	}
+	var body struct {
+		Content []struct {
+			Type   string `json:"type"`
+			Source struct {
+				MediaType string `json:"media_type"`
+				Data      string `json:"data"`
+			} `json:"source"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("unmarshal block: %v", err)
+	}
+	if len(body.Content) != 1 || body.Content[0].Type != "image" {
+		t.Fatalf("expected a single image block, got %s", raw)
+	}
+	if body.Content[0].Source.MediaType != "image/png" ||
+		body.Content[0].Source.Data != "cG5n" {
+		t.Errorf("unexpected image source: %s", raw)
+	}
+}
+
+// TestToolResultBlockTextOnly confirms plain results keep their text block.
+func TestToolResultBlockTextOnly(t *testing.T) {
+	block := toolResultBlock(message.ToolResult{
+		ToolCallID: "call_1",
+		Content:    "ok",
+	})
+	content := block.OfToolResult.Content
+	if len(content) != 1 || content[0].OfText == nil ||
+		content[0].OfText.Text != "ok" {
+		t.Errorf("expected single text block, got %+v", content)
+	}
+}
+
+// TestBuildOutputConfigPassesFullSchema confirms a complete JSON Schema keeps
+// its $defs and unions while every object is closed.
+func TestBuildOutputConfigPassesFullSchema(t *testing.T) {
+	c := &Client{}
+	cfg := c.buildOutputConfig(schema.NewStructuredOutputFromSchema(
+		"result", "", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"item": map[string]any{"oneOf": []any{
+					map[string]any{"$ref": "#/$defs/leaf"},
+				}},
+			},
+			"$defs": map[string]any{
+				"leaf": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"value": map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+	))
+
+	got := cfg.Format.Schema
+	if got["additionalProperties"] != false {
+		t.Errorf("expected root closed, got %v", got)
+	}
+	leaf := got["$defs"].(map[string]any)["leaf"].(map[string]any)
+	if leaf["additionalProperties"] != false {
+		t.Errorf("expected $defs object closed, got %v", leaf)
+	}
+	item := got["properties"].(map[string]any)["item"].(map[string]any)
+	if _, ok := item["oneOf"]; !ok {
+		t.Errorf("expected oneOf passed through, got %v", item)
+	}
+}
+
+// TestStructuredOutputHeaders confirms structured output goes out as
+// output_config.format with only the Files API beta flag, since structured
+// outputs and prompt caching need no beta header.
+func TestStructuredOutputHeaders(t *testing.T) {
+	var body map[string]any
+	var beta string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			beta = r.Header.Get("anthropic-beta")
+			raw, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(raw, &body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, messageOK)
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+	)
+	if _, err := client.SendMessagesWithStructuredOutput(
+		context.Background(),
+		[]message.Message{message.NewUserMessage("classify")},
+		nil,
+		&schema.StructuredOutputInfo{Name: "label"},
+	); err != nil {
+		t.Fatalf("SendMessagesWithStructuredOutput: %v", err)
+	}
+
+	if beta != filesAPIBeta {
+		t.Errorf("anthropic-beta = %q, want %q", beta, filesAPIBeta)
+	}
+	config, _ := body["output_config"].(map[string]any)
+	format, _ := config["format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Errorf("output_config = %v", body["output_config"])
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("AI_ANTHROPIC_API_KEY", "env-key")
+	t.Setenv("AI_ANTHROPIC_BASE_URL", "https://proxy.example.test")
+	t.Setenv("AI_ANTHROPIC_MODEL", string(model.Claude45Sonnet))
+
+	var fromEnv Options
+	applyEnv(&fromEnv)
+	if fromEnv.apiKey != "env-key" ||
+		fromEnv.baseURL != "https://proxy.example.test" ||
+		fromEnv.model.ID != model.Claude45Sonnet {
+		t.Errorf("environment not applied: %+v", fromEnv)
+	}
+
+	explicit := Options{
+		apiKey:  "option-key",
+		baseURL: "https://option.example.test",
+		model:   model.AnthropicModels[model.Claude45Haiku],
+	}
+	applyEnv(&explicit)
+	if explicit.apiKey != "option-key" ||
+		explicit.baseURL != "https://option.example.test" ||
+		explicit.model.ID != model.Claude45Haiku {
+		t.Errorf("options overridden by environment: %+v", explicit)
+	}
+
+	bedrock := Options{useBedrock: true}
+	applyEnv(&bedrock)
+	if bedrock.apiKey != "" || bedrock.model.ID != "" {
+		t.Errorf("environment applied to a Bedrock client: %+v", bedrock)
+	}
+}
+
+// TestFileCacheUploadsOnce confirms an image repeated across requests is
+// uploaded once and then referenced by file ID with the Files API beta header.
+func TestFileCacheUploadsOnce(t *testing.T) {
+	var uploads int
+	var bodies []string
+	var betas []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/v1/files" {
+				uploads++
+				_, _ = io.WriteString(w, `{"id":"file_abc","type":"file",`+
+					`"filename":"upload","mime_type":"image/png",`+
+					`"size_bytes":3,"created_at":"2025-01-01T00:00:00Z"}`)
+				return
+			}
+			raw, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(raw))
+			betas = append(betas, r.Header.Get("anthropic-beta"))
+			_, _ = io.WriteString(w, messageOK)
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+		WithFileCache(llm.NewFileCache()),
+	)
+
+	msg := message.NewUserMessage("what is this?")
+	msg.AddBinary("image/png", []byte("png"))
+	history := []message.Message{msg}
+	for range 2 {
+		if _, err := client.SendMessages(
+			context.Background(),
+			history,
+			nil,
+		); err != nil {
+			t.Fatalf("SendMessages: %v", err)
+		}
+		history = append(history, message.NewUserMessage("and now?"))
+	}
+
+	if uploads != 1 {
+		t.Errorf("uploads = %d, want 1", uploads)
+	}
+	for i, body := range bodies {
+		if !strings.Contains(body, `"file_id":"file_abc"`) ||
+			strings.Contains(body, `"base64"`) {
+			t.Errorf("request %d does not reference the file: %s", i, body)
+		}
+		if !strings.Contains(betas[i], filesAPIBeta) {
+			t.Errorf("request %d beta header = %q", i, betas[i])
+		}
+	}
+	if history[0].BinaryContent()[0].FileID != "" {
+		t.Error("caller's message was modified")
+	}
+}
+
+func TestUploadFileRef(t *testing.T) {
+	var body string
+	var deleted string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+				_, _ = io.WriteString(w, `{"id":"file_pdf","type":"file",`+
+					`"filename":"report.pdf","mime_type":"application/pdf",`+
+					`"size_bytes":4,"created_at":"2025-01-01T00:00:00Z"}`)
+			case r.Method == http.MethodDelete:
+				deleted = r.URL.Path
+				_, _ = io.WriteString(w, `{"id":"file_pdf",`+
+					`"type":"file_deleted"}`)
+			default:
+				raw, _ := io.ReadAll(r.Body)
+				body = string(raw)
+				_, _ = io.WriteString(w, messageOK)
+			}
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+	)
+	uploader, ok := llm.AsFileUploader(client)
+	if !ok {
+		t.Fatal("client does not support file uploads")
+	}
+
+	ctx := context.Background()
+	ref, err := uploader.UploadFile(ctx, []byte("%PDF"), "application/pdf")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if ref.ID != "file_pdf" || ref.Provider != model.ProviderAnthropic ||
+		ref.SizeBytes != 4 || !ref.ExpiresAt.IsZero() {
+		t.Errorf("ref = %+v", ref)
+	}
+
+	msg := message.NewUserMessage("summarize this")
+	msg.AddFileRef(ref)
+	if _, err := client.SendMessages(
+		ctx,
+		[]message.Message{msg},
+		nil,
+	); err != nil {
+		t.Fatalf("SendMessages: %v", err)
+	}
+	if !strings.Contains(body, `"type":"document"`) ||
+		!strings.Contains(body, `"file_id":"file_pdf"`) {
+		t.Errorf("request does not reference the document: %s", body)
+	}
+
+	if err := uploader.DeleteFile(ctx, ref); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if deleted != "/v1/files/file_pdf" {
+		t.Errorf("deleted path = %q", deleted)
+	}
+}
+
+func TestVideoRejected(t *testing.T) {
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL("http://127.0.0.1:0"),
+		WithModel(model.Model{APIModel: "claude"}),
+	)
+	msg := message.NewUserMessage("what happens?")
+	msg.AddVideoURL("https://www.youtube.com/watch?v=abc", nil)
+	history := []message.Message{msg}
+
+	_, err := client.SendMessages(context.Background(), history, nil)
+	if !errors.Is(err, llm.ErrCapabilityUnsupported) {
+		t.Errorf("SendMessages err = %v", err)
+	}
+	for event := range client.StreamResponse(
+		context.Background(),
+		history,
+		nil,
+	) {
+		if !errors.Is(event.Error, llm.ErrCapabilityUnsupported) {
+			t.Errorf("stream event = %+v", event)
+		}
+	}
+}
+
+func TestUserMetadata(t *testing.T) {
+	body := toolChoiceBody(t, nil, WithUser("user-hash"))
+	md, ok := body["metadata"].(map[string]any)
+	if !ok || md["user_id"] != "user-hash" {
+		t.Fatalf("metadata = %v, want user_id", body["metadata"])
+	}
+
+	body = toolChoiceBody(t, nil)
+	if _, ok := body["metadata"]; ok {
+		t.Errorf("metadata sent without a user: %v", body["metadata"])
+	}
+}
+
+func TestUserFromContextOverridesOption(t *testing.T) {
+	c := &Client{options: optsFrom(WithUser("default"))}
+	ctx := llm.ContextWithUser(context.Background(), "per-call")
+	if got := c.forRequest(ctx).options.user; got != "per-call" {
+		t.Errorf("user = %q, want per-call", got)
+	}
+	if c.options.user != "default" {
+		t.Error("forRequest modified the shared client")
+	}
+}
+
+func TestExtractContentCitations(t *testing.T) {
+	var msg anthropicsdk.Message
+	raw := `{"id":"msg_1","type":"message","role":"assistant",` +
+		`"model":"claude","stop_reason":"end_turn","content":[` +
+		`{"type":"text","text":"Intro. "},` +
+		`{"type":"text","text":"The sky is blue.","citations":[` +
+		`{"type":"char_location","cited_text":"sky is blue",` +
+		`"document_index":1,"document_title":"Notes",` +
+		`"start_char_index":4,"end_char_index":15}]},` +
+		`{"type":"text","text":" See web.","citations":[` +
+		`{"type":"web_search_result_location","cited_text":"x",` +
+		`"url":"https://example.com","title":"Example",` +
+		`"encrypted_index":"e"}]}],` +
+		`"usage":{"input_tokens":1,"output_tokens":1}}`
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	content, citations, _ := (&Client{}).extractContent(msg)
+	if len(citations) != 2 {
+		t.Fatalf("citations = %+v, want 2", citations)
+	}
+	doc := citations[0]
+	if content[doc.Start:doc.End] != "The sky is blue." ||
+		doc.Title != "Notes" || doc.DocumentIndex != 1 ||
+		doc.SourceStart != 4 || doc.SourceEnd != 15 ||
+		doc.CitedText != "sky is blue" {
+		t.Errorf("document citation = %+v", doc)
+	}
+	web := citations[1]
+	if web.URL != "https://example.com" || web.Title != "Example" ||
+		content[web.Start:web.End] != " See web." {
+		t.Errorf("web citation = %+v", web)
+	}
+}
+
+func TestCitationsEnabledOnDocuments(t *testing.T) {
+	ref := message.FileRef{ID: "file_1", MIMEType: "application/pdf"}
+	for _, enabled := range []bool{false, true} {
+		raw, err := json.Marshal(fileRefBlock(ref, enabled))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		got := strings.Contains(string(raw), `"citations":{"enabled":true}`)
+		if got != enabled {
+			t.Errorf("citations=%v: block = %s", enabled, raw)
+		}
+	}
+}
+
+func TestWebSearchFromContext(t *testing.T) {
+	ctx := llm.ContextWithWebSearch(context.Background(), llm.WebSearch{
+		MaxUses:        3,
+		BlockedDomains: []string{"example.com"},
+	})
+
+	c := &Client{options: optsFrom()}
+	tools := c.forRequest(ctx).convertTools(nil)
+	if len(tools) != 1 || tools[0].OfWebSearchTool20250305 == nil {
+		t.Fatalf("tools = %+v, want web search", tools)
+	}
+	p := tools[0].OfWebSearchTool20250305
+	if p.MaxUses.Value != 3 || len(p.BlockedDomains) != 1 {
+		t.Errorf("web search = %+v", p)
+	}
+	if len(c.options.builtinTools) != 0 {
+		t.Error("forRequest modified the shared client")
+	}
+
+	configured := &Client{options: optsFrom(
+		WithWebSearch(WebSearchConfig{MaxUses: 1}),
+	)}
+	tools = configured.forRequest(ctx).convertTools(nil)
+	if len(tools) != 1 ||
+		tools[0].OfWebSearchTool20250305.MaxUses.Value != 1 {
+		t.Errorf("configured web search replaced: %+v", tools)
+	}
+}
+
+func TestStreamUsageUpdates(t *testing.T) {
+	events := []string{
+		`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message",` +
+			`"role":"assistant","model":"claude","content":[],` +
+			`"usage":{"input_tokens":25,"output_tokens":1}}}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":0,` +
+			`"content_block":{"type":"text","text":""}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,` +
+			`"delta":{"type":"text_delta","text":"Hello"}}`,
+		`event: content_block_stop
+data: {"type":"content_block_stop","index":0}`,
+		`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},` +
+			`"usage":{"output_tokens":12}}`,
+		`event: message_stop
+data: {"type":"message_stop"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, e := range events {
+				_, _ = io.WriteString(w, e+"\n\n")
+			}
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+	)
+
+	var updates []llm.TokenUsage
+	var final *llm.Response
+	for event := range client.StreamResponse(
+		context.Background(),
+		[]message.Message{message.NewUserMessage("hi")},
+		nil,
+	) {
+		switch event.Type {
+		case types.EventUsageUpdate:
+			updates = append(updates, *event.Usage)
+		case types.EventComplete:
+			final = event.Response
+		case types.EventError:
+			t.Fatalf("stream error: %v", event.Error)
+		}
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("usage updates = %+v, want 2", updates)
+	}
+	if updates[0].InputTokens != 25 || updates[0].OutputTokens != 1 {
+		t.Errorf("first update = %+v", updates[0])
+	}
+	if updates[1].InputTokens != 25 || updates[1].OutputTokens != 12 {
+		t.Errorf("last update = %+v", updates[1])
+	}
+	if final == nil || final.Usage != updates[1] {
+		t.Errorf("final usage = %+v, want %+v", final, updates[1])
+	}
+}
+
+func TestSystemCacheHints(t *testing.T) {
+	system := func(text string, hint message.CacheHint) message.Message {
+		msg := message.NewSystemMessage(text)
+		msg.Cache = hint
+		return msg
+	}
+	tests := []struct {
+		name   string
+		system []message.Message
+		opts   []Option
+		want   []bool
+	}{
+		{
+			name: "default caches last",
+			system: []message.Message{
+				system("a", message.CacheDefault),
+				system("b", message.CacheDefault),
+			},
+			want: []bool{false, true},
+		},
+		{
+			name: "breakpoint moves the marker",
+			system: []message.Message{
+				system("stable", message.CacheBreakpoint),
+				system("per turn", message.CacheDefault),
+			},
+			want: []bool{true, false},
+		},
+		{
+			name: "skip suppresses the default",
+			system: []message.Message{
+				system("changing", message.CacheSkip),
+			},
+			want: []bool{false},
+		},
+		{
+			name: "disable cache wins",
+			system: []message.Message{
+				system("stable", message.CacheBreakpoint),
+			},
+			opts: []Option{WithDisableCache()},
+			want: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{options: optsFrom(tt.opts...)}
+			params := c.preparedMessages(nil, nil, tt.system)
+			if len(params.System) != len(tt.want) {
+				t.Fatalf("system blocks = %d", len(params.System))
+			}
+			for i, want := range tt.want {
+				got := params.System[i].CacheControl.Type == "ephemeral"
+				if got != want {
+					t.Errorf("block %d cached = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
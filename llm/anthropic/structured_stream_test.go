@@ -0,0 +1,141 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func sseEvent(t *testing.T, name string, data map[string]any) string {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", name, raw)
+}
+
+// TestStreamStructuredOutput confirms the streaming structured-output path
+// sends output_config.format with stream set, that the text deltas
+// concatenate to a document matching the schema, and that the response on
+// message_stop carries it as StructuredOutput.
+func TestStreamStructuredOutput(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(raw, &body)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, sseEvent(t, "message_start",
+				map[string]any{
+					"type": "message_start",
+					"message": map[string]any{
+						"id": "msg_1", "type": "message", "role": "assistant",
+						"model": "claude", "content": []any{},
+						"usage": map[string]any{"input_tokens": 20},
+					},
+				}))
+			_, _ = io.WriteString(w, sseEvent(t, "content_block_start",
+				map[string]any{
+					"type":          "content_block_start",
+					"index":         0,
+					"content_block": map[string]any{"type": "text", "text": ""},
+				}))
+			parts := []string{`{"city":`, `"Paris",`, `"temp":21}`}
+			for _, part := range parts {
+				_, _ = io.WriteString(w, sseEvent(t, "content_block_delta",
+					map[string]any{
+						"type":  "content_block_delta",
+						"index": 0,
+						"delta": map[string]any{
+							"type": "text_delta",
+							"text": part,
+						},
+					}))
+				w.(http.Flusher).Flush()
+			}
+			_, _ = io.WriteString(w, sseEvent(t, "content_block_stop",
+				map[string]any{"type": "content_block_stop", "index": 0}))
+			_, _ = io.WriteString(w, sseEvent(t, "message_delta",
+				map[string]any{
+					"type":  "message_delta",
+					"delta": map[string]any{"stop_reason": "end_turn"},
+					"usage": map[string]any{"output_tokens": 9},
+				}))
+			_, _ = io.WriteString(w, sseEvent(t, "message_stop",
+				map[string]any{"type": "message_stop"}))
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "claude"}),
+	)
+	output := schema.Object().
+		Field("city", schema.String()).
+		Field("temp", schema.Integer()).
+		Required("city", "temp").
+		Output("weather", "Current weather")
+
+	var streamed strings.Builder
+	var final *string
+	var native bool
+	var finish message.FinishReason
+	for evt := range client.StreamResponseWithStructuredOutput(
+		context.Background(),
+		[]message.Message{message.NewUserMessage("weather in Paris?")},
+		nil,
+		output,
+	) {
+		switch evt.Type {
+		case types.EventContentDelta:
+			streamed.WriteString(evt.Content)
+		case types.EventComplete:
+			final = evt.Response.StructuredOutput
+			native = evt.Response.UsedNativeStructuredOutput
+			finish = evt.Response.FinishReason
+		case types.EventError:
+			t.Fatalf("stream error: %v", evt.Error)
+		}
+	}
+
+	config, _ := body["output_config"].(map[string]any)
+	format, _ := config["format"].(map[string]any)
+	if format["type"] != "json_schema" || format["schema"] == nil {
+		t.Fatalf("output_config = %v, want a json_schema format", config)
+	}
+	if body["stream"] != true {
+		t.Errorf("stream = %v, want true", body["stream"])
+	}
+
+	var weather struct {
+		City string `json:"city"`
+		Temp int    `json:"temp"`
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(streamed.String())))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&weather); err != nil {
+		t.Fatalf("streamed %q does not parse: %v", streamed.String(), err)
+	}
+	if weather.City != "Paris" || weather.Temp != 21 {
+		t.Errorf("weather = %+v", weather)
+	}
+	if final == nil || *final != streamed.String() || !native {
+		t.Errorf("StructuredOutput = %v, native = %v", final, native)
+	}
+	if finish != message.FinishReasonEndTurn {
+		t.Errorf("FinishReason = %v, want end turn", finish)
+	}
+}
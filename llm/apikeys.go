@@ -0,0 +1,362 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ErrNoUsableAPIKeys is returned by a [WithAPIKeys] client once every key has
+// been dropped after an authentication failure, or when it was built without
+// keys.
+var ErrNoUsableAPIKeys = errors.New("no usable API keys")
+
+// KeySelection chooses which API key serves the next request.
+type KeySelection int
+
+const (
+	// KeySelectionRoundRobin hands requests to keys in turn. This is the
+	// default.
+	KeySelectionRoundRobin KeySelection = iota
+	// KeySelectionLeastLoaded hands each request to the key with the fewest
+	// requests in flight.
+	KeySelectionLeastLoaded
+)
+
+const defaultKeyCooldown = 30 * time.Second
+
+type apiKeyOptions struct {
+	selection KeySelection
+	cooldown  time.Duration
+}
+
+// APIKeyOption configures a [WithAPIKeys] client.
+type APIKeyOption func(*apiKeyOptions)
+
+// WithKeySelection sets how requests are spread across keys. Defaults to
+// [KeySelectionRoundRobin].
+func WithKeySelection(selection KeySelection) APIKeyOption {
+	return func(o *apiKeyOptions) { o.selection = selection }
+}
+
+// WithKeyCooldown sets how long a key is passed over after it is rate
+// limited without a Retry-After header. Defaults to 30 seconds.
+func WithKeyCooldown(d time.Duration) APIKeyOption {
+	return func(o *apiKeyOptions) { o.cooldown = d }
+}
+
+// WithAPIKeys returns an LLM that spreads requests across several API keys
+// for the same provider. newClient builds the vendor client for one key, for
+// example:
+//
+//	client := llm.WithAPIKeys(func(key string) llm.LLM {
+//		return llmopenai.NewLLM(
+//			llmopenai.WithAPIKey(key),
+//			llmopenai.WithModel(model.OpenAIModels[model.GPT5]),
+//		)
+//	}, keys)
+//
+// Each key tracks its own rate limit: a 429 response puts the key on cooldown
+// for the Retry-After period and the request moves to the next key. A 401 or
+// 403 drops the key for the lifetime of the client and the request moves on as
+// well. Errors are matched through [RetryableError]. Streams switch keys only
+// until the first event has been forwarded.
+//
+// Keys are identified by their position in keys in logs; the key itself is
+// never logged.
+func WithAPIKeys(
+	newClient func(apiKey string) LLM,
+	keys []string,
+	opts ...APIKeyOption,
+) LLM {
+	options := apiKeyOptions{cooldown: defaultKeyCooldown}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	pool := &keyPoolLLM{options: options}
+	for i, key := range keys {
+		pool.keys = append(pool.keys, &pooledKey{
+			client: newClient(key),
+			index:  i,
+		})
+	}
+	return pool
+}
+
+type pooledKey struct {
+	client    LLM
+	index     int
+	inFlight  int
+	coolUntil time.Time
+	revoked   bool
+}
+
+type keyPoolLLM struct {
+	mu      sync.Mutex
+	keys    []*pooledKey
+	next    int
+	options apiKeyOptions
+}
+
+func (p *keyPoolLLM) Model() model.Model {
+	if len(p.keys) == 0 {
+		return model.Model{}
+	}
+	return p.keys[0].client.Model()
+}
+
+func (p *keyPoolLLM) SupportsStructuredOutput() bool {
+	if len(p.keys) == 0 {
+		return false
+	}
+	return p.keys[0].client.SupportsStructuredOutput()
+}
+
+func (p *keyPoolLLM) Unwrap() LLM {
+	if len(p.keys) == 0 {
+		return nil
+	}
+	return p.keys[0].client
+}
+
+func (p *keyPoolLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	return p.send(ctx, func(client LLM) (*Response, error) {
+		return client.SendMessages(ctx, messages, tools)
+	})
+}
+
+func (p *keyPoolLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return p.send(ctx, func(client LLM) (*Response, error) {
+		return client.SendMessagesWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		)
+	})
+}
+
+func (p *keyPoolLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return p.stream(ctx, func(client LLM) <-chan Event {
+		return client.StreamResponse(ctx, messages, tools)
+	})
+}
+
+func (p *keyPoolLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return p.stream(ctx, func(client LLM) <-chan Event {
+		return client.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		)
+	})
+}
+
+func (p *keyPoolLLM) send(
+	ctx context.Context,
+	call func(LLM) (*Response, error),
+) (*Response, error) {
+	tried := make(map[*pooledKey]bool)
+	var lastErr error
+	for {
+		key, err := p.acquire(tried)
+		if err != nil {
+			return nil, exhaustedError(err, lastErr)
+		}
+		if key == nil {
+			return nil, lastErr
+		}
+		tried[key] = true
+
+		resp, err := call(key.client)
+		p.release(ctx, key, err)
+		if err == nil || !isKeyError(ctx, err) {
+			return resp, err
+		}
+		lastErr = err
+	}
+}
+
+func (p *keyPoolLLM) stream(
+	ctx context.Context,
+	open func(LLM) <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		tried := make(map[*pooledKey]bool)
+		var lastErr error
+		for {
+			key, err := p.acquire(tried)
+			if err != nil {
+				lastErr = exhaustedError(err, lastErr)
+			}
+			if key == nil {
+				select {
+				case outCh <- Event{Type: types.EventError, Error: lastErr}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			tried[key] = true
+
+			streamErr, retry := p.forward(ctx, key, open(key.client), outCh)
+			if !retry {
+				return
+			}
+			lastErr = streamErr
+		}
+	}()
+	return outCh
+}
+
+func (p *keyPoolLLM) forward(
+	ctx context.Context,
+	key *pooledKey,
+	innerCh <-chan Event,
+	outCh chan<- Event,
+) (streamErr error, retry bool) {
+	defer func() { p.release(ctx, key, streamErr) }()
+
+	forwarded := false
+	for evt := range innerCh {
+		if evt.Type == types.EventError && streamErr == nil {
+			streamErr = evt.Error
+			if !forwarded && isKeyError(ctx, evt.Error) {
+				drainEvents(innerCh)
+				return streamErr, true
+			}
+		}
+		forwarded = true
+		select {
+		case outCh <- evt:
+		case <-ctx.Done():
+			drainEvents(innerCh)
+			return streamErr, false
+		}
+	}
+	return streamErr, false
+}
+
+func (p *keyPoolLLM) acquire(
+	tried map[*pooledKey]bool,
+) (*pooledKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var ready, cooling *pooledKey
+	usable := false
+	for i := range p.keys {
+		key := p.keys[(p.next+i)%len(p.keys)]
+		if key.revoked {
+			continue
+		}
+		usable = true
+		if tried[key] {
+			continue
+		}
+		if key.coolUntil.After(now) {
+			if cooling == nil || key.coolUntil.Before(cooling.coolUntil) {
+				cooling = key
+			}
+			continue
+		}
+		if ready == nil ||
+			(p.options.selection == KeySelectionLeastLoaded &&
+				key.inFlight < ready.inFlight) {
+			ready = key
+		}
+	}
+	if !usable {
+		return nil, ErrNoUsableAPIKeys
+	}
+
+	key := ready
+	if key == nil {
+		key = cooling
+	}
+	if key != nil {
+		key.inFlight++
+		p.next = (key.index + 1) % len(p.keys)
+	}
+	return key, nil
+}
+
+func (p *keyPoolLLM) release(ctx context.Context, key *pooledKey, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key.inFlight--
+	var retryable RetryableError
+	if err == nil || !errors.As(err, &retryable) {
+		return
+	}
+	switch retryable.GetStatusCode() {
+	case 401, 403:
+		if !key.revoked {
+			key.revoked = true
+			retryLogger(ctx).Warn("Dropping API key after auth failure",
+				"key_index", key.index,
+				"error", err.Error())
+		}
+	case 429:
+		cooldown := p.options.cooldown
+		if retryAfter := retryable.GetRetryAfter(); retryAfter != "" {
+			if ms, err := parseRetryAfter(retryAfter); err == nil {
+				cooldown = time.Duration(ms) * time.Millisecond
+			}
+		}
+		key.coolUntil = time.Now().Add(cooldown)
+	}
+}
+
+func isKeyError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var retryable RetryableError
+	if !errors.As(err, &retryable) {
+		return false
+	}
+	switch retryable.GetStatusCode() {
+	case 401, 403, 429:
+		return true
+	}
+	return false
+}
+
+func exhaustedError(err, lastErr error) error {
+	if lastErr == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", err, lastErr)
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func statusError(code int) error {
+	return GenericRetryableError{
+		Err:        errors.New("provider error"),
+		StatusCode: code,
+	}
+}
+
+func keyClients(
+	clients map[string]*scriptedLLM,
+) func(string) LLM {
+	return func(key string) LLM {
+		c := clients[key]
+		c.model = model.Model{APIModel: key}
+		return c
+	}
+}
+
+func TestWithAPIKeys_RoundRobin(t *testing.T) {
+	clients := map[string]*scriptedLLM{
+		"a": {content: "a"},
+		"b": {content: "b"},
+	}
+	client := WithAPIKeys(keyClients(clients), []string{"a", "b"})
+
+	var got []string
+	for range 4 {
+		resp, err := client.SendMessages(context.Background(), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, resp.Content)
+	}
+	want := []string{"a", "b", "a", "b"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWithAPIKeys_DropsKeyOnAuthFailure(t *testing.T) {
+	clients := map[string]*scriptedLLM{
+		"revoked": {err: statusError(401)},
+		"good":    {content: "ok"},
+	}
+	client := WithAPIKeys(
+		keyClients(clients),
+		[]string{"revoked", "good"},
+	)
+
+	for range 3 {
+		resp, err := client.SendMessages(context.Background(), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Content != "ok" {
+			t.Errorf("expected good key, got %q", resp.Content)
+		}
+	}
+	if clients["revoked"].calls != 1 {
+		t.Errorf("expected revoked key used once, got %d",
+			clients["revoked"].calls)
+	}
+}
+
+func TestWithAPIKeys_RateLimitedKeyCoolsDown(t *testing.T) {
+	clients := map[string]*scriptedLLM{
+		"limited": {err: statusError(429)},
+		"spare":   {content: "ok"},
+	}
+	client := WithAPIKeys(
+		keyClients(clients),
+		[]string{"limited", "spare"},
+	)
+
+	for range 3 {
+		if _, err := client.SendMessages(
+			context.Background(), nil, nil,
+		); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if clients["limited"].calls != 1 {
+		t.Errorf("expected limited key skipped while cooling, got %d calls",
+			clients["limited"].calls)
+	}
+	if clients["spare"].calls != 3 {
+		t.Errorf("expected spare key to serve all, got %d calls",
+			clients["spare"].calls)
+	}
+}
+
+func TestWithAPIKeys_AllKeysRevoked(t *testing.T) {
+	clients := map[string]*scriptedLLM{
+		"a": {err: statusError(401)},
+		"b": {err: statusError(403)},
+	}
+	client := WithAPIKeys(keyClients(clients), []string{"a", "b"})
+
+	_, err := client.SendMessages(context.Background(), nil, nil)
+	if !errors.Is(err, ErrNoUsableAPIKeys) {
+		t.Fatalf("expected ErrNoUsableAPIKeys, got %v", err)
+	}
+
+	evt := <-client.StreamResponse(context.Background(), nil, nil)
+	if !errors.Is(evt.Error, ErrNoUsableAPIKeys) {
+		t.Fatalf("expected ErrNoUsableAPIKeys from stream, got %v", evt.Error)
+	}
+}
+
+func TestWithAPIKeys_ReturnsOtherErrorsUnchanged(t *testing.T) {
+	badRequest := statusError(400)
+	clients := map[string]*scriptedLLM{
+		"a": {err: badRequest},
+		"b": {content: "unused"},
+	}
+	client := WithAPIKeys(keyClients(clients), []string{"a", "b"})
+
+	_, err := client.SendMessages(context.Background(), nil, nil)
+	if !errors.Is(err, badRequest) {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+	if clients["b"].calls != 0 {
+		t.Errorf("expected second key untouched, got %d calls",
+			clients["b"].calls)
+	}
+}
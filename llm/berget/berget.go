@@ -9,6 +9,7 @@ package berget
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical Berget AI OpenAI-compatible API endpoint.
@@ -19,9 +20,13 @@ type Option = llmopenai.Option
 
 // NewLLM constructs a Berget AI LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override.
+// The endpoint defaults to [DefaultBaseURL]; AI_BERGET_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. The API key and model fall back
+// to AI_BERGET_API_KEY and AI_BERGET_MODEL when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderBerget),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
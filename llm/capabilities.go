@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ErrCapabilityUnsupported is returned when a request needs a capability the
+// model's flags say it lacks, such as images for a model without attachment
+// support or tools for a model marked [model.Model.ToolsUnsupported].
+var ErrCapabilityUnsupported = errors.New("model capability unsupported")
+
+// attachmentNote replaces a message whose only content was stripped by
+// [CapabilityAdapt], so the model knows something was sent.
+const attachmentNote = "[attachment omitted: this model cannot read images or files]"
+
+// ValidateCapabilities checks a request against the model's capability flags
+// before it is sent: tools require a model that supports them, and image or
+// file parts require [model.Model.SupportsAttachments]. It returns an error
+// wrapping [ErrCapabilityUnsupported] naming the first problem found.
+func ValidateCapabilities(
+	m model.Model,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) error {
+	if len(tools) > 0 && m.ToolsUnsupported {
+		return fmt.Errorf(
+			"%w: model %s does not support tools",
+			ErrCapabilityUnsupported,
+			m.APIModel,
+		)
+	}
+	if m.SupportsAttachments {
+		return nil
+	}
+	for i, msg := range messages {
+		if hasAttachments(msg) {
+			return fmt.Errorf(
+				"%w: model %s does not accept images or files (message %d)",
+				ErrCapabilityUnsupported,
+				m.APIModel,
+				i,
+			)
+		}
+	}
+	return nil
+}
+
+// RejectVideo returns an error wrapping [ErrCapabilityUnsupported] when any
+// message carries a [message.VideoContent] part. Vendor clients whose API has
+// no video input call it before sending, so video is never dropped silently.
+func RejectVideo(provider model.Provider, messages []message.Message) error {
+	for i, msg := range messages {
+		if len(msg.VideoContent()) > 0 {
+			return fmt.Errorf(
+				"%w: %s does not accept video input (message %d)",
+				ErrCapabilityUnsupported,
+				provider,
+				i,
+			)
+		}
+	}
+	return nil
+}
+
+// CapabilityMode selects how [WithCapabilityCheck] handles a request the model
+// cannot serve.
+type CapabilityMode int
+
+const (
+	// CapabilityReject fails the request with [ErrCapabilityUnsupported]
+	// before it reaches the provider.
+	CapabilityReject CapabilityMode = iota
+	// CapabilityAdapt removes the unsupported content (image and file parts,
+	// or the tool list), logs a warning, and sends the rest of the request.
+	CapabilityAdapt
+)
+
+// WithCapabilityCheck wraps an LLM client so every request is validated
+// against the model's capability flags with [ValidateCapabilities] before it
+// is sent, replacing confusing provider 400s with a clear error. With
+// [CapabilityAdapt] the request is stripped down to what the model supports
+// instead; warnings go to the logger on the request context, or slog.Default.
+func WithCapabilityCheck(inner LLM, mode CapabilityMode) LLM {
+	return &capabilityLLM{inner: inner, mode: mode}
+}
+
+type capabilityLLM struct {
+	inner LLM
+	mode  CapabilityMode
+}
+
+func (c *capabilityLLM) Model() model.Model {
+	return c.inner.Model()
+}
+
+func (c *capabilityLLM) Unwrap() LLM {
+	return c.inner
+}
+
+func (c *capabilityLLM) SupportsStructuredOutput() bool {
+	return c.inner.SupportsStructuredOutput()
+}
+
+func (c *capabilityLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	messages, tools, err := c.prepare(ctx, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.SendMessages(ctx, messages, tools)
+}
+
+func (c *capabilityLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	messages, tools, err := c.prepare(ctx, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+}
+
+func (c *capabilityLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	messages, tools, err := c.prepare(ctx, messages, tools)
+	if err != nil {
+		return errorEvent(err)
+	}
+	return c.inner.StreamResponse(ctx, messages, tools)
+}
+
+func (c *capabilityLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	messages, tools, err := c.prepare(ctx, messages, tools)
+	if err != nil {
+		return errorEvent(err)
+	}
+	return c.inner.StreamResponseWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+}
+
+func (c *capabilityLLM) prepare(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) ([]message.Message, []tool.BaseTool, error) {
+	m := c.inner.Model()
+	if c.mode == CapabilityReject {
+		return messages, tools, ValidateCapabilities(m, messages, tools)
+	}
+
+	if len(tools) > 0 && m.ToolsUnsupported {
+		retryLogger(ctx).WarnContext(ctx, "dropping tools unsupported by model",
+			slog.String("model", m.APIModel),
+			slog.Int("tools", len(tools)),
+		)
+		tools = nil
+	}
+	if !m.SupportsAttachments {
+		var stripped int
+		messages, stripped = stripAttachments(messages)
+		if stripped > 0 {
+			retryLogger(ctx).WarnContext(
+				ctx,
+				"dropping attachments unsupported by model",
+				slog.String("model", m.APIModel),
+				slog.Int("attachments", stripped),
+			)
+		}
+	}
+	return messages, tools, nil
+}
+
+func hasAttachments(msg message.Message) bool {
+	for _, part := range msg.Parts {
+		switch part.(type) {
+		case message.BinaryContent,
+			message.ImageURLContent,
+			message.FileRef,
+			message.VideoContent:
+			return true
+		}
+	}
+	return false
+}
+
+// stripAttachments returns messages without image and file parts, and how
+// many parts were removed. Messages are copied only when they change; one left
+// with no parts carries a short note instead.
+func stripAttachments(
+	messages []message.Message,
+) ([]message.Message, int) {
+	stripped := 0
+	out := make([]message.Message, len(messages))
+	for i, msg := range messages {
+		out[i] = msg
+		if !hasAttachments(msg) {
+			continue
+		}
+		parts := make([]message.ContentPart, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			switch part.(type) {
+			case message.BinaryContent,
+				message.ImageURLContent,
+				message.FileRef,
+				message.VideoContent:
+				stripped++
+			default:
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, message.TextContent{Text: attachmentNote})
+		}
+		out[i].Parts = parts
+	}
+	return out, stripped
+}
+
+// errorEvent returns a closed channel holding a single error event, for
+// decorators that fail a stream before it starts.
+func errorEvent(err error) <-chan Event {
+	ch := make(chan Event, 1)
+	ch <- Event{Type: types.EventError, Error: err}
+	close(ch)
+	return ch
+}
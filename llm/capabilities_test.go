@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// capturingLLM records the messages and tools of the last request it served.
+type capturingLLM struct {
+	scriptedLLM
+	messages []message.Message
+	tools    []tool.BaseTool
+}
+
+func (c *capturingLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	c.messages, c.tools = messages, tools
+	return c.scriptedLLM.SendMessages(ctx, messages, tools)
+}
+
+type noopTool struct{}
+
+func (noopTool) Info() tool.Info {
+	return tool.Info{Name: "noop", Parameters: map[string]any{}}
+}
+
+func (noopTool) Run(context.Context, tool.Call) (tool.Response, error) {
+	return tool.NewTextResponse("ok"), nil
+}
+
+func imageMessage(text string) message.Message {
+	msg := message.NewUserMessage(text)
+	msg.AddBinary("image/png", []byte("png"))
+	return msg
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	textOnly := model.Model{APIModel: "text-only"}
+	noTools := model.Model{
+		APIModel:            "no-tools",
+		SupportsAttachments: true,
+		ToolsUnsupported:    true,
+	}
+	image := []message.Message{imageMessage("describe")}
+	tools := []tool.BaseTool{noopTool{}}
+
+	if err := ValidateCapabilities(textOnly, image, nil); !errors.Is(
+		err,
+		ErrCapabilityUnsupported,
+	) {
+		t.Errorf("expected image rejected for text-only model, got %v", err)
+	}
+	if err := ValidateCapabilities(noTools, nil, tools); !errors.Is(
+		err,
+		ErrCapabilityUnsupported,
+	) {
+		t.Errorf("expected tools rejected, got %v", err)
+	}
+	if err := ValidateCapabilities(noTools, image, nil); err != nil {
+		t.Errorf("expected vision request allowed, got %v", err)
+	}
+	if err := ValidateCapabilities(textOnly, nil, tools); err != nil {
+		t.Errorf("expected tools allowed by default, got %v", err)
+	}
+}
+
+func TestWithCapabilityCheck_RejectsBeforeCall(t *testing.T) {
+	inner := &capturingLLM{
+		scriptedLLM: scriptedLLM{model: model.Model{APIModel: "text-only"}},
+	}
+	client := WithCapabilityCheck(inner, CapabilityReject)
+	messages := []message.Message{imageMessage("describe")}
+
+	_, err := client.SendMessages(context.Background(), messages, nil)
+	if !errors.Is(err, ErrCapabilityUnsupported) {
+		t.Fatalf("expected ErrCapabilityUnsupported, got %v", err)
+	}
+
+	var streamErr error
+	for evt := range client.StreamResponse(
+		context.Background(),
+		messages,
+		nil,
+	) {
+		if evt.Type == types.EventError {
+			streamErr = evt.Error
+		}
+	}
+	if !errors.Is(streamErr, ErrCapabilityUnsupported) {
+		t.Errorf("expected stream error event, got %v", streamErr)
+	}
+	if inner.calls != 0 {
+		t.Errorf("expected provider not called, got %d calls", inner.calls)
+	}
+}
+
+func TestWithCapabilityCheck_AdaptStripsContent(t *testing.T) {
+	inner := &capturingLLM{scriptedLLM: scriptedLLM{
+		model:   model.Model{APIModel: "limited", ToolsUnsupported: true},
+		content: "ok",
+	}}
+	client := WithCapabilityCheck(inner, CapabilityAdapt)
+
+	imageOnly := message.NewMessage(message.User, []message.ContentPart{
+		message.ImageURLContent{URL: "https://example.com/a.png"},
+	})
+	messages := []message.Message{imageMessage("describe"), imageOnly}
+	_, err := client.SendMessages(
+		context.Background(),
+		messages,
+		[]tool.BaseTool{noopTool{}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if inner.tools != nil {
+		t.Errorf("expected tools dropped, got %d", len(inner.tools))
+	}
+	if got := inner.messages[0]; len(got.Parts) != 1 ||
+		got.Content().Text != "describe" {
+		t.Errorf("expected only text kept, got %+v", got.Parts)
+	}
+	if got := inner.messages[1].Content().Text; got != attachmentNote {
+		t.Errorf("expected note for emptied message, got %q", got)
+	}
+	if len(messages[0].BinaryContent()) != 1 {
+		t.Error("expected caller's messages left unchanged")
+	}
+}
+
+func TestRejectVideo(t *testing.T) {
+	msg := message.NewUserMessage("what happens?")
+	if err := RejectVideo(
+		model.ProviderOpenAI,
+		[]message.Message{msg},
+	); err != nil {
+		t.Fatalf("text-only request rejected: %v", err)
+	}
+
+	msg.AddVideoURL("https://www.youtube.com/watch?v=abc", nil)
+	err := RejectVideo(model.ProviderOpenAI, []message.Message{msg})
+	if !errors.Is(err, ErrCapabilityUnsupported) {
+		t.Fatalf("err = %v, want ErrCapabilityUnsupported", err)
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ErrStreamIncomplete is returned by [CollectStream] when the stream closes
+// without a complete event, for example because the connection dropped.
+var ErrStreamIncomplete = errors.New("llm: stream ended without a response")
+
+// CollectStream reads stream to the end and returns the response it carried,
+// for callers that use a streaming method but only need the final result.
+// The [types.EventComplete] response is used as is, with content, reasoning,
+// and tool calls filled in from the stream's delta and tool-use events when
+// the provider left them empty.
+//
+// It returns the first error event's error as soon as it arrives; cancel the
+// stream's context in that case so the provider's goroutines are released.
+// When the stream closes without a complete event, the response assembled
+// from the events seen is returned together with [ErrStreamIncomplete].
+func CollectStream(stream <-chan Event) (*Response, error) {
+	c := newStreamCollector()
+	for evt := range stream {
+		if err := c.add(evt); err != nil {
+			return nil, err
+		}
+	}
+	return c.response()
+}
+
+type streamCollector struct {
+	content   strings.Builder
+	reasoning strings.Builder
+	calls     []message.ToolCall
+	byID      map[string]int
+	final     *Response
+}
+
+func newStreamCollector() *streamCollector {
+	return &streamCollector{byID: make(map[string]int)}
+}
+
+func (c *streamCollector) add(evt Event) error {
+	switch evt.Type {
+	case types.EventError:
+		if evt.Error == nil {
+			return errors.New("llm: stream error event without error")
+		}
+		return evt.Error
+	case types.EventContentDelta:
+		c.content.WriteString(evt.Content)
+	case types.EventThinkingDelta:
+		c.reasoning.WriteString(evt.Thinking)
+	case types.EventToolUseStart:
+		if evt.ToolCall != nil {
+			c.byID[evt.ToolCall.ID] = len(c.calls)
+			c.calls = append(c.calls, *evt.ToolCall)
+		}
+	case types.EventToolUseDelta:
+		if evt.ToolCall != nil {
+			if i, ok := c.byID[evt.ToolCall.ID]; ok {
+				c.calls[i].Input += evt.ToolCall.Input
+			}
+		}
+	case types.EventToolUseStop:
+		if evt.ToolCall != nil {
+			if i, ok := c.byID[evt.ToolCall.ID]; ok {
+				c.calls[i].Finished = true
+			}
+		}
+	case types.EventComplete:
+		if evt.Response != nil {
+			c.final = evt.Response
+		}
+	}
+	return nil
+}
+
+func (c *streamCollector) response() (*Response, error) {
+	if c.final == nil {
+		return &Response{
+			Content:   c.content.String(),
+			Reasoning: c.reasoning.String(),
+			ToolCalls: c.calls,
+		}, ErrStreamIncomplete
+	}
+	resp := *c.final
+	if resp.Content == "" {
+		resp.Content = c.content.String()
+	}
+	if resp.Reasoning == "" {
+		resp.Reasoning = c.reasoning.String()
+	}
+	if len(resp.ToolCalls) == 0 {
+		resp.ToolCalls = c.calls
+	}
+	return &resp, nil
+}
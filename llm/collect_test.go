@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func eventStream(events ...Event) <-chan Event {
+	ch := make(chan Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch
+}
+
+func TestCollectStreamFillsFromDeltas(t *testing.T) {
+	resp, err := CollectStream(eventStream(
+		Event{Type: types.EventThinkingDelta, Thinking: "hmm"},
+		Event{Type: types.EventContentDelta, Content: "Hel"},
+		Event{Type: types.EventContentDelta, Content: "lo"},
+		Event{
+			Type:     types.EventToolUseStart,
+			ToolCall: &message.ToolCall{ID: "c1", Name: "search"},
+		},
+		Event{
+			Type:     types.EventToolUseDelta,
+			ToolCall: &message.ToolCall{ID: "c1", Input: `{"q":`},
+		},
+		Event{
+			Type:     types.EventToolUseDelta,
+			ToolCall: &message.ToolCall{ID: "c1", Input: `"go"}`},
+		},
+		Event{
+			Type:     types.EventToolUseStop,
+			ToolCall: &message.ToolCall{ID: "c1"},
+		},
+		Event{
+			Type:     types.EventComplete,
+			Response: &Response{FinishReason: message.FinishReasonToolUse},
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Hello" || resp.Reasoning != "hmm" {
+		t.Errorf("content %q, reasoning %q", resp.Content, resp.Reasoning)
+	}
+	if resp.FinishReason != message.FinishReasonToolUse {
+		t.Errorf("finish reason = %q", resp.FinishReason)
+	}
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	call := resp.ToolCalls[0]
+	if call.Name != "search" || call.Input != `{"q":"go"}` || !call.Finished {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestCollectStreamPrefersCompleteResponse(t *testing.T) {
+	resp, err := CollectStream(eventStream(
+		Event{Type: types.EventContentDelta, Content: "partial"},
+		Event{Type: types.EventComplete, Response: &Response{Content: "full"}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "full" {
+		t.Errorf("content = %q, want full", resp.Content)
+	}
+}
+
+func TestCollectStreamReturnsFirstError(t *testing.T) {
+	first := errors.New("first")
+	_, err := CollectStream(eventStream(
+		Event{Type: types.EventContentDelta, Content: "x"},
+		Event{Type: types.EventError, Error: first},
+		Event{Type: types.EventError, Error: errors.New("second")},
+	))
+	if !errors.Is(err, first) {
+		t.Errorf("err = %v, want first", err)
+	}
+}
+
+func TestCollectStreamIncomplete(t *testing.T) {
+	resp, err := CollectStream(eventStream(
+		Event{Type: types.EventContentDelta, Content: "cut"},
+	))
+	if !errors.Is(err, ErrStreamIncomplete) {
+		t.Fatalf("err = %v, want ErrStreamIncomplete", err)
+	}
+	if resp == nil || resp.Content != "cut" {
+		t.Errorf("partial response = %+v", resp)
+	}
+}
@@ -0,0 +1,43 @@
+package llm
+
+import "github.com/joakimcarlsson/ai/model"
+
+// EstimateCost returns the cost in USD of usage at the catalog prices of m.
+// Input and output tokens are priced at CostPer1MIn and CostPer1MOut. The
+// catalog's cached prices follow each vendor's billing: for Anthropic models
+// CostPer1MInCached is the cache write price and CostPer1MOutCached the cache
+// read price; for other providers CostPer1MInCached is the cache read price
+// and cache writes, which they do not bill separately, cost CostPer1MIn.
+// Custom models without prices cost zero.
+func EstimateCost(m model.Model, usage TokenUsage) float64 {
+	writePrice, readPrice := cachePrices(m)
+	return perMillion(usage.InputTokens, m.CostPer1MIn) +
+		perMillion(usage.OutputTokens, m.CostPer1MOut) +
+		perMillion(usage.CacheCreationTokens, writePrice) +
+		perMillion(usage.CacheReadTokens, readPrice)
+}
+
+// EstimateCacheSavings returns how much less usage cost in USD at the
+// catalog prices of m than it would have without prompt caching, where every
+// cached token is billed as plain input. Cache reads save the difference to
+// the input price; cache writes that cost more than plain input, as on
+// Anthropic, count against it, so the result is negative when a cache entry
+// was written but not read back.
+func EstimateCacheSavings(m model.Model, usage TokenUsage) float64 {
+	writePrice, readPrice := cachePrices(m)
+	return perMillion(usage.CacheReadTokens, m.CostPer1MIn-readPrice) -
+		perMillion(usage.CacheCreationTokens, writePrice-m.CostPer1MIn)
+}
+
+// cachePrices returns the cache write and read prices of m per million
+// tokens, following the catalog conventions described on [EstimateCost].
+func cachePrices(m model.Model) (write, read float64) {
+	if m.Provider == model.ProviderAnthropic {
+		return m.CostPer1MInCached, m.CostPer1MOutCached
+	}
+	return m.CostPer1MIn, m.CostPer1MInCached
+}
+
+func perMillion(tokens int64, price float64) float64 {
+	return float64(tokens) / 1_000_000 * price
+}
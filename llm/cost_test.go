@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func TestEstimateCost(t *testing.T) {
+	usage := TokenUsage{
+		InputTokens:         1_000_000,
+		OutputTokens:        1_000_000,
+		CacheCreationTokens: 1_000_000,
+		CacheReadTokens:     1_000_000,
+	}
+	cases := []struct {
+		name string
+		m    model.Model
+		want float64
+	}{
+		{
+			name: "openai",
+			m: model.Model{
+				Provider:          model.ProviderOpenAI,
+				CostPer1MIn:       2,
+				CostPer1MOut:      8,
+				CostPer1MInCached: 0.5,
+			},
+			want: 2 + 8 + 2 + 0.5,
+		},
+		{
+			name: "anthropic",
+			m: model.Model{
+				Provider:           model.ProviderAnthropic,
+				CostPer1MIn:        3,
+				CostPer1MOut:       15,
+				CostPer1MInCached:  3.75,
+				CostPer1MOutCached: 0.3,
+			},
+			want: 3 + 15 + 3.75 + 0.3,
+		},
+		{
+			name: "unpriced",
+			m:    model.NewCustomModel(),
+			want: 0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EstimateCost(tc.m, usage)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("EstimateCost = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEstimateCacheSavings(t *testing.T) {
+	anthropic := model.Model{
+		Provider:           model.ProviderAnthropic,
+		CostPer1MIn:        3,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+	}
+	openai := model.Model{
+		Provider:          model.ProviderOpenAI,
+		CostPer1MIn:       2,
+		CostPer1MInCached: 0.5,
+	}
+	cases := []struct {
+		name  string
+		m     model.Model
+		usage TokenUsage
+		want  float64
+	}{
+		{
+			name:  "anthropic read",
+			m:     anthropic,
+			usage: TokenUsage{CacheReadTokens: 1_000_000},
+			want:  3 - 0.3,
+		},
+		{
+			name:  "anthropic write only",
+			m:     anthropic,
+			usage: TokenUsage{CacheCreationTokens: 1_000_000},
+			want:  3 - 3.75,
+		},
+		{
+			name: "openai writes are free",
+			m:    openai,
+			usage: TokenUsage{
+				CacheCreationTokens: 1_000_000,
+				CacheReadTokens:     1_000_000,
+			},
+			want: 2 - 0.5,
+		},
+		{
+			name:  "unpriced",
+			m:     model.NewCustomModel(),
+			usage: TokenUsage{CacheReadTokens: 1_000_000},
+			want:  0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EstimateCacheSavings(tc.m, tc.usage)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("EstimateCacheSavings = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ package deepseek
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical DeepSeek API endpoint.
@@ -23,9 +24,13 @@ type Option = llmopenai.Option
 
 // NewLLM constructs a DeepSeek LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override.
+// The endpoint defaults to [DefaultBaseURL]; AI_DEEPSEEK_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. The API key and model fall back
+// to AI_DEEPSEEK_API_KEY and AI_DEEPSEEK_MODEL when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderDeepSeek),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"os"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// EnvPrefix starts the names of the environment variables vendor constructors
+// read for settings that were not passed as options. The full name is
+// AI_<PROVIDER>_<SETTING>, e.g. AI_OPENAI_BASE_URL or AI_ANTHROPIC_API_KEY.
+const EnvPrefix = "AI_"
+
+// Settings read from the environment by vendor constructors.
+const (
+	EnvAPIKey  = "API_KEY"
+	EnvModel   = "MODEL"
+	EnvBaseURL = "BASE_URL"
+)
+
+// EnvName returns the environment variable holding setting for provider. The
+// provider name is upper-cased and characters other than letters and digits
+// become underscores.
+func EnvName(provider model.Provider, setting string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(string(provider)))
+	return EnvPrefix + name + "_" + setting
+}
+
+// Env returns the value of the environment variable named by [EnvName].
+func Env(provider model.Provider, setting string) string {
+	return os.Getenv(EnvName(provider, setting))
+}
+
+// ModelFromEnv resolves the model named by AI_<PROVIDER>_MODEL. The value is
+// looked up with [model.Lookup]; unknown names become a custom model for
+// provider with that API model identifier, so new models can be used before
+// they are added to the catalog. ok is false when the variable is unset.
+func ModelFromEnv(provider model.Provider) (model.Model, bool) {
+	name := Env(provider, EnvModel)
+	if name == "" {
+		return model.Model{}, false
+	}
+	if m, ok := model.Lookup(provider, name); ok {
+		return m, true
+	}
+	return model.NewCustomModel(
+		model.WithModelID(model.ID(name)),
+		model.WithName(name),
+		model.WithProvider(provider),
+		model.WithAPIModel(name),
+	), true
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func TestEnvName(t *testing.T) {
+	cases := map[model.Provider]string{
+		model.ProviderOpenAI:      "AI_OPENAI_BASE_URL",
+		model.ProviderGoogleCloud: "AI_GOOGLE_CLOUD_BASE_URL",
+	}
+	for provider, want := range cases {
+		if got := EnvName(provider, EnvBaseURL); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", provider, got, want)
+		}
+	}
+}
+
+func TestModelFromEnv(t *testing.T) {
+	t.Setenv("AI_OPENAI_MODEL", "")
+	if _, ok := ModelFromEnv(model.ProviderOpenAI); ok {
+		t.Fatal("expected no model when the variable is empty")
+	}
+
+	t.Setenv("AI_OPENAI_MODEL", string(model.GPT4o))
+	m, ok := ModelFromEnv(model.ProviderOpenAI)
+	if !ok || m.ID != model.GPT4o {
+		t.Fatalf("got %+v, %v; want catalog model %s", m, ok, model.GPT4o)
+	}
+	if m.ContextWindow != model.OpenAIModels[model.GPT4o].ContextWindow {
+		t.Errorf("catalog metadata not carried over: %+v", m)
+	}
+
+	t.Setenv("AI_OPENAI_MODEL", "ft:gpt-4o:acme:custom")
+	m, ok = ModelFromEnv(model.ProviderOpenAI)
+	if !ok {
+		t.Fatal("expected a custom model for an unknown name")
+	}
+	if m.APIModel != "ft:gpt-4o:acme:custom" ||
+		m.Provider != model.ProviderOpenAI {
+		t.Errorf("unexpected custom model: %+v", m)
+	}
+}
+
+func TestModelFromEnvMatchesAPIModel(t *testing.T) {
+	want := model.AnthropicModels[model.Claude45Sonnet]
+	t.Setenv("AI_ANTHROPIC_MODEL", want.APIModel)
+	m, ok := ModelFromEnv(model.ProviderAnthropic)
+	if !ok || m.ID != want.ID {
+		t.Fatalf("got %+v, %v; want %s", m, ok, want.ID)
+	}
+}
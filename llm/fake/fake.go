@@ -0,0 +1,469 @@
+// Package fake provides a scriptable, in-memory implementation of the
+// [llm.LLM] interface for unit tests.
+//
+// Queue canned replies — text, tool calls, structured output, or errors — and
+// the client returns them in order, recording every request it receives so
+// tests can assert on the messages, tools, and schemas the code under test
+// sent. No network access or API keys are involved.
+//
+//	client := fake.NewLLM(
+//		fake.WithResponses(
+//			fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+//			fake.Text("It is sunny in Paris."),
+//		),
+//	)
+//
+//	a := agent.New(client, agent.WithTools(weatherTool))
+//	resp, _ := a.Chat(ctx, "What's the weather in Paris?")
+//
+//	calls := client.Calls()
+//	// calls[1].Messages holds the tool result sent back to the model.
+//
+// Streaming requests emit the configured deltas (or the whole content as a
+// single delta), tool-use start/delta/stop events, and a final
+// [types.EventComplete] carrying the full [llm.Response].
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ErrNoResponses is returned when a request arrives after every queued
+// response has been consumed and no handler is configured.
+var ErrNoResponses = errors.New("fake: no responses queued")
+
+// DefaultModel is the model reported by a client constructed without
+// [WithModel].
+var DefaultModel = model.Model{
+	ID:                    "fake-model",
+	Name:                  "Fake Model",
+	Provider:              "fake",
+	APIModel:              "fake-model",
+	ContextWindow:         128_000,
+	DefaultMaxTokens:      4096,
+	SupportsStructuredOut: true,
+}
+
+// Step is one scripted reply. Build it with [Text], [ToolCalls], [Structured],
+// [Error], or [Reply]; the With* methods refine it.
+type Step struct {
+	// Response is returned on success. Ignored when Err is set.
+	Response llm.Response
+	// Err is returned (or emitted as an error event when streaming) instead
+	// of a response.
+	Err error
+	// Deltas are the content chunks emitted when streaming. When empty the
+	// whole content is emitted as a single delta.
+	Deltas []string
+}
+
+// Text returns a step replying with plain text and an end-turn finish reason.
+func Text(content string) Step {
+	return Step{Response: llm.Response{
+		Content:      content,
+		FinishReason: message.FinishReasonEndTurn,
+	}}
+}
+
+// ToolCalls returns a step requesting the given tool calls with a tool-use
+// finish reason.
+func ToolCalls(calls ...message.ToolCall) Step {
+	return Step{Response: llm.Response{
+		ToolCalls:    calls,
+		FinishReason: message.FinishReasonToolUse,
+	}}
+}
+
+// Structured returns a step replying with jsonOutput as native structured
+// output.
+func Structured(jsonOutput string) Step {
+	return Step{Response: llm.Response{
+		Content:                    jsonOutput,
+		StructuredOutput:           &jsonOutput,
+		UsedNativeStructuredOutput: true,
+		FinishReason:               message.FinishReasonEndTurn,
+	}}
+}
+
+// Error returns a step that fails with err.
+func Error(err error) Step {
+	return Step{Err: err}
+}
+
+// Reply returns a step replying with resp verbatim.
+func Reply(resp llm.Response) Step {
+	return Step{Response: resp}
+}
+
+// Call builds a finished function tool call. IDs are assigned when the step is
+// served if left empty.
+func Call(name, input string) message.ToolCall {
+	return message.ToolCall{
+		Name:     name,
+		Input:    input,
+		Type:     "function",
+		Finished: true,
+	}
+}
+
+// WithDeltas sets the content chunks emitted when the step is streamed.
+func (s Step) WithDeltas(deltas ...string) Step {
+	s.Deltas = deltas
+	return s
+}
+
+// WithUsage sets the token usage reported by the step.
+func (s Step) WithUsage(usage llm.TokenUsage) Step {
+	s.Response.Usage = usage
+	return s
+}
+
+// WithReasoning sets the reasoning content reported by the step.
+func (s Step) WithReasoning(reasoning string) Step {
+	s.Response.Reasoning = reasoning
+	return s
+}
+
+// WithFinishReason overrides the finish reason reported by the step.
+func (s Step) WithFinishReason(reason message.FinishReason) Step {
+	s.Response.FinishReason = reason
+	return s
+}
+
+// Request is a recorded call to the client.
+type Request struct {
+	// Messages is a copy of the conversation sent with the request.
+	Messages []message.Message
+	// Tools holds the names of the tools offered with the request.
+	Tools []string
+	// Schema is the structured output schema, nil for plain requests.
+	Schema *schema.StructuredOutputInfo
+	// Stream reports whether the request used a streaming method.
+	Stream bool
+}
+
+// Handler computes a reply for requests that arrive once the queue is empty.
+type Handler func(ctx context.Context, req Request) Step
+
+// Options configures the fake client.
+type Options struct {
+	model      model.Model
+	steps      []Step
+	handler    Handler
+	structured *bool
+}
+
+// Option configures Options.
+type Option func(*Options)
+
+// WithModel sets the model reported by the client.
+func WithModel(m model.Model) Option { return func(o *Options) { o.model = m } }
+
+// WithResponses queues steps to be served in order.
+func WithResponses(steps ...Step) Option {
+	return func(o *Options) { o.steps = append(o.steps, steps...) }
+}
+
+// WithHandler sets a handler that produces replies once the queue is empty,
+// for tests that need responses computed from the request.
+func WithHandler(h Handler) Option {
+	return func(o *Options) { o.handler = h }
+}
+
+// WithStructuredOutput overrides whether the client reports native structured
+// output support. Defaults to the model's SupportsStructuredOut.
+func WithStructuredOutput(supported bool) Option {
+	return func(o *Options) { o.structured = &supported }
+}
+
+// Client is a scriptable [llm.LLM]. It is safe for concurrent use.
+type Client struct {
+	options Options
+
+	mu       sync.Mutex
+	steps    []Step
+	requests []Request
+	nextID   int
+}
+
+// NewLLM constructs a fake client. Unlike the vendor constructors it returns
+// the concrete *Client so tests can enqueue replies and inspect requests; wrap
+// it with [llm.WithTracing] when spans are under test.
+func NewLLM(opts ...Option) *Client {
+	options := Options{model: DefaultModel}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Client{
+		options: options,
+		steps:   append([]Step(nil), options.steps...),
+	}
+}
+
+// Enqueue appends steps to the reply queue.
+func (c *Client) Enqueue(steps ...Step) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, steps...)
+}
+
+// Remaining returns the number of queued steps not yet served.
+func (c *Client) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.steps)
+}
+
+// Calls returns a copy of every request received, in order.
+func (c *Client) Calls() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// CallCount returns the number of requests received.
+func (c *Client) CallCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+// LastCall returns the most recent request. The boolean is false when no
+// request has been received.
+func (c *Client) LastCall() (Request, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) == 0 {
+		return Request{}, false
+	}
+	return c.requests[len(c.requests)-1], true
+}
+
+// Reset clears the reply queue and the recorded requests.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = nil
+	c.requests = nil
+	c.nextID = 0
+}
+
+// Model returns the configured model.
+func (c *Client) Model() model.Model { return c.options.model }
+
+// SupportsStructuredOutput reports whether the client claims native structured
+// output support.
+func (c *Client) SupportsStructuredOutput() bool {
+	if c.options.structured != nil {
+		return *c.options.structured
+	}
+	return c.options.model.SupportsStructuredOut
+}
+
+func (c *Client) next(ctx context.Context, req Request) Step {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	if len(c.steps) > 0 {
+		step := c.steps[0]
+		c.steps = c.steps[1:]
+		c.mu.Unlock()
+		return c.assignIDs(step)
+	}
+	c.mu.Unlock()
+
+	if c.options.handler != nil {
+		return c.assignIDs(c.options.handler(ctx, req))
+	}
+	return Step{
+		Err: fmt.Errorf("%w (request %d)", ErrNoResponses, c.CallCount()),
+	}
+}
+
+func (c *Client) assignIDs(step Step) Step {
+	if len(step.Response.ToolCalls) == 0 {
+		return step
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := make([]message.ToolCall, len(step.Response.ToolCalls))
+	for i, call := range step.Response.ToolCalls {
+		if call.ID == "" {
+			c.nextID++
+			call.ID = fmt.Sprintf("call_%d", c.nextID)
+		}
+		calls[i] = call
+	}
+	step.Response.ToolCalls = calls
+	return step
+}
+
+func newRequest(
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+	stream bool,
+) Request {
+	req := Request{
+		Messages: append([]message.Message(nil), messages...),
+		Schema:   outputSchema,
+		Stream:   stream,
+	}
+	for _, t := range tools {
+		req.Tools = append(req.Tools, t.Info().Name)
+	}
+	return req
+}
+
+func (c *Client) send(ctx context.Context, req Request) (*llm.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	step := c.next(ctx, req)
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	resp := step.Response
+	return &resp, nil
+}
+
+// SendMessages returns the next queued reply.
+func (c *Client) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	return c.send(ctx, newRequest(messages, tools, nil, false))
+}
+
+// SendMessagesWithStructuredOutput returns the next queued reply, recording
+// outputSchema on the request.
+func (c *Client) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*llm.Response, error) {
+	return c.send(ctx, newRequest(messages, tools, outputSchema, false))
+}
+
+// StreamResponse streams the next queued reply.
+func (c *Client) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan llm.Event {
+	return c.stream(ctx, newRequest(messages, tools, nil, true))
+}
+
+// StreamResponseWithStructuredOutput streams the next queued reply, recording
+// outputSchema on the request.
+func (c *Client) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan llm.Event {
+	return c.stream(ctx, newRequest(messages, tools, outputSchema, true))
+}
+
+func (c *Client) stream(ctx context.Context, req Request) <-chan llm.Event {
+	step := c.next(ctx, req)
+	eventChan := make(chan llm.Event)
+
+	go func() {
+		defer close(eventChan)
+
+		emit := func(evt llm.Event) bool {
+			select {
+			case eventChan <- evt:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if step.Err != nil {
+			emit(llm.Event{Type: types.EventError, Error: step.Err})
+			return
+		}
+
+		resp := step.Response
+		if resp.Reasoning != "" {
+			if !emit(llm.Event{
+				Type:     types.EventThinkingDelta,
+				Thinking: resp.Reasoning,
+			}) {
+				return
+			}
+		}
+
+		for _, evt := range contentEvents(resp.Content, step.Deltas) {
+			if !emit(evt) {
+				return
+			}
+		}
+
+		for _, call := range resp.ToolCalls {
+			for _, evt := range toolCallEvents(call) {
+				if !emit(evt) {
+					return
+				}
+			}
+		}
+
+		emit(llm.Event{Type: types.EventComplete, Response: &resp})
+	}()
+
+	return eventChan
+}
+
+func contentEvents(content string, deltas []string) []llm.Event {
+	if len(deltas) == 0 {
+		if content == "" {
+			return nil
+		}
+		deltas = []string{content}
+	}
+	events := []llm.Event{{Type: types.EventContentStart}}
+	for _, d := range deltas {
+		events = append(events, llm.Event{
+			Type:    types.EventContentDelta,
+			Content: d,
+		})
+	}
+	return append(events, llm.Event{Type: types.EventContentStop})
+}
+
+func toolCallEvents(call message.ToolCall) []llm.Event {
+	return []llm.Event{
+		{
+			Type: types.EventToolUseStart,
+			ToolCall: &message.ToolCall{
+				ID:   call.ID,
+				Name: call.Name,
+				Type: call.Type,
+			},
+		},
+		{
+			Type: types.EventToolUseDelta,
+			ToolCall: &message.ToolCall{
+				ID:    call.ID,
+				Input: call.Input,
+			},
+		},
+		{
+			Type:     types.EventToolUseStop,
+			ToolCall: &message.ToolCall{ID: call.ID},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type stubTool struct{ name string }
+
+func (s stubTool) Info() tool.Info {
+	return tool.Info{Name: s.name, Parameters: map[string]any{}}
+}
+
+func (s stubTool) Run(context.Context, tool.Call) (tool.Response, error) {
+	return tool.Response{}, nil
+}
+
+var _ llm.LLM = (*Client)(nil)
+
+func TestClient_ServesStepsInOrderAndRecordsRequests(t *testing.T) {
+	client := NewLLM(WithResponses(
+		ToolCalls(Call("lookup", `{"q":"go"}`)),
+		Text("done").WithUsage(llm.TokenUsage{InputTokens: 5}),
+	))
+	ctx := context.Background()
+	msgs := []message.Message{message.NewUserMessage("hi")}
+
+	resp, err := client.SendMessages(ctx, msgs, []tool.BaseTool{
+		stubTool{name: "lookup"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("expected tool call with assigned id, got %+v", resp.ToolCalls)
+	}
+	if resp.FinishReason != message.FinishReasonToolUse {
+		t.Errorf("expected tool_use finish reason, got %q", resp.FinishReason)
+	}
+
+	resp, err = client.SendMessages(ctx, msgs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "done" || resp.Usage.InputTokens != 5 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	calls := client.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 recorded calls, got %d", len(calls))
+	}
+	if len(calls[0].Tools) != 1 || calls[0].Tools[0] != "lookup" {
+		t.Errorf("expected recorded tool names, got %v", calls[0].Tools)
+	}
+	if calls[0].Messages[0].Content().Text != "hi" {
+		t.Errorf("expected recorded message, got %+v", calls[0].Messages)
+	}
+
+	if _, err := client.SendMessages(ctx, msgs, nil); !errors.Is(
+		err,
+		ErrNoResponses,
+	) {
+		t.Errorf("expected ErrNoResponses, got %v", err)
+	}
+}
+
+func TestClient_ErrorStep(t *testing.T) {
+	boom := errors.New("boom")
+	client := NewLLM(WithResponses(Error(boom)))
+
+	_, err := client.SendMessages(context.Background(), nil, nil)
+	if !errors.Is(err, boom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+}
+
+func TestClient_HandlerAfterQueue(t *testing.T) {
+	client := NewLLM(WithHandler(func(_ context.Context, req Request) Step {
+		return Text(req.Messages[len(req.Messages)-1].Content().Text)
+	}))
+
+	resp, err := client.SendMessages(
+		context.Background(),
+		[]message.Message{message.NewUserMessage("echo me")},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "echo me" {
+		t.Errorf("expected handler reply, got %q", resp.Content)
+	}
+}
+
+func TestClient_StructuredOutput(t *testing.T) {
+	client := NewLLM(WithResponses(Structured(`{"answer":42}`)))
+	info := &schema.StructuredOutputInfo{Name: "answer"}
+
+	resp, err := client.SendMessagesWithStructuredOutput(
+		context.Background(),
+		nil,
+		nil,
+		info,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StructuredOutput == nil ||
+		*resp.StructuredOutput != `{"answer":42}` {
+		t.Errorf("unexpected structured output: %v", resp.StructuredOutput)
+	}
+	if last, _ := client.LastCall(); last.Schema != info {
+		t.Error("expected schema to be recorded")
+	}
+}
+
+func TestClient_StreamEmitsDeltasAndToolCalls(t *testing.T) {
+	client := NewLLM(WithResponses(
+		Text("hello world").WithDeltas("hello", " world"),
+		ToolCalls(Call("lookup", `{}`)),
+	))
+	ctx := context.Background()
+
+	var deltas []string
+	var final *llm.Response
+	for evt := range client.StreamResponse(ctx, nil, nil) {
+		switch evt.Type {
+		case types.EventContentDelta:
+			deltas = append(deltas, evt.Content)
+		case types.EventComplete:
+			final = evt.Response
+		}
+	}
+	if len(deltas) != 2 || deltas[0] != "hello" || deltas[1] != " world" {
+		t.Errorf("unexpected deltas: %v", deltas)
+	}
+	if final == nil || final.Content != "hello world" {
+		t.Fatalf("unexpected final response: %+v", final)
+	}
+
+	var seen []types.EventType
+	for evt := range client.StreamResponse(ctx, nil, nil) {
+		seen = append(seen, evt.Type)
+	}
+	want := []types.EventType{
+		types.EventToolUseStart,
+		types.EventToolUseDelta,
+		types.EventToolUseStop,
+		types.EventComplete,
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], seen[i])
+		}
+	}
+
+	if last, _ := client.LastCall(); !last.Stream {
+		t.Error("expected streaming request to be recorded")
+	}
+}
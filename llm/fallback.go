@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fallbackStatusCodes are the HTTP statuses that move a request on to the
+// next client: rate limiting, timeouts, and server-side overload.
+var fallbackStatusCodes = []int{408, 429, 500, 502, 503, 504, 529}
+
+// WithFallback returns an LLM that sends each request to primary and, when it
+// fails with a retryable error, replays the same messages against each backup
+// in order. An error is retryable when it satisfies [RetryableError] with a
+// rate-limit, timeout, or 5xx status, or when a per-request timeout expired
+// while ctx itself is still live. Any other error is returned immediately.
+//
+// The response carries the model that served it in [Response.ServedBy], and
+// every switch to a backup is logged and recorded as a "fallback" span event.
+// Streams fail over only until the first event has been forwarded; after that
+// the stream stays with the client that produced it.
+//
+// Model and SupportsStructuredOutput report the primary client.
+func WithFallback(primary LLM, backups ...LLM) LLM {
+	return &fallbackLLM{clients: append([]LLM{primary}, backups...)}
+}
+
+type fallbackLLM struct {
+	clients []LLM
+}
+
+func (f *fallbackLLM) Model() model.Model {
+	return f.clients[0].Model()
+}
+
+func (f *fallbackLLM) SupportsStructuredOutput() bool {
+	return f.clients[0].SupportsStructuredOutput()
+}
+
+func (f *fallbackLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	return f.send(ctx, func(client LLM) (*Response, error) {
+		return client.SendMessages(ctx, messages, tools)
+	})
+}
+
+func (f *fallbackLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return f.send(ctx, func(client LLM) (*Response, error) {
+		return client.SendMessagesWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		)
+	})
+}
+
+func (f *fallbackLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return f.stream(ctx, func(client LLM) <-chan Event {
+		return client.StreamResponse(ctx, messages, tools)
+	})
+}
+
+func (f *fallbackLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return f.stream(ctx, func(client LLM) <-chan Event {
+		return client.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		)
+	})
+}
+
+func (f *fallbackLLM) send(
+	ctx context.Context,
+	call func(LLM) (*Response, error),
+) (*Response, error) {
+	var errs []error
+	for i, client := range f.clients {
+		resp, err := call(client)
+		if err == nil {
+			markServedBy(resp, client)
+			return resp, nil
+		}
+		errs = append(errs, err)
+		if !f.canFallBack(ctx, i, err) {
+			break
+		}
+		f.recordFallback(ctx, i, err)
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf(
+		"all %d fallback clients failed: %w",
+		len(errs),
+		errors.Join(errs...),
+	)
+}
+
+func (f *fallbackLLM) stream(
+	ctx context.Context,
+	open func(LLM) <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for i, client := range f.clients {
+			innerCh := open(client)
+			forwarded := false
+			for evt := range innerCh {
+				if !forwarded && evt.Type == types.EventError &&
+					f.canFallBack(ctx, i, evt.Error) {
+					drainEvents(innerCh)
+					f.recordFallback(ctx, i, evt.Error)
+					break
+				}
+				forwarded = true
+				if evt.Type == types.EventComplete {
+					markServedBy(evt.Response, client)
+				}
+				select {
+				case outCh <- evt:
+				case <-ctx.Done():
+					drainEvents(innerCh)
+					return
+				}
+			}
+			if forwarded {
+				return
+			}
+		}
+	}()
+	return outCh
+}
+
+func (f *fallbackLLM) canFallBack(
+	ctx context.Context,
+	index int,
+	err error,
+) bool {
+	return index < len(f.clients)-1 && isFallbackError(ctx, err)
+}
+
+func (f *fallbackLLM) recordFallback(
+	ctx context.Context,
+	index int,
+	err error,
+) {
+	from := f.clients[index].Model()
+	to := f.clients[index+1].Model()
+
+	retryLogger(ctx).Warn("Falling back to next LLM client",
+		"from_provider", string(from.Provider),
+		"from_model", from.APIModel,
+		"to_provider", string(to.Provider),
+		"to_model", to.APIModel,
+		"error", err.Error())
+
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("fallback", trace.WithAttributes(
+		attribute.String("from_model", from.APIModel),
+		attribute.String("to_model", to.APIModel),
+		attribute.String("error", err.Error()),
+	))
+}
+
+func isFallbackError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var retryable RetryableError
+	if !errors.As(err, &retryable) {
+		return false
+	}
+	return slices.Contains(fallbackStatusCodes, retryable.GetStatusCode())
+}
+
+func markServedBy(resp *Response, client LLM) {
+	if resp == nil {
+		return
+	}
+	m := client.Model()
+	resp.ServedBy = &m
+}
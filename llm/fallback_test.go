@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// scriptedLLM answers every call with err when set, otherwise with a response
+// carrying content. Streams emit the same outcome as a single event.
+type scriptedLLM struct {
+	model   model.Model
+	content string
+	err     error
+	calls   int
+}
+
+func (s *scriptedLLM) SendMessages(
+	context.Context, []message.Message, []tool.BaseTool,
+) (*Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Response{Content: s.content}, nil
+}
+
+func (s *scriptedLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	_ *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return s.SendMessages(ctx, messages, tools)
+}
+
+func (s *scriptedLLM) StreamResponse(
+	context.Context, []message.Message, []tool.BaseTool,
+) <-chan Event {
+	s.calls++
+	ch := make(chan Event, 1)
+	if s.err != nil {
+		ch <- Event{Type: types.EventError, Error: s.err}
+	} else {
+		ch <- Event{
+			Type:     types.EventComplete,
+			Response: &Response{Content: s.content},
+		}
+	}
+	close(ch)
+	return ch
+}
+
+func (s *scriptedLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	_ *schema.StructuredOutputInfo,
+) <-chan Event {
+	return s.StreamResponse(ctx, messages, tools)
+}
+
+func (s *scriptedLLM) Model() model.Model             { return s.model }
+func (s *scriptedLLM) SupportsStructuredOutput() bool { return true }
+
+func overloaded() error {
+	return GenericRetryableError{
+		Err:        errors.New("overloaded"),
+		StatusCode: 503,
+	}
+}
+
+func TestWithFallback_FailsOverOnRetryableError(t *testing.T) {
+	primary := &scriptedLLM{
+		model: model.Model{APIModel: "primary"},
+		err:   overloaded(),
+	}
+	backup := &scriptedLLM{
+		model:   model.Model{APIModel: "backup"},
+		content: "from backup",
+	}
+
+	resp, err := WithFallback(primary, backup).
+		SendMessages(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "from backup" {
+		t.Errorf("expected backup content, got %q", resp.Content)
+	}
+	if resp.ServedBy == nil || resp.ServedBy.APIModel != "backup" {
+		t.Errorf("expected ServedBy backup, got %+v", resp.ServedBy)
+	}
+}
+
+func TestWithFallback_ReturnsNonRetryableErrorImmediately(t *testing.T) {
+	badRequest := errors.New("invalid request")
+	primary := &scriptedLLM{err: badRequest}
+	backup := &scriptedLLM{content: "unused"}
+
+	_, err := WithFallback(primary, backup).
+		SendMessages(context.Background(), nil, nil)
+	if !errors.Is(err, badRequest) {
+		t.Fatalf("expected primary error, got %v", err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("expected backup untouched, got %d calls", backup.calls)
+	}
+}
+
+func TestWithFallback_JoinsErrorsWhenAllFail(t *testing.T) {
+	primary := &scriptedLLM{err: overloaded()}
+	backup := &scriptedLLM{err: overloaded()}
+
+	_, err := WithFallback(primary, backup).
+		SendMessages(context.Background(), nil, nil)
+	var retryable RetryableError
+	if !errors.As(err, &retryable) {
+		t.Fatalf("expected retryable error in chain, got %v", err)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Errorf("expected one call each, got %d and %d",
+			primary.calls, backup.calls)
+	}
+}
+
+func TestWithFallback_StreamFailsOverBeforeFirstEvent(t *testing.T) {
+	primary := &scriptedLLM{err: overloaded()}
+	backup := &scriptedLLM{
+		model:   model.Model{APIModel: "backup"},
+		content: "streamed",
+	}
+
+	var events []Event
+	ch := WithFallback(primary, backup).
+		StreamResponse(context.Background(), nil, nil)
+	for evt := range ch {
+		events = append(events, evt)
+	}
+
+	if len(events) != 1 || events[0].Type != types.EventComplete {
+		t.Fatalf("expected a single complete event, got %+v", events)
+	}
+	resp := events[0].Response
+	if resp.Content != "streamed" || resp.ServedBy.APIModel != "backup" {
+		t.Errorf("expected backup response, got %+v", resp)
+	}
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// FileUploader is implemented by vendor clients that can store files with the
+// provider, so large inputs such as PDFs or videos are uploaded once and then
+// referenced from messages with [message.Message.AddFileRef].
+//
+// Uploaded files are not free to keep: some providers delete them on a
+// schedule (Gemini keeps files for 48 hours, reported in
+// [message.FileRef.ExpiresAt]) while others keep them until deleted, so call
+// DeleteFile once a file is no longer needed.
+type FileUploader interface {
+	UploadFile(
+		ctx context.Context,
+		data []byte,
+		mimeType string,
+	) (message.FileRef, error)
+	DeleteFile(ctx context.Context, ref message.FileRef) error
+}
+
+// AsFileUploader returns the [FileUploader] behind client, looking through
+// the decorators in this package. ok is false when the vendor client does not
+// support file uploads.
+func AsFileUploader(client LLM) (FileUploader, bool) {
+	for client != nil {
+		if u, ok := client.(FileUploader); ok {
+			return u, true
+		}
+		w, ok := client.(interface{ Unwrap() LLM })
+		if !ok {
+			break
+		}
+		client = w.Unwrap()
+	}
+	return nil, false
+}
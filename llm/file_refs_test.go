@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+type uploadingLLM struct {
+	scriptedLLM
+	deleted []string
+}
+
+func (u *uploadingLLM) UploadFile(
+	_ context.Context,
+	_ []byte,
+	mimeType string,
+) (message.FileRef, error) {
+	return message.FileRef{ID: "file_1", MIMEType: mimeType}, nil
+}
+
+func (u *uploadingLLM) DeleteFile(
+	_ context.Context,
+	ref message.FileRef,
+) error {
+	u.deleted = append(u.deleted, ref.ID)
+	return nil
+}
+
+func TestAsFileUploaderUnwrapsDecorators(t *testing.T) {
+	inner := &uploadingLLM{}
+	traced := WithTracing(inner, TracingAttrs{})
+	client := WithIdempotencyKeys(WithCapabilityCheck(traced, CapabilityAdapt))
+
+	uploader, ok := AsFileUploader(client)
+	if !ok {
+		t.Fatal("uploader not found behind decorators")
+	}
+	ref, err := uploader.UploadFile(context.Background(), nil, "video/mp4")
+	if err != nil || ref.ID != "file_1" {
+		t.Fatalf("UploadFile = %+v, %v", ref, err)
+	}
+	if err := uploader.DeleteFile(context.Background(), ref); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if len(inner.deleted) != 1 {
+		t.Errorf("deleted = %v", inner.deleted)
+	}
+}
+
+func TestAsFileUploaderUnsupported(t *testing.T) {
+	client := WithTracing(&scriptedLLM{}, TracingAttrs{})
+	if _, ok := AsFileUploader(client); ok {
+		t.Error("expected no uploader")
+	}
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// FileUploadFunc uploads data to a provider's file storage and returns the
+// file ID used to reference it in later requests.
+type FileUploadFunc func(
+	ctx context.Context,
+	mimeType string,
+	data []byte,
+) (string, error)
+
+// FileCache uploads binary message content once and remembers the provider
+// file ID by content hash, so an image repeated across turns is sent as a
+// small reference instead of being re-encoded into every request. Vendor
+// packages that support file uploads accept a cache through their
+// WithFileCache option.
+//
+// File IDs belong to one provider account, so share a cache only between
+// clients of the same vendor and API key. A FileCache is safe for concurrent
+// use; concurrent requests for the same content share a single upload.
+type FileCache struct {
+	mu    sync.Mutex
+	files map[string]*cachedFile
+}
+
+type cachedFile struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// NewFileCache returns an empty file cache.
+func NewFileCache() *FileCache {
+	return &FileCache{files: make(map[string]*cachedFile)}
+}
+
+// FileID returns the file ID for data, calling upload the first time the
+// content is seen. A failed upload is not cached and is retried on the next
+// call.
+func (c *FileCache) FileID(
+	ctx context.Context,
+	mimeType string,
+	data []byte,
+	upload FileUploadFunc,
+) (string, error) {
+	key := contentKey(mimeType, data)
+
+	c.mu.Lock()
+	if f, ok := c.files[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if f.err == nil {
+			return f.id, nil
+		}
+		return c.FileID(ctx, mimeType, data, upload)
+	}
+	f := &cachedFile{done: make(chan struct{})}
+	c.files[key] = f
+	c.mu.Unlock()
+
+	f.id, f.err = upload(ctx, mimeType, data)
+	if f.err != nil {
+		c.mu.Lock()
+		delete(c.files, key)
+		c.mu.Unlock()
+	}
+	close(f.done)
+	return f.id, f.err
+}
+
+// Forget drops every cached file ID, e.g. after the files were deleted on
+// the provider side. Uploads already in progress are unaffected.
+func (c *FileCache) Forget() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = make(map[string]*cachedFile)
+}
+
+// ResolveFiles returns messages with the FileID of every binary content part
+// set from the cache, uploading content not seen before. The input messages
+// are not modified; only messages containing binary content are copied. A
+// nil cache returns messages unchanged.
+func (c *FileCache) ResolveFiles(
+	ctx context.Context,
+	messages []message.Message,
+	upload FileUploadFunc,
+) ([]message.Message, error) {
+	if c == nil {
+		return messages, nil
+	}
+	var resolved []message.Message
+	for i, msg := range messages {
+		var parts []message.ContentPart
+		for j, part := range msg.Parts {
+			bin, ok := part.(message.BinaryContent)
+			if !ok || bin.FileID != "" {
+				continue
+			}
+			id, err := c.FileID(ctx, bin.MIMEType, bin.Data, upload)
+			if err != nil {
+				return nil, err
+			}
+			if parts == nil {
+				parts = slices.Clone(msg.Parts)
+			}
+			bin.FileID = id
+			parts[j] = bin
+		}
+		if parts == nil {
+			continue
+		}
+		if resolved == nil {
+			resolved = slices.Clone(messages)
+		}
+		resolved[i].Parts = parts
+	}
+	if resolved == nil {
+		return messages, nil
+	}
+	return resolved, nil
+}
+
+func contentKey(mimeType string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(mimeType))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
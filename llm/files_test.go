@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+func TestFileCache_UploadsOncePerContent(t *testing.T) {
+	var uploads atomic.Int32
+	upload := func(context.Context, string, []byte) (string, error) {
+		return "file_" + string(rune('a'+uploads.Add(1)-1)), nil
+	}
+	cache := NewFileCache()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	ids := make([]string, 8)
+	for i := range ids {
+		wg.Go(func() {
+			id, err := cache.FileID(ctx, "image/png", []byte("same"), upload)
+			if err != nil {
+				t.Errorf("FileID: %v", err)
+			}
+			ids[i] = id
+		})
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != "file_a" {
+			t.Fatalf("ids = %v, want all file_a", ids)
+		}
+	}
+
+	other, _ := cache.FileID(ctx, "image/png", []byte("other"), upload)
+	if other != "file_b" || uploads.Load() != 2 {
+		t.Errorf("other = %q after %d uploads", other, uploads.Load())
+	}
+}
+
+func TestFileCache_FailedUploadRetried(t *testing.T) {
+	calls := 0
+	upload := func(context.Context, string, []byte) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.New("boom")
+		}
+		return "file_ok", nil
+	}
+	cache := NewFileCache()
+	ctx := context.Background()
+
+	data := []byte("x")
+	if _, err := cache.FileID(ctx, "image/png", data, upload); err == nil {
+		t.Fatal("expected the first upload to fail")
+	}
+	id, err := cache.FileID(ctx, "image/png", data, upload)
+	if err != nil || id != "file_ok" {
+		t.Fatalf("retry = %q, %v", id, err)
+	}
+}
+
+func TestFileCache_ResolveFilesCopies(t *testing.T) {
+	upload := func(context.Context, string, []byte) (string, error) {
+		return "file_1", nil
+	}
+	withImage := message.NewUserMessage("look")
+	withImage.AddBinary("image/png", []byte("png"))
+	messages := []message.Message{message.NewUserMessage("hi"), withImage}
+
+	resolved, err := NewFileCache().ResolveFiles(
+		context.Background(),
+		messages,
+		upload,
+	)
+	if err != nil {
+		t.Fatalf("ResolveFiles: %v", err)
+	}
+	if got := resolved[1].BinaryContent()[0].FileID; got != "file_1" {
+		t.Errorf("FileID = %q, want file_1", got)
+	}
+	if messages[1].BinaryContent()[0].FileID != "" {
+		t.Error("input messages were modified")
+	}
+
+	var nilCache *FileCache
+	same, _ := nilCache.ResolveFiles(context.Background(), messages, upload)
+	if &same[0] != &messages[0] {
+		t.Error("nil cache should return messages unchanged")
+	}
+}
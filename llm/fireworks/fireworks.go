@@ -10,6 +10,7 @@ package fireworks
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical Fireworks API endpoint.
@@ -20,9 +21,13 @@ type Option = llmopenai.Option
 
 // NewLLM constructs a Fireworks LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override.
+// The endpoint defaults to [DefaultBaseURL]; AI_FIREWORKS_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. The API key and model fall back
+// to AI_FIREWORKS_API_KEY and AI_FIREWORKS_MODEL when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderFireworks),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/joakimcarlsson/ai/llm v0.5.0
 	github.com/joakimcarlsson/ai/llm/openai v0.4.5
+	github.com/joakimcarlsson/ai/model v0.6.0
 )
 
 require (
@@ -16,7 +17,7 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
 	github.com/joakimcarlsson/ai/message v0.4.0 // indirect
-	github.com/joakimcarlsson/ai/model v0.6.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
 	github.com/joakimcarlsson/ai/tool v0.1.2 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
@@ -57,6 +58,7 @@ replace (
 	github.com/joakimcarlsson/ai/llm => ../
 	github.com/joakimcarlsson/ai/llm/openai => ../openai
 	github.com/joakimcarlsson/ai/message => ../../message
+	github.com/joakimcarlsson/ai/metrics => ../../metrics
 	github.com/joakimcarlsson/ai/model => ../../model
 	github.com/joakimcarlsson/ai/schema => ../../schema
 	github.com/joakimcarlsson/ai/tool => ../../tool
@@ -4,6 +4,7 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -52,6 +53,7 @@ type Options struct {
 	toolChoice       *llm.ToolChoice
 	builtinTools     []*genai.Tool
 	httpClient       *http.Client
+	fileCache        *llm.FileCache
 }
 
 // Option configures Options.
@@ -101,6 +103,16 @@ func WithTimeout(
 	return func(o *Options) { o.timeout = &timeout }
 }
 
+// WithFileCache uploads images and other binary content to the Gemini File
+// API once and references them by URI afterwards, instead of inlining the
+// bytes in every request. The cache remembers uploads by content hash, so
+// content that stays in the conversation history is uploaded only on its
+// first turn. Gemini deletes uploaded files after 48 hours; call
+// [llm.FileCache.Forget] before reusing a cache for longer than that.
+func WithFileCache(cache *llm.FileCache) Option {
+	return func(o *Options) { o.fileCache = cache }
+}
+
 // WithHTTPClient injects a custom *http.Client, set on the genai ClientConfig's
 // HTTPClient field. Use it for outbound proxies, custom TLS (private CAs, mTLS),
 // connection-pool tuning, or transport-level instrumentation. A nil client is a
@@ -219,17 +231,30 @@ type Client struct {
 	client  *genai.Client
 }
 
-// NewLLM constructs a Gemini LLM client.
+// NewLLM constructs a Gemini LLM client. The API key and model fall back to
+// AI_GEMINI_API_KEY and AI_GEMINI_MODEL when not passed as options, and
+// AI_GEMINI_BASE_URL overrides the API endpoint.
 func NewLLM(opts ...Option) llm.LLM {
 	options := Options{}
 	for _, o := range opts {
 		o(&options)
 	}
+	if options.apiKey == "" {
+		options.apiKey = llm.Env(model.ProviderGemini, llm.EnvAPIKey)
+	}
+	if options.model.ID == "" && options.model.APIModel == "" {
+		if m, ok := llm.ModelFromEnv(model.ProviderGemini); ok {
+			options.model = m
+		}
+	}
 
 	cfg := &genai.ClientConfig{
 		APIKey:  options.apiKey,
 		Backend: genai.BackendGeminiAPI,
 	}
+	if baseURL := llm.Env(model.ProviderGemini, llm.EnvBaseURL); baseURL != "" {
+		cfg.HTTPOptions.BaseURL = baseURL
+	}
 	if options.httpClient != nil {
 		cfg.HTTPClient = options.httpClient
 	}
@@ -245,6 +270,93 @@ func NewLLM(opts ...Option) llm.LLM {
 	)
 }
 
+// uploadFile uploads data to the File API for [llm.FileCache] and returns
+// the file URI, which requests reference through FileData.
+func (c *Client) uploadFile(
+	ctx context.Context,
+	mimeType string,
+	data []byte,
+) (string, error) {
+	file, err := c.client.Files.Upload(
+		ctx,
+		bytes.NewReader(data),
+		&genai.UploadFileConfig{MIMEType: mimeType},
+	)
+	if err != nil {
+		return "", fmt.Errorf("gemini: upload file: %w", wrapError(err))
+	}
+	return file.URI, nil
+}
+
+// UploadFile stores data with the Gemini File API, for inputs such as videos
+// or long PDFs that are too large to inline, and returns a reference to attach
+// with [message.Message.AddFileRef]. Gemini deletes uploaded files after 48
+// hours; ExpiresAt on the reference reports when. The File API is not
+// available on Vertex AI.
+func (c *Client) UploadFile(
+	ctx context.Context,
+	data []byte,
+	mimeType string,
+) (message.FileRef, error) {
+	file, err := c.client.Files.Upload(
+		ctx,
+		bytes.NewReader(data),
+		&genai.UploadFileConfig{MIMEType: mimeType},
+	)
+	if err != nil {
+		return message.FileRef{}, fmt.Errorf(
+			"gemini: upload file: %w",
+			wrapError(err),
+		)
+	}
+	ref := message.FileRef{
+		Provider:  c.options.model.Provider,
+		ID:        file.Name,
+		URI:       file.URI,
+		MIMEType:  mimeType,
+		Name:      file.DisplayName,
+		ExpiresAt: file.ExpirationTime,
+	}
+	if file.SizeBytes != nil {
+		ref.SizeBytes = *file.SizeBytes
+	}
+	return ref, nil
+}
+
+// DeleteFile removes a file uploaded with [Client.UploadFile] before it
+// expires.
+func (c *Client) DeleteFile(ctx context.Context, ref message.FileRef) error {
+	if _, err := c.client.Files.Delete(ctx, ref.ID, nil); err != nil {
+		return fmt.Errorf(
+			"gemini: delete file %s: %w",
+			ref.ID,
+			wrapError(err),
+		)
+	}
+	return nil
+}
+
+// videoPart returns a file part for video, carrying its sampling settings as
+// video metadata.
+func videoPart(video message.VideoContent) *genai.Part {
+	data := &genai.FileData{FileURI: video.URL, MIMEType: video.MIMEType}
+	if video.File != nil {
+		data.FileURI = video.File.URI
+	}
+	part := &genai.Part{FileData: data}
+	if s := video.Sampling; s != nil {
+		part.VideoMetadata = &genai.VideoMetadata{
+			StartOffset: s.Start,
+			EndOffset:   s.End,
+		}
+		if s.FPS > 0 {
+			fps := s.FPS
+			part.VideoMetadata.FPS = &fps
+		}
+	}
+	return part
+}
+
 // NewWithExistingClient is for embedding by other packages (e.g. llm/vertexai)
 // that build the Gemini SDK client themselves and want this package's request logic.
 // The returned *Client is the bare implementation, not wrapped in tracing.
@@ -273,6 +385,15 @@ func (c *Client) convertMessages(
 		case message.User:
 			parts := []*genai.Part{{Text: msg.Content().String()}}
 			for _, binaryContent := range msg.BinaryContent() {
+				if binaryContent.FileID != "" {
+					parts = append(parts, &genai.Part{
+						FileData: &genai.FileData{
+							FileURI:  binaryContent.FileID,
+							MIMEType: binaryContent.MIMEType,
+						},
+					})
+					continue
+				}
 				parts = append(parts, &genai.Part{
 					InlineData: &genai.Blob{
 						MIMEType: binaryContent.MIMEType,
@@ -280,6 +401,17 @@ func (c *Client) convertMessages(
 					},
 				})
 			}
+			for _, ref := range msg.FileRefs() {
+				parts = append(parts, &genai.Part{
+					FileData: &genai.FileData{
+						FileURI:  ref.URI,
+						MIMEType: ref.MIMEType,
+					},
+				})
+			}
+			for _, video := range msg.VideoContent() {
+				parts = append(parts, videoPart(video))
+			}
 			geminiMessages = append(geminiMessages, &genai.Content{
 				Role: "user", Parts: parts,
 			})
@@ -312,10 +444,8 @@ func (c *Client) convertMessages(
 			for _, toolResult := range msg.ToolResults() {
 				parts := []*genai.Part{{
 					FunctionResponse: &genai.FunctionResponse{
-						Name: toolResult.Name,
-						Response: map[string]interface{}{
-							"content": toolResult.Content,
-						},
+						Name:     toolResult.Name,
+						Response: functionResponse(toolResult),
 					},
 				}}
 				// Gemini rejects Role != ("user"|"model"): sending "function"
@@ -334,6 +464,21 @@ func (c *Client) convertMessages(
 	return geminiMessages, systemMessages
 }
 
+// functionResponse builds the structured response for a tool result. JSON
+// objects from tool.NewJSONResponse are passed through as-is so the model sees
+// their fields; any other content is wrapped under a "content" key, with a
+// note in place of each image.
+func functionResponse(toolResult message.ToolResult) map[string]any {
+	if toolResult.MIMEType == "application/json" {
+		var object map[string]any
+		err := json.Unmarshal([]byte(toolResult.Content), &object)
+		if err == nil && object != nil {
+			return object
+		}
+	}
+	return map[string]any{"content": toolResult.TextWithImageNotes()}
+}
+
 func (c *Client) convertTools(tools []tool.BaseTool) []*genai.Tool {
 	var out []*genai.Tool
 	if len(tools) > 0 {
@@ -463,6 +608,31 @@ func toolConfigParam(choice llm.ToolChoice) *genai.ToolConfig {
 	return &genai.ToolConfig{FunctionCallingConfig: fc}
 }
 
+// forRequest returns c, or a copy of c using the tool choice and reasoning
+// effort carried by ctx (see [llm.ContextWithToolChoice] and
+// [llm.ContextWithReasoningEffort]) when either is set. The effort maps onto
+// the thinking level of the same name and replaces a configured thinking
+// budget, which cannot be sent with it; models that take no thinking level
+// (see [llm.SupportsReasoningEffort]) ignore it.
+func (c *Client) forRequest(ctx context.Context) *Client {
+	choice, hasChoice := llm.ToolChoiceFromContext(ctx)
+	effort, hasEffort := llm.ReasoningEffortFromContext(ctx)
+	hasEffort = hasEffort && llm.SupportsReasoningEffort(c.options.model)
+	if !hasChoice && !hasEffort {
+		return c
+	}
+	clone := *c
+	if hasChoice {
+		clone.options.toolChoice = &choice
+	}
+	if hasEffort {
+		level := ThinkingLevel(effort)
+		clone.options.thinkingLevel = &level
+		clone.options.thinkingBudget = nil
+	}
+	return &clone
+}
+
 // validateToolChoice rejects a malformed tool choice before a request is sent.
 func (c *Client) validateToolChoice() error {
 	if c.options.toolChoice == nil {
@@ -477,9 +647,18 @@ func (c *Client) SendMessages(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return nil, err
+	}
 	geminiMessages, systemMessages := c.convertMessages(messages)
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
@@ -571,9 +750,18 @@ func (c *Client) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		return nil, err
+	}
 	geminiMessages, systemMessages := c.convertMessages(messages)
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
@@ -585,10 +773,7 @@ func (c *Client) SendMessagesWithStructuredOutput(
 	history := geminiMessages[:len(geminiMessages)-1]
 	lastMsg := geminiMessages[len(geminiMessages)-1]
 	config := c.buildConfig(systemMessages, tools)
-	config.ResponseSchema = c.convertSchemaToGenai(
-		outputSchema.Parameters,
-		outputSchema.Required,
-	)
+	c.applyOutputSchema(config, outputSchema)
 
 	chat, err := c.client.Chats.Create(
 		ctx,
@@ -604,7 +789,11 @@ func (c *Client) SendMessagesWithStructuredOutput(
 		ctx,
 		RetryConfig(),
 		func() (*llm.Response, error) {
-			response, err := chat.Send(ctx, lastMsg.Parts[0])
+			var lastMsgParts []genai.Part
+			for _, part := range lastMsg.Parts {
+				lastMsgParts = append(lastMsgParts, *part)
+			}
+			response, err := chat.SendMessage(ctx, lastMsgParts...)
 			if err != nil {
 				return nil, wrapError(err)
 			}
@@ -670,12 +859,24 @@ func (c *Client) streamInternal(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		eventChan := make(chan llm.Event, 1)
 		eventChan <- llm.Event{Type: types.EventError, Error: err}
 		close(eventChan)
 		return eventChan
 	}
+	messages, err := c.options.fileCache.ResolveFiles(
+		ctx,
+		messages,
+		c.uploadFile,
+	)
+	if err != nil {
+		eventChan := make(chan llm.Event, 1)
+		eventChan <- llm.Event{Type: types.EventError, Error: err}
+		close(eventChan)
+		return eventChan
+	}
 	geminiMessages, systemMessages := c.convertMessages(messages)
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
@@ -692,10 +893,7 @@ func (c *Client) streamInternal(
 	lastMsg := geminiMessages[len(geminiMessages)-1]
 	config := c.buildConfig(systemMessages, tools)
 	if outputSchema != nil {
-		config.ResponseSchema = c.convertSchemaToGenai(
-			outputSchema.Parameters,
-			outputSchema.Required,
-		)
+		c.applyOutputSchema(config, outputSchema)
 	}
 
 	chat, err := c.client.Chats.Create(
@@ -734,6 +932,13 @@ func (c *Client) streamInternal(
 				}
 
 				finalResp = resp
+				if resp.UsageMetadata != nil {
+					usage := c.usage(resp)
+					eventChan <- llm.Event{
+						Type:  types.EventUsageUpdate,
+						Usage: &usage,
+					}
+				}
 
 				if len(resp.Candidates) > 0 &&
 					resp.Candidates[0].Content != nil {
@@ -975,6 +1180,25 @@ func mapJSONTypeToGenAI(jsonType string) genai.Type {
 	}
 }
 
+// applyOutputSchema sets the response schema on config. A complete JSON Schema
+// (with $defs, $ref, or unions) is passed through as-is via
+// ResponseJsonSchema; the properties form is converted to a genai.Schema.
+// Either form requires a JSON response MIME type.
+func (c *Client) applyOutputSchema(
+	config *genai.GenerateContentConfig,
+	outputSchema *schema.StructuredOutputInfo,
+) {
+	config.ResponseMIMEType = "application/json"
+	if outputSchema.Schema != nil {
+		config.ResponseJsonSchema = outputSchema.Schema
+		return
+	}
+	config.ResponseSchema = c.convertSchemaToGenai(
+		outputSchema.Parameters,
+		outputSchema.Required,
+	)
+}
+
 func (c *Client) convertSchemaToGenai(
 	parameters map[string]any,
 	required []string,
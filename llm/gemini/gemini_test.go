@@ -1,9 +1,12 @@
 package gemini
 
 import (
+	"context"
 	"testing"
 
+	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/model"
+	"google.golang.org/genai"
 )
 
 func reasoningClient(opts ...Option) *Client {
@@ -67,3 +70,30 @@ func TestThinkingDisabledWithoutReasoning(t *testing.T) {
 		t.Error("expected no ThinkingConfig when model cannot reason")
 	}
 }
+
+// TestForRequestEffortReplacesBudget verifies a reasoning effort from the
+// context is sent as a thinking level without the configured budget, which the
+// API rejects alongside a level, and is ignored on Gemini 2.x.
+func TestForRequestEffortReplacesBudget(t *testing.T) {
+	ctx := llm.ContextWithReasoningEffort(
+		context.Background(),
+		llm.ReasoningEffortHigh,
+	)
+	c := reasoningClient(WithThinkingBudget(2048))
+	c.options.model.Provider = model.ProviderGemini
+	c.options.model.APIModel = "gemini-3-pro"
+
+	tc := c.forRequest(ctx).buildConfig(nil, nil).ThinkingConfig
+	if tc == nil || tc.ThinkingLevel != genai.ThinkingLevelHigh {
+		t.Fatalf("ThinkingConfig = %+v, want level high", tc)
+	}
+	if tc.ThinkingBudget != nil {
+		t.Errorf("ThinkingBudget = %d, want unset", *tc.ThinkingBudget)
+	}
+
+	c.options.model.APIModel = "gemini-2.5-flash"
+	tc = c.forRequest(ctx).buildConfig(nil, nil).ThinkingConfig
+	if tc == nil || tc.ThinkingLevel != "" || tc.ThinkingBudget == nil {
+		t.Errorf("ThinkingConfig = %+v, want only the budget", tc)
+	}
+}
@@ -4,6 +4,7 @@ go 1.25.0
 
 require (
 	github.com/joakimcarlsson/ai/message v0.4.0
+	github.com/joakimcarlsson/ai/metrics v0.1.0
 	github.com/joakimcarlsson/ai/model v0.6.0
 	github.com/joakimcarlsson/ai/schema v0.2.0
 	github.com/joakimcarlsson/ai/tool v0.1.2
@@ -48,6 +49,7 @@ require (
 
 replace (
 	github.com/joakimcarlsson/ai/message => ../message
+	github.com/joakimcarlsson/ai/metrics => ../metrics
 	github.com/joakimcarlsson/ai/model => ../model
 	github.com/joakimcarlsson/ai/schema => ../schema
 	github.com/joakimcarlsson/ai/tool => ../tool
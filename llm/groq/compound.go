@@ -245,7 +245,10 @@ func (c *compoundClient) convertMessages(
 			for _, result := range msg.ToolResults() {
 				out = append(
 					out,
-					openaisdk.ToolMessage(result.Content, result.ToolCallID),
+					openaisdk.ToolMessage(
+						result.TextWithImageNotes(),
+						result.ToolCallID,
+					),
 				)
 			}
 		}
@@ -428,12 +431,22 @@ func (c *compoundClient) buildResponse(
 	}
 }
 
+func errorEvent(err error) <-chan llm.Event {
+	eventChan := make(chan llm.Event, 1)
+	eventChan <- llm.Event{Type: types.EventError, Error: err}
+	close(eventChan)
+	return eventChan
+}
+
 // SendMessages sends a conversation and returns the complete response.
 func (c *compoundClient) SendMessages(
 	ctx context.Context,
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	reqOpts := c.requestOptions(convertedTools)
@@ -464,6 +477,9 @@ func (c *compoundClient) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	params.ResponseFormat = c.responseFormat(outputSchema)
@@ -494,18 +510,11 @@ func (c *compoundClient) SendMessagesWithStructuredOutput(
 func (c *compoundClient) responseFormat(
 	outputSchema *schema.StructuredOutputInfo,
 ) openaisdk.ChatCompletionNewParamsResponseFormatUnion {
-	schemaMap := map[string]any{
-		"type":       "object",
-		"properties": outputSchema.Parameters,
-	}
-	if len(outputSchema.Required) > 0 {
-		schemaMap["required"] = outputSchema.Required
-	}
 	return openaisdk.ChatCompletionNewParamsResponseFormatUnion{
 		OfJSONSchema: &openaisdk.ResponseFormatJSONSchemaParam{
 			JSONSchema: openaisdk.ResponseFormatJSONSchemaJSONSchemaParam{
 				Name:   "structured_output",
-				Schema: schemaMap,
+				Schema: outputSchema.StrictJSONSchema(),
 				Strict: openaisdk.Bool(true),
 			},
 		},
@@ -537,6 +546,9 @@ func (c *compoundClient) runStream(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	params.StreamOptions = openaisdk.ChatCompletionStreamOptionsParam{
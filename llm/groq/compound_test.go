@@ -2,13 +2,16 @@ package groq
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/types"
 	openaisdk "github.com/openai/openai-go/v3"
 )
 
@@ -116,3 +119,39 @@ func TestCompoundPreparedParamsStopSequencesCappedAtFour(t *testing.T) {
 		)
 	}
 }
+
+// TestCompoundRejectsVideo verifies that a request carrying video fails with
+// llm.ErrCapabilityUnsupported on both the send and stream paths without
+// reaching the server.
+func TestCompoundRejectsVideo(t *testing.T) {
+	var n int
+	client := NewCompoundLLM(
+		WithCompoundAPIKey("test-key"),
+		WithCompoundBaseURL("http://127.0.0.1:1"),
+		WithCompoundModel(model.Model{APIModel: "groq/compound"}),
+		WithCompoundHTTPClient(&http.Client{
+			Transport: countingRT{RoundTripper: http.DefaultTransport, n: &n},
+		}),
+	)
+	msg := message.NewUserMessage("what happens?")
+	msg.AddVideoURL("https://www.youtube.com/watch?v=abc", nil)
+	messages := []message.Message{msg}
+
+	_, err := client.SendMessages(context.Background(), messages, nil)
+	if !errors.Is(err, llm.ErrCapabilityUnsupported) {
+		t.Errorf("SendMessages err = %v, want ErrCapabilityUnsupported", err)
+	}
+	for event := range client.StreamResponse(
+		context.Background(),
+		messages,
+		nil,
+	) {
+		if event.Type != types.EventError ||
+			!errors.Is(event.Error, llm.ErrCapabilityUnsupported) {
+			t.Errorf("stream event = %+v, want capability error", event)
+		}
+	}
+	if n != 0 {
+		t.Errorf("%d requests reached the server, want 0", n)
+	}
+}
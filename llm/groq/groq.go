@@ -10,6 +10,7 @@ package groq
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical Groq OpenAI-compatible API endpoint.
@@ -20,9 +21,13 @@ type Option = llmopenai.Option
 
 // NewLLM constructs a Groq LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override.
+// The endpoint defaults to [DefaultBaseURL]; AI_GROQ_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. The API key and model fall back
+// to AI_GROQ_API_KEY and AI_GROQ_MODEL when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderGROQ),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type idempotencyKeyKey struct{}
+
+// ContextWithIdempotencyKey returns a copy of ctx carrying key. Vendor
+// packages that support idempotency send it with requests made with the
+// returned context, including their own retries of that request, so the
+// provider can recognize a repeated request instead of running it twice.
+//
+// Use a key derived from your own request or job ID when the same logical
+// request may be sent again from another process, e.g. under at-least-once
+// delivery; the key must be identical on every attempt.
+func ContextWithIdempotencyKey(
+	ctx context.Context,
+	key string,
+) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key carried by ctx, if
+// any.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// NewIdempotencyKey returns a random idempotency key.
+func NewIdempotencyKey() string {
+	return rand.Text()
+}
+
+// WithIdempotencyKeys wraps an LLM client so every request that does not
+// already carry a key from [ContextWithIdempotencyKey] gets a fresh one from
+// [NewIdempotencyKey]. The key covers the vendor package's retries of that
+// request; requests repeated by the caller get a new key, so set one
+// explicitly when those should be deduplicated too.
+func WithIdempotencyKeys(inner LLM) LLM {
+	return &idempotencyLLM{inner: inner}
+}
+
+type idempotencyLLM struct {
+	inner LLM
+}
+
+func withIdempotencyKey(ctx context.Context) context.Context {
+	if _, ok := IdempotencyKeyFromContext(ctx); ok {
+		return ctx
+	}
+	return ContextWithIdempotencyKey(ctx, NewIdempotencyKey())
+}
+
+func (c *idempotencyLLM) Model() model.Model {
+	return c.inner.Model()
+}
+
+func (c *idempotencyLLM) Unwrap() LLM {
+	return c.inner
+}
+
+func (c *idempotencyLLM) SupportsStructuredOutput() bool {
+	return c.inner.SupportsStructuredOutput()
+}
+
+func (c *idempotencyLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	return c.inner.SendMessages(withIdempotencyKey(ctx), messages, tools)
+}
+
+func (c *idempotencyLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return c.inner.SendMessagesWithStructuredOutput(
+		withIdempotencyKey(ctx),
+		messages,
+		tools,
+		outputSchema,
+	)
+}
+
+func (c *idempotencyLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return c.inner.StreamResponse(withIdempotencyKey(ctx), messages, tools)
+}
+
+func (c *idempotencyLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return c.inner.StreamResponseWithStructuredOutput(
+		withIdempotencyKey(ctx),
+		messages,
+		tools,
+		outputSchema,
+	)
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+// keyRecordingLLM records the idempotency key of every request it serves.
+type keyRecordingLLM struct {
+	scriptedLLM
+	keys []string
+}
+
+func (k *keyRecordingLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	key, _ := IdempotencyKeyFromContext(ctx)
+	k.keys = append(k.keys, key)
+	return k.scriptedLLM.SendMessages(ctx, messages, tools)
+}
+
+func TestWithIdempotencyKeys(t *testing.T) {
+	inner := &keyRecordingLLM{}
+	client := WithIdempotencyKeys(inner)
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := client.SendMessages(ctx, nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	ctx = ContextWithIdempotencyKey(ctx, "order-7")
+	if _, err := client.SendMessages(ctx, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if inner.keys[0] == "" || inner.keys[0] == inner.keys[1] {
+		t.Errorf("expected a distinct key per request, got %q", inner.keys)
+	}
+	if inner.keys[2] != "order-7" {
+		t.Errorf("expected caller's key kept, got %q", inner.keys[2])
+	}
+	if _, ok := IdempotencyKeyFromContext(
+		ContextWithIdempotencyKey(context.Background(), ""),
+	); ok {
+		t.Error("expected empty key treated as unset")
+	}
+}
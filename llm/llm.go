@@ -156,6 +156,11 @@ type Response struct {
 	// ProviderMetadata carries provider-specific structured data from
 	// server-side built-in tools. Keys are namespaced per provider.
 	ProviderMetadata map[string]any
+	// Citations lists the sources the model cited, with offsets into
+	// Content. Anthropic populates it for web search and for documents with
+	// citations enabled, and the OpenAI Responses API client for web search
+	// results; other providers leave it nil.
+	Citations []message.Citation
 	// LogProbs holds per-token log probabilities for the primary choice when
 	// log probabilities were requested (llm/openai.WithLogprobs); nil
 	// otherwise. Only OpenAI and OpenAI-compatible providers populate it.
@@ -181,6 +186,9 @@ type Response struct {
 	// HTTP response. Only those headers are retained — never the full set — to
 	// avoid leaking auth-echo headers. Nil when unavailable.
 	ResponseHeaders http.Header
+	// ServedBy is the model of the client that produced this response when it
+	// came through a [WithFallback] chain. Nil for direct calls.
+	ServedBy *model.Model
 }
 
 // SelectResponseHeaders extracts the provider request id and a small allowlist
@@ -220,6 +228,9 @@ type Event struct {
 	Response *Response
 	ToolCall *message.ToolCall
 	Error    error
+	// Usage holds the running totals for the call so far on
+	// [types.EventUsageUpdate] events.
+	Usage *TokenUsage
 }
 
 // LLM defines the interface for interacting with Large Language Model providers.
@@ -292,6 +303,27 @@ func (t *tracingLLM) Model() model.Model {
 	return t.inner.Model()
 }
 
+func (t *tracingLLM) Unwrap() LLM {
+	return t.inner
+}
+
+// ConfiguredMaxTokens returns the max_tokens the vendor client behind client
+// was constructed with, looking through the decorators in this package. It is
+// zero when the client did not set one.
+func ConfiguredMaxTokens(client LLM) int64 {
+	for client != nil {
+		if t, ok := client.(*tracingLLM); ok {
+			return t.attrs.MaxTokens
+		}
+		w, ok := client.(interface{ Unwrap() LLM })
+		if !ok {
+			break
+		}
+		client = w.Unwrap()
+	}
+	return 0
+}
+
 func (t *tracingLLM) SupportsStructuredOutput() bool {
 	return t.inner.SupportsStructuredOutput()
 }
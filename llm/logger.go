@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type loggerKey struct{}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// ContextWithLogger returns a copy of ctx carrying logger. The retry helpers
+// and vendor packages log through the logger found on the request context.
+func ContextWithLogger(
+	ctx context.Context,
+	logger *slog.Logger,
+) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the logger carried by ctx, or a logger that
+// discards all records when none is set.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok &&
+		logger != nil {
+		return logger
+	}
+	return discardLogger
+}
+
+// retryLogger keeps retry warnings on slog.Default unless a logger was
+// configured, so existing deployments continue to see them.
+func retryLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok &&
+		logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithLogger wraps an LLM client so every call emits structured debug logs
+// for request start and completion (model, message count, tokens, duration)
+// and a warning when a request fails. The logger is also attached to the
+// request context so retries are reported through it. A nil logger returns
+// inner unchanged.
+func WithLogger(inner LLM, logger *slog.Logger) LLM {
+	if logger == nil {
+		return inner
+	}
+	return &loggingLLM{inner: inner, logger: logger}
+}
+
+type loggingLLM struct {
+	inner  LLM
+	logger *slog.Logger
+}
+
+func (l *loggingLLM) Model() model.Model {
+	return l.inner.Model()
+}
+
+func (l *loggingLLM) Unwrap() LLM {
+	return l.inner
+}
+
+func (l *loggingLLM) SupportsStructuredOutput() bool {
+	return l.inner.SupportsStructuredOutput()
+}
+
+func (l *loggingLLM) start(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	structured bool,
+) (context.Context, time.Time) {
+	m := l.inner.Model()
+	l.logger.DebugContext(ctx, "llm request started",
+		slog.String("provider", string(m.Provider)),
+		slog.String("model", m.APIModel),
+		slog.Int("messages", len(messages)),
+		slog.Int("tools", len(tools)),
+		slog.Bool("structured_output", structured),
+	)
+	return ContextWithLogger(ctx, l.logger), time.Now()
+}
+
+func (l *loggingLLM) finish(
+	ctx context.Context,
+	start time.Time,
+	resp *Response,
+	err error,
+) {
+	m := l.inner.Model()
+	if err != nil {
+		l.logger.WarnContext(ctx, "llm request failed",
+			slog.String("provider", string(m.Provider)),
+			slog.String("model", m.APIModel),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	attrs := []any{
+		slog.String("provider", string(m.Provider)),
+		slog.String("model", m.APIModel),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int64("input_tokens", resp.Usage.InputTokens),
+			slog.Int64("output_tokens", resp.Usage.OutputTokens),
+			slog.String("finish_reason", string(resp.FinishReason)),
+			slog.Int("tool_calls", len(resp.ToolCalls)),
+		)
+	}
+	l.logger.DebugContext(ctx, "llm request completed", attrs...)
+}
+
+func (l *loggingLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	ctx, start := l.start(ctx, messages, tools, false)
+	resp, err := l.inner.SendMessages(ctx, messages, tools)
+	l.finish(ctx, start, resp, err)
+	return resp, err
+}
+
+func (l *loggingLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	ctx, start := l.start(ctx, messages, tools, true)
+	resp, err := l.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+	l.finish(ctx, start, resp, err)
+	return resp, err
+}
+
+func (l *loggingLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	ctx, start := l.start(ctx, messages, tools, false)
+	return l.observeStream(
+		ctx,
+		start,
+		l.inner.StreamResponse(ctx, messages, tools),
+	)
+}
+
+func (l *loggingLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	ctx, start := l.start(ctx, messages, tools, true)
+	return l.observeStream(
+		ctx,
+		start,
+		l.inner.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		),
+	)
+}
+
+func (l *loggingLLM) observeStream(
+	ctx context.Context,
+	start time.Time,
+	innerCh <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for evt := range innerCh {
+			if evt.Type == types.EventComplete && evt.Response != nil {
+				l.finish(ctx, start, evt.Response, nil)
+			}
+			if evt.Type == types.EventError && evt.Error != nil {
+				l.finish(ctx, start, nil, evt.Error)
+			}
+			select {
+			case outCh <- evt:
+			case <-ctx.Done():
+				drainEvents(innerCh)
+				return
+			}
+		}
+	}()
+	return outCh
+}
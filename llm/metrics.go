@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/metrics"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// WithMetrics wraps an LLM client so every call reports request count, latency,
+// token usage, and errors to collector. A nil collector returns inner unchanged.
+//
+// See the metrics package for the metric names and labels that are reported.
+func WithMetrics(inner LLM, collector metrics.Collector) LLM {
+	if collector == nil {
+		return inner
+	}
+	return &metricsLLM{inner: inner, collector: collector}
+}
+
+type metricsLLM struct {
+	inner     LLM
+	collector metrics.Collector
+}
+
+func (m *metricsLLM) Model() model.Model {
+	return m.inner.Model()
+}
+
+func (m *metricsLLM) Unwrap() LLM {
+	return m.inner
+}
+
+func (m *metricsLLM) SupportsStructuredOutput() bool {
+	return m.inner.SupportsStructuredOutput()
+}
+
+func (m *metricsLLM) record(start time.Time, resp *Response, err error) {
+	mdl := m.inner.Model()
+	status := metrics.StatusOK
+	if err != nil {
+		status = metrics.StatusError
+	}
+
+	labels := metrics.Labels{
+		metrics.LabelProvider: string(mdl.Provider),
+		metrics.LabelModel:    mdl.APIModel,
+		metrics.LabelStatus:   status,
+	}
+	m.collector.IncCounter(metrics.LLMRequests, 1, labels)
+	m.collector.ObserveHistogram(
+		metrics.LLMRequestDuration,
+		time.Since(start).Seconds(),
+		labels,
+	)
+
+	if err != nil {
+		m.collector.IncCounter(metrics.LLMErrors, 1, metrics.Labels{
+			metrics.LabelProvider: string(mdl.Provider),
+			metrics.LabelModel:    mdl.APIModel,
+		})
+		return
+	}
+
+	if resp == nil {
+		return
+	}
+
+	tokens := map[string]int64{
+		"input":          resp.Usage.InputTokens,
+		"output":         resp.Usage.OutputTokens,
+		"cache_read":     resp.Usage.CacheReadTokens,
+		"cache_creation": resp.Usage.CacheCreationTokens,
+	}
+	for kind, count := range tokens {
+		if count == 0 {
+			continue
+		}
+		m.collector.IncCounter(metrics.LLMTokens, float64(count), metrics.Labels{
+			metrics.LabelProvider: string(mdl.Provider),
+			metrics.LabelModel:    mdl.APIModel,
+			metrics.LabelType:     kind,
+		})
+	}
+}
+
+func (m *metricsLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	start := time.Now()
+	resp, err := m.inner.SendMessages(ctx, messages, tools)
+	m.record(start, resp, err)
+	return resp, err
+}
+
+func (m *metricsLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	start := time.Now()
+	resp, err := m.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+	m.record(start, resp, err)
+	return resp, err
+}
+
+func (m *metricsLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	start := time.Now()
+	return m.observeStream(
+		ctx,
+		start,
+		m.inner.StreamResponse(ctx, messages, tools),
+	)
+}
+
+func (m *metricsLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	start := time.Now()
+	return m.observeStream(
+		ctx,
+		start,
+		m.inner.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		),
+	)
+}
+
+func (m *metricsLLM) observeStream(
+	ctx context.Context,
+	start time.Time,
+	innerCh <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for evt := range innerCh {
+			if evt.Type == types.EventComplete && evt.Response != nil {
+				m.record(start, evt.Response, nil)
+			}
+			if evt.Type == types.EventError && evt.Error != nil {
+				m.record(start, nil, evt.Error)
+			}
+			select {
+			case outCh <- evt:
+			case <-ctx.Done():
+				drainEvents(innerCh)
+				return
+			}
+		}
+	}()
+	return outCh
+}
@@ -14,6 +14,7 @@ package ollama
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is Ollama's default OpenAI-compatible endpoint.
@@ -24,12 +25,14 @@ type Option = llmopenai.Option
 
 // NewLLM constructs an Ollama LLM client.
 //
-// The defaults ([llmopenai.WithBaseURL]([DefaultBaseURL]) and
-// [llmopenai.WithAPIKey]("")) are prepended; callers can override either by
-// passing the same option again in opts.
+// The endpoint defaults to [DefaultBaseURL]; AI_OLLAMA_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. No API key is sent unless
+// AI_OLLAMA_API_KEY or [llmopenai.WithAPIKey] provides one, and the model falls
+// back to AI_OLLAMA_MODEL.
 func NewLLM(opts ...Option) llm.LLM {
 	defaults := []Option{
-		llmopenai.WithBaseURL(DefaultBaseURL),
+		llmopenai.WithEnvProvider(model.ProviderOllama),
+		llmopenai.WithDefaultBaseURL(DefaultBaseURL),
 		llmopenai.WithAPIKey(""),
 	}
 	return llmopenai.NewLLM(append(defaults, opts...)...)
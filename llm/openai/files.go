@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/joakimcarlsson/ai/message"
+	openaisdk "github.com/openai/openai-go/v3"
+)
+
+// UploadFile stores data with the OpenAI Files API for use as a file input,
+// typically a PDF, and returns a reference to attach with
+// [message.Message.AddFileRef]. Files are kept until deleted with
+// [Client.DeleteFile].
+func (c *Client) UploadFile(
+	ctx context.Context,
+	data []byte,
+	mimeType string,
+) (message.FileRef, error) {
+	file, err := c.client.Files.New(
+		ctx,
+		openaisdk.FileNewParams{
+			File: openaisdk.File(
+				bytes.NewReader(data),
+				uploadName(mimeType),
+				mimeType,
+			),
+			Purpose: openaisdk.FilePurposeUserData,
+		},
+	)
+	if err != nil {
+		return message.FileRef{}, fmt.Errorf("upload file: %w", err)
+	}
+	ref := message.FileRef{
+		Provider:  c.options.model.Provider,
+		ID:        file.ID,
+		MIMEType:  mimeType,
+		Name:      file.Filename,
+		SizeBytes: file.Bytes,
+	}
+	if file.ExpiresAt > 0 {
+		ref.ExpiresAt = time.Unix(file.ExpiresAt, 0)
+	}
+	return ref, nil
+}
+
+// DeleteFile removes a file uploaded with [Client.UploadFile].
+func (c *Client) DeleteFile(ctx context.Context, ref message.FileRef) error {
+	_, err := c.client.Files.Delete(ctx, ref.ID)
+	if err != nil {
+		return fmt.Errorf("delete file %s: %w", ref.ID, err)
+	}
+	return nil
+}
+
+func uploadName(mimeType string) string {
+	exts, _ := mime.ExtensionsByType(mimeType)
+	if len(exts) == 0 {
+		return "upload"
+	}
+	return "upload" + exts[0]
+}
+
+func fileRefPart(
+	ref message.FileRef,
+) openaisdk.ChatCompletionContentPartUnionParam {
+	return openaisdk.ChatCompletionContentPartUnionParam{
+		OfFile: &openaisdk.ChatCompletionContentPartFileParam{
+			File: openaisdk.ChatCompletionContentPartFileFileParam{
+				FileID: openaisdk.String(ref.ID),
+			},
+		},
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/joakimcarlsson/ai/llm"
@@ -48,12 +49,15 @@ type Options struct {
 	baseURL                string
 	disableCache           bool
 	reasoningEffort        *ReasoningEffort
+	verbosity              *llm.Verbosity
 	extraHeaders           map[string]string
 	frequencyPenalty       *float64
 	presencePenalty        *float64
 	seed                   *int64
 	parallelToolCalls      *bool
 	toolChoice             *llm.ToolChoice
+	user                   string
+	metadata               map[string]string
 	extraBodyFields        map[string]any
 	metadataFields         map[string]string
 	httpClient             *http.Client
@@ -61,6 +65,8 @@ type Options struct {
 	topLogprobs            *int
 	n                      *int64
 	reasoningContentReplay bool
+	envProvider            model.Provider
+	defaultBaseURL         string
 }
 
 // Option configures Options.
@@ -120,6 +126,22 @@ func WithBaseURL(
 	return func(o *Options) { o.baseURL = baseURL }
 }
 
+// WithEnvProvider sets the provider whose AI_<PROVIDER>_API_KEY,
+// AI_<PROVIDER>_BASE_URL and AI_<PROVIDER>_MODEL environment variables fill in
+// settings not passed as options (see [llm.EnvName]). It defaults to
+// [model.ProviderOpenAI]; the OpenAI-compatible wrapper packages set their
+// own provider so that, for example, llm/groq reads AI_GROQ_API_KEY.
+func WithEnvProvider(provider model.Provider) Option {
+	return func(o *Options) { o.envProvider = provider }
+}
+
+// WithDefaultBaseURL sets the endpoint used when neither [WithBaseURL] nor
+// the AI_<PROVIDER>_BASE_URL environment variable is set. Wrapper packages use
+// it for their canonical endpoint.
+func WithDefaultBaseURL(baseURL string) Option {
+	return func(o *Options) { o.defaultBaseURL = baseURL }
+}
+
 // WithReasoningContentReplay enables echoing previous reasoning content back in assistant messages.
 func WithReasoningContentReplay(enable bool) Option {
 	return func(o *Options) { o.reasoningContentReplay = enable }
@@ -148,6 +170,12 @@ func WithReasoningEffort(effort ReasoningEffort) Option {
 	return func(o *Options) { o.reasoningEffort = &effort }
 }
 
+// WithVerbosity sets how long and detailed answers should be. Only GPT-5
+// models accept it; it is not sent to other models.
+func WithVerbosity(v llm.Verbosity) Option {
+	return func(o *Options) { o.verbosity = &v }
+}
+
 // WithFrequencyPenalty sets the frequency penalty.
 func WithFrequencyPenalty(
 	p float64,
@@ -206,6 +234,21 @@ func WithToolChoice(choice llm.ToolChoice) Option {
 	return func(o *Options) { o.toolChoice = &choice }
 }
 
+// WithUser sets the end-user ID sent in OpenAI's user field, which OpenAI
+// uses to attribute abuse to individual users of an application. Use an
+// opaque ID such as a hash. [llm.ContextWithUser] overrides it per call.
+func WithUser(userID string) Option {
+	return func(o *Options) { o.user = userID }
+}
+
+// WithMetadata sets key-value pairs sent in OpenAI's metadata field (up to 16
+// pairs). Metadata set with [llm.ContextWithMetadata] is merged over it per
+// call. It is only sent to OpenAI and Azure OpenAI, as OpenAI-compatible
+// providers may reject the field.
+func WithMetadata(metadata map[string]string) Option {
+	return func(o *Options) { o.metadata = metadata }
+}
+
 // WithRequestJSONField injects an arbitrary top-level field into the request
 // body via the SDK's WithJSONSet. It is the shared mechanism OpenAI-compatible
 // providers (OpenRouter, Perplexity, ...) use to express vendor features that
@@ -280,11 +323,14 @@ type Client struct {
 
 // NewLLM constructs an OpenAI LLM client. The returned [llm.LLM] is wrapped
 // with [llm.WithTracing], so callers always get tracing spans and metrics.
+// Settings not passed as options are read from AI_OPENAI_API_KEY,
+// AI_OPENAI_BASE_URL and AI_OPENAI_MODEL; see [WithEnvProvider].
 func NewLLM(opts ...Option) llm.LLM {
 	options := Options{}
 	for _, o := range opts {
 		o(&options)
 	}
+	applyEnv(&options)
 
 	clientOpts := []option.RequestOption{}
 	if options.apiKey != "" {
@@ -313,6 +359,29 @@ func NewLLM(opts ...Option) llm.LLM {
 	})
 }
 
+// applyEnv fills in the API key, base URL and model from the environment
+// when they were not set by options. Explicit options always win.
+func applyEnv(o *Options) {
+	provider := o.envProvider
+	if provider == "" {
+		provider = model.ProviderOpenAI
+	}
+	if o.apiKey == "" {
+		o.apiKey = llm.Env(provider, llm.EnvAPIKey)
+	}
+	if o.baseURL == "" {
+		o.baseURL = llm.Env(provider, llm.EnvBaseURL)
+	}
+	if o.baseURL == "" {
+		o.baseURL = o.defaultBaseURL
+	}
+	if o.model.ID == "" && o.model.APIModel == "" {
+		if m, ok := llm.ModelFromEnv(provider); ok {
+			o.model = m
+		}
+	}
+}
+
 // NewWithExistingClient is for embedding by other packages (e.g. llm/azure) that
 // build the OpenAI SDK client themselves and want this package's request logic.
 // The returned *Client is the bare implementation, not wrapped in tracing.
@@ -383,6 +452,10 @@ func (c *Client) convertMessages(
 				)
 			}
 
+			for _, ref := range msg.FileRefs() {
+				content = append(content, fileRefPart(ref))
+			}
+
 			openaiMessages = append(
 				openaiMessages,
 				openaisdk.UserMessage(content),
@@ -436,7 +509,10 @@ func (c *Client) convertMessages(
 		case message.Tool:
 			for _, result := range msg.ToolResults() {
 				openaiMessages = append(openaiMessages,
-					openaisdk.ToolMessage(result.Content, result.ToolCallID),
+					openaisdk.ToolMessage(
+						result.TextWithImageNotes(),
+						result.ToolCallID,
+					),
 				)
 			}
 		}
@@ -544,10 +620,36 @@ func (c *Client) preparedParams(
 		c.options.topP,
 		nil,
 	)
-	pb.ApplyFloat64Temperature(
-		func(t *float64) { params.Temperature = openaisdk.Float(*t) },
-	)
-	pb.ApplyFloat64TopP(func(p *float64) { params.TopP = openaisdk.Float(*p) })
+	if !isReasoningModel(c.options.model) {
+		pb.ApplyFloat64Temperature(
+			func(t *float64) { params.Temperature = openaisdk.Float(*t) },
+		)
+		pb.ApplyFloat64TopP(
+			func(p *float64) { params.TopP = openaisdk.Float(*p) },
+		)
+		pb.ApplyFloat64FrequencyPenalty(c.options.frequencyPenalty,
+			func(fp *float64) {
+				params.FrequencyPenalty = openaisdk.Float(*fp)
+			})
+		pb.ApplyFloat64PresencePenalty(c.options.presencePenalty,
+			func(pp *float64) {
+				params.PresencePenalty = openaisdk.Float(*pp)
+			})
+
+		if len(c.options.logitBias) > 0 {
+			bias := make(map[string]int64, len(c.options.logitBias))
+			for token, b := range c.options.logitBias {
+				bias[token] = int64(b)
+			}
+			params.LogitBias = bias
+		}
+		if c.options.topLogprobs != nil {
+			params.Logprobs = openaisdk.Bool(true)
+			params.TopLogprobs = openaisdk.Int(
+				int64(*c.options.topLogprobs),
+			)
+		}
+	}
 
 	if len(c.options.stopSequences) > 0 {
 		stops := c.options.stopSequences
@@ -559,24 +661,8 @@ func (c *Client) preparedParams(
 		}
 	}
 
-	pb.ApplyFloat64FrequencyPenalty(c.options.frequencyPenalty,
-		func(fp *float64) { params.FrequencyPenalty = openaisdk.Float(*fp) })
-	pb.ApplyFloat64PresencePenalty(c.options.presencePenalty,
-		func(pp *float64) { params.PresencePenalty = openaisdk.Float(*pp) })
 	pb.ApplyInt64Seed(c.options.seed,
 		func(s *int64) { params.Seed = openaisdk.Int(*s) })
-
-	if len(c.options.logitBias) > 0 {
-		bias := make(map[string]int64, len(c.options.logitBias))
-		for token, b := range c.options.logitBias {
-			bias[token] = int64(b)
-		}
-		params.LogitBias = bias
-	}
-	if c.options.topLogprobs != nil {
-		params.Logprobs = openaisdk.Bool(true)
-		params.TopLogprobs = openaisdk.Int(int64(*c.options.topLogprobs))
-	}
 	if c.options.n != nil {
 		params.N = openaisdk.Int(*c.options.n)
 	}
@@ -594,10 +680,56 @@ func (c *Client) preparedParams(
 			params.ReasoningEffort = shared.ReasoningEffortHigh
 		}
 	}
+	if c.options.verbosity != nil && llm.SupportsVerbosity(c.options.model) {
+		params.Verbosity = openaisdk.ChatCompletionNewParamsVerbosity(
+			*c.options.verbosity,
+		)
+	}
+
+	if c.options.user != "" {
+		params.User = openaisdk.String(c.options.user)
+	}
+	if len(c.options.metadata) > 0 && acceptsMetadata(c.options.model) {
+		params.Metadata = shared.Metadata(c.options.metadata)
+	}
 
 	return params
 }
 
+// acceptsMetadata reports whether requests for m may carry the metadata
+// field, which OpenAI-compatible providers may not accept.
+func acceptsMetadata(m model.Model) bool {
+	switch m.Provider {
+	case model.ProviderOpenAI, model.ProviderAzure, "":
+		return true
+	}
+	return false
+}
+
+// isReasoningModel reports whether m is an OpenAI reasoning model, from the
+// o-series or GPT-5 family. These reject temperature, top_p, penalties,
+// logprobs, and logit_bias with a 400, so those settings are left out of
+// requests to them; the output limit is always sent as max_completion_tokens.
+// Custom models without a provider are recognized by their API model name.
+// Models on OpenAI-compatible providers accept the parameters and are never
+// treated as such.
+func isReasoningModel(m model.Model) bool {
+	switch m.Provider {
+	case model.ProviderOpenAI, model.ProviderAzure:
+		if m.CanReason {
+			return true
+		}
+	case "":
+	default:
+		return false
+	}
+	name := m.APIModel
+	if strings.HasPrefix(name, "gpt-5") {
+		return true
+	}
+	return len(name) > 1 && name[0] == 'o' && name[1] >= '1' && name[1] <= '9'
+}
+
 // requestOptions returns per-call SDK request options derived from Options.
 //
 // The OpenAI Go SDK has no native top_k field on ChatCompletionNewParams. When
@@ -620,11 +752,57 @@ func (c *Client) requestOptions() []option.RequestOption {
 
 // requestOptionsInto returns the per-call request options plus a hook that
 // copies the raw [*http.Response] into raw, so the request id and selected
-// response headers can be lifted onto [llm.Response] after the call.
+// response headers can be lifted onto [llm.Response] after the call. The
+// idempotency key carried by ctx, if any, is sent as well.
 func (c *Client) requestOptionsInto(
+	ctx context.Context,
 	raw **http.Response,
 ) []option.RequestOption {
-	return append(c.requestOptions(), option.WithResponseInto(raw))
+	opts := append(c.requestOptions(), option.WithResponseInto(raw))
+	return append(opts, idempotencyOptions(ctx)...)
+}
+
+// idempotencyOptions returns an Idempotency-Key header option for the key
+// carried by ctx (see [llm.ContextWithIdempotencyKey]), or nil.
+func idempotencyOptions(ctx context.Context) []option.RequestOption {
+	key, ok := llm.IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []option.RequestOption{option.WithHeader("Idempotency-Key", key)}
+}
+
+// forRequest returns c, or a copy of c using the tool choice, end-user ID,
+// metadata, reasoning effort, and verbosity carried by ctx (see
+// [llm.ContextWithToolChoice], [llm.ContextWithUser],
+// [llm.ContextWithMetadata], [llm.ContextWithReasoningEffort], and
+// [llm.ContextWithVerbosity]) when any is set.
+func (c *Client) forRequest(ctx context.Context) *Client {
+	choice, hasChoice := llm.ToolChoiceFromContext(ctx)
+	user, hasUser := llm.UserFromContext(ctx)
+	hasMetadata := len(llm.MetadataFromContext(ctx)) > 0
+	effort, hasEffort := llm.ReasoningEffortFromContext(ctx)
+	verbosity, hasVerbosity := llm.VerbosityFromContext(ctx)
+	if !hasChoice && !hasUser && !hasMetadata && !hasEffort &&
+		!hasVerbosity {
+		return c
+	}
+	clone := *c
+	if hasChoice {
+		clone.options.toolChoice = &choice
+	}
+	if hasUser {
+		clone.options.user = user
+	}
+	if hasEffort {
+		e := ReasoningEffort(effort)
+		clone.options.reasoningEffort = &e
+	}
+	if hasVerbosity {
+		clone.options.verbosity = &verbosity
+	}
+	clone.options.metadata = llm.MergeMetadata(ctx, c.options.metadata)
+	return &clone
 }
 
 // validateToolChoice rejects a malformed tool choice before a request is sent.
@@ -641,9 +819,13 @@ func (c *Client) SendMessages(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
 		c.convertTools(tools),
@@ -660,7 +842,7 @@ func (c *Client) SendMessages(
 			openaiResponse, err := c.client.Chat.Completions.New(
 				ctx,
 				params,
-				c.requestOptionsInto(&raw)...)
+				c.requestOptionsInto(ctx, &raw)...)
 			if err != nil {
 				return nil, wrapError(err)
 			}
@@ -714,9 +896,13 @@ func (c *Client) StreamResponse(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return errorEvent(err)
 	}
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
 		c.convertTools(tools),
@@ -726,12 +912,11 @@ func (c *Client) StreamResponse(
 	}
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
-	defer cancel()
-
 	eventChan := make(chan llm.Event)
 
 	go func() {
 		defer close(eventChan)
+		defer cancel()
 		llm.ExecuteStreamWithRetry(ctx, RetryConfig(), func() error {
 			return c.runStream(ctx, params, eventChan, false)
 		}, eventChan)
@@ -760,7 +945,7 @@ func (c *Client) runStream(
 	openaiStream := c.client.Chat.Completions.NewStreaming(
 		ctx,
 		params,
-		c.requestOptionsInto(&raw)...)
+		c.requestOptionsInto(ctx, &raw)...)
 
 	acc := openaisdk.ChatCompletionAccumulator{}
 	currentContent := ""
@@ -1006,20 +1191,11 @@ func (c *Client) providerMetadata(
 func (c *Client) responseFormatForSchema(
 	outputSchema *schema.StructuredOutputInfo,
 ) openaisdk.ChatCompletionNewParamsResponseFormatUnion {
-	schemaMap := map[string]any{
-		"type":                 "object",
-		"properties":           outputSchema.Parameters,
-		"additionalProperties": false,
-	}
-	if len(outputSchema.Required) > 0 {
-		schemaMap["required"] = outputSchema.Required
-	}
-
 	return openaisdk.ChatCompletionNewParamsResponseFormatUnion{
 		OfJSONSchema: &openaisdk.ResponseFormatJSONSchemaParam{
 			JSONSchema: openaisdk.ResponseFormatJSONSchemaJSONSchemaParam{
 				Name:   outputSchema.Name,
-				Schema: schemaMap,
+				Schema: outputSchema.StrictJSONSchema(),
 				Strict: openaisdk.Bool(true),
 			},
 		},
@@ -1033,9 +1209,13 @@ func (c *Client) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return nil, err
 	}
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
 		c.convertTools(tools),
@@ -1053,7 +1233,7 @@ func (c *Client) SendMessagesWithStructuredOutput(
 			openaiResponse, err := c.client.Chat.Completions.New(
 				ctx,
 				params,
-				c.requestOptionsInto(&raw)...)
+				c.requestOptionsInto(ctx, &raw)...)
 			if err != nil {
 				return nil, wrapError(err)
 			}
@@ -1096,15 +1276,22 @@ func (c *Client) SendMessagesWithStructuredOutput(
 }
 
 // StreamResponseWithStructuredOutput streams with a JSON schema constraint.
+// The schema is sent as a strict json_schema response_format, so the
+// concatenated content deltas form a document valid against it, and the
+// final [types.EventComplete] response carries it as StructuredOutput.
 func (c *Client) StreamResponseWithStructuredOutput(
 	ctx context.Context,
 	messages []message.Message,
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
 	if err := c.validateToolChoice(); err != nil {
 		return errorEvent(err)
 	}
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
 		c.convertTools(tools),
@@ -1115,12 +1302,11 @@ func (c *Client) StreamResponseWithStructuredOutput(
 	}
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
-	defer cancel()
-
 	eventChan := make(chan llm.Event)
 
 	go func() {
 		defer close(eventChan)
+		defer cancel()
 		llm.ExecuteStreamWithRetry(ctx, RetryConfig(), func() error {
 			return c.runStream(ctx, params, eventChan, true)
 		}, eventChan)
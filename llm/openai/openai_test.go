@@ -13,6 +13,8 @@ import (
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
 	"github.com/joakimcarlsson/ai/tool"
+	openaisdk "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
 )
 
 // stubTool is a no-op BaseTool used to populate the request's tools slice so
@@ -190,6 +192,147 @@ func TestPreparedParamsStopSequencesCappedAtFour(t *testing.T) {
 	}
 }
 
+// TestPreparedParamsReasoningModel verifies that sampling parameters the
+// o-series rejects are left out while the token limit is sent as
+// max_completion_tokens and the reasoning effort is kept.
+func TestPreparedParamsReasoningModel(t *testing.T) {
+	temperature, topP, penalty := 0.2, 0.9, 0.5
+	logprobs := 3
+	effort := ReasoningEffortHigh
+	opts := Options{
+		maxTokens:        2048,
+		temperature:      &temperature,
+		topP:             &topP,
+		frequencyPenalty: &penalty,
+		presencePenalty:  &penalty,
+		logitBias:        map[string]int{"50256": -100},
+		topLogprobs:      &logprobs,
+		reasoningEffort:  &effort,
+	}
+
+	for _, m := range []model.Model{
+		model.OpenAIModels[model.O3Mini],
+		{APIModel: "o1"},
+		{APIModel: "gpt-5-mini"},
+	} {
+		t.Run(m.APIModel, func(t *testing.T) {
+			opts.model = m
+			params := (&Client{options: opts}).preparedParams(nil, nil)
+
+			if params.Temperature.Valid() || params.TopP.Valid() ||
+				params.FrequencyPenalty.Valid() ||
+				params.PresencePenalty.Valid() || params.Logprobs.Valid() ||
+				params.TopLogprobs.Valid() || params.LogitBias != nil {
+				t.Error("sampling parameters sent to a reasoning model")
+			}
+			if params.MaxCompletionTokens.Value != 2048 ||
+				params.MaxTokens.Valid() {
+				t.Errorf(
+					"max_completion_tokens = %d, max_tokens set = %v",
+					params.MaxCompletionTokens.Value,
+					params.MaxTokens.Valid(),
+				)
+			}
+			if m.CanReason &&
+				params.ReasoningEffort != shared.ReasoningEffortHigh {
+				t.Errorf("reasoning effort = %q", params.ReasoningEffort)
+			}
+		})
+	}
+}
+
+// TestPreparedParamsSamplingKeptForOtherModels verifies that chat models,
+// and reasoning models on OpenAI-compatible providers, still receive
+// temperature and top_p.
+func TestPreparedParamsSamplingKeptForOtherModels(t *testing.T) {
+	temperature, topP := 0.2, 0.9
+	for _, m := range []model.Model{
+		model.OpenAIModels[model.GPT4o],
+		{APIModel: "gpt-4.1"},
+		{Provider: model.ProviderDeepSeek, APIModel: "o1", CanReason: true},
+	} {
+		t.Run(m.APIModel, func(t *testing.T) {
+			params := (&Client{options: Options{
+				model:       m,
+				temperature: &temperature,
+				topP:        &topP,
+			}}).preparedParams(nil, nil)
+			if params.Temperature.Value != 0.2 || params.TopP.Value != 0.9 {
+				t.Errorf(
+					"temperature = %v, top_p = %v",
+					params.Temperature,
+					params.TopP,
+				)
+			}
+		})
+	}
+}
+
+func TestPreparedParamsUserAndMetadata(t *testing.T) {
+	c := &Client{options: Options{
+		model:    model.OpenAIModels[model.GPT4o],
+		user:     "default",
+		metadata: map[string]string{"app": "chat", "tenant": "a"},
+	}}
+	ctx := llm.ContextWithUser(context.Background(), "per-call")
+	ctx = llm.ContextWithMetadata(ctx, map[string]string{"tenant": "b"})
+
+	params := c.forRequest(ctx).preparedParams(nil, nil)
+	if params.User.Value != "per-call" {
+		t.Errorf("user = %q, want per-call", params.User.Value)
+	}
+	if params.Metadata["tenant"] != "b" || params.Metadata["app"] != "chat" {
+		t.Errorf("metadata = %v", params.Metadata)
+	}
+	if c.options.metadata["tenant"] != "a" {
+		t.Error("forRequest modified the shared client's metadata")
+	}
+}
+
+func TestPreparedParamsReasoningFromContext(t *testing.T) {
+	effort := ReasoningEffortLow
+	c := &Client{options: Options{
+		model:           model.OpenAIModels[model.GPT5],
+		reasoningEffort: &effort,
+	}}
+	ctx := llm.ContextWithReasoningEffort(
+		context.Background(),
+		llm.ReasoningEffortHigh,
+	)
+	ctx = llm.ContextWithVerbosity(ctx, llm.VerbosityLow)
+
+	params := c.forRequest(ctx).preparedParams(nil, nil)
+	if params.ReasoningEffort != shared.ReasoningEffortHigh {
+		t.Errorf("reasoning effort = %q, want high", params.ReasoningEffort)
+	}
+	if params.Verbosity != openaisdk.ChatCompletionNewParamsVerbosityLow {
+		t.Errorf("verbosity = %q, want low", params.Verbosity)
+	}
+	if *c.options.reasoningEffort != ReasoningEffortLow {
+		t.Error("forRequest modified the shared client's effort")
+	}
+
+	c.options.model = model.OpenAIModels[model.GPT4o]
+	params = c.forRequest(ctx).preparedParams(nil, nil)
+	if params.Verbosity != "" {
+		t.Errorf("verbosity %q sent to a non-GPT-5 model", params.Verbosity)
+	}
+}
+
+func TestPreparedParamsMetadataOnlyForOpenAI(t *testing.T) {
+	params := (&Client{options: Options{
+		model:    model.Model{Provider: model.ProviderGROQ, APIModel: "llama"},
+		user:     "u1",
+		metadata: map[string]string{"app": "chat"},
+	}}).preparedParams(nil, nil)
+	if params.Metadata != nil {
+		t.Errorf("metadata sent to a compatible provider: %v", params.Metadata)
+	}
+	if params.User.Value != "u1" {
+		t.Errorf("user = %q, want u1", params.User.Value)
+	}
+}
+
 // TestRequestOptionsTopK verifies that top_k yields a request option only on
 // the compatible-provider path: it requires both WithTopK and a custom base
 // URL, since OpenAI/Azure proper reject top_k.
@@ -781,3 +924,91 @@ func newCompletionServer(
 			_, _ = io.WriteString(w, response)
 		}))
 }
+
+// TestWireIdempotencyKey confirms the idempotency key carried by the context
+// is sent as the Idempotency-Key header, and that WithIdempotencyKeys
+// generates one when the context has none.
+func TestWireIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"id":"x","object":"chat.completion",`+
+				`"choices":[{"index":0,"message":{"role":"assistant",`+
+				`"content":"hi"},"finish_reason":"stop"}],`+
+				`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "gpt-4o-mini"}),
+	)
+	msgs := []message.Message{message.NewUserMessage("hello")}
+	ctx := context.Background()
+
+	keyed := llm.ContextWithIdempotencyKey(ctx, "job-42")
+	if _, err := client.SendMessages(keyed, msgs, nil); err != nil {
+		t.Fatalf("SendMessages: %v", err)
+	}
+	if _, err := client.SendMessages(ctx, msgs, nil); err != nil {
+		t.Fatalf("SendMessages: %v", err)
+	}
+	auto := llm.WithIdempotencyKeys(client)
+	if _, err := auto.SendMessages(ctx, msgs, nil); err != nil {
+		t.Fatalf("SendMessages: %v", err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(keys))
+	}
+	if keys[0] != "job-42" {
+		t.Errorf("expected explicit key sent, got %q", keys[0])
+	}
+	if keys[1] != "" {
+		t.Errorf("expected no key without one in context, got %q", keys[1])
+	}
+	if keys[2] == "" {
+		t.Error("expected WithIdempotencyKeys to generate a key")
+	}
+}
+
+func TestApplyEnvProvider(t *testing.T) {
+	t.Setenv("AI_OPENAI_API_KEY", "openai-key")
+	t.Setenv("AI_GROQ_API_KEY", "groq-key")
+	t.Setenv("AI_GROQ_BASE_URL", "")
+	t.Setenv("AI_GROQ_MODEL", string(model.Llama4Scout))
+
+	opts := Options{
+		envProvider:    model.ProviderGROQ,
+		defaultBaseURL: "https://default.example.test",
+	}
+	applyEnv(&opts)
+	if opts.apiKey != "groq-key" {
+		t.Errorf("apiKey = %q, want groq-key", opts.apiKey)
+	}
+	if opts.baseURL != "https://default.example.test" {
+		t.Errorf("baseURL = %q, want the default", opts.baseURL)
+	}
+	if opts.model.ID != model.Llama4Scout {
+		t.Errorf("model = %q, want %q", opts.model.ID, model.Llama4Scout)
+	}
+
+	t.Setenv("AI_GROQ_BASE_URL", "https://env.example.test")
+	opts = Options{
+		envProvider:    model.ProviderGROQ,
+		defaultBaseURL: "https://default.example.test",
+	}
+	applyEnv(&opts)
+	if opts.baseURL != "https://env.example.test" {
+		t.Errorf("baseURL = %q, want the environment value", opts.baseURL)
+	}
+
+	opts = Options{apiKey: "option-key", baseURL: "https://option.test"}
+	applyEnv(&opts)
+	if opts.apiKey != "option-key" || opts.baseURL != "https://option.test" {
+		t.Errorf("options overridden by environment: %+v", opts)
+	}
+}
@@ -73,6 +73,7 @@ type ResponsesOptions struct {
 	baseURL         string
 	extraHeaders    map[string]string
 	reasoningEffort *ReasoningEffort
+	verbosity       *llm.Verbosity
 	builtinTools    []responses.ToolUnionParam
 	httpClient      *http.Client
 }
@@ -137,6 +138,12 @@ func WithResponsesReasoningEffort(e ReasoningEffort) ResponsesOption {
 	return func(o *ResponsesOptions) { o.reasoningEffort = &e }
 }
 
+// WithResponsesVerbosity sets how long and detailed answers should be. Only
+// GPT-5 models accept it; it is not sent to other models.
+func WithResponsesVerbosity(v llm.Verbosity) ResponsesOption {
+	return func(o *ResponsesOptions) { o.verbosity = &v }
+}
+
 // WithWebSearch enables the web_search built-in tool. Pass a [WebSearchOpts]
 // to tune context size, allowed domains, or user location.
 func WithWebSearch(opts ...WebSearchOpts) ResponsesOption {
@@ -280,6 +287,16 @@ func NewResponsesLLM(opts ...ResponsesOption) llm.LLM {
 	})
 }
 
+// responsesRequestOptions returns the per-call request options: a hook that
+// copies the raw [*http.Response] into raw, plus the idempotency key carried
+// by ctx, if any.
+func responsesRequestOptions(
+	ctx context.Context,
+	raw **http.Response,
+) []option.RequestOption {
+	return append(idempotencyOptions(ctx), option.WithResponseInto(raw))
+}
+
 // Model returns the configured LLM model.
 func (c *responsesClient) Model() model.Model { return c.options.model }
 
@@ -339,7 +356,9 @@ func (c *responsesClient) convertMessages(
 					OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
 						CallID: result.ToolCallID,
 						Output: responses.ResponseInputItemFunctionCallOutputOutputUnionParam{
-							OfString: openaisdk.String(result.Content),
+							OfString: openaisdk.String(
+								result.TextWithImageNotes(),
+							),
 						},
 					},
 				})
@@ -350,12 +369,13 @@ func (c *responsesClient) convertMessages(
 }
 
 func (c *responsesClient) convertTools(
+	ctx context.Context,
 	tools []tool.BaseTool,
 ) []responses.ToolUnionParam {
 	out := make(
 		[]responses.ToolUnionParam,
 		0,
-		len(tools)+len(c.options.builtinTools),
+		len(tools)+len(c.options.builtinTools)+1,
 	)
 	for _, t := range tools {
 		info := t.Info()
@@ -375,7 +395,40 @@ func (c *responsesClient) convertTools(
 			},
 		})
 	}
-	return append(out, c.options.builtinTools...)
+	out = append(out, c.options.builtinTools...)
+	if search, ok := llm.WebSearchFromContext(ctx); ok && !c.hasWebSearch() {
+		out = append(out, webSearchTool(search))
+	}
+	return out
+}
+
+// hasWebSearch reports whether the client was configured with
+// [WithWebSearch] or [WithWebSearchPreview].
+func (c *responsesClient) hasWebSearch() bool {
+	for _, t := range c.options.builtinTools {
+		if t.OfWebSearch != nil || t.OfWebSearchPreview != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// webSearchTool builds the web_search tool for a vendor-neutral
+// [llm.WebSearch]. The Responses API has no use cap or blocked domains, so
+// MaxUses and BlockedDomains are ignored.
+func webSearchTool(search llm.WebSearch) responses.ToolUnionParam {
+	opts := WebSearchOpts{AllowedDomains: search.AllowedDomains}
+	if loc := search.Location; loc != nil {
+		opts.UserLocation = &UserLocation{
+			City:     loc.City,
+			Country:  loc.Country,
+			Region:   loc.Region,
+			Timezone: loc.Timezone,
+		}
+	}
+	var o ResponsesOptions
+	WithWebSearch(opts)(&o)
+	return o.builtinTools[0]
 }
 
 func (c *responsesClient) preparedParams(
@@ -391,11 +444,13 @@ func (c *responsesClient) preparedParams(
 	if c.options.maxOutputTokens > 0 {
 		params.MaxOutputTokens = openaisdk.Int(c.options.maxOutputTokens)
 	}
-	if c.options.temperature != nil {
-		params.Temperature = openaisdk.Float(*c.options.temperature)
-	}
-	if c.options.topP != nil {
-		params.TopP = openaisdk.Float(*c.options.topP)
+	if !isReasoningModel(c.options.model) {
+		if c.options.temperature != nil {
+			params.Temperature = openaisdk.Float(*c.options.temperature)
+		}
+		if c.options.topP != nil {
+			params.TopP = openaisdk.Float(*c.options.topP)
+		}
 	}
 	if c.options.model.CanReason && c.options.reasoningEffort != nil {
 		switch *c.options.reasoningEffort {
@@ -407,18 +462,44 @@ func (c *responsesClient) preparedParams(
 			params.Reasoning.Effort = shared.ReasoningEffortHigh
 		}
 	}
+	if c.options.verbosity != nil && llm.SupportsVerbosity(c.options.model) {
+		params.Text.Verbosity = responses.ResponseTextConfigVerbosity(
+			*c.options.verbosity,
+		)
+	}
 	return params
 }
 
+// forRequest returns c, or a copy of c using the reasoning effort and
+// verbosity carried by ctx (see [llm.ContextWithReasoningEffort] and
+// [llm.ContextWithVerbosity]) when either is set.
+func (c *responsesClient) forRequest(ctx context.Context) *responsesClient {
+	effort, hasEffort := llm.ReasoningEffortFromContext(ctx)
+	verbosity, hasVerbosity := llm.VerbosityFromContext(ctx)
+	if !hasEffort && !hasVerbosity {
+		return c
+	}
+	clone := *c
+	if hasEffort {
+		e := ReasoningEffort(effort)
+		clone.options.reasoningEffort = &e
+	}
+	if hasVerbosity {
+		clone.options.verbosity = &verbosity
+	}
+	return &clone
+}
+
 // extractOutput walks a completed Response and returns assistant content,
-// function tool calls, and provider metadata (citations from output_text
-// annotations).
+// function tool calls, the url_citation annotations of the output text as
+// citations, and provider metadata (the same citations in flat form).
 func (c *responsesClient) extractOutput(
 	resp *responses.Response,
-) (string, []message.ToolCall, map[string]any) {
+) (string, []message.ToolCall, []message.Citation, map[string]any) {
 	var content strings.Builder
 	var toolCalls []message.ToolCall
 	var citations []map[string]any
+	var cited []message.Citation
 
 	for _, item := range resp.Output {
 		switch item.Type {
@@ -427,6 +508,7 @@ func (c *responsesClient) extractOutput(
 				if part.Type != "output_text" {
 					continue
 				}
+				offset := content.Len()
 				content.WriteString(part.Text)
 				for _, ann := range part.Annotations {
 					if ann.Type == "url_citation" {
@@ -436,6 +518,14 @@ func (c *responsesClient) extractOutput(
 							"start_index": ann.StartIndex,
 							"end_index":   ann.EndIndex,
 						})
+						cited = append(cited, urlCitation(
+							part.Text,
+							offset,
+							ann.URL,
+							ann.Title,
+							ann.StartIndex,
+							ann.EndIndex,
+						))
 					}
 				}
 			}
@@ -467,7 +557,69 @@ func (c *responsesClient) extractOutput(
 	if len(citations) > 0 {
 		meta = map[string]any{"openai.url_citations": citations}
 	}
-	return content.String(), toolCalls, meta
+	return content.String(), toolCalls, cited, meta
+}
+
+// outputTextCitations returns the url_citation annotations of a completed
+// streamed response. Offsets count only output text, matching the content a
+// stream accumulates from text deltas.
+func outputTextCitations(resp *responses.Response) []message.Citation {
+	var cited []message.Citation
+	offset := 0
+	for _, item := range resp.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type != "output_text" {
+				continue
+			}
+			for _, ann := range part.Annotations {
+				if ann.Type == "url_citation" {
+					cited = append(cited, urlCitation(
+						part.Text,
+						offset,
+						ann.URL,
+						ann.Title,
+						ann.StartIndex,
+						ann.EndIndex,
+					))
+				}
+			}
+			offset += len(part.Text)
+		}
+	}
+	return cited
+}
+
+// urlCitation builds a citation for a url_citation annotation on text, which
+// starts at byte offset within the response content. The API's indices count
+// characters, so they are converted to byte offsets.
+func urlCitation(
+	text string,
+	offset int,
+	url, title string,
+	start, end int64,
+) message.Citation {
+	return message.Citation{
+		Type:  "url_citation",
+		Title: title,
+		URL:   url,
+		Start: offset + byteOffset(text, int(start)),
+		End:   offset + byteOffset(text, int(end)),
+	}
+}
+
+// byteOffset returns the byte index of the n-th character of s, or len(s)
+// when s is shorter.
+func byteOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
 }
 
 func (c *responsesClient) usage(resp *responses.Response) llm.TokenUsage {
@@ -504,9 +656,13 @@ func (c *responsesClient) SendMessages(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
-		c.convertTools(tools),
+		c.convertTools(ctx, tools),
 	)
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
@@ -518,18 +674,19 @@ func (c *responsesClient) SendMessages(
 		func() (*llm.Response, error) {
 			var raw *http.Response
 			resp, err := c.client.Responses.New(
-				ctx, params, option.WithResponseInto(&raw),
+				ctx, params, responsesRequestOptions(ctx, &raw)...,
 			)
 			if err != nil {
 				return nil, wrapError(err)
 			}
-			content, toolCalls, meta := c.extractOutput(resp)
+			content, toolCalls, cited, meta := c.extractOutput(resp)
 			out := &llm.Response{
 				Content:            content,
 				ToolCalls:          toolCalls,
 				Usage:              c.usage(resp),
 				FinishReason:       c.finishReason(resp),
 				ProviderMetadata:   meta,
+				Citations:          cited,
 				ProviderResponseID: resp.ID,
 			}
 			applyResponseHeaders(out, raw)
@@ -545,11 +702,15 @@ func (c *responsesClient) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	c = c.forRequest(ctx)
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
-		c.convertTools(tools),
+		c.convertTools(ctx, tools),
 	)
-	params.Text = c.structuredTextConfig(outputSchema)
+	params.Text.Format = c.structuredTextFormat(outputSchema)
 
 	ctx, cancel := llm.ApplyTimeout(ctx, c.options.timeout)
 	defer cancel()
@@ -560,12 +721,12 @@ func (c *responsesClient) SendMessagesWithStructuredOutput(
 		func() (*llm.Response, error) {
 			var raw *http.Response
 			resp, err := c.client.Responses.New(
-				ctx, params, option.WithResponseInto(&raw),
+				ctx, params, responsesRequestOptions(ctx, &raw)...,
 			)
 			if err != nil {
 				return nil, wrapError(err)
 			}
-			content, toolCalls, meta := c.extractOutput(resp)
+			content, toolCalls, cited, meta := c.extractOutput(resp)
 			out := &llm.Response{
 				Content:                    content,
 				ToolCalls:                  toolCalls,
@@ -574,6 +735,7 @@ func (c *responsesClient) SendMessagesWithStructuredOutput(
 				StructuredOutput:           &content,
 				UsedNativeStructuredOutput: true,
 				ProviderMetadata:           meta,
+				Citations:                  cited,
 				ProviderResponseID:         resp.ID,
 			}
 			applyResponseHeaders(out, raw)
@@ -582,23 +744,14 @@ func (c *responsesClient) SendMessagesWithStructuredOutput(
 	)
 }
 
-func (c *responsesClient) structuredTextConfig(
+func (c *responsesClient) structuredTextFormat(
 	outputSchema *schema.StructuredOutputInfo,
-) responses.ResponseTextConfigParam {
-	schemaMap := map[string]any{
-		"type":       "object",
-		"properties": outputSchema.Parameters,
-	}
-	if len(outputSchema.Required) > 0 {
-		schemaMap["required"] = outputSchema.Required
-	}
-	return responses.ResponseTextConfigParam{
-		Format: responses.ResponseFormatTextConfigUnionParam{
-			OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
-				Name:   "structured_output",
-				Schema: schemaMap,
-				Strict: openaisdk.Bool(true),
-			},
+) responses.ResponseFormatTextConfigUnionParam {
+	return responses.ResponseFormatTextConfigUnionParam{
+		OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   "structured_output",
+			Schema: outputSchema.StrictJSONSchema(),
+			Strict: openaisdk.Bool(true),
 		},
 	}
 }
@@ -609,9 +762,13 @@ func (c *responsesClient) StreamResponse(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
-		c.convertTools(tools),
+		c.convertTools(ctx, tools),
 	)
 	return c.runStream(ctx, params, false)
 }
@@ -623,11 +780,15 @@ func (c *responsesClient) StreamResponseWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	c = c.forRequest(ctx)
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	params := c.preparedParams(
 		c.convertMessages(messages),
-		c.convertTools(tools),
+		c.convertTools(ctx, tools),
 	)
-	params.Text = c.structuredTextConfig(outputSchema)
+	params.Text.Format = c.structuredTextFormat(outputSchema)
 	return c.runStream(ctx, params, true)
 }
 
@@ -646,7 +807,7 @@ func (c *responsesClient) runStream(
 		llm.ExecuteStreamWithRetry(ctx, RetryConfig(), func() error {
 			var raw *http.Response
 			stream := c.client.Responses.NewStreaming(
-				ctx, params, option.WithResponseInto(&raw),
+				ctx, params, responsesRequestOptions(ctx, &raw)...,
 			)
 			var content strings.Builder
 			var citations []map[string]any
@@ -729,12 +890,14 @@ func (c *responsesClient) runStream(
 					if len(citations) > 0 {
 						meta = map[string]any{"openai.url_citations": citations}
 					}
+					cited := outputTextCitations(&event.Response)
 					finalResp := &llm.Response{
 						Content:            contentStr,
 						ToolCalls:          toolCalls,
 						Usage:              c.usage(&event.Response),
 						FinishReason:       c.finishReason(&event.Response),
 						ProviderMetadata:   meta,
+						Citations:          cited,
 						ProviderResponseID: event.Response.ID,
 					}
 					applyResponseHeaders(finalResp, raw)
@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func streamChunk(t *testing.T, content, finishReason string) string {
+	t.Helper()
+	choice := map[string]any{
+		"index": 0,
+		"delta": map[string]any{"content": content},
+	}
+	if finishReason != "" {
+		choice["finish_reason"] = finishReason
+	}
+	raw, err := json.Marshal(map[string]any{
+		"id":      "chunk",
+		"object":  "chat.completion.chunk",
+		"created": 1,
+		"model":   "gpt-4o-mini",
+		"choices": []any{choice},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("data: %s\n\n", raw)
+}
+
+// TestStreamStructuredOutputStrict confirms the streaming structured-output
+// path sends a strict json_schema response_format, that the content deltas
+// concatenate to a document matching the schema, and that the final response
+// carries it as StructuredOutput. WithTimeout is set to guard against the
+// stream context being cancelled when the method returns.
+func TestStreamStructuredOutputStrict(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(raw, &body)
+			w.Header().Set("Content-Type", "text/event-stream")
+			parts := []string{`{"city":`, `"Paris",`, `"temp":21}`}
+			for _, part := range parts {
+				_, _ = io.WriteString(w, streamChunk(t, part, ""))
+				w.(http.Flusher).Flush()
+				time.Sleep(10 * time.Millisecond)
+			}
+			_, _ = io.WriteString(w, streamChunk(t, "", "stop"))
+			_, _ = io.WriteString(w, "data: [DONE]\n\n")
+		}))
+	defer srv.Close()
+
+	client := NewLLM(
+		WithAPIKey("test-key"),
+		WithBaseURL(srv.URL),
+		WithModel(model.Model{APIModel: "gpt-4o-mini"}),
+		WithTimeout(5*time.Second),
+	)
+	output := schema.Object().
+		Field("city", schema.String()).
+		Field("temp", schema.Integer()).
+		Required("city", "temp").
+		Output("weather", "Current weather")
+
+	var streamed strings.Builder
+	var final *string
+	var native bool
+	for evt := range client.StreamResponseWithStructuredOutput(
+		context.Background(),
+		[]message.Message{message.NewUserMessage("weather in Paris?")},
+		nil,
+		output,
+	) {
+		switch evt.Type {
+		case types.EventContentDelta:
+			streamed.WriteString(evt.Content)
+		case types.EventComplete:
+			final = evt.Response.StructuredOutput
+			native = evt.Response.UsedNativeStructuredOutput
+		case types.EventError:
+			t.Fatalf("stream error: %v", evt.Error)
+		}
+	}
+
+	format, _ := body["response_format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Fatalf("response_format = %v, want json_schema", format)
+	}
+	jsonSchema, _ := format["json_schema"].(map[string]any)
+	if jsonSchema["strict"] != true || jsonSchema["name"] != "weather" {
+		t.Errorf("json_schema = %v, want strict weather schema", jsonSchema)
+	}
+	if body["stream"] != true {
+		t.Errorf("stream = %v, want true", body["stream"])
+	}
+
+	var weather struct {
+		City string `json:"city"`
+		Temp int    `json:"temp"`
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(streamed.String())))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&weather); err != nil {
+		t.Fatalf("streamed %q does not parse: %v", streamed.String(), err)
+	}
+	if weather.City != "Paris" || weather.Temp != 21 {
+		t.Errorf("weather = %+v", weather)
+	}
+	if final == nil || *final != streamed.String() || !native {
+		t.Errorf("StructuredOutput = %v, native = %v", final, native)
+	}
+}
@@ -12,6 +12,7 @@ package openrouter
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical OpenRouter API endpoint.
@@ -22,11 +23,16 @@ type Option = llmopenai.Option
 
 // NewLLM constructs an OpenRouter LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override (e.g. to point at a regional endpoint).
+// The endpoint defaults to [DefaultBaseURL]; AI_OPENROUTER_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it (e.g. to point at a regional
+// endpoint). The API key and model fall back to AI_OPENROUTER_API_KEY and
+// AI_OPENROUTER_MODEL when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderOpenRouter),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
 
 // WithProviderRouting sets OpenRouter's provider routing object. order lists
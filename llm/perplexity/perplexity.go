@@ -12,6 +12,7 @@ package perplexity
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical Perplexity API endpoint.
@@ -29,12 +30,15 @@ type Option = llmopenai.Option
 
 // NewLLM constructs a Perplexity LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to override. The client always surfaces the response citations and
-// search_results into [llm.Response].ProviderMetadata.
+// The endpoint defaults to [DefaultBaseURL]; AI_PERPLEXITY_BASE_URL or
+// [llmopenai.WithBaseURL] in opts overrides it. The API key and model fall back
+// to AI_PERPLEXITY_API_KEY and AI_PERPLEXITY_MODEL when not passed as options.
+// The client always surfaces the response citations and search_results into
+// [llm.Response].ProviderMetadata.
 func NewLLM(opts ...Option) llm.LLM {
 	base := []Option{
-		llmopenai.WithBaseURL(DefaultBaseURL),
+		llmopenai.WithEnvProvider(model.ProviderPerplexity),
+		llmopenai.WithDefaultBaseURL(DefaultBaseURL),
 		llmopenai.WithResponseMetadataField("citations", MetadataKeyCitations),
 		llmopenai.WithResponseMetadataField(
 			"search_results", MetadataKeySearchResults),
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// ReasoningEffort is a vendor-neutral reasoning depth for models that think
+// before answering. Vendor packages map it onto their own setting: OpenAI's
+// reasoning_effort, Anthropic's effort, and Gemini's thinking level.
+type ReasoningEffort string
+
+// ReasoningEffort values.
+const (
+	ReasoningEffortLow    ReasoningEffort = "low"
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	ReasoningEffortHigh   ReasoningEffort = "high"
+)
+
+// Validate reports an error for an unknown effort level.
+func (e ReasoningEffort) Validate() error {
+	switch e {
+	case ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh:
+		return nil
+	}
+	return fmt.Errorf("llm: unknown reasoning effort %q", string(e))
+}
+
+// Verbosity is a vendor-neutral hint for how long and detailed the model's
+// answers should be. Only OpenAI's GPT-5 models accept it; other vendors
+// ignore it.
+type Verbosity string
+
+// Verbosity values.
+const (
+	VerbosityLow    Verbosity = "low"
+	VerbosityMedium Verbosity = "medium"
+	VerbosityHigh   Verbosity = "high"
+)
+
+// Validate reports an error for an unknown verbosity level.
+func (v Verbosity) Validate() error {
+	switch v {
+	case VerbosityLow, VerbosityMedium, VerbosityHigh:
+		return nil
+	}
+	return fmt.Errorf("llm: unknown verbosity %q", string(v))
+}
+
+// SupportsVerbosity reports whether m accepts a [Verbosity] setting, which
+// OpenAI's GPT-5 models do, including through Azure.
+func SupportsVerbosity(m model.Model) bool {
+	switch m.Provider {
+	case model.ProviderOpenAI, model.ProviderAzure:
+		return strings.HasPrefix(m.APIModel, "gpt-5")
+	}
+	return false
+}
+
+// SupportsReasoningEffort reports whether m accepts a [ReasoningEffort]. Every
+// model that can reason does, except Gemini 2.x, which takes a thinking
+// budget instead of a thinking level.
+func SupportsReasoningEffort(m model.Model) bool {
+	if !m.CanReason {
+		return false
+	}
+	switch m.Provider {
+	case model.ProviderGemini, model.ProviderVertexAI:
+		return !strings.HasPrefix(m.APIModel, "gemini-2")
+	}
+	return true
+}
+
+type (
+	reasoningEffortKey struct{}
+	verbosityKey       struct{}
+)
+
+// ContextWithReasoningEffort returns a copy of ctx whose requests use effort,
+// overriding the reasoning effort the client was configured with. Clients of
+// models that do not support it (see [SupportsReasoningEffort]) ignore it.
+func ContextWithReasoningEffort(
+	ctx context.Context,
+	effort ReasoningEffort,
+) context.Context {
+	return context.WithValue(ctx, reasoningEffortKey{}, effort)
+}
+
+// ReasoningEffortFromContext returns the reasoning effort carried by ctx, if
+// any.
+func ReasoningEffortFromContext(ctx context.Context) (ReasoningEffort, bool) {
+	effort, ok := ctx.Value(reasoningEffortKey{}).(ReasoningEffort)
+	return effort, ok
+}
+
+// ContextWithVerbosity returns a copy of ctx whose requests use verbosity,
+// overriding the verbosity the client was configured with.
+func ContextWithVerbosity(
+	ctx context.Context,
+	verbosity Verbosity,
+) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, verbosity)
+}
+
+// VerbosityFromContext returns the verbosity carried by ctx, if any.
+func VerbosityFromContext(ctx context.Context) (Verbosity, bool) {
+	verbosity, ok := ctx.Value(verbosityKey{}).(Verbosity)
+	return verbosity, ok
+}
@@ -0,0 +1,413 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoRecording is returned in replay mode when a request has no matching
+// interaction in the cassette.
+var ErrNoRecording = errors.New("no recorded interaction for request")
+
+// RecordMode selects how a [Recorder] treats outgoing requests.
+type RecordMode int
+
+const (
+	// RecordModeAuto replays requests found in the cassette and records the
+	// rest. This is the default.
+	RecordModeAuto RecordMode = iota
+	// RecordModeRecord sends every request to the provider and overwrites the
+	// cassette with the new interactions.
+	RecordModeRecord
+	// RecordModeReplay serves requests only from the cassette and fails with
+	// [ErrNoRecording] on a miss. Use it in CI.
+	RecordModeReplay
+)
+
+const scrubbedValue = "[REDACTED]"
+
+var defaultScrubHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+var defaultScrubQuery = []string{"key", "api_key", "access_token"}
+
+type recorderOptions struct {
+	mode         RecordMode
+	transport    http.RoundTripper
+	scrubHeaders []string
+	scrubQuery   []string
+}
+
+// RecorderOption configures a [Recorder].
+type RecorderOption func(*recorderOptions)
+
+// WithRecordMode sets the recorder mode. Defaults to [RecordModeAuto].
+func WithRecordMode(mode RecordMode) RecorderOption {
+	return func(o *recorderOptions) { o.mode = mode }
+}
+
+// WithRecorderTransport sets the transport used to reach the provider when
+// recording. Defaults to http.DefaultTransport.
+func WithRecorderTransport(rt http.RoundTripper) RecorderOption {
+	return func(o *recorderOptions) { o.transport = rt }
+}
+
+// WithScrubHeaders adds header names whose values are redacted before an
+// interaction is written to disk. Authorization and the common API key
+// headers are always scrubbed.
+func WithScrubHeaders(names ...string) RecorderOption {
+	return func(o *recorderOptions) {
+		o.scrubHeaders = append(o.scrubHeaders, names...)
+	}
+}
+
+// WithScrubQueryParams adds query parameter names whose values are redacted
+// before an interaction is hashed or written to disk. The key, api_key, and
+// access_token parameters are always scrubbed.
+func WithScrubQueryParams(names ...string) RecorderOption {
+	return func(o *recorderOptions) {
+		o.scrubQuery = append(o.scrubQuery, names...)
+	}
+}
+
+// Recorder is an [http.RoundTripper] that records provider HTTP traffic to a
+// cassette file and replays it deterministically, so integration tests can
+// exercise real provider responses without live API keys.
+//
+// Requests are matched on a hash of the method, URL, and body with secrets
+// scrubbed; credentials never reach the cassette. Streaming responses are
+// captured as they are read, so SSE streams replay event for event. The
+// cassette is written once a recorded body has been read to the end or
+// closed; if writing fails, that read or Close returns the error, so the
+// provider call fails instead of leaving a stale cassette behind.
+//
+// Pass the recorder's client to a vendor's WithHTTPClient option:
+//
+//	rec, err := llm.NewRecorder("testdata/chat.json",
+//		llm.WithRecordMode(llm.RecordModeReplay),
+//	)
+//	client := anthropic.NewLLM(
+//		anthropic.WithAPIKey(os.Getenv("ANTHROPIC_API_KEY")),
+//		anthropic.WithHTTPClient(rec.Client()),
+//	)
+type Recorder struct {
+	path    string
+	options recorderOptions
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int
+}
+
+// Interaction is a recorded request/response pair as stored in a cassette.
+type Interaction struct {
+	Hash     string           `json:"hash"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed request half of an [Interaction].
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response half of an [Interaction]. Body holds the
+// raw bytes as text, or base64 when BodyEncoding is "base64".
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewRecorder opens the cassette at path. In auto and replay modes existing
+// interactions are loaded; a missing file is an error only in replay mode.
+// In record mode the cassette starts empty and is overwritten.
+func NewRecorder(path string, opts ...RecorderOption) (*Recorder, error) {
+	options := recorderOptions{
+		transport:    http.DefaultTransport,
+		scrubHeaders: append([]string(nil), defaultScrubHeaders...),
+		scrubQuery:   append([]string(nil), defaultScrubQuery...),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	r := &Recorder{
+		path:     path,
+		options:  options,
+		replayed: make(map[string]int),
+	}
+	if options.mode == RecordModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) &&
+			options.mode != RecordModeReplay {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	r.interactions = c.Interactions
+	return r, nil
+}
+
+// Client returns an *http.Client that routes through the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns a copy of the interactions currently in the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	scrubbedURL := r.scrubURL(req.URL)
+	hash := requestHash(req.Method, scrubbedURL, body)
+
+	if r.options.mode != RecordModeRecord {
+		if resp, ok := r.replay(req, hash); ok {
+			return resp, nil
+		}
+		if r.options.mode == RecordModeReplay {
+			return nil, fmt.Errorf(
+				"%w: %s %s",
+				ErrNoRecording,
+				req.Method,
+				scrubbedURL,
+			)
+		}
+	}
+
+	resp, err := r.options.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Hash: hash,
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     scrubbedURL,
+			Headers: r.scrubHeaders(req.Header),
+			Body:    string(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    r.scrubHeaders(resp.Header),
+		},
+	}
+	resp.Body = &recordingBody{
+		inner: resp.Body,
+		done: func(data []byte) error {
+			body, encoding := encodeBody(data)
+			interaction.Response.Body = body
+			interaction.Response.BodyEncoding = encoding
+			return r.append(interaction)
+		},
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(
+	req *http.Request,
+	hash string,
+) (*http.Response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []Interaction
+	for _, in := range r.interactions {
+		if in.Hash == hash {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	idx := r.replayed[hash]
+	if idx >= len(matches) {
+		idx = len(matches) - 1
+	}
+	r.replayed[hash]++
+
+	recorded := matches[idx].Response
+	body, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return nil, false
+	}
+
+	status := fmt.Sprintf(
+		"%d %s",
+		recorded.StatusCode,
+		http.StatusText(recorded.StatusCode),
+	)
+	return &http.Response{
+		Status:        status,
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, true
+}
+
+func (r *Recorder) append(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+	return r.save()
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(
+		cassette{Interactions: r.interactions},
+		"",
+		"  ",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) scrubURL(u *url.URL) string {
+	scrubbed := *u
+	query := scrubbed.Query()
+	for _, name := range r.options.scrubQuery {
+		if query.Has(name) {
+			query.Set(name, scrubbedValue)
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+	scrubbed.User = nil
+	return scrubbed.String()
+}
+
+func (r *Recorder) scrubHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	scrubbed := h.Clone()
+	for name := range scrubbed {
+		for _, secret := range r.options.scrubHeaders {
+			if strings.EqualFold(name, secret) {
+				scrubbed[name] = []string{scrubbedValue}
+			}
+		}
+	}
+	return scrubbed
+}
+
+func requestHash(method, rawURL string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(rawURL))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeBody(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
+
+type recordingBody struct {
+	inner   io.ReadCloser
+	buf     bytes.Buffer
+	done    func([]byte) error
+	once    sync.Once
+	saveErr error
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.inner.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if saveErr := b.finish(); saveErr != nil {
+			return n, saveErr
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	return errors.Join(b.finish(), b.inner.Close())
+}
+
+func (b *recordingBody) finish() error {
+	b.once.Do(func() { b.saveErr = b.done(b.buf.Bytes()) })
+	return b.saveErr
+}
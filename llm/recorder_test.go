@@ -0,0 +1,205 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newCountingServer(
+	t *testing.T,
+	body string,
+) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			if strings.HasSuffix(r.URL.Path, "/stream") {
+				w.Header().Set("Content-Type", "text/event-stream")
+				flusher := w.(http.Flusher)
+				for _, chunk := range strings.SplitAfter(body, "\n\n") {
+					_, _ = io.WriteString(w, chunk)
+					flusher.Flush()
+				}
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, body)
+		},
+	))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func doPost(
+	t *testing.T,
+	client *http.Client,
+	url, body string,
+) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return resp, string(data)
+}
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	srv, hits := newCountingServer(t, `{"content":"hello"}`)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := NewRecorder(path, WithRecordMode(RecordModeRecord))
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	_, got := doPost(t, rec.Client(), srv.URL+"/v1/chat?key=abc", `{"q":1}`)
+	if got != `{"content":"hello"}` {
+		t.Fatalf("unexpected recorded body: %q", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cassette not written: %v", err)
+	}
+	if strings.Contains(string(data), "sk-secret") ||
+		strings.Contains(string(data), "key=abc") {
+		t.Errorf("cassette leaked a secret:\n%s", data)
+	}
+
+	replay, err := NewRecorder(path, WithRecordMode(RecordModeReplay))
+	if err != nil {
+		t.Fatalf("NewRecorder replay: %v", err)
+	}
+	resp, got := doPost(
+		t,
+		replay.Client(),
+		srv.URL+"/v1/chat?key=other",
+		`{"q":1}`,
+	)
+	if got != `{"content":"hello"}` {
+		t.Errorf("unexpected replayed body: %q", got)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected replayed headers, got %v", resp.Header)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected replay to skip the server, got %d hits", hits.Load())
+	}
+
+	req, _ := http.NewRequest(
+		http.MethodPost,
+		srv.URL+"/v1/chat",
+		strings.NewReader(`{"q":2}`),
+	)
+	if _, err := replay.Client().Do(req); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for unmatched request, got %v", err)
+	}
+}
+
+func TestRecorder_AutoModeRecordsMissesOnly(t *testing.T) {
+	srv, hits := newCountingServer(t, `{"ok":true}`)
+	path := filepath.Join(t.TempDir(), "nested", "cassette.json")
+
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	doPost(t, rec.Client(), srv.URL+"/a", `{}`)
+	doPost(t, rec.Client(), srv.URL+"/a", `{}`)
+	doPost(t, rec.Client(), srv.URL+"/b", `{}`)
+
+	if hits.Load() != 2 {
+		t.Errorf("expected 2 server hits, got %d", hits.Load())
+	}
+	if n := len(rec.Interactions()); n != 2 {
+		t.Errorf("expected 2 interactions, got %d", n)
+	}
+}
+
+func TestRecorder_ReplaysStreamingResponses(t *testing.T) {
+	stream := "data: {\"delta\":\"hel\"}\n\n" +
+		"data: {\"delta\":\"lo\"}\n\n" +
+		"data: [DONE]\n\n"
+	srv, _ := newCountingServer(t, stream)
+	path := filepath.Join(t.TempDir(), "stream.json")
+
+	rec, err := NewRecorder(path, WithRecordMode(RecordModeRecord))
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	doPost(t, rec.Client(), srv.URL+"/stream", `{"stream":true}`)
+	srv.Close()
+
+	replay, err := NewRecorder(path, WithRecordMode(RecordModeReplay))
+	if err != nil {
+		t.Fatalf("NewRecorder replay: %v", err)
+	}
+	resp, got := doPost(
+		t,
+		replay.Client(),
+		srv.URL+"/stream",
+		`{"stream":true}`,
+	)
+	if got != stream {
+		t.Errorf("expected replayed stream %q, got %q", stream, got)
+	}
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected event-stream content type, got %q",
+			resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestRecorder_ReplayRequiresCassette(t *testing.T) {
+	_, err := NewRecorder(
+		filepath.Join(t.TempDir(), "missing.json"),
+		WithRecordMode(RecordModeReplay),
+	)
+	if err == nil {
+		t.Fatal("expected error for missing cassette in replay mode")
+	}
+}
+
+func TestRecorder_SaveFailureSurfaces(t *testing.T) {
+	srv, _ := newCountingServer(t, `{"content":"hello"}`)
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := NewRecorder(
+		filepath.Join(blocker, "cassette.json"),
+		WithRecordMode(RecordModeRecord),
+	)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	resp, err := rec.Client().Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	if err == nil || !strings.Contains(err.Error(), "cassette") {
+		t.Errorf("read err = %v, want cassette write failure", err)
+	}
+	if err := resp.Body.Close(); err == nil {
+		t.Error("Close did not report the cassette write failure")
+	}
+}
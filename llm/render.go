@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type renderOptions struct {
+	reasoning io.Writer
+	toolCalls bool
+	markdown  bool
+}
+
+// RenderOption configures [RenderStream].
+type RenderOption func(*renderOptions)
+
+// RenderReasoning writes thinking deltas to w, which may be the same writer
+// as the content or a separate one such as os.Stderr. Reasoning is not
+// written by default.
+func RenderReasoning(w io.Writer) RenderOption {
+	return func(o *renderOptions) { o.reasoning = w }
+}
+
+// RenderToolCalls writes a line naming each tool the model calls.
+func RenderToolCalls() RenderOption {
+	return func(o *renderOptions) { o.toolCalls = true }
+}
+
+// RenderMarkdown renders the content as terminal markdown through a
+// [types.MarkdownWriter], which writes one completed line at a time.
+func RenderMarkdown() RenderOption {
+	return func(o *renderOptions) { o.markdown = true }
+}
+
+// RenderStream writes the content deltas of stream to w as they arrive and
+// returns the final response, replacing the event loop CLI programs would
+// otherwise write by hand. A newline is written after the content if it did
+// not end with one.
+//
+// Errors are returned as by [CollectStream]: the first error event or write
+// failure ends rendering, so cancel the stream's context in that case, and a
+// stream that closes without completing returns [ErrStreamIncomplete].
+func RenderStream(
+	w io.Writer,
+	stream <-chan Event,
+	opts ...RenderOption,
+) (*Response, error) {
+	var o renderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	out := w
+	var md *types.MarkdownWriter
+	if o.markdown {
+		md = types.NewMarkdownWriter(w)
+		out = md
+	}
+
+	c := newStreamCollector()
+	var last string
+	for evt := range stream {
+		if err := c.add(evt); err != nil {
+			return nil, err
+		}
+		var err error
+		switch evt.Type {
+		case types.EventContentDelta:
+			if evt.Content != "" {
+				_, err = io.WriteString(out, evt.Content)
+				last = evt.Content
+			}
+		case types.EventThinkingDelta:
+			if o.reasoning != nil {
+				_, err = io.WriteString(o.reasoning, evt.Thinking)
+			}
+		case types.EventToolUseStart:
+			if o.toolCalls && evt.ToolCall != nil {
+				sep := ""
+				if !endsLine(last) {
+					sep = "\n"
+				}
+				_, err = fmt.Fprintf(
+					out,
+					"%s[tool: %s]\n",
+					sep,
+					evt.ToolCall.Name,
+				)
+				last = "\n"
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("llm: render stream: %w", err)
+		}
+	}
+
+	if !endsLine(last) {
+		if _, err := io.WriteString(out, "\n"); err != nil {
+			return nil, fmt.Errorf("llm: render stream: %w", err)
+		}
+	}
+	if md != nil {
+		if err := md.Flush(); err != nil {
+			return nil, fmt.Errorf("llm: render stream: %w", err)
+		}
+	}
+	return c.response()
+}
+
+func endsLine(s string) bool {
+	return s == "" || s[len(s)-1] == '\n'
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func TestRenderStreamWritesContent(t *testing.T) {
+	var out, thinking strings.Builder
+	resp, err := RenderStream(&out, eventStream(
+		Event{Type: types.EventThinkingDelta, Thinking: "hmm"},
+		Event{Type: types.EventContentDelta, Content: "Hel"},
+		Event{
+			Type:     types.EventToolUseStart,
+			ToolCall: &message.ToolCall{ID: "c1", Name: "search"},
+		},
+		Event{Type: types.EventContentDelta, Content: "lo"},
+		Event{Type: types.EventComplete, Response: &Response{}},
+	), RenderReasoning(&thinking), RenderToolCalls())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "Hel\n[tool: search]\nlo\n" {
+		t.Errorf("output = %q", got)
+	}
+	if thinking.String() != "hmm" {
+		t.Errorf("reasoning = %q", thinking.String())
+	}
+	if resp.Content != "Hello" || len(resp.ToolCalls) != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestRenderStreamReturnsError(t *testing.T) {
+	var out strings.Builder
+	boom := errors.New("boom")
+	_, err := RenderStream(&out, eventStream(
+		Event{Type: types.EventContentDelta, Content: "partial"},
+		Event{Type: types.EventError, Error: boom},
+	))
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if out.String() != "partial" {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestRenderStreamMarkdown(t *testing.T) {
+	var out strings.Builder
+	_, err := RenderStream(&out, eventStream(
+		Event{Type: types.EventContentDelta, Content: "# Title\n- a **b"},
+		Event{Type: types.EventContentDelta, Content: "** `c`\n```go\n"},
+		Event{Type: types.EventContentDelta, Content: "x := 1\n```\ndone"},
+		Event{Type: types.EventComplete, Response: &Response{}},
+	), RenderMarkdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[1mTitle\x1b[0m\n" +
+		"• a \x1b[1mb\x1b[0m \x1b[36mc\x1b[0m\n" +
+		"\x1b[2mx := 1\x1b[0m\n" +
+		"done\n"
+	if out.String() != want {
+		t.Errorf("output = %q\nwant     %q", out.String(), want)
+	}
+}
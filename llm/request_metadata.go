@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"maps"
+)
+
+type requestUserKey struct{}
+
+type requestMetadataKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the ID of the end user a
+// request is made for. Vendor packages that support it send the ID in place
+// of their WithUser option, as OpenAI's user field or Anthropic's
+// metadata.user_id, so providers can attribute abuse to individual users of a
+// multi-tenant application. Use an opaque ID such as a hash, never an email
+// address or name.
+func ContextWithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, requestUserKey{}, userID)
+}
+
+// UserFromContext returns the end-user ID carried by ctx, if any.
+func UserFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestUserKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ContextWithMetadata returns a copy of ctx carrying request metadata, merged
+// over any metadata ctx already carries. Vendor packages that support request
+// metadata merge it over their WithMetadata option for requests made with the
+// returned context.
+func ContextWithMetadata(
+	ctx context.Context,
+	metadata map[string]string,
+) context.Context {
+	merged := maps.Clone(MetadataFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(metadata))
+	}
+	maps.Copy(merged, metadata)
+	return context.WithValue(ctx, requestMetadataKey{}, merged)
+}
+
+// MetadataFromContext returns the request metadata carried by ctx, or nil.
+// The map must not be modified.
+func MetadataFromContext(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(requestMetadataKey{}).(map[string]string)
+	return md
+}
+
+// MergeMetadata returns base with the metadata carried by ctx merged over it.
+// base is not modified; it is returned as is when ctx carries none.
+func MergeMetadata(
+	ctx context.Context,
+	base map[string]string,
+) map[string]string {
+	md := MetadataFromContext(ctx)
+	if len(md) == 0 {
+		return base
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]string, len(md))
+	}
+	maps.Copy(merged, md)
+	return merged
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextWithMetadataMerges(t *testing.T) {
+	ctx := ContextWithMetadata(context.Background(), map[string]string{
+		"tenant": "a",
+		"env":    "prod",
+	})
+	ctx = ContextWithMetadata(ctx, map[string]string{"tenant": "b"})
+
+	base := map[string]string{"app": "chat", "env": "dev"}
+	got := MergeMetadata(ctx, base)
+	want := map[string]string{"app": "chat", "env": "prod", "tenant": "b"}
+	if len(got) != len(want) {
+		t.Fatalf("merged = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("merged[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+	if base["env"] != "dev" || len(base) != 2 {
+		t.Errorf("base modified: %v", base)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	if _, ok := UserFromContext(context.Background()); ok {
+		t.Error("user found in empty context")
+	}
+	ctx := ContextWithUser(context.Background(), "u1")
+	if id, ok := UserFromContext(ctx); !ok || id != "u1" {
+		t.Errorf("user = %q, %v", id, ok)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"time"
 
 	"github.com/joakimcarlsson/ai/types"
@@ -166,7 +165,7 @@ func ExecuteWithRetry[T any](
 			return result, err
 		}
 
-		slog.Warn("Retrying operation due to error",
+		retryLogger(ctx).Warn("Retrying operation due to error",
 			"attempt", attempts,
 			"max_retries", config.MaxRetries,
 			"retry_after_ms", retryAfterMs,
@@ -219,7 +218,7 @@ func ExecuteStreamWithRetry(
 			return
 		}
 
-		slog.Warn("Retrying stream operation due to error",
+		retryLogger(ctx).Warn("Retrying stream operation due to error",
 			"attempt", attempts,
 			"max_retries", config.MaxRetries,
 			"retry_after_ms", retryAfterMs,
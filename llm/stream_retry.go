@@ -0,0 +1,303 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrStreamDropped is reported when a stream's event channel closes without a
+// complete or error event.
+var ErrStreamDropped = errors.New("llm: stream ended before completion")
+
+const (
+	defaultStreamRestarts     = 2
+	defaultStreamRestartDelay = time.Second
+)
+
+type streamRetryOptions struct {
+	maxRestarts int
+	delay       time.Duration
+}
+
+// StreamRetryOption configures a [WithStreamRetry] client.
+type StreamRetryOption func(*streamRetryOptions)
+
+// WithMaxStreamRestarts sets how many times one stream may be restarted.
+// Defaults to 2.
+func WithMaxStreamRestarts(n int) StreamRetryOption {
+	return func(o *streamRetryOptions) { o.maxRestarts = n }
+}
+
+// WithStreamRestartDelay sets how long to wait before restarting a dropped
+// stream. Defaults to one second.
+func WithStreamRestartDelay(d time.Duration) StreamRetryOption {
+	return func(o *streamRetryOptions) { o.delay = d }
+}
+
+// WithStreamRetry returns an LLM that restarts a stream which drops midway.
+// A drop is a transient error event (a 429 or 5xx [RetryableError], a
+// per-request timeout, an unexpected EOF, or a network error) or the channel
+// closing without a complete event, while ctx is still live.
+//
+// Providers cannot resume a generation, so a restart sends the same request
+// again from scratch. Content and thinking already forwarded are not sent a
+// second time: the restarted stream's deltas are skipped until they pass the
+// text already emitted, and only the remainder is forwarded. The regenerated
+// text may differ from the first attempt, so the deltas can join unevenly;
+// the [Response] in the complete event holds the final attempt's full output
+// and is the one to store. Each restart is announced with a
+// [types.EventWarning] event carrying the drop error, logged, and recorded as
+// a "stream_restart" span event.
+//
+// A stream that has already forwarded a tool use event is not restarted,
+// since a partial tool call cannot be reconciled with a regenerated one, and
+// its error is passed through. Non-streaming calls go straight to inner.
+func WithStreamRetry(inner LLM, opts ...StreamRetryOption) LLM {
+	options := streamRetryOptions{
+		maxRestarts: defaultStreamRestarts,
+		delay:       defaultStreamRestartDelay,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &streamRetryLLM{inner: inner, options: options}
+}
+
+type streamRetryLLM struct {
+	inner   LLM
+	options streamRetryOptions
+}
+
+func (s *streamRetryLLM) Model() model.Model {
+	return s.inner.Model()
+}
+
+func (s *streamRetryLLM) Unwrap() LLM {
+	return s.inner
+}
+
+func (s *streamRetryLLM) SupportsStructuredOutput() bool {
+	return s.inner.SupportsStructuredOutput()
+}
+
+func (s *streamRetryLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	return s.inner.SendMessages(ctx, messages, tools)
+}
+
+func (s *streamRetryLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return s.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+}
+
+func (s *streamRetryLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return s.stream(ctx, func() <-chan Event {
+		return s.inner.StreamResponse(ctx, messages, tools)
+	})
+}
+
+func (s *streamRetryLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return s.stream(ctx, func() <-chan Event {
+		return s.inner.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		)
+	})
+}
+
+// streamProgress tracks what has been forwarded across attempts, and what
+// the current attempt has produced, so a restarted stream can skip it.
+type streamProgress struct {
+	sent      map[types.EventType]int
+	seen      map[types.EventType]int
+	usedTools bool
+}
+
+func newStreamProgress() *streamProgress {
+	return &streamProgress{
+		sent: make(map[types.EventType]int),
+		seen: make(map[types.EventType]int),
+	}
+}
+
+func (s *streamRetryLLM) stream(
+	ctx context.Context,
+	open func() <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		send := func(evt Event) bool {
+			select {
+			case outCh <- evt:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		p := newStreamProgress()
+		for restarts := 0; ; restarts++ {
+			clear(p.seen)
+			innerCh := open()
+			dropErr := ErrStreamDropped
+			done := false
+			for evt := range innerCh {
+				if evt.Type == types.EventError {
+					dropErr = evt.Error
+					drainEvents(innerCh)
+					break
+				}
+				if evt.Type == types.EventComplete {
+					done = true
+				}
+				if !s.forward(p, evt, send) {
+					drainEvents(innerCh)
+					return
+				}
+			}
+			if done {
+				return
+			}
+			if p.usedTools || restarts >= s.options.maxRestarts ||
+				!isStreamDrop(ctx, dropErr) {
+				send(Event{Type: types.EventError, Error: dropErr})
+				return
+			}
+			if !s.restart(ctx, restarts+1, dropErr, send) {
+				return
+			}
+		}
+	}()
+	return outCh
+}
+
+func (s *streamRetryLLM) forward(
+	p *streamProgress,
+	evt Event,
+	send func(Event) bool,
+) bool {
+	switch evt.Type {
+	case types.EventContentDelta:
+		evt.Content = p.advance(evt.Type, evt.Content)
+		if evt.Content == "" {
+			return true
+		}
+	case types.EventThinkingDelta:
+		evt.Thinking = p.advance(evt.Type, evt.Thinking)
+		if evt.Thinking == "" {
+			return true
+		}
+	case types.EventContentStart, types.EventContentStop:
+		p.seen[evt.Type]++
+		if p.seen[evt.Type] <= p.sent[evt.Type] {
+			return true
+		}
+		p.sent[evt.Type]++
+	case types.EventToolUseStart,
+		types.EventToolUseDelta,
+		types.EventToolUseStop:
+		p.usedTools = true
+	}
+	return send(evt)
+}
+
+// advance counts delta towards the current attempt's text of kind t and
+// returns the part of it that has not been forwarded yet. The cut is moved
+// forward to a rune boundary, since a regenerated text can place multi-byte
+// characters differently from the one already forwarded.
+func (p *streamProgress) advance(t types.EventType, delta string) string {
+	seen, sent := p.seen[t], p.sent[t]
+	p.seen[t] += len(delta)
+	if seen+len(delta) <= sent {
+		return ""
+	}
+	if seen < sent {
+		cut := sent - seen
+		for cut < len(delta) && !utf8.RuneStart(delta[cut]) {
+			cut++
+		}
+		delta = delta[cut:]
+	}
+	p.sent[t] += len(delta)
+	return delta
+}
+
+func (s *streamRetryLLM) restart(
+	ctx context.Context,
+	attempt int,
+	err error,
+	send func(Event) bool,
+) bool {
+	retryLogger(ctx).Warn("Restarting dropped stream",
+		"attempt", attempt,
+		"max_restarts", s.options.maxRestarts,
+		"error", err.Error())
+
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("stream_restart", trace.WithAttributes(
+		attribute.Int("attempt", attempt),
+		attribute.String("error", err.Error()),
+	))
+
+	if !send(Event{Type: types.EventWarning, Error: err}) {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		send(Event{Type: types.EventError, Error: ctx.Err()})
+		return false
+	case <-time.After(s.options.delay):
+		return true
+	}
+}
+
+func isStreamDrop(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrStreamDropped) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return isFallbackError(ctx, err)
+}
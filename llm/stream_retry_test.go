@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// attemptsLLM streams attempts[i] on its i-th StreamResponse call.
+type attemptsLLM struct {
+	attempts [][]Event
+	calls    int
+}
+
+func (a *attemptsLLM) SendMessages(
+	context.Context, []message.Message, []tool.BaseTool,
+) (*Response, error) {
+	return &Response{}, nil
+}
+
+func (a *attemptsLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	_ *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return a.SendMessages(ctx, messages, tools)
+}
+
+func (a *attemptsLLM) StreamResponse(
+	context.Context, []message.Message, []tool.BaseTool,
+) <-chan Event {
+	events := a.attempts[a.calls]
+	a.calls++
+	ch := make(chan Event, len(events))
+	for _, evt := range events {
+		ch <- evt
+	}
+	close(ch)
+	return ch
+}
+
+func (a *attemptsLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	_ *schema.StructuredOutputInfo,
+) <-chan Event {
+	return a.StreamResponse(ctx, messages, tools)
+}
+
+func (a *attemptsLLM) Model() model.Model             { return model.Model{} }
+func (a *attemptsLLM) SupportsStructuredOutput() bool { return true }
+
+func delta(text string) Event {
+	return Event{Type: types.EventContentDelta, Content: text}
+}
+
+func complete(text string) Event {
+	return Event{Type: types.EventComplete, Response: &Response{Content: text}}
+}
+
+func collectStream(ch <-chan Event) (string, []Event) {
+	var b strings.Builder
+	var events []Event
+	for evt := range ch {
+		events = append(events, evt)
+		if evt.Type == types.EventContentDelta {
+			b.WriteString(evt.Content)
+		}
+	}
+	return b.String(), events
+}
+
+func countEvents(events []Event, t types.EventType) int {
+	n := 0
+	for _, evt := range events {
+		if evt.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWithStreamRetry_RestartsWithoutDuplicating(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{
+		{
+			{Type: types.EventContentStart},
+			delta("Hello, "),
+			delta("wor"),
+			{Type: types.EventError, Error: io.ErrUnexpectedEOF},
+		},
+		{
+			{Type: types.EventContentStart},
+			delta("Hello"),
+			delta(", world"),
+			delta("!"),
+			{Type: types.EventContentStop},
+			complete("Hello, world!"),
+		},
+	}}
+
+	client := WithStreamRetry(inner, WithStreamRestartDelay(0))
+	text, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+
+	if text != "Hello, world!" {
+		t.Errorf("streamed %q, want %q", text, "Hello, world!")
+	}
+	if inner.calls != 2 {
+		t.Errorf("calls = %d, want 2", inner.calls)
+	}
+	if n := countEvents(events, types.EventContentStart); n != 1 {
+		t.Errorf("content_start forwarded %d times, want 1", n)
+	}
+	if n := countEvents(events, types.EventWarning); n != 1 {
+		t.Errorf("warnings = %d, want 1", n)
+	}
+	if n := countEvents(events, types.EventError); n != 0 {
+		t.Errorf("errors = %d, want 0", n)
+	}
+	last := events[len(events)-1]
+	if last.Type != types.EventComplete ||
+		last.Response.Content != "Hello, world!" {
+		t.Errorf("last event = %+v", last)
+	}
+}
+
+func TestWithStreamRetry_RestartsWhenChannelClosesEarly(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{
+		{delta("partial")},
+		{delta("partial answer"), complete("partial answer")},
+	}}
+
+	client := WithStreamRetry(inner, WithStreamRestartDelay(0))
+	text, _ := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+	if text != "partial answer" {
+		t.Errorf("streamed %q", text)
+	}
+}
+
+func TestWithStreamRetry_GivesUpAfterMaxRestarts(t *testing.T) {
+	drop := []Event{delta("x"), {Type: types.EventError, Error: overloaded()}}
+	inner := &attemptsLLM{attempts: [][]Event{drop, drop, drop}}
+
+	client := WithStreamRetry(inner,
+		WithMaxStreamRestarts(1),
+		WithStreamRestartDelay(time.Millisecond),
+	)
+	text, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+	if inner.calls != 2 {
+		t.Errorf("calls = %d, want 2", inner.calls)
+	}
+	if text != "x" {
+		t.Errorf("streamed %q, want %q", text, "x")
+	}
+	last := events[len(events)-1]
+	var retryable RetryableError
+	if last.Type != types.EventError || !errors.As(last.Error, &retryable) {
+		t.Errorf("last event = %+v, want the drop error", last)
+	}
+}
+
+func TestWithStreamRetry_PassesThroughPermanentErrors(t *testing.T) {
+	badRequest := errors.New("invalid request")
+	inner := &attemptsLLM{attempts: [][]Event{
+		{delta("a"), {Type: types.EventError, Error: badRequest}},
+	}}
+
+	client := WithStreamRetry(inner, WithStreamRestartDelay(0))
+	_, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+	last := events[len(events)-1]
+	if !errors.Is(last.Error, badRequest) {
+		t.Errorf("last event = %+v, want the request error", last)
+	}
+}
+
+func TestWithStreamRetry_DoesNotRestartAfterToolUse(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{
+		{
+			{
+				Type:     types.EventToolUseStart,
+				ToolCall: &message.ToolCall{ID: "1", Name: "search"},
+			},
+			{Type: types.EventError, Error: io.ErrUnexpectedEOF},
+		},
+		{complete("unused")},
+	}}
+
+	client := WithStreamRetry(inner, WithStreamRestartDelay(0))
+	_, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+	if last := events[len(events)-1]; last.Type != types.EventError {
+		t.Errorf("last event = %+v, want an error", last)
+	}
+}
+
+func TestWithStreamRetry_CutsOnRuneBoundary(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{
+		{
+			delta("abc"),
+			{Type: types.EventError, Error: io.ErrUnexpectedEOF},
+		},
+		{
+			delta("\u00e9\u00e9!"),
+			complete("\u00e9\u00e9!"),
+		},
+	}}
+
+	client := WithStreamRetry(inner, WithStreamRestartDelay(0))
+	text, _ := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+
+	if !utf8.ValidString(text) || text != "abc!" {
+		t.Errorf("streamed %q, want %q", text, "abc!")
+	}
+}
+
+func TestWithStreamRetry_Unwrap(t *testing.T) {
+	client := WithStreamRetry(&uploadingLLM{})
+	if _, ok := AsFileUploader(client); !ok {
+		t.Error("uploader not found behind WithStreamRetry")
+	}
+}
@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// ThrottleStream re-times the EventContentDelta events of a StreamResponse
+// channel to a steady charsPerSec for a typewriter effect. Other events are
+// forwarded in order once the text before them has been flushed. Cancelling
+// ctx closes the returned channel. See [types.ThrottleStream].
+func ThrottleStream(
+	ctx context.Context,
+	in <-chan Event,
+	charsPerSec int,
+) <-chan Event {
+	return types.ThrottleStream(ctx, in, charsPerSec,
+		func(evt Event) (string, bool) {
+			return evt.Content, evt.Type == types.EventContentDelta
+		},
+		func(evt Event, content string) Event {
+			evt.Content = content
+			return evt
+		},
+	)
+}
@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// IDGenerator is a function that generates unique tool call IDs.
+type IDGenerator func() string
+
+// SequentialIDs returns an [IDGenerator] producing prefix1, prefix2, ... It is
+// safe for concurrent use and intended for golden-file tests that need stable
+// tool call IDs.
+func SequentialIDs(prefix string) IDGenerator {
+	var n atomic.Int64
+	return func() string {
+		return fmt.Sprintf("%s%d", prefix, n.Add(1))
+	}
+}
+
+// WithToolCallIDs wraps an LLM client so the IDs of every tool call it returns
+// are replaced with IDs from gen. Streaming tool-use events and the final
+// response carry the same replacement ID for a given call. A nil gen returns
+// inner unchanged, keeping the provider's IDs.
+func WithToolCallIDs(inner LLM, gen IDGenerator) LLM {
+	if gen == nil {
+		return inner
+	}
+	return &toolCallIDLLM{inner: inner, gen: gen}
+}
+
+type toolCallIDLLM struct {
+	inner LLM
+	gen   IDGenerator
+}
+
+type toolCallIDMapper struct {
+	mu  sync.Mutex
+	gen IDGenerator
+	ids map[string]string
+}
+
+func (m *toolCallIDMapper) id(original string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if original != "" {
+		if id, ok := m.ids[original]; ok {
+			return id
+		}
+	}
+	id := m.gen()
+	if original != "" {
+		m.ids[original] = id
+	}
+	return id
+}
+
+func (m *toolCallIDMapper) response(resp *Response) *Response {
+	if resp == nil ||
+		(len(resp.ToolCalls) == 0 && len(resp.Choices) == 0) {
+		return resp
+	}
+	out := *resp
+	out.ToolCalls = m.calls(resp.ToolCalls)
+	if len(resp.Choices) > 0 {
+		out.Choices = make([]Choice, len(resp.Choices))
+		for i, choice := range resp.Choices {
+			choice.ToolCalls = m.calls(choice.ToolCalls)
+			out.Choices[i] = choice
+		}
+	}
+	return &out
+}
+
+func (m *toolCallIDMapper) calls(calls []message.ToolCall) []message.ToolCall {
+	if len(calls) == 0 {
+		return calls
+	}
+	out := make([]message.ToolCall, len(calls))
+	for i, call := range calls {
+		call.ID = m.id(call.ID)
+		out[i] = call
+	}
+	return out
+}
+
+func (t *toolCallIDLLM) mapper() *toolCallIDMapper {
+	return &toolCallIDMapper{gen: t.gen, ids: make(map[string]string)}
+}
+
+func (t *toolCallIDLLM) Model() model.Model {
+	return t.inner.Model()
+}
+
+func (t *toolCallIDLLM) Unwrap() LLM {
+	return t.inner
+}
+
+func (t *toolCallIDLLM) SupportsStructuredOutput() bool {
+	return t.inner.SupportsStructuredOutput()
+}
+
+func (t *toolCallIDLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	resp, err := t.inner.SendMessages(ctx, messages, tools)
+	if err != nil {
+		return resp, err
+	}
+	return t.mapper().response(resp), nil
+}
+
+func (t *toolCallIDLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	resp, err := t.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+	if err != nil {
+		return resp, err
+	}
+	return t.mapper().response(resp), nil
+}
+
+func (t *toolCallIDLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return t.mapStream(ctx, t.inner.StreamResponse(ctx, messages, tools))
+}
+
+func (t *toolCallIDLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return t.mapStream(
+		ctx,
+		t.inner.StreamResponseWithStructuredOutput(
+			ctx,
+			messages,
+			tools,
+			outputSchema,
+		),
+	)
+}
+
+func (t *toolCallIDLLM) mapStream(
+	ctx context.Context,
+	innerCh <-chan Event,
+) <-chan Event {
+	m := t.mapper()
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for evt := range innerCh {
+			if evt.ToolCall != nil && evt.ToolCall.ID != "" {
+				call := *evt.ToolCall
+				call.ID = m.id(call.ID)
+				evt.ToolCall = &call
+			}
+			if evt.Type == types.EventComplete {
+				evt.Response = m.response(evt.Response)
+			}
+			select {
+			case outCh <- evt:
+			case <-ctx.Done():
+				drainEvents(innerCh)
+				return
+			}
+		}
+	}()
+	return outCh
+}
@@ -1,6 +1,9 @@
 package llm
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // ToolChoiceMode controls whether/which tool the model may call.
 type ToolChoiceMode int
@@ -38,3 +41,22 @@ func (tc ToolChoice) Validate() error {
 	}
 	return nil
 }
+
+type toolChoiceKey struct{}
+
+// ContextWithToolChoice returns a copy of ctx carrying choice. Vendor packages
+// that support tool choice apply it to requests made with the returned context
+// in place of their WithToolChoice option, so callers can force or forbid tool
+// use for a single call.
+func ContextWithToolChoice(
+	ctx context.Context,
+	choice ToolChoice,
+) context.Context {
+	return context.WithValue(ctx, toolChoiceKey{}, choice)
+}
+
+// ToolChoiceFromContext returns the tool choice carried by ctx, if any.
+func ToolChoiceFromContext(ctx context.Context) (ToolChoice, bool) {
+	choice, ok := ctx.Value(toolChoiceKey{}).(ToolChoice)
+	return choice, ok
+}
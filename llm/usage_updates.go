@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// TextCounter counts the tokens in a piece of text. The tokens package's
+// BPETokenizer satisfies it.
+type TextCounter interface {
+	Count(text string) int
+}
+
+// WithUsageUpdates returns an LLM whose streams emit a
+// [types.EventUsageUpdate] event with running totals after every content,
+// thinking, or tool input delta, for live token meters.
+//
+// Providers that report usage mid-stream, such as Anthropic and Gemini, send
+// their own updates; their totals are passed on and always win. Until the
+// provider reports output tokens, and for providers that stay silent until
+// the end, the output count is estimated by running counter over the deltas
+// received so far. Input tokens are only known once the provider reports
+// them. Estimates are approximate; the [Response] in the complete event
+// carries the provider's final usage.
+func WithUsageUpdates(inner LLM, counter TextCounter) LLM {
+	return &usageUpdatesLLM{inner: inner, counter: counter}
+}
+
+type usageUpdatesLLM struct {
+	inner   LLM
+	counter TextCounter
+}
+
+func (u *usageUpdatesLLM) Model() model.Model {
+	return u.inner.Model()
+}
+
+func (u *usageUpdatesLLM) Unwrap() LLM {
+	return u.inner
+}
+
+func (u *usageUpdatesLLM) SupportsStructuredOutput() bool {
+	return u.inner.SupportsStructuredOutput()
+}
+
+func (u *usageUpdatesLLM) SendMessages(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) (*Response, error) {
+	return u.inner.SendMessages(ctx, messages, tools)
+}
+
+func (u *usageUpdatesLLM) SendMessagesWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) (*Response, error) {
+	return u.inner.SendMessagesWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	)
+}
+
+func (u *usageUpdatesLLM) StreamResponse(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+) <-chan Event {
+	return u.stream(ctx, u.inner.StreamResponse(ctx, messages, tools))
+}
+
+func (u *usageUpdatesLLM) StreamResponseWithStructuredOutput(
+	ctx context.Context,
+	messages []message.Message,
+	tools []tool.BaseTool,
+	outputSchema *schema.StructuredOutputInfo,
+) <-chan Event {
+	return u.stream(ctx, u.inner.StreamResponseWithStructuredOutput(
+		ctx,
+		messages,
+		tools,
+		outputSchema,
+	))
+}
+
+func (u *usageUpdatesLLM) stream(
+	ctx context.Context,
+	innerCh <-chan Event,
+) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		send := func(evt Event) bool {
+			select {
+			case outCh <- evt:
+				return true
+			case <-ctx.Done():
+				drainEvents(innerCh)
+				return false
+			}
+		}
+
+		var reported TokenUsage
+		var estimated int64
+		running := func() *TokenUsage {
+			usage := reported
+			usage.OutputTokens = max(usage.OutputTokens, estimated)
+			return &usage
+		}
+
+		for evt := range innerCh {
+			var text string
+			switch evt.Type {
+			case types.EventUsageUpdate:
+				if evt.Usage != nil {
+					reported = *evt.Usage
+					evt.Usage = running()
+				}
+				if !send(evt) {
+					return
+				}
+				continue
+			case types.EventContentDelta:
+				text = evt.Content
+			case types.EventThinkingDelta:
+				text = evt.Thinking
+			case types.EventToolUseDelta:
+				if evt.ToolCall != nil {
+					text = evt.ToolCall.Input
+				}
+			}
+			if !send(evt) {
+				return
+			}
+			if text == "" {
+				continue
+			}
+			estimated += int64(u.counter.Count(text))
+			if !send(Event{
+				Type:  types.EventUsageUpdate,
+				Usage: running(),
+			}) {
+				return
+			}
+		}
+	}()
+	return outCh
+}
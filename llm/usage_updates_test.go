@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/types"
+)
+
+// wordCounter counts whitespace-separated words as tokens.
+type wordCounter struct{}
+
+func (wordCounter) Count(text string) int {
+	return len(strings.Fields(text))
+}
+
+func usageUpdates(events []Event) []TokenUsage {
+	var out []TokenUsage
+	for _, evt := range events {
+		if evt.Type == types.EventUsageUpdate {
+			out = append(out, *evt.Usage)
+		}
+	}
+	return out
+}
+
+func TestWithUsageUpdates_EstimatesSilentProvider(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{{
+		delta("one two "),
+		delta("three"),
+		complete("one two three"),
+	}}}
+
+	client := WithUsageUpdates(inner, wordCounter{})
+	text, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+	if text != "one two three" {
+		t.Errorf("streamed %q", text)
+	}
+
+	updates := usageUpdates(events)
+	if len(updates) != 2 {
+		t.Fatalf("updates = %+v, want 2", updates)
+	}
+	if updates[0].OutputTokens != 2 || updates[1].OutputTokens != 3 {
+		t.Errorf("output tokens = %d, %d, want 2, 3",
+			updates[0].OutputTokens, updates[1].OutputTokens)
+	}
+	if events[len(events)-1].Type != types.EventComplete {
+		t.Errorf("last event = %+v, want complete", events[len(events)-1])
+	}
+}
+
+func TestWithUsageUpdates_MergesProviderUsage(t *testing.T) {
+	inner := &attemptsLLM{attempts: [][]Event{{
+		{
+			Type:  types.EventUsageUpdate,
+			Usage: &TokenUsage{InputTokens: 40, OutputTokens: 1},
+		},
+		delta("a b c"),
+		{
+			Type:  types.EventUsageUpdate,
+			Usage: &TokenUsage{InputTokens: 40, OutputTokens: 9},
+		},
+		complete("a b c"),
+	}}}
+
+	client := WithUsageUpdates(inner, wordCounter{})
+	_, events := collectStream(
+		client.StreamResponse(context.Background(), nil, nil),
+	)
+
+	updates := usageUpdates(events)
+	want := []TokenUsage{
+		{InputTokens: 40, OutputTokens: 1},
+		{InputTokens: 40, OutputTokens: 3},
+		{InputTokens: 40, OutputTokens: 9},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("updates = %+v, want %+v", updates, want)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+}
+
+func TestWithUsageUpdates_Unwrap(t *testing.T) {
+	client := WithUsageUpdates(&uploadingLLM{}, wordCounter{})
+	if _, ok := AsFileUploader(client); !ok {
+		t.Error("uploader not found behind WithUsageUpdates")
+	}
+}
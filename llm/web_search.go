@@ -0,0 +1,47 @@
+package llm
+
+import "context"
+
+// WebSearch is a vendor-neutral request for the provider's native,
+// server-side web search tool. Vendor packages that offer one (Anthropic's
+// web_search and the OpenAI Responses API's web_search) add it to requests
+// made with a context from [ContextWithWebSearch]; other vendors ignore it.
+// The sources the model cites are returned on [Response].Citations.
+type WebSearch struct {
+	// MaxUses caps the searches per request. Zero leaves it to the provider.
+	// OpenAI has no such limit and ignores it.
+	MaxUses int
+	// AllowedDomains restricts results to these domains.
+	AllowedDomains []string
+	// BlockedDomains excludes these domains. OpenAI ignores it.
+	BlockedDomains []string
+	// Location is the approximate user location, used to localize results.
+	Location *WebSearchLocation
+}
+
+// WebSearchLocation is an approximate user location for [WebSearch].
+type WebSearchLocation struct {
+	City     string
+	Country  string
+	Region   string
+	Timezone string
+}
+
+type webSearchKey struct{}
+
+// ContextWithWebSearch returns a copy of ctx that enables the provider's
+// native web search tool for requests made with it. A client already
+// configured with its vendor's WithWebSearch option keeps that
+// configuration.
+func ContextWithWebSearch(
+	ctx context.Context,
+	search WebSearch,
+) context.Context {
+	return context.WithValue(ctx, webSearchKey{}, search)
+}
+
+// WebSearchFromContext returns the web search request carried by ctx, if any.
+func WebSearchFromContext(ctx context.Context) (WebSearch, bool) {
+	search, ok := ctx.Value(webSearchKey{}).(WebSearch)
+	return search, ok
+}
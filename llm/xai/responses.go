@@ -321,7 +321,9 @@ func (c *xaiResponsesClient) convertMessages(
 					OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
 						CallID: result.ToolCallID,
 						Output: responses.ResponseInputItemFunctionCallOutputOutputUnionParam{
-							OfString: openaisdk.String(result.Content),
+							OfString: openaisdk.String(
+								result.TextWithImageNotes(),
+							),
 						},
 					},
 				})
@@ -492,12 +494,22 @@ func (c *xaiResponsesClient) buildResponse(
 	}
 }
 
+func errorEvent(err error) <-chan llm.Event {
+	eventChan := make(chan llm.Event, 1)
+	eventChan <- llm.Event{Type: types.EventError, Error: err}
+	close(eventChan)
+	return eventChan
+}
+
 // SendMessages sends a conversation and returns the complete response.
 func (c *xaiResponsesClient) SendMessages(
 	ctx context.Context,
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) (*llm.Response, error) {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	reqOpts := c.requestOptions(convertedTools)
@@ -525,6 +537,9 @@ func (c *xaiResponsesClient) SendMessagesWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) (*llm.Response, error) {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return nil, err
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	params.Text = c.structuredTextConfig(outputSchema)
@@ -552,18 +567,11 @@ func (c *xaiResponsesClient) SendMessagesWithStructuredOutput(
 func (c *xaiResponsesClient) structuredTextConfig(
 	outputSchema *schema.StructuredOutputInfo,
 ) responses.ResponseTextConfigParam {
-	schemaMap := map[string]any{
-		"type":       "object",
-		"properties": outputSchema.Parameters,
-	}
-	if len(outputSchema.Required) > 0 {
-		schemaMap["required"] = outputSchema.Required
-	}
 	return responses.ResponseTextConfigParam{
 		Format: responses.ResponseFormatTextConfigUnionParam{
 			OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
 				Name:   "structured_output",
-				Schema: schemaMap,
+				Schema: outputSchema.StrictJSONSchema(),
 				Strict: openaisdk.Bool(true),
 			},
 		},
@@ -576,6 +584,9 @@ func (c *xaiResponsesClient) StreamResponse(
 	messages []message.Message,
 	tools []tool.BaseTool,
 ) <-chan llm.Event {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	return c.runStream(ctx, params, c.requestOptions(convertedTools), false)
@@ -588,6 +599,9 @@ func (c *xaiResponsesClient) StreamResponseWithStructuredOutput(
 	tools []tool.BaseTool,
 	outputSchema *schema.StructuredOutputInfo,
 ) <-chan llm.Event {
+	if err := llm.RejectVideo(c.options.model.Provider, messages); err != nil {
+		return errorEvent(err)
+	}
 	convertedTools := c.convertTools(tools)
 	params := c.preparedParams(c.convertMessages(messages), convertedTools)
 	params.Text = c.structuredTextConfig(outputSchema)
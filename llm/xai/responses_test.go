@@ -2,13 +2,16 @@ package xai
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/types"
 )
 
 const responsesOK = `{"id":"resp_1","object":"response","status":"completed",` +
@@ -59,3 +62,39 @@ func TestResponsesWithHTTPClientTransportUsed(t *testing.T) {
 		t.Error("injected transport was not used for the request")
 	}
 }
+
+// TestResponsesRejectsVideo verifies that a request carrying video fails with
+// llm.ErrCapabilityUnsupported on both the send and stream paths without
+// reaching the server.
+func TestResponsesRejectsVideo(t *testing.T) {
+	var n int
+	client := NewResponsesLLM(
+		WithResponsesAPIKey("test-key"),
+		WithResponsesBaseURL("http://127.0.0.1:1"),
+		WithResponsesModel(model.Model{APIModel: "grok-4"}),
+		WithResponsesHTTPClient(&http.Client{
+			Transport: countingRT{RoundTripper: http.DefaultTransport, n: &n},
+		}),
+	)
+	msg := message.NewUserMessage("what happens?")
+	msg.AddVideoURL("https://www.youtube.com/watch?v=abc", nil)
+	messages := []message.Message{msg}
+
+	_, err := client.SendMessages(context.Background(), messages, nil)
+	if !errors.Is(err, llm.ErrCapabilityUnsupported) {
+		t.Errorf("SendMessages err = %v, want ErrCapabilityUnsupported", err)
+	}
+	for event := range client.StreamResponse(
+		context.Background(),
+		messages,
+		nil,
+	) {
+		if event.Type != types.EventError ||
+			!errors.Is(event.Error, llm.ErrCapabilityUnsupported) {
+			t.Errorf("stream event = %+v, want capability error", event)
+		}
+	}
+	if n != 0 {
+		t.Errorf("%d requests reached the server, want 0", n)
+	}
+}
@@ -10,6 +10,7 @@ package xai
 import (
 	"github.com/joakimcarlsson/ai/llm"
 	llmopenai "github.com/joakimcarlsson/ai/llm/openai"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 // DefaultBaseURL is the canonical xAI API endpoint.
@@ -21,9 +22,14 @@ type Option = llmopenai.Option
 
 // NewLLM constructs an xAI LLM client.
 //
-// [llmopenai.WithBaseURL] is prepended with [DefaultBaseURL]; pass it again in
-// opts to point at a different endpoint (e.g. a corporate proxy).
+// The endpoint defaults to [DefaultBaseURL]; AI_XAI_BASE_URL or
+// [llmopenai.WithBaseURL] in opts points it elsewhere (e.g. a corporate
+// proxy). The API key and model fall back to AI_XAI_API_KEY and AI_XAI_MODEL
+// when not passed as options.
 func NewLLM(opts ...Option) llm.LLM {
 	return llmopenai.NewLLM(
-		append([]Option{llmopenai.WithBaseURL(DefaultBaseURL)}, opts...)...)
+		append([]Option{
+			llmopenai.WithEnvProvider(model.ProviderXAI),
+			llmopenai.WithDefaultBaseURL(DefaultBaseURL),
+		}, opts...)...)
 }
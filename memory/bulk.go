@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// BulkStore is a [Store] that can count and delete all of an owner's
+// memories in one operation, for data-deletion requests, migrations, and
+// admin tooling. The built-in stores and the pgvector store implement it;
+// use [Count] and [DeleteAll] to fall back to per-entry operations for
+// stores that do not.
+type BulkStore interface {
+	Store
+	// Count returns the number of memories stored for owner id.
+	Count(ctx context.Context, id string) (int, error)
+	// DeleteAll deletes every memory stored for owner id and returns how
+	// many were deleted.
+	DeleteAll(ctx context.Context, id string) (int, error)
+}
+
+// Count returns the number of memories stored for owner id, using
+// [BulkStore.Count] when store implements it and counting the entries
+// returned by GetAll otherwise.
+func Count(ctx context.Context, store Store, id string) (int, error) {
+	if bs, ok := store.(BulkStore); ok {
+		return bs.Count(ctx, id)
+	}
+	entries, err := store.GetAll(ctx, id, math.MaxInt32)
+	if err != nil {
+		return 0, fmt.Errorf("memory: count: %w", err)
+	}
+	return len(entries), nil
+}
+
+// DeleteAll deletes every memory stored for owner id and returns how many
+// were deleted. It uses [BulkStore.DeleteAll] when store implements it and
+// otherwise deletes the entries returned by GetAll one at a time, returning
+// the number deleted before any failure along with the error.
+func DeleteAll(ctx context.Context, store Store, id string) (int, error) {
+	if bs, ok := store.(BulkStore); ok {
+		return bs.DeleteAll(ctx, id)
+	}
+	entries, err := store.GetAll(ctx, id, math.MaxInt32)
+	if err != nil {
+		return 0, fmt.Errorf("memory: delete all: %w", err)
+	}
+	for i, e := range entries {
+		if err := store.Delete(ctx, e.ID); err != nil {
+			return i, fmt.Errorf("memory: delete all: %w", err)
+		}
+	}
+	return len(entries), nil
+}
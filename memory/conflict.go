@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// Metadata keys set by [ResolveConflicts] on a fact that supersedes
+// contradicted memories.
+const (
+	// MetadataSupersedes holds the IDs of the superseded memories, as
+	// []string.
+	MetadataSupersedes = "supersedes"
+	// MetadataSupersededFacts holds the content of the superseded memories,
+	// as []string in the same order, so the history survives their deletion.
+	MetadataSupersededFacts = "superseded_facts"
+)
+
+const conflictSystemPrompt = `You are a memory consistency checker. Given existing memories about a user and a new fact, find the existing memories the new fact directly contradicts, such that both cannot be true at the same time (e.g. "Loves Italian food" and "Hates Italian food", or "Lives in Oslo" and "Moved to Bergen").
+
+Do not report memories that are merely related, more or less specific, or compatible with the new fact.
+
+Respond ONLY with valid JSON in this exact format:
+{"conflicts": [{"memory_id": "id of the contradicted memory", "reason": "short explanation"}]}
+
+If there are no contradictions, respond with {"conflicts": []}.`
+
+type conflictResult struct {
+	Conflicts []struct {
+		MemoryID string `json:"memory_id"`
+		Reason   string `json:"reason"`
+	} `json:"conflicts"`
+}
+
+// DetectConflicts asks the LLM which of the existing memories newFact
+// contradicts and returns them. An unparseable response is treated as no
+// conflicts, and IDs that are not among existing are ignored.
+func DetectConflicts(
+	ctx context.Context,
+	llmClient llm.LLM,
+	newFact string,
+	existing []Entry,
+) ([]Entry, error) {
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	var existingStr strings.Builder
+	for _, m := range existing {
+		fmt.Fprintf(&existingStr, "- [id:%s] %s\n", m.ID, m.Content)
+	}
+
+	messages := []message.Message{
+		message.NewSystemMessage(conflictSystemPrompt),
+		message.NewUserMessage(fmt.Sprintf(
+			"Existing memories:\n%s\nNew fact: %s",
+			existingStr.String(),
+			newFact,
+		)),
+	}
+
+	resp, err := llmClient.SendMessages(ctx, messages, nil)
+	if err != nil {
+		return nil, fmt.Errorf("conflict detection LLM call failed: %w", err)
+	}
+
+	var result conflictResult
+	content := stripCodeFence(resp.Content)
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, nil
+	}
+
+	var conflicts []Entry
+	for _, c := range result.Conflicts {
+		for _, e := range existing {
+			if e.ID == c.MemoryID {
+				conflicts = append(conflicts, e)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// Resolution is the outcome of [ResolveConflicts].
+type Resolution struct {
+	// Metadata is the metadata to store the new fact with: a copy of the
+	// given metadata with [MetadataSupersedes] and [MetadataSupersededFacts]
+	// set, or the given metadata unchanged if nothing is superseded.
+	Metadata map[string]any
+	// Superseded lists the memories the new fact contradicts.
+	Superseded []Entry
+}
+
+// Apply deletes the superseded memories, except those whose IDs are in keep,
+// such as a memory deduplication updated to hold the new fact. Call it only
+// once the new fact is stored, so a failed store leaves the old memories in
+// place. Every deletion is attempted and their errors are joined.
+func (r *Resolution) Apply(
+	ctx context.Context,
+	store Store,
+	keep ...string,
+) error {
+	var errs []error
+	for _, e := range r.Superseded {
+		if slices.Contains(keep, e.ID) {
+			continue
+		}
+		if err := store.Delete(ctx, e.ID); err != nil {
+			errs = append(errs, fmt.Errorf(
+				"failed to delete superseded memory %s: %w",
+				e.ID,
+				err,
+			))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ResolveConflicts finds the memories of ownerID that newFact contradicts,
+// as found by [DetectConflicts] among the five nearest, so a changed fact
+// can supersede the old one instead of being stored beside it. Nothing is
+// deleted: store newFact with the returned Metadata, then call
+// [Resolution.Apply]. The returned Resolution is never nil; on error it
+// holds metadata unchanged and no superseded memories.
+func ResolveConflicts(
+	ctx context.Context,
+	store Store,
+	llmClient llm.LLM,
+	ownerID string,
+	newFact string,
+	metadata map[string]any,
+) (*Resolution, error) {
+	res := &Resolution{Metadata: metadata}
+
+	existing, err := store.Search(ctx, ownerID, newFact, 5)
+	if err != nil {
+		return res, err
+	}
+
+	conflicts, err := DetectConflicts(ctx, llmClient, newFact, existing)
+	if err != nil || len(conflicts) == 0 {
+		return res, err
+	}
+
+	ids := make([]string, 0, len(conflicts))
+	facts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		ids = append(ids, c.ID)
+		facts = append(facts, c.Content)
+	}
+
+	res.Metadata = maps.Clone(metadata)
+	if res.Metadata == nil {
+		res.Metadata = make(map[string]any, 2)
+	}
+	res.Metadata[MetadataSupersedes] = ids
+	res.Metadata[MetadataSupersededFacts] = facts
+	res.Superseded = conflicts
+	return res, nil
+}
@@ -20,6 +20,52 @@ const (
 	DedupEventNone   DedupEvent = "NONE"
 )
 
+// DedupStrategy is the action [AutoDedup] takes when a new fact has
+// near-duplicates among the existing memories.
+type DedupStrategy string
+
+// Dedup strategies.
+const (
+	// DedupMerge asks the LLM to decide whether to add, update, delete, or
+	// skip, merging the new fact into existing memories where it fits. This
+	// is the default.
+	DedupMerge DedupStrategy = "merge"
+	// DedupSkip keeps the existing memories and drops the new fact without
+	// an LLM call.
+	DedupSkip DedupStrategy = "skip"
+	// DedupReplace overwrites the most similar existing memory with the new
+	// fact without an LLM call.
+	DedupReplace DedupStrategy = "replace"
+)
+
+// DedupConfig holds the deduplication settings configured by [AutoDedup].
+type DedupConfig struct {
+	// Threshold is the minimum similarity score for an existing memory to
+	// count as a near-duplicate. Zero treats every search result as one.
+	Threshold float64
+	// Strategy is the action taken on near-duplicates. Empty means
+	// [DedupMerge].
+	Strategy DedupStrategy
+}
+
+// DedupOption configures [AutoDedup].
+type DedupOption func(*DedupConfig)
+
+// WithDedupThreshold sets the minimum similarity score, as reported in
+// [Entry.Score] by the store's Search, for an existing memory to count as a
+// near-duplicate of a new fact. Facts without one are stored without an LLM
+// call. Raise it when distinct facts are being merged. By default every one
+// of the five nearest memories is considered.
+func WithDedupThreshold(threshold float64) DedupOption {
+	return func(c *DedupConfig) { c.Threshold = threshold }
+}
+
+// WithDedupStrategy sets the action taken when a new fact has
+// near-duplicates. Defaults to [DedupMerge].
+func WithDedupStrategy(strategy DedupStrategy) DedupOption {
+	return func(c *DedupConfig) { c.Strategy = strategy }
+}
+
 // DedupDecision represents a single deduplication decision.
 type DedupDecision struct {
 	Event    DedupEvent `json:"event"`
@@ -99,3 +145,60 @@ func Deduplicate(
 
 	return &result, nil
 }
+
+// DeduplicateWith is [Deduplicate] with the threshold and strategy from cfg
+// applied. Existing memories scoring below cfg.Threshold are ignored; if none
+// remain, the new fact is added. Otherwise [DedupSkip] and [DedupReplace]
+// decide without calling llmClient, and [DedupMerge] calls [Deduplicate]
+// with the remaining memories.
+func DeduplicateWith(
+	ctx context.Context,
+	llmClient llm.LLM,
+	newFact string,
+	existing []Entry,
+	cfg DedupConfig,
+) (*DedupResult, error) {
+	var similar []Entry
+	for _, e := range existing {
+		if e.Score >= cfg.Threshold {
+			similar = append(similar, e)
+		}
+	}
+
+	if len(similar) == 0 {
+		return &DedupResult{
+			Decisions: []DedupDecision{{
+				Event: DedupEventAdd,
+				Text:  newFact,
+			}},
+		}, nil
+	}
+
+	switch cfg.Strategy {
+	case DedupSkip:
+		return &DedupResult{
+			Decisions: []DedupDecision{{
+				Event: DedupEventNone,
+				Text:  newFact,
+			}},
+		}, nil
+	case DedupReplace:
+		closest := similar[0]
+		for _, e := range similar[1:] {
+			if e.Score > closest.Score {
+				closest = e
+			}
+		}
+		return &DedupResult{
+			Decisions: []DedupDecision{{
+				Event:    DedupEventUpdate,
+				MemoryID: closest.ID,
+				Text:     newFact,
+			}},
+		}, nil
+	case DedupMerge, "":
+		return Deduplicate(ctx, llmClient, newFact, similar)
+	default:
+		return nil, fmt.Errorf("unknown dedup strategy %q", cfg.Strategy)
+	}
+}
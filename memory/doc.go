@@ -37,7 +37,8 @@
 //
 // # Memory Options
 //
-//   - [AutoExtract]: Automatically extract facts from conversations
+//   - [AutoExtract]: Extract facts from conversations in the background
+//   - [WithExtractInterval]: Extract every n turns instead of every turn
 //   - [AutoDedup]: Deduplicate similar memories to avoid redundancy
 //   - [LLM]: Use a separate LLM for memory operations (extraction/deduplication)
 //
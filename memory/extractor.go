@@ -40,6 +40,23 @@ type factExtractionResult struct {
 	Facts []string `json:"facts"`
 }
 
+type extractNowKey struct{}
+
+// ExtractNow returns a context that makes an agent with [AutoExtract]
+// extract memories after the turn it is passed to, even when
+// [WithExtractInterval] has not been reached yet.
+//
+//	resp, err := a.Chat(memory.ExtractNow(ctx), "Thanks, bye!")
+func ExtractNow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, extractNowKey{}, true)
+}
+
+// ExtractNowFromContext reports whether ctx was returned by [ExtractNow].
+func ExtractNowFromContext(ctx context.Context) bool {
+	now, _ := ctx.Value(extractNowKey{}).(bool)
+	return now
+}
+
 // ExtractFacts extracts facts from a conversation using an LLM.
 // It only extracts facts from user messages, ignoring system and assistant messages.
 func ExtractFacts(
@@ -76,11 +93,7 @@ func ExtractFacts(
 		return nil, err
 	}
 
-	content := strings.TrimSpace(resp.Content)
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimPrefix(content, "```")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
+	content := stripCodeFence(resp.Content)
 
 	var result factExtractionResult
 	if err := json.Unmarshal([]byte(content), &result); err != nil {
@@ -89,3 +102,13 @@ func ExtractFacts(
 
 	return result.Facts, nil
 }
+
+// stripCodeFence removes the Markdown code fence models often wrap JSON
+// responses in.
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
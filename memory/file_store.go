@@ -138,7 +138,7 @@ func (s *fileStore) Search(
 	for i, e := range entries {
 		scoredEntries[i] = scored{
 			entry: e,
-			score: cosineSimilarity(queryVector, e.Vector),
+			score: embeddings.CosineSimilarity(queryVector, e.Vector),
 		}
 	}
 
@@ -259,3 +259,27 @@ func (s *fileStore) Update(
 
 	return nil
 }
+
+func (s *fileStore) Count(_ context.Context, id string) (int, error) {
+	s.mu.RLock()
+	entries, err := s.loadEntries(id)
+	s.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (s *fileStore) DeleteAll(_ context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.loadEntries(id)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(s.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	return len(entries), nil
+}
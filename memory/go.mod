@@ -17,6 +17,7 @@ require (
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/google/jsonschema-go v0.4.3 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.29.0 // indirect
+	github.com/joakimcarlsson/ai/metrics v0.1.0 // indirect
 	github.com/joakimcarlsson/ai/model v0.6.0 // indirect
 	github.com/joakimcarlsson/ai/schema v0.2.0 // indirect
 	github.com/joakimcarlsson/ai/tracing v0.1.1 // indirect
@@ -52,6 +53,7 @@ replace (
 	github.com/joakimcarlsson/ai/embeddings => ../embeddings
 	github.com/joakimcarlsson/ai/llm => ../llm
 	github.com/joakimcarlsson/ai/message => ../message
+	github.com/joakimcarlsson/ai/metrics => ../metrics
 	github.com/joakimcarlsson/ai/model => ../model
 	github.com/joakimcarlsson/ai/schema => ../schema
 	github.com/joakimcarlsson/ai/tool => ../tool
@@ -90,7 +90,7 @@ func (s *memoryStore) Search(
 	for i, e := range userEntries {
 		scoredEntries[i] = scored{
 			entry: e,
-			score: cosineSimilarity(queryVector, e.Vector),
+			score: embeddings.CosineSimilarity(queryVector, e.Vector),
 		}
 	}
 
@@ -178,3 +178,17 @@ func (s *memoryStore) Update(
 
 	return nil
 }
+
+func (s *memoryStore) Count(_ context.Context, id string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries[id]), nil
+}
+
+func (s *memoryStore) DeleteAll(_ context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.entries[id])
+	delete(s.entries, id)
+	return n, nil
+}
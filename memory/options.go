@@ -6,9 +6,13 @@ import (
 
 // Config holds memory-related configuration for an agent.
 type Config struct {
-	AutoExtract bool
-	AutoDedup   bool
-	LLM         llm.LLM
+	AutoExtract        bool
+	AutoDedup          bool
+	Dedup              DedupConfig
+	ConflictResolution bool
+	FailClosed         bool
+	ExtractInterval    int
+	LLM                llm.LLM
 }
 
 // Option is a functional option for configuring memory behavior.
@@ -17,6 +21,11 @@ type Option func(*Config)
 // AutoExtract enables automatic fact extraction from conversations.
 // When enabled, the agent uses an LLM to extract relevant facts from each conversation
 // and stores them in the memory store.
+//
+// Extraction runs in a background goroutine after the response is returned,
+// so its LLM call never adds latency to a turn. It gets its own context and
+// logs its errors; call the agent's FlushMemories method before shutting
+// down to wait for extractions still running.
 func AutoExtract() Option {
 	return func(c *Config) {
 		c.AutoExtract = true
@@ -26,9 +35,57 @@ func AutoExtract() Option {
 // AutoDedup enables LLM-based memory deduplication on store.
 // When enabled, before storing a new memory, the agent searches for similar existing
 // memories and asks an LLM to decide whether to ADD, UPDATE, DELETE, or skip.
-func AutoDedup() Option {
+// Use [WithDedupThreshold] and [WithDedupStrategy] to tune which memories count
+// as near-duplicates and what happens to them.
+func AutoDedup(opts ...DedupOption) Option {
 	return func(c *Config) {
 		c.AutoDedup = true
+		for _, opt := range opts {
+			opt(&c.Dedup)
+		}
+	}
+}
+
+// WithExtractInterval makes [AutoExtract] run once every n turns instead of
+// after each one, cutting the extra LLM calls for chatty sessions. Turns in
+// between are not lost: extraction reads the whole session, so facts from
+// every accumulated turn are picked up when it runs. Use [ExtractNow] or the
+// agent's ExtractMemories method to extract before the interval is reached,
+// such as when a session ends. Recall still happens every turn. Values below
+// 2 extract after every turn.
+func WithExtractInterval(n int) Option {
+	return func(c *Config) {
+		c.ExtractInterval = n
+	}
+}
+
+// WithConflictResolution checks each extracted fact against the user's
+// existing memories before storing it. Memories the fact contradicts, such
+// as "Loves Italian food" after the user says they hate it, are deleted once
+// the new fact is stored with links to them; see [ResolveConflicts]. This
+// costs one extra LLM call per fact and only applies with [AutoExtract].
+func WithConflictResolution() Option {
+	return func(c *Config) {
+		c.ConflictResolution = true
+	}
+}
+
+// WithFailOpen keeps the conversation going when the memory store fails,
+// for example because its embeddings provider is down: the error is logged
+// as a warning and the turn runs without memories. This is the default.
+func WithFailOpen() Option {
+	return func(c *Config) {
+		c.FailClosed = false
+	}
+}
+
+// WithFailClosed fails the turn when recalling memories for it fails, for
+// applications where answering without the user's memories is worse than
+// not answering. Auto-extraction runs after the reply is sent, so its
+// failures are only logged either way.
+func WithFailClosed() Option {
+	return func(c *Config) {
+		c.FailClosed = true
 	}
 }
 
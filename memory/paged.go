@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrInvalidCursor is returned by GetAllPaged when the cursor was not
+// returned by an earlier call for the same store.
+var ErrInvalidCursor = errors.New("memory: invalid cursor")
+
+// PagedStore is a [Store] that can page through an owner's memories without
+// loading them all, for admin UIs and dashboards over large stores. The
+// pgvector store implements it with keyset pagination; use [GetAllPaged] to
+// fall back to GetAll for stores that do not.
+type PagedStore interface {
+	Store
+	// GetAllPaged returns up to pageSize memories stored for owner id,
+	// newest first, starting after cursor. Pass an empty cursor for the
+	// first page. The returned cursor fetches the next page and is empty
+	// after the last one.
+	GetAllPaged(
+		ctx context.Context,
+		id string,
+		cursor string,
+		pageSize int,
+	) ([]Entry, string, error)
+}
+
+// GetAllPaged returns one page of the memories stored for owner id, using
+// [PagedStore.GetAllPaged] when store implements it. Otherwise it loads the
+// entries with GetAll and pages through them in memory, in the order GetAll
+// returns them. Cursors from one store are not valid for another.
+func GetAllPaged(
+	ctx context.Context,
+	store Store,
+	id string,
+	cursor string,
+	pageSize int,
+) ([]Entry, string, error) {
+	if ps, ok := store.(PagedStore); ok {
+		return ps.GetAllPaged(ctx, id, cursor, pageSize)
+	}
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("memory: page size must be positive")
+	}
+	offset := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, "", fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+		}
+		offset = n
+	}
+	entries, err := store.GetAll(ctx, id, math.MaxInt32)
+	if err != nil {
+		return nil, "", fmt.Errorf("memory: get all paged: %w", err)
+	}
+	if offset >= len(entries) {
+		return nil, "", nil
+	}
+	end := min(offset+pageSize, len(entries))
+	next := ""
+	if end < len(entries) {
+		next = strconv.Itoa(end)
+	}
+	return entries[offset:end], next, nil
+}
@@ -0,0 +1,80 @@
+package pgvector
+
+import "fmt"
+
+// DistanceMetric selects the pgvector distance operator used for similarity search.
+type DistanceMetric string
+
+// Supported distance metrics.
+const (
+	// DistanceCosine uses cosine distance (<=>). Scores are 1 - distance.
+	DistanceCosine DistanceMetric = "cosine"
+	// DistanceL2 uses Euclidean distance (<->). Scores are 1 / (1 + distance).
+	DistanceL2 DistanceMetric = "l2"
+	// DistanceInnerProduct uses negative inner product (<#>). Scores are the inner product.
+	DistanceInnerProduct DistanceMetric = "inner_product"
+)
+
+// IndexType selects the approximate nearest-neighbor index built on the vector column.
+type IndexType string
+
+// Supported vector index types.
+const (
+	// IndexHNSW builds a hierarchical navigable small world index.
+	IndexHNSW IndexType = "hnsw"
+	// IndexIVFFlat builds an inverted file index with flat compression.
+	IndexIVFFlat IndexType = "ivfflat"
+)
+
+func (m DistanceMetric) operator() string {
+	switch m {
+	case DistanceL2:
+		return "<->"
+	case DistanceInnerProduct:
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+// opclass returns the operator class for the metric on columns of type typ,
+// such as vector or halfvec.
+func (m DistanceMetric) opclass(typ string) string {
+	switch m {
+	case DistanceL2:
+		return typ + "_l2_ops"
+	case DistanceInnerProduct:
+		return typ + "_ip_ops"
+	default:
+		return typ + "_cosine_ops"
+	}
+}
+
+func (m DistanceMetric) scoreExpr(distance string) string {
+	switch m {
+	case DistanceL2:
+		return fmt.Sprintf("1 / (1 + (%s))", distance)
+	case DistanceInnerProduct:
+		return fmt.Sprintf("-(%s)", distance)
+	default:
+		return fmt.Sprintf("1 - (%s)", distance)
+	}
+}
+
+func (m DistanceMetric) validate() error {
+	switch m {
+	case DistanceCosine, DistanceL2, DistanceInnerProduct:
+		return nil
+	default:
+		return fmt.Errorf("unsupported distance metric: %q", m)
+	}
+}
+
+func (t IndexType) validate() error {
+	switch t {
+	case IndexHNSW, IndexIVFFlat:
+		return nil
+	default:
+		return fmt.Errorf("unsupported index type: %q", t)
+	}
+}
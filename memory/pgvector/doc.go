@@ -53,6 +53,43 @@
 //	    pgvector.WithIDGenerator(snowflakeID),
 //	)
 //
+// # Connection Pool
+//
+// Pool settings are applied to the connection opened by [MemoryStore]:
+//
+//	store, err := pgvector.MemoryStore(ctx, connStr, embedder,
+//	    pgvector.WithMaxOpenConns(20),
+//	    pgvector.WithMaxIdleConns(5),
+//	    pgvector.WithConnMaxLifetime(30*time.Minute),
+//	)
+//
+// To share one pool across the memory store and a postgres session store,
+// open the *sql.DB yourself and pass it to [MemoryStoreFromDB]:
+//
+//	db, err := sql.Open("postgres", connStr)
+//	sessions, err := postgres.SessionStoreFromDB(ctx, db)
+//	memories, err := pgvector.MemoryStoreFromDB(ctx, db, embedder)
+//
+// # Vector Dimension
+//
+// The vector column is sized from the embedder's model configuration
+// (model.EmbeddingModel.EmbeddingDims). If the embedder is configured to emit
+// a different dimension, pass it explicitly with [WithDimensions]:
+//
+//	store, err := pgvector.MemoryStore(ctx, connStr, embedder,
+//	    pgvector.WithDimensions(1024),
+//	)
+//
+// On initialization the store checks the dimension of an existing memories
+// table and returns an error wrapping [ErrDimensionMismatch] if it differs.
+// Changing embedding models requires migrating or recreating the table.
+//
+// pgvector indexes at most 2000 dimensions of the vector type. Between 2001
+// and 4000 dimensions, such as text-embedding-3-large's 3072, the index is
+// built on a halfvec cast of the column and searches use the same cast.
+// Above 4000 no index is built: a warning is logged and searches scan the
+// owner's rows.
+//
 // # Database Schema
 //
 // The package creates a memories table with:
@@ -64,5 +101,34 @@
 //   - metadata: JSONB for additional data
 //   - created_at: Timestamp
 //
-// Similarity search uses cosine distance (<=>) for efficient nearest-neighbor queries.
+// Similarity search uses cosine distance (<=>) by default, backed by an HNSW index.
+//
+// # Distance Metric and Index Type
+//
+// Use [WithDistanceMetric] to switch to Euclidean ([DistanceL2]) or inner
+// product ([DistanceInnerProduct]) distance, and [WithIndexType] to choose
+// between HNSW and IVFFlat indexes:
+//
+//	store, err := pgvector.MemoryStore(ctx, connStr, embedder,
+//	    pgvector.WithDistanceMetric(pgvector.DistanceInnerProduct),
+//	    pgvector.WithIndexType(pgvector.IndexIVFFlat),
+//	    pgvector.WithIVFFlatLists(200),
+//	)
+//
+// HNSW build and query parameters are tuned with [WithIndexParams]:
+//
+//	store, err := pgvector.MemoryStore(ctx, connStr, embedder,
+//	    pgvector.WithIndexParams(pgvector.IndexParams{
+//	        M:              32,
+//	        EFConstruction: 128,
+//	        EFSearch:       100,
+//	    }),
+//	)
+//
+// The operator class is fixed when the index is created. Changing the metric
+// or index type on an existing table requires a reindex:
+//
+//	DROP INDEX memories_vector_idx;
+//
+// The index is recreated with the new settings the next time the store is opened.
 package pgvector
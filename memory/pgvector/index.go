@@ -0,0 +1,100 @@
+package pgvector
+
+import (
+	"fmt"
+	"strings"
+)
+
+const createVectorIndexSQL = `
+CREATE INDEX IF NOT EXISTS memories_vector_idx ON memories USING %s (%s %s)%s
+`
+
+// pgvector indexes vector columns of up to 2000 dimensions, and halfvec
+// expressions of up to 4000.
+const (
+	maxVectorIndexDims  = 2000
+	maxHalfvecIndexDims = 4000
+)
+
+// indexCast returns the cast that makes the vector column indexable at dims:
+// none up to 2000 dimensions, halfvec up to 4000. Searches apply the same
+// cast so the index is used. ok is false when no index can be built.
+func indexCast(dims int) (cast string, ok bool) {
+	switch {
+	case dims <= maxVectorIndexDims:
+		return "", true
+	case dims <= maxHalfvecIndexDims:
+		return fmt.Sprintf("::halfvec(%d)", dims), true
+	default:
+		return "", false
+	}
+}
+
+// IndexParams tunes the HNSW vector index. Zero values fall back to the
+// pgvector defaults (m = 16, ef_construction = 64, ef_search = 40).
+type IndexParams struct {
+	// M is the maximum number of connections per layer. Applied at index creation.
+	M int
+	// EFConstruction is the size of the candidate list used while building the index.
+	// Applied at index creation.
+	EFConstruction int
+	// EFSearch is the size of the candidate list used at query time. Higher values
+	// improve recall at the cost of speed. Applied per search via hnsw.ef_search.
+	EFSearch int
+}
+
+// vectorIndexSQL returns the statement creating the vector index for dims
+// dimensions, or "" when pgvector cannot index that many.
+func vectorIndexSQL(options storeOptions, dims int) string {
+	cast, ok := indexCast(dims)
+	if !ok {
+		return ""
+	}
+
+	column, opclass := "vector", options.metric.opclass("vector")
+	if cast != "" {
+		column, opclass = "(vector"+cast+")", options.metric.opclass("halfvec")
+	}
+
+	var params []string
+	switch options.indexType {
+	case IndexIVFFlat:
+		params = append(params, fmt.Sprintf("lists = %d", options.lists))
+	case IndexHNSW:
+		if options.indexParams.M > 0 {
+			params = append(
+				params,
+				fmt.Sprintf("m = %d", options.indexParams.M),
+			)
+		}
+		if options.indexParams.EFConstruction > 0 {
+			params = append(
+				params,
+				fmt.Sprintf(
+					"ef_construction = %d",
+					options.indexParams.EFConstruction,
+				),
+			)
+		}
+	}
+
+	var with string
+	if len(params) > 0 {
+		with = " WITH (" + strings.Join(params, ", ") + ")"
+	}
+
+	return fmt.Sprintf(
+		createVectorIndexSQL,
+		options.indexType,
+		column,
+		opclass,
+		with,
+	)
+}
+
+func efSearch(options storeOptions) int {
+	if options.indexType != IndexHNSW {
+		return 0
+	}
+	return options.indexParams.EFSearch
+}
@@ -3,8 +3,11 @@ package pgvector
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -25,21 +28,37 @@ CREATE TABLE IF NOT EXISTS memories (
 );
 
 CREATE INDEX IF NOT EXISTS memories_owner_idx ON memories(owner_id);
+CREATE INDEX IF NOT EXISTS memories_owner_created_idx
+    ON memories(owner_id, created_at DESC, id DESC);
 `
 
-const createHNSWIndexSQL = `
-CREATE INDEX IF NOT EXISTS memories_vector_idx ON memories USING hnsw (vector vector_cosine_ops)
+const vectorDimensionSQL = `
+SELECT atttypmod
+FROM pg_attribute
+WHERE attrelid = 'memories'::regclass
+  AND attname = 'vector'
+  AND NOT attisdropped
 `
 
+// ErrDimensionMismatch is returned when the embedding dimension does not match
+// the dimension of the memories table's vector column.
+var ErrDimensionMismatch = errors.New("pgvector: vector dimension mismatch")
+
 type memoryStore struct {
 	db          *sql.DB
 	embedder    embeddings.Embedding
 	idGenerator IDGenerator
+	dims        int
+	metric      DistanceMetric
+	efSearch    int
+	cast        string
 }
 
 // MemoryStore creates a new PostgreSQL-backed memory store with pgvector for semantic search.
 // It automatically creates the memories table and pgvector extension if they don't exist.
-// The vector dimension is determined from the embedder's model configuration.
+// The vector dimension is determined from the embedder's model configuration,
+// or from [WithDimensions] when set. If the memories table already exists with
+// a different dimension, an error wrapping [ErrDimensionMismatch] is returned.
 func MemoryStore(
 	ctx context.Context,
 	connString string,
@@ -51,44 +70,151 @@ func MemoryStore(
 		opt(&options)
 	}
 
-	db, err := openDB(connString)
+	db, err := openDB(connString, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	dims := embedder.Model().EmbeddingDims
+	store, err := newMemoryStore(ctx, db, embedder, options)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// MemoryStoreFromDB creates a pgvector memory store on an existing database handle.
+// Use this to share one connection pool across session and memory stores.
+// The caller owns db and is responsible for configuring and closing it; pool
+// options such as [WithMaxOpenConns] are ignored.
+func MemoryStoreFromDB(
+	ctx context.Context,
+	db *sql.DB,
+	embedder embeddings.Embedding,
+	opts ...Option,
+) (memory.Store, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return newMemoryStore(ctx, db, embedder, options)
+}
+
+func newMemoryStore(
+	ctx context.Context,
+	db *sql.DB,
+	embedder embeddings.Embedding,
+	options storeOptions,
+) (*memoryStore, error) {
+	if err := options.metric.validate(); err != nil {
+		return nil, err
+	}
+	if err := options.indexType.validate(); err != nil {
+		return nil, err
+	}
+
+	dims := options.dimensions
 	if dims == 0 {
-		dims = 1536
+		dims = embedder.Model().EmbeddingDims
+	}
+	if dims <= 0 {
+		return nil, fmt.Errorf(
+			"embedder model %q does not declare an embedding dimension; use WithDimensions",
+			embedder.Model().ID,
+		)
 	}
 
 	createSQL := fmt.Sprintf(createMemoriesTableSQL, dims)
 	if _, err := db.ExecContext(ctx, createSQL); err != nil {
-		db.Close()
 		return nil, fmt.Errorf("failed to create memories table: %w", err)
 	}
 
-	db.ExecContext(ctx, createHNSWIndexSQL)
+	if err := checkDimensions(ctx, db, dims); err != nil {
+		return nil, err
+	}
+
+	cast, _ := indexCast(dims)
+	if indexSQL := vectorIndexSQL(options, dims); indexSQL != "" {
+		if _, err := db.ExecContext(ctx, indexSQL); err != nil {
+			return nil, fmt.Errorf("failed to create vector index: %w", err)
+		}
+	} else {
+		slog.WarnContext(ctx,
+			"pgvector: too many dimensions to index, searches scan every row",
+			"dimensions", dims,
+			"max_dimensions", maxHalfvecIndexDims,
+		)
+	}
 
 	return &memoryStore{
 		db:          db,
 		embedder:    embedder,
 		idGenerator: options.idGenerator,
+		dims:        dims,
+		metric:      options.metric,
+		efSearch:    efSearch(options),
+		cast:        cast,
 	}, nil
 }
 
+func checkDimensions(ctx context.Context, db *sql.DB, dims int) error {
+	var existing int
+	if err := db.QueryRowContext(ctx, vectorDimensionSQL).
+		Scan(&existing); err != nil {
+		return fmt.Errorf("failed to read vector dimension: %w", err)
+	}
+
+	if existing > 0 && existing != dims {
+		return fmt.Errorf(
+			"%w: memories table has vector(%d) but embedder produces %d dimensions",
+			ErrDimensionMismatch,
+			existing,
+			dims,
+		)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) embed(
+	ctx context.Context,
+	text string,
+) (string, error) {
+	resp, err := s.embedder.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	if len(resp.Embeddings) == 0 {
+		return "", fmt.Errorf("embedder returned no embeddings")
+	}
+
+	vec := resp.Embeddings[0]
+	if len(vec) != s.dims {
+		return "", fmt.Errorf(
+			"%w: got %d dimensions, table expects %d",
+			ErrDimensionMismatch,
+			len(vec),
+			s.dims,
+		)
+	}
+
+	return vectorToString(vec), nil
+}
+
 func (s *memoryStore) Store(
 	ctx context.Context,
 	id string,
 	fact string,
 	metadata map[string]any,
 ) error {
-	resp, err := s.embedder.GenerateEmbeddings(ctx, []string{fact})
+	vectorStr, err := s.embed(ctx, fact)
 	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+		return err
 	}
 
-	vectorStr := vectorToString(resp.Embeddings[0])
-
 	var metadataJSON []byte
 	if metadata != nil {
 		metadataJSON, err = json.Marshal(metadata)
@@ -111,26 +237,56 @@ func (s *memoryStore) Search(
 	query string,
 	limit int,
 ) ([]memory.Entry, error) {
-	resp, err := s.embedder.GenerateEmbeddings(ctx, []string{query})
+	vectorStr, err := s.embed(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		return nil, err
 	}
 
-	vectorStr := vectorToString(resp.Embeddings[0])
-
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, owner_id, content, metadata, created_at, 1 - (vector <=> $1::vector) as score
+	distance := "vector" + s.cast + " " + s.metric.operator() +
+		" $1::vector" + s.cast
+	searchSQL := fmt.Sprintf(`
+		SELECT id, owner_id, content, metadata, created_at, %s as score
 		FROM memories
 		WHERE owner_id = $2
-		ORDER BY vector <=> $1::vector
+		ORDER BY %s
 		LIMIT $3
-	`, vectorStr, id, limit)
+	`, s.metric.scoreExpr(distance), distance)
+
+	if s.efSearch == 0 {
+		rows, err := s.db.QueryContext(ctx, searchSQL, vectorStr, id, limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		return scanEntries(rows)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", s.efSearch),
+	); err != nil {
+		return nil, fmt.Errorf("failed to set hnsw.ef_search: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, searchSQL, vectorStr, id, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return scanEntries(rows)
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
 }
 
 func (s *memoryStore) GetAll(
@@ -153,6 +309,82 @@ func (s *memoryStore) GetAll(
 	return scanEntries(rows)
 }
 
+// GetAllPaged implements [memory.PagedStore] with keyset pagination on
+// (created_at, id), so later pages cost the same as the first however deep
+// they are. The cursor encodes the last entry of the previous page.
+func (s *memoryStore) GetAllPaged(
+	ctx context.Context,
+	id string,
+	cursor string,
+	pageSize int,
+) ([]memory.Entry, string, error) {
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("page size must be positive")
+	}
+
+	query := `
+		SELECT id, owner_id, content, metadata, created_at, 0 as score
+		FROM memories
+		WHERE owner_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	args := []any{id, pageSize + 1}
+	if cursor != "" {
+		createdAt, lastID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = `
+			SELECT id, owner_id, content, metadata, created_at, 0 as score
+			FROM memories
+			WHERE owner_id = $1 AND (created_at, id) < ($3, $4)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		`
+		args = append(args, createdAt, lastID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(entries) <= pageSize {
+		return entries, "", nil
+	}
+	entries = entries[:pageSize]
+	last := entries[pageSize-1]
+	return entries, encodeCursor(last.CreatedAt, last.ID), nil
+}
+
+func encodeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	invalid := fmt.Errorf("%w: %q", memory.ErrInvalidCursor, cursor)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", invalid
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", invalid
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", invalid
+	}
+	return createdAt, id, nil
+}
+
 func (s *memoryStore) Delete(ctx context.Context, memoryID string) error {
 	_, err := s.db.ExecContext(
 		ctx,
@@ -162,19 +394,40 @@ func (s *memoryStore) Delete(ctx context.Context, memoryID string) error {
 	return err
 }
 
+func (s *memoryStore) Count(ctx context.Context, id string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM memories WHERE owner_id = $1",
+		id,
+	).Scan(&n)
+	return n, err
+}
+
+func (s *memoryStore) DeleteAll(ctx context.Context, id string) (int, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM memories WHERE owner_id = $1",
+		id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
 func (s *memoryStore) Update(
 	ctx context.Context,
 	memoryID string,
 	fact string,
 	metadata map[string]any,
 ) error {
-	resp, err := s.embedder.GenerateEmbeddings(ctx, []string{fact})
+	vectorStr, err := s.embed(ctx, fact)
 	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+		return err
 	}
 
-	vectorStr := vectorToString(resp.Embeddings[0])
-
 	var metadataJSON []byte
 	if metadata != nil {
 		metadataJSON, err = json.Marshal(metadata)
@@ -1,12 +1,24 @@
 package pgvector
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // IDGenerator is a function that generates unique IDs for database records.
 type IDGenerator func() string
 
 type storeOptions struct {
-	idGenerator IDGenerator
+	idGenerator     IDGenerator
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxLifetime *time.Duration
+	dimensions      int
+	metric          DistanceMetric
+	indexType       IndexType
+	lists           int
+	indexParams     IndexParams
 }
 
 // Option configures a pgvector store.
@@ -20,8 +32,91 @@ func WithIDGenerator(gen IDGenerator) Option {
 	}
 }
 
+// WithDimensions overrides the vector dimension used for the memories table.
+// Use this when the embedder is configured to return a non-default dimension
+// (for example via the provider's WithDimensions option). By default the
+// dimension is taken from the embedder's model configuration.
+func WithDimensions(dims int) Option {
+	return func(o *storeOptions) {
+		o.dimensions = dims
+	}
+}
+
+// WithDistanceMetric sets the distance metric used for similarity search and
+// the operator class of the vector index. By default, cosine distance is used.
+//
+// The metric is baked into the index at creation time. Changing it on an
+// existing table requires dropping memories_vector_idx so it is rebuilt with
+// the matching operator class.
+func WithDistanceMetric(metric DistanceMetric) Option {
+	return func(o *storeOptions) {
+		o.metric = metric
+	}
+}
+
+// WithIndexType sets the vector index type. By default, an HNSW index is used.
+// Changing the type on an existing table requires dropping memories_vector_idx.
+func WithIndexType(indexType IndexType) Option {
+	return func(o *storeOptions) {
+		o.indexType = indexType
+	}
+}
+
+// WithIVFFlatLists sets the number of inverted lists for an IVFFlat index.
+// Only applies when [WithIndexType] is [IndexIVFFlat]. Default: 100.
+func WithIVFFlatLists(lists int) Option {
+	return func(o *storeOptions) {
+		o.lists = lists
+	}
+}
+
+// WithIndexParams tunes the HNSW index on the vector column. M and
+// EFConstruction are applied when the index is created; EFSearch is set per
+// search with SET LOCAL hnsw.ef_search. Only applies to [IndexHNSW].
+//
+//	pgvector.WithIndexParams(pgvector.IndexParams{
+//	    M:              32,
+//	    EFConstruction: 128,
+//	    EFSearch:       100,
+//	})
+func WithIndexParams(params IndexParams) Option {
+	return func(o *storeOptions) {
+		o.indexParams = params
+	}
+}
+
+// WithMaxOpenConns sets the maximum number of open connections in the pool.
+// See [sql.DB.SetMaxOpenConns]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithMaxOpenConns(n int) Option {
+	return func(o *storeOptions) {
+		o.maxOpenConns = &n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections in the pool.
+// See [sql.DB.SetMaxIdleConns]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithMaxIdleConns(n int) Option {
+	return func(o *storeOptions) {
+		o.maxIdleConns = &n
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused.
+// See [sql.DB.SetConnMaxLifetime]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *storeOptions) {
+		o.connMaxLifetime = &d
+	}
+}
+
 func defaultOptions() storeOptions {
 	return storeOptions{
+		metric:    DistanceCosine,
+		indexType: IndexHNSW,
+		lists:     100,
 		idGenerator: func() string {
 			return uuid.New().String()
 		},
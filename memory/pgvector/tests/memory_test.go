@@ -0,0 +1,357 @@
+package pgvector_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/embeddings"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/memory/pgvector"
+	"github.com/joakimcarlsson/ai/model"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// letterEmbedder embeds a text as the one-hot vector of its first byte, so
+// "apple pie" is identical to the query "apple" and orthogonal to
+// "car engine" under every distance metric.
+type letterEmbedder struct {
+	// dims is the dimension the model declares.
+	dims int
+	// out overrides the length of the returned vectors when set.
+	out int
+}
+
+func (e *letterEmbedder) GenerateEmbeddings(
+	_ context.Context,
+	texts []string,
+	_ ...string,
+) (*embeddings.EmbeddingResponse, error) {
+	n := e.dims
+	if e.out > 0 {
+		n = e.out
+	}
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vecs[i] = make([]float32, n)
+		vecs[i][int(text[0])%n] = 1
+	}
+	return &embeddings.EmbeddingResponse{Embeddings: vecs}, nil
+}
+
+func (e *letterEmbedder) GenerateMultimodalEmbeddings(
+	context.Context,
+	[]embeddings.MultimodalInput,
+	...string,
+) (*embeddings.EmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (e *letterEmbedder) GenerateContextualizedEmbeddings(
+	context.Context,
+	[][]string,
+	...string,
+) (*embeddings.ContextualizedEmbeddingResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (e *letterEmbedder) Model() model.EmbeddingModel {
+	return model.EmbeddingModel{
+		ID:            "letter",
+		APIModel:      "letter",
+		EmbeddingDims: e.dims,
+	}
+}
+
+var unsafeSchemaChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// testDB returns a connection whose search path starts at a schema private
+// to the calling test, so each test gets its own memories table. Tests are
+// skipped unless PGVECTOR_DSN points at a PostgreSQL server with the
+// pgvector extension available.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("PGVECTOR_DSN not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := "pgv_" + unsafeSchemaChars.ReplaceAllString(
+		strings.ToLower(t.Name()),
+		"_",
+	)
+	ctx := context.Background()
+	_, err = admin.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS vector")
+	require.NoError(t, err)
+	_, err = admin.ExecContext(ctx, fmt.Sprintf(
+		`DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s`,
+		schema,
+	))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = admin.ExecContext(
+			context.Background(),
+			"DROP SCHEMA "+schema+" CASCADE",
+		)
+	})
+
+	param := "search_path=" + schema + ",public"
+	switch {
+	case !strings.Contains(dsn, "://"):
+		dsn += " " + param
+	case strings.Contains(dsn, "?"):
+		dsn += "&" + param
+	default:
+		dsn += "?" + param
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newStore(
+	t *testing.T,
+	db *sql.DB,
+	embedder embeddings.Embedding,
+	opts ...pgvector.Option,
+) memory.Store {
+	t.Helper()
+	store, err := pgvector.MemoryStoreFromDB(
+		context.Background(),
+		db,
+		embedder,
+		opts...,
+	)
+	require.NoError(t, err)
+	return store
+}
+
+// vectorIndex returns the definition of the vector index, or "" when the
+// store created none.
+func vectorIndex(t *testing.T, db *sql.DB) string {
+	t.Helper()
+	var def string
+	err := db.QueryRowContext(context.Background(), `
+		SELECT indexdef FROM pg_indexes
+		WHERE schemaname = current_schema()
+		  AND indexname = 'memories_vector_idx'
+	`).Scan(&def)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ""
+	}
+	require.NoError(t, err)
+	return def
+}
+
+// assertSearch stores facts for two owners and checks that a search returns
+// only the owner's memories, the closest first.
+func assertSearch(t *testing.T, store memory.Store) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, store.Store(ctx, "alice", "car engine", nil))
+	require.NoError(t, store.Store(ctx, "alice", "apple pie", nil))
+	require.NoError(t, store.Store(ctx, "bob", "apple tart", nil))
+
+	results, err := store.Search(ctx, "alice", "apple", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "apple pie", results[0].Content)
+	assert.Equal(t, "car engine", results[1].Content)
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+func TestMemoryStore_MetricsAndIndexTypes(t *testing.T) {
+	opclasses := map[pgvector.DistanceMetric]string{
+		pgvector.DistanceCosine:       "vector_cosine_ops",
+		pgvector.DistanceL2:           "vector_l2_ops",
+		pgvector.DistanceInnerProduct: "vector_ip_ops",
+	}
+	for metric, opclass := range opclasses {
+		for _, index := range []pgvector.IndexType{
+			pgvector.IndexHNSW,
+			pgvector.IndexIVFFlat,
+		} {
+			t.Run(string(metric)+"_"+string(index), func(t *testing.T) {
+				db := testDB(t)
+				store := newStore(t, db, &letterEmbedder{dims: 8},
+					pgvector.WithDistanceMetric(metric),
+					pgvector.WithIndexType(index),
+					pgvector.WithIVFFlatLists(1),
+				)
+
+				def := vectorIndex(t, db)
+				assert.Contains(t, def, "USING "+string(index))
+				assert.Contains(t, def, opclass)
+				assertSearch(t, store)
+			})
+		}
+	}
+}
+
+func TestMemoryStore_HNSWIndexParams(t *testing.T) {
+	db := testDB(t)
+	store := newStore(t, db, &letterEmbedder{dims: 8},
+		pgvector.WithIndexParams(pgvector.IndexParams{
+			M:              8,
+			EFConstruction: 32,
+			EFSearch:       50,
+		}),
+	)
+
+	def := vectorIndex(t, db)
+	assert.Contains(t, def, "m='8'")
+	assert.Contains(t, def, "ef_construction='32'")
+	assertSearch(t, store)
+}
+
+func TestMemoryStore_HalfvecIndexAbove2000Dims(t *testing.T) {
+	db := testDB(t)
+	store := newStore(t, db, &letterEmbedder{dims: 3072})
+
+	def := vectorIndex(t, db)
+	assert.Contains(t, def, "halfvec(3072)")
+	assert.Contains(t, def, "halfvec_cosine_ops")
+	assertSearch(t, store)
+}
+
+func TestMemoryStore_NoIndexAbove4000Dims(t *testing.T) {
+	db := testDB(t)
+	store := newStore(t, db, &letterEmbedder{dims: 4096})
+
+	assert.Empty(t, vectorIndex(t, db))
+	assertSearch(t, store)
+}
+
+func TestMemoryStore_DimensionsFromOption(t *testing.T) {
+	db := testDB(t)
+	store := newStore(
+		t,
+		db,
+		&letterEmbedder{out: 6},
+		pgvector.WithDimensions(6),
+	)
+
+	assertSearch(t, store)
+}
+
+func TestMemoryStore_ExistingTableDimensionMismatch(t *testing.T) {
+	db := testDB(t)
+	newStore(t, db, &letterEmbedder{dims: 8})
+
+	_, err := pgvector.MemoryStoreFromDB(
+		context.Background(),
+		db,
+		&letterEmbedder{dims: 16},
+	)
+	assert.ErrorIs(t, err, pgvector.ErrDimensionMismatch)
+
+	_, err = pgvector.MemoryStoreFromDB(
+		context.Background(),
+		db,
+		&letterEmbedder{dims: 16},
+		pgvector.WithDimensions(8),
+	)
+	assert.NoError(t, err)
+}
+
+func TestMemoryStore_EmbeddingDimensionMismatch(t *testing.T) {
+	db := testDB(t)
+	store := newStore(t, db, &letterEmbedder{dims: 8, out: 4})
+	ctx := context.Background()
+
+	err := store.Store(ctx, "alice", "apple pie", nil)
+	assert.ErrorIs(t, err, pgvector.ErrDimensionMismatch)
+
+	_, err = store.Search(ctx, "alice", "apple", 5)
+	assert.ErrorIs(t, err, pgvector.ErrDimensionMismatch)
+}
+
+func TestMemoryStore_GetAllPaged(t *testing.T) {
+	db := testDB(t)
+	store := newStore(t, db, &letterEmbedder{dims: 8})
+	ctx := context.Background()
+
+	for i := range 5 {
+		fact := fmt.Sprintf("fact %d", i)
+		require.NoError(t, store.Store(ctx, "alice", fact, nil))
+	}
+	require.NoError(t, store.Store(ctx, "bob", "other", nil))
+
+	var got []memory.Entry
+	cursor, pages := "", 0
+	for {
+		page, next, err := memory.GetAllPaged(ctx, store, "alice", cursor, 2)
+		require.NoError(t, err)
+		got = append(got, page...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, 3, pages)
+	require.Len(t, got, 5)
+	seen := map[string]bool{}
+	for i, e := range got {
+		assert.Equal(t, "alice", e.OwnerID)
+		assert.False(t, seen[e.ID], "entry %s returned twice", e.ID)
+		seen[e.ID] = true
+		if i > 0 {
+			assert.False(t, e.CreatedAt.After(got[i-1].CreatedAt))
+		}
+	}
+	assert.Equal(t, "fact 4", got[0].Content)
+
+	_, _, err := memory.GetAllPaged(ctx, store, "alice", "not-a-cursor", 2)
+	assert.ErrorIs(t, err, memory.ErrInvalidCursor)
+}
+
+func TestMemoryStore_RejectsInvalidOptions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		embedder embeddings.Embedding
+		opts     []pgvector.Option
+	}{
+		{
+			name:     "metric",
+			embedder: &letterEmbedder{dims: 8},
+			opts: []pgvector.Option{
+				pgvector.WithDistanceMetric("manhattan"),
+			},
+		},
+		{
+			name:     "index",
+			embedder: &letterEmbedder{dims: 8},
+			opts:     []pgvector.Option{pgvector.WithIndexType("btree")},
+		},
+		{
+			name:     "dimensions",
+			embedder: &letterEmbedder{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pgvector.MemoryStoreFromDB(
+				ctx,
+				nil,
+				tt.embedder,
+				tt.opts...,
+			)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -38,12 +38,29 @@
 //	    postgres.WithIDGenerator(snowflakeID),
 //	)
 //
+// # Connection Pool
+//
+// Pool settings are applied to the connection opened by [SessionStore]:
+//
+//	store, err := postgres.SessionStore(ctx, connStr,
+//	    postgres.WithMaxOpenConns(20),
+//	    postgres.WithMaxIdleConns(5),
+//	    postgres.WithConnMaxLifetime(30*time.Minute),
+//	)
+//
+// To share one pool across the session store and a pgvector memory store,
+// open the *sql.DB yourself and pass it to [SessionStoreFromDB]:
+//
+//	db, err := sql.Open("postgres", connStr)
+//	sessions, err := postgres.SessionStoreFromDB(ctx, db)
+//	memories, err := pgvector.MemoryStoreFromDB(ctx, db, embedder)
+//
 // # Database Schema
 //
 // The package creates two tables:
 //
 //   - sessions: Stores session metadata (id, created_at)
-//   - messages: Stores messages with foreign key to sessions (id, session_id, role, parts, model, created_at)
+//   - messages: Stores messages with foreign key to sessions (id, session_id, role, parts, parts_gz, model, created_at)
 //
 // Messages are stored as JSONB for flexible content part serialization.
 package postgres
@@ -1,12 +1,20 @@
 package postgres
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // IDGenerator is a function that generates unique IDs for database records.
 type IDGenerator func() string
 
 type storeOptions struct {
-	idGenerator IDGenerator
+	idGenerator     IDGenerator
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxLifetime *time.Duration
+	compress        bool
 }
 
 // Option configures a postgres store.
@@ -20,6 +28,45 @@ func WithIDGenerator(gen IDGenerator) Option {
 	}
 }
 
+// WithMaxOpenConns sets the maximum number of open connections in the pool.
+// See [sql.DB.SetMaxOpenConns]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithMaxOpenConns(n int) Option {
+	return func(o *storeOptions) {
+		o.maxOpenConns = &n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections in the pool.
+// See [sql.DB.SetMaxIdleConns]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithMaxIdleConns(n int) Option {
+	return func(o *storeOptions) {
+		o.maxIdleConns = &n
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused.
+// See [sql.DB.SetConnMaxLifetime]. Ignored when the store is created from an
+// existing *sql.DB.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *storeOptions) {
+		o.connMaxLifetime = &d
+	}
+}
+
+// WithCompression stores session message parts gzip-compressed in a BYTEA
+// column instead of JSONB. Rows written without compression remain readable,
+// so it can be enabled on an existing database. A messages table created
+// before compression existed is altered to add the column, which needs a role
+// allowed to alter it; stores without this option never alter the table.
+// Compressed parts cannot be queried with JSONB operators.
+func WithCompression() Option {
+	return func(o *storeOptions) {
+		o.compress = true
+	}
+}
+
 func defaultOptions() storeOptions {
 	return storeOptions{
 		idGenerator: func() string {
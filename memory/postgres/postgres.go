@@ -21,13 +21,23 @@ import (
 	_ "github.com/lib/pq" // Register the postgres database/sql driver.
 )
 
-// openDB opens a connection to the PostgreSQL database.
-func openDB(connString string) (*sql.DB, error) {
+// openDB opens a connection to the PostgreSQL database and applies the pool settings.
+func openDB(connString string, options storeOptions) (*sql.DB, error) {
 	db, err := sql.Open("postgres", connString)
 	if err != nil {
 		return nil, err
 	}
 
+	if options.maxOpenConns != nil {
+		db.SetMaxOpenConns(*options.maxOpenConns)
+	}
+	if options.maxIdleConns != nil {
+		db.SetMaxIdleConns(*options.maxIdleConns)
+	}
+	if options.connMaxLifetime != nil {
+		db.SetConnMaxLifetime(*options.connMaxLifetime)
+	}
+
 	if err := db.Ping(); err != nil {
 		db.Close()
 		return nil, err
@@ -1,10 +1,16 @@
 package postgres
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/session"
@@ -21,16 +27,44 @@ CREATE TABLE IF NOT EXISTS messages (
     id TEXT PRIMARY KEY,
     session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
     role TEXT NOT NULL,
-    parts JSONB NOT NULL,
+    parts JSONB,
+    parts_gz BYTEA,
     model TEXT,
     created_at BIGINT NOT NULL
 );
 
-CREATE INDEX IF NOT EXISTS messages_session_idx ON messages(session_id, created_at)`
+CREATE INDEX IF NOT EXISTS messages_session_idx ON messages(session_id, created_at);
+
+CREATE INDEX IF NOT EXISTS messages_session_page_idx
+    ON messages(session_id, created_at, id)`
+
+const tablesExistSQL = `
+SELECT to_regclass('sessions') IS NOT NULL, to_regclass('messages') IS NOT NULL`
+
+const hasCompressionColumnSQL = `
+SELECT EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = current_schema()
+        AND table_name = 'messages'
+        AND column_name = 'parts_gz'
+)`
+
+const migrateMessagesCompressionSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parts_gz BYTEA;
+ALTER TABLE messages ALTER COLUMN parts DROP NOT NULL`
+
+// partsColumns selects the message parts of a messages table; legacyParts
+// is used on tables created before compression, which lack parts_gz.
+const (
+	partsColumns = "parts, parts_gz"
+	legacyParts  = "parts, NULL::bytea AS parts_gz"
+)
 
 type sessionStore struct {
 	db          *sql.DB
 	idGenerator IDGenerator
+	compress    bool
+	parts       string
 }
 
 // SessionStore creates a new PostgreSQL-backed session store.
@@ -45,22 +79,108 @@ func SessionStore(
 		opt(&options)
 	}
 
-	db, err := openDB(connString)
+	db, err := openDB(connString, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if _, err := db.ExecContext(ctx, createSessionsTableSQL); err != nil {
+	store, err := newSessionStore(ctx, db, options)
+	if err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create sessions table: %w", err)
+		return nil, err
 	}
 
-	if _, err := db.ExecContext(ctx, createMessagesTableSQL); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to create messages table: %w", err)
+	return store, nil
+}
+
+// SessionStoreFromDB creates a PostgreSQL-backed session store on an existing
+// database handle. Use this to share one connection pool across session and
+// memory stores. The caller owns db and is responsible for configuring and
+// closing it; pool options such as [WithMaxOpenConns] are ignored.
+func SessionStoreFromDB(
+	ctx context.Context,
+	db *sql.DB,
+	opts ...Option,
+) (session.Store, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
 	}
 
-	return &sessionStore{db: db, idGenerator: options.idGenerator}, nil
+	return newSessionStore(ctx, db, options)
+}
+
+func newSessionStore(
+	ctx context.Context,
+	db *sql.DB,
+	options storeOptions,
+) (*sessionStore, error) {
+	var sessions, messages bool
+	err := db.QueryRowContext(ctx, tablesExistSQL).Scan(&sessions, &messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect session tables: %w", err)
+	}
+
+	if !sessions {
+		if _, err := db.ExecContext(ctx, createSessionsTableSQL); err != nil {
+			return nil, fmt.Errorf("failed to create sessions table: %w", err)
+		}
+	}
+
+	if !messages {
+		if _, err := db.ExecContext(ctx, createMessagesTableSQL); err != nil {
+			return nil, fmt.Errorf("failed to create messages table: %w", err)
+		}
+	}
+
+	parts, err := migrateCompression(ctx, db, options.compress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionStore{
+		db:          db,
+		idGenerator: options.idGenerator,
+		compress:    options.compress,
+		parts:       parts,
+	}, nil
+}
+
+// migrateCompression adds the parts_gz column to a messages table created
+// before compression, only when compress is set, so stores that do not
+// compress never need to alter the schema. It returns the columns to select
+// the message parts with.
+func migrateCompression(
+	ctx context.Context,
+	db *sql.DB,
+	compress bool,
+) (string, error) {
+	var migrated bool
+	err := db.QueryRowContext(ctx, hasCompressionColumnSQL).Scan(&migrated)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect messages table: %w", err)
+	}
+	if migrated {
+		return partsColumns, nil
+	}
+	if !compress {
+		return legacyParts, nil
+	}
+	_, err = db.ExecContext(ctx, migrateMessagesCompressionSQL)
+	if err != nil {
+		return "", fmt.Errorf("failed to migrate messages table: %w", err)
+	}
+	return partsColumns, nil
+}
+
+func (s *sessionStore) session(id string) *pgSession {
+	return &pgSession{
+		db:          s.db,
+		id:          id,
+		idGenerator: s.idGenerator,
+		compress:    s.compress,
+		parts:       s.parts,
+	}
 }
 
 func (s *sessionStore) Exists(ctx context.Context, id string) (bool, error) {
@@ -81,25 +201,123 @@ func (s *sessionStore) Create(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
-	return &pgSession{db: s.db, id: id, idGenerator: s.idGenerator}, nil
+	return s.session(id), nil
 }
 
 func (s *sessionStore) Load(
 	_ context.Context,
 	id string,
 ) (session.Session, error) {
-	return &pgSession{db: s.db, id: id, idGenerator: s.idGenerator}, nil
+	return s.session(id), nil
 }
 
 func (s *sessionStore) Delete(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1", id)
+	reserved := session.ReservedIDs(id)
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM sessions WHERE id IN ($1, $2, $3)",
+		id, reserved[0], reserved[1],
+	)
 	return err
 }
 
+// GetAllPaged implements [session.PagedStore] with keyset pagination on
+// (created_at, id), so later pages cost the same as the first however deep
+// they are. The cursor encodes the last message of the previous page.
+func (s *sessionStore) GetAllPaged(
+	ctx context.Context,
+	id string,
+	cursor string,
+	pageSize int,
+) ([]message.Message, string, error) {
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("page size must be positive")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, created_at, %s
+		FROM messages
+		WHERE session_id = $1
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2
+	`, s.parts)
+	args := []any{id, pageSize + 1}
+	if cursor != "" {
+		createdAt, lastID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = fmt.Sprintf(`
+			SELECT id, created_at, %s
+			FROM messages
+			WHERE session_id = $1 AND (created_at, id) > ($3, $4)
+			ORDER BY created_at ASC, id ASC
+			LIMIT $2
+		`, s.parts)
+		args = append(args, createdAt, lastID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	messages := []message.Message{}
+	var lastID string
+	var lastCreatedAt int64
+	for rows.Next() {
+		if len(messages) == pageSize {
+			return messages, encodeCursor(lastCreatedAt, lastID), rows.Close()
+		}
+
+		var msgJSON, msgGzip []byte
+		if err := rows.Scan(
+			&lastID,
+			&lastCreatedAt,
+			&msgJSON,
+			&msgGzip,
+		); err != nil {
+			return nil, "", err
+		}
+
+		msg, err := decodeMessage(msgJSON, msgGzip)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, "", rows.Err()
+}
+
+func encodeCursor(createdAt int64, id string) string {
+	raw := strconv.FormatInt(createdAt, 10) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (int64, string, error) {
+	invalid := fmt.Errorf("%w: %q", session.ErrInvalidCursor, cursor)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", invalid
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return 0, "", invalid
+	}
+	createdAt, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, "", invalid
+	}
+	return createdAt, id, nil
+}
+
 type pgSession struct {
 	db          *sql.DB
 	id          string
 	idGenerator IDGenerator
+	compress    bool
+	parts       string
 }
 
 func (s *pgSession) ID() string {
@@ -110,22 +328,22 @@ func (s *pgSession) GetMessages(
 	ctx context.Context,
 	limit *int,
 ) ([]message.Message, error) {
-	query := `
-		SELECT parts
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM messages
 		WHERE session_id = $1
 		ORDER BY created_at ASC
-	`
+	`, s.parts)
 	if limit != nil {
 		query = fmt.Sprintf(`
-			SELECT parts FROM (
-				SELECT parts, created_at
+			SELECT parts, parts_gz FROM (
+				SELECT %s, created_at
 				FROM messages
 				WHERE session_id = $1
 				ORDER BY created_at DESC
 				LIMIT %d
 			) sub ORDER BY created_at ASC
-		`, *limit)
+		`, s.parts, *limit)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, s.id)
@@ -136,14 +354,14 @@ func (s *pgSession) GetMessages(
 
 	var messages []message.Message
 	for rows.Next() {
-		var msgJSON []byte
+		var msgJSON, msgGzip []byte
 
-		if err := rows.Scan(&msgJSON); err != nil {
+		if err := rows.Scan(&msgJSON, &msgGzip); err != nil {
 			return nil, err
 		}
 
-		var msg message.Message
-		if err := json.Unmarshal(msgJSON, &msg); err != nil {
+		msg, err := decodeMessage(msgJSON, msgGzip)
+		if err != nil {
 			return nil, err
 		}
 
@@ -160,17 +378,80 @@ func (s *pgSession) GetMessages(
 func (s *pgSession) AddMessages(
 	ctx context.Context,
 	msgs []message.Message,
+) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertMessages(ctx, tx, msgs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetMessages implements [session.Replacer], replacing the session's
+// messages in a single transaction.
+func (s *pgSession) SetMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM messages WHERE session_id = $1",
+		s.id,
+	)
+	if err != nil {
+		return err
+	}
+	if err := s.insertMessages(ctx, tx, msgs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *pgSession) insertMessages(
+	ctx context.Context,
+	tx *sql.Tx,
+	msgs []message.Message,
 ) error {
 	for _, msg := range msgs {
-		msgJSON, err := json.Marshal(msg)
+		msgJSON, msgGzip, err := s.encodeMessage(msg)
 		if err != nil {
 			return err
 		}
 
-		_, err = s.db.ExecContext(ctx, `
+		query := `
 			INSERT INTO messages (id, session_id, role, parts, model, created_at)
 			VALUES ($1, $2, $3, $4, $5, $6)
-		`, s.idGenerator(), s.id, string(msg.Role), msgJSON, string(msg.Model), msg.CreatedAt)
+		`
+		args := []any{
+			s.idGenerator(),
+			s.id,
+			string(msg.Role),
+			msgJSON,
+			string(msg.Model),
+			msg.CreatedAt,
+		}
+		if s.parts == partsColumns {
+			query = `
+				INSERT INTO messages (id, session_id, role, parts, model, created_at, parts_gz)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`
+			args = append(args, msgGzip)
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
 			return err
 		}
@@ -186,15 +467,15 @@ func (s *pgSession) PopMessage(ctx context.Context) (*message.Message, error) {
 	defer tx.Rollback()
 
 	var msgID string
-	var msgJSON []byte
+	var msgJSON, msgGzip []byte
 
-	err = tx.QueryRowContext(ctx, `
-		SELECT id, parts
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT id, %s
 		FROM messages
 		WHERE session_id = $1
 		ORDER BY created_at DESC
 		LIMIT 1
-	`, s.id).Scan(&msgID, &msgJSON)
+	`, s.parts), s.id).Scan(&msgID, &msgJSON, &msgGzip)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -212,8 +493,8 @@ func (s *pgSession) PopMessage(ctx context.Context) (*message.Message, error) {
 		return nil, err
 	}
 
-	var msg message.Message
-	if err := json.Unmarshal(msgJSON, &msg); err != nil {
+	msg, err := decodeMessage(msgJSON, msgGzip)
+	if err != nil {
 		return nil, err
 	}
 
@@ -228,3 +509,44 @@ func (s *pgSession) Clear(ctx context.Context) error {
 	)
 	return err
 }
+
+func (s *pgSession) encodeMessage(
+	msg message.Message,
+) (msgJSON, msgGzip []byte, err error) {
+	data, err := message.MarshalUnescaped(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.compress {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, nil, fmt.Errorf("failed to compress message: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to compress message: %w", err)
+	}
+	return nil, buf.Bytes(), nil
+}
+
+func decodeMessage(msgJSON, msgGzip []byte) (message.Message, error) {
+	var msg message.Message
+	if msgGzip != nil {
+		zr, err := gzip.NewReader(bytes.NewReader(msgGzip))
+		if err != nil {
+			return msg, fmt.Errorf("failed to decompress message: %w", err)
+		}
+		defer zr.Close()
+		msgJSON, err = io.ReadAll(zr)
+		if err != nil {
+			return msg, fmt.Errorf("failed to decompress message: %w", err)
+		}
+	}
+	if err := json.Unmarshal(msgJSON, &msg); err != nil {
+		return msg, err
+	}
+	return msg, nil
+}
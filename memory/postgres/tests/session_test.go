@@ -3,6 +3,7 @@ package postgres_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/joakimcarlsson/ai/memory/postgres"
 	"github.com/joakimcarlsson/ai/message"
 	"github.com/joakimcarlsson/ai/session"
+	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -655,6 +657,137 @@ func TestPostgresSession_ConcurrentAddMessages(t *testing.T) {
 	assert.Len(t, got, writers*perWriter)
 }
 
+func TestPostgresSession_ConcurrentBatchAddMessages(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	s, err := store.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	const writers = 8
+	const batches = 5
+	const batchSize = 4
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := range writers {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			loaded, err := store.Load(ctx, sessionID(t))
+			if err != nil {
+				errs <- err
+				return
+			}
+			for b := range batches {
+				batch := make([]message.Message, batchSize)
+				for i := range batch {
+					batch[i] = message.NewUserMessage(
+						fmt.Sprintf("w%d-b%d-m%d", w, b, i),
+					)
+				}
+				if err := loaded.AddMessages(ctx, batch); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	got, err := s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, writers*batches*batchSize)
+
+	seen := make(map[string]bool, len(got))
+	for _, msg := range got {
+		text := msg.Content().Text
+		assert.False(t, seen[text], "duplicate message %q", text)
+		seen[text] = true
+	}
+}
+
+func TestPostgresSession_AddMessagesIsAtomic(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+	gen := func() string {
+		calls++
+		if calls >= 2 {
+			return t.Name() + "-dup"
+		}
+		return fmt.Sprintf("%s-%d", t.Name(), calls)
+	}
+	store := newStore(t, postgres.WithIDGenerator(gen))
+
+	s, err := store.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	err = s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("a"),
+		message.NewUserMessage("b"),
+		message.NewUserMessage("c"),
+	})
+	require.Error(t, err, "colliding ids should fail the batch")
+
+	got, err := s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got, "a failed batch must not leave partial writes")
+}
+
+func TestPostgresStore_WithPoolOptions(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t,
+		postgres.WithMaxOpenConns(2),
+		postgres.WithMaxIdleConns(1),
+		postgres.WithConnMaxLifetime(time.Minute),
+	)
+
+	s, err := store.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	require.NoError(t, s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("hello"),
+	}))
+
+	got, err := s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestPostgresStore_SessionStoreFromDB(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", sharedConnStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := postgres.SessionStoreFromDB(ctx, db)
+	require.NoError(t, err)
+
+	s, err := store.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	require.NoError(t, s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("shared pool"),
+	}))
+
+	other := newStore(t)
+	loaded, err := other.Load(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	got, err := loaded.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "shared pool", got[0].Content().Text)
+
+	require.NoError(t, db.PingContext(ctx), "caller-owned db must stay open")
+}
+
 func TestPostgresSession_GetMessagesNegativeLimit(t *testing.T) {
 	ctx := context.Background()
 	store := newStore(t)
@@ -706,6 +839,143 @@ func TestPostgresSession_LargeContentRoundTrip(t *testing.T) {
 	assert.Equal(t, large, got[0].Content().Text)
 }
 
+func TestPostgresSession_CompressionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t, postgres.WithCompression())
+
+	s, err := store.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+
+	large := strings.Repeat("tool output line\n", 50_000)
+	msgs := []message.Message{
+		message.NewUserMessage("run the report"),
+		message.NewMessage(message.Tool, []message.ContentPart{
+			message.ToolResult{
+				ToolCallID: "call-1",
+				Name:       "report",
+				Content:    large,
+			},
+		}),
+	}
+	require.NoError(t, s.AddMessages(ctx, msgs))
+
+	db, err := sql.Open("postgres", sharedConnStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	var jsonRows, gzipBytes int
+	require.NoError(t, db.QueryRowContext(ctx, `
+		SELECT COUNT(parts), COALESCE(SUM(octet_length(parts_gz)), 0)
+		FROM messages WHERE session_id = $1
+	`, sessionID(t)).Scan(&jsonRows, &gzipBytes))
+	assert.Zero(t, jsonRows, "compressed rows must not populate parts")
+	assert.Less(t, gzipBytes, len(large)/10)
+
+	got, err := s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "run the report", got[0].Content().Text)
+	require.Len(t, got[1].ToolResults(), 1)
+	assert.Equal(t, large, got[1].ToolResults()[0].Content)
+
+	popped, err := s.PopMessage(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, popped)
+	assert.Equal(t, large, popped.ToolResults()[0].Content)
+}
+
+func TestPostgresSession_CompressionReadsUncompressedRows(t *testing.T) {
+	ctx := context.Background()
+	id := sessionID(t)
+
+	plain, err := newStore(t).Create(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, plain.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("written before compression"),
+	}))
+
+	compressed, err := newStore(t, postgres.WithCompression()).Load(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, compressed.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("written after compression"),
+	}))
+
+	got, err := compressed.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "written before compression", got[0].Content().Text)
+	assert.Equal(t, "written after compression", got[1].Content().Text)
+}
+
+func TestPostgresSession_LegacyTableMigratedOnlyForCompression(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("postgres", sharedConnStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.ExecContext(ctx, `
+		CREATE SCHEMA legacy;
+		CREATE TABLE legacy.sessions (
+			id TEXT PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW()
+		);
+		CREATE TABLE legacy.messages (
+			id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL
+				REFERENCES legacy.sessions(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			parts JSONB NOT NULL,
+			model TEXT,
+			created_at BIGINT NOT NULL
+		)`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(ctx, "DROP SCHEMA legacy CASCADE")
+	})
+	legacyConnStr := sharedConnStr + "&search_path=legacy"
+	hasColumn := func() bool {
+		var exists bool
+		require.NoError(t, db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = 'legacy'
+					AND table_name = 'messages'
+					AND column_name = 'parts_gz'
+			)`).Scan(&exists))
+		return exists
+	}
+
+	plain, err := postgres.SessionStore(ctx, legacyConnStr)
+	require.NoError(t, err)
+	s, err := plain.Create(ctx, sessionID(t))
+	require.NoError(t, err)
+	require.NoError(t, s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("written to a legacy table"),
+	}))
+	got, err := s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.False(t, hasColumn(), "store without compression altered the table")
+
+	compressed, err := postgres.SessionStore(
+		ctx,
+		legacyConnStr,
+		postgres.WithCompression(),
+	)
+	require.NoError(t, err)
+	assert.True(t, hasColumn())
+	s, err = compressed.Load(ctx, sessionID(t))
+	require.NoError(t, err)
+	require.NoError(t, s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("written compressed"),
+	}))
+	got, err = s.GetMessages(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "written to a legacy table", got[0].Content().Text)
+	assert.Equal(t, "written compressed", got[1].Content().Text)
+}
+
 func TestPostgresSession_MultipleToolCallsInOneMessage(t *testing.T) {
 	ctx := context.Background()
 	store := newStore(t)
@@ -870,3 +1140,48 @@ func TestPostgresSession_ContextCancellation(t *testing.T) {
 	_, err = s.PopMessage(canceled)
 	require.Error(t, err)
 }
+
+func TestPostgresStore_GetAllPaged(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+	id := sessionID(t)
+
+	s, err := store.Create(ctx, id)
+	require.NoError(t, err)
+	var msgs []message.Message
+	for i := range 5 {
+		msg := message.NewUserMessage(fmt.Sprintf("msg-%d", i))
+		msg.CreatedAt = int64(100 + i/2)
+		msgs = append(msgs, msg)
+	}
+	require.NoError(t, s.AddMessages(ctx, msgs))
+
+	paged, ok := store.(session.PagedStore)
+	require.True(t, ok, "postgres store should implement session.PagedStore")
+
+	var got []string
+	cursor := ""
+	pages := 0
+	for {
+		page, next, err := paged.GetAllPaged(ctx, id, cursor, 2)
+		require.NoError(t, err)
+		for _, m := range page {
+			got = append(got, m.Content().Text)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.ElementsMatch(
+		t,
+		[]string{"msg-0", "msg-1", "msg-2", "msg-3", "msg-4"},
+		got,
+	)
+
+	_, _, err = paged.GetAllPaged(ctx, id, "not-a-cursor", 2)
+	assert.ErrorIs(t, err, session.ErrInvalidCursor)
+}
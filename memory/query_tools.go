@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const (
+	defaultQueryLimit = 20
+	maxQueryEntries   = 1000
+)
+
+var metadataFilterParam = map[string]any{
+	"type":                 "object",
+	"additionalProperties": map[string]any{"type": "string"},
+	"description":          "Only include memories whose metadata has all of these key/value pairs, e.g. {\"category\": \"health\"}",
+}
+
+// QueryTools returns tools the LLM can use to inspect memory beyond
+// recall_memories: list_memories, count_memories, and search_memories. All
+// three accept a metadata filter. [Store] has no filtered queries, so the
+// tools fetch up to 1000 entries from the store and filter them in process.
+func QueryTools(store Store, memoryID string) []tool.BaseTool {
+	return []tool.BaseTool{
+		&listMemoriesTool{store: store, memoryID: memoryID},
+		&countMemoriesTool{store: store, memoryID: memoryID},
+		&searchMemoriesTool{store: store, memoryID: memoryID},
+	}
+}
+
+// MatchesMetadata reports whether entry has every key in filter with the
+// given value. Non-string metadata values are compared by their formatted
+// string form.
+func MatchesMetadata(entry Entry, filter map[string]string) bool {
+	for k, want := range filter {
+		got, ok := entry.Metadata[k]
+		if !ok || fmt.Sprint(got) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func filterEntries(entries []Entry, filter map[string]string) []Entry {
+	if len(filter) == 0 {
+		return entries
+	}
+	var matched []Entry
+	for _, e := range entries {
+		if MatchesMetadata(e, filter) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+func formatEntries(entries []Entry) string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		line := fmt.Sprintf("- [id:%s] %s", e.ID, e.Content)
+		if len(e.Metadata) > 0 {
+			var pairs []string
+			for _, k := range slices.Sorted(maps.Keys(e.Metadata)) {
+				pairs = append(pairs, fmt.Sprintf("%s=%v", k, e.Metadata[k]))
+			}
+			line += " {" + strings.Join(pairs, ", ") + "}"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func queryLimit(limit int) int {
+	if limit <= 0 {
+		return defaultQueryLimit
+	}
+	return min(limit, maxQueryEntries)
+}
+
+type listMemoriesTool struct {
+	store    Store
+	memoryID string
+}
+
+func (t *listMemoriesTool) Info() tool.Info {
+	return tool.Info{
+		Name:        "list_memories",
+		Description: "List stored memories about the user, optionally filtered by metadata. Use to review everything remembered rather than searching for something specific.",
+		Parameters: map[string]any{
+			"metadata": metadataFilterParam,
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of memories to list (default 20)",
+			},
+		},
+	}
+}
+
+func (t *listMemoriesTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input struct {
+		Metadata map[string]string `json:"metadata"`
+		Limit    int               `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+
+	entries, err := t.store.GetAll(ctx, t.memoryID, maxQueryEntries)
+	if err != nil {
+		return tool.NewTextErrorResponse(
+			"failed to list memories: " + err.Error(),
+		), nil
+	}
+
+	entries = filterEntries(entries, input.Metadata)
+	if len(entries) == 0 {
+		return tool.NewTextResponse("No memories found"), nil
+	}
+	entries = entries[:min(len(entries), queryLimit(input.Limit))]
+
+	return tool.NewTextResponse(formatEntries(entries)), nil
+}
+
+type countMemoriesTool struct {
+	store    Store
+	memoryID string
+}
+
+func (t *countMemoriesTool) Info() tool.Info {
+	return tool.Info{
+		Name:        "count_memories",
+		Description: "Count stored memories about the user, optionally filtered by metadata.",
+		Parameters: map[string]any{
+			"metadata": metadataFilterParam,
+		},
+	}
+}
+
+func (t *countMemoriesTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input struct {
+		Metadata map[string]string `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+
+	entries, err := t.store.GetAll(ctx, t.memoryID, maxQueryEntries)
+	if err != nil {
+		return tool.NewTextErrorResponse(
+			"failed to count memories: " + err.Error(),
+		), nil
+	}
+
+	count := len(filterEntries(entries, input.Metadata))
+	if len(entries) >= maxQueryEntries {
+		return tool.NewTextResponse(fmt.Sprintf(
+			"Matching memories: at least %d (only the first %d were checked)",
+			count,
+			maxQueryEntries,
+		)), nil
+	}
+	return tool.NewTextResponse(
+		fmt.Sprintf("Matching memories: %d", count),
+	), nil
+}
+
+type searchMemoriesTool struct {
+	store    Store
+	memoryID string
+}
+
+func (t *searchMemoriesTool) Info() tool.Info {
+	return tool.Info{
+		Name:        "search_memories",
+		Description: "Search stored memories about the user by meaning and metadata. Use when only memories with certain metadata, such as a category, are relevant.",
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "What to search for; omit to match on metadata alone",
+			},
+			"metadata": metadataFilterParam,
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of memories to return (default 20)",
+			},
+		},
+	}
+}
+
+func (t *searchMemoriesTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input struct {
+		Query    string            `json:"query"`
+		Metadata map[string]string `json:"metadata"`
+		Limit    int               `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+	if input.Query == "" && len(input.Metadata) == 0 {
+		return tool.NewTextErrorResponse(
+			"provide a query, a metadata filter, or both",
+		), nil
+	}
+
+	limit := queryLimit(input.Limit)
+	fetch := limit
+	if len(input.Metadata) > 0 {
+		fetch = maxQueryEntries
+	}
+
+	var entries []Entry
+	var err error
+	if input.Query != "" {
+		entries, err = t.store.Search(ctx, t.memoryID, input.Query, fetch)
+	} else {
+		entries, err = t.store.GetAll(ctx, t.memoryID, fetch)
+	}
+	if err != nil {
+		return tool.NewTextErrorResponse(
+			"failed to search memories: " + err.Error(),
+		), nil
+	}
+
+	entries = filterEntries(entries, input.Metadata)
+	if len(entries) == 0 {
+		return tool.NewTextResponse("No matching memories found"), nil
+	}
+	entries = entries[:min(len(entries), limit)]
+
+	return tool.NewTextResponse(formatEntries(entries)), nil
+}
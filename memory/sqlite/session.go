@@ -111,16 +111,17 @@ func (s *sessionStore) Delete(ctx context.Context, id string) error {
 		"DELETE FROM %smessages WHERE session_id = ?",
 		s.prefix,
 	)
-	if _, err := tx.ExecContext(ctx, deleteMessages, id); err != nil {
-		return err
-	}
-
 	deleteSession := fmt.Sprintf(
 		"DELETE FROM %ssessions WHERE id = ?",
 		s.prefix,
 	)
-	if _, err := tx.ExecContext(ctx, deleteSession, id); err != nil {
-		return err
+	for _, sid := range append([]string{id}, session.ReservedIDs(id)...) {
+		if _, err := tx.ExecContext(ctx, deleteMessages, sid); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, deleteSession, sid); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit()
@@ -191,19 +192,64 @@ func (s *sqliteSession) AddMessages(
 	ctx context.Context,
 	msgs []message.Message,
 ) error {
-	table := s.prefix + "messages"
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertMessages(ctx, tx, msgs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetMessages implements [session.Replacer], replacing the session's
+// messages in a single transaction.
+func (s *sqliteSession) SetMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(
+		"DELETE FROM %s WHERE session_id = ?",
+		s.prefix+"messages",
+	)
+	if _, err := tx.ExecContext(ctx, query, s.id); err != nil {
+		return err
+	}
+	if err := s.insertMessages(ctx, tx, msgs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteSession) insertMessages(
+	ctx context.Context,
+	tx *sql.Tx,
+	msgs []message.Message,
+) error {
 	query := fmt.Sprintf(
 		"INSERT INTO %s (session_id, role, parts, model, created_at) VALUES (?, ?, ?, ?, ?)",
-		table,
+		s.prefix+"messages",
 	)
 
 	for _, msg := range msgs {
-		msgJSON, err := json.Marshal(msg)
+		msgJSON, err := message.MarshalUnescaped(msg)
 		if err != nil {
 			return err
 		}
 
-		_, err = s.db.ExecContext(ctx, query,
+		_, err = tx.ExecContext(ctx, query,
 			s.id, string(msg.Role), msgJSON, string(msg.Model), msg.CreatedAt,
 		)
 		if err != nil {
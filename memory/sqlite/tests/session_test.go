@@ -660,6 +660,29 @@ func TestSQLiteSession_UnicodeAndSpecialCharacters(t *testing.T) {
 	assert.Equal(t, text, got[0].Content().Text)
 }
 
+func TestSQLiteSession_StoresHTMLUnescaped(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLite(t)
+
+	store, err := sqlite.SessionStore(ctx, db)
+	require.NoError(t, err)
+
+	s, err := store.Create(ctx, "s1")
+	require.NoError(t, err)
+
+	code := "if a < b && c > d { return <div></div> }"
+	require.NoError(t, s.AddMessages(ctx, []message.Message{
+		message.NewUserMessage(code),
+	}))
+
+	var parts string
+	require.NoError(t, db.QueryRowContext(ctx,
+		"SELECT parts FROM messages WHERE session_id = ?", "s1",
+	).Scan(&parts))
+	assert.Contains(t, parts, code)
+	assert.NotContains(t, parts, `\u00`)
+}
+
 func TestSQLiteStore_Prefix(t *testing.T) {
 	ctx := context.Background()
 	db := setupSQLite(t)
@@ -11,8 +11,11 @@
 package message
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
@@ -95,6 +98,33 @@ type ToolResult struct {
 	Metadata string `json:"metadata"`
 	// IsError indicates whether the tool execution resulted in an error.
 	IsError bool `json:"is_error"`
+	// MIMEType is the media type of Content, such as "application/json".
+	// Empty means plain text.
+	MIMEType string `json:"mime_type,omitempty"`
+	// Images holds image output from the tool. Providers that accept images in
+	// tool results send them alongside Content; others send
+	// [ToolResult.TextWithImageNotes] instead.
+	Images []BinaryContent `json:"images,omitempty"`
+}
+
+// TextWithImageNotes returns Content followed by a short note for each image,
+// for providers that cannot pass images back in tool results. The model then
+// knows the tool produced an image it cannot see.
+func (tr ToolResult) TextWithImageNotes() string {
+	if len(tr.Images) == 0 {
+		return tr.Content
+	}
+	lines := make([]string, 0, len(tr.Images)+1)
+	if tr.Content != "" {
+		lines = append(lines, tr.Content)
+	}
+	for _, img := range tr.Images {
+		lines = append(lines, fmt.Sprintf(
+			"[%s image omitted: this model cannot view images in tool results]",
+			img.MIMEType,
+		))
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (ToolResult) isPart() {}
@@ -154,6 +184,11 @@ type BinaryContent struct {
 	MIMEType string
 	// Data contains the raw binary content.
 	Data []byte
+	// FileID references a copy of Data already uploaded to the provider's
+	// file storage. Vendors that support file references send it instead of
+	// Data. It is set per request by llm.FileCache and is normally empty
+	// in stored history.
+	FileID string `json:",omitempty"`
 }
 
 // String returns the binary content as a base64-encoded string,
@@ -168,6 +203,112 @@ func (bc BinaryContent) String(provider model.Provider) string {
 
 func (BinaryContent) isPart() {}
 
+// FileRef references a file uploaded to a provider's file storage, such as a
+// large PDF or a video that is too big to inline. Obtain one from a vendor
+// client's UploadFile and attach it with [Message.AddFileRef]. A reference is
+// only valid with the provider, and account, that issued it.
+type FileRef struct {
+	// Provider is the provider that stores the file.
+	Provider model.Provider `json:"provider"`
+	// ID identifies the file for deletion and, for providers that reference
+	// files by ID, in requests.
+	ID string `json:"id"`
+	// URI is the address requests use to reference the file, for providers
+	// that reference files by URI rather than ID (Gemini).
+	URI string `json:"uri,omitempty"`
+	// MIMEType is the media type of the file.
+	MIMEType string `json:"mime_type"`
+	// Name is the file name given at upload, if any.
+	Name string `json:"name,omitempty"`
+	// SizeBytes is the size of the uploaded content.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+	// ExpiresAt is when the provider deletes the file. It is zero when the
+	// file is kept until deleted.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Expired reports whether the provider has deleted the file by now.
+func (f FileRef) Expired(now time.Time) bool {
+	return !f.ExpiresAt.IsZero() && !now.Before(f.ExpiresAt)
+}
+
+func (FileRef) isPart() {}
+
+// VideoContent is a video input, given either as a URL or as a file uploaded
+// with a vendor client's UploadFile. Only Gemini accepts video; other vendor
+// clients reject requests containing it.
+type VideoContent struct {
+	// URL is a video address the provider can fetch, such as a YouTube link.
+	URL string `json:"url,omitempty"`
+	// File references an uploaded video. It takes precedence over URL.
+	File *FileRef `json:"file,omitempty"`
+	// MIMEType is the media type of the video. It may be empty for URLs.
+	MIMEType string `json:"mime_type,omitempty"`
+	// Sampling optionally limits which part of the video the model sees and
+	// how densely it is sampled.
+	Sampling *VideoSampling `json:"sampling,omitempty"`
+}
+
+// VideoSampling controls how a provider samples a video into frames.
+type VideoSampling struct {
+	// FPS is the number of frames sampled per second. Zero uses the
+	// provider's default, which for Gemini is one frame per second.
+	FPS float64 `json:"fps,omitempty"`
+	// Start is the offset at which the clip begins.
+	Start time.Duration `json:"start,omitempty"`
+	// End is the offset at which the clip ends. Zero means the end of the
+	// video.
+	End time.Duration `json:"end,omitempty"`
+}
+
+func (VideoContent) isPart() {}
+
+// Citation is a source the model cited for part of its answer, such as a
+// passage of a supplied document or a web search result. Providers that
+// return citations, such as Anthropic, report them on the response; an
+// agent stores them on the assistant message so they can be rendered as
+// footnotes. Citation parts are not sent back to providers.
+type Citation struct {
+	// Type is the provider's citation kind, such as "char_location",
+	// "page_location" or "web_search_result_location".
+	Type string `json:"type,omitempty"`
+	// Title is the title of the cited document or web page.
+	Title string `json:"title,omitempty"`
+	// URL is the address of the cited web page or search result source.
+	URL string `json:"url,omitempty"`
+	// CitedText is the quoted span of the source.
+	CitedText string `json:"cited_text,omitempty"`
+	// DocumentIndex is the position of the cited document among those in the
+	// request, for document citations.
+	DocumentIndex int `json:"document_index,omitempty"`
+	// SourceStart and SourceEnd locate the quoted span within the document,
+	// in characters, pages or content blocks depending on Type. Both are
+	// zero when the provider gives no location.
+	SourceStart int `json:"source_start,omitempty"`
+	SourceEnd   int `json:"source_end,omitempty"`
+	// Start and End are the byte offsets of the supported text within the
+	// message content, so Content[Start:End] is the cited claim.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (Citation) isPart() {}
+
+// CacheHint tells providers with explicit prompt caching markers, such as
+// Anthropic, where to place a cache breakpoint. Providers that cache
+// prompt prefixes automatically ignore it.
+type CacheHint int
+
+const (
+	// CacheDefault leaves the breakpoint to the provider's own placement.
+	CacheDefault CacheHint = iota
+	// CacheBreakpoint caches the prompt up to and including this message.
+	CacheBreakpoint
+	// CacheSkip keeps the provider from placing a breakpoint on this
+	// message, for content that changes from call to call.
+	CacheSkip
+)
+
 // Message represents a single message in a conversation with an AI model.
 // It can contain multiple content parts including text, images, tool calls, and tool results.
 type Message struct {
@@ -179,6 +320,9 @@ type Message struct {
 	Model model.ID
 	// CreatedAt is a Unix timestamp (nanoseconds) indicating when the message was created.
 	CreatedAt int64
+	// Cache is the prompt caching hint for this message. Anthropic honours
+	// it on system messages.
+	Cache CacheHint
 }
 
 // NewMessage creates a new message with the specified role and content parts.
@@ -257,6 +401,28 @@ func (m *Message) BinaryContent() []BinaryContent {
 	return binaryContents
 }
 
+// VideoContent returns all video parts from the message.
+func (m *Message) VideoContent() []VideoContent {
+	var videos []VideoContent
+	for _, part := range m.Parts {
+		if c, ok := part.(VideoContent); ok {
+			videos = append(videos, c)
+		}
+	}
+	return videos
+}
+
+// FileRefs returns all file reference parts from the message.
+func (m *Message) FileRefs() []FileRef {
+	var refs []FileRef
+	for _, part := range m.Parts {
+		if c, ok := part.(FileRef); ok {
+			refs = append(refs, c)
+		}
+	}
+	return refs
+}
+
 // ImageURLContent returns all image URL content parts from the message.
 func (m *Message) ImageURLContent() []ImageURLContent {
 	imageURLContents := make([]ImageURLContent, 0)
@@ -290,6 +456,24 @@ func (m *Message) ToolResults() []ToolResult {
 	return toolResults
 }
 
+// Citations returns all citation parts from the message.
+func (m *Message) Citations() []Citation {
+	var citations []Citation
+	for _, part := range m.Parts {
+		if c, ok := part.(Citation); ok {
+			citations = append(citations, c)
+		}
+	}
+	return citations
+}
+
+// AddCitations appends citation parts to the message.
+func (m *Message) AddCitations(citations []Citation) {
+	for _, c := range citations {
+		m.Parts = append(m.Parts, c)
+	}
+}
+
 // AppendContent adds text to the existing text content or creates new text content.
 func (m *Message) AppendContent(delta string) {
 	found := false
@@ -343,6 +527,27 @@ func (m *Message) AddBinary(mimeType string, data []byte) {
 	m.Parts = append(m.Parts, BinaryContent{MIMEType: mimeType, Data: data})
 }
 
+// AddFileRef adds a reference to an uploaded file to the message.
+func (m *Message) AddFileRef(ref FileRef) {
+	m.Parts = append(m.Parts, ref)
+}
+
+// AddVideoURL adds a video the provider fetches from url, such as a YouTube
+// link. sampling may be nil.
+func (m *Message) AddVideoURL(url string, sampling *VideoSampling) {
+	m.Parts = append(m.Parts, VideoContent{URL: url, Sampling: sampling})
+}
+
+// AddVideoFile adds a video uploaded with a vendor client's UploadFile.
+// sampling may be nil.
+func (m *Message) AddVideoFile(ref FileRef, sampling *VideoSampling) {
+	m.Parts = append(m.Parts, VideoContent{
+		File:     &ref,
+		MIMEType: ref.MIMEType,
+		Sampling: sampling,
+	})
+}
+
 type contentPartWrapper struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
@@ -353,6 +558,7 @@ type messageJSON struct {
 	Parts     []contentPartWrapper `json:"parts"`
 	Model     model.ID             `json:"model,omitempty"`
 	CreatedAt int64                `json:"created_at"`
+	Cache     CacheHint            `json:"cache,omitempty"`
 }
 
 // MarshalJSON encodes the message and its typed content parts for JSON storage.
@@ -367,31 +573,50 @@ func (m Message) MarshalJSON() ([]byte, error) {
 			typeName = "image_url"
 		case BinaryContent:
 			typeName = "binary"
+		case FileRef:
+			typeName = "file_ref"
+		case VideoContent:
+			typeName = "video"
 		case ToolCall:
 			typeName = "tool_call"
 		case ToolResult:
 			typeName = "tool_result"
 		case ReasoningContent:
 			typeName = "reasoning"
+		case Citation:
+			typeName = "citation"
 		default:
 			typeName = "unknown"
 		}
 
-		data, err := json.Marshal(part)
+		data, err := MarshalUnescaped(part)
 		if err != nil {
 			return nil, err
 		}
 		parts = append(parts, contentPartWrapper{Type: typeName, Data: data})
 	}
 
-	return json.Marshal(messageJSON{
+	return MarshalUnescaped(messageJSON{
 		Role:      m.Role,
 		Parts:     parts,
 		Model:     m.Model,
 		CreatedAt: m.CreatedAt,
+		Cache:     m.Cache,
 	})
 }
 
+// marshalUnescaped encodes v like json.Marshal but leaves <, > and & as is,
+// so stored prompts, code and tool output read the same as the original.
+func MarshalUnescaped(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
 // UnmarshalJSON decodes JSON into a message, dispatching on each wrapped part's type tag.
 func (m *Message) UnmarshalJSON(data []byte) error {
 	var mj messageJSON
@@ -402,6 +627,7 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 	m.Role = mj.Role
 	m.Model = mj.Model
 	m.CreatedAt = mj.CreatedAt
+	m.Cache = mj.Cache
 	m.Parts = make([]ContentPart, 0, len(mj.Parts))
 
 	for _, wrapper := range mj.Parts {
@@ -425,6 +651,18 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			part = bc
+		case "file_ref":
+			var fr FileRef
+			if err := json.Unmarshal(wrapper.Data, &fr); err != nil {
+				return err
+			}
+			part = fr
+		case "video":
+			var vc VideoContent
+			if err := json.Unmarshal(wrapper.Data, &vc); err != nil {
+				return err
+			}
+			part = vc
 		case "tool_call":
 			var tc ToolCall
 			if err := json.Unmarshal(wrapper.Data, &tc); err != nil {
@@ -443,6 +681,12 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			part = rc
+		case "citation":
+			var c Citation
+			if err := json.Unmarshal(wrapper.Data, &c); err != nil {
+				return err
+			}
+			part = c
 		default:
 			continue
 		}
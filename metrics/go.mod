@@ -0,0 +1,3 @@
+module github.com/joakimcarlsson/ai/metrics
+
+go 1.25.0
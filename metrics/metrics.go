@@ -0,0 +1,85 @@
+// Package metrics defines a lightweight collector interface for exporting
+// request, latency, token, tool, and error metrics without wiring up
+// OpenTelemetry.
+//
+// Implement [Collector] on top of your metrics backend (Prometheus, StatsD,
+// Datadog, ...) and pass it to llm.WithMetrics or agent.WithMetrics. When no
+// collector is configured the library uses [Noop], which discards everything.
+//
+// Example Prometheus adapter:
+//
+//	type promCollector struct {
+//		counters   *prometheus.CounterVec
+//		histograms *prometheus.HistogramVec
+//	}
+//
+//	func (p *promCollector) IncCounter(name string, value float64, labels metrics.Labels) {
+//		p.counters.With(prometheus.Labels(labels)).Add(value)
+//	}
+//
+//	func (p *promCollector) ObserveHistogram(name string, value float64, labels metrics.Labels) {
+//		p.histograms.With(prometheus.Labels(labels)).Observe(value)
+//	}
+//
+//	agent.New(llmClient, agent.WithMetrics(&promCollector{...}))
+package metrics
+
+// Labels are key/value dimensions attached to a metric observation.
+type Labels map[string]string
+
+// Collector receives metric observations from the library.
+// Implementations must be safe for concurrent use.
+type Collector interface {
+	// IncCounter adds value to the named counter.
+	IncCounter(name string, value float64, labels Labels)
+	// ObserveHistogram records value in the named histogram.
+	ObserveHistogram(name string, value float64, labels Labels)
+}
+
+// Metric names reported by the library.
+const (
+	// LLMRequests counts LLM requests. Labels: provider, model, status.
+	LLMRequests = "llm_requests_total"
+	// LLMRequestDuration observes LLM request latency in seconds. Labels: provider, model, status.
+	LLMRequestDuration = "llm_request_duration_seconds"
+	// LLMTokens counts tokens consumed. Labels: provider, model, type (input, output, cache_read, cache_creation).
+	LLMTokens = "llm_tokens_total"
+	// LLMErrors counts failed LLM requests. Labels: provider, model.
+	LLMErrors = "llm_errors_total"
+	// ToolExecutions counts tool executions. Labels: tool, status.
+	ToolExecutions = "agent_tool_executions_total"
+	// ToolDuration observes tool execution latency in seconds. Labels: tool, status.
+	ToolDuration = "agent_tool_duration_seconds"
+	// ToolErrors counts failed tool executions. Labels: tool.
+	ToolErrors = "agent_tool_errors_total"
+	// AgentRuns counts agent runs (Chat, ChatStream, Continue). Labels: agent, status.
+	AgentRuns = "agent_runs_total"
+	// AgentRunDuration observes agent run latency in seconds. Labels: agent, status.
+	AgentRunDuration = "agent_run_duration_seconds"
+)
+
+// Label keys used by the library.
+const (
+	LabelProvider = "provider"
+	LabelModel    = "model"
+	LabelStatus   = "status"
+	LabelType     = "type"
+	LabelTool     = "tool"
+	LabelAgent    = "agent"
+)
+
+// Status label values.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Noop returns a collector that discards all observations.
+func Noop() Collector {
+	return noopCollector{}
+}
+
+type noopCollector struct{}
+
+func (noopCollector) IncCounter(string, float64, Labels)       {}
+func (noopCollector) ObserveHistogram(string, float64, Labels) {}
@@ -38,6 +38,12 @@ type AudioModel struct {
 	// SupportsStreaming indicates if the model supports streaming audio
 	// generation.
 	SupportsStreaming bool `json:"supports_streaming"`
+	// SupportsSSML indicates if the model accepts SSML markup, including
+	// phoneme tags, in place of plain text.
+	SupportsSSML bool `json:"supports_ssml"`
+	// SupportsPronunciationDictionaries indicates if the model can apply
+	// pronunciation dictionaries stored with the provider.
+	SupportsPronunciationDictionaries bool `json:"supports_pronunciation_dictionaries"`
 	// LatencyMs is the typical latency in milliseconds for audio generation.
 	LatencyMs int64 `json:"latency_ms,omitempty"`
 }
@@ -26,9 +26,16 @@ var AzureSpeechAudioModels = map[ID]AudioModel{
 			"riff-24khz-16bit-mono-pcm",
 			"ogg-16khz-16bit-mono-opus",
 			"ogg-24khz-16bit-mono-opus",
+			"raw-8khz-16bit-mono-pcm",
+			"raw-16khz-16bit-mono-pcm",
+			"raw-24khz-16bit-mono-pcm",
+			"raw-8khz-8bit-mono-mulaw",
+			"riff-8khz-8bit-mono-mulaw",
+			"raw-8khz-8bit-mono-alaw",
 		},
 		DefaultFormat:     "audio-24khz-160kbitrate-mono-mp3",
 		SupportsStreaming: false,
+		SupportsSSML:      true,
 	},
 	AzureSpeechNeuralHD: {
 		ID:             AzureSpeechNeuralHD,
@@ -46,9 +53,16 @@ var AzureSpeechAudioModels = map[ID]AudioModel{
 			"riff-48khz-16bit-mono-pcm",
 			"ogg-16khz-16bit-mono-opus",
 			"ogg-24khz-16bit-mono-opus",
+			"raw-8khz-16bit-mono-pcm",
+			"raw-16khz-16bit-mono-pcm",
+			"raw-24khz-16bit-mono-pcm",
+			"raw-8khz-8bit-mono-mulaw",
+			"riff-8khz-8bit-mono-mulaw",
+			"raw-8khz-8bit-mono-alaw",
 		},
 		DefaultFormat:     "audio-24khz-160kbitrate-mono-mp3",
 		SupportsStreaming: true,
+		SupportsSSML:      true,
 	},
 }
 
@@ -136,3 +136,11 @@ func WithImageGeneration(supportsImageGeneration bool) Option {
 		m.SupportsImageGeneration = supportsImageGeneration
 	}
 }
+
+// WithToolSupport sets whether the model accepts tool definitions. Custom
+// models support tools unless this is set to false.
+func WithToolSupport(supportsTools bool) Option {
+	return func(m *Model) {
+		m.ToolsUnsupported = !supportsTools
+	}
+}
@@ -31,15 +31,29 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_v3",
 		MaxCharacters: 5000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsPronunciationDictionaries: true,
 	},
 	ElevenMultilingualV2: {
 		ID:            ElevenMultilingualV2,
@@ -48,15 +62,29 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_multilingual_v2",
 		MaxCharacters: 10000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsPronunciationDictionaries: true,
 	},
 	ElevenFlashV2_5: {
 		ID:            ElevenFlashV2_5,
@@ -65,15 +93,29 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_flash_v2_5",
 		MaxCharacters: 40000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsPronunciationDictionaries: true,
 	},
 	ElevenFlashV2: {
 		ID:            ElevenFlashV2,
@@ -82,15 +124,30 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_flash_v2",
 		MaxCharacters: 30000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsSSML:                      true,
+		SupportsPronunciationDictionaries: true,
 	},
 	ElevenTurboV2_5: {
 		ID:            ElevenTurboV2_5,
@@ -99,15 +156,29 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_turbo_v2_5",
 		MaxCharacters: 40000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsPronunciationDictionaries: true,
 	},
 	ElevenTurboV2: {
 		ID:            ElevenTurboV2,
@@ -116,15 +187,30 @@ var ElevenLabsAudioModels = map[ID]AudioModel{
 		APIModel:      "eleven_turbo_v2",
 		MaxCharacters: 30000,
 		SupportedFormats: []string{
+			"mp3_22050_32",
+			"mp3_44100_32",
+			"mp3_44100_64",
+			"mp3_44100_96",
 			"mp3_44100_128",
 			"mp3_44100_192",
+			"pcm_8000",
 			"pcm_16000",
 			"pcm_22050",
 			"pcm_24000",
 			"pcm_44100",
+			"pcm_48000",
+			"ulaw_8000",
+			"alaw_8000",
+			"opus_48000_32",
+			"opus_48000_64",
+			"opus_48000_96",
+			"opus_48000_128",
+			"opus_48000_192",
 		},
-		DefaultFormat:     "mp3_44100_128",
-		SupportsStreaming: true,
+		DefaultFormat:                     "mp3_44100_128",
+		SupportsStreaming:                 true,
+		SupportsSSML:                      true,
+		SupportsPronunciationDictionaries: true,
 	},
 }
 
@@ -203,6 +203,7 @@ var GeminiModels = map[ID]Model{
 		DefaultMaxTokens:      50000,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	Gemini25FlashLite: {
 		ID:                    Gemini25FlashLite,
@@ -217,6 +218,7 @@ var GeminiModels = map[ID]Model{
 		DefaultMaxTokens:      50000,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	Gemini25: {
 		ID:                    Gemini25,
@@ -232,6 +234,7 @@ var GeminiModels = map[ID]Model{
 		CanReason:             true,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	Gemini25FlashLitePreview: {
 		ID:                    Gemini25FlashLitePreview,
@@ -246,6 +249,7 @@ var GeminiModels = map[ID]Model{
 		DefaultMaxTokens:      50000,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	Gemini20Flash: {
 		ID:                    Gemini20Flash,
@@ -260,6 +264,7 @@ var GeminiModels = map[ID]Model{
 		DefaultMaxTokens:      6000,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	Gemini20FlashLite: {
 		ID:                    Gemini20FlashLite,
@@ -274,6 +279,7 @@ var GeminiModels = map[ID]Model{
 		DefaultMaxTokens:      6000,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 }
 
@@ -317,6 +323,7 @@ var GeminiImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultAspectRatio: "1:1",
 		SupportedQualities: []string{"default"},
 		DefaultQuality:     "default",
+		MaxReferenceImages: 3,
 	},
 	Gemini3ProImage: {
 		ID:       Gemini3ProImage,
@@ -345,6 +352,7 @@ var GeminiImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultAspectRatio:    "1:1",
 		SupportedQualities:    []string{"default"},
 		DefaultQuality:        "default",
+		MaxReferenceImages:    14,
 	},
 	Gemini31FlashImagePreview: {
 		ID:       Gemini31FlashImagePreview,
@@ -395,6 +403,7 @@ var GeminiImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultAspectRatio: "1:1",
 		SupportedQualities: []string{"default"},
 		DefaultQuality:     "default",
+		MaxReferenceImages: 14,
 	},
 	Gemini31FlashLiteImage: {
 		ID:       Gemini31FlashLiteImage,
@@ -445,6 +454,7 @@ var GeminiImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultAspectRatio: "1:1",
 		SupportedQualities: []string{"default"},
 		DefaultQuality:     "default",
+		MaxReferenceImages: 3,
 	},
 	Imagen4: {
 		ID:       Imagen4,
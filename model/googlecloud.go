@@ -119,6 +119,7 @@ var GoogleCloudAudioModels = map[ID]AudioModel{
 		},
 		DefaultFormat:     "MP3",
 		SupportsStreaming: false,
+		SupportsSSML:      true,
 	},
 	GoogleCloudTTSWavenet: {
 		ID:             GoogleCloudTTSWavenet,
@@ -133,6 +134,7 @@ var GoogleCloudAudioModels = map[ID]AudioModel{
 		},
 		DefaultFormat:     "MP3",
 		SupportsStreaming: false,
+		SupportsSSML:      true,
 	},
 	GoogleCloudTTSNeural2: {
 		ID:             GoogleCloudTTSNeural2,
@@ -147,6 +149,7 @@ var GoogleCloudAudioModels = map[ID]AudioModel{
 		},
 		DefaultFormat:     "MP3",
 		SupportsStreaming: false,
+		SupportsSSML:      true,
 	},
 	GoogleCloudTTSStudio: {
 		ID:             GoogleCloudTTSStudio,
@@ -161,6 +164,7 @@ var GoogleCloudAudioModels = map[ID]AudioModel{
 		},
 		DefaultFormat:     "MP3",
 		SupportsStreaming: false,
+		SupportsSSML:      true,
 	},
 	GoogleCloudTTSChirp3HD: {
 		ID:             GoogleCloudTTSChirp3HD,
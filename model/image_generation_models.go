@@ -30,4 +30,7 @@ type ImageGenerationModel struct {
 	DefaultAspectRatio string `json:"default_aspect_ratio,omitempty"`
 	// SupportsStreaming indicates if this model supports streaming partial images during generation.
 	SupportsStreaming bool `json:"supports_streaming,omitempty"`
+	// MaxReferenceImages is the number of reference images the model accepts
+	// for style or subject consistency, or 0 when it accepts none.
+	MaxReferenceImages int `json:"max_reference_images,omitempty"`
 }
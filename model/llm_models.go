@@ -62,4 +62,10 @@ type Model struct {
 	SupportsStructuredOut bool `json:"supports_structured_output"`
 	// SupportsImageGeneration indicates if the model can generate images.
 	SupportsImageGeneration bool `json:"supports_image_generation"`
+	// ToolsUnsupported marks models that reject tool definitions. It is
+	// negated so that the zero value, including custom models, allows tools.
+	ToolsUnsupported bool `json:"tools_unsupported"`
+	// SchemaExcludesTools marks models that support structured output and
+	// tools, but reject a request combining the two, such as Gemini 2.x.
+	SchemaExcludesTools bool `json:"schema_excludes_tools"`
 }
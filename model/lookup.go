@@ -0,0 +1,47 @@
+package model
+
+// Lookup finds a model of the given provider in this package's catalogs,
+// matching name against the model ID first and then against the API model
+// identifier.
+func Lookup(provider Provider, name string) (Model, bool) {
+	if name == "" {
+		return Model{}, false
+	}
+	for _, catalog := range llmCatalogs() {
+		if m, ok := catalog[ID(name)]; ok && m.Provider == provider {
+			return m, true
+		}
+	}
+	for _, catalog := range llmCatalogs() {
+		for _, m := range catalog {
+			if m.Provider == provider && m.APIModel == name {
+				return m, true
+			}
+		}
+	}
+	return Model{}, false
+}
+
+func llmCatalogs() []map[ID]Model {
+	return []map[ID]Model{
+		OpenAIModels,
+		AnthropicModels,
+		GeminiModels,
+		VertexAIGeminiModels,
+		AzureModels,
+		BergetModels,
+		CerebrasModels,
+		CohereModels,
+		DeepSeekModels,
+		FireworksModels,
+		GroqModels,
+		MetaModels,
+		MistralModels,
+		OllamaModels,
+		OpenRouterModels,
+		PerplexityModels,
+		QwenModels,
+		TogetherModels,
+		XAIModels,
+	}
+}
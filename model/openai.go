@@ -647,7 +647,7 @@ var OpenAIEmbeddingModels = map[ID]EmbeddingModel{
 		EmbeddingDims:       3072,
 		SupportedDimensions: []int{256, 512, 1024, 1536, 2048, 3072},
 		MaxBatchSize:        2048,
-		MaxTokensPerBatch:   1000000,
+		MaxTokensPerBatch:   300000,
 	},
 	TextEmbedding3Small: {
 		ID:                  TextEmbedding3Small,
@@ -659,7 +659,7 @@ var OpenAIEmbeddingModels = map[ID]EmbeddingModel{
 		EmbeddingDims:       1536,
 		SupportedDimensions: []int{512, 1536},
 		MaxBatchSize:        2048,
-		MaxTokensPerBatch:   1000000,
+		MaxTokensPerBatch:   300000,
 	},
 	AdaEmbedding002: {
 		ID:                  AdaEmbedding002,
@@ -671,7 +671,7 @@ var OpenAIEmbeddingModels = map[ID]EmbeddingModel{
 		EmbeddingDims:       1536,
 		SupportedDimensions: []int{1536},
 		MaxBatchSize:        2048,
-		MaxTokensPerBatch:   1000000,
+		MaxTokensPerBatch:   300000,
 	},
 }
 
@@ -704,6 +704,7 @@ var OpenAIImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultSize:        "1024x1024",
 		SupportedQualities: []string{"low", "medium", "high"},
 		DefaultQuality:     "medium",
+		MaxReferenceImages: 16,
 		SupportsStreaming:  true,
 	},
 	GPTImage2: {
@@ -733,6 +734,7 @@ var OpenAIImageGenerationModels = map[ID]ImageGenerationModel{
 		DefaultSize:        "1024x1024",
 		SupportedQualities: []string{"low", "medium", "high"},
 		DefaultQuality:     "medium",
+		MaxReferenceImages: 16,
 		SupportsStreaming:  true,
 	},
 }
@@ -27,6 +27,7 @@ var PerplexityModels = map[ID]Model{
 		CanReason:             false,
 		SupportsAttachments:   false,
 		SupportsStructuredOut: false,
+		ToolsUnsupported:      true,
 	},
 	SonarPro: {
 		ID:                    SonarPro,
@@ -42,6 +43,7 @@ var PerplexityModels = map[ID]Model{
 		CanReason:             false,
 		SupportsAttachments:   false,
 		SupportsStructuredOut: false,
+		ToolsUnsupported:      true,
 	},
 	SonarReasoning: {
 		ID:                    SonarReasoning,
@@ -57,6 +59,7 @@ var PerplexityModels = map[ID]Model{
 		CanReason:             true,
 		SupportsAttachments:   false,
 		SupportsStructuredOut: false,
+		ToolsUnsupported:      true,
 	},
 	SonarReasoningPro: {
 		ID:                    SonarReasoningPro,
@@ -72,6 +75,7 @@ var PerplexityModels = map[ID]Model{
 		CanReason:             true,
 		SupportsAttachments:   false,
 		SupportsStructuredOut: false,
+		ToolsUnsupported:      true,
 	},
 	SonarDeepResearch: {
 		ID:                    SonarDeepResearch,
@@ -87,5 +91,6 @@ var PerplexityModels = map[ID]Model{
 		CanReason:             true,
 		SupportsAttachments:   false,
 		SupportsStructuredOut: false,
+		ToolsUnsupported:      true,
 	},
 }
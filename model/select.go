@@ -0,0 +1,167 @@
+package model
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrNoMatchingModel is returned by [Select] when no catalog model meets the
+// requirements.
+var ErrNoMatchingModel = errors.New("model: no model matches requirements")
+
+// Require lists the capabilities a model must have to be chosen by [Select].
+// Zero fields place no constraint.
+type Require struct {
+	// Vision requires image and file input (SupportsAttachments).
+	Vision bool
+	// Tools requires tool calling support.
+	Tools bool
+	// Reasoning requires chain-of-thought reasoning (CanReason).
+	Reasoning bool
+	// StructuredOutput requires structured JSON output.
+	StructuredOutput bool
+	// ImageGeneration requires image output.
+	ImageGeneration bool
+	// MinContextWindow requires a context window of at least this many
+	// tokens.
+	MinContextWindow int64
+	// Providers limits the candidates to these providers.
+	Providers []Provider
+}
+
+// Matches reports whether m meets every requirement in r.
+func (r Require) Matches(m Model) bool {
+	switch {
+	case r.Vision && !m.SupportsAttachments,
+		r.Tools && m.ToolsUnsupported,
+		r.Reasoning && !m.CanReason,
+		r.StructuredOutput && !m.SupportsStructuredOut,
+		r.ImageGeneration && !m.SupportsImageGeneration,
+		m.ContextWindow < r.MinContextWindow:
+		return false
+	}
+	return len(r.Providers) == 0 || slices.Contains(r.Providers, m.Provider)
+}
+
+// Preference orders two candidate models for [Select]. It returns a negative
+// number when a is preferred, a positive number when b is, and zero when
+// they are equally good.
+type Preference func(a, b Model) int
+
+// CostFunc returns the price of a model used to rank it.
+type CostFunc func(Model) float64
+
+// InputCost prices a model by its cost per 1 million input tokens.
+func InputCost(m Model) float64 {
+	return m.CostPer1MIn
+}
+
+// OutputCost prices a model by its cost per 1 million output tokens.
+func OutputCost(m Model) float64 {
+	return m.CostPer1MOut
+}
+
+// TotalCost prices a model by the sum of its input and output costs per 1
+// million tokens.
+func TotalCost(m Model) float64 {
+	return m.CostPer1MIn + m.CostPer1MOut
+}
+
+// CheapestBy prefers the model with the lowest cost. Local models, such as
+// those served by Ollama, are listed at zero cost and therefore win; limit
+// [Require.Providers] to hosted providers to skip them.
+func CheapestBy(cost CostFunc) Preference {
+	return func(a, b Model) int {
+		return cmp.Compare(cost(a), cost(b))
+	}
+}
+
+// LargestContext prefers the model with the largest context window.
+func LargestContext() Preference {
+	return func(a, b Model) int {
+		return cmp.Compare(b.ContextWindow, a.ContextWindow)
+	}
+}
+
+// Select returns the catalog model that meets req and ranks first under
+// prefs. Later preferences break ties left by earlier ones, and remaining
+// ties are broken by provider and ID so the choice is stable. It returns an
+// error wrapping [ErrNoMatchingModel] when nothing qualifies.
+//
+// Example:
+//
+//	m, err := model.Select(
+//		model.Require{Vision: true, Tools: true},
+//		model.CheapestBy(model.InputCost),
+//	)
+func Select(req Require, prefs ...Preference) (Model, error) {
+	var (
+		best  Model
+		found bool
+	)
+	for _, catalog := range llmCatalogs() {
+		for _, m := range catalog {
+			if !req.Matches(m) {
+				continue
+			}
+			if !found || rank(m, best, prefs) < 0 {
+				best, found = m, true
+			}
+		}
+	}
+	if !found {
+		return Model{}, fmt.Errorf("%w: %s", ErrNoMatchingModel, req)
+	}
+	return best, nil
+}
+
+func rank(a, b Model, prefs []Preference) int {
+	for _, pref := range prefs {
+		if c := pref(a, b); c != 0 {
+			return c
+		}
+	}
+	if c := cmp.Compare(a.Provider, b.Provider); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// String lists the requirements that are set, for error messages.
+func (r Require) String() string {
+	var parts []string
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{r.Vision, "vision"},
+		{r.Tools, "tools"},
+		{r.Reasoning, "reasoning"},
+		{r.StructuredOutput, "structured output"},
+		{r.ImageGeneration, "image generation"},
+	} {
+		if flag.set {
+			parts = append(parts, flag.name)
+		}
+	}
+	if r.MinContextWindow > 0 {
+		parts = append(
+			parts,
+			fmt.Sprintf("context window >= %d", r.MinContextWindow),
+		)
+	}
+	if len(r.Providers) > 0 {
+		names := make([]string, len(r.Providers))
+		for i, p := range r.Providers {
+			names[i] = string(p)
+		}
+		parts = append(parts, "provider in "+strings.Join(names, ", "))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
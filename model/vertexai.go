@@ -30,6 +30,7 @@ var VertexAIGeminiModels = map[ID]Model{
 		DefaultMaxTokens:      GeminiModels[Gemini25Flash].DefaultMaxTokens,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	VertexAIGemini25: {
 		ID:                    VertexAIGemini25,
@@ -44,6 +45,7 @@ var VertexAIGeminiModels = map[ID]Model{
 		DefaultMaxTokens:      GeminiModels[Gemini25].DefaultMaxTokens,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	VertexAIGemini35Flash: {
 		ID:                    VertexAIGemini35Flash,
@@ -104,6 +106,7 @@ var VertexAIGeminiModels = map[ID]Model{
 		CanReason:             GeminiModels[Gemini25FlashLite].CanReason,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	VertexAIGemini20Flash: {
 		ID:                    VertexAIGemini20Flash,
@@ -119,6 +122,7 @@ var VertexAIGeminiModels = map[ID]Model{
 		CanReason:             GeminiModels[Gemini20Flash].CanReason,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 	VertexAIGemini20FlashLite: {
 		ID:                    VertexAIGemini20FlashLite,
@@ -134,5 +138,6 @@ var VertexAIGeminiModels = map[ID]Model{
 		CanReason:             GeminiModels[Gemini20FlashLite].CanReason,
 		SupportsAttachments:   true,
 		SupportsStructuredOut: true,
+		SchemaExcludesTools:   true,
 	},
 }
@@ -0,0 +1,3 @@
+module github.com/joakimcarlsson/ai/moderation
+
+go 1.25.0
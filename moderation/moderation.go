@@ -0,0 +1,159 @@
+// Package moderation screens text and images against a content policy.
+// [Moderator] is the minimal interface agents consume; [Client] adds
+// per-category scores and image inputs.
+//
+// This package defines the interfaces and the data types that flow through
+// them. Concrete vendor implementations live in subpackages
+// (moderation/openai); each subpackage exports its own NewModerator
+// constructor that returns a [Client].
+//
+// Example usage:
+//
+//	import (
+//		"github.com/joakimcarlsson/ai/moderation"
+//		"github.com/joakimcarlsson/ai/moderation/openai"
+//	)
+//
+//	client := openai.NewModerator(
+//		openai.WithAPIKey(os.Getenv("OPENAI_API_KEY")),
+//	)
+//
+//	result, err := client.Check(ctx, userInput)
+//	if err != nil {
+//		return err
+//	}
+//	for _, c := range result.Categories {
+//		fmt.Printf("%s flagged=%v score=%.3f\n", c.Name, c.Flagged, c.Score)
+//	}
+package moderation
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrImagesUnsupported is returned by [Client.CheckInputs] when an image is
+// passed to a model that only moderates text.
+var ErrImagesUnsupported = errors.New(
+	"moderation: model does not support image inputs",
+)
+
+// Moderator checks text against a content policy.
+type Moderator interface {
+	// Moderate classifies text. An error means the check could not be made,
+	// not that the text was flagged.
+	Moderate(ctx context.Context, text string) (*Result, error)
+}
+
+// Result is the outcome of a moderation check.
+type Result struct {
+	// Flagged reports whether the text violates the policy.
+	Flagged bool
+	// Categories lists the policy categories the text was flagged for, such
+	// as "harassment" or "self-harm/intent".
+	Categories []string
+	// Scores holds the provider's confidence per category, where available.
+	Scores map[string]float64
+}
+
+// ModeratorFunc adapts a function to the [Moderator] interface.
+type ModeratorFunc func(ctx context.Context, text string) (*Result, error)
+
+// Moderate calls f.
+func (f ModeratorFunc) Moderate(
+	ctx context.Context,
+	text string,
+) (*Result, error) {
+	return f(ctx, text)
+}
+
+// Input is one part of the content passed to [Client.CheckInputs]: text, or
+// an image by URL.
+type Input struct {
+	// Text is the text to classify. Ignored when ImageURL is set.
+	Text string
+	// ImageURL is an http(s) URL or a base64 data URL of an image.
+	ImageURL string
+}
+
+// TextInput returns an [Input] holding text.
+func TextInput(text string) Input {
+	return Input{Text: text}
+}
+
+// ImageURLInput returns an [Input] holding an image URL, which may be a
+// base64 data URL.
+func ImageURLInput(url string) Input {
+	return Input{ImageURL: url}
+}
+
+// ImageDataInput returns an [Input] holding image bytes, sent as a base64
+// data URL with the given MIME type, such as "image/png".
+func ImageDataInput(data []byte, mimeType string) Input {
+	return Input{
+		ImageURL: "data:" + mimeType + ";base64," +
+			base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+// CategoryResult is the verdict for one policy category.
+type CategoryResult struct {
+	// Name is the provider's category name, such as "violence/graphic".
+	Name string
+	// Flagged reports whether the content violates this category.
+	Flagged bool
+	// Score is the provider's confidence that the category applies, from 0
+	// to 1.
+	Score float64
+	// AppliedInputTypes lists the input types, "text" or "image", the
+	// category was evaluated on. Empty when the provider does not report it.
+	AppliedInputTypes []string
+}
+
+// ModerationResult is the detailed outcome of a [Client] check.
+type ModerationResult struct {
+	// Flagged reports whether any category was flagged.
+	Flagged bool
+	// Categories holds every category the provider evaluated, sorted by
+	// name.
+	Categories []CategoryResult
+	// Model is the model that produced the result.
+	Model string
+}
+
+// Category returns the result for the named category.
+func (r ModerationResult) Category(name string) (CategoryResult, bool) {
+	for _, c := range r.Categories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return CategoryResult{}, false
+}
+
+// Result converts r to the summary [Result] returned by [Moderator].
+func (r ModerationResult) Result() *Result {
+	result := &Result{
+		Flagged: r.Flagged,
+		Scores:  make(map[string]float64, len(r.Categories)),
+	}
+	for _, c := range r.Categories {
+		result.Scores[c.Name] = c.Score
+		if c.Flagged {
+			result.Categories = append(result.Categories, c.Name)
+		}
+	}
+	return result
+}
+
+// Client is a provider moderation client that reports every category's
+// flag and score and can classify images. Every Client is a [Moderator].
+type Client interface {
+	Moderator
+	// Check classifies text.
+	Check(ctx context.Context, text string) (ModerationResult, error)
+	// CheckInputs classifies text and images together as one piece of
+	// content.
+	CheckInputs(ctx context.Context, inputs ...Input) (ModerationResult, error)
+}
@@ -0,0 +1,7 @@
+module github.com/joakimcarlsson/ai/moderation/openai
+
+go 1.25.0
+
+require github.com/joakimcarlsson/ai/moderation v0.0.0-00010101000000-000000000000
+
+replace github.com/joakimcarlsson/ai/moderation => ../
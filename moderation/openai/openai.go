@@ -0,0 +1,267 @@
+// Package openai provides an implementation of the [moderation.Client]
+// interface for OpenAI's moderations endpoint, including image inputs for
+// the omni-moderation models.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/ai/moderation"
+)
+
+// Options configures the moderation client.
+type Options struct {
+	apiKey  string
+	model   string
+	baseURL string
+	timeout *time.Duration
+}
+
+// Option configures Options.
+type Option func(*Options)
+
+// WithAPIKey sets the API key used to authenticate with the provider.
+func WithAPIKey(apiKey string) Option {
+	return func(o *Options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithModel selects the moderation model. Defaults to
+// "omni-moderation-latest".
+func WithModel(model string) Option {
+	return func(o *Options) {
+		o.model = model
+	}
+}
+
+// WithBaseURL overrides the API base URL, for proxies and compatible
+// services. Defaults to "https://api.openai.com/v1".
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithTimeout sets the maximum duration to wait for a single request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.timeout = &timeout
+	}
+}
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultModel   = "omni-moderation-latest"
+)
+
+type client struct {
+	options    Options
+	httpClient *http.Client
+}
+
+// NewModerator constructs a [moderation.Client] for the OpenAI moderations
+// endpoint.
+func NewModerator(opts ...Option) moderation.Client {
+	options := Options{
+		model:   defaultModel,
+		baseURL: defaultBaseURL,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	timeout := 30 * time.Second
+	if options.timeout != nil {
+		timeout = *options.timeout
+	}
+
+	return &client{
+		options:    options,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type openAIRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+}
+
+type openAIInput struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Results []struct {
+		Flagged                   bool                `json:"flagged"`
+		Categories                map[string]bool     `json:"categories"`
+		CategoryScores            map[string]float64  `json:"category_scores"`
+		CategoryAppliedInputTypes map[string][]string `json:"category_applied_input_types"`
+	} `json:"results"`
+}
+
+// Moderate classifies text with the OpenAI moderations endpoint.
+func (c *client) Moderate(
+	ctx context.Context,
+	text string,
+) (*moderation.Result, error) {
+	result, err := c.Check(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return result.Result(), nil
+}
+
+// Check classifies text and returns the flag and score of every category.
+func (c *client) Check(
+	ctx context.Context,
+	text string,
+) (moderation.ModerationResult, error) {
+	return c.send(ctx, text)
+}
+
+// CheckInputs classifies text and images together, as one piece of
+// content. Images require an omni-moderation model.
+func (c *client) CheckInputs(
+	ctx context.Context,
+	inputs ...moderation.Input,
+) (moderation.ModerationResult, error) {
+	if len(inputs) == 0 {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"moderation: no inputs",
+		)
+	}
+
+	parts := make([]openAIInput, len(inputs))
+	for i, in := range inputs {
+		if in.ImageURL == "" {
+			parts[i] = openAIInput{Type: "text", Text: in.Text}
+			continue
+		}
+		if !strings.HasPrefix(c.options.model, "omni-") {
+			return moderation.ModerationResult{}, fmt.Errorf(
+				"%w: %s",
+				moderation.ErrImagesUnsupported,
+				c.options.model,
+			)
+		}
+		parts[i] = openAIInput{
+			Type:     "image_url",
+			ImageURL: &openAIImageURL{URL: in.ImageURL},
+		}
+	}
+	return c.send(ctx, parts)
+}
+
+func (c *client) send(
+	ctx context.Context,
+	input any,
+) (moderation.ModerationResult, error) {
+	jsonBody, err := json.Marshal(openAIRequest{
+		Model: c.options.model,
+		Input: input,
+	})
+	if err != nil {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"failed to marshal moderation request: %w",
+			err,
+		)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.options.baseURL+"/moderations",
+		bytes.NewReader(jsonBody),
+	)
+	if err != nil {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"failed to create moderation request: %w",
+			err,
+		)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.options.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"failed to make moderation request: %w",
+			err,
+		)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"failed to read moderation response body: %w",
+			err,
+		)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"moderation API request failed with status %d: %s",
+			resp.StatusCode,
+			string(body),
+		)
+	}
+
+	var apiResp openAIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"failed to unmarshal moderation response: %w",
+			err,
+		)
+	}
+	if len(apiResp.Results) == 0 {
+		return moderation.ModerationResult{}, fmt.Errorf(
+			"moderation response has no results",
+		)
+	}
+
+	r := apiResp.Results[0]
+	result := moderation.ModerationResult{
+		Flagged: r.Flagged,
+		Model:   apiResp.Model,
+	}
+	for name, score := range r.CategoryScores {
+		result.Categories = append(
+			result.Categories,
+			moderation.CategoryResult{
+				Name:              name,
+				Flagged:           r.Categories[name],
+				Score:             score,
+				AppliedInputTypes: r.CategoryAppliedInputTypes[name],
+			},
+		)
+	}
+	for name, flagged := range r.Categories {
+		if _, ok := r.CategoryScores[name]; !ok {
+			result.Categories = append(
+				result.Categories,
+				moderation.CategoryResult{Name: name, Flagged: flagged},
+			)
+		}
+	}
+	sort.Slice(result.Categories, func(i, j int) bool {
+		return result.Categories[i].Name < result.Categories[j].Name
+	})
+	return result, nil
+}
@@ -39,6 +39,18 @@
 //	    }),
 //	)
 //
+// # Variants
+//
+// Compare prompt versions by registering them in an experiment and picking
+// one per request, stably per user:
+//
+//	exp, err := prompt.NewExperiment("system", []prompt.Variant{
+//	    {Name: "control", Source: "You are {{.role}}.", Weight: 1},
+//	    {Name: "friendly", Source: "You are a friendly {{.role}}.", Weight: 1},
+//	})
+//	sel := exp.Pick(userID)
+//	result, err := sel.Process(data) // sel.Variant names the version used
+//
 // # Built-in Functions
 //
 // The package provides many useful functions beyond Go's defaults:
@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+)
+
+// Variant is one named version of a prompt in an [Experiment].
+type Variant struct {
+	// Name identifies the variant, e.g. "control" or "v2-concise".
+	Name string
+	// Source is the template source.
+	Source string
+	// Weight is the variant's share of traffic relative to the other
+	// variants. A variant with zero weight is never picked by [Experiment.Pick]
+	// or [Experiment.Random] but can still be chosen with [Experiment.Get].
+	Weight int
+}
+
+// Selection is the variant an [Experiment] chose for a request.
+type Selection struct {
+	// Experiment is the experiment name.
+	Experiment string
+	// Variant is the name of the chosen variant, to record alongside the
+	// request's outcome.
+	Variant string
+	// Template is the chosen variant's parsed template.
+	Template *Template
+}
+
+// Process executes the chosen variant's template with data.
+func (s Selection) Process(data map[string]any) (string, error) {
+	return s.Template.Process(data)
+}
+
+// Experiment holds competing versions of a prompt and picks one per request,
+// either by hashing a stable key such as a user ID, so the same key always
+// gets the same variant, or at random. Picks are weighted by
+// [Variant.Weight]. An Experiment is safe for concurrent use.
+type Experiment struct {
+	name     string
+	variants []experimentVariant
+	total    int
+}
+
+type experimentVariant struct {
+	name   string
+	weight int
+	tmpl   *Template
+}
+
+// NewExperiment parses every variant with [New], applying opts to each. The
+// template of a variant is named "<experiment>/<variant>", which is also its
+// key in a [Cache] passed with [WithCache]; [WithName] is overridden.
+func NewExperiment(
+	name string,
+	variants []Variant,
+	opts ...Option,
+) (*Experiment, error) {
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("prompt: experiment %s has no variants", name)
+	}
+	e := &Experiment{
+		name:     name,
+		variants: make([]experimentVariant, 0, len(variants)),
+	}
+	seen := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if v.Name == "" {
+			return nil, fmt.Errorf(
+				"prompt: experiment %s has a variant without a name",
+				name,
+			)
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf(
+				"prompt: experiment %s has duplicate variant %s",
+				name,
+				v.Name,
+			)
+		}
+		seen[v.Name] = true
+		if v.Weight < 0 {
+			return nil, fmt.Errorf(
+				"prompt: variant %s has negative weight %d",
+				v.Name,
+				v.Weight,
+			)
+		}
+		tmpl, err := New(
+			v.Source,
+			append(slices.Clip(opts), WithName(name+"/"+v.Name))...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("prompt: variant %s: %w", v.Name, err)
+		}
+		e.variants = append(e.variants, experimentVariant{
+			name:   v.Name,
+			weight: v.Weight,
+			tmpl:   tmpl,
+		})
+		e.total += v.Weight
+	}
+	if e.total == 0 {
+		return nil, fmt.Errorf(
+			"prompt: experiment %s has no variant with positive weight",
+			name,
+		)
+	}
+	return e, nil
+}
+
+// ErrUnknownVariant is returned by [Experiment.Get] for a name the
+// experiment does not have.
+var ErrUnknownVariant = errors.New("prompt: unknown variant")
+
+// Name returns the experiment name.
+func (e *Experiment) Name() string {
+	return e.name
+}
+
+// Variants returns the variant names in the order they were given.
+func (e *Experiment) Variants() []string {
+	names := make([]string, len(e.variants))
+	for i, v := range e.variants {
+		names[i] = v.name
+	}
+	return names
+}
+
+// Pick returns the variant for key. The choice depends only on the
+// experiment name, key, and the variants' weights, so a user keeps the same
+// variant across requests and restarts, and separate experiments bucket
+// users independently.
+func (e *Experiment) Pick(key string) Selection {
+	h := fnv.New64a()
+	h.Write([]byte(e.name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return e.bucket(int(h.Sum64() % uint64(e.total)))
+}
+
+// Random returns a variant chosen at random by weight, for requests with no
+// stable key.
+func (e *Experiment) Random() Selection {
+	return e.bucket(rand.IntN(e.total))
+}
+
+// Get returns the variant with the given name, e.g. to force a variant for
+// debugging or to pin a winner after the experiment ends.
+func (e *Experiment) Get(name string) (Selection, error) {
+	for _, v := range e.variants {
+		if v.name == name {
+			return e.selection(v), nil
+		}
+	}
+	return Selection{}, fmt.Errorf(
+		"%w %s in experiment %s",
+		ErrUnknownVariant,
+		name,
+		e.name,
+	)
+}
+
+func (e *Experiment) bucket(n int) Selection {
+	for _, v := range e.variants {
+		if n < v.weight {
+			return e.selection(v)
+		}
+		n -= v.weight
+	}
+	return e.selection(e.variants[len(e.variants)-1])
+}
+
+func (e *Experiment) selection(v experimentVariant) Selection {
+	return Selection{Experiment: e.name, Variant: v.name, Template: v.tmpl}
+}
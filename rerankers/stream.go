@@ -0,0 +1,152 @@
+package rerankers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+const defaultStreamBatchSize = 50
+
+// RerankEvent is a progressive update emitted by [RerankStream] after each
+// batch of documents has been scored.
+type RerankEvent struct {
+	// Results holds the best documents scored so far, most relevant first.
+	// Each Index refers to the position in the full input list.
+	Results []RerankerResult
+	// Scored is the number of documents scored so far.
+	Scored int
+	// Total is the number of documents being reranked.
+	Total int
+	// Usage accumulates resource consumption across completed batches.
+	Usage RerankerUsage
+	// Done reports that every batch has been scored and this is the final
+	// ranking.
+	Done bool
+	// Err is set on the last event when a batch fails. Results still holds
+	// the ranking of the batches that completed before the failure.
+	Err error
+}
+
+type streamOptions struct {
+	batchSize int
+	topN      int
+}
+
+// StreamOption configures [RerankStream].
+type StreamOption func(*streamOptions)
+
+// WithBatchSize sets how many documents are sent to the reranker per request.
+// Smaller batches produce earlier, more frequent updates at the cost of more
+// requests. Defaults to 50.
+func WithBatchSize(size int) StreamOption {
+	return func(o *streamOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithTopN limits each event to the N most relevant documents seen so far.
+// Zero, the default, keeps every scored document.
+func WithTopN(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.topN = n
+	}
+}
+
+// RerankStream reranks documents in batches and emits the running best-N
+// after each batch completes, so interactive callers can show results before
+// the whole corpus has been scored. Batches are scored in order, one request
+// at a time, and the final event has Done set.
+//
+// Scores from separate requests are compared directly, which holds for
+// rerankers that score each query-document pair independently. If the
+// reranker itself was built with a top-K limit, each batch contributes at
+// most K documents to the running ranking.
+//
+// A failed batch ends the stream with an event carrying Err. Cancelling ctx
+// stops scoring and closes the channel without a final event.
+func RerankStream(
+	ctx context.Context,
+	reranker Reranker,
+	query string,
+	documents []string,
+	opts ...StreamOption,
+) <-chan RerankEvent {
+	options := streamOptions{batchSize: defaultStreamBatchSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.batchSize <= 0 {
+		options.batchSize = defaultStreamBatchSize
+	}
+
+	out := make(chan RerankEvent)
+	go func() {
+		defer close(out)
+
+		evt := RerankEvent{Results: []RerankerResult{}, Total: len(documents)}
+		send := func(evt RerankEvent) bool {
+			select {
+			case out <- evt:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var best []RerankerResult
+		for start := 0; start < len(documents); start += options.batchSize {
+			end := min(start+options.batchSize, len(documents))
+			resp, err := reranker.Rerank(ctx, query, documents[start:end])
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				evt.Err = fmt.Errorf(
+					"failed to rerank documents %d-%d: %w",
+					start,
+					end-1,
+					err,
+				)
+				send(evt)
+				return
+			}
+
+			for _, r := range resp.Results {
+				r.Index += start
+				best = append(best, r)
+			}
+			best = rankResults(best, options.topN)
+
+			evt.Results = slices.Clone(best)
+			evt.Scored = end
+			evt.Usage.TotalTokens += resp.Usage.TotalTokens
+			evt.Done = end == len(documents)
+			if !send(evt) {
+				return
+			}
+		}
+
+		if len(documents) == 0 {
+			evt.Done = true
+			send(evt)
+		}
+	}()
+	return out
+}
+
+// rankResults sorts results by descending relevance, breaking ties by input
+// position, and truncates to topN when topN is positive.
+func rankResults(results []RerankerResult, topN int) []RerankerResult {
+	slices.SortStableFunc(results, func(a, b RerankerResult) int {
+		if c := cmp.Compare(b.RelevanceScore, a.RelevanceScore); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+	if topN > 0 && len(results) > topN {
+		results = results[:topN]
+	}
+	return results
+}
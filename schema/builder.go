@@ -0,0 +1,172 @@
+package schema
+
+// Builder assembles a JSON Schema node without hand-writing nested
+// map[string]any literals. Start from a constructor such as [Object], [String],
+// or [Array], chain modifiers, and call [Builder.Build] for the schema map or
+// [Builder.Output] for a [StructuredOutputInfo].
+//
+// Example:
+//
+//	info := schema.Object().
+//	    Field("language", schema.String().Desc("Programming language")).
+//	    Field("complexity", schema.Enum("low", "medium", "high")).
+//	    Field("functions", schema.Array(schema.String())).
+//	    Required("language", "complexity").
+//	    Output("code_analysis", "Analyze code structure")
+type Builder struct {
+	node     map[string]any
+	props    map[string]*Builder
+	required []string
+}
+
+func newBuilder(jsonType string) *Builder {
+	return &Builder{node: map[string]any{"type": jsonType}}
+}
+
+// Object starts an object schema. Add properties with [Builder.Field]. Built
+// objects are closed with additionalProperties: false, matching
+// [GenerateSchema]; override it with [Builder.Set].
+func Object() *Builder {
+	b := newBuilder("object")
+	b.props = make(map[string]*Builder)
+	return b
+}
+
+// String starts a string schema.
+func String() *Builder {
+	return newBuilder("string")
+}
+
+// Integer starts an integer schema.
+func Integer() *Builder {
+	return newBuilder("integer")
+}
+
+// Number starts a number schema.
+func Number() *Builder {
+	return newBuilder("number")
+}
+
+// Boolean starts a boolean schema.
+func Boolean() *Builder {
+	return newBuilder("boolean")
+}
+
+// Array starts an array schema whose elements match items.
+func Array(items *Builder) *Builder {
+	b := newBuilder("array")
+	b.node["items"] = items
+	return b
+}
+
+// Enum starts a string schema restricted to values.
+func Enum(values ...string) *Builder {
+	b := newBuilder("string")
+	b.node["enum"] = values
+	return b
+}
+
+// OneOf starts a union schema matching exactly one of options. Strict-mode
+// providers receive it as anyOf; see [Strict].
+func OneOf(options ...*Builder) *Builder {
+	return &Builder{node: map[string]any{"oneOf": options}}
+}
+
+// AnyOf starts a union schema matching at least one of options.
+func AnyOf(options ...*Builder) *Builder {
+	return &Builder{node: map[string]any{"anyOf": options}}
+}
+
+// Desc sets the description.
+func (b *Builder) Desc(description string) *Builder {
+	b.node["description"] = description
+	return b
+}
+
+// Field adds a property to an object schema, replacing any property with the
+// same name.
+func (b *Builder) Field(name string, field *Builder) *Builder {
+	if b.props == nil {
+		b.props = make(map[string]*Builder)
+	}
+	b.props[name] = field
+	return b
+}
+
+// Required marks object properties as required. It may be called more than
+// once; names accumulate in order.
+func (b *Builder) Required(names ...string) *Builder {
+	b.required = append(b.required, names...)
+	return b
+}
+
+// Nullable additionally allows null, e.g. "string" becomes
+// ["string", "null"]. It has no effect on a union.
+func (b *Builder) Nullable() *Builder {
+	if t, ok := b.node["type"].(string); ok {
+		b.node["type"] = []string{t, "null"}
+	}
+	return b
+}
+
+// Set sets any other JSON Schema keyword, such as "minimum", "maxItems", or
+// "format". A *Builder value is built in place.
+func (b *Builder) Set(keyword string, value any) *Builder {
+	b.node[keyword] = value
+	return b
+}
+
+// Build returns the JSON Schema for this node. Each call returns a fresh map,
+// so the builder can keep being modified and built again.
+func (b *Builder) Build() map[string]any {
+	out := make(map[string]any, len(b.node)+2)
+	for key, value := range b.node {
+		out[key] = buildValue(value)
+	}
+	if b.props != nil {
+		out["properties"] = b.properties()
+		if _, ok := out["additionalProperties"]; !ok {
+			out["additionalProperties"] = false
+		}
+	}
+	if len(b.required) > 0 {
+		out["required"] = append([]string(nil), b.required...)
+	}
+	return out
+}
+
+// Output returns a [StructuredOutputInfo] whose Parameters and Required come
+// from this object schema.
+func (b *Builder) Output(name, description string) *StructuredOutputInfo {
+	return NewStructuredOutputInfo(
+		name,
+		description,
+		b.properties(),
+		append([]string(nil), b.required...),
+	)
+}
+
+func (b *Builder) properties() map[string]any {
+	props := make(map[string]any, len(b.props))
+	for name, field := range b.props {
+		props[name] = field.Build()
+	}
+	return props
+}
+
+func buildValue(value any) any {
+	switch v := value.(type) {
+	case *Builder:
+		return v.Build()
+	case []*Builder:
+		out := make([]any, len(v))
+		for i, option := range v {
+			out[i] = option.Build()
+		}
+		return out
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return value
+	}
+}
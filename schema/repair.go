@@ -0,0 +1,240 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type parseOptions struct {
+	repair bool
+}
+
+// ParseOption configures [Parse].
+type ParseOption func(*parseOptions)
+
+// WithAutoRepair makes [Parse] retry with [Repair] applied when the raw
+// output is not valid JSON.
+func WithAutoRepair() ParseOption {
+	return func(o *parseOptions) {
+		o.repair = true
+	}
+}
+
+// Parse unmarshals structured output from a model into v. Without options it
+// behaves like json.Unmarshal. With [WithAutoRepair], output that fails to
+// parse is passed through [Repair] and parsed once more; if that also fails
+// the original error is returned.
+//
+// Example:
+//
+//	var analysis CodeAnalysis
+//	err := schema.Parse(*resp.StructuredOutput, &analysis, schema.WithAutoRepair())
+func Parse(raw string, v any, opts ...ParseOption) error {
+	var o parseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	err := json.Unmarshal([]byte(raw), v)
+	if err == nil || !o.repair {
+		return err
+	}
+	if repairErr := json.Unmarshal([]byte(Repair(raw)), v); repairErr != nil {
+		return fmt.Errorf("schema: parse structured output: %w", err)
+	}
+	return nil
+}
+
+// Repair rewrites almost-valid JSON from a model into valid JSON. It is best
+// effort: input it cannot fix is returned in a form that still fails to parse.
+// The repairs attempted are:
+//
+//   - surrounding whitespace and markdown code fences (```json ... ```) are
+//     removed;
+//   - prose before the first '{' or '[' and after the matching close is
+//     dropped;
+//   - trailing commas before '}' or ']' are removed;
+//   - bare identifier keys ({name: 1}) are quoted;
+//   - single-quoted strings are converted to double-quoted ones;
+//   - truncated output is closed: an unterminated string gets its closing
+//     quote, a dangling ':' gets null, and open objects and arrays are
+//     closed in order.
+//
+// Content inside strings is never altered.
+func Repair(raw string) string {
+	text := extractJSON(stripFences(strings.TrimSpace(raw)))
+	r := repairer{src: text}
+	return r.run()
+}
+
+func stripFences(text string) string {
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+		text = text[newline+1:]
+	} else {
+		text = strings.TrimPrefix(text, "```")
+	}
+	text = strings.TrimSpace(text)
+	return strings.TrimSpace(strings.TrimSuffix(text, "```"))
+}
+
+// extractJSON trims text to start at the first '{' or '['. Trailing prose is
+// dropped by the repairer once the top-level value closes.
+func extractJSON(text string) string {
+	if start := strings.IndexAny(text, "{["); start >= 0 {
+		return text[start:]
+	}
+	return text
+}
+
+type repairer struct {
+	src    string
+	pos    int
+	out    strings.Builder
+	closes []byte
+}
+
+func (r *repairer) run() string {
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case c == '"' || c == '\'':
+			r.copyString(c)
+		case c == '{' || c == '[':
+			r.closes = append(r.closes, closerFor(c))
+			r.out.WriteByte(c)
+			r.pos++
+		case c == '}' || c == ']':
+			r.trimTrailingComma()
+			if n := len(r.closes); n > 0 {
+				r.closes = r.closes[:n-1]
+			}
+			r.out.WriteByte(c)
+			r.pos++
+			if len(r.closes) == 0 {
+				return r.out.String()
+			}
+		case isIdentStart(c) && r.expectingKey():
+			r.copyKey()
+		default:
+			r.out.WriteByte(c)
+			r.pos++
+		}
+	}
+	return r.finish()
+}
+
+// copyString copies a string literal starting at the opening quote, emitting
+// it double-quoted. An unterminated string is closed, dropping a dangling
+// backslash that would otherwise escape the closing quote.
+func (r *repairer) copyString(quote byte) {
+	r.out.WriteByte('"')
+	r.pos++
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case c == '\\' && r.pos+1 < len(r.src):
+			next := r.src[r.pos+1]
+			if quote == '\'' && next == '\'' {
+				r.out.WriteByte('\'')
+			} else {
+				r.out.WriteByte(c)
+				r.out.WriteByte(next)
+			}
+			r.pos += 2
+			continue
+		case c == '\\':
+		case c == quote:
+			r.out.WriteByte('"')
+			r.pos++
+			return
+		case c == '"':
+			r.out.WriteString(`\"`)
+		default:
+			r.out.WriteByte(c)
+		}
+		r.pos++
+	}
+	r.out.WriteByte('"')
+}
+
+// copyKey quotes a bare identifier when it is followed by ':'; otherwise it
+// is copied unchanged.
+func (r *repairer) copyKey() {
+	start := r.pos
+	for r.pos < len(r.src) && isIdentPart(r.src[r.pos]) {
+		r.pos++
+	}
+	ident := r.src[start:r.pos]
+	rest := strings.TrimLeft(r.src[r.pos:], " \t\r\n")
+	if strings.HasPrefix(rest, ":") {
+		r.out.WriteString(`"` + ident + `"`)
+		return
+	}
+	r.out.WriteString(ident)
+}
+
+// expectingKey reports whether the output is positioned where an object key
+// belongs: inside an object, right after '{' or ','.
+func (r *repairer) expectingKey() bool {
+	if len(r.closes) == 0 || r.closes[len(r.closes)-1] != '}' {
+		return false
+	}
+	last := lastNonSpace(r.out.String())
+	return last == '{' || last == ','
+}
+
+func (r *repairer) trimTrailingComma() {
+	text := r.out.String()
+	trimmed := strings.TrimRight(text, " \t\r\n")
+	if !strings.HasSuffix(trimmed, ",") {
+		return
+	}
+	r.out.Reset()
+	r.out.WriteString(trimmed[:len(trimmed)-1])
+	r.out.WriteString(text[len(trimmed):])
+}
+
+func (r *repairer) finish() string {
+	if len(r.closes) == 0 {
+		return r.out.String()
+	}
+	r.trimTrailingComma()
+	text := strings.TrimRight(r.out.String(), " \t\r\n")
+	if strings.HasSuffix(text, ":") {
+		text += " null"
+	}
+	var b strings.Builder
+	b.WriteString(text)
+	for i := len(r.closes) - 1; i >= 0; i-- {
+		b.WriteByte(r.closes[i])
+	}
+	return b.String()
+}
+
+func closerFor(open byte) byte {
+	if open == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+func lastNonSpace(text string) byte {
+	text = strings.TrimRight(text, " \t\r\n")
+	if text == "" {
+		return 0
+	}
+	return text[len(text)-1]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -19,6 +19,32 @@ type StructuredOutputInfo struct {
 	Parameters map[string]any `json:"parameters"`
 	// Required lists the property names that must be present in the output.
 	Required []string `json:"required"`
+	// Schema is a complete JSON Schema for the output. When set it is used
+	// instead of Parameters and Required, so it may use $defs and $ref,
+	// oneOf/anyOf unions, and arbitrarily nested objects.
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// JSONSchema returns the root JSON Schema for the output: Schema when set,
+// otherwise an object schema built from Parameters and Required.
+func (s *StructuredOutputInfo) JSONSchema() map[string]any {
+	if s.Schema != nil {
+		return s.Schema
+	}
+	root := map[string]any{
+		"type":       "object",
+		"properties": s.Parameters,
+	}
+	if len(s.Required) > 0 {
+		root["required"] = s.Required
+	}
+	return root
+}
+
+// StrictJSONSchema returns [StructuredOutputInfo.JSONSchema] adapted with
+// [Strict] for providers that enforce OpenAI-style strict mode.
+func (s *StructuredOutputInfo) StrictJSONSchema() map[string]any {
+	return Strict(s.JSONSchema())
 }
 
 // NewStructuredOutputInfo creates a new structured output schema with the provided parameters.
@@ -35,6 +61,34 @@ func NewStructuredOutputInfo(
 	}
 }
 
+// NewStructuredOutputFromSchema creates a structured output schema from a
+// complete JSON Schema document, for shapes struct reflection cannot express
+// such as discriminated unions or recursive definitions.
+//
+// Example:
+//
+//	shape := schema.NewStructuredOutputFromSchema("shape", "A shape", map[string]any{
+//	    "type": "object",
+//	    "properties": map[string]any{
+//	        "shape": map[string]any{"oneOf": []any{
+//	            map[string]any{"$ref": "#/$defs/circle"},
+//	            map[string]any{"$ref": "#/$defs/square"},
+//	        }},
+//	    },
+//	    "required": []string{"shape"},
+//	    "$defs": map[string]any{ /* circle, square */ },
+//	})
+func NewStructuredOutputFromSchema(
+	name, description string,
+	jsonSchema map[string]any,
+) *StructuredOutputInfo {
+	return &StructuredOutputInfo{
+		Name:        name,
+		Description: description,
+		Schema:      jsonSchema,
+	}
+}
+
 // NewStructuredOutputFromStruct creates a new structured output schema from a Go struct.
 // It uses reflection to automatically generate the JSON schema from struct fields and tags.
 //
@@ -0,0 +1,190 @@
+package schema
+
+import "slices"
+
+// Strict returns a copy of jsonSchema rewritten for OpenAI-style strict
+// structured output, which rejects schemas that leave any object open or any
+// property optional:
+//
+//   - every object with properties gets additionalProperties: false;
+//   - every property is listed in required, and properties that were not
+//     required become nullable so the model can still omit a value;
+//   - oneOf is rewritten to anyOf, the union keyword strict mode accepts.
+//
+// The walk covers nested properties, array items, anyOf/oneOf/allOf branches,
+// and $defs/definitions, so $ref targets are adapted too. jsonSchema itself
+// is not modified.
+func Strict(jsonSchema map[string]any) map[string]any {
+	return adaptNode(jsonSchema, true)
+}
+
+// CloseObjects returns a copy of jsonSchema with additionalProperties: false
+// set on every object that has properties, for providers that require closed
+// objects but allow optional properties. jsonSchema itself is not modified.
+func CloseObjects(jsonSchema map[string]any) map[string]any {
+	return adaptNode(jsonSchema, false)
+}
+
+// adaptNode copies node, recursing into every subschema position.
+func adaptNode(node map[string]any, strict bool) map[string]any {
+	if node == nil {
+		return nil
+	}
+	out := make(map[string]any, len(node))
+	for key, value := range node {
+		switch key {
+		case "properties", "$defs", "definitions":
+			out[key] = adaptSchemaMap(value, strict)
+		case "items", "not", "additionalProperties":
+			out[key] = adaptValue(value, strict)
+		case "anyOf", "oneOf", "allOf", "prefixItems":
+			out[key] = adaptList(value, strict)
+		default:
+			out[key] = copyValue(value)
+		}
+	}
+
+	if strict {
+		if union, ok := out["oneOf"]; ok {
+			delete(out, "oneOf")
+			out["anyOf"] = union
+		}
+	}
+
+	props, ok := out["properties"].(map[string]any)
+	if !ok {
+		return out
+	}
+	out["additionalProperties"] = false
+	if !strict {
+		return out
+	}
+
+	required := stringList(out["required"])
+	names := make([]string, 0, len(props))
+	for name, prop := range props {
+		names = append(names, name)
+		if slices.Contains(required, name) {
+			continue
+		}
+		if propSchema, ok := prop.(map[string]any); ok {
+			props[name] = nullable(propSchema)
+		}
+	}
+	slices.Sort(names)
+	out["required"] = names
+	return out
+}
+
+func adaptValue(value any, strict bool) any {
+	if node, ok := value.(map[string]any); ok {
+		return adaptNode(node, strict)
+	}
+	return copyValue(value)
+}
+
+func adaptSchemaMap(value any, strict bool) any {
+	nodes, ok := value.(map[string]any)
+	if !ok {
+		return copyValue(value)
+	}
+	out := make(map[string]any, len(nodes))
+	for name, node := range nodes {
+		out[name] = adaptValue(node, strict)
+	}
+	return out
+}
+
+func adaptList(value any, strict bool) any {
+	nodes, ok := value.([]any)
+	if !ok {
+		return copyValue(value)
+	}
+	out := make([]any, len(nodes))
+	for i, node := range nodes {
+		out[i] = adaptValue(node, strict)
+	}
+	return out
+}
+
+// nullable returns prop widened to also accept null. Typed schemas gain
+// "null" in their type (and enum); untyped ones such as $ref or unions are
+// wrapped in anyOf with a null branch.
+func nullable(prop map[string]any) map[string]any {
+	switch t := prop["type"].(type) {
+	case string:
+		if t == "null" {
+			return prop
+		}
+		prop["type"] = []any{t, "null"}
+	case []string:
+		if slices.Contains(t, "null") {
+			return prop
+		}
+		prop["type"] = append(stringsToAny(t), "null")
+	case []any:
+		if slices.Contains(t, any("null")) {
+			return prop
+		}
+		prop["type"] = append(t, "null")
+	default:
+		return map[string]any{
+			"anyOf": []any{prop, map[string]any{"type": "null"}},
+		}
+	}
+
+	switch enum := prop["enum"].(type) {
+	case []string:
+		prop["enum"] = append(stringsToAny(enum), nil)
+	case []any:
+		prop["enum"] = append(enum, nil)
+	}
+	return prop
+}
+
+// copyValue deep-copies the map and slice shapes found in JSON Schema
+// documents built in Go or decoded from JSON. Other values are shared.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = copyValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = copyValue(item)
+		}
+		return out
+	case []string:
+		return slices.Clone(v)
+	default:
+		return value
+	}
+}
+
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func stringsToAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -29,6 +29,13 @@
 //	    agent.WithSession("test-session", store),
 //	)
 //
+// # Redaction
+//
+// Wrap any store with [WithRedactor] to mask secrets and PII before messages
+// are persisted:
+//
+//	store := session.WithRedactor(session.FileStore("./sessions"), session.RegexRedactor())
+//
 // # Custom Implementations
 //
 // Implement the [Store] interface for custom backends like PostgreSQL or Redis.
@@ -1,8 +1,11 @@
 package session
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -12,16 +15,41 @@ import (
 
 // fileStore is a file-based session store that persists conversations to disk.
 type fileStore struct {
-	dir string
+	dir      string
+	compress bool
+}
+
+// FileOption configures a file-based session store.
+type FileOption func(*fileStore)
+
+// WithCompression gzip-compresses session files on write. Files are
+// decompressed transparently on read, and uncompressed files written before
+// compression was enabled remain readable.
+func WithCompression() FileOption {
+	return func(s *fileStore) {
+		s.compress = true
+	}
 }
 
 // FileStore creates a file-based session store that persists conversations to disk.
 // Sessions are stored as JSON files in the specified directory.
-func FileStore(dir string) Store {
+func FileStore(dir string, opts ...FileOption) Store {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil
 	}
-	return &fileStore{dir: dir}
+	store := &fileStore{dir: dir}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+func (s *fileStore) session(id string) *fileSession {
+	return &fileSession{
+		id:       id,
+		filePath: s.filePath(id),
+		compress: s.compress,
+	}
 }
 
 func (s *fileStore) filePath(id string) string {
@@ -40,24 +68,34 @@ func (s *fileStore) Exists(_ context.Context, id string) (bool, error) {
 }
 
 func (s *fileStore) Create(_ context.Context, id string) (Session, error) {
-	filePath := s.filePath(id)
-	if err := os.WriteFile(filePath, []byte("[]"), 0644); err != nil {
+	session := s.session(id)
+	if err := session.saveMessages([]message.Message{}); err != nil {
 		return nil, err
 	}
-	return &fileSession{id: id, filePath: filePath}, nil
+	return session, nil
 }
 
 func (s *fileStore) Load(_ context.Context, id string) (Session, error) {
-	return &fileSession{id: id, filePath: s.filePath(id)}, nil
+	return s.session(id), nil
 }
 
 func (s *fileStore) Delete(_ context.Context, id string) error {
-	return os.Remove(s.filePath(id))
+	if err := os.Remove(s.filePath(id)); err != nil {
+		return err
+	}
+	for _, reserved := range ReservedIDs(id) {
+		err := os.Remove(s.filePath(reserved))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 type fileSession struct {
 	id       string
 	filePath string
+	compress bool
 	mu       sync.RWMutex
 }
 
@@ -155,6 +193,13 @@ func (s *fileSession) loadMessages() ([]message.Message, error) {
 		return nil, err
 	}
 
+	if isGzip(data) {
+		data, err = gunzip(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var messages []message.Message
 	if err := json.Unmarshal(data, &messages); err != nil {
 		return nil, err
@@ -164,10 +209,72 @@ func (s *fileSession) loadMessages() ([]message.Message, error) {
 }
 
 func (s *fileSession) saveMessages(messages []message.Message) error {
-	data, err := json.MarshalIndent(messages, "", "  ")
+	if s.compress {
+		data, err := message.MarshalUnescaped(messages)
+		if err != nil {
+			return err
+		}
+		data, err = gzipBytes(data)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(s.filePath, data)
+	}
+
+	data, err := message.MarshalUnescaped(messages)
 	if err != nil {
 		return err
 	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.filePath, indented.Bytes())
+}
 
-	return os.WriteFile(s.filePath, data, 0644)
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so a crash mid-write leaves the previous contents intact.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
 }
@@ -2,9 +2,10 @@ module github.com/joakimcarlsson/ai/session
 
 go 1.25.0
 
-require github.com/joakimcarlsson/ai/message v0.4.0
-
-require github.com/joakimcarlsson/ai/model v0.6.0 // indirect
+require (
+	github.com/joakimcarlsson/ai/message v0.4.0
+	github.com/joakimcarlsson/ai/model v0.6.0
+)
 
 replace (
 	github.com/joakimcarlsson/ai/message => ../message
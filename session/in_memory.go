@@ -42,6 +42,9 @@ func (s *memoryStore) Load(_ context.Context, id string) (Session, error) {
 
 func (s *memoryStore) Delete(_ context.Context, id string) error {
 	s.sessions.Delete(id)
+	for _, reserved := range ReservedIDs(id) {
+		s.sessions.Delete(reserved)
+	}
 	return nil
 }
 
@@ -0,0 +1,29 @@
+package session
+
+import (
+	"context"
+	"errors"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// ErrInvalidCursor is returned by [PagedStore.GetAllPaged] when the cursor
+// was not returned by an earlier call for the same store.
+var ErrInvalidCursor = errors.New("session: invalid cursor")
+
+// PagedStore is a [Store] that can page through a session's messages
+// without loading the whole conversation, for admin UIs and dashboards. The
+// PostgreSQL store in memory/postgres implements it.
+type PagedStore interface {
+	Store
+	// GetAllPaged returns up to pageSize messages of session id, oldest
+	// first, starting after cursor. Pass an empty cursor for the first page.
+	// The returned cursor fetches the next page and is empty after the last
+	// one.
+	GetAllPaged(
+		ctx context.Context,
+		id string,
+		cursor string,
+		pageSize int,
+	) ([]message.Message, string, error)
+}
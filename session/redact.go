@@ -0,0 +1,309 @@
+package session
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// Redactor transforms a message before it is persisted, typically to mask
+// secrets or personally identifiable information. It must not mutate the
+// message it receives.
+type Redactor func(message.Message) message.Message
+
+// RedactOption configures [WithRedactor].
+type RedactOption func(*redactOptions)
+
+type redactOptions struct {
+	onRead bool
+}
+
+// RedactOnRead also applies the redactor to messages returned by GetMessages
+// and PopMessage, so history written before redaction was enabled is masked
+// too.
+func RedactOnRead() RedactOption {
+	return func(o *redactOptions) { o.onRead = true }
+}
+
+// WithRedactor wraps a store so every message passes through redactor before
+// it is written to the underlying backend. It works with any [Store],
+// including the SQL-backed ones. Reserved state and usage records (see
+// [IsReserved]) hold JSON written by this package and are not redacted. A nil
+// redactor returns store unchanged.
+//
+//	pg, err := postgres.SessionStore(ctx, connString)
+//	if err != nil {
+//		return err
+//	}
+//	store := session.WithRedactor(pg, session.RegexRedactor())
+func WithRedactor(
+	store Store,
+	redactor Redactor,
+	opts ...RedactOption,
+) Store {
+	if redactor == nil {
+		return store
+	}
+	options := redactOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &redactingStore{
+		inner:    store,
+		redactor: redactor,
+		options:  options,
+	}
+}
+
+type redactingStore struct {
+	inner    Store
+	redactor Redactor
+	options  redactOptions
+}
+
+func (s *redactingStore) wrap(sess Session, id string) Session {
+	if sess == nil || IsReserved(id) {
+		return sess
+	}
+	return &redactingSession{inner: sess, store: s}
+}
+
+func (s *redactingStore) Exists(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	return s.inner.Exists(ctx, id)
+}
+
+func (s *redactingStore) Create(
+	ctx context.Context,
+	id string,
+) (Session, error) {
+	sess, err := s.inner.Create(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrap(sess, id), nil
+}
+
+func (s *redactingStore) Load(
+	ctx context.Context,
+	id string,
+) (Session, error) {
+	sess, err := s.inner.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrap(sess, id), nil
+}
+
+func (s *redactingStore) Delete(ctx context.Context, id string) error {
+	return s.inner.Delete(ctx, id)
+}
+
+type redactingSession struct {
+	inner Session
+	store *redactingStore
+}
+
+func (s *redactingSession) ID() string {
+	return s.inner.ID()
+}
+
+func (s *redactingSession) GetMessages(
+	ctx context.Context,
+	limit *int,
+) ([]message.Message, error) {
+	msgs, err := s.inner.GetMessages(ctx, limit)
+	if err != nil || !s.store.options.onRead {
+		return msgs, err
+	}
+	return s.redactAll(msgs), nil
+}
+
+func (s *redactingSession) AddMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	return s.inner.AddMessages(ctx, s.redactAll(msgs))
+}
+
+func (s *redactingSession) SetMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	return ReplaceMessages(ctx, s.inner, s.redactAll(msgs))
+}
+
+func (s *redactingSession) PopMessage(
+	ctx context.Context,
+) (*message.Message, error) {
+	msg, err := s.inner.PopMessage(ctx)
+	if err != nil || msg == nil || !s.store.options.onRead {
+		return msg, err
+	}
+	redacted := s.store.redactor(*msg)
+	return &redacted, nil
+}
+
+func (s *redactingSession) Clear(ctx context.Context) error {
+	return s.inner.Clear(ctx)
+}
+
+func (s *redactingSession) redactAll(
+	msgs []message.Message,
+) []message.Message {
+	if len(msgs) == 0 {
+		return msgs
+	}
+	out := make([]message.Message, len(msgs))
+	for i, msg := range msgs {
+		out[i] = s.store.redactor(msg)
+	}
+	return out
+}
+
+// RedactionRule masks every match of Pattern with Replacement. When Validate
+// is set, only matches for which it returns true are masked.
+type RedactionRule struct {
+	// Name identifies the rule.
+	Name string
+	// Pattern matches the text to mask.
+	Pattern *regexp.Regexp
+	// Replacement is substituted for each masked match.
+	Replacement string
+	// Validate filters matches, e.g. by checksum. Nil masks every match.
+	Validate func(match string) bool
+}
+
+// Built-in redaction rules used by [RegexRedactor] when no rules are given.
+var (
+	// EmailRule masks email addresses.
+	EmailRule = RedactionRule{
+		Name: "email",
+		Pattern: regexp.MustCompile(
+			`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+		),
+		Replacement: "[REDACTED_EMAIL]",
+	}
+	// CreditCardRule masks 13 to 19 digit card numbers, optionally separated
+	// by spaces or dashes, that pass the Luhn checksum.
+	CreditCardRule = RedactionRule{
+		Name:        "credit_card",
+		Pattern:     regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Replacement: "[REDACTED_CREDIT_CARD]",
+		Validate:    luhnValid,
+	}
+	// APIKeyRule masks common API key and token formats (OpenAI/Anthropic
+	// style sk- keys, AWS access key IDs, GitHub tokens, Google API keys,
+	// Slack tokens) and bearer tokens.
+	APIKeyRule = RedactionRule{
+		Name: "api_key",
+		Pattern: regexp.MustCompile(
+			`\b(?:sk-[A-Za-z0-9_\-]{16,}` +
+				`|AKIA[0-9A-Z]{16}` +
+				`|gh[pousr]_[A-Za-z0-9]{36,}` +
+				`|AIza[0-9A-Za-z_\-]{35}` +
+				`|xox[abprs]-[A-Za-z0-9\-]{10,})` +
+				`|(?i:bearer)\s+[A-Za-z0-9._~+/\-]{20,}=*`,
+		),
+		Replacement: "[REDACTED_API_KEY]",
+	}
+)
+
+// DefaultRedactionRules returns the built-in email, credit card, and API key
+// rules.
+func DefaultRedactionRules() []RedactionRule {
+	return []RedactionRule{APIKeyRule, EmailRule, CreditCardRule}
+}
+
+// RegexRedactor returns a [Redactor] that applies rules to message text,
+// reasoning, tool call inputs, and tool results. With no rules it uses
+// [DefaultRedactionRules].
+func RegexRedactor(rules ...RedactionRule) Redactor {
+	if len(rules) == 0 {
+		rules = DefaultRedactionRules()
+	}
+	redact := func(s string) string {
+		for _, rule := range rules {
+			s = rule.apply(s)
+		}
+		return s
+	}
+	return func(msg message.Message) message.Message {
+		return RedactText(msg, redact)
+	}
+}
+
+// RedactText returns a copy of msg with redact applied to every text-bearing
+// part: text, reasoning, tool call inputs, and tool result content. Use it to
+// build custom redactors.
+func RedactText(
+	msg message.Message,
+	redact func(string) string,
+) message.Message {
+	if len(msg.Parts) == 0 {
+		return msg
+	}
+	parts := make([]message.ContentPart, len(msg.Parts))
+	for i, part := range msg.Parts {
+		switch p := part.(type) {
+		case message.TextContent:
+			p.Text = redact(p.Text)
+			parts[i] = p
+		case message.ReasoningContent:
+			p.Text = redact(p.Text)
+			parts[i] = p
+		case message.ToolCall:
+			p.Input = redact(p.Input)
+			parts[i] = p
+		case message.ToolResult:
+			p.Content = redact(p.Content)
+			parts[i] = p
+		default:
+			parts[i] = part
+		}
+	}
+	msg.Parts = parts
+	return msg
+}
+
+func (r RedactionRule) apply(s string) string {
+	if r.Pattern == nil {
+		return s
+	}
+	return r.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+		if r.Validate != nil && !r.Validate(match) {
+			return match
+		}
+		return r.Replacement
+	})
+}
+
+func luhnValid(number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ReservedIDs returns the IDs of the reserved records kept alongside session
+// id: its state (see [StateSuffix]) and its usage (see [UsageSuffix]). Store
+// implementations delete them together with the session so they are not left
+// behind.
+func ReservedIDs(id string) []string {
+	return []string{id + StateSuffix, id + UsageSuffix}
+}
+
+// IsReserved reports whether id names a reserved record rather than a
+// conversation.
+func IsReserved(id string) bool {
+	return strings.HasSuffix(id, StateSuffix) ||
+		strings.HasSuffix(id, UsageSuffix)
+}
+
+var recordMu sync.Mutex
+
+// openRecord loads the reserved record id, creating it when it is missing.
+// Creation is serialized within the process, and a record that another
+// process created first is loaded rather than failing or being overwritten.
+func openRecord(
+	ctx context.Context,
+	store Store,
+	id string,
+) (Session, error) {
+	exists, err := store.Exists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return store.Load(ctx, id)
+	}
+
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	exists, err = store.Exists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return store.Load(ctx, id)
+	}
+	sess, err := store.Create(ctx, id)
+	if err == nil {
+		return sess, nil
+	}
+	if exists, existsErr := store.Exists(ctx, id); existsErr == nil && exists {
+		return store.Load(ctx, id)
+	}
+	return nil, err
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/joakimcarlsson/ai/message"
+)
+
+// StateSuffix is appended to a session ID to form the ID of the reserved
+// record that holds key-value state for that session, such as an agent's
+// template variables. The record is an ordinary session in the same store
+// holding a single message, so every [Store] implementation supports it.
+const StateSuffix = ".state"
+
+// LoadState reads the state saved for session id with [SaveState]. It returns
+// nil without error when no state has been saved. Values come back as decoded
+// JSON, so numbers are float64 and nested objects are map[string]any.
+func LoadState(
+	ctx context.Context,
+	store Store,
+	id string,
+) (map[string]any, error) {
+	stateID := id + StateSuffix
+	exists, err := store.Exists(ctx, stateID)
+	if err != nil || !exists {
+		return nil, err
+	}
+	sess, err := store.Load(ctx, stateID)
+	if err != nil || sess == nil {
+		return nil, err
+	}
+	msgs, err := sess.GetMessages(ctx, nil)
+	if err != nil || len(msgs) == 0 {
+		return nil, err
+	}
+
+	var state map[string]any
+	text := msgs[len(msgs)-1].Content().Text
+	if err := json.Unmarshal([]byte(text), &state); err != nil {
+		return nil, fmt.Errorf("session: decode state for %s: %w", id, err)
+	}
+	return state, nil
+}
+
+// SaveState replaces the state saved for session id. state must be JSON
+// serializable. The record is replaced with [ReplaceMessages], so with the
+// built-in stores a failed or interrupted save keeps the previous state.
+func SaveState(
+	ctx context.Context,
+	store Store,
+	id string,
+	state map[string]any,
+) error {
+	data, err := message.MarshalUnescaped(state)
+	if err != nil {
+		return fmt.Errorf("session: encode state for %s: %w", id, err)
+	}
+	record := []message.Message{message.NewSystemMessage(string(data))}
+
+	sess, err := openRecord(ctx, store, id+StateSuffix)
+	if err != nil {
+		return err
+	}
+	if sess == nil {
+		return fmt.Errorf("session: state record for %s unavailable", id)
+	}
+	return ReplaceMessages(ctx, sess, record)
+}
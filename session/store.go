@@ -2,6 +2,8 @@ package session
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/joakimcarlsson/ai/message"
 )
@@ -15,6 +17,48 @@ type Session interface {
 	Clear(ctx context.Context) error
 }
 
+// Replacer is implemented by sessions that can replace their whole history
+// in one step, so a failure part way through leaves the previous history in
+// place. Every built-in store's sessions implement it.
+type Replacer interface {
+	SetMessages(ctx context.Context, msgs []message.Message) error
+}
+
+// ReplaceMessages replaces the messages stored in sess with msgs, atomically
+// when sess implements [Replacer]. Otherwise it clears the session and adds
+// msgs, and puts the previous messages back when adding fails.
+func ReplaceMessages(
+	ctx context.Context,
+	sess Session,
+	msgs []message.Message,
+) error {
+	if r, ok := sess.(Replacer); ok {
+		return r.SetMessages(ctx, msgs)
+	}
+	previous, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := sess.Clear(ctx); err != nil {
+		return err
+	}
+	if err := sess.AddMessages(ctx, msgs); err != nil {
+		restoreErr := sess.Clear(ctx)
+		if restoreErr == nil {
+			restoreErr = sess.AddMessages(ctx, previous)
+		}
+		if restoreErr != nil {
+			return errors.Join(err, fmt.Errorf(
+				"session: restore %s: %w",
+				sess.ID(),
+				restoreErr,
+			))
+		}
+		return err
+	}
+	return nil
+}
+
 // Store manages session persistence and retrieval.
 type Store interface {
 	Exists(ctx context.Context, id string) (bool, error)
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// UsageSuffix is appended to a session ID to form the ID of the reserved
+// record that holds token usage for that session. Like the state record (see
+// [StateSuffix]) it is an ordinary session in the same store; each model call
+// is appended as one message carrying the model ID and its usage, so the
+// record is append-only and never rewritten.
+const UsageSuffix = ".usage"
+
+// Usage is token usage and estimated cost, either for a single model call or
+// summed over a session.
+type Usage struct {
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int64   `json:"cache_read_tokens,omitempty"`
+	ReasoningTokens     int64   `json:"reasoning_tokens,omitempty"`
+	Cost                float64 `json:"cost,omitempty"`
+	// CacheSavings is the estimated cost avoided by prompt caching, in USD.
+	// It is negative when cache writes cost more than the reads saved.
+	CacheSavings float64 `json:"cache_savings,omitempty"`
+	// Calls is the number of model calls summed. RecordUsage counts each
+	// record as one call regardless of this field.
+	Calls int `json:"-"`
+}
+
+// Add accumulates other into u.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.Cost += other.Cost
+	u.CacheSavings += other.CacheSavings
+	u.Calls += other.Calls
+}
+
+// RecordUsage appends the usage of one model call made with modelID to the
+// usage record of session id. Concurrent first calls for a session create the
+// record once, so no call is lost to a racing create.
+func RecordUsage(
+	ctx context.Context,
+	store Store,
+	id string,
+	modelID model.ID,
+	usage Usage,
+) error {
+	data, err := message.MarshalUnescaped(usage)
+	if err != nil {
+		return fmt.Errorf("session: encode usage for %s: %w", id, err)
+	}
+	entry := message.NewSystemMessage(string(data))
+	entry.Model = modelID
+
+	sess, err := openRecord(ctx, store, id+UsageSuffix)
+	if err != nil {
+		return err
+	}
+	if sess == nil {
+		return fmt.Errorf("session: usage record for %s unavailable", id)
+	}
+	return sess.AddMessages(ctx, []message.Message{entry})
+}
+
+// LoadUsage returns the usage summed over every call recorded for session id
+// with [RecordUsage]. It returns a zero Usage without error when nothing has
+// been recorded.
+func LoadUsage(ctx context.Context, store Store, id string) (Usage, error) {
+	byModel, err := LoadUsageByModel(ctx, store, id)
+	if err != nil {
+		return Usage{}, err
+	}
+	var total Usage
+	for _, u := range byModel {
+		total.Add(u)
+	}
+	return total, nil
+}
+
+// LoadUsageByModel is like [LoadUsage] but keeps a separate total per model,
+// for sessions that switch models or hand off between agents.
+func LoadUsageByModel(
+	ctx context.Context,
+	store Store,
+	id string,
+) (map[model.ID]Usage, error) {
+	usageID := id + UsageSuffix
+	exists, err := store.Exists(ctx, usageID)
+	if err != nil || !exists {
+		return nil, err
+	}
+	sess, err := store.Load(ctx, usageID)
+	if err != nil || sess == nil {
+		return nil, err
+	}
+	msgs, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[model.ID]Usage)
+	for _, msg := range msgs {
+		var call Usage
+		if err := json.Unmarshal(
+			[]byte(msg.Content().Text),
+			&call,
+		); err != nil {
+			return nil, fmt.Errorf("session: decode usage for %s: %w", id, err)
+		}
+		call.Calls = 1
+		total := totals[msg.Model]
+		total.Add(call)
+		totals[msg.Model] = total
+	}
+	return totals, nil
+}
+
+// ResetUsage deletes the usage recorded for session id. The conversation
+// itself is left untouched.
+func ResetUsage(ctx context.Context, store Store, id string) error {
+	usageID := id + UsageSuffix
+	exists, err := store.Exists(ctx, usageID)
+	if err != nil || !exists {
+		return err
+	}
+	return store.Delete(ctx, usageID)
+}
@@ -227,6 +227,9 @@ func (c *Client) Transcribe(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	uploadURL, err := c.upload(ctx, audioFile)
 	if err != nil {
@@ -235,7 +238,7 @@ func (c *Client) Transcribe(
 
 	transcriptReq := transcriptRequest{
 		AudioURL:      uploadURL,
-		SpeakerLabels: c.options.speakerLabels,
+		SpeakerLabels: c.options.speakerLabels || opts.Diarization,
 	}
 
 	apiModel := c.options.model.APIModel
@@ -416,13 +419,24 @@ func (c *Client) mapResponse(result *transcriptResponse) *stt.Response {
 	words := make([]stt.Word, len(result.Words))
 	for i, w := range result.Words {
 		words[i] = stt.Word{
-			Word:  w.Text,
-			Start: float64(w.Start) / 1000.0,
-			End:   float64(w.End) / 1000.0,
+			Word:    w.Text,
+			Start:   float64(w.Start) / 1000.0,
+			End:     float64(w.End) / 1000.0,
+			Speaker: w.Speaker,
 		}
 	}
 	resp.Words = words
 
+	for i, u := range result.Utterances {
+		resp.Segments = append(resp.Segments, stt.Segment{
+			ID:      i,
+			Start:   float64(u.Start) / 1000.0,
+			End:     float64(u.End) / 1000.0,
+			Text:    u.Text,
+			Speaker: u.Speaker,
+		})
+	}
+
 	return resp
 }
 
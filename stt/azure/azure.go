@@ -172,7 +172,7 @@ type definitionPayload struct {
 }
 
 type diarizationPayload struct {
-	MaxSpeakers int  `json:"maxSpeakers"`
+	MaxSpeakers int  `json:"maxSpeakers,omitempty"`
 	Enabled     bool `json:"enabled"`
 }
 
@@ -216,6 +216,9 @@ func (c *Client) Transcribe(
 	for _, o := range options {
 		o(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	if c.options.apiKey == "" {
 		return nil, fmt.Errorf("azure speech: api key is required")
@@ -231,7 +234,7 @@ func (c *Client) Transcribe(
 		Channels:        c.options.channels,
 		ProfanityFilter: c.options.profanity,
 	}
-	if c.options.diarize {
+	if c.options.diarize || opts.Diarization {
 		def.Diarization = &diarizationPayload{
 			MaxSpeakers: c.options.maxSpeakers,
 			Enabled:     true,
@@ -382,6 +385,7 @@ func mapResponse(
 				End: float64(
 					w.OffsetMilliseconds+w.DurationMilliseconds,
 				) / 1000.0,
+				Speaker: seg.Speaker,
 			})
 		}
 	}
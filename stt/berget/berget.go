@@ -153,6 +153,9 @@ func (c *Client) post(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	lang := c.options.language
 	if opts.Language != "" {
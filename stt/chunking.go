@@ -0,0 +1,459 @@
+package stt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// ErrUnsupportedAudioFormat is returned by [WAVSplitter] when the audio is
+// not uncompressed PCM WAV. Supply a custom [Splitter] with [WithSplitter] to
+// chunk other formats.
+var ErrUnsupportedAudioFormat = errors.New(
+	"stt: audio format cannot be split",
+)
+
+// AudioChunk is a self-contained piece of a larger audio file.
+type AudioChunk struct {
+	// Audio is the chunk encoded in the same container as the source.
+	Audio []byte
+	// Start is the offset of the chunk in the source audio, in seconds.
+	Start float64
+	// End is the offset where the chunk ends in the source audio, in seconds.
+	End float64
+}
+
+// Splitter divides audio into chunks no larger than maxBytes each, with
+// consecutive chunks sharing overlap of audio. Chunks must be returned in
+// order.
+type Splitter func(
+	audio []byte,
+	maxBytes int,
+	overlap time.Duration,
+) ([]AudioChunk, error)
+
+// ChunkingOption configures [WithChunking].
+type ChunkingOption func(*chunkingConfig)
+
+type chunkingConfig struct {
+	splitter Splitter
+}
+
+// WithSplitter replaces the default [WAVSplitter], for example to split
+// compressed formats with an external decoder.
+func WithSplitter(splitter Splitter) ChunkingOption {
+	return func(c *chunkingConfig) {
+		if splitter != nil {
+			c.splitter = splitter
+		}
+	}
+}
+
+// WithChunking wraps a SpeechToText client so audio larger than maxBytes is
+// split into chunks, transcribed one at a time, and stitched back into a
+// single [Response]. Segment and word timestamps are shifted to their
+// position in the source audio, and text repeated in the overlap between
+// chunks is dropped. Audio within the limit is passed through unchanged, as
+// are streaming sessions.
+//
+// The default splitter handles PCM WAV, cutting at the quietest point near
+// each chunk boundary:
+//
+//	client := stt.WithChunking(
+//		sttopenai.NewSpeechToText(sttopenai.WithAPIKey(key)),
+//		25<<20,
+//		2*time.Second,
+//	)
+func WithChunking(
+	inner SpeechToText,
+	maxBytes int,
+	overlap time.Duration,
+	opts ...ChunkingOption,
+) SpeechToText {
+	config := chunkingConfig{splitter: WAVSplitter}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &chunkingClient{
+		inner:    inner,
+		maxBytes: maxBytes,
+		overlap:  overlap,
+		config:   config,
+	}
+}
+
+type chunkingClient struct {
+	inner    SpeechToText
+	maxBytes int
+	overlap  time.Duration
+	config   chunkingConfig
+}
+
+func (c *chunkingClient) Model() model.TranscriptionModel {
+	return c.inner.Model()
+}
+
+func (c *chunkingClient) SupportsStreaming() bool {
+	return c.inner.SupportsStreaming()
+}
+
+func (c *chunkingClient) StreamTranscribe(
+	ctx context.Context,
+	audio <-chan []byte,
+	options ...Option,
+) (<-chan StreamResult, error) {
+	return c.inner.StreamTranscribe(ctx, audio, options...)
+}
+
+func (c *chunkingClient) Transcribe(
+	ctx context.Context,
+	audioFile []byte,
+	options ...Option,
+) (*Response, error) {
+	return c.run(ctx, audioFile, options, c.inner.Transcribe)
+}
+
+func (c *chunkingClient) Translate(
+	ctx context.Context,
+	audioFile []byte,
+	options ...Option,
+) (*Response, error) {
+	return c.run(ctx, audioFile, options, c.inner.Translate)
+}
+
+func (c *chunkingClient) run(
+	ctx context.Context,
+	audioFile []byte,
+	options []Option,
+	call func(context.Context, []byte, ...Option) (*Response, error),
+) (*Response, error) {
+	if c.maxBytes <= 0 || len(audioFile) <= c.maxBytes {
+		return call(ctx, audioFile, options...)
+	}
+
+	chunks, err := c.config.splitter(audioFile, c.maxBytes, c.overlap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split audio: %w", err)
+	}
+
+	responses := make([]*Response, len(chunks))
+	for i, chunk := range chunks {
+		resp, err := call(ctx, chunk.Audio, options...)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to transcribe chunk %d of %d: %w",
+				i+1,
+				len(chunks),
+				err,
+			)
+		}
+		responses[i] = resp
+	}
+
+	return stitchResponses(chunks, responses), nil
+}
+
+func stitchResponses(chunks []AudioChunk, responses []*Response) *Response {
+	out := &Response{}
+	var words []string
+	for i, resp := range responses {
+		chunk := chunks[i]
+		from, to := ownedRange(chunks, i)
+
+		if out.Language == "" {
+			out.Language = resp.Language
+		}
+		if out.Model == "" {
+			out.Model = resp.Model
+		}
+		out.Usage.InputTokens += resp.Usage.InputTokens
+		out.Usage.OutputTokens += resp.Usage.OutputTokens
+		out.Usage.TotalTokens += resp.Usage.TotalTokens
+		out.Usage.AudioTokens += resp.Usage.AudioTokens
+		out.Usage.TextTokens += resp.Usage.TextTokens
+		out.Usage.DurationSec += resp.Usage.DurationSec
+		out.Duration = chunk.End
+
+		var kept []string
+		for _, seg := range resp.Segments {
+			seg.Start += chunk.Start
+			seg.End += chunk.Start
+			if mid := (seg.Start + seg.End) / 2; mid < from || mid >= to {
+				continue
+			}
+			seg.ID = len(out.Segments)
+			out.Segments = append(out.Segments, seg)
+			kept = append(kept, strings.TrimSpace(seg.Text))
+		}
+		for _, w := range resp.Words {
+			w.Start += chunk.Start
+			w.End += chunk.Start
+			if mid := (w.Start + w.End) / 2; mid < from || mid >= to {
+				continue
+			}
+			out.Words = append(out.Words, w)
+		}
+
+		if len(resp.Segments) > 0 {
+			words = append(words, strings.Fields(strings.Join(kept, " "))...)
+			continue
+		}
+		words = appendOverlapping(words, strings.Fields(resp.Text))
+	}
+
+	out.Text = strings.Join(words, " ")
+	return out
+}
+
+func ownedRange(chunks []AudioChunk, i int) (float64, float64) {
+	from, to := math.Inf(-1), math.Inf(1)
+	if i > 0 {
+		from = (chunks[i].Start + chunks[i-1].End) / 2
+	}
+	if i < len(chunks)-1 {
+		to = (chunks[i+1].Start + chunks[i].End) / 2
+	}
+	return from, to
+}
+
+const (
+	minOverlapWords = 2
+	maxOverlapWords = 50
+)
+
+func appendOverlapping(words, next []string) []string {
+	n := min(len(words), len(next), maxOverlapWords)
+	for k := n; k >= minOverlapWords; k-- {
+		if sameWords(words[len(words)-k:], next[:k]) {
+			return append(words, next[k:]...)
+		}
+	}
+	return append(words, next...)
+}
+
+func sameWords(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return unicode.IsPunct(r)
+	}))
+}
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+	silenceWindow       = 20 * time.Millisecond
+	silenceSearchRatio  = 0.2
+)
+
+type wavInfo struct {
+	fmtChunk      []byte
+	format        uint16
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+	blockAlign    int
+	data          []byte
+}
+
+// WAVSplitter splits uncompressed PCM WAV audio. Each chunk is cut at the
+// quietest 20ms window in the last fifth of the space maxBytes allows, so
+// words are rarely split, and the next chunk starts overlap before the cut.
+// Returns [ErrUnsupportedAudioFormat] for any other encoding.
+func WAVSplitter(
+	audio []byte,
+	maxBytes int,
+	overlap time.Duration,
+) ([]AudioChunk, error) {
+	info, err := parseWAV(audio)
+	if err != nil {
+		return nil, err
+	}
+
+	bytesPerSec := info.sampleRate * info.blockAlign
+	maxData := maxBytes - wavHeaderSize(info)
+	maxData -= maxData % info.blockAlign
+	overlapBytes := int(overlap.Seconds()*float64(info.sampleRate)) *
+		info.blockAlign
+	if maxData <= overlapBytes || maxData <= 0 {
+		return nil, fmt.Errorf(
+			"stt: maxBytes %d leaves no room for audio beyond the overlap",
+			maxBytes,
+		)
+	}
+
+	var chunks []AudioChunk
+	start := 0
+	for {
+		end := min(start+maxData, len(info.data))
+		if end < len(info.data) {
+			end = quietestCut(info, start+overlapBytes, end)
+		}
+		chunks = append(chunks, AudioChunk{
+			Audio: encodeWAV(info, info.data[start:end]),
+			Start: float64(start) / float64(bytesPerSec),
+			End:   float64(end) / float64(bytesPerSec),
+		})
+		if end >= len(info.data) {
+			return chunks, nil
+		}
+		start = end - overlapBytes
+	}
+}
+
+func quietestCut(info wavInfo, lower, end int) int {
+	window := int(silenceWindow.Seconds()*float64(info.sampleRate)) *
+		info.blockAlign
+	if window <= 0 {
+		return end
+	}
+	searchFrom := end - int(float64(end-lower)*silenceSearchRatio)
+	searchFrom -= searchFrom % info.blockAlign
+	searchFrom = max(searchFrom, lower+info.blockAlign)
+
+	best, bestEnergy := end, math.Inf(1)
+	for pos := end - window; pos >= searchFrom; pos -= window {
+		energy, ok := windowEnergy(info, info.data[pos:pos+window])
+		if !ok {
+			return end
+		}
+		if energy < bestEnergy {
+			best, bestEnergy = pos+window/2, energy
+		}
+	}
+	return best - best%info.blockAlign
+}
+
+func windowEnergy(info wavInfo, window []byte) (float64, bool) {
+	width := info.bitsPerSample / 8
+	var sum float64
+	n := 0
+	for i := 0; i+width <= len(window); i += width {
+		s := window[i : i+width]
+		var v float64
+		switch {
+		case info.format == wavFormatFloat && width == 4:
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(s)))
+		case info.format == wavFormatFloat:
+			return 0, false
+		case width == 1:
+			v = (float64(s[0]) - 128) / 128
+		case width == 2:
+			v = float64(int16(binary.LittleEndian.Uint16(s))) / (1 << 15)
+		case width == 3:
+			x := int32(s[0]) | int32(s[1])<<8 | int32(s[2])<<16
+			v = float64(x<<8>>8) / (1 << 23)
+		case width == 4:
+			v = float64(int32(binary.LittleEndian.Uint32(s))) / (1 << 31)
+		default:
+			return 0, false
+		}
+		sum += v * v
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+func parseWAV(audio []byte) (wavInfo, error) {
+	if len(audio) < 12 || string(audio[0:4]) != "RIFF" ||
+		string(audio[8:12]) != "WAVE" {
+		return wavInfo{}, fmt.Errorf(
+			"%w: not a WAV file",
+			ErrUnsupportedAudioFormat,
+		)
+	}
+
+	var info wavInfo
+	pos := 12
+	for pos+8 <= len(audio) {
+		id := string(audio[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(audio[pos+4 : pos+8]))
+		body := pos + 8
+		if size < 0 || body+size > len(audio) {
+			size = len(audio) - body
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return wavInfo{}, fmt.Errorf(
+					"%w: malformed fmt chunk",
+					ErrUnsupportedAudioFormat,
+				)
+			}
+			f := audio[body : body+size]
+			info.fmtChunk = f
+			info.format = binary.LittleEndian.Uint16(f[0:2])
+			info.channels = int(binary.LittleEndian.Uint16(f[2:4]))
+			info.sampleRate = int(binary.LittleEndian.Uint32(f[4:8]))
+			info.blockAlign = int(binary.LittleEndian.Uint16(f[12:14]))
+			info.bitsPerSample = int(binary.LittleEndian.Uint16(f[14:16]))
+		case "data":
+			info.data = audio[body : body+size]
+		}
+		pos = body + size + size%2
+	}
+
+	switch {
+	case info.fmtChunk == nil || info.data == nil:
+		return wavInfo{}, fmt.Errorf(
+			"%w: missing fmt or data chunk",
+			ErrUnsupportedAudioFormat,
+		)
+	case info.format != wavFormatPCM && info.format != wavFormatFloat &&
+		info.format != wavFormatExtensible:
+		return wavInfo{}, fmt.Errorf(
+			"%w: WAV encoding %d is not PCM",
+			ErrUnsupportedAudioFormat,
+			info.format,
+		)
+	case info.sampleRate <= 0 || info.blockAlign <= 0 ||
+		info.channels <= 0 || info.bitsPerSample <= 0:
+		return wavInfo{}, fmt.Errorf(
+			"%w: invalid WAV parameters",
+			ErrUnsupportedAudioFormat,
+		)
+	}
+	info.data = info.data[:len(info.data)-len(info.data)%info.blockAlign]
+	return info, nil
+}
+
+func wavHeaderSize(info wavInfo) int {
+	return 12 + 8 + len(info.fmtChunk) + len(info.fmtChunk)%2 + 8
+}
+
+func encodeWAV(info wavInfo, data []byte) []byte {
+	header := wavHeaderSize(info)
+	out := make([]byte, header, header+len(data)+1)
+	copy(out[0:4], "RIFF")
+	pad := len(data) % 2
+	binary.LittleEndian.PutUint32(out[4:8], uint32(header-8+len(data)+pad))
+	copy(out[8:12], "WAVE")
+	copy(out[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(out[16:20], uint32(len(info.fmtChunk)))
+	copy(out[20:], info.fmtChunk)
+	copy(out[header-8:header-4], "data")
+	binary.LittleEndian.PutUint32(out[header-4:header], uint32(len(data)))
+	out = append(out, data...)
+	if pad == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
@@ -244,6 +244,9 @@ func (c *Client) Transcribe(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	params := url.Values{}
 	params.Set("model", c.options.model.APIModel)
@@ -259,7 +262,8 @@ func (c *Client) Transcribe(
 	if c.options.punctuate != nil && *c.options.punctuate {
 		params.Set("punctuate", "true")
 	}
-	if c.options.diarize != nil && *c.options.diarize {
+	if opts.Diarization ||
+		(c.options.diarize != nil && *c.options.diarize) {
 		params.Set("diarize", "true")
 	}
 	if c.options.smartFormat != nil && *c.options.smartFormat {
@@ -336,8 +340,12 @@ func (c *Client) mapBatchResponse(dgResp *batchResponse) *stt.Response {
 		words := make([]stt.Word, len(alt.Words))
 		for i, w := range alt.Words {
 			words[i] = stt.Word{Word: w.Word, Start: w.Start, End: w.End}
+			if w.Speaker != nil {
+				words[i].Speaker = strconv.Itoa(*w.Speaker)
+			}
 		}
 		result.Words = words
+		result.Segments = stt.SegmentsBySpeaker(words)
 	}
 
 	return result
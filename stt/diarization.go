@@ -0,0 +1,58 @@
+package stt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+// ErrDiarizationNotSupported is returned by Transcribe when [WithDiarization]
+// is set but the configured model cannot label speakers. Check ahead of time
+// via model.TranscriptionModel.SupportsDiarization.
+var ErrDiarizationNotSupported = errors.New(
+	"stt: diarization not supported by this model",
+)
+
+// CheckDiarization returns [ErrDiarizationNotSupported] when opts request
+// diarization and m does not support it. Vendor packages call it before
+// sending a request.
+func CheckDiarization(m model.TranscriptionModel, opts Options) error {
+	if opts.Diarization && !m.SupportsDiarization {
+		return fmt.Errorf("%w: %s", ErrDiarizationNotSupported, m.APIModel)
+	}
+	return nil
+}
+
+// SegmentsBySpeaker groups consecutive words with the same speaker into
+// segments, for providers that only label speakers per word. Returns nil when
+// no word has a speaker.
+func SegmentsBySpeaker(words []Word) []Segment {
+	var segments []Segment
+	var text []string
+	labelled := false
+	for i, w := range words {
+		if w.Speaker != "" {
+			labelled = true
+		}
+		if i == 0 || w.Speaker != words[i-1].Speaker {
+			if len(segments) > 0 {
+				segments[len(segments)-1].Text = strings.Join(text, " ")
+			}
+			segments = append(segments, Segment{
+				ID:      len(segments),
+				Start:   w.Start,
+				Speaker: w.Speaker,
+			})
+			text = text[:0]
+		}
+		segments[len(segments)-1].End = w.End
+		text = append(text, w.Word)
+	}
+	if !labelled {
+		return nil
+	}
+	segments[len(segments)-1].Text = strings.Join(text, " ")
+	return segments
+}
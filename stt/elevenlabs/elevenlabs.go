@@ -241,6 +241,9 @@ func (c *Client) Transcribe(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
@@ -262,7 +265,8 @@ func (c *Client) Transcribe(
 			)
 		}
 	}
-	if c.options.diarize != nil && *c.options.diarize {
+	if opts.Diarization ||
+		(c.options.diarize != nil && *c.options.diarize) {
 		if err := writer.WriteField("diarize", "true"); err != nil {
 			return nil, fmt.Errorf("failed to write diarize field: %w", err)
 		}
@@ -367,12 +371,15 @@ func (c *Client) mapBatchResponse(elResp *batchResponse) *stt.Response {
 		if w.Type != "word" {
 			continue
 		}
-		words = append(
-			words,
-			stt.Word{Word: w.Text, Start: w.Start, End: w.End},
-		)
+		words = append(words, stt.Word{
+			Word:    w.Text,
+			Start:   w.Start,
+			End:     w.End,
+			Speaker: w.SpeakerID,
+		})
 	}
 	result.Words = words
+	result.Segments = stt.SegmentsBySpeaker(words)
 
 	return result
 }
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
@@ -137,6 +138,12 @@ type requestConfig struct {
 	Model                      string `json:"model,omitempty"`
 	EnableWordTimeOffsets      bool   `json:"enableWordTimeOffsets,omitempty"`
 	EnableAutomaticPunctuation bool   `json:"enableAutomaticPunctuation,omitempty"`
+
+	DiarizationConfig *diarizationConfig `json:"diarizationConfig,omitempty"`
+}
+
+type diarizationConfig struct {
+	EnableSpeakerDiarization bool `json:"enableSpeakerDiarization"`
 }
 
 type requestAudio struct {
@@ -149,9 +156,10 @@ type response struct {
 			Transcript string  `json:"transcript"`
 			Confidence float64 `json:"confidence"`
 			Words      []struct {
-				StartTime string `json:"startTime"`
-				EndTime   string `json:"endTime"`
-				Word      string `json:"word"`
+				StartTime  string `json:"startTime"`
+				EndTime    string `json:"endTime"`
+				Word       string `json:"word"`
+				SpeakerTag int    `json:"speakerTag,omitempty"`
 			} `json:"words"`
 		} `json:"alternatives"`
 	} `json:"results"`
@@ -168,6 +176,9 @@ func (c *Client) Transcribe(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	langCode := c.options.languageCode
 	if opts.Language != "" {
@@ -179,6 +190,11 @@ func (c *Client) Transcribe(
 		EnableWordTimeOffsets:      true,
 		EnableAutomaticPunctuation: true,
 	}
+	if opts.Diarization {
+		cfg.DiarizationConfig = &diarizationConfig{
+			EnableSpeakerDiarization: true,
+		}
+	}
 	if c.options.encoding != "" {
 		cfg.Encoding = c.options.encoding
 	}
@@ -243,7 +259,7 @@ func (c *Client) Transcribe(
 		return nil, fmt.Errorf("failed to unmarshal STT response: %w", err)
 	}
 
-	return c.mapResponse(&gcResp, langCode), nil
+	return c.mapResponse(&gcResp, langCode, opts.Diarization), nil
 }
 
 // Translate is not supported by Google Cloud STT.
@@ -255,7 +271,11 @@ func (c *Client) Translate(
 	return nil, fmt.Errorf("google cloud STT does not support translation")
 }
 
-func (c *Client) mapResponse(gcResp *response, language string) *stt.Response {
+func (c *Client) mapResponse(
+	gcResp *response,
+	language string,
+	diarized bool,
+) *stt.Response {
 	result := &stt.Response{
 		Language: language,
 		Model:    c.options.model.APIModel,
@@ -271,6 +291,9 @@ func (c *Client) mapResponse(gcResp *response, language string) *stt.Response {
 		alt := r.Alternatives[0]
 		fullText += alt.Transcript
 
+		if diarized {
+			continue
+		}
 		for _, w := range alt.Words {
 			allWords = append(allWords, stt.Word{
 				Word:  w.Word,
@@ -280,6 +303,24 @@ func (c *Client) mapResponse(gcResp *response, language string) *stt.Response {
 		}
 	}
 
+	if diarized && len(gcResp.Results) > 0 {
+		last := gcResp.Results[len(gcResp.Results)-1]
+		if len(last.Alternatives) > 0 {
+			for _, w := range last.Alternatives[0].Words {
+				word := stt.Word{
+					Word:  w.Word,
+					Start: parseDuration(w.StartTime),
+					End:   parseDuration(w.EndTime),
+				}
+				if w.SpeakerTag > 0 {
+					word.Speaker = strconv.Itoa(w.SpeakerTag)
+				}
+				allWords = append(allWords, word)
+			}
+		}
+		result.Segments = stt.SegmentsBySpeaker(allWords)
+	}
+
 	result.Text = fullText
 	result.Words = allWords
 
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
@@ -136,6 +137,9 @@ func (c *Client) Transcribe(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := stt.CheckDiarization(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	params := openaisdk.AudioTranscriptionNewParams{
 		Model: openaisdk.AudioModel(c.options.model.APIModel),
@@ -157,11 +161,14 @@ func (c *Client) Transcribe(
 		params.Prompt = openaisdk.String(opts.Prompt)
 	}
 
-	if opts.ResponseFormat != "" {
+	switch {
+	case opts.ResponseFormat != "":
 		params.ResponseFormat = openaisdk.AudioResponseFormat(
 			opts.ResponseFormat,
 		)
-	} else {
+	case opts.Diarization:
+		params.ResponseFormat = openaisdk.AudioResponseFormat("diarized_json")
+	default:
 		params.ResponseFormat = openaisdk.AudioResponseFormat("verbose_json")
 	}
 
@@ -181,7 +188,9 @@ func (c *Client) Transcribe(
 	return c.convertResponse(response), nil
 }
 
-// Translate converts audio to English text regardless of the source language.
+// Translate converts audio to English text regardless of the source language
+// using the translations endpoint. Like Transcribe it requests verbose_json by
+// default, so the response carries language, duration, and segments.
 func (c *Client) Translate(
 	ctx context.Context,
 	audioFile []byte,
@@ -220,7 +229,7 @@ func (c *Client) Translate(
 			params.ResponseFormat = openaisdk.AudioTranslationNewParamsResponseFormatJSON
 		}
 	} else {
-		params.ResponseFormat = openaisdk.AudioTranslationNewParamsResponseFormatJSON
+		params.ResponseFormat = openaisdk.AudioTranslationNewParamsResponseFormatVerboseJSON
 	}
 
 	if opts.Temperature != nil {
@@ -232,10 +241,12 @@ func (c *Client) Translate(
 		return nil, fmt.Errorf("failed to translate audio: %w", err)
 	}
 
-	return &stt.Response{
+	result := &stt.Response{
 		Text:  response.Text,
 		Model: c.options.model.APIModel,
-	}, nil
+	}
+	applyVerbose(result, response.RawJSON())
+	return result, nil
 }
 
 type verboseTranscription struct {
@@ -243,15 +254,16 @@ type verboseTranscription struct {
 	Language string  `json:"language"`
 	Duration float64 `json:"duration"`
 	Segments []struct {
-		ID               int     `json:"id"`
-		Start            float64 `json:"start"`
-		End              float64 `json:"end"`
-		Text             string  `json:"text"`
-		Tokens           []int   `json:"tokens"`
-		Temperature      float64 `json:"temperature"`
-		AvgLogprob       float64 `json:"avg_logprob"`
-		CompressionRatio float64 `json:"compression_ratio"`
-		NoSpeechProb     float64 `json:"no_speech_prob"`
+		ID               json.RawMessage `json:"id"`
+		Start            float64         `json:"start"`
+		End              float64         `json:"end"`
+		Text             string          `json:"text"`
+		Tokens           []int           `json:"tokens"`
+		Temperature      float64         `json:"temperature"`
+		AvgLogprob       float64         `json:"avg_logprob"`
+		CompressionRatio float64         `json:"compression_ratio"`
+		NoSpeechProb     float64         `json:"no_speech_prob"`
+		Speaker          string          `json:"speaker"`
 	} `json:"segments"`
 	Words []struct {
 		Word  string  `json:"word"`
@@ -279,34 +291,44 @@ func (c *Client) convertResponse(
 		result.Usage.DurationSec = response.Usage.Seconds
 	}
 
-	raw := response.RawJSON()
-	if raw != "" {
-		var verbose verboseTranscription
-		if err := json.Unmarshal([]byte(raw), &verbose); err == nil {
-			result.Language = verbose.Language
-			result.Duration = verbose.Duration
-			for _, s := range verbose.Segments {
-				result.Segments = append(result.Segments, stt.Segment{
-					ID:               s.ID,
-					Start:            s.Start,
-					End:              s.End,
-					Text:             s.Text,
-					Tokens:           s.Tokens,
-					Temperature:      s.Temperature,
-					AvgLogprob:       s.AvgLogprob,
-					CompressionRatio: s.CompressionRatio,
-					NoSpeechProb:     s.NoSpeechProb,
-				})
-			}
-			for _, w := range verbose.Words {
-				result.Words = append(result.Words, stt.Word{
-					Word:  w.Word,
-					Start: w.Start,
-					End:   w.End,
-				})
-			}
-		}
-	}
+	applyVerbose(result, response.RawJSON())
 
 	return result
 }
+
+func applyVerbose(result *stt.Response, raw string) {
+	if raw == "" {
+		return
+	}
+	var verbose verboseTranscription
+	if err := json.Unmarshal([]byte(raw), &verbose); err != nil {
+		return
+	}
+	result.Language = verbose.Language
+	result.Duration = verbose.Duration
+	for i, s := range verbose.Segments {
+		id, err := strconv.Atoi(string(s.ID))
+		if err != nil {
+			id = i
+		}
+		result.Segments = append(result.Segments, stt.Segment{
+			ID:               id,
+			Start:            s.Start,
+			End:              s.End,
+			Text:             s.Text,
+			Tokens:           s.Tokens,
+			Temperature:      s.Temperature,
+			AvgLogprob:       s.AvgLogprob,
+			CompressionRatio: s.CompressionRatio,
+			NoSpeechProb:     s.NoSpeechProb,
+			Speaker:          s.Speaker,
+		})
+	}
+	for _, w := range verbose.Words {
+		result.Words = append(result.Words, stt.Word{
+			Word:  w.Word,
+			Start: w.Start,
+			End:   w.End,
+		})
+	}
+}
@@ -141,6 +141,9 @@ type Options struct {
 	// Channels is the channel count of audio fed into a streaming session.
 	// Defaults to 1 when supported by the provider.
 	Channels int
+	// Diarization requests speaker labels on segments and words. Providers
+	// whose model cannot diarize return [ErrDiarizationNotSupported].
+	Diarization bool
 }
 
 // Option customizes a single Transcribe, Translate, or StreamTranscribe call.
@@ -212,6 +215,15 @@ func WithChannels(n int) Option {
 	}
 }
 
+// WithDiarization requests speaker diarization. Segments and words in the
+// response carry a Speaker label. Returns [ErrDiarizationNotSupported] when the
+// model has no native diarization. Batch transcription only.
+func WithDiarization() Option {
+	return func(o *Options) {
+		o.Diarization = true
+	}
+}
+
 // TracingAttrs are construction-time attributes vendor packages forward to the
 // [WithTracing] wrapper so they appear on every span produced for the wrapped
 // client.
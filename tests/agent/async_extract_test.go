@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type gatedLLM struct {
+	*mockLLM
+	release chan struct{}
+}
+
+func (g *gatedLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	<-g.release
+	return g.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func TestAutoExtract_FlushWaitsForExtraction(t *testing.T) {
+	memLLM := &gatedLLM{
+		mockLLM: newMockLLM(mockResponse{Content: extractedFacts}),
+		release: make(chan struct{}),
+	}
+	a := agent.New(newMockLLM(mockResponse{Content: "noted"}),
+		agent.WithSession("async", session.MemoryStore()),
+		agent.WithMemory("user-1", newKnowledgeStore(),
+			memory.AutoExtract(),
+			memory.LLM(memLLM),
+		),
+	)
+
+	resp, err := a.Chat(context.Background(), "I like green tea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "noted" {
+		t.Errorf("content = %q, want noted", resp.Content)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := a.FlushMemories(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("flush with extraction running = %v, want canceled", err)
+	}
+
+	close(memLLM.release)
+	if err := a.FlushMemories(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := memLLM.CallCount(); got != 1 {
+		t.Errorf("memory LLM called %d times, want 1", got)
+	}
+}
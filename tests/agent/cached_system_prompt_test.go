@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func systemMessagesOf(msgs []message.Message) []message.Message {
+	var out []message.Message
+	for _, msg := range msgs {
+		if msg.Role == message.System {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func TestWithCachedSystemPrompt_SeparatesTurnContext(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("You are support."),
+		agent.WithCachedSystemPrompt(),
+		agent.WithKnowledgeBase(newKnowledgeStore(),
+			agent.KnowledgeAutoRetrieve(),
+			agent.KnowledgeTopK(1),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "refunds?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system := systemMessagesOf(mockLLM.calls[0])
+	if len(system) != 2 {
+		t.Fatalf("expected 2 system messages, got %d", len(system))
+	}
+	if system[0].Content().Text != "You are support." ||
+		system[0].Cache != message.CacheBreakpoint {
+		t.Errorf("unexpected prompt message: %q, cache %v",
+			system[0].Content().Text, system[0].Cache)
+	}
+	if !strings.HasPrefix(system[1].Content().Text, "Relevant knowledge") ||
+		system[1].Cache != message.CacheSkip {
+		t.Errorf("unexpected context message: %q, cache %v",
+			system[1].Content().Text, system[1].Cache)
+	}
+}
+
+func TestWithCachedSystemPrompt_SkipsChangedPrompt(t *testing.T) {
+	ctx := context.Background()
+	mockLLM := newMockLLM(
+		mockResponse{Content: "one"},
+		mockResponse{Content: "two"},
+		mockResponse{Content: "three"},
+	)
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("Theme: {{.theme}}"),
+		agent.WithState(map[string]any{"theme": "dark"}),
+		agent.WithCachedSystemPrompt(),
+	)
+
+	if _, err := a.Chat(ctx, "first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.SetState(ctx, "theme", "light"); err != nil {
+		t.Fatalf("SetState failed: %v", err)
+	}
+	if _, err := a.Chat(ctx, "second"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := a.Chat(ctx, "third"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []message.CacheHint{
+		message.CacheBreakpoint,
+		message.CacheSkip,
+		message.CacheBreakpoint,
+	}
+	for i, hint := range want {
+		system := systemMessagesOf(mockLLM.calls[i])
+		if len(system) != 1 {
+			t.Fatalf("call %d: expected 1 system message, got %d",
+				i, len(system))
+		}
+		if system[0].Cache != hint {
+			t.Errorf("call %d: cache = %v, want %v", i, system[0].Cache, hint)
+		}
+	}
+}
+
+func TestWithoutCachedSystemPrompt_SendsSingleMessage(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("You are support."),
+		agent.WithKnowledgeBase(newKnowledgeStore(),
+			agent.KnowledgeAutoRetrieve(),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "refunds?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system := systemMessagesOf(mockLLM.calls[0])
+	if len(system) != 1 || system[0].Cache != message.CacheDefault {
+		t.Errorf("unexpected system messages: %+v", system)
+	}
+}
+
+func TestWithCachedSystemPrompt_RecordsCacheSavings(t *testing.T) {
+	ctx := context.Background()
+	m := model.Model{
+		ID:                 "priced",
+		Provider:           model.ProviderAnthropic,
+		CostPer1MIn:        3,
+		CostPer1MOut:       15,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+	}
+	usage := llm.TokenUsage{
+		InputTokens:     100,
+		OutputTokens:    10,
+		CacheReadTokens: 2_000,
+	}
+	client := fake.NewLLM(
+		fake.WithModel(m),
+		fake.WithResponses(fake.Text("ok").WithUsage(usage)),
+	)
+	a := agent.New(client,
+		agent.WithSystemPrompt("You are support."),
+		agent.WithCachedSystemPrompt(),
+		agent.WithSession("cached", session.MemoryStore()),
+		agent.WithUsageTracking(),
+	)
+
+	if _, err := a.Chat(ctx, "hello"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	total, err := a.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	want := llm.EstimateCacheSavings(m, usage)
+	if want <= 0 || math.Abs(total.CacheSavings-want) > 1e-12 {
+		t.Errorf("CacheSavings = %v, want %v", total.CacheSavings, want)
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+var testCitations = []message.Citation{{
+	Type:      "web_search_result_location",
+	Title:     "Example",
+	URL:       "https://example.com",
+	CitedText: "sky is blue",
+	End:       16,
+}}
+
+func TestCitations_OnChatResponseAndSession(t *testing.T) {
+	mock := newMockLLM(mockResponse{
+		Content:   "The sky is blue.",
+		Citations: testCitations,
+	})
+	store := session.MemoryStore()
+	a := agent.New(mock, agent.WithSession("s1", store))
+
+	resp, err := a.Chat(context.Background(), "why?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Citations) != 1 ||
+		resp.Citations[0].URL != "https://example.com" {
+		t.Errorf("citations = %+v", resp.Citations)
+	}
+
+	sess, err := store.Load(context.Background(), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := sess.GetMessages(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := msgs[len(msgs)-1]
+	if got := last.Citations(); len(got) != 1 || got[0] != testCitations[0] {
+		t.Errorf("stored citations = %+v", got)
+	}
+}
+
+func TestCitations_Stream(t *testing.T) {
+	mock := newMockLLM(mockResponse{
+		Content:   "The sky is blue.",
+		Citations: testCitations,
+	})
+	a := agent.New(mock)
+
+	var final *agent.ChatResponse
+	for event := range a.ChatStream(context.Background(), "why?") {
+		if event.Type == types.EventComplete {
+			final = event.Response
+		}
+	}
+	if final == nil || len(final.Citations) != 1 {
+		t.Fatalf("final response = %+v", final)
+	}
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/tokens"
+	"github.com/joakimcarlsson/ai/tokens/summarize"
+)
+
+func seedSession(
+	t *testing.T,
+	store session.Store,
+	id string,
+	texts ...string,
+) session.Session {
+	t.Helper()
+	ctx := context.Background()
+	sess, err := store.Create(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []message.Message
+	for i, text := range texts {
+		if i%2 == 0 {
+			msgs = append(msgs, message.NewUserMessage(text))
+		} else {
+			answer := message.NewAssistantMessage()
+			answer.AppendContent(text)
+			msgs = append(msgs, answer)
+		}
+	}
+	if err := sess.AddMessages(ctx, msgs); err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func TestCompactSession_SummarizesAndArchives(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	sess := seedSession(t, store, "conv", "q1", "a1", "q2", "a2", "q3", "a3")
+	archive, err := store.Create(ctx, "conv-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summarizer := newMockLLM(mockResponse{Content: "They asked q1 and q2."})
+
+	res, err := agent.CompactSession(
+		ctx,
+		sess,
+		summarize.Strategy(summarizer, summarize.KeepRecent(2)),
+		agent.CompactArchive(archive),
+	)
+	if err != nil {
+		t.Fatalf("CompactSession: %v", err)
+	}
+	if res.MessagesBefore != 6 || res.MessagesAfter != 3 {
+		t.Errorf("result = %+v, want 6 before and 3 after", res)
+	}
+
+	stored, _ := sess.GetMessages(ctx, nil)
+	if len(stored) != 3 {
+		t.Fatalf("stored %d messages, want 3", len(stored))
+	}
+	if stored[0].Role != message.Summary ||
+		!strings.Contains(stored[0].Content().Text, "They asked q1 and q2.") {
+		t.Errorf("first message = %s %q, want the summary",
+			stored[0].Role, stored[0].Content().Text)
+	}
+	if stored[1].Content().Text != "q3" || stored[2].Content().Text != "a3" {
+		t.Errorf("recent turns not kept: %v", stored[1:])
+	}
+
+	archived, _ := archive.GetMessages(ctx, nil)
+	if len(archived) != 6 {
+		t.Errorf("archived %d messages, want 6", len(archived))
+	}
+}
+
+func TestCompactSession_TrimmingStrategyKeepsSummary(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	sess := seedSession(t, store, "conv", "q1", "a1")
+	summary := message.NewSummaryMessage("Earlier: greetings.")
+	if err := sess.AddMessages(ctx, []message.Message{summary}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := agent.CompactSession(
+		ctx,
+		sess,
+		&summaryKeepingStrategy{},
+		agent.CompactMaxTokens(100),
+	)
+	if err != nil {
+		t.Fatalf("CompactSession: %v", err)
+	}
+	if res.MessagesAfter != 1 {
+		t.Fatalf("MessagesAfter = %d, want 1", res.MessagesAfter)
+	}
+	stored, _ := sess.GetMessages(ctx, nil)
+	if stored[0].Role != message.Summary {
+		t.Errorf("summary stored as %s, want summary role", stored[0].Role)
+	}
+}
+
+func TestCompactSession_NoChangeLeavesStoreAlone(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	sess := seedSession(t, store, "conv", "q1", "a1")
+	archive, _ := store.Create(ctx, "conv-archive")
+
+	res, err := agent.CompactSession(
+		ctx,
+		sess,
+		&keepLastStrategy{keep: 10},
+		agent.CompactArchive(archive),
+		agent.CompactMaxTokens(10_000),
+	)
+	if err != nil {
+		t.Fatalf("CompactSession: %v", err)
+	}
+	if res.MessagesBefore != 2 || res.MessagesAfter != 2 {
+		t.Errorf("result = %+v, want no change", res)
+	}
+	if archived, _ := archive.GetMessages(ctx, nil); len(archived) != 0 {
+		t.Errorf("archived %d messages for a no-op", len(archived))
+	}
+}
+
+func TestCompactSession_StrategyError(t *testing.T) {
+	ctx := context.Background()
+	sess := seedSession(t, session.MemoryStore(), "conv", "q1", "a1")
+
+	_, err := agent.CompactSession(ctx, sess, failingStrategy{})
+	if err == nil || !errors.Is(err, errStrategy) {
+		t.Fatalf("err = %v, want the strategy's error", err)
+	}
+	if stored, _ := sess.GetMessages(ctx, nil); len(stored) != 2 {
+		t.Errorf("session changed after a failed compaction: %v", stored)
+	}
+}
+
+func TestCompactSession_TrimmingRequiresBudget(t *testing.T) {
+	ctx := context.Background()
+	sess := seedSession(t, session.MemoryStore(), "conv",
+		"q1", "a1", "q2", "a2")
+
+	_, err := agent.CompactSession(ctx, sess, &keepLastStrategy{keep: 1})
+	if !errors.Is(err, agent.ErrCompactBudget) {
+		t.Fatalf("err = %v, want ErrCompactBudget", err)
+	}
+	if stored, _ := sess.GetMessages(ctx, nil); len(stored) != 4 {
+		t.Errorf("session changed without a budget: %d messages", len(stored))
+	}
+}
+
+func TestCompactSession_FailedWriteKeepsHistory(t *testing.T) {
+	ctx := context.Background()
+	inner := seedSession(t, session.MemoryStore(), "conv",
+		"q1", "a1", "q2", "a2", "q3", "a3")
+	sess := &failingAddSession{Session: inner}
+	summarizer := newMockLLM(mockResponse{Content: "Earlier turns."})
+
+	_, err := agent.CompactSession(
+		ctx,
+		sess,
+		summarize.Strategy(summarizer, summarize.KeepRecent(2)),
+	)
+	if !errors.Is(err, errAdd) {
+		t.Fatalf("err = %v, want the write error", err)
+	}
+	stored, _ := inner.GetMessages(ctx, nil)
+	if len(stored) != 6 || stored[0].Content().Text != "q1" {
+		t.Errorf("history lost after a failed write: %v", stored)
+	}
+}
+
+var errAdd = errors.New("add failed")
+
+type failingAddSession struct {
+	session.Session
+	failed bool
+}
+
+func (s *failingAddSession) AddMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	if !s.failed {
+		s.failed = true
+		return errAdd
+	}
+	return s.Session.AddMessages(ctx, msgs)
+}
+
+type summaryKeepingStrategy struct{}
+
+func (summaryKeepingStrategy) Fit(
+	_ context.Context,
+	input tokens.StrategyInput,
+) (*tokens.StrategyResult, error) {
+	last := input.Messages[len(input.Messages)-1]
+	last.Role = message.User
+	return &tokens.StrategyResult{
+		Messages:  []message.Message{last},
+		Summaries: []int{0},
+	}, nil
+}
+
+var errStrategy = errors.New("strategy failed")
+
+type failingStrategy struct{}
+
+func (failingStrategy) Fit(
+	context.Context,
+	tokens.StrategyInput,
+) (*tokens.StrategyResult, error) {
+	return nil, errStrategy
+}
@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tokens"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func smallWindowLLM(steps ...fake.Step) *fake.Client {
+	return fake.NewLLM(
+		fake.WithModel(model.Model{
+			ID:            "small",
+			Provider:      "fake",
+			ContextWindow: 400,
+		}),
+		fake.WithResponses(steps...),
+	)
+}
+
+type countingLLM struct {
+	*fake.Client
+	counter *tokens.Counter
+}
+
+func withCounter(t *testing.T, client *fake.Client) *countingLLM {
+	t.Helper()
+	counter, err := tokens.NewCounter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &countingLLM{Client: client, counter: counter}
+}
+
+func (c *countingLLM) CountTokens(
+	ctx context.Context,
+	opts tokens.CountOptions,
+) (*tokens.TokenCount, error) {
+	return c.counter.CountTokens(ctx, opts)
+}
+
+type keepLastStrategy struct {
+	keep  int
+	calls int
+}
+
+func (s *keepLastStrategy) Fit(
+	_ context.Context,
+	input tokens.StrategyInput,
+) (*tokens.StrategyResult, error) {
+	s.calls++
+	var out []message.Message
+	for _, msg := range input.Messages {
+		if msg.Role == message.System {
+			out = append(out, msg)
+		}
+	}
+	start := max(len(input.Messages)-s.keep, len(out))
+	out = append(out, input.Messages[start:]...)
+	return &tokens.StrategyResult{Messages: out}, nil
+}
+
+func TestContextOverflow_ErrorsWithoutStrategy(t *testing.T) {
+	big := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	client := smallWindowLLM(
+		fake.ToolCalls(fake.Call("echo", big)),
+		fake.Text("done"),
+	)
+	a := agent.New(withCounter(t, client), agent.WithTools(&echoTool{}))
+
+	_, err := a.Chat(context.Background(), "echo it")
+	if !errors.Is(err, agent.ErrContextOverflow) {
+		t.Fatalf("err = %v, want ErrContextOverflow", err)
+	}
+	if !strings.Contains(err.Error(), "token limit by") {
+		t.Errorf("error does not report the overflow: %v", err)
+	}
+	if client.CallCount() != 1 {
+		t.Errorf(
+			"calls = %d, want the overflowing call skipped",
+			client.CallCount(),
+		)
+	}
+}
+
+func TestContextOverflow_StreamErrorsWithoutStrategy(t *testing.T) {
+	big := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	client := smallWindowLLM(
+		fake.ToolCalls(fake.Call("echo", big)),
+		fake.Text("done"),
+	)
+	a := agent.New(withCounter(t, client), agent.WithTools(&echoTool{}))
+
+	var streamErr error
+	for event := range a.ChatStream(context.Background(), "echo it") {
+		if event.Type == types.EventError {
+			streamErr = event.Error
+		}
+	}
+	if !errors.Is(streamErr, agent.ErrContextOverflow) {
+		t.Fatalf("stream error = %v, want ErrContextOverflow", streamErr)
+	}
+}
+
+func TestContextOverflow_EstimateFailsBeyondTolerance(t *testing.T) {
+	big := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	client := smallWindowLLM(
+		fake.ToolCalls(fake.Call("echo", big)),
+		fake.Text("done"),
+	)
+	a := agent.New(client, agent.WithTools(&echoTool{}))
+
+	_, err := a.Chat(context.Background(), "echo it")
+	if !errors.Is(err, agent.ErrContextOverflow) {
+		t.Fatalf("err = %v, want ErrContextOverflow", err)
+	}
+	if client.CallCount() != 1 {
+		t.Errorf(
+			"calls = %d, want the overflowing call skipped",
+			client.CallCount(),
+		)
+	}
+}
+
+func TestContextOverflow_EstimateWithinToleranceIsSent(t *testing.T) {
+	client := smallWindowLLM(fake.Text("done"))
+	a := agent.New(client)
+
+	// About 311 estimated tokens against a limit of 300.
+	resp, err := a.Chat(
+		context.Background(),
+		strings.Repeat("word ", 300),
+	)
+	if err != nil {
+		t.Fatalf("estimate slightly over the limit failed the run: %v", err)
+	}
+	if resp.Content != "done" || client.CallCount() != 1 {
+		t.Errorf("content %q after %d calls", resp.Content, client.CallCount())
+	}
+}
+
+func TestContextOverflow_WithinLimitUnchanged(t *testing.T) {
+	client := smallWindowLLM(
+		fake.ToolCalls(fake.Call("echo", "hi")),
+		fake.Text("done"),
+	)
+	a := agent.New(client, agent.WithTools(&echoTool{}))
+
+	resp, err := a.Chat(context.Background(), "echo it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "done" || client.CallCount() != 2 {
+		t.Errorf("content %q after %d calls", resp.Content, client.CallCount())
+	}
+}
+
+func TestContextOverflow_StrategyFitsToolResults(t *testing.T) {
+	big := strings.Repeat("lorem ipsum dolor sit amet ", 40)
+	client := fake.NewLLM(fake.WithResponses(
+		fake.ToolCalls(fake.Call("echo", big)),
+		fake.ToolCalls(fake.Call("echo", big)),
+		fake.Text("done"),
+	))
+	strategy := &keepLastStrategy{keep: 2}
+	a := agent.New(client,
+		agent.WithTools(&echoTool{}),
+		agent.WithContextStrategy(strategy, 600),
+	)
+
+	resp, err := a.Chat(context.Background(), "echo it twice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "done" {
+		t.Fatalf("content = %q", resp.Content)
+	}
+	if strategy.calls < 2 {
+		t.Errorf("strategy applied %d times, want 2", strategy.calls)
+	}
+	calls := client.Calls()
+	if got := len(calls[len(calls)-1].Messages); got != 2 {
+		t.Errorf("last call sent %d messages, want 2", got)
+	}
+}
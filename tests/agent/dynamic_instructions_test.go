@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+func TestDynamicInstructions_FollowStaticPrompt(t *testing.T) {
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPrompt("You are a support assistant."),
+		agent.WithSystemPromptLayer("Never share internal pricing."),
+		agent.WithDynamicInstructions(
+			func(_ context.Context, state map[string]any) (string, error) {
+				return fmt.Sprintf("Open ticket: %v.", state["ticket"]), nil
+			},
+		),
+		agent.WithState(map[string]any{"ticket": 42}),
+	)
+
+	want := "You are a support assistant.\n\n" +
+		"Never share internal pricing.\n\n" +
+		"Open ticket: 42."
+	if got := sentSystemPrompt(t, a, m); got != want {
+		t.Errorf("system prompt = %q, want %q", got, want)
+	}
+}
+
+func TestDynamicInstructions_AloneAndEmpty(t *testing.T) {
+	output := "Only dynamic."
+	m := newMockLLM(mockResponse{Content: "ok"}, mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPrompt("Base."),
+		agent.WithDynamicInstructions(
+			func(context.Context, map[string]any) (string, error) {
+				return output, nil
+			},
+		),
+	)
+	if got := sentSystemPrompt(t, a, m); got != "Base.\n\nOnly dynamic." {
+		t.Errorf("system prompt = %q", got)
+	}
+
+	output = ""
+	if got := sentSystemPrompt(t, a, m); got != "Base." {
+		t.Errorf("empty dynamic instructions changed prompt: %q", got)
+	}
+
+	solo := newMockLLM(mockResponse{Content: "ok"})
+	b := agent.New(solo, agent.WithDynamicInstructions(
+		func(context.Context, map[string]any) (string, error) {
+			return "Just this.", nil
+		},
+	))
+	if got := sentSystemPrompt(t, b, solo); got != "Just this." {
+		t.Errorf("system prompt = %q, want %q", got, "Just this.")
+	}
+}
+
+func TestDynamicInstructions_UncachedWithCachedPrompt(t *testing.T) {
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPrompt("Fixed rules."),
+		agent.WithCachedSystemPrompt(),
+		agent.WithDynamicInstructions(
+			func(context.Context, map[string]any) (string, error) {
+				return "Per-turn context.", nil
+			},
+		),
+	)
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	system := systemMessagesOf(m.calls[0])
+	if len(system) != 2 {
+		t.Fatalf("expected 2 system messages, got %d", len(system))
+	}
+	if system[0].Content().Text != "Fixed rules." ||
+		system[0].Cache != message.CacheBreakpoint {
+		t.Errorf("unexpected prompt message: %q, cache %v",
+			system[0].Content().Text, system[0].Cache)
+	}
+	if system[1].Content().Text != "Per-turn context." ||
+		system[1].Cache != message.CacheSkip {
+		t.Errorf("unexpected dynamic message: %q, cache %v",
+			system[1].Content().Text, system[1].Cache)
+	}
+}
+
+func TestDynamicInstructions_Error(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m, agent.WithDynamicInstructions(
+		func(context.Context, map[string]any) (string, error) {
+			return "", errLookup
+		},
+	))
+
+	_, err := a.Chat(context.Background(), "hi")
+	if !errors.Is(err, errLookup) {
+		t.Fatalf("err = %v, want the provider's error", err)
+	}
+	if m.CallCount() != 0 {
+		t.Error("model called despite the provider failing")
+	}
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type toolNamesLLM struct {
+	*mockLLM
+	mu    sync.Mutex
+	names [][]string
+}
+
+func (m *toolNamesLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	var names []string
+	for _, t := range tools {
+		names = append(names, t.Info().Name)
+	}
+	m.mu.Lock()
+	m.names = append(m.names, names)
+	m.mu.Unlock()
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func (m *toolNamesLLM) lastNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.names[len(m.names)-1]
+}
+
+func TestAgent_AddAndRemoveToolBetweenTurns(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM()}
+	a := agent.New(mock, agent.WithTools(&echoTool{}))
+	ctx := context.Background()
+
+	a.AddTool(&errorTool{})
+	if _, err := a.Chat(ctx, "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := mock.lastNames(); !slices.Equal(got,
+		[]string{"echo", "error_tool"}) {
+		t.Errorf("expected echo and error_tool, got %v", got)
+	}
+
+	if !a.RemoveTool("echo") {
+		t.Fatal("expected echo to be removed")
+	}
+	if a.RemoveTool("echo") {
+		t.Error("expected second removal to report false")
+	}
+	if _, err := a.Chat(ctx, "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := mock.lastNames(); !slices.Equal(got, []string{"error_tool"}) {
+		t.Errorf("expected only error_tool, got %v", got)
+	}
+}
+
+func TestAgent_AddToolReplacesSameName(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM()}
+	a := agent.New(mock, agent.WithTools(&echoTool{}))
+
+	a.AddTool(&echoTool{})
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := mock.lastNames(); !slices.Equal(got, []string{"echo"}) {
+		t.Errorf("expected a single echo tool, got %v", got)
+	}
+}
+
+func TestAgent_SetToolsKeepsDelegationTools(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM()}
+	target := agent.New(newMockLLM())
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithHandoffs(agent.HandoffConfig{
+			Name:        "billing",
+			Description: "Billing questions",
+			Agent:       target,
+		}),
+	)
+
+	a.SetTools(&errorTool{})
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	got := mock.lastNames()
+	if slices.Contains(got, "echo") || !slices.Contains(got, "error_tool") {
+		t.Errorf("expected echo replaced by error_tool, got %v", got)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected handoff tool to remain, got %v", got)
+	}
+}
+
+func TestAgent_ToolChangesAreConcurrencySafe(t *testing.T) {
+	a := agent.New(newMockLLM(), agent.WithTools(&echoTool{}))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for range 50 {
+			a.AddTool(&errorTool{})
+			a.RemoveTool("error_tool")
+		}
+	})
+	wg.Go(func() {
+		for range 50 {
+			a.SetTools(&echoTool{})
+		}
+	})
+	for range 10 {
+		if _, err := a.Chat(ctx, "hi"); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func endTurn(content string) mockResponse {
+	return mockResponse{
+		Content:      content,
+		FinishReason: message.FinishReasonEndTurn,
+	}
+}
+
+func TestRetryOnEmpty_RetriesUntilContent(t *testing.T) {
+	mock := newMockLLM(
+		endTurn(""),
+		endTurn(" \n"),
+		endTurn("hello"),
+	)
+	a := agent.New(mock, agent.WithRetryOnEmpty(2))
+
+	resp, err := a.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "hello" || resp.Empty {
+		t.Errorf("response = %q, empty = %v", resp.Content, resp.Empty)
+	}
+	if mock.CallCount() != 3 || resp.TotalTurns != 3 {
+		t.Errorf(
+			"calls = %d, turns = %d, want 3",
+			mock.CallCount(),
+			resp.TotalTurns,
+		)
+	}
+}
+
+func TestRetryOnEmpty_ExhaustedReportsEmpty(t *testing.T) {
+	mock := newMockLLM(
+		endTurn(""),
+		endTurn("  "),
+		endTurn("unused"),
+	)
+	store := session.MemoryStore()
+	a := agent.New(
+		mock,
+		agent.WithRetryOnEmpty(1),
+		agent.WithSession("s1", store),
+	)
+
+	resp, err := a.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Empty {
+		t.Error("Empty = false, want true")
+	}
+	if mock.CallCount() != 2 {
+		t.Errorf("calls = %d, want 2", mock.CallCount())
+	}
+
+	sess, err := store.Load(context.Background(), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := sess.GetMessages(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 {
+		t.Errorf("session messages = %d, want only the user message", len(msgs))
+	}
+}
+
+func TestEmptyResponse_NoRetryByDefault(t *testing.T) {
+	mock := newMockLLM(endTurn(""), endTurn("x"))
+	a := agent.New(mock)
+
+	resp, err := a.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Empty || mock.CallCount() != 1 {
+		t.Errorf("empty = %v, calls = %d", resp.Empty, mock.CallCount())
+	}
+}
+
+func TestEmptyResponse_StreamEvent(t *testing.T) {
+	mock := newMockLLM(endTurn(""), endTurn(""))
+	a := agent.New(mock, agent.WithRetryOnEmpty(1))
+
+	var sawEmpty bool
+	var final *agent.ChatResponse
+	for event := range a.ChatStream(context.Background(), "hi") {
+		switch event.Type {
+		case types.EventEmptyResponse:
+			sawEmpty = true
+		case types.EventComplete:
+			final = event.Response
+		}
+	}
+	if !sawEmpty {
+		t.Error("missing EventEmptyResponse")
+	}
+	if final == nil || !final.Empty || final.TotalTurns != 2 {
+		t.Errorf("final response = %+v", final)
+	}
+}
+
+func TestEmptyResponse_StreamContentNotEmpty(t *testing.T) {
+	mock := newMockLLM(endTurn("hello"))
+	a := agent.New(mock, agent.WithRetryOnEmpty(1))
+
+	for event := range a.ChatStream(context.Background(), "hi") {
+		if event.Type == types.EventEmptyResponse {
+			t.Error("unexpected EventEmptyResponse")
+		}
+	}
+}
+
+func TestEmptyResponse_CutShortNotEmpty(t *testing.T) {
+	for _, reason := range []message.FinishReason{
+		message.FinishReasonMaxTokens,
+		message.FinishReasonError,
+	} {
+		t.Run(string(reason), func(t *testing.T) {
+			mock := newMockLLM(
+				mockResponse{FinishReason: reason},
+				endTurn("unused"),
+			)
+			a := agent.New(mock, agent.WithRetryOnEmpty(1))
+
+			resp, err := a.Chat(context.Background(), "hi")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Empty || mock.CallCount() != 1 {
+				t.Errorf(
+					"empty = %v, calls = %d, want false and 1",
+					resp.Empty,
+					mock.CallCount(),
+				)
+			}
+
+			stream := newMockLLM(mockResponse{FinishReason: reason})
+			a = agent.New(stream, agent.WithRetryOnEmpty(1))
+			for event := range a.ChatStream(context.Background(), "hi") {
+				if event.Type == types.EventEmptyResponse {
+					t.Error("unexpected EventEmptyResponse")
+				}
+			}
+			if stream.CallCount() != 1 {
+				t.Errorf("stream calls = %d, want 1", stream.CallCount())
+			}
+		})
+	}
+}
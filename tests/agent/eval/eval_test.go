@@ -0,0 +1,172 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/agent/eval"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type weatherTool struct{}
+
+func (weatherTool) Info() tool.Info {
+	return tool.Info{
+		Name:        "get_weather",
+		Description: "Get the weather for a city",
+		Parameters: map[string]any{
+			"city": map[string]any{"type": "string"},
+		},
+		Required: []string{"city"},
+	}
+}
+
+func (weatherTool) Run(context.Context, tool.Call) (tool.Response, error) {
+	return tool.NewTextResponse("sunny, 22C"), nil
+}
+
+func weatherAgent(steps ...fake.Step) *agent.Agent {
+	client := fake.NewLLM(fake.WithResponses(steps...))
+	return agent.New(client, agent.WithTools(weatherTool{}))
+}
+
+func TestRunPassingCase(t *testing.T) {
+	a := weatherAgent(
+		fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+		fake.Text("It is sunny in Paris."),
+	)
+
+	report := eval.Run(context.Background(), a, []eval.Case{{
+		Name:  "weather",
+		Input: "What's the weather in Paris?",
+		Assert: []eval.Assertion{
+			eval.CalledTool("get_weather"),
+			eval.CalledToolWith("get_weather", "city", "Paris"),
+			eval.CalledToolsInOrder("get_weather"),
+			eval.ToolCallCount(1),
+			eval.NotCalledTool("send_email"),
+			eval.Contains("sunny"),
+			eval.NotContains("rain"),
+			eval.Matches(`(?i)paris`),
+		},
+	}})
+
+	if !report.OK() {
+		t.Fatalf("report failed:\n%s", report)
+	}
+	if report.Passed() != 1 {
+		t.Errorf("Passed() = %d, want 1", report.Passed())
+	}
+}
+
+func TestRunReportsEveryFailure(t *testing.T) {
+	a := weatherAgent(
+		fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+		fake.Text("It is sunny in Paris."),
+	)
+
+	report := eval.Run(context.Background(), a, []eval.Case{{
+		Name:  "wrong city",
+		Input: "What's the weather in London?",
+		Assert: []eval.Assertion{
+			eval.CalledToolWith("get_weather", "city", "London"),
+			eval.Contains("London"),
+			eval.CalledTool("get_weather"),
+		},
+	}})
+
+	if report.OK() {
+		t.Fatal("expected the case to fail")
+	}
+	result := report.Results[0]
+	if len(result.Failures) != 2 {
+		t.Fatalf("failures = %v, want 2", result.Failures)
+	}
+	out := report.String()
+	if !strings.Contains(out, "FAIL wrong city") ||
+		!strings.Contains(out, "0/1 passed") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+}
+
+func TestRunAgentError(t *testing.T) {
+	boom := errors.New("boom")
+	report := eval.Run(context.Background(), nil, []eval.Case{{
+		Name:   "error",
+		Input:  "hi",
+		Agent:  weatherAgent(fake.Error(boom)),
+		Assert: []eval.Assertion{eval.Contains("hi")},
+	}})
+
+	result := report.Results[0]
+	if !errors.Is(result.Err, boom) || result.Passed() {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Failures) != 0 {
+		t.Errorf("assertions ran after an agent error: %v", result.Failures)
+	}
+}
+
+func TestJudge(t *testing.T) {
+	a := weatherAgent(fake.Text("I can't help with that, sorry."))
+	judge := fake.NewLLM(fake.WithResponses(
+		fake.Structured(`{"score":0.9,"reason":"declines politely"}`),
+		fake.Structured(`{"score":0.2,"reason":"too curt"}`),
+	))
+
+	out := eval.Outcome{Input: "Diagnose my rash"}
+	resp, err := a.Chat(context.Background(), out.Input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Response = resp
+
+	check := eval.Judge(judge, "politely declines medical advice", 0.5)
+	if err := check(context.Background(), out); err != nil {
+		t.Errorf("first verdict failed: %v", err)
+	}
+	if err := check(context.Background(), out); err == nil ||
+		!strings.Contains(err.Error(), "too curt") {
+		t.Errorf("second verdict err = %v", err)
+	}
+
+	calls := judge.Calls()
+	if len(calls) != 2 || calls[0].Schema == nil {
+		t.Errorf("judge was not asked for structured output: %+v", calls)
+	}
+}
+
+func TestGrade_RepairsTextVerdict(t *testing.T) {
+	judge := fake.NewLLM(
+		fake.WithStructuredOutput(false),
+		fake.WithResponses(fake.Text(
+			"Here is my verdict:\n```json\n"+
+				`{score: 0.75, 'reason': "mostly polite",}`+"\n```",
+		)),
+	)
+	out := eval.Outcome{
+		Input:    "Diagnose my rash",
+		Response: &agent.ChatResponse{Content: "I can't help with that."},
+	}
+
+	verdict, err := eval.Grade(context.Background(), judge, "polite", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Score != 0.75 || verdict.Reason != "mostly polite" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+}
+
+func TestRunT(t *testing.T) {
+	a := weatherAgent(fake.Text("hello"))
+	eval.RunT(t, a, []eval.Case{{
+		Name:   "greets",
+		Input:  "hi",
+		Assert: []eval.Assertion{eval.Contains("hello")},
+	}})
+}
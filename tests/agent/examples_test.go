@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/tokens"
+)
+
+func exampleTurns() []message.Message {
+	answer := message.NewAssistantMessage()
+	answer.AppendContent("positive")
+	return []message.Message{
+		message.NewUserMessage("I love this!"),
+		answer,
+	}
+}
+
+type budgetStrategy struct {
+	maxTokens int64
+	messages  int
+}
+
+func (s *budgetStrategy) Fit(
+	_ context.Context,
+	input tokens.StrategyInput,
+) (*tokens.StrategyResult, error) {
+	s.maxTokens = input.MaxTokens
+	s.messages = len(input.Messages)
+	return &tokens.StrategyResult{Messages: input.Messages}, nil
+}
+
+func TestWithExamples_InsertedAfterSystemPrompt(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "negative"})
+	a := agent.New(mock,
+		agent.WithSystemPrompt("Classify sentiment."),
+		agent.WithExamples(exampleTurns()),
+	)
+
+	if _, err := a.Chat(context.Background(), "This is awful."); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	sent := mock.calls[0]
+	want := []struct {
+		role message.Role
+		text string
+	}{
+		{message.System, "Classify sentiment."},
+		{message.User, "I love this!"},
+		{message.Assistant, "positive"},
+		{message.User, "This is awful."},
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(sent))
+	}
+	for i, w := range want {
+		if sent[i].Role != w.role || sent[i].Content().Text != w.text {
+			t.Errorf("message %d: expected %s %q, got %s %q",
+				i, w.role, w.text, sent[i].Role, sent[i].Content().Text)
+		}
+	}
+}
+
+func TestWithExamples_NotPersistedToSession(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	a := agent.New(newMockLLM(mockResponse{Content: "negative"}),
+		agent.WithSession("examples", store),
+		agent.WithExamples(exampleTurns()),
+	)
+
+	if _, err := a.Chat(ctx, "This is awful."); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	sess, _ := store.Load(ctx, "examples")
+	msgs, _ := sess.GetMessages(ctx, nil)
+	for _, msg := range msgs {
+		if msg.Content().Text == "I love this!" ||
+			msg.Content().Text == "positive" {
+			t.Errorf("example persisted to session: %+v", msg)
+		}
+	}
+	if len(msgs) != 2 {
+		t.Errorf("expected user and assistant turn, got %d messages",
+			len(msgs))
+	}
+}
+
+func TestWithExamples_CountTowardContextBudget(t *testing.T) {
+	strategy := &budgetStrategy{}
+	a := agent.New(newMockLLM(mockResponse{Content: "negative"}),
+		agent.WithExamples(exampleTurns()),
+		agent.WithContextStrategy(strategy, 10000),
+	)
+
+	if _, err := a.Chat(context.Background(), "This is awful."); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if strategy.maxTokens <= 0 || strategy.maxTokens >= 10000 {
+		t.Errorf("expected budget reduced by example tokens, got %d",
+			strategy.maxTokens)
+	}
+	if strategy.messages != 1 {
+		t.Errorf("expected only the live turn to be fitted, got %d",
+			strategy.messages)
+	}
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+const extractedFacts = `{"facts":["Likes green tea."]}`
+
+func newExtractingAgent(interval int) (*agent.Agent, *mockLLM) {
+	memLLM := newMockLLM(
+		mockResponse{Content: extractedFacts},
+		mockResponse{Content: extractedFacts},
+	)
+	a := agent.New(newMockLLM(
+		mockResponse{Content: "one"},
+		mockResponse{Content: "two"},
+		mockResponse{Content: "three"},
+	),
+		agent.WithSession("extract", session.MemoryStore()),
+		agent.WithMemory("user-1", newKnowledgeStore(),
+			memory.AutoExtract(),
+			memory.WithExtractInterval(interval),
+			memory.LLM(memLLM),
+		),
+	)
+	return a, memLLM
+}
+
+func waitForCalls(t *testing.T, m *mockLLM, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.CallCount() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("memory LLM called %d times, want %d", m.CallCount(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWithExtractInterval_BatchesTurns(t *testing.T) {
+	a, memLLM := newExtractingAgent(3)
+	ctx := context.Background()
+
+	for _, msg := range []string{"hi", "I like green tea"} {
+		if _, err := a.Chat(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := memLLM.CallCount(); got != 0 {
+		t.Fatalf("extracted after %d turns, want none before 3", got)
+	}
+
+	if _, err := a.Chat(ctx, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	waitForCalls(t, memLLM, 1)
+}
+
+func TestExtractNow_ForcesExtraction(t *testing.T) {
+	a, memLLM := newExtractingAgent(10)
+
+	if _, err := a.Chat(
+		memory.ExtractNow(context.Background()),
+		"I like green tea",
+	); err != nil {
+		t.Fatal(err)
+	}
+	waitForCalls(t, memLLM, 1)
+}
+
+func TestExtractMemories_FlushesPendingTurns(t *testing.T) {
+	a, memLLM := newExtractingAgent(10)
+	ctx := context.Background()
+
+	if err := a.ExtractMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if memLLM.CallCount() != 0 {
+		t.Fatal("extracted with no turns pending")
+	}
+
+	if _, err := a.Chat(ctx, "I like green tea"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ExtractMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := memLLM.CallCount(); got != 1 {
+		t.Fatalf("memory LLM called %d times, want 1", got)
+	}
+	conversation := memLLM.calls[0][1].Content().Text
+	if !strings.Contains(conversation, "I like green tea") {
+		t.Errorf("extraction missed the pending turn: %q", conversation)
+	}
+
+	if err := a.ExtractMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := memLLM.CallCount(); got != 1 {
+		t.Errorf("second flush extracted again, calls = %d", got)
+	}
+}
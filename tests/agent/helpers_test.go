@@ -26,6 +26,7 @@ type mockResponse struct {
 	ToolCalls    []message.ToolCall
 	FinishReason message.FinishReason
 	Usage        llm.TokenUsage
+	Citations    []message.Citation
 	Err          error
 }
 
@@ -79,6 +80,7 @@ func (m *mockLLM) SendMessages(
 		ToolCalls:    resp.ToolCalls,
 		FinishReason: resp.FinishReason,
 		Usage:        resp.Usage,
+		Citations:    resp.Citations,
 	}, nil
 }
 
@@ -119,6 +121,7 @@ func (m *mockLLM) StreamResponse(
 				ToolCalls:    resp.ToolCalls,
 				FinishReason: resp.FinishReason,
 				Usage:        resp.Usage,
+				Citations:    resp.Citations,
 			},
 		}
 	}()
@@ -0,0 +1,322 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/rerankers"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type knowledgeStore struct {
+	entries  []memory.Entry
+	err      error
+	searched []string
+	limits   []int
+}
+
+func (s *knowledgeStore) Store(
+	context.Context,
+	string,
+	string,
+	map[string]any,
+) error {
+	return errors.New("read-only")
+}
+
+func (s *knowledgeStore) Search(
+	_ context.Context,
+	id string,
+	_ string,
+	limit int,
+) ([]memory.Entry, error) {
+	s.searched = append(s.searched, id)
+	s.limits = append(s.limits, limit)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if limit < len(s.entries) {
+		return s.entries[:limit], nil
+	}
+	return s.entries, nil
+}
+
+func (s *knowledgeStore) GetAll(
+	context.Context,
+	string,
+	int,
+) ([]memory.Entry, error) {
+	return s.entries, nil
+}
+
+func (s *knowledgeStore) Delete(context.Context, string) error {
+	return errors.New("read-only")
+}
+
+func (s *knowledgeStore) Update(
+	context.Context,
+	string,
+	string,
+	map[string]any,
+) error {
+	return errors.New("read-only")
+}
+
+type reverseReranker struct{}
+
+func (reverseReranker) Rerank(
+	_ context.Context,
+	_ string,
+	docs []string,
+) (*rerankers.RerankerResponse, error) {
+	resp := &rerankers.RerankerResponse{}
+	for i := len(docs) - 1; i >= 0; i-- {
+		resp.Results = append(resp.Results, rerankers.RerankerResult{
+			Index:          i,
+			RelevanceScore: float64(i+1) / float64(len(docs)),
+		})
+	}
+	return resp, nil
+}
+
+func (reverseReranker) Model() model.RerankerModel {
+	return model.RerankerModel{}
+}
+
+func newKnowledgeStore() *knowledgeStore {
+	return &knowledgeStore{
+		entries: []memory.Entry{
+			{
+				Content:  "Refunds are processed within 5 days.",
+				Score:    0.9,
+				Metadata: map[string]any{"source": "refunds.md"},
+			},
+			{Content: "Shipping is free over $50.", Score: 0.6},
+			{Content: "Our office is closed on Sundays.", Score: 0.2},
+		},
+	}
+}
+
+func systemPromptOf(t *testing.T, msgs []message.Message) string {
+	t.Helper()
+	for _, msg := range msgs {
+		if msg.Role == message.System {
+			return msg.Content().Text
+		}
+	}
+	t.Fatal("no system message sent")
+	return ""
+}
+
+func TestWithKnowledgeBase_ExposesSearchTool(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{{
+				ID:    "call_1",
+				Name:  "search_knowledge",
+				Input: `{"query":"refund policy"}`,
+				Type:  "function",
+			}},
+		},
+		mockResponse{Content: "Five days."},
+	)
+	a := agent.New(mockLLM,
+		agent.WithKnowledgeBase(store,
+			agent.KnowledgeCorpus("docs"),
+			agent.KnowledgeMinScore(0.5),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "refunds?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(store.searched) != 1 || store.searched[0] != "docs" {
+		t.Fatalf("expected one search of corpus docs, got %v", store.searched)
+	}
+
+	var result string
+	for _, msg := range mockLLM.calls[1] {
+		for _, tr := range msg.ToolResults() {
+			result = tr.Content
+		}
+	}
+	if !strings.Contains(result, "[refunds.md] Refunds are processed") {
+		t.Errorf("expected sourced refund chunk, got %q", result)
+	}
+	if !strings.Contains(result, "Shipping is free") {
+		t.Errorf("expected shipping chunk, got %q", result)
+	}
+	if strings.Contains(result, "Sundays") {
+		t.Errorf("expected low-score chunk to be filtered, got %q", result)
+	}
+}
+
+func TestWithKnowledgeBase_AutoRetrieveInjectsContext(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("You are support."),
+		agent.WithKnowledgeBase(store,
+			agent.KnowledgeAutoRetrieve(),
+			agent.KnowledgeTopK(1),
+		),
+	)
+
+	resp, err := a.Chat(context.Background(), "refunds?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.ToolCalls) != 0 {
+		t.Fatalf("unexpected tool calls: %v", resp.ToolCalls)
+	}
+
+	prompt := systemPromptOf(t, mockLLM.calls[0])
+	if !strings.Contains(prompt, "Relevant knowledge base excerpts:") ||
+		!strings.Contains(prompt, "Refunds are processed") {
+		t.Errorf("expected knowledge in system prompt, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Shipping") {
+		t.Errorf("expected top-k of 1, got %q", prompt)
+	}
+	if store.searched[0] != agent.DefaultKnowledgeCorpus {
+		t.Errorf("expected default corpus, got %q", store.searched[0])
+	}
+}
+
+func TestWithKnowledgeBase_RerankerReordersCandidates(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithKnowledgeBase(store,
+			agent.KnowledgeAutoRetrieve(),
+			agent.KnowledgeReranker(reverseReranker{}),
+			agent.KnowledgeCandidates(10),
+			agent.KnowledgeTopK(1),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "hours?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if store.limits[0] != 10 {
+		t.Errorf("expected 10 candidates fetched, got %d", store.limits[0])
+	}
+	prompt := systemPromptOf(t, mockLLM.calls[0])
+	if !strings.Contains(prompt, "Sundays") ||
+		strings.Contains(prompt, "Refunds") {
+		t.Errorf("expected reranked top chunk only, got %q", prompt)
+	}
+}
+
+func TestWithKnowledgeBase_MaxTokensBudget(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithKnowledgeBase(store,
+			agent.KnowledgeAutoRetrieve(),
+			agent.KnowledgeMaxTokens(15),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "refunds?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prompt := systemPromptOf(t, mockLLM.calls[0])
+	if !strings.Contains(prompt, "Refunds") {
+		t.Errorf("expected first chunk within budget, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Sundays") {
+		t.Errorf("expected budget to drop later chunks, got %q", prompt)
+	}
+}
+
+func TestWithKnowledgeBase_SearchErrorDoesNotFailChat(t *testing.T) {
+	store := &knowledgeStore{err: errors.New("index offline")}
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("base"),
+		agent.WithKnowledgeBase(store, agent.KnowledgeAutoRetrieve()),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompt := systemPromptOf(t, mockLLM.calls[0]); prompt != "base" {
+		t.Errorf("expected unchanged system prompt, got %q", prompt)
+	}
+}
+
+func TestWithAutoRetrieve_ReportsSources(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithAutoRetrieve(store, 2, agent.KnowledgeMinScore(0.5)),
+	)
+
+	resp, err := a.Chat(context.Background(), "refunds?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if store.limits[0] != 2 {
+		t.Errorf("expected k of 2, got %d", store.limits[0])
+	}
+	if len(resp.Sources) != 2 {
+		t.Fatalf("expected 2 sources, got %v", resp.Sources)
+	}
+	if resp.Sources[0].Metadata["source"] != "refunds.md" {
+		t.Errorf("expected refunds.md first, got %v", resp.Sources[0])
+	}
+	if !strings.Contains(systemPromptOf(t, mockLLM.calls[0]), "Shipping") {
+		t.Error("expected second source in system prompt")
+	}
+}
+
+func TestWithAutoRetrieve_ThresholdSkipsInjection(t *testing.T) {
+	store := newKnowledgeStore()
+	mockLLM := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mockLLM,
+		agent.WithSystemPrompt("base"),
+		agent.WithAutoRetrieve(store, 3, agent.KnowledgeMinScore(0.95)),
+	)
+
+	resp, err := a.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Sources) != 0 {
+		t.Errorf("expected no sources, got %v", resp.Sources)
+	}
+	if prompt := systemPromptOf(t, mockLLM.calls[0]); prompt != "base" {
+		t.Errorf("expected unchanged system prompt, got %q", prompt)
+	}
+}
+
+func TestWithAutoRetrieve_StreamReportsSources(t *testing.T) {
+	store := newKnowledgeStore()
+	a := agent.New(
+		newMockLLM(mockResponse{Content: "ok"}),
+		agent.WithAutoRetrieve(store, 1),
+	)
+
+	var sources []memory.Entry
+	for event := range a.ChatStream(context.Background(), "refunds?") {
+		if event.Type == types.EventComplete && event.Response != nil {
+			sources = event.Response.Sources
+		}
+	}
+
+	if len(sources) != 1 || sources[0].Content != store.entries[0].Content {
+		t.Errorf("expected the top entry as source, got %v", sources)
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestLogger() (*slog.Logger, *syncBuffer) {
+	buf := &syncBuffer{}
+	handler := slog.NewTextHandler(
+		buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	)
+	return slog.New(handler), buf
+}
+
+func TestWithLogger_LogsLLMAndToolCalls(t *testing.T) {
+	mockLLM := newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{
+					ID:    "tc-1",
+					Name:  "echo",
+					Input: `{"text":"a"}`,
+					Type:  "function",
+				},
+			},
+			Usage: llm.TokenUsage{InputTokens: 12, OutputTokens: 3},
+		},
+		mockResponse{Content: "done"},
+	)
+	logger, buf := newTestLogger()
+	a := agent.New(mockLLM,
+		agent.WithTools(&echoTool{}),
+		agent.WithLogger(logger),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`msg="llm request started"`,
+		`msg="llm request completed"`,
+		"model=",
+		"input_tokens=12",
+		`msg="tool executed"`,
+		"tool=echo",
+		"tool_call_id=tc-1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected log output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWithLogger_LogsLLMFailure(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{Err: errors.New("boom")})
+	logger, buf := newTestLogger()
+	a := agent.New(mockLLM, agent.WithLogger(logger))
+
+	if _, err := a.Chat(context.Background(), "hi"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `msg="llm request failed"`) ||
+		!strings.Contains(out, "error=boom") {
+		t.Errorf("expected failure log, got:\n%s", out)
+	}
+}
+
+func TestWithLogger_DefaultIsSilent(t *testing.T) {
+	logger, buf := newTestLogger()
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	mockLLM := newMockLLM(mockResponse{Content: "hello"})
+	a := agent.New(mockLLM)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out := buf.String(); out != "" {
+		t.Errorf("expected no log output without WithLogger, got:\n%s", out)
+	}
+}
+
+func TestLoggerFromContext_DefaultsToDiscard(t *testing.T) {
+	logger := llm.LoggerFromContext(context.Background())
+	if logger == nil {
+		t.Fatal("expected non-nil logger")
+	}
+	if logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected default logger to discard records")
+	}
+
+	custom, _ := newTestLogger()
+	ctx := llm.ContextWithLogger(context.Background(), custom)
+	if llm.LoggerFromContext(ctx) != custom {
+		t.Error("expected logger from context")
+	}
+}
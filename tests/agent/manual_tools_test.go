@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func twoToolCalls() mockResponse {
+	return mockResponse{
+		ToolCalls: []message.ToolCall{
+			{ID: "tc-1", Name: "echo", Input: `{"text":"a"}`, Type: "function"},
+			{ID: "tc-2", Name: "echo", Input: `{"text":"b"}`, Type: "function"},
+		},
+	}
+}
+
+func TestManualToolExecution_PauseAndSubmit(t *testing.T) {
+	store := session.MemoryStore()
+	mockLLM := newMockLLM(twoToolCalls(), mockResponse{Content: "done"})
+
+	a := agent.New(mockLLM,
+		agent.WithManualToolExecution(),
+		agent.WithTools(&echoTool{}),
+		agent.WithSession("manual", store),
+	)
+
+	resp, err := a.Chat(context.Background(), "go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.ToolCalls) != 2 {
+		t.Fatalf("expected 2 pending tool calls, got %d", len(resp.ToolCalls))
+	}
+	if len(resp.ToolResults) != 0 {
+		t.Errorf("expected no tool results, got %d", len(resp.ToolResults))
+	}
+
+	pending, err := a.PendingToolCalls(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ID != "tc-1" || pending[1].ID != "tc-2" {
+		t.Fatalf("unexpected pending calls: %+v", pending)
+	}
+
+	resp, err = a.SubmitToolResults(context.Background(), []message.ToolResult{
+		{ToolCallID: "tc-2", Content: "B"},
+		{ToolCallID: "tc-1", Content: "A"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "done" {
+		t.Errorf("unexpected response: %q", resp.Content)
+	}
+
+	pending, err = a.PendingToolCalls(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending calls, got %+v", pending)
+	}
+
+	sess, err := store.Load(context.Background(), "manual")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msgs, _ := sess.GetMessages(context.Background(), nil)
+	var names []string
+	for _, msg := range msgs {
+		for _, r := range msg.ToolResults() {
+			names = append(names, r.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "echo" || names[1] != "echo" {
+		t.Errorf("expected result names filled from calls, got %v", names)
+	}
+}
+
+func TestManualToolExecution_ResumeFromAnotherAgent(t *testing.T) {
+	store := session.MemoryStore()
+
+	first := agent.New(newMockLLM(twoToolCalls()),
+		agent.WithManualToolExecution(),
+		agent.WithTools(&echoTool{}),
+		agent.WithSession("shared", store),
+	)
+	if _, err := first.Chat(context.Background(), "go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secondLLM := newMockLLM(mockResponse{Content: "resumed"})
+	second := agent.New(secondLLM,
+		agent.WithManualToolExecution(),
+		agent.WithTools(&echoTool{}),
+		agent.WithSession("shared", store),
+	)
+
+	pending, err := second.PendingToolCalls(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending calls, got %d", len(pending))
+	}
+
+	var results []message.ToolResult
+	for _, tc := range pending {
+		results = append(results, message.ToolResult{
+			ToolCallID: tc.ID,
+			Content:    "ok",
+		})
+	}
+	resp, err := second.SubmitToolResults(context.Background(), results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "resumed" {
+		t.Errorf("unexpected response: %q", resp.Content)
+	}
+	if secondLLM.CallCount() != 1 {
+		t.Errorf("expected 1 LLM call, got %d", secondLLM.CallCount())
+	}
+}
+
+func TestSubmitToolResults_Mismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []message.ToolResult
+		want    agent.ToolResultMismatchError
+	}{
+		{
+			name:    "missing",
+			results: []message.ToolResult{{ToolCallID: "tc-1"}},
+			want:    agent.ToolResultMismatchError{Missing: []string{"tc-2"}},
+		},
+		{
+			name: "unknown",
+			results: []message.ToolResult{
+				{ToolCallID: "tc-1"},
+				{ToolCallID: "tc-2"},
+				{ToolCallID: "tc-9"},
+			},
+			want: agent.ToolResultMismatchError{Unknown: []string{"tc-9"}},
+		},
+		{
+			name: "duplicate",
+			results: []message.ToolResult{
+				{ToolCallID: "tc-1"},
+				{ToolCallID: "tc-1"},
+				{ToolCallID: "tc-2"},
+			},
+			want: agent.ToolResultMismatchError{Duplicate: []string{"tc-1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLM := newMockLLM(twoToolCalls())
+			a := agent.New(mockLLM,
+				agent.WithManualToolExecution(),
+				agent.WithTools(&echoTool{}),
+				agent.WithSession("mismatch", session.MemoryStore()),
+			)
+			if _, err := a.Chat(context.Background(), "go"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err := a.SubmitToolResults(context.Background(), tt.results)
+			var mismatch *agent.ToolResultMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("expected ToolResultMismatchError, got %v", err)
+			}
+			if !slices.Equal(mismatch.Missing, tt.want.Missing) ||
+				!slices.Equal(mismatch.Unknown, tt.want.Unknown) ||
+				!slices.Equal(mismatch.Duplicate, tt.want.Duplicate) {
+				t.Errorf("got %+v, want %+v", *mismatch, tt.want)
+			}
+			if mockLLM.CallCount() != 1 {
+				t.Errorf("expected no LLM call after mismatch")
+			}
+
+			pending, _ := a.PendingToolCalls(context.Background())
+			if len(pending) != 2 {
+				t.Errorf("expected calls to stay pending, got %d", len(pending))
+			}
+		})
+	}
+}
+
+func TestSubmitToolResults_NothingPending(t *testing.T) {
+	a := agent.New(newMockLLM(mockResponse{Content: "hi"}),
+		agent.WithManualToolExecution(),
+		agent.WithSession("idle", session.MemoryStore()),
+	)
+	if _, err := a.Chat(context.Background(), "hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := a.SubmitToolResults(context.Background(), []message.ToolResult{
+		{ToolCallID: "tc-1", Content: "x"},
+	})
+	if !errors.Is(err, agent.ErrNoPendingToolCalls) {
+		t.Errorf("expected ErrNoPendingToolCalls, got %v", err)
+	}
+}
+
+func TestPendingToolCalls_NoSession(t *testing.T) {
+	a := agent.New(newMockLLM())
+	if _, err := a.PendingToolCalls(context.Background()); err == nil {
+		t.Fatal("expected error without a session")
+	}
+}
+
+func TestSubmitToolResultsStream(t *testing.T) {
+	mockLLM := newMockLLM(twoToolCalls(), mockResponse{Content: "streamed"})
+	a := agent.New(mockLLM,
+		agent.WithManualToolExecution(),
+		agent.WithTools(&echoTool{}),
+		agent.WithSession("stream", session.MemoryStore()),
+	)
+	if _, err := a.Chat(context.Background(), "go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var errEvent error
+	for evt := range a.SubmitToolResultsStream(
+		context.Background(),
+		[]message.ToolResult{{ToolCallID: "tc-1"}},
+	) {
+		if evt.Type == types.EventError {
+			errEvent = evt.Error
+		}
+	}
+	var mismatch *agent.ToolResultMismatchError
+	if !errors.As(errEvent, &mismatch) {
+		t.Fatalf("expected mismatch error event, got %v", errEvent)
+	}
+
+	var content string
+	for evt := range a.SubmitToolResultsStream(
+		context.Background(),
+		[]message.ToolResult{
+			{ToolCallID: "tc-1", Content: "A"},
+			{ToolCallID: "tc-2", Content: "B"},
+		},
+	) {
+		switch evt.Type {
+		case types.EventError:
+			t.Fatalf("unexpected error: %v", evt.Error)
+		case types.EventComplete:
+			content = evt.Response.Content
+		}
+	}
+	if content != "streamed" {
+		t.Errorf("unexpected response: %q", content)
+	}
+}
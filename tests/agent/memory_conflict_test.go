@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+var errStore = errors.New("store failed")
+
+type supersedingStore struct {
+	entries  []memory.Entry
+	failWith error
+}
+
+func (s *supersedingStore) Search(
+	context.Context,
+	string,
+	string,
+	int,
+) ([]memory.Entry, error) {
+	return slices.Clone(s.entries), nil
+}
+
+func (s *supersedingStore) Store(
+	_ context.Context,
+	_ string,
+	fact string,
+	metadata map[string]any,
+) error {
+	if s.failWith != nil {
+		return s.failWith
+	}
+	s.entries = append(s.entries, memory.Entry{
+		ID:       "new",
+		Content:  fact,
+		Metadata: metadata,
+	})
+	return nil
+}
+
+func (s *supersedingStore) GetAll(
+	context.Context,
+	string,
+	int,
+) ([]memory.Entry, error) {
+	return slices.Clone(s.entries), nil
+}
+
+func (s *supersedingStore) Update(
+	context.Context,
+	string,
+	string,
+	map[string]any,
+) error {
+	return nil
+}
+
+func (s *supersedingStore) Delete(_ context.Context, id string) error {
+	s.entries = slices.DeleteFunc(s.entries, func(e memory.Entry) bool {
+		return e.ID == id
+	})
+	return nil
+}
+
+func extractWithConflict(t *testing.T, store *supersedingStore) {
+	t.Helper()
+	memLLM := newMockLLM(
+		mockResponse{Content: `{"facts":["Hates Italian food."]}`},
+		mockResponse{Content: "```json\n" +
+			`{"conflicts":[{"memory_id":"old","reason":"opposite"}]}` +
+			"\n```"},
+	)
+	a := agent.New(newMockLLM(mockResponse{Content: "Noted."}),
+		agent.WithSession("conflict", session.MemoryStore()),
+		agent.WithMemory("user-1", store,
+			memory.AutoExtract(),
+			memory.WithExtractInterval(10),
+			memory.WithConflictResolution(),
+			memory.LLM(memLLM),
+		),
+	)
+	ctx := context.Background()
+	if _, err := a.Chat(ctx, "I hate Italian food now"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ExtractMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConflictResolution_DeletesAfterStoring(t *testing.T) {
+	store := &supersedingStore{entries: []memory.Entry{
+		{ID: "old", Content: "Loves Italian food."},
+	}}
+	extractWithConflict(t, store)
+
+	if len(store.entries) != 1 || store.entries[0].ID != "new" {
+		t.Fatalf("entries = %+v, want only the new fact", store.entries)
+	}
+	ids, _ := store.entries[0].Metadata[memory.MetadataSupersedes].([]string)
+	if !slices.Equal(ids, []string{"old"}) {
+		t.Errorf("supersedes = %v, want [old]", ids)
+	}
+}
+
+func TestConflictResolution_FailedStoreKeepsOldFact(t *testing.T) {
+	store := &supersedingStore{
+		entries:  []memory.Entry{{ID: "old", Content: "Loves Italian food."}},
+		failWith: errStore,
+	}
+	extractWithConflict(t, store)
+
+	if len(store.entries) != 1 || store.entries[0].ID != "old" {
+		t.Errorf("entries = %+v, want the old fact kept", store.entries)
+	}
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+)
+
+var errEmbeddingsDown = errors.New("embeddings provider unavailable")
+
+func TestWithMemory_FailsOpenByDefault(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "hello"})
+	store := newMemoryToolsStore()
+	store.err = errEmbeddingsDown
+	a := agent.New(mock, agent.WithMemory("user-1", store))
+
+	resp, err := a.Chat(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "hello" {
+		t.Errorf("content = %q, want hello", resp.Content)
+	}
+	if len(store.searched) != 1 {
+		t.Errorf("expected one recall attempt, got %v", store.searched)
+	}
+}
+
+func TestWithMemory_FailClosed(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "hello"})
+	store := newMemoryToolsStore()
+	store.err = errEmbeddingsDown
+	a := agent.New(mock,
+		agent.WithMemory("user-1", store, memory.WithFailClosed()),
+	)
+
+	_, err := a.Chat(context.Background(), "hi")
+	if !errors.Is(err, errEmbeddingsDown) {
+		t.Fatalf("err = %v, want %v", err, errEmbeddingsDown)
+	}
+	if mock.CallCount() != 0 {
+		t.Errorf("model called %d times, want 0", mock.CallCount())
+	}
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type toolInfoLLM struct {
+	*mockLLM
+	infos []tool.Info
+}
+
+func (m *toolInfoLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	m.infos = nil
+	for _, t := range tools {
+		m.infos = append(m.infos, t.Info())
+	}
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func newMemoryToolsStore() *knowledgeStore {
+	return &knowledgeStore{
+		entries: []memory.Entry{
+			{
+				ID:       "m1",
+				Content:  "Allergic to peanuts.",
+				Metadata: map[string]any{"category": "health"},
+			},
+			{
+				ID:       "m2",
+				Content:  "Prefers window seats.",
+				Metadata: map[string]any{"category": "preference"},
+			},
+			{ID: "m3", Content: "Lives in Oslo."},
+		},
+	}
+}
+
+func toolResultOf(calls [][]message.Message) string {
+	var result string
+	for _, msg := range calls[len(calls)-1] {
+		for _, tr := range msg.ToolResults() {
+			result = tr.Content
+		}
+	}
+	return result
+}
+
+func TestWithMemoryTools_SelectsAndDescribesTools(t *testing.T) {
+	mock := &toolInfoLLM{mockLLM: newMockLLM(mockResponse{Content: "ok"})}
+	a := agent.New(mock,
+		agent.WithMemory("user-1", newMemoryToolsStore(), memory.AutoExtract()),
+		agent.WithMemoryTools(
+			agent.MemoryToolSelection(
+				agent.MemoryToolList,
+				agent.MemoryToolSearch,
+			),
+			agent.MemoryToolDescription(agent.MemoryToolSearch, "Look it up."),
+		),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, info := range mock.infos {
+		names = append(names, info.Name)
+		if info.Name == agent.MemoryToolSearch &&
+			info.Description != "Look it up." {
+			t.Errorf("search description = %q", info.Description)
+		}
+	}
+	want := []string{agent.MemoryToolList, agent.MemoryToolSearch}
+	if !slices.Equal(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
+
+func TestWithMemoryTools_DefaultsToAllTools(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM(mockResponse{Content: "ok"})}
+	a := agent.New(mock,
+		agent.WithMemory("user-1", newMemoryToolsStore()),
+		agent.WithMemoryTools(),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := mock.lastNames(); len(got) != 7 {
+		t.Errorf("tools = %v, want all 7 memory tools", got)
+	}
+}
+
+func TestWithMemoryTools_SearchFiltersByMetadata(t *testing.T) {
+	mock := newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_1",
+			Name:  agent.MemoryToolSearch,
+			Input: `{"query":"diet","metadata":{"category":"health"}}`,
+			Type:  "function",
+		}}},
+		mockResponse{Content: "done"},
+	)
+	a := agent.New(mock,
+		agent.WithMemory("user-1", newMemoryToolsStore()),
+		agent.WithMemoryTools(),
+	)
+
+	if _, err := a.Chat(context.Background(), "what can I eat?"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := toolResultOf(mock.calls)
+	if !strings.Contains(result, "[id:m1] Allergic to peanuts.") {
+		t.Errorf("expected health memory, got %q", result)
+	}
+	if strings.Contains(result, "window") || strings.Contains(result, "Oslo") {
+		t.Errorf("expected other categories filtered out, got %q", result)
+	}
+}
+
+func TestWithMemoryTools_CountAndList(t *testing.T) {
+	mock := newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_1",
+			Name:  agent.MemoryToolCount,
+			Input: `{}`,
+			Type:  "function",
+		}}},
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_2",
+			Name:  agent.MemoryToolList,
+			Input: `{"limit":1}`,
+			Type:  "function",
+		}}},
+		mockResponse{Content: "done"},
+	)
+	a := agent.New(mock,
+		agent.WithMemory("user-1", newMemoryToolsStore()),
+		agent.WithMemoryTools(),
+	)
+
+	if _, err := a.Chat(context.Background(), "what do you know?"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := toolResultOf(mock.calls[:2]); got != "Matching memories: 3" {
+		t.Errorf("count result = %q", got)
+	}
+	list := toolResultOf(mock.calls)
+	if list != "- [id:m1] Allergic to peanuts. {category=health}" {
+		t.Errorf("list result = %q", list)
+	}
+}
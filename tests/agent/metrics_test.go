@@ -2,11 +2,14 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/joakimcarlsson/ai/agent"
 	llm "github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/metrics"
 	"github.com/joakimcarlsson/ai/types"
 )
 
@@ -184,3 +187,180 @@ func TestMetrics_Stream(t *testing.T) {
 		t.Error("expected TotalDuration > 0")
 	}
 }
+
+type observation struct {
+	name   string
+	value  float64
+	labels metrics.Labels
+}
+
+type recordingCollector struct {
+	mu         sync.Mutex
+	counters   []observation
+	histograms []observation
+}
+
+func (c *recordingCollector) IncCounter(
+	name string,
+	value float64,
+	labels metrics.Labels,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters = append(c.counters, observation{name, value, labels})
+}
+
+func (c *recordingCollector) ObserveHistogram(
+	name string,
+	value float64,
+	labels metrics.Labels,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.histograms = append(c.histograms, observation{name, value, labels})
+}
+
+func (c *recordingCollector) counterTotal(
+	name string,
+	match metrics.Labels,
+) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total float64
+	for _, o := range c.counters {
+		if o.name != name || !labelsMatch(o.labels, match) {
+			continue
+		}
+		total += o.value
+	}
+	return total
+}
+
+func (c *recordingCollector) histogramCount(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int
+	for _, o := range c.histograms {
+		if o.name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func labelsMatch(labels, match metrics.Labels) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWithMetrics_RecordsLLMToolAndRunMetrics(t *testing.T) {
+	mockLLM := newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{
+					ID:    "tc-1",
+					Name:  "echo",
+					Input: `{"text":"a"}`,
+					Type:  "function",
+				},
+			},
+			Usage: llm.TokenUsage{InputTokens: 100, OutputTokens: 20},
+		},
+		mockResponse{
+			Content: "done",
+			Usage:   llm.TokenUsage{InputTokens: 30, OutputTokens: 5},
+		},
+	)
+	collector := &recordingCollector{}
+	a := agent.New(mockLLM,
+		agent.WithTools(&echoTool{}),
+		agent.WithMetrics(collector),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := collector.counterTotal(
+		metrics.LLMRequests,
+		metrics.Labels{metrics.LabelStatus: metrics.StatusOK},
+	); got != 2 {
+		t.Errorf("expected 2 llm requests, got %v", got)
+	}
+	if got := collector.counterTotal(
+		metrics.LLMTokens,
+		metrics.Labels{metrics.LabelType: "input"},
+	); got != 130 {
+		t.Errorf("expected 130 input tokens, got %v", got)
+	}
+	if got := collector.counterTotal(
+		metrics.LLMTokens,
+		metrics.Labels{metrics.LabelType: "output"},
+	); got != 25 {
+		t.Errorf("expected 25 output tokens, got %v", got)
+	}
+	if got := collector.counterTotal(
+		metrics.ToolExecutions,
+		metrics.Labels{
+			metrics.LabelTool:   "echo",
+			metrics.LabelStatus: metrics.StatusOK,
+		},
+	); got != 1 {
+		t.Errorf("expected 1 echo tool execution, got %v", got)
+	}
+	if got := collector.counterTotal(metrics.AgentRuns, nil); got != 1 {
+		t.Errorf("expected 1 agent run, got %v", got)
+	}
+	if n := collector.histogramCount(metrics.LLMRequestDuration); n != 2 {
+		t.Errorf("expected 2 llm latency observations, got %d", n)
+	}
+	if n := collector.histogramCount(metrics.ToolDuration); n != 1 {
+		t.Errorf("expected 1 tool latency observation, got %d", n)
+	}
+}
+
+func TestWithMetrics_RecordsErrors(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{Err: errors.New("boom")})
+	collector := &recordingCollector{}
+	a := agent.New(mockLLM, agent.WithMetrics(collector))
+
+	if _, err := a.Chat(context.Background(), "hi"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if got := collector.counterTotal(metrics.LLMErrors, nil); got != 1 {
+		t.Errorf("expected 1 llm error, got %v", got)
+	}
+	if got := collector.counterTotal(
+		metrics.AgentRuns,
+		metrics.Labels{metrics.LabelStatus: metrics.StatusError},
+	); got != 1 {
+		t.Errorf("expected 1 failed agent run, got %v", got)
+	}
+}
+
+func TestWithMetrics_StreamRecordsLLMRequest(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{
+		Content: "streamed",
+		Usage:   llm.TokenUsage{InputTokens: 7, OutputTokens: 3},
+	})
+	collector := &recordingCollector{}
+	a := agent.New(mockLLM, agent.WithMetrics(collector))
+
+	for range a.ChatStream(context.Background(), "hi") {
+	}
+
+	if got := collector.counterTotal(metrics.LLMRequests, nil); got != 1 {
+		t.Errorf("expected 1 llm request, got %v", got)
+	}
+	if got := collector.counterTotal(
+		metrics.LLMTokens,
+		metrics.Labels{metrics.LabelType: "output"},
+	); got != 3 {
+		t.Errorf("expected 3 output tokens, got %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func recordingMiddleware(name string, log *[]string) agent.AgentMiddleware {
+	return func(next agent.ChatFunc) agent.ChatFunc {
+		return func(
+			ctx context.Context,
+			msg string,
+			opts ...agent.ChatOption,
+		) (*agent.ChatResponse, error) {
+			*log = append(*log, name+" in")
+			resp, err := next(ctx, msg, opts...)
+			*log = append(*log, name+" out")
+			return resp, err
+		}
+	}
+}
+
+func TestWithMiddleware_Order(t *testing.T) {
+	var log []string
+	a := agent.New(newMockLLM(mockResponse{Content: "hi"}),
+		agent.WithMiddleware(recordingMiddleware("first", &log)),
+		agent.WithMiddleware(recordingMiddleware("second", &log)),
+	)
+
+	if _, err := a.Chat(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first in", "second in", "second out", "first out"}
+	if !slices.Equal(log, want) {
+		t.Errorf("order = %v, want %v", log, want)
+	}
+}
+
+func TestWithMiddleware_ShortCircuit(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "hi"})
+	errDenied := errors.New("denied")
+	a := agent.New(mock, agent.WithMiddleware(
+		func(agent.ChatFunc) agent.ChatFunc {
+			return func(
+				context.Context,
+				string,
+				...agent.ChatOption,
+			) (*agent.ChatResponse, error) {
+				return nil, errDenied
+			}
+		},
+	))
+
+	if _, err := a.Chat(context.Background(), "hello"); !errors.Is(
+		err,
+		errDenied,
+	) {
+		t.Fatalf("err = %v, want errDenied", err)
+	}
+	if mock.CallCount() != 0 {
+		t.Errorf("LLM calls = %d, want 0", mock.CallCount())
+	}
+}
+
+func TestWithMiddleware_StreamForwardsEventsAndFinalResponse(t *testing.T) {
+	upper := func(next agent.ChatFunc) agent.ChatFunc {
+		return func(
+			ctx context.Context,
+			msg string,
+			opts ...agent.ChatOption,
+		) (*agent.ChatResponse, error) {
+			resp, err := next(ctx, msg, opts...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Content = strings.ToUpper(resp.Content)
+			return resp, nil
+		}
+	}
+	a := agent.New(newMockLLM(mockResponse{Content: "hello"}),
+		agent.WithMiddleware(upper),
+	)
+
+	var deltas string
+	var final *agent.ChatResponse
+	completes := 0
+	for evt := range a.ChatStream(context.Background(), "hi") {
+		switch evt.Type {
+		case types.EventContentDelta:
+			deltas += evt.Content
+		case types.EventComplete:
+			completes++
+			final = evt.Response
+		case types.EventError:
+			t.Fatal(evt.Error)
+		}
+	}
+
+	if deltas != "hello" {
+		t.Errorf("deltas = %q, want hello", deltas)
+	}
+	if completes != 1 || final == nil || final.Content != "HELLO" {
+		t.Errorf("complete events = %d, final = %+v", completes, final)
+	}
+}
+
+func TestModerationMiddleware(t *testing.T) {
+	check := func(_ context.Context, text string) error {
+		if strings.Contains(text, "forbidden") {
+			return errors.New("flagged")
+		}
+		return nil
+	}
+
+	t.Run("input", func(t *testing.T) {
+		mock := newMockLLM(mockResponse{Content: "ok"})
+		a := agent.New(mock,
+			agent.WithMiddleware(agent.ModerationMiddleware(check)),
+		)
+		_, err := a.Chat(context.Background(), "say something forbidden")
+		var modErr *agent.ModerationError
+		if !errors.As(err, &modErr) || modErr.Output {
+			t.Fatalf("err = %v, want input ModerationError", err)
+		}
+		if mock.CallCount() != 0 {
+			t.Errorf("LLM calls = %d, want 0", mock.CallCount())
+		}
+	})
+
+	t.Run("output", func(t *testing.T) {
+		store := session.MemoryStore()
+		a := agent.New(
+			newMockLLM(mockResponse{Content: "a forbidden answer"}),
+			agent.WithSession("s1", store),
+			agent.WithMiddleware(agent.ModerationMiddleware(check)),
+		)
+		var streamErr error
+		for evt := range a.ChatStream(context.Background(), "hi") {
+			if evt.Type == types.EventError {
+				streamErr = evt.Error
+			}
+		}
+		var modErr *agent.ModerationError
+		if !errors.As(streamErr, &modErr) || !modErr.Output {
+			t.Fatalf("err = %v, want output ModerationError", streamErr)
+		}
+
+		got := sessionTexts(t, store, "s1")
+		if !slices.Equal(got, []string{"user:hi"}) {
+			t.Errorf("session = %q, want only the user message", got)
+		}
+	})
+}
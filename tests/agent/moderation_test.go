@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/moderation"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func keywordModerator(word string) moderation.Moderator {
+	return moderation.ModeratorFunc(
+		func(_ context.Context, text string) (*moderation.Result, error) {
+			if strings.Contains(text, word) {
+				return &moderation.Result{
+					Flagged:    true,
+					Categories: []string{"violence"},
+				}, nil
+			}
+			return &moderation.Result{}, nil
+		},
+	)
+}
+
+func TestWithModeration_RefusesFlaggedInput(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mock, agent.WithModeration(
+		keywordModerator("attack"),
+		agent.ModerationRefusal("Not here."),
+	))
+
+	resp, err := a.Chat(context.Background(), "plan an attack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Not here." {
+		t.Errorf("content = %q, want refusal", resp.Content)
+	}
+	if resp.Moderation == nil || resp.Moderation.Categories[0] != "violence" {
+		t.Errorf("moderation = %+v", resp.Moderation)
+	}
+	if mock.CallCount() != 0 {
+		t.Errorf("LLM calls = %d, want 0", mock.CallCount())
+	}
+}
+
+func TestWithModeration_OutputOnlyWhenEnabled(t *testing.T) {
+	for _, screen := range []bool{false, true} {
+		var opts []agent.ModerationOption
+		if screen {
+			opts = append(opts, agent.ModerationScreenOutput())
+		}
+		a := agent.New(
+			newMockLLM(mockResponse{Content: "an attack plan"}),
+			agent.WithModeration(keywordModerator("attack"), opts...),
+		)
+
+		resp, err := a.Chat(context.Background(), "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		refused := resp.Content == agent.DefaultModerationRefusal
+		if refused != screen || (resp.Moderation != nil) != screen {
+			t.Errorf("screen=%v: content = %q, moderation = %+v",
+				screen, resp.Content, resp.Moderation)
+		}
+	}
+}
+
+func TestWithModeration_ReplacesFlaggedOutputInSession(t *testing.T) {
+	store := session.MemoryStore()
+	a := agent.New(
+		newMockLLM(mockResponse{Content: "an attack plan"}),
+		agent.WithSession("s1", store),
+		agent.WithModeration(
+			keywordModerator("attack"),
+			agent.ModerationRefusal("Not here."),
+			agent.ModerationScreenOutput(),
+		),
+	)
+
+	resp, err := a.Chat(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Not here." || resp.Moderation == nil {
+		t.Errorf(
+			"content = %q, moderation = %+v",
+			resp.Content,
+			resp.Moderation,
+		)
+	}
+	got := sessionTexts(t, store, "s1")
+	want := []string{"user:hello", "assistant:Not here."}
+	if !slices.Equal(got, want) {
+		t.Errorf("session = %q, want %q", got, want)
+	}
+}
+
+func TestWithModeration_ModeratorErrorFailsTurn(t *testing.T) {
+	failing := moderation.ModeratorFunc(
+		func(context.Context, string) (*moderation.Result, error) {
+			return nil, errors.New("moderation API down")
+		},
+	)
+	mock := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(mock, agent.WithModeration(failing))
+
+	_, err := a.Chat(context.Background(), "hello")
+	var modErr *agent.ModerationError
+	if !errors.As(err, &modErr) || modErr.Output {
+		t.Fatalf("err = %v, want input ModerationError", err)
+	}
+	if mock.CallCount() != 0 {
+		t.Errorf("LLM calls = %d, want 0", mock.CallCount())
+	}
+}
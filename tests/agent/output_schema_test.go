@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/schema"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type weatherTool struct{}
+
+func (weatherTool) Info() tool.Info {
+	return tool.NewInfo("get_weather", "Current weather for a city", struct {
+		City string `json:"city"`
+	}{})
+}
+
+func (weatherTool) Run(context.Context, tool.Call) (tool.Response, error) {
+	return tool.NewTextResponse("18C and sunny"), nil
+}
+
+type weatherSummary struct {
+	City    string `json:"city"`
+	Summary string `json:"summary"`
+}
+
+var weatherSummarySchema = schema.Object().
+	Field("city", schema.String()).
+	Field("summary", schema.String()).
+	Required("city", "summary").
+	Output("weather_summary", "A short weather summary")
+
+const weatherSummaryJSON = `{"city":"Paris","summary":"18C and sunny"}`
+
+func TestWithOutputSchema_ToolsThenStructuredAnswer(t *testing.T) {
+	client := fake.NewLLM(fake.WithResponses(
+		fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+		fake.Structured(weatherSummaryJSON),
+	))
+	a := agent.New(client,
+		agent.WithTools(weatherTool{}),
+		agent.WithOutputSchema(weatherSummarySchema),
+	)
+
+	resp, err := a.Chat(context.Background(), "Weather in Paris?")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	calls := client.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("model called %d times, want 2", len(calls))
+	}
+	for i, call := range calls {
+		if call.Schema != weatherSummarySchema {
+			t.Errorf("call %d sent without the output schema", i)
+		}
+		if !slices.Contains(call.Tools, "get_weather") {
+			t.Errorf("call %d sent without tools: %v", i, call.Tools)
+		}
+	}
+	if resp.TotalToolCalls != 1 {
+		t.Errorf("tool calls = %d, want 1", resp.TotalToolCalls)
+	}
+	if resp.StructuredOutput == nil {
+		t.Fatal("expected structured output")
+	}
+	var summary weatherSummary
+	if err := schema.Parse(*resp.StructuredOutput, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.City != "Paris" || summary.Summary != "18C and sunny" {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestWithOutputSchema_Stream(t *testing.T) {
+	client := fake.NewLLM(fake.WithResponses(
+		fake.ToolCalls(fake.Call("get_weather", `{"city":"Paris"}`)),
+		fake.Structured(weatherSummaryJSON),
+	))
+	a := agent.New(client,
+		agent.WithTools(weatherTool{}),
+		agent.WithOutputSchema(weatherSummarySchema),
+	)
+
+	var final *agent.ChatResponse
+	for event := range a.ChatStream(context.Background(), "Weather?") {
+		switch event.Type {
+		case types.EventError:
+			t.Fatal(event.Error)
+		case types.EventComplete:
+			final = event.Response
+		}
+	}
+
+	if final == nil || final.StructuredOutput == nil ||
+		*final.StructuredOutput != weatherSummaryJSON {
+		t.Fatalf("final response = %+v", final)
+	}
+}
+
+func TestWithOutputSchema_Unsupported(t *testing.T) {
+	client := fake.NewLLM(
+		fake.WithStructuredOutput(false),
+		fake.WithResponses(fake.Text("hi")),
+	)
+	a := agent.New(client, agent.WithOutputSchema(weatherSummarySchema))
+
+	_, err := a.Chat(context.Background(), "hi")
+	if !errors.Is(err, agent.ErrStructuredOutputUnsupported) {
+		t.Fatalf("err = %v, want ErrStructuredOutputUnsupported", err)
+	}
+	if len(client.Calls()) != 0 {
+		t.Error("model called despite the unsupported schema")
+	}
+}
+
+func TestWithOutputSchema_ToolsUnsupportedWithSchema(t *testing.T) {
+	client := fake.NewLLM(
+		fake.WithModel(model.GeminiModels[model.Gemini25Flash]),
+		fake.WithResponses(fake.Text("hi")),
+	)
+	a := agent.New(client,
+		agent.WithTools(weatherTool{}),
+		agent.WithOutputSchema(weatherSummarySchema),
+	)
+
+	_, err := a.Chat(context.Background(), "hi")
+	if !errors.Is(err, agent.ErrStructuredOutputUnsupported) {
+		t.Fatalf("err = %v, want ErrStructuredOutputUnsupported", err)
+	}
+	if calls := client.Calls(); len(calls) != 0 {
+		t.Errorf("model called %d times, want 0", len(calls))
+	}
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type progressTool struct{}
+
+func (t *progressTool) Info() tool.Info {
+	return tool.NewInfo("build", "Builds the project", struct{}{})
+}
+
+func (t *progressTool) Run(
+	ctx context.Context,
+	_ tool.Call,
+) (tool.Response, error) {
+	tool.ReportProgress(ctx, tool.Progress{Message: "compiling", Percent: 40})
+	tool.ReportProgress(ctx, tool.Progress{Message: "linking", Percent: 90})
+	return tool.NewTextResponse("build ok"), nil
+}
+
+func progressMock() *mockLLM {
+	return newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{ID: "tc-1", Name: "build", Input: `{}`, Type: "function"},
+			},
+		},
+		mockResponse{Content: "done"},
+	)
+}
+
+func TestToolProgress_StreamEvents(t *testing.T) {
+	a := agent.New(progressMock(), agent.WithTools(&progressTool{}))
+
+	var progress []agent.ChatEvent
+	var sawResult bool
+	for event := range a.ChatStream(context.Background(), "build it") {
+		switch event.Type {
+		case types.EventToolProgress:
+			if sawResult {
+				t.Error("progress event after tool result")
+			}
+			progress = append(progress, event)
+		case types.EventToolUseStop:
+			sawResult = true
+			if event.ToolResult.Output != "build ok" {
+				t.Errorf("result = %q", event.ToolResult.Output)
+			}
+		case types.EventError:
+			t.Fatal(event.Error)
+		}
+	}
+
+	if len(progress) != 2 {
+		t.Fatalf("progress events = %d, want 2", len(progress))
+	}
+	first := progress[0]
+	if first.ToolCall == nil || first.ToolCall.ID != "tc-1" ||
+		first.ToolCall.Name != "build" {
+		t.Errorf("tool call = %+v", first.ToolCall)
+	}
+	if first.ToolProgress.Message != "compiling" ||
+		first.ToolProgress.Percent != 40 {
+		t.Errorf("progress = %+v", first.ToolProgress)
+	}
+	if progress[1].ToolProgress.Message != "linking" {
+		t.Errorf("second progress = %+v", progress[1].ToolProgress)
+	}
+	if !sawResult {
+		t.Error("missing tool result event")
+	}
+}
+
+func TestToolProgress_ChatIgnoresProgress(t *testing.T) {
+	a := agent.New(progressMock(), agent.WithTools(&progressTool{}))
+
+	resp, err := a.Chat(context.Background(), "build it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ToolResults) != 1 ||
+		resp.ToolResults[0].Output != "build ok" {
+		t.Errorf("tool results = %+v", resp.ToolResults)
+	}
+}
+
+func TestReportProgress_NoEmitter(t *testing.T) {
+	tool.ReportProgress(context.Background(), tool.Progress{Message: "x"})
+
+	var got []tool.Progress
+	ctx := tool.WithProgressEmitter(
+		context.Background(),
+		tool.ProgressEmitterFunc(func(_ context.Context, p tool.Progress) {
+			got = append(got, p)
+		}),
+	)
+	tool.ReportProgress(ctx, tool.Progress{Message: "step", Percent: -1})
+	if len(got) != 1 || got[0].Message != "step" || got[0].Percent != -1 {
+		t.Errorf("got = %+v", got)
+	}
+}
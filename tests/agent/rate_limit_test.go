@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func TestUserRateLimit_RejectsOverLimit(t *testing.T) {
+	mock := newMockLLM(
+		mockResponse{Content: "one"},
+		mockResponse{Content: "two"},
+		mockResponse{Content: "three"},
+	)
+	a := agent.New(mock, agent.WithUserRateLimit(2))
+	ctx := agent.WithUserID(context.Background(), "alice")
+
+	for range 2 {
+		if _, err := a.Chat(ctx, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := a.Chat(ctx, "hi")
+	var rlErr *agent.RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("err = %v, want RateLimitError", err)
+	}
+	if rlErr.UserID != "alice" || rlErr.Limit != 2 {
+		t.Errorf("error = %+v", rlErr)
+	}
+	if rlErr.RetryAfter <= 0 || rlErr.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s", rlErr.RetryAfter)
+	}
+	if mock.CallCount() != 2 {
+		t.Errorf("LLM calls = %d, want 2", mock.CallCount())
+	}
+}
+
+func TestUserRateLimit_PerUser(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "a"}, mockResponse{Content: "b"})
+	a := agent.New(mock, agent.WithUserRateLimit(1))
+
+	alice := agent.WithUserID(context.Background(), "alice")
+	bob := agent.WithUserID(context.Background(), "bob")
+	if _, err := a.Chat(alice, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Chat(bob, "hi"); err != nil {
+		t.Fatalf("bob limited by alice's requests: %v", err)
+	}
+}
+
+func TestUserRateLimit_StreamRejects(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "a"})
+	a := agent.New(mock, agent.WithUserRateLimit(1))
+	ctx := agent.WithUserID(context.Background(), "alice")
+
+	for range a.ChatStream(ctx, "hi") {
+	}
+
+	var streamErr error
+	for event := range a.ChatStream(ctx, "hi") {
+		if event.Type == types.EventError {
+			streamErr = event.Error
+		}
+	}
+	var rlErr *agent.RateLimitError
+	if !errors.As(streamErr, &rlErr) {
+		t.Fatalf("stream error = %v, want RateLimitError", streamErr)
+	}
+}
+
+func TestUserRateLimit_NoUserNotLimited(t *testing.T) {
+	mock := newMockLLM(mockResponse{Content: "a"}, mockResponse{Content: "b"})
+	a := agent.New(mock, agent.WithUserRateLimit(1))
+
+	for range 2 {
+		if _, err := a.Chat(context.Background(), "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// userLLM records the provider user ID each model call receives.
+type userLLM struct {
+	*mockLLM
+	users []string
+}
+
+func (m *userLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	id, _ := llm.UserFromContext(ctx)
+	m.users = append(m.users, id)
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func TestWithUserID_NotSentToProviderByDefault(t *testing.T) {
+	mock := &userLLM{mockLLM: newMockLLM(mockResponse{Content: "ok"})}
+	a := agent.New(mock, agent.WithUserRateLimit(5))
+
+	ctx := agent.WithUserID(context.Background(), "alice")
+	if _, err := a.Chat(ctx, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.users) != 1 || mock.users[0] != "" {
+		t.Errorf("provider users = %q, want none", mock.users)
+	}
+}
+
+func TestWithProviderUserID_SendsUserID(t *testing.T) {
+	mock := &userLLM{mockLLM: newMockLLM(
+		mockResponse{Content: "one"},
+		mockResponse{Content: "two"},
+	)}
+	a := agent.New(mock, agent.WithProviderUserID())
+
+	ctx := agent.WithUserID(context.Background(), "alice")
+	if _, err := a.Chat(ctx, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := a.Chat(
+		context.Background(),
+		"hi",
+		agent.WithCallUserID("bob"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(mock.users, []string{"alice", "bob"}) {
+		t.Errorf("provider users = %q, want alice then bob", mock.users)
+	}
+}
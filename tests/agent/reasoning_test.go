@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+var gpt5 = model.Model{
+	ID:        "gpt-5",
+	Provider:  model.ProviderOpenAI,
+	APIModel:  "gpt-5",
+	CanReason: true,
+}
+
+type reasoningLLM struct {
+	*mockLLM
+	model     model.Model
+	efforts   []llm.ReasoningEffort
+	verbosity []llm.Verbosity
+	missing   int
+}
+
+func (m *reasoningLLM) Model() model.Model { return m.model }
+
+func (m *reasoningLLM) record(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	effort, hasEffort := llm.ReasoningEffortFromContext(ctx)
+	verbosity, hasVerbosity := llm.VerbosityFromContext(ctx)
+	if !hasEffort || !hasVerbosity {
+		m.missing++
+		return
+	}
+	m.efforts = append(m.efforts, effort)
+	m.verbosity = append(m.verbosity, verbosity)
+}
+
+func (m *reasoningLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	m.record(ctx)
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func (m *reasoningLLM) StreamResponse(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) <-chan llm.Event {
+	m.record(ctx)
+	return m.mockLLM.StreamResponse(ctx, msgs, tools)
+}
+
+func TestWithReasoningEffort_EveryModelCall(t *testing.T) {
+	mock := &reasoningLLM{
+		model: gpt5,
+		mockLLM: newMockLLM(
+			mockResponse{
+				ToolCalls: []message.ToolCall{
+					{ID: "tc-1", Name: "echo", Input: `{}`, Type: "function"},
+				},
+			},
+			mockResponse{Content: "done"},
+		),
+	}
+	a := agent.New(
+		mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithReasoningEffort(llm.ReasoningEffortHigh),
+		agent.WithVerbosity(llm.VerbosityLow),
+	)
+
+	if _, err := a.Chat(context.Background(), "think"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.efforts) != 2 || mock.missing != 0 {
+		t.Fatalf(
+			"efforts = %v, missing = %d",
+			mock.efforts,
+			mock.missing,
+		)
+	}
+	for i := range mock.efforts {
+		if mock.efforts[i] != llm.ReasoningEffortHigh ||
+			mock.verbosity[i] != llm.VerbosityLow {
+			t.Errorf("call %d: effort %q, verbosity %q",
+				i, mock.efforts[i], mock.verbosity[i])
+		}
+	}
+}
+
+func TestWithReasoningEffort_Stream(t *testing.T) {
+	mock := &reasoningLLM{
+		model:   gpt5,
+		mockLLM: newMockLLM(mockResponse{Content: "x"}),
+	}
+	a := agent.New(
+		mock,
+		agent.WithReasoningEffort(llm.ReasoningEffortLow),
+		agent.WithVerbosity(llm.VerbosityHigh),
+	)
+
+	for evt := range a.ChatStream(context.Background(), "hi") {
+		if evt.Type == types.EventError {
+			t.Fatal(evt.Error)
+		}
+	}
+	if len(mock.efforts) != 1 || mock.efforts[0] != llm.ReasoningEffortLow {
+		t.Errorf("efforts = %v", mock.efforts)
+	}
+}
+
+func TestWithReasoningEffort_RejectsUnsupportedModel(t *testing.T) {
+	nonReasoning := gpt5
+	nonReasoning.CanReason = false
+	gemini25 := model.GeminiModels[model.Gemini25Flash]
+	claude := model.Model{
+		ID:        "claude",
+		Provider:  model.ProviderAnthropic,
+		APIModel:  "claude-sonnet-4-5",
+		CanReason: true,
+	}
+	tests := []struct {
+		name  string
+		model model.Model
+		opts  []agent.Option
+		want  error
+	}{
+		{
+			name:  "effort without reasoning",
+			model: nonReasoning,
+			opts: []agent.Option{
+				agent.WithReasoningEffort(llm.ReasoningEffortMedium),
+			},
+			want: agent.ErrReasoningUnsupported,
+		},
+		{
+			name:  "effort on gemini 2.5",
+			model: gemini25,
+			opts: []agent.Option{
+				agent.WithReasoningEffort(llm.ReasoningEffortMedium),
+			},
+			want: agent.ErrReasoningUnsupported,
+		},
+		{
+			name:  "verbosity outside gpt-5",
+			model: claude,
+			opts: []agent.Option{
+				agent.WithReasoningEffort(llm.ReasoningEffortMedium),
+				agent.WithVerbosity(llm.VerbosityLow),
+			},
+			want: agent.ErrVerbosityUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &reasoningLLM{model: tt.model, mockLLM: newMockLLM()}
+			a := agent.New(mock, tt.opts...)
+
+			_, err := a.Chat(context.Background(), "hi")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if mock.CallCount() != 0 {
+				t.Errorf("model called %d times", mock.CallCount())
+			}
+		})
+	}
+}
+
+func TestWithReasoningEffort_RejectsUnknownLevel(t *testing.T) {
+	mock := &reasoningLLM{model: gpt5, mockLLM: newMockLLM()}
+	a := agent.New(mock, agent.WithReasoningEffort("extreme"))
+
+	if _, err := a.Chat(context.Background(), "hi"); err == nil {
+		t.Fatal("expected an error for an unknown effort")
+	}
+}
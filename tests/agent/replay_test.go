@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func sessionTexts(t *testing.T, store session.Store, id string) []string {
+	t.Helper()
+	ctx := context.Background()
+	sess, err := store.Load(ctx, id)
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	msgs, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, string(msg.Role)+":"+msg.Content().Text)
+	}
+	return texts
+}
+
+func lastUserText(msgs []message.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == message.User {
+			return msgs[i].Content().Text
+		}
+	}
+	return ""
+}
+
+func TestRegenerate_ReplacesLastTurn(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	mock := newMockLLM(
+		mockResponse{Content: "first"},
+		mockResponse{Content: "second"},
+		mockResponse{Content: "retry"},
+	)
+	a := agent.New(mock, agent.WithSession("regen", store))
+
+	for _, msg := range []string{"one", "two"} {
+		if _, err := a.Chat(ctx, msg); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+
+	resp, err := a.Regenerate(ctx)
+	if err != nil {
+		t.Fatalf("Regenerate failed: %v", err)
+	}
+	if resp.Content != "retry" {
+		t.Errorf("expected regenerated response, got %q", resp.Content)
+	}
+	if got := lastUserText(mock.calls[2]); got != "two" {
+		t.Errorf("expected last user turn resubmitted, got %q", got)
+	}
+
+	want := []string{
+		"user:one", "assistant:first", "user:two", "assistant:retry",
+	}
+	got := sessionTexts(t, store, "regen")
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected session %v, got %v", want, got)
+	}
+}
+
+func TestEditAndResubmit_TruncatesAtMessage(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	mock := newMockLLM(
+		mockResponse{Content: "first"},
+		mockResponse{Content: "second"},
+		mockResponse{Content: "edited answer"},
+	)
+	a := agent.New(mock, agent.WithSession("edit", store))
+
+	for _, msg := range []string{"one", "two"} {
+		if _, err := a.Chat(ctx, msg); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+
+	var content strings.Builder
+	for evt := range a.EditAndResubmitStream(ctx, 0, "uno") {
+		if evt.Type == types.EventError {
+			t.Fatalf("stream error: %v", evt.Error)
+		}
+		content.WriteString(evt.Content)
+	}
+	if content.String() != "edited answer" {
+		t.Errorf("expected edited answer, got %q", content.String())
+	}
+	if sent := mock.calls[2]; len(sent) != 1 {
+		t.Errorf("expected history truncated before edit, sent %d messages",
+			len(sent))
+	}
+
+	want := []string{"user:uno", "assistant:edited answer"}
+	got := sessionTexts(t, store, "edit")
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected session %v, got %v", want, got)
+	}
+}
+
+func TestEditAndResubmit_RejectsNonUserMessage(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	a := agent.New(
+		newMockLLM(mockResponse{Content: "first"}),
+		agent.WithSession("reject", store),
+	)
+	if _, err := a.Chat(ctx, "one"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if _, err := a.EditAndResubmit(ctx, 1, "x"); err == nil {
+		t.Error("expected error editing an assistant message")
+	}
+	if _, err := a.EditAndResubmit(ctx, 5, "x"); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+	if got := sessionTexts(t, store, "reject"); len(got) != 2 {
+		t.Errorf("expected session untouched, got %v", got)
+	}
+}
+
+func TestRegenerate_RequiresSession(t *testing.T) {
+	a := agent.New(newMockLLM())
+	if _, err := a.Regenerate(context.Background()); err == nil {
+		t.Error("expected error without a session")
+	}
+
+	var gotErr error
+	for evt := range a.RegenerateStream(context.Background()) {
+		gotErr = evt.Error
+	}
+	if gotErr == nil {
+		t.Error("expected error event without a session")
+	}
+}
+
+func TestRegenerate_ResubmitsAttachments(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	sess, err := store.Create(ctx, "attach")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := message.NewUserMessage("what is this?")
+	user.AddImageURL("https://example.com/cat.png", "")
+	reply := message.NewAssistantMessage()
+	reply.AppendContent("a dog")
+	if err := sess.AddMessages(
+		ctx,
+		[]message.Message{user, reply},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockLLM(mockResponse{Content: "a cat"})
+	a := agent.New(mock, agent.WithSession("attach", store))
+	if _, err := a.Regenerate(ctx); err != nil {
+		t.Fatalf("Regenerate failed: %v", err)
+	}
+
+	sent := mock.calls[0]
+	if len(sent) != 1 || len(sent[0].ImageURLContent()) != 1 ||
+		sent[0].Content().Text != "what is this?" {
+		t.Errorf("resubmitted %+v, want text and image", sent)
+	}
+	msgs, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || len(msgs[0].ImageURLContent()) != 1 ||
+		msgs[1].Content().Text != "a cat" {
+		t.Errorf("session = %+v, want image turn and new reply", msgs)
+	}
+}
+
+func TestRegenerate_FailedRunKeepsSession(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	mock := newMockLLM(
+		mockResponse{Content: "first"},
+		mockResponse{Content: "second"},
+		mockResponse{Err: errors.New("provider down")},
+		mockResponse{Err: errors.New("provider down")},
+	)
+	a := agent.New(mock, agent.WithSession("fail", store))
+	for _, msg := range []string{"one", "two"} {
+		if _, err := a.Chat(ctx, msg); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+	want := sessionTexts(t, store, "fail")
+
+	if _, err := a.Regenerate(ctx); err == nil {
+		t.Fatal("expected the failed run to return an error")
+	}
+	if got := sessionTexts(t, store, "fail"); !slices.Equal(got, want) {
+		t.Errorf("session after failed Regenerate = %v, want %v", got, want)
+	}
+
+	for evt := range a.EditAndResubmitStream(ctx, 0, "uno") {
+		if evt.Type == types.EventComplete {
+			t.Error("expected the failed stream to end with an error")
+		}
+	}
+	if got := sessionTexts(t, store, "fail"); !slices.Equal(got, want) {
+		t.Errorf("session after failed edit = %v, want %v", got, want)
+	}
+}
+
+// slowSaveStore is a session store whose sessions take a while to replace
+// their history, so anything reading the session during the save sees the
+// old history.
+type slowSaveStore struct {
+	session.Store
+}
+
+func (s slowSaveStore) Create(
+	ctx context.Context,
+	id string,
+) (session.Session, error) {
+	sess, err := s.Store.Create(ctx, id)
+	return slowSaveSession{sess}, err
+}
+
+func (s slowSaveStore) Load(
+	ctx context.Context,
+	id string,
+) (session.Session, error) {
+	sess, err := s.Store.Load(ctx, id)
+	return slowSaveSession{sess}, err
+}
+
+type slowSaveSession struct {
+	session.Session
+}
+
+func (s slowSaveSession) SetMessages(
+	ctx context.Context,
+	msgs []message.Message,
+) error {
+	time.Sleep(50 * time.Millisecond)
+	return session.ReplaceMessages(ctx, s.Session, msgs)
+}
+
+func TestRegenerate_ExtractsMemoriesFromNewTurn(t *testing.T) {
+	ctx := context.Background()
+	memLLM := newMockLLM(
+		mockResponse{Content: extractedFacts},
+		mockResponse{Content: extractedFacts},
+	)
+	a := agent.New(
+		newMockLLM(
+			mockResponse{Content: "discarded reply"},
+			mockResponse{Content: "kept reply"},
+		),
+		agent.WithSession("extract", slowSaveStore{session.MemoryStore()}),
+		agent.WithMemory("user-1", newKnowledgeStore(),
+			memory.AutoExtract(),
+			memory.LLM(memLLM),
+		),
+	)
+
+	if _, err := a.Chat(ctx, "I like coffee"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.FlushMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.EditAndResubmit(ctx, 0, "I like tea"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.FlushMemories(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := memLLM.CallCount(); got != 2 {
+		t.Fatalf("memory LLM called %d times, want 2", got)
+	}
+	prompt := memLLM.calls[1][1].Content().Text
+	for _, want := range []string{"I like tea", "kept reply"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("extraction prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	for _, stale := range []string{"I like coffee", "discarded reply"} {
+		if strings.Contains(prompt, stale) {
+			t.Errorf("extraction prompt has discarded %q:\n%s", stale, prompt)
+		}
+	}
+}
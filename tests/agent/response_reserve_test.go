@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func reserveLLM(window, defaultMaxTokens int64) *fake.Client {
+	return fake.NewLLM(
+		fake.WithModel(model.Model{
+			ID:               "reserve",
+			Provider:         "fake",
+			ContextWindow:    window,
+			DefaultMaxTokens: defaultMaxTokens,
+		}),
+		fake.WithResponses(fake.Text("ok")),
+	)
+}
+
+func TestResponseReserve_Budget(t *testing.T) {
+	tests := []struct {
+		name             string
+		defaultMaxTokens int64
+		maxTokens        int64
+		limit            int64
+		opts             []agent.Option
+		want             int64
+	}{
+		{
+			name:             "explicit limit not lowered by reserve",
+			defaultMaxTokens: 2000,
+			limit:            9000,
+			opts:             []agent.Option{agent.WithResponseReserve(2000)},
+			want:             9000,
+		},
+		{
+			name:  "explicit limit capped at window",
+			limit: 20_000,
+			want:  10_000,
+		},
+		{
+			name: "with response reserve",
+			opts: []agent.Option{agent.WithResponseReserve(1000)},
+			want: 10_000 - 1000 - 1024,
+		},
+		{
+			name:      "client max tokens",
+			maxTokens: 500,
+			want:      10_000 - 500 - 1024,
+		},
+		{
+			name:             "model default ignored",
+			defaultMaxTokens: 1000,
+			want:             10_000 - 2500,
+		},
+		{
+			name:             "reserve capped at a quarter of the window",
+			defaultMaxTokens: 10_000,
+			maxTokens:        10_000,
+			want:             10_000 - 2500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := &budgetStrategy{}
+			opts := append([]agent.Option{
+				agent.WithContextStrategy(strategy, tt.limit),
+			}, tt.opts...)
+			client := llm.WithTracing(
+				reserveLLM(10_000, tt.defaultMaxTokens),
+				llm.TracingAttrs{MaxTokens: tt.maxTokens},
+			)
+			a := agent.New(client, opts...)
+
+			if _, err := a.Chat(context.Background(), "hi"); err != nil {
+				t.Fatal(err)
+			}
+			if strategy.maxTokens != tt.want {
+				t.Errorf("budget = %d, want %d", strategy.maxTokens, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseReserve_BehindKeyPool(t *testing.T) {
+	strategy := &budgetStrategy{}
+	client := llm.WithAPIKeys(func(string) llm.LLM {
+		return llm.WithTracing(
+			reserveLLM(10_000, 0),
+			llm.TracingAttrs{MaxTokens: 500},
+		)
+	}, []string{"key-a", "key-b"})
+	a := agent.New(client, agent.WithContextStrategy(strategy, 0))
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(10_000 - 500 - 1024); strategy.maxTokens != want {
+		t.Errorf("budget = %d, want %d", strategy.maxTokens, want)
+	}
+}
+
+func TestResponseReserve_OverflowsWithoutStrategy(t *testing.T) {
+	prompt := strings.Repeat("lorem ipsum dolor sit amet ", 1300)
+
+	client := reserveLLM(8000, 0)
+	a := agent.New(withCounter(t, client), agent.WithResponseReserve(50))
+	if _, err := a.Chat(context.Background(), prompt); err != nil {
+		t.Fatalf("prompt should fit with a small reserve: %v", err)
+	}
+
+	client = reserveLLM(8000, 0)
+	a = agent.New(withCounter(t, client), agent.WithResponseReserve(950))
+	_, err := a.Chat(context.Background(), prompt)
+	if !errors.Is(err, agent.ErrContextOverflow) {
+		t.Fatalf("err = %v, want ErrContextOverflow", err)
+	}
+	if client.CallCount() != 0 {
+		t.Errorf("calls = %d, want the call skipped", client.CallCount())
+	}
+}
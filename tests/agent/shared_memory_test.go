@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+type writableMemoryStore struct {
+	*knowledgeStore
+	stored map[string][]string
+}
+
+func (s *writableMemoryStore) Store(
+	_ context.Context,
+	id string,
+	fact string,
+	_ map[string]any,
+) error {
+	if s.stored == nil {
+		s.stored = make(map[string][]string)
+	}
+	s.stored[id] = append(s.stored[id], fact)
+	return nil
+}
+
+func TestWithSharedMemory_ReadOnlyExposesRecallOnly(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_1",
+			Name:  "shared_recall_memories",
+			Input: `{"query":"diet"}`,
+			Type:  "function",
+		}}},
+		mockResponse{Content: "done"},
+	)}
+	store := newMemoryToolsStore()
+	reader := agent.New(mock,
+		agent.WithSharedMemory(store, "curator", true),
+	)
+
+	_, err := reader.Chat(context.Background(), "what is known?")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"shared_recall_memories",
+		"shared_list_memories",
+		"shared_count_memories",
+		"shared_search_memories",
+	}
+	if got := mock.lastNames(); !slices.Equal(got, want) {
+		t.Errorf("tools = %v, want %v", got, want)
+	}
+	if len(store.searched) != 1 || store.searched[0] != "curator" {
+		t.Errorf("searched = %v, want the curator's memory", store.searched)
+	}
+	if result := toolResultOf(mock.calls); !strings.Contains(
+		result,
+		"Allergic to peanuts.",
+	) {
+		t.Errorf("expected shared memories, got %q", result)
+	}
+}
+
+func TestWithSharedMemory_WritableStoresIntoOwner(t *testing.T) {
+	mock := &toolNamesLLM{mockLLM: newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_1",
+			Name:  "shared_store_memory",
+			Input: `{"fact":"Release is on Friday."}`,
+			Type:  "function",
+		}}},
+		mockResponse{Content: "done"},
+	)}
+	store := &writableMemoryStore{knowledgeStore: newMemoryToolsStore()}
+	writer := agent.New(mock,
+		agent.WithMemory("writer", store),
+		agent.WithSharedMemory(store, "team", false),
+	)
+
+	if _, err := writer.Chat(context.Background(), "note it"); err != nil {
+		t.Fatal(err)
+	}
+
+	names := mock.lastNames()
+	if !slices.Contains(names, agent.MemoryToolStore) ||
+		!slices.Contains(names, "shared_store_memory") ||
+		!slices.Contains(names, "shared_delete_memory") {
+		t.Errorf("tools = %v", names)
+	}
+	if got := store.stored["team"]; len(got) != 1 ||
+		got[0] != "Release is on Friday." {
+		t.Errorf("team memory = %v", store.stored)
+	}
+	if len(store.stored["writer"]) != 0 {
+		t.Errorf("fact stored in the agent's own memory: %v", store.stored)
+	}
+}
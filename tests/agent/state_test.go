@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type rememberTool struct{}
+
+func (t *rememberTool) Info() tool.Info {
+	return tool.Info{Name: "remember", Parameters: map[string]any{}}
+}
+
+func (t *rememberTool) Run(
+	ctx context.Context,
+	_ tool.Call,
+) (tool.Response, error) {
+	if err := agent.SetStateValue(ctx, "theme", "dark"); err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+	return tool.NewTextResponse("saved"), nil
+}
+
+func TestPersistentState_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := session.FileStore(t.TempDir())
+	hooks := agent.Hooks{
+		PostToolUse: func(
+			ctx context.Context,
+			_ agent.PostToolUseContext,
+		) (agent.PostToolUseResult, error) {
+			count, _ := agent.StateValue(ctx, "saves")
+			n, _ := count.(float64)
+			err := agent.SetStateValue(ctx, "saves", n+1)
+			return agent.PostToolUseResult{Action: agent.HookAllow}, err
+		},
+	}
+	mock := newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{
+			{ID: "tc-1", Name: "remember", Input: `{}`, Type: "function"},
+		}},
+		mockResponse{Content: "done"},
+	)
+	first := agent.New(mock,
+		agent.WithSystemPrompt("Theme: {{.theme}}"),
+		agent.WithState(map[string]any{"theme": "light", "lang": "en"}),
+		agent.WithSession("prefs", store),
+		agent.WithPersistentState(),
+		agent.WithTools(&rememberTool{}),
+		agent.WithHooks(hooks),
+	)
+	if _, err := first.Chat(ctx, "remember dark mode"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	second := newMockLLM(mockResponse{Content: "hi"})
+	restarted := agent.New(second,
+		agent.WithSystemPrompt("Theme: {{.theme}}"),
+		agent.WithState(map[string]any{"theme": "light", "lang": "sv"}),
+		agent.WithSession("prefs", store),
+		agent.WithPersistentState(),
+	)
+	state := restarted.State()
+	if state["theme"] != "dark" || state["saves"] != float64(1) {
+		t.Errorf("expected tool and hook updates restored, got %v", state)
+	}
+	if state["lang"] != "en" {
+		t.Errorf("expected saved value to override default, got %v", state)
+	}
+
+	if _, err := restarted.Chat(ctx, "hello"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got := second.calls[0][0].Content().Text; got != "Theme: dark" {
+		t.Errorf("expected restored state in system prompt, got %q", got)
+	}
+	for _, text := range sessionTexts(t, store, "prefs") {
+		if strings.HasPrefix(text, "system:") {
+			t.Errorf("expected state kept out of session history, got %q", text)
+		}
+	}
+}
+
+func TestState_NotPersistedByDefault(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	a := agent.New(newMockLLM(),
+		agent.WithSession("plain", store),
+	)
+	if err := a.SetState(ctx, "counter", 1); err != nil {
+		t.Fatalf("SetState failed: %v", err)
+	}
+
+	restarted := agent.New(newMockLLM(),
+		agent.WithSession("plain", store),
+		agent.WithPersistentState(),
+	)
+	if _, ok := restarted.StateValue("counter"); ok {
+		t.Error("expected state without WithPersistentState to stay in memory")
+	}
+	if err := agent.SetStateValue(ctx, "counter", 1); err == nil {
+		t.Error("expected SetStateValue outside a run to fail")
+	}
+}
+
+type counterTool struct{}
+
+func (t *counterTool) Info() tool.Info {
+	return tool.Info{Name: "count", Parameters: map[string]any{}}
+}
+
+func (t *counterTool) Run(
+	ctx context.Context,
+	_ tool.Call,
+) (tool.Response, error) {
+	state, ok := agent.StateFromContext(ctx)
+	if !ok {
+		return tool.NewTextErrorResponse("no state"), nil
+	}
+	err := state.Update(ctx, func(s map[string]any) {
+		n, _ := s["count"].(int)
+		s["count"] = n + 1
+	})
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+	return tool.NewTextResponse("counted"), nil
+}
+
+func TestStateFromContext_VisibleNextTurn(t *testing.T) {
+	var calls []message.ToolCall
+	for _, id := range []string{"a", "b", "c", "d"} {
+		calls = append(calls, message.ToolCall{
+			ID: id, Name: "count", Input: `{}`, Type: "function",
+		})
+	}
+	mock := newMockLLM(
+		mockResponse{ToolCalls: calls},
+		mockResponse{Content: "done"},
+		mockResponse{Content: "again"},
+	)
+	var seen []any
+	a := agent.New(mock,
+		agent.WithTools(&counterTool{}),
+		agent.WithInstructionProvider(func(
+			_ context.Context,
+			state map[string]any,
+		) (string, error) {
+			seen = append(seen, state["count"])
+			return "count tracker", nil
+		}),
+	)
+
+	for _, msg := range []string{"count four times", "how many?"} {
+		if _, err := a.Chat(context.Background(), msg); err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+	}
+
+	if len(seen) != 2 || seen[0] != nil || seen[1] != 4 {
+		t.Errorf("expected provider to see 4 on the next turn, got %v", seen)
+	}
+	if _, ok := agent.StateFromContext(context.Background()); ok {
+		t.Error("expected no state handle outside a run")
+	}
+}
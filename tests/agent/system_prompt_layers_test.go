@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+func sentSystemPrompt(t *testing.T, a *agent.Agent, m *mockLLM) string {
+	t.Helper()
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	msgs := m.calls[len(m.calls)-1]
+	if len(msgs) == 0 || msgs[0].Role != message.System {
+		t.Fatalf("no system message sent: %v", msgs)
+	}
+	return msgs[0].Content().Text
+}
+
+func TestSystemPromptLayers_AppendedInOrder(t *testing.T) {
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPrompt("You are {{.persona}}."),
+		agent.WithSystemPromptLayer("Never share internal pricing."),
+		agent.WithSystemPromptLayer("{{if .tone}}Tone: {{.tone}}.{{end}}"),
+		agent.WithSystemPromptLayer("Answer in {{.language}}."),
+		agent.WithState(map[string]any{
+			"persona":  "a support assistant",
+			"language": "Swedish",
+		}),
+	)
+
+	want := "You are a support assistant.\n\n" +
+		"Never share internal pricing.\n\n" +
+		"Answer in Swedish."
+	if got := sentSystemPrompt(t, a, m); got != want {
+		t.Errorf("system prompt = %q, want %q", got, want)
+	}
+}
+
+func TestSystemPromptLayers_FollowInstructionProvider(t *testing.T) {
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPrompt("ignored"),
+		agent.WithInstructionProvider(
+			func(context.Context, map[string]any) (string, error) {
+				return "Dynamic base.", nil
+			},
+		),
+		agent.WithSystemPromptLayer("Policy."),
+		agent.WithSystemPromptSeparator("\n---\n"),
+	)
+
+	want := "Dynamic base.\n---\nPolicy."
+	if got := sentSystemPrompt(t, a, m); got != want {
+		t.Errorf("system prompt = %q, want %q", got, want)
+	}
+}
+
+func TestSystemPromptLayers_WithoutBase(t *testing.T) {
+	m := newMockLLM(mockResponse{Content: "ok"})
+	a := agent.New(m,
+		agent.WithSystemPromptLayer("First."),
+		agent.WithSystemPromptLayer("Second."),
+	)
+
+	if got := sentSystemPrompt(t, a, m); got != "First.\n\nSecond." {
+		t.Errorf("system prompt = %q", got)
+	}
+}
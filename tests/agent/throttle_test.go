@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func feedEvents(events ...agent.ChatEvent) <-chan agent.ChatEvent {
+	ch := make(chan agent.ChatEvent, len(events))
+	for _, evt := range events {
+		ch <- evt
+	}
+	close(ch)
+	return ch
+}
+
+func delta(text string) agent.ChatEvent {
+	return agent.ChatEvent{Type: types.EventContentDelta, Content: text}
+}
+
+func TestThrottleStream_PacesContent(t *testing.T) {
+	in := feedEvents(delta("hello "), delta("world"))
+
+	start := time.Now()
+	var text strings.Builder
+	deltas := 0
+	for evt := range agent.ThrottleStream(context.Background(), in, 100) {
+		text.WriteString(evt.Content)
+		deltas++
+	}
+	elapsed := time.Since(start)
+
+	if text.String() != "hello world" {
+		t.Errorf("expected text preserved, got %q", text.String())
+	}
+	if deltas != len("hello world") {
+		t.Errorf("expected one delta per character, got %d", deltas)
+	}
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("expected ~100ms of pacing, took %v", elapsed)
+	}
+}
+
+func TestThrottleStream_FlushesBeforeOtherEvents(t *testing.T) {
+	in := feedEvents(
+		delta("a long burst of text"),
+		agent.ChatEvent{Type: types.EventToolUseStart},
+		delta("after"),
+		agent.ChatEvent{Type: types.EventComplete},
+	)
+
+	var got []agent.ChatEvent
+	for evt := range agent.ThrottleStream(context.Background(), in, 1) {
+		last := len(got) - 1
+		if last >= 0 && evt.Type == types.EventContentDelta &&
+			got[last].Type == types.EventContentDelta {
+			got[last].Content += evt.Content
+			continue
+		}
+		got = append(got, evt)
+	}
+
+	want := []agent.ChatEvent{
+		delta("a long burst of text"),
+		{Type: types.EventToolUseStart},
+		delta("after"),
+		{Type: types.EventComplete},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].Content != want[i].Content {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestThrottleStream_CancelClosesAndDrains(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan agent.ChatEvent)
+	out := agent.ThrottleStream(ctx, in, 1)
+
+	in <- delta("slow text")
+	<-out
+	cancel()
+
+	sent := make(chan struct{})
+	go func() {
+		in <- delta("more")
+		close(in)
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("producer blocked after cancel")
+	}
+	for evt := range out {
+		_ = evt
+	}
+}
+
+func TestThrottleStream_DisabledPassesThrough(t *testing.T) {
+	in := feedEvents(delta("x"))
+	if out := agent.ThrottleStream(context.Background(), in, 0); out != in {
+		t.Error("expected input channel returned unchanged")
+	}
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+func TestWithToolCallIDGenerator_RewritesIDs(t *testing.T) {
+	mockLLM := newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{
+					ID:    "toolu_01RandomA",
+					Name:  "echo",
+					Input: `{"text":"a"}`,
+					Type:  "function",
+				},
+				{
+					ID:    "toolu_01RandomB",
+					Name:  "echo",
+					Input: `{"text":"b"}`,
+					Type:  "function",
+				},
+			},
+		},
+		mockResponse{Content: "done"},
+	)
+	a := agent.New(mockLLM,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolCallIDGenerator(llm.SequentialIDs("call_")),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var callIDs, resultIDs []string
+	for _, msg := range mockLLM.calls[1] {
+		for _, tc := range msg.ToolCalls() {
+			callIDs = append(callIDs, tc.ID)
+		}
+		for _, tr := range msg.ToolResults() {
+			resultIDs = append(resultIDs, tr.ToolCallID)
+		}
+	}
+
+	want := []string{"call_1", "call_2"}
+	if len(callIDs) != 2 || callIDs[0] != want[0] || callIDs[1] != want[1] {
+		t.Errorf("expected tool call IDs %v, got %v", want, callIDs)
+	}
+	if len(resultIDs) != 2 {
+		t.Fatalf("expected 2 tool results, got %v", resultIDs)
+	}
+	for _, id := range resultIDs {
+		if id != want[0] && id != want[1] {
+			t.Errorf("tool result carries unmapped ID %q", id)
+		}
+	}
+}
+
+func TestWithToolCallIDs_StreamMatchesFinalResponse(t *testing.T) {
+	mockLLM := newMockLLM(mockResponse{
+		ToolCalls: []message.ToolCall{
+			{ID: "provider-id", Name: "echo", Input: `{}`, Type: "function"},
+		},
+	})
+	client := llm.WithToolCallIDs(mockLLM, llm.SequentialIDs("id-"))
+
+	var final *llm.Response
+	for evt := range client.StreamResponse(context.Background(), nil, nil) {
+		if evt.Type == types.EventComplete {
+			final = evt.Response
+		}
+	}
+	if final == nil || len(final.ToolCalls) != 1 {
+		t.Fatalf("expected final response with one tool call, got %+v", final)
+	}
+	if final.ToolCalls[0].ID != "id-1" {
+		t.Errorf("expected id-1, got %q", final.ToolCalls[0].ID)
+	}
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type toolChoiceLLM struct {
+	*mockLLM
+	mu      sync.Mutex
+	choices []*llm.ToolChoice
+}
+
+func (m *toolChoiceLLM) record(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if choice, ok := llm.ToolChoiceFromContext(ctx); ok {
+		m.choices = append(m.choices, &choice)
+	} else {
+		m.choices = append(m.choices, nil)
+	}
+}
+
+func (m *toolChoiceLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	m.record(ctx)
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func (m *toolChoiceLLM) StreamResponse(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) <-chan llm.Event {
+	m.record(ctx)
+	return m.mockLLM.StreamResponse(ctx, msgs, tools)
+}
+
+func forcedEcho() llm.ToolChoice {
+	return llm.ToolChoice{Mode: llm.ToolChoiceSpecific, Name: "echo"}
+}
+
+func echoThenAnswer() []mockResponse {
+	return []mockResponse{
+		{
+			ToolCalls: []message.ToolCall{{
+				ID:       "call_1",
+				Name:     "echo",
+				Input:    `{"text":"hi"}`,
+				Type:     "function",
+				Finished: true,
+			}},
+			FinishReason: message.FinishReasonToolUse,
+		},
+		{Content: "done", FinishReason: message.FinishReasonEndTurn},
+	}
+}
+
+func TestWithToolChoice_AppliesToFirstModelCallOnly(t *testing.T) {
+	mock := &toolChoiceLLM{mockLLM: newMockLLM(echoThenAnswer()...)}
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolChoice(forcedEcho()),
+	)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if len(mock.choices) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(mock.choices))
+	}
+	if mock.choices[0] == nil || *mock.choices[0] != forcedEcho() {
+		t.Errorf("expected forced echo on first call, got %+v",
+			mock.choices[0])
+	}
+	if mock.choices[1] != nil {
+		t.Errorf("expected no choice on follow-up call, got %+v",
+			mock.choices[1])
+	}
+}
+
+func TestWithCallToolChoice_OverridesAgentSetting(t *testing.T) {
+	mock := &toolChoiceLLM{mockLLM: newMockLLM(mockResponse{Content: "ok"})}
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolChoice(forcedEcho()),
+	)
+
+	none := llm.ToolChoice{Mode: llm.ToolChoiceNone}
+	for range a.ChatStream(
+		context.Background(),
+		"hi",
+		agent.WithCallToolChoice(none),
+	) {
+	}
+
+	if len(mock.choices) != 1 || mock.choices[0] == nil ||
+		*mock.choices[0] != none {
+		t.Errorf("expected per-call none choice, got %+v", mock.choices)
+	}
+}
+
+func TestWithToolChoice_UnsetLeavesContextClean(t *testing.T) {
+	mock := &toolChoiceLLM{mockLLM: newMockLLM(mockResponse{Content: "ok"})}
+	a := agent.New(mock, agent.WithTools(&echoTool{}))
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if mock.choices[0] != nil {
+		t.Errorf("expected no tool choice, got %+v", mock.choices[0])
+	}
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type sourceTool struct {
+	name   string
+	source string
+	calls  []string
+}
+
+func (s *sourceTool) Info() tool.Info {
+	return tool.NewInfo(s.name, "search "+s.source, struct{}{})
+}
+
+func (s *sourceTool) Run(
+	_ context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	s.calls = append(s.calls, params.Name)
+	return tool.NewTextResponse(s.source), nil
+}
+
+func TestToolsets_CollidingNamesAreNamespaced(t *testing.T) {
+	local := &sourceTool{name: "search", source: "local"}
+	github := &sourceTool{name: "search", source: "github"}
+	docs := &sourceTool{name: "search", source: "docs"}
+	client := fake.NewLLM(fake.WithResponses(
+		fake.ToolCalls(fake.Call("github_search", "{}")),
+		fake.Text("done"),
+	))
+	a := agent.New(client,
+		agent.WithTools(local),
+		agent.WithToolsets(
+			tool.NewToolset("github", github),
+			tool.NamespacedToolset("context7", tool.NewToolset("mcp", docs)),
+		),
+	)
+
+	resp, err := a.Chat(context.Background(), "find it")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	offered := client.Calls()[0].Tools
+	want := []string{"search", "github_search", "context7_search"}
+	for _, name := range want {
+		if !slices.Contains(offered, name) {
+			t.Errorf("tool %q not offered, got %v", name, offered)
+		}
+	}
+	if len(github.calls) != 1 || github.calls[0] != "search" {
+		t.Errorf("github tool calls = %v, want [search]", github.calls)
+	}
+	if len(local.calls) != 0 || len(docs.calls) != 0 {
+		t.Errorf("wrong tool ran: local %v, docs %v", local.calls, docs.calls)
+	}
+	if len(resp.ToolResults) != 1 ||
+		resp.ToolResults[0].ToolName != "github_search" ||
+		resp.ToolResults[0].Output != "github" {
+		t.Errorf("tool results = %+v", resp.ToolResults)
+	}
+}
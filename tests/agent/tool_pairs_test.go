@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestChat_DropsToolResultsOrphanedByTrimming(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	sess, err := store.Create(ctx, "trimmed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := message.NewAssistantMessage()
+	call.AppendToolCalls([]message.ToolCall{{
+		ID:    "call_1",
+		Name:  "echo",
+		Input: `{"text":"hi"}`,
+		Type:  "function",
+	}})
+	result := message.NewMessage(message.Tool, []message.ContentPart{
+		message.ToolResult{ToolCallID: "call_1", Name: "echo", Content: "hi"},
+	})
+	answer := message.NewAssistantMessage()
+	answer.AppendContent("It said hi.")
+	if err := sess.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("echo hi"),
+		call,
+		result,
+		answer,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockLLM(mockResponse{Content: "you're welcome"})
+	a := agent.New(mock,
+		agent.WithSession("trimmed", store),
+		agent.WithContextStrategy(&keepLastStrategy{keep: 3}, 100000),
+	)
+
+	if _, err := a.Chat(ctx, "thanks"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := mock.calls[0]
+	for _, msg := range sent {
+		if len(msg.ToolResults()) > 0 {
+			t.Fatalf("orphaned tool result sent: %+v", sent)
+		}
+	}
+	if len(sent) != 2 || sent[0].Content().Text != "It said hi." {
+		t.Errorf("sent %d messages, want the answer and new turn", len(sent))
+	}
+}
+
+func TestChat_KeepsAnsweredToolResults(t *testing.T) {
+	mock := newMockLLM(
+		mockResponse{ToolCalls: []message.ToolCall{{
+			ID:    "call_1",
+			Name:  "echo",
+			Input: `{"text":"hi"}`,
+			Type:  "function",
+		}}},
+		mockResponse{Content: "done"},
+	)
+	a := agent.New(mock, agent.WithTools(&echoTool{}))
+
+	if _, err := a.Chat(context.Background(), "echo hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := toolResultOf(mock.calls); result == "" {
+		t.Error("expected the tool result in the follow-up request")
+	}
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+)
+
+func echoCall(text string) mockResponse {
+	return mockResponse{
+		ToolCalls: []message.ToolCall{{
+			ID:       "call_1",
+			Name:     "echo",
+			Input:    text,
+			Type:     "function",
+			Finished: true,
+		}},
+		FinishReason: message.FinishReasonToolUse,
+	}
+}
+
+func TestToolResultLimit_Truncates(t *testing.T) {
+	big := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	mock := newMockLLM(echoCall(big), mockResponse{Content: "done"})
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolResultLimit(50),
+	)
+
+	resp, err := a.Chat(context.Background(), "echo it")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := sentToolResult(t, mock).Content
+	if !strings.Contains(sent, "\n[truncated ") {
+		t.Fatalf("tool result not truncated: %q", sent)
+	}
+	if !strings.HasPrefix(sent, "echo: lorem ipsum") {
+		t.Errorf("truncated result lost its start: %q", sent[:40])
+	}
+	if len(sent) > 400 {
+		t.Errorf("truncated result is %d bytes", len(sent))
+	}
+	if resp.ToolResults[0].Output != sent {
+		t.Error("ChatResponse.ToolResults does not match what was sent")
+	}
+}
+
+func TestToolResultLimit_SmallResultUnchanged(t *testing.T) {
+	mock := newMockLLM(echoCall("hi"), mockResponse{Content: "done"})
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolResultLimit(50),
+	)
+
+	if _, err := a.Chat(context.Background(), "echo it"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := sentToolResult(t, mock).Content; sent != "echo: hi" {
+		t.Errorf("got %q", sent)
+	}
+}
+
+func TestToolResultLimit_Summarizes(t *testing.T) {
+	big := strings.Repeat("row 42: status ok\n", 300)
+	mock := newMockLLM(echoCall(big), mockResponse{Content: "done"})
+	summarizer := newMockLLM(mockResponse{Content: "300 rows, all ok"})
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolResultLimit(50, agent.ToolResultSummarizer(summarizer)),
+	)
+
+	if _, err := a.Chat(context.Background(), "echo it"); err != nil {
+		t.Fatal(err)
+	}
+	sent := sentToolResult(t, mock).Content
+	if !strings.HasPrefix(sent, "[summarized from ") ||
+		!strings.HasSuffix(sent, "300 rows, all ok") {
+		t.Errorf("got %q", sent)
+	}
+	if summarizer.CallCount() != 1 {
+		t.Errorf("summarizer called %d times", summarizer.CallCount())
+	}
+}
+
+func TestToolResultLimit_SummarizerFailureTruncates(t *testing.T) {
+	big := strings.Repeat("row 42: status ok\n", 300)
+	mock := newMockLLM(echoCall(big), mockResponse{Content: "done"})
+	summarizer := newMockLLM(mockResponse{Err: errors.New("overloaded")})
+	a := agent.New(mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithToolResultLimit(50, agent.ToolResultSummarizer(summarizer)),
+	)
+
+	if _, err := a.Chat(context.Background(), "echo it"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := sentToolResult(t, mock).Content; !strings.Contains(
+		sent,
+		"\n[truncated ",
+	) {
+		t.Errorf("got %q", sent)
+	}
+}
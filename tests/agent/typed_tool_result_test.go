@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type typedTool struct {
+	name string
+	resp tool.Response
+}
+
+func (t *typedTool) Info() tool.Info {
+	return tool.NewInfo(t.name, "Returns a typed response", struct{}{})
+}
+
+func (t *typedTool) Run(context.Context, tool.Call) (tool.Response, error) {
+	return t.resp, nil
+}
+
+func callTool(name string) mockResponse {
+	return mockResponse{
+		ToolCalls: []message.ToolCall{{
+			ID:       "call_1",
+			Name:     name,
+			Input:    `{}`,
+			Type:     "function",
+			Finished: true,
+		}},
+		FinishReason: message.FinishReasonToolUse,
+	}
+}
+
+func sentToolResult(t *testing.T, mock *mockLLM) message.ToolResult {
+	t.Helper()
+	sent := mock.calls[1]
+	results := sent[len(sent)-1].ToolResults()
+	if len(results) != 1 {
+		t.Fatalf("expected one tool result, got %d", len(results))
+	}
+	return results[0]
+}
+
+func TestTypedToolResult_ImageForwarded(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	mock := newMockLLM(callTool("screenshot"), mockResponse{Content: "done"})
+	a := agent.New(mock, agent.WithTools(&typedTool{
+		name: "screenshot",
+		resp: tool.NewFileResponse(png, "image/png"),
+	}))
+
+	if _, err := a.Chat(context.Background(), "take a screenshot"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	result := sentToolResult(t, mock)
+	if len(result.Images) != 1 || result.Images[0].MIMEType != "image/png" ||
+		string(result.Images[0].Data) != string(png) {
+		t.Errorf("expected image forwarded to model, got %+v", result)
+	}
+}
+
+func TestTypedToolResult_MultiPartForwarded(t *testing.T) {
+	mock := newMockLLM(callTool("chart"), mockResponse{Content: "done"})
+	a := agent.New(mock, agent.WithTools(&typedTool{
+		name: "chart",
+		resp: tool.NewMultiPartResponse(
+			tool.TextPart("Revenue by quarter"),
+			tool.ImagePart([]byte("chart"), "image/png"),
+			tool.ImagePart([]byte("legend"), "image/jpeg"),
+		),
+	}))
+
+	if _, err := a.Chat(context.Background(), "plot revenue"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	result := sentToolResult(t, mock)
+	if result.Content != "Revenue by quarter" {
+		t.Errorf("expected text part as content, got %q", result.Content)
+	}
+	if len(result.Images) != 2 || result.Images[1].MIMEType != "image/jpeg" {
+		t.Errorf("expected both images forwarded, got %+v", result.Images)
+	}
+}
+
+func TestTypedToolResult_JSONMarked(t *testing.T) {
+	mock := newMockLLM(callTool("lookup"), mockResponse{Content: "done"})
+	a := agent.New(mock, agent.WithTools(&typedTool{
+		name: "lookup",
+		resp: tool.NewJSONResponse(map[string]int{"count": 3}),
+	}))
+
+	if _, err := a.Chat(context.Background(), "look it up"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	result := sentToolResult(t, mock)
+	if result.MIMEType != "application/json" ||
+		result.Content != `{"count":3}` {
+		t.Errorf("expected JSON content type, got %+v", result)
+	}
+	if len(result.Images) != 0 {
+		t.Errorf("expected no images, got %+v", result.Images)
+	}
+}
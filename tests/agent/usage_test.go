@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestUsageTracking_AccumulatesAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	store := session.FileStore(t.TempDir())
+	mock := newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{ID: "tc-1", Name: "remember", Input: `{}`, Type: "function"},
+			},
+			Usage: llm.TokenUsage{InputTokens: 100, OutputTokens: 10},
+		},
+		mockResponse{
+			Content: "done",
+			Usage:   llm.TokenUsage{InputTokens: 120, OutputTokens: 5},
+		},
+		mockResponse{
+			Content: "again",
+			Usage:   llm.TokenUsage{InputTokens: 50, OutputTokens: 7},
+		},
+	)
+	first := agent.New(mock,
+		agent.WithSession("billing", store),
+		agent.WithUsageTracking(),
+		agent.WithTools(&rememberTool{}),
+	)
+	if _, err := first.Chat(ctx, "remember"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	second := agent.New(mock,
+		agent.WithSession("billing", store),
+		agent.WithUsageTracking(),
+	)
+	for event := range second.ChatStream(ctx, "hello") {
+		if event.Error != nil {
+			t.Fatalf("ChatStream failed: %v", event.Error)
+		}
+	}
+
+	usage, err := second.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if usage.InputTokens != 270 || usage.OutputTokens != 22 ||
+		usage.Calls != 3 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	byModel, err := session.LoadUsageByModel(ctx, store, "billing")
+	if err != nil {
+		t.Fatalf("LoadUsageByModel failed: %v", err)
+	}
+	if byModel["mock-model"].Calls != 3 {
+		t.Errorf("unexpected per-model usage: %+v", byModel)
+	}
+
+	if err := second.ResetUsage(ctx); err != nil {
+		t.Fatalf("ResetUsage failed: %v", err)
+	}
+	usage, err = second.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage after reset failed: %v", err)
+	}
+	if usage != (session.Usage{}) {
+		t.Errorf("usage not reset: %+v", usage)
+	}
+
+	sess, err := store.Load(ctx, "billing")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	history, err := sess.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if len(history) == 0 {
+		t.Error("reset removed the conversation history")
+	}
+}
+
+func TestUsageTracking_DisabledByDefault(t *testing.T) {
+	ctx := context.Background()
+	store := session.MemoryStore()
+	mock := newMockLLM(mockResponse{
+		Content: "hi",
+		Usage:   llm.TokenUsage{InputTokens: 10, OutputTokens: 2},
+	})
+	a := agent.New(mock, agent.WithSession("plain", store))
+	if _, err := a.Chat(ctx, "hello"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	exists, err := store.Exists(ctx, "plain"+session.UsageSuffix)
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if exists {
+		t.Error("usage recorded without WithUsageTracking")
+	}
+}
+
+func TestUsage_RequiresSession(t *testing.T) {
+	a := agent.New(newMockLLM())
+	if _, err := a.Usage(context.Background()); err == nil {
+		t.Error("expected an error without a session")
+	}
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/llm/fake"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type wordCounter struct{}
+
+func (wordCounter) Count(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestChatStream_UsageUpdatesRunAcrossModelCalls(t *testing.T) {
+	client := fake.NewLLM(fake.WithResponses(
+		fake.ToolCalls(fake.Call("echo", "hi")).
+			WithUsage(llm.TokenUsage{InputTokens: 100, OutputTokens: 10}),
+		fake.Text("one two three").
+			WithDeltas("one two ", "three").
+			WithUsage(llm.TokenUsage{InputTokens: 120, OutputTokens: 3}),
+	))
+	a := agent.New(
+		llm.WithUsageUpdates(client, wordCounter{}),
+		agent.WithTools(&echoTool{}),
+	)
+
+	var updates []llm.TokenUsage
+	var final *agent.ChatResponse
+	for evt := range a.ChatStream(context.Background(), "go") {
+		switch evt.Type {
+		case types.EventUsageUpdate:
+			updates = append(updates, *evt.Usage)
+		case types.EventComplete:
+			final = evt.Response
+		case types.EventError:
+			t.Fatalf("stream error: %v", evt.Error)
+		}
+	}
+
+	if final == nil {
+		t.Fatal("no complete event")
+	}
+	if len(updates) == 0 {
+		t.Fatal("no usage updates")
+	}
+	last := updates[len(updates)-1]
+	if last.InputTokens != 100 || last.OutputTokens != 13 {
+		t.Errorf("last update = %+v, want 100 in and 13 out", last)
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].OutputTokens < updates[i-1].OutputTokens {
+			t.Errorf("usage went backwards: %+v", updates)
+			break
+		}
+	}
+	if final.Usage.InputTokens != 220 || final.Usage.OutputTokens != 13 {
+		t.Errorf("final usage = %+v", final.Usage)
+	}
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestWithUserID_KeysMemoryPerRequest(t *testing.T) {
+	store := newMemoryToolsStore()
+	a := agent.New(newMockLLM(
+		mockResponse{Content: "hi alice"},
+		mockResponse{Content: "hi bob"},
+		mockResponse{Content: "hi stranger"},
+	), agent.WithMemory("", store))
+
+	ctx := context.Background()
+	for _, ctx := range []context.Context{
+		agent.WithUserID(ctx, "alice"),
+		agent.WithUserID(ctx, "bob"),
+		ctx,
+	} {
+		if _, err := a.Chat(ctx, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := []string{"alice", "bob"}; !slices.Equal(store.searched, want) {
+		t.Errorf("searched = %v, want %v", store.searched, want)
+	}
+}
+
+func TestWithUserID_MemoryIDTakesPrecedence(t *testing.T) {
+	store := newMemoryToolsStore()
+	a := agent.New(newMockLLM(mockResponse{Content: "ok"}),
+		agent.WithMemory("owner", store),
+	)
+
+	ctx := agent.WithUserID(context.Background(), "hashed-user")
+	if _, err := a.Chat(ctx, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(store.searched, []string{"owner"}) {
+		t.Errorf("searched = %v, want [owner]", store.searched)
+	}
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	ctx := agent.WithUserID(context.Background(), "alice")
+	if id, ok := agent.UserIDFromContext(ctx); !ok || id != "alice" {
+		t.Errorf("UserIDFromContext = %q, %v", id, ok)
+	}
+	if _, ok := agent.UserIDFromContext(context.Background()); ok {
+		t.Error("expected no user ID on a bare context")
+	}
+}
+
+func TestWithCallUserID_ScopesMemory(t *testing.T) {
+	store := newMemoryToolsStore()
+	a := agent.New(newMockLLM(
+		mockResponse{Content: "hi alice"},
+		mockResponse{Content: "hi bob"},
+	), agent.WithMemory("", store))
+
+	ctx := context.Background()
+	_, err := a.Chat(ctx, "hello", agent.WithCallUserID("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for event := range a.ChatStream(
+		ctx,
+		"hello",
+		agent.WithCallUserID("bob"),
+	) {
+		if event.Error != nil {
+			t.Fatal(event.Error)
+		}
+	}
+
+	if want := []string{"alice", "bob"}; !slices.Equal(store.searched, want) {
+		t.Errorf("searched = %v, want %v", store.searched, want)
+	}
+}
+
+func TestWithUserID_RejectsSharedSession(t *testing.T) {
+	store := newMemoryToolsStore()
+	a := agent.New(newMockLLM(mockResponse{Content: "hi"}),
+		agent.WithSession("shared", session.MemoryStore()),
+		agent.WithMemory("", store, memory.AutoExtract()),
+	)
+
+	ctx := agent.WithUserID(context.Background(), "alice")
+	_, err := a.Chat(ctx, "hello")
+	if !errors.Is(err, agent.ErrSharedSessionMemory) {
+		t.Fatalf("err = %v, want ErrSharedSessionMemory", err)
+	}
+	if len(store.searched) != 0 {
+		t.Errorf("searched = %v, want no memory access", store.searched)
+	}
+}
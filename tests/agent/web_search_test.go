@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/agent"
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/types"
+)
+
+type webSearchLLM struct {
+	*mockLLM
+	searches []llm.WebSearch
+	missing  int
+}
+
+func (m *webSearchLLM) record(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if search, ok := llm.WebSearchFromContext(ctx); ok {
+		m.searches = append(m.searches, search)
+	} else {
+		m.missing++
+	}
+}
+
+func (m *webSearchLLM) SendMessages(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) (*llm.Response, error) {
+	m.record(ctx)
+	return m.mockLLM.SendMessages(ctx, msgs, tools)
+}
+
+func (m *webSearchLLM) StreamResponse(
+	ctx context.Context,
+	msgs []message.Message,
+	tools []tool.BaseTool,
+) <-chan llm.Event {
+	m.record(ctx)
+	return m.mockLLM.StreamResponse(ctx, msgs, tools)
+}
+
+func TestWithWebSearch_EveryModelCall(t *testing.T) {
+	mock := &webSearchLLM{mockLLM: newMockLLM(
+		mockResponse{
+			ToolCalls: []message.ToolCall{
+				{ID: "tc-1", Name: "echo", Input: `{}`, Type: "function"},
+			},
+		},
+		mockResponse{Content: "done"},
+	)}
+	a := agent.New(
+		mock,
+		agent.WithTools(&echoTool{}),
+		agent.WithWebSearch(llm.WebSearch{MaxUses: 2}),
+	)
+
+	if _, err := a.Chat(context.Background(), "search"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.searches) != 2 || mock.missing != 0 {
+		t.Fatalf(
+			"searches = %+v, missing = %d",
+			mock.searches,
+			mock.missing,
+		)
+	}
+	if mock.searches[0].MaxUses != 2 {
+		t.Errorf("search = %+v", mock.searches[0])
+	}
+}
+
+func TestWithWebSearch_Stream(t *testing.T) {
+	mock := &webSearchLLM{mockLLM: newMockLLM(mockResponse{Content: "x"})}
+	a := agent.New(mock, agent.WithWebSearch())
+
+	for event := range a.ChatStream(context.Background(), "search") {
+		if event.Type == types.EventError {
+			t.Fatal(event.Error)
+		}
+	}
+	if len(mock.searches) != 1 {
+		t.Errorf("searches = %d, want 1", len(mock.searches))
+	}
+}
+
+func TestWithWebSearch_DisabledByDefault(t *testing.T) {
+	mock := &webSearchLLM{mockLLM: newMockLLM(mockResponse{Content: "x"})}
+	a := agent.New(mock)
+
+	if _, err := a.Chat(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.searches) != 0 {
+		t.Errorf("web search enabled without WithWebSearch")
+	}
+}
@@ -0,0 +1,51 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/batch"
+	"github.com/joakimcarlsson/ai/llm"
+)
+
+func TestSingleType(t *testing.T) {
+	chat := batch.Request{Type: batch.RequestTypeChat}
+	embed := batch.Request{Type: batch.RequestTypeEmbedding}
+
+	got, err := batch.SingleType([]batch.Request{embed, embed})
+	if err != nil || got != batch.RequestTypeEmbedding {
+		t.Errorf("expected embedding type, got %v, %v", got, err)
+	}
+	_, err = batch.SingleType([]batch.Request{chat, embed})
+	if !errors.Is(err, batch.ErrMixedRequestTypes) {
+		t.Errorf("expected ErrMixedRequestTypes, got %v", err)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	var c batch.Collector
+	c.Result("summary-2").ChatResponse = &llm.Response{Content: "b"}
+	c.Result("summary-1").Err = fmt.Errorf("rate limited")
+	c.Result("summary-2").Err = nil
+	c.Result("summary-3")
+
+	resp := c.Response()
+	if resp.Total != 3 || resp.Completed != 1 || resp.Failed != 1 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	for i, want := range []string{"summary-2", "summary-1", "summary-3"} {
+		if resp.Results[i].ID != want || resp.Results[i].Index != i {
+			t.Errorf("result %d: got %s at %d, want %s",
+				i, resp.Results[i].ID, resp.Results[i].Index, want)
+		}
+	}
+	if resp.Results[0].ChatResponse.Content != "b" {
+		t.Error("expected repeated ID to update the same result")
+	}
+
+	var empty batch.Collector
+	if got := empty.Response(); got.Results == nil || got.Total != 0 {
+		t.Errorf("expected empty non-nil results, got %+v", got)
+	}
+}
@@ -0,0 +1,184 @@
+package completion_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/completion"
+	"github.com/joakimcarlsson/ai/completion/openai"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func newServer(
+	t *testing.T,
+	capture *map[string]any,
+	header *http.Header,
+	body string,
+) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/completions" {
+				t.Errorf("path = %s, want /v1/completions", r.URL.Path)
+			}
+			raw, _ := io.ReadAll(r.Body)
+			if capture != nil {
+				_ = json.Unmarshal(raw, capture)
+			}
+			if header != nil {
+				*header = r.Header.Clone()
+			}
+			if strings.HasPrefix(body, "data:") {
+				w.Header().Set("Content-Type", "text/event-stream")
+			} else {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			_, _ = io.WriteString(w, body)
+		}))
+}
+
+func newClient(baseURL string, opts ...openai.Option) completion.Completion {
+	opts = append([]openai.Option{
+		openai.WithBaseURL(baseURL + "/v1"),
+		openai.WithModel(model.NewCustomModel(
+			model.WithAPIModel("instruct-model"),
+		)),
+	}, opts...)
+	return openai.NewCompletion(opts...)
+}
+
+func TestComplete(t *testing.T) {
+	var body map[string]any
+	var header http.Header
+	srv := newServer(t, &body, &header, `{"id":"c","choices":[`+
+		`{"index":0,"text":" there was a fox.","finish_reason":"stop"}],`+
+		`"usage":{"prompt_tokens":4,"completion_tokens":5}}`)
+	defer srv.Close()
+
+	maxTokens := int64(16)
+	resp, err := newClient(srv.URL, openai.WithAPIKey("key")).Complete(
+		context.Background(),
+		completion.Request{
+			Prompt:    "Once upon a time",
+			MaxTokens: &maxTokens,
+			Stop:      []string{"\n"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Content != " there was a fox." ||
+		resp.FinishReason != completion.FinishReasonStop {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Usage.InputTokens != 4 || resp.Usage.OutputTokens != 5 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if body["model"] != "instruct-model" ||
+		body["prompt"] != "Once upon a time" ||
+		body["max_tokens"] != float64(16) ||
+		body["stream"] != false {
+		t.Errorf("request body = %v", body)
+	}
+	if _, ok := body["messages"]; ok {
+		t.Error("request carries chat messages")
+	}
+	if got := header.Get("Authorization"); got != "Bearer key" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestCompleteWithoutAPIKey(t *testing.T) {
+	var header http.Header
+	srv := newServer(t, nil, &header,
+		`{"choices":[{"text":"ok","finish_reason":"length"}]}`)
+	defer srv.Close()
+
+	resp, err := newClient(srv.URL).Complete(
+		context.Background(),
+		completion.Request{Prompt: "hi"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != completion.FinishReasonLength {
+		t.Errorf("finish reason = %q", resp.FinishReason)
+	}
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, `{"error":"bad model"}`, http.StatusBadRequest)
+		}))
+	defer srv.Close()
+
+	_, err := newClient(srv.URL).Complete(
+		context.Background(),
+		completion.Request{Prompt: "hi"},
+	)
+	if err == nil || !strings.Contains(err.Error(), "status 400") ||
+		!strings.Contains(err.Error(), "bad model") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestCompleteStream(t *testing.T) {
+	var body map[string]any
+	srv := newServer(t, &body, nil,
+		"data: {\"choices\":[{\"text\":\"Hello\"}]}\n\n"+
+			"data: {\"choices\":[{\"text\":\", world\","+
+			"\"finish_reason\":\"stop\"}]}\n\n"+
+			"data: {\"choices\":[],\"usage\":"+
+			"{\"prompt_tokens\":2,\"completion_tokens\":3}}\n\n"+
+			"data: [DONE]\n\n")
+	defer srv.Close()
+
+	var deltas []string
+	var final *completion.Response
+	for evt := range newClient(srv.URL).CompleteStream(
+		context.Background(),
+		completion.Request{Prompt: "Say hi"},
+	) {
+		switch evt.Type {
+		case completion.EventContentDelta:
+			deltas = append(deltas, evt.Content)
+		case completion.EventComplete:
+			final = evt.Response
+		case completion.EventError:
+			t.Fatalf("stream error: %v", evt.Error)
+		}
+	}
+
+	if strings.Join(deltas, "|") != "Hello|, world" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if final == nil || final.Content != "Hello, world" ||
+		final.FinishReason != completion.FinishReasonStop ||
+		final.Usage.OutputTokens != 3 {
+		t.Errorf("final = %+v", final)
+	}
+	opts, _ := body["stream_options"].(map[string]any)
+	if body["stream"] != true || opts["include_usage"] != true {
+		t.Errorf("request body = %v", body)
+	}
+}
+
+func TestCompleteRequiresPrompt(t *testing.T) {
+	_, err := newClient("http://127.0.0.1:0").Complete(
+		context.Background(),
+		completion.Request{},
+	)
+	if err == nil {
+		t.Error("expected error for empty prompt")
+	}
+}
@@ -0,0 +1,139 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/embeddings"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+var corpusTopics = []string{"refund", "shipping", "password"}
+
+type topicEmbedding struct {
+	batches    []int
+	inputTypes []string
+}
+
+func (e *topicEmbedding) GenerateEmbeddings(
+	_ context.Context,
+	texts []string,
+	inputType ...string,
+) (*embeddings.EmbeddingResponse, error) {
+	e.batches = append(e.batches, len(texts))
+	e.inputTypes = append(e.inputTypes, inputType...)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float32, len(corpusTopics))
+		for j, topic := range corpusTopics {
+			vectors[i][j] = float32(strings.Count(text, topic))
+		}
+	}
+	return &embeddings.EmbeddingResponse{Embeddings: vectors}, nil
+}
+
+func (e *topicEmbedding) GenerateMultimodalEmbeddings(
+	context.Context,
+	[]embeddings.MultimodalInput,
+	...string,
+) (*embeddings.EmbeddingResponse, error) {
+	return &embeddings.EmbeddingResponse{}, nil
+}
+
+func (e *topicEmbedding) GenerateContextualizedEmbeddings(
+	context.Context,
+	[][]string,
+	...string,
+) (*embeddings.ContextualizedEmbeddingResponse, error) {
+	return &embeddings.ContextualizedEmbeddingResponse{}, nil
+}
+
+func (e *topicEmbedding) Model() model.EmbeddingModel {
+	return model.EmbeddingModel{APIModel: "topics"}
+}
+
+var corpusDocs = []string{
+	"refund policy: a refund takes 5 days",
+	"shipping is free over 50",
+	"reset your password from settings",
+	"shipping refund for damaged parcels",
+}
+
+func TestCorpus_SearchRanksBySimilarity(t *testing.T) {
+	embedder := &topicEmbedding{}
+	corpus, err := embeddings.NewCorpus(
+		context.Background(),
+		embedder,
+		corpusDocs,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := corpus.Search(context.Background(), "refund please", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 2 || matches[0].Index != 0 || matches[1].Index != 3 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	if matches[0].Text != corpusDocs[0] || matches[0].Score < 0.99 {
+		t.Errorf("top match = %+v", matches[0])
+	}
+	if !slices.Equal(embedder.batches, []int{4, 1}) {
+		t.Errorf("requests = %v, want docs then query", embedder.batches)
+	}
+}
+
+func TestCorpus_BatchesAndInputTypes(t *testing.T) {
+	embedder := &topicEmbedding{}
+	corpus, err := embeddings.NewCorpus(
+		context.Background(),
+		embedder,
+		corpusDocs,
+		embeddings.WithBatchSize(3),
+		embeddings.WithInputTypes("document", "query"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = corpus.Search(context.Background(), "password", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(embedder.batches, []int{3, 1, 1}) {
+		t.Errorf("batches = %v, want [3 1 1]", embedder.batches)
+	}
+	want := []string{"document", "document", "query"}
+	if !slices.Equal(embedder.inputTypes, want) {
+		t.Errorf("input types = %v, want %v", embedder.inputTypes, want)
+	}
+	if corpus.Len() != len(corpusDocs) {
+		t.Errorf("Len = %d, want %d", corpus.Len(), len(corpusDocs))
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 0}, []float32{-1, 0}, -1},
+		{[]float32{1, 0}, []float32{1}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		got := embeddings.CosineSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %v, want %v",
+				tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/embeddings"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+type stubEmbedding struct {
+	calls int
+}
+
+func (s *stubEmbedding) GenerateEmbeddings(
+	_ context.Context,
+	texts []string,
+	_ ...string,
+) (*embeddings.EmbeddingResponse, error) {
+	s.calls++
+	return &embeddings.EmbeddingResponse{
+		Embeddings: make([][]float32, len(texts)),
+	}, nil
+}
+
+func (s *stubEmbedding) GenerateMultimodalEmbeddings(
+	_ context.Context,
+	inputs []embeddings.MultimodalInput,
+	_ ...string,
+) (*embeddings.EmbeddingResponse, error) {
+	s.calls++
+	return &embeddings.EmbeddingResponse{}, nil
+}
+
+func (s *stubEmbedding) GenerateContextualizedEmbeddings(
+	_ context.Context,
+	documentChunks [][]string,
+	_ ...string,
+) (*embeddings.ContextualizedEmbeddingResponse, error) {
+	s.calls++
+	return &embeddings.ContextualizedEmbeddingResponse{}, nil
+}
+
+func (s *stubEmbedding) Model() model.EmbeddingModel {
+	return model.EmbeddingModel{APIModel: "stub", MaxInputTokens: 10}
+}
+
+var long = strings.Repeat("word ", 20)
+
+func TestTruncationPolicy_Error(t *testing.T) {
+	inner := &stubEmbedding{}
+	e := embeddings.WithTruncationPolicy(
+		inner,
+		embeddings.TruncationError,
+		nil,
+	)
+
+	_, err := e.GenerateEmbeddings(
+		context.Background(),
+		[]string{"short", long, "short", long},
+	)
+	var tooLong *embeddings.InputTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("err = %v, want InputTooLongError", err)
+	}
+	if len(tooLong.Inputs) != 2 || tooLong.Inputs[0].Index != 1 ||
+		tooLong.Inputs[1].Index != 3 {
+		t.Errorf("inputs = %+v, want indexes 1 and 3", tooLong.Inputs)
+	}
+	if inner.calls != 0 {
+		t.Error("request sent despite over-length inputs")
+	}
+}
+
+func TestTruncationPolicy_ErrorContextualized(t *testing.T) {
+	e := embeddings.WithTruncationPolicy(
+		&stubEmbedding{},
+		embeddings.TruncationError,
+		nil,
+	)
+
+	_, err := e.GenerateContextualizedEmbeddings(
+		context.Background(),
+		[][]string{{"a"}, {"b", long}},
+	)
+	var tooLong *embeddings.InputTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("err = %v, want InputTooLongError", err)
+	}
+	in := tooLong.Inputs[0]
+	if in.Index != 1 || in.Chunk != 1 {
+		t.Errorf("input = %+v, want document 1 chunk 1", in)
+	}
+	if !strings.Contains(err.Error(), "#1.1") {
+		t.Errorf("error does not name the chunk: %v", err)
+	}
+}
+
+func TestTruncationPolicy_Warn(t *testing.T) {
+	var logs bytes.Buffer
+	inner := &stubEmbedding{}
+	e := embeddings.WithTruncationPolicy(
+		inner,
+		embeddings.TruncationWarn,
+		slog.New(slog.NewTextHandler(&logs, nil)),
+	)
+
+	if _, err := e.GenerateEmbeddings(
+		context.Background(),
+		[]string{long},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 1 {
+		t.Error("request not sent under the warn policy")
+	}
+	if !strings.Contains(logs.String(), "#0") {
+		t.Errorf("warning does not list the input: %q", logs.String())
+	}
+}
+
+func TestTruncationPolicy_Silent(t *testing.T) {
+	var logs bytes.Buffer
+	inner := &stubEmbedding{}
+	e := embeddings.WithTruncationPolicy(
+		inner,
+		embeddings.TruncationSilent,
+		slog.New(slog.NewTextHandler(&logs, nil)),
+	)
+
+	if _, err := e.GenerateEmbeddings(
+		context.Background(),
+		[]string{long},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 1 || logs.Len() != 0 {
+		t.Errorf("calls = %d, logs = %q", inner.calls, logs.String())
+	}
+}
+
+type countingEmbedding struct {
+	stubEmbedding
+}
+
+func (c *countingEmbedding) CountTokens(text string) int64 {
+	return int64(len(strings.Fields(text)))
+}
+
+func TestTruncationPolicy_UsesClientTokenCounter(t *testing.T) {
+	e := embeddings.WithTruncationPolicy(
+		embeddings.WithTracing(
+			&countingEmbedding{},
+			embeddings.TracingAttrs{},
+		),
+		embeddings.TruncationError,
+		nil,
+	)
+
+	twelve := strings.Repeat("a ", 12)
+	_, err := e.GenerateEmbeddings(
+		context.Background(),
+		[]string{strings.Repeat("x", 100), twelve},
+	)
+	var tooLong *embeddings.InputTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("err = %v, want InputTooLongError", err)
+	}
+	if len(tooLong.Inputs) != 1 || tooLong.Inputs[0].Index != 1 ||
+		tooLong.Inputs[0].Tokens != 12 {
+		t.Errorf("inputs = %+v, want input 1 with 12 tokens", tooLong.Inputs)
+	}
+}
@@ -0,0 +1,106 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	imagegemini "github.com/joakimcarlsson/ai/image/gemini"
+	imageopenai "github.com/joakimcarlsson/ai/image/openai"
+	"github.com/joakimcarlsson/ai/model"
+	"google.golang.org/genai"
+)
+
+func TestGemini_ReportsSeedAndEnhancedPrompt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, ":predict") {
+				t.Errorf("path = %s, want a predict call", r.URL.Path)
+			}
+			var req struct {
+				Parameters struct {
+					Seed int32 `json:"seed"`
+				} `json:"parameters"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			if req.Parameters.Seed != 42 {
+				t.Errorf("seed = %d, want 42", req.Parameters.Seed)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"predictions": [{
+				"bytesBase64Encoded": "aGk=",
+				"mimeType": "image/png",
+				"prompt": "a fluffy orange cat on a windowsill"
+			}]}`))
+		},
+	))
+	defer srv.Close()
+	t.Setenv("GOOGLE_VERTEX_BASE_URL", srv.URL)
+
+	client := imagegemini.NewGeneration(
+		imagegemini.WithAPIKey("key"),
+		imagegemini.WithBackend(genai.BackendVertexAI),
+		imagegemini.WithModel(model.GeminiImageGenerationModels[model.Imagen4]),
+		imagegemini.WithSeed(42),
+	)
+	resp, err := client.GenerateImage(context.Background(), "a cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Images) != 1 {
+		t.Fatalf("images = %+v, want 1", resp.Images)
+	}
+	img := resp.Images[0]
+	if img.RevisedPrompt != "a fluffy orange cat on a windowsill" {
+		t.Errorf("revised prompt = %q", img.RevisedPrompt)
+	}
+	if img.Seed == nil || *img.Seed != 42 {
+		t.Errorf("seed = %v, want 42", img.Seed)
+	}
+}
+
+func TestOpenAI_ReportsRevisedPrompt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/images/generations" {
+				t.Errorf("path = %s, want /images/generations", r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"created": 1, "data": [{
+				"b64_json": "aGk=",
+				"revised_prompt": "a fluffy orange cat on a windowsill"
+			}]}`))
+		},
+	))
+	defer srv.Close()
+
+	client := imageopenai.NewGeneration(
+		imageopenai.WithAPIKey("key"),
+		imageopenai.WithBaseURL(srv.URL),
+		imageopenai.WithModel(
+			model.OpenAIImageGenerationModels[model.GPTImage15],
+		),
+	)
+	resp, err := client.GenerateImage(context.Background(), "a cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Images) != 1 {
+		t.Fatalf("images = %+v, want 1", resp.Images)
+	}
+	img := resp.Images[0]
+	if img.RevisedPrompt != "a fluffy orange cat on a windowsill" ||
+		img.ImageBase64 != "aGk=" {
+		t.Errorf("image = %+v", img)
+	}
+	if img.Seed != nil {
+		t.Errorf("seed = %d, want nil since OpenAI reports none", *img.Seed)
+	}
+}
@@ -0,0 +1,45 @@
+package image
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/image"
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func TestCheckReferenceImages_NoneAlwaysAllowed(t *testing.T) {
+	m := model.GeminiImageGenerationModels[model.Imagen4]
+	if err := image.CheckReferenceImages(m, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckReferenceImages_RejectsUnsupportedModel(t *testing.T) {
+	m := model.GeminiImageGenerationModels[model.Imagen4]
+	err := image.CheckReferenceImages(m, 1)
+	if !errors.Is(err, image.ErrReferenceImagesNotSupported) {
+		t.Fatalf("expected ErrReferenceImagesNotSupported, got %v", err)
+	}
+}
+
+func TestCheckReferenceImages_EnforcesModelLimit(t *testing.T) {
+	m := model.GeminiImageGenerationModels[model.Gemini25FlashImage]
+	if err := image.CheckReferenceImages(m, m.MaxReferenceImages); err != nil {
+		t.Fatalf("unexpected error at limit: %v", err)
+	}
+
+	err := image.CheckReferenceImages(m, m.MaxReferenceImages+1)
+	if !errors.Is(err, image.ErrReferenceImagesNotSupported) {
+		t.Fatalf("expected ErrReferenceImagesNotSupported, got %v", err)
+	}
+}
+
+func TestCheckReferenceImages_OpenAIImageModels(t *testing.T) {
+	for _, id := range []model.ID{model.GPTImage15, model.GPTImage2} {
+		m := model.OpenAIImageGenerationModels[id]
+		if err := image.CheckReferenceImages(m, 2); err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		}
+	}
+}
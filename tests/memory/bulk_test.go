@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/embeddings"
+	"github.com/joakimcarlsson/ai/memory"
+)
+
+type constEmbedding struct {
+	embeddings.Embedding
+}
+
+func (constEmbedding) GenerateEmbeddings(
+	_ context.Context,
+	texts []string,
+	_ ...string,
+) (*embeddings.EmbeddingResponse, error) {
+	resp := &embeddings.EmbeddingResponse{}
+	for range texts {
+		resp.Embeddings = append(resp.Embeddings, []float32{1, 0})
+	}
+	return resp, nil
+}
+
+func seed(t *testing.T, store memory.Store, owner string, facts ...string) {
+	t.Helper()
+	for _, f := range facts {
+		if err := store.Store(context.Background(), owner, f, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBulkOperations_BuiltInStores(t *testing.T) {
+	stores := map[string]memory.Store{
+		"memory": memory.NewStore(constEmbedding{}),
+		"file":   memory.FileStore(t.TempDir(), constEmbedding{}),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, ok := store.(memory.BulkStore); !ok {
+				t.Fatal("store does not implement BulkStore")
+			}
+			seed(t, store, "alice", "a", "b", "c")
+			seed(t, store, "bob", "d")
+
+			if n, err := memory.Count(ctx, store, "alice"); err != nil ||
+				n != 3 {
+				t.Fatalf("Count = %d, %v, want 3", n, err)
+			}
+			if n, err := memory.DeleteAll(ctx, store, "alice"); err != nil ||
+				n != 3 {
+				t.Fatalf("DeleteAll = %d, %v, want 3", n, err)
+			}
+			if n, _ := memory.Count(ctx, store, "alice"); n != 0 {
+				t.Errorf("Count after DeleteAll = %d, want 0", n)
+			}
+			if n, _ := memory.Count(ctx, store, "bob"); n != 1 {
+				t.Errorf("bob's memories = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestBulkOperations_FallbackForPlainStore(t *testing.T) {
+	ctx := context.Background()
+	store := &conflictStore{entries: []memory.Entry{{ID: "a"}, {ID: "b"}}}
+
+	if n, err := memory.Count(ctx, store, "alice"); err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v, want 2", n, err)
+	}
+	n, err := memory.DeleteAll(ctx, store, "alice")
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteAll = %d, %v, want 2", n, err)
+	}
+	if len(store.deleted) != 2 {
+		t.Errorf("deleted = %v, want both entries", store.deleted)
+	}
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type conflictLLM struct {
+	llm.LLM
+	content string
+}
+
+func (m *conflictLLM) SendMessages(
+	context.Context,
+	[]message.Message,
+	[]tool.BaseTool,
+) (*llm.Response, error) {
+	return &llm.Response{Content: m.content}, nil
+}
+
+var errDelete = errors.New("delete failed")
+
+type conflictStore struct {
+	entries    []memory.Entry
+	deleted    []string
+	failDelete string
+}
+
+func (s *conflictStore) Search(
+	context.Context,
+	string,
+	string,
+	int,
+) ([]memory.Entry, error) {
+	return s.entries, nil
+}
+
+func (s *conflictStore) Store(
+	context.Context,
+	string,
+	string,
+	map[string]any,
+) error {
+	return nil
+}
+
+func (s *conflictStore) GetAll(
+	context.Context,
+	string,
+	int,
+) ([]memory.Entry, error) {
+	return s.entries, nil
+}
+
+func (s *conflictStore) Update(
+	context.Context,
+	string,
+	string,
+	map[string]any,
+) error {
+	return nil
+}
+
+func (s *conflictStore) Delete(_ context.Context, id string) error {
+	if id == s.failDelete {
+		return errDelete
+	}
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestResolveConflicts_SupersedesContradiction(t *testing.T) {
+	store := &conflictStore{entries: []memory.Entry{
+		{ID: "a", Content: "Loves Italian food."},
+		{ID: "b", Content: "Lives in Oslo."},
+	}}
+	client := &conflictLLM{
+		content: "```json\n" +
+			`{"conflicts":[{"memory_id":"a","reason":"opposite"},` +
+			`{"memory_id":"zzz","reason":"unknown id"}]}` +
+			"\n```",
+	}
+	metadata := map[string]any{"source": "auto_extract"}
+
+	res, err := memory.ResolveConflicts(
+		context.Background(),
+		store,
+		client,
+		"user-1",
+		"Hates Italian food.",
+		metadata,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.deleted) != 0 {
+		t.Errorf("deleted %v before the new fact was stored", store.deleted)
+	}
+	ids, _ := res.Metadata[memory.MetadataSupersedes].([]string)
+	if !slices.Equal(ids, []string{"a"}) {
+		t.Errorf("supersedes = %v, want [a]", ids)
+	}
+	facts, _ := res.Metadata[memory.MetadataSupersededFacts].([]string)
+	if !slices.Equal(facts, []string{"Loves Italian food."}) {
+		t.Errorf("superseded facts = %v", facts)
+	}
+	if res.Metadata["source"] != "auto_extract" {
+		t.Errorf("existing metadata lost: %v", res.Metadata)
+	}
+	if _, ok := metadata[memory.MetadataSupersedes]; ok {
+		t.Error("input metadata was modified")
+	}
+
+	if err := res.Apply(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(store.deleted, []string{"a"}) {
+		t.Errorf("deleted = %v, want [a]", store.deleted)
+	}
+}
+
+func TestResolution_ApplyKeepsAndJoinsErrors(t *testing.T) {
+	store := &conflictStore{failDelete: "b"}
+	res := &memory.Resolution{Superseded: []memory.Entry{
+		{ID: "a"}, {ID: "b"}, {ID: "c"},
+	}}
+
+	err := res.Apply(context.Background(), store, "a")
+	if !errors.Is(err, errDelete) {
+		t.Errorf("err = %v, want the delete error", err)
+	}
+	if !slices.Equal(store.deleted, []string{"c"}) {
+		t.Errorf("deleted = %v, want [c]", store.deleted)
+	}
+}
+
+func TestResolveConflicts_NoConflicts(t *testing.T) {
+	store := &conflictStore{entries: []memory.Entry{
+		{ID: "a", Content: "Loves Italian food."},
+	}}
+	client := &conflictLLM{content: `{"conflicts":[]}`}
+	metadata := map[string]any{"source": "auto_extract"}
+
+	res, err := memory.ResolveConflicts(
+		context.Background(),
+		store,
+		client,
+		"user-1",
+		"Has a dog.",
+		metadata,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Superseded) != 0 {
+		t.Errorf("superseded = %v, want none", res.Superseded)
+	}
+	if _, ok := res.Metadata[memory.MetadataSupersedes]; ok {
+		t.Errorf("unexpected supersedes link: %v", res.Metadata)
+	}
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	llm "github.com/joakimcarlsson/ai/llm"
+	"github.com/joakimcarlsson/ai/memory"
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type dedupLLM struct {
+	llm.LLM
+	calls int
+}
+
+func (m *dedupLLM) SendMessages(
+	context.Context,
+	[]message.Message,
+	[]tool.BaseTool,
+) (*llm.Response, error) {
+	m.calls++
+	return &llm.Response{
+		Content: `{"decisions":[{"event":"UPDATE","memory_id":"a","text":"merged"}]}`,
+	}, nil
+}
+
+var existing = []memory.Entry{
+	{ID: "a", Content: "Likes tea.", Score: 0.95},
+	{ID: "b", Content: "Likes green tea.", Score: 0.97},
+	{ID: "c", Content: "Lives in Oslo.", Score: 0.4},
+}
+
+func dedupConfig(opts ...memory.DedupOption) memory.DedupConfig {
+	return memory.Apply(memory.AutoDedup(opts...)).Dedup
+}
+
+func TestDeduplicateWith_BelowThresholdAdds(t *testing.T) {
+	client := &dedupLLM{}
+	result, err := memory.DeduplicateWith(
+		context.Background(),
+		client,
+		"Has a dog.",
+		existing,
+		dedupConfig(memory.WithDedupThreshold(0.99)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.calls != 0 {
+		t.Errorf("LLM calls = %d, want 0", client.calls)
+	}
+	if len(result.Decisions) != 1 ||
+		result.Decisions[0].Event != memory.DedupEventAdd {
+		t.Errorf("decisions = %+v, want one ADD", result.Decisions)
+	}
+}
+
+func TestDeduplicateWith_Skip(t *testing.T) {
+	client := &dedupLLM{}
+	result, err := memory.DeduplicateWith(
+		context.Background(),
+		client,
+		"Enjoys tea.",
+		existing,
+		dedupConfig(
+			memory.WithDedupThreshold(0.9),
+			memory.WithDedupStrategy(memory.DedupSkip),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.calls != 0 {
+		t.Errorf("LLM calls = %d, want 0", client.calls)
+	}
+	if len(result.Decisions) != 1 ||
+		result.Decisions[0].Event != memory.DedupEventNone {
+		t.Errorf("decisions = %+v, want one NONE", result.Decisions)
+	}
+}
+
+func TestDeduplicateWith_ReplaceClosest(t *testing.T) {
+	result, err := memory.DeduplicateWith(
+		context.Background(),
+		&dedupLLM{},
+		"Likes oolong tea.",
+		existing,
+		dedupConfig(memory.WithDedupStrategy(memory.DedupReplace)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := memory.DedupDecision{
+		Event:    memory.DedupEventUpdate,
+		MemoryID: "b",
+		Text:     "Likes oolong tea.",
+	}
+	if len(result.Decisions) != 1 || result.Decisions[0] != want {
+		t.Errorf("decisions = %+v, want %+v", result.Decisions, want)
+	}
+}
+
+func TestDeduplicateWith_MergeUsesLLM(t *testing.T) {
+	client := &dedupLLM{}
+	result, err := memory.DeduplicateWith(
+		context.Background(),
+		client,
+		"Enjoys tea.",
+		existing,
+		dedupConfig(memory.WithDedupThreshold(0.9)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.calls != 1 {
+		t.Errorf("LLM calls = %d, want 1", client.calls)
+	}
+	if len(result.Decisions) != 1 ||
+		result.Decisions[0].Event != memory.DedupEventUpdate {
+		t.Errorf("decisions = %+v, want the LLM's UPDATE", result.Decisions)
+	}
+}
+
+func TestDeduplicateWith_UnknownStrategy(t *testing.T) {
+	_, err := memory.DeduplicateWith(
+		context.Background(),
+		&dedupLLM{},
+		"Enjoys tea.",
+		existing,
+		dedupConfig(memory.WithDedupStrategy("bogus")),
+	)
+	if err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
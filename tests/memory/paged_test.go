@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/memory"
+)
+
+func TestGetAllPaged_FallbackPagesThroughGetAll(t *testing.T) {
+	ctx := context.Background()
+	store := &conflictStore{entries: []memory.Entry{
+		{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"},
+	}}
+
+	var got []string
+	cursor := ""
+	pages := 0
+	for {
+		page, next, err := memory.GetAllPaged(ctx, store, "alice", cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page {
+			got = append(got, e.ID)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	want := []string{"a", "b", "c", "d", "e"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetAllPaged_InvalidCursor(t *testing.T) {
+	store := &conflictStore{entries: []memory.Entry{{ID: "a"}}}
+	_, _, err := memory.GetAllPaged(
+		context.Background(),
+		store,
+		"alice",
+		"bogus",
+		2,
+	)
+	if !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
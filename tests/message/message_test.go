@@ -2,9 +2,12 @@ package message
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/model"
 )
 
 func TestNewUserMessage(t *testing.T) {
@@ -119,6 +122,102 @@ func TestToolResults(t *testing.T) {
 	}
 }
 
+func TestToolResult_TextWithImageNotes(t *testing.T) {
+	tr := message.ToolResult{
+		Content: "Revenue chart",
+		Images: []message.BinaryContent{
+			{MIMEType: "image/png", Data: []byte("png")},
+		},
+	}
+	want := "Revenue chart\n" +
+		"[image/png image omitted: this model cannot view images in tool results]"
+	if got := tr.TextWithImageNotes(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	plain := message.ToolResult{Content: "ok"}
+	if got := plain.TextWithImageNotes(); got != "ok" {
+		t.Errorf("expected content unchanged, got %q", got)
+	}
+}
+
+func TestToolResult_ImagesRoundTrip(t *testing.T) {
+	m := message.NewMessage(message.Tool, []message.ContentPart{
+		message.ToolResult{
+			ToolCallID: "1",
+			Images: []message.BinaryContent{
+				{MIMEType: "image/png", Data: []byte{0x89, 'P'}},
+			},
+		},
+	})
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var decoded message.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	images := decoded.ToolResults()[0].Images
+	if len(images) != 1 || string(images[0].Data) != "\x89P" {
+		t.Errorf("expected image to round-trip, got %+v", images)
+	}
+}
+
+func TestMarshalJSON_NoHTMLEscaping(t *testing.T) {
+	code := `if a && b { return "<div>" }`
+	m := message.NewMessage(message.Tool, []message.ContentPart{
+		message.TextContent{Text: code},
+		message.ToolResult{ToolCallID: "1", Content: code},
+	})
+
+	data, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), `\u00`) {
+		t.Errorf("expected unescaped JSON, got %s", data)
+	}
+	var decoded message.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if decoded.Content().Text != code ||
+		decoded.ToolResults()[0].Content != code {
+		t.Errorf("expected %q to round-trip, got %+v", code, decoded)
+	}
+}
+
+func TestCitations_RoundTrip(t *testing.T) {
+	m := message.NewAssistantMessage()
+	m.AppendContent("The sky is blue.")
+	m.AddCitations([]message.Citation{{
+		Type:      "web_search_result_location",
+		Title:     "Example",
+		URL:       "https://example.com",
+		CitedText: "sky is blue",
+		Start:     0,
+		End:       16,
+	}})
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var decoded message.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	citations := decoded.Citations()
+	if len(citations) != 1 || citations[0] != m.Citations()[0] {
+		t.Errorf("expected citation to round-trip, got %+v", citations)
+	}
+	if decoded.Content().Text != "The sky is blue." {
+		t.Errorf("content = %q", decoded.Content().Text)
+	}
+}
+
 func TestBinaryContentAccessor(t *testing.T) {
 	m := message.NewMessage(message.User, []message.ContentPart{
 		message.TextContent{Text: "look"},
@@ -426,6 +525,87 @@ func TestJSON_RoundTrip_BinaryContent(t *testing.T) {
 	}
 }
 
+func TestJSON_RoundTrip_FileRef(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	orig := message.NewUserMessage("summarize the video")
+	orig.AddFileRef(message.FileRef{
+		Provider:  model.ProviderGemini,
+		ID:        "files/abc",
+		URI:       "https://example.com/files/abc",
+		MIMEType:  "video/mp4",
+		SizeBytes: 1024,
+		ExpiresAt: expires,
+	})
+
+	data, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var decoded message.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	refs := decoded.FileRefs()
+	if len(refs) != 1 {
+		t.Fatalf("expected 1 file ref, got %d", len(refs))
+	}
+	if refs[0] != orig.FileRefs()[0] {
+		t.Errorf("got %+v, want %+v", refs[0], orig.FileRefs()[0])
+	}
+	if refs[0].Expired(expires.Add(-time.Second)) {
+		t.Error("expected ref to be valid before ExpiresAt")
+	}
+	if !refs[0].Expired(expires) {
+		t.Error("expected ref to be expired at ExpiresAt")
+	}
+	if (message.FileRef{}).Expired(expires) {
+		t.Error("expected ref without ExpiresAt to never expire")
+	}
+}
+
+func TestJSON_RoundTrip_Video(t *testing.T) {
+	orig := message.NewUserMessage("what happens in these clips?")
+	sampling := &message.VideoSampling{
+		FPS:   2,
+		Start: 10 * time.Second,
+		End:   40 * time.Second,
+	}
+	orig.AddVideoURL("https://www.youtube.com/watch?v=abc", sampling)
+	orig.AddVideoFile(message.FileRef{
+		Provider: model.ProviderGemini,
+		ID:       "files/clip",
+		URI:      "https://example.com/files/clip",
+		MIMEType: "video/mp4",
+	}, nil)
+
+	data, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var decoded message.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	videos := decoded.VideoContent()
+	if len(videos) != 2 {
+		t.Fatalf("expected 2 videos, got %d", len(videos))
+	}
+	if s := videos[0].Sampling; s == nil || s.FPS != 2 ||
+		s.Start != 10*time.Second || s.End != 40*time.Second {
+		t.Errorf("wrong sampling: %+v", s)
+	}
+	if videos[1].File == nil || videos[1].File.ID != "files/clip" {
+		t.Errorf("wrong file: %+v", videos[1].File)
+	}
+	if videos[1].MIMEType != "video/mp4" {
+		t.Errorf("wrong mime: %q", videos[1].MIMEType)
+	}
+}
+
 func TestJSON_RoundTrip_MixedParts(t *testing.T) {
 	orig := message.NewMessage(
 		message.Assistant,
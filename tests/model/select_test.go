@@ -0,0 +1,143 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+)
+
+func allModels() []model.Model {
+	var out []model.Model
+	for _, catalog := range []map[model.ID]model.Model{
+		model.OpenAIModels,
+		model.AnthropicModels,
+		model.GeminiModels,
+		model.VertexAIGeminiModels,
+		model.AzureModels,
+		model.BergetModels,
+		model.CerebrasModels,
+		model.CohereModels,
+		model.DeepSeekModels,
+		model.FireworksModels,
+		model.GroqModels,
+		model.MetaModels,
+		model.MistralModels,
+		model.OllamaModels,
+		model.OpenRouterModels,
+		model.PerplexityModels,
+		model.QwenModels,
+		model.TogetherModels,
+		model.XAIModels,
+	} {
+		for _, m := range catalog {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func TestSelect_CheapestMatching(t *testing.T) {
+	req := model.Require{
+		Vision: true,
+		Tools:  true,
+		Providers: []model.Provider{
+			model.ProviderOpenAI,
+			model.ProviderAnthropic,
+		},
+	}
+	got, err := model.Select(req, model.CheapestBy(model.InputCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.Matches(got) {
+		t.Fatalf("%s does not meet the requirements", got.ID)
+	}
+	for _, m := range allModels() {
+		if req.Matches(m) && m.CostPer1MIn < got.CostPer1MIn {
+			t.Errorf(
+				"%s costs %.4f, cheaper than selected %s at %.4f",
+				m.ID, m.CostPer1MIn, got.ID, got.CostPer1MIn,
+			)
+		}
+	}
+}
+
+func TestSelect_Stable(t *testing.T) {
+	req := model.Require{Tools: true}
+	first, err := model.Select(req, model.CheapestBy(model.TotalCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		got, err := model.Select(req, model.CheapestBy(model.TotalCost))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != first.ID || got.Provider != first.Provider {
+			t.Fatalf("selected %s, then %s", first.ID, got.ID)
+		}
+	}
+}
+
+func TestSelect_PreferenceTieBreak(t *testing.T) {
+	req := model.Require{Providers: []model.Provider{model.ProviderOllama}}
+	got, err := model.Select(
+		req,
+		model.CheapestBy(model.InputCost),
+		model.LargestContext(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range allModels() {
+		if req.Matches(m) && m.CostPer1MIn == got.CostPer1MIn &&
+			m.ContextWindow > got.ContextWindow {
+			t.Errorf(
+				"%s has a larger window than selected %s",
+				m.ID, got.ID,
+			)
+		}
+	}
+}
+
+func TestSelect_NoMatch(t *testing.T) {
+	_, err := model.Select(model.Require{
+		Tools:            true,
+		MinContextWindow: 1 << 40,
+	})
+	if !errors.Is(err, model.ErrNoMatchingModel) {
+		t.Fatalf("err = %v, want ErrNoMatchingModel", err)
+	}
+}
+
+func TestRequire_Matches(t *testing.T) {
+	m := model.NewCustomModel(
+		model.WithProvider("custom"),
+		model.WithAttachments(true),
+		model.WithContextWindow(8000),
+	)
+	tests := []struct {
+		name string
+		req  model.Require
+		want bool
+	}{
+		{"empty", model.Require{}, true},
+		{"vision and tools", model.Require{Vision: true, Tools: true}, true},
+		{"reasoning", model.Require{Reasoning: true}, false},
+		{"window fits", model.Require{MinContextWindow: 8000}, true},
+		{"window too small", model.Require{MinContextWindow: 8001}, false},
+		{
+			"other provider",
+			model.Require{Providers: []model.Provider{model.ProviderOpenAI}},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Matches(m); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/moderation"
+	moderationopenai "github.com/joakimcarlsson/ai/moderation/openai"
+)
+
+func TestOpenAI_Moderate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/moderations" {
+				t.Errorf("path = %s, want /moderations", r.URL.Path)
+			}
+			if got := r.Header.Get("Authorization"); got != "Bearer key" {
+				t.Errorf("Authorization = %q", got)
+			}
+			var req struct {
+				Model string `json:"model"`
+				Input string `json:"input"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			if req.Model != "omni-moderation-latest" || req.Input != "text" {
+				t.Errorf("request = %+v", req)
+			}
+			_, _ = w.Write([]byte(`{"results":[{
+				"flagged": true,
+				"categories": {"violence": true, "harassment": true, "sexual": false},
+				"category_scores": {"violence": 0.9, "harassment": 0.7, "sexual": 0.01}
+			}]}`))
+		},
+	))
+	defer srv.Close()
+
+	m := moderationopenai.NewModerator(
+		moderationopenai.WithAPIKey("key"),
+		moderationopenai.WithBaseURL(srv.URL),
+	)
+	result, err := m.Moderate(context.Background(), "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Flagged {
+		t.Error("expected flagged result")
+	}
+	if want := []string{"harassment", "violence"}; !slices.Equal(
+		result.Categories,
+		want,
+	) {
+		t.Errorf("categories = %v, want %v", result.Categories, want)
+	}
+	if result.Scores["violence"] != 0.9 {
+		t.Errorf("scores = %v", result.Scores)
+	}
+}
+
+func TestOpenAI_ModerateHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "bad key", http.StatusUnauthorized)
+		},
+	))
+	defer srv.Close()
+
+	m := moderationopenai.NewModerator(
+		moderationopenai.WithBaseURL(srv.URL),
+	)
+	if _, err := m.Moderate(context.Background(), "text"); err == nil {
+		t.Fatal("expected error for 401 response")
+	}
+}
+
+func TestOpenAI_CheckInputsReturnsCategoryScores(t *testing.T) {
+	var gotInput json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Input json.RawMessage `json:"input"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			gotInput = req.Input
+			_, _ = w.Write([]byte(`{"model":"omni-moderation-2024-09-26",
+				"results":[{
+				"flagged": true,
+				"categories": {"violence": true, "sexual": false},
+				"category_scores": {"violence": 0.91, "sexual": 0.02},
+				"category_applied_input_types": {
+					"violence": ["text", "image"],
+					"sexual": ["text", "image"]
+				}
+			}]}`))
+		},
+	))
+	defer srv.Close()
+
+	client := moderationopenai.NewModerator(
+		moderationopenai.WithBaseURL(srv.URL),
+	)
+
+	result, err := client.CheckInputs(
+		context.Background(),
+		moderation.TextInput("caption"),
+		moderation.ImageDataInput([]byte{1, 2}, "image/png"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInput := `[{"type":"text","text":"caption"},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,AQI="}}]`
+	if string(gotInput) != wantInput {
+		t.Errorf("input = %s, want %s", gotInput, wantInput)
+	}
+	if result.Model != "omni-moderation-2024-09-26" || !result.Flagged {
+		t.Errorf("result = %+v", result)
+	}
+	violence, ok := result.Category("violence")
+	if !ok || !violence.Flagged || violence.Score != 0.91 ||
+		!slices.Equal(violence.AppliedInputTypes, []string{"text", "image"}) {
+		t.Errorf("violence = %+v", violence)
+	}
+	sexual, ok := result.Category("sexual")
+	if !ok || sexual.Flagged || sexual.Score != 0.02 {
+		t.Errorf("sexual = %+v", sexual)
+	}
+	if len(result.Categories) != 2 || result.Categories[0].Name != "sexual" {
+		t.Errorf("categories not sorted by name: %+v", result.Categories)
+	}
+}
+
+func TestOpenAI_ImagesRequireOmniModel(t *testing.T) {
+	client := moderationopenai.NewModerator(
+		moderationopenai.WithModel("text-moderation-latest"),
+	)
+	_, err := client.CheckInputs(
+		context.Background(),
+		moderation.ImageURLInput("https://example.com/a.png"),
+	)
+	if !errors.Is(err, moderation.ErrImagesUnsupported) {
+		t.Fatalf("err = %v, want ErrImagesUnsupported", err)
+	}
+}
@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/prompt"
+)
+
+func newExperiment(t *testing.T, opts ...prompt.Option) *prompt.Experiment {
+	t.Helper()
+	e, err := prompt.NewExperiment("greeting", []prompt.Variant{
+		{Name: "control", Source: "Hello, {{.name}}.", Weight: 1},
+		{Name: "warm", Source: "Hi {{.name}}, great to see you!", Weight: 3},
+		{Name: "off", Source: "unused", Weight: 0},
+	}, opts...)
+	if err != nil {
+		t.Fatalf("NewExperiment: %v", err)
+	}
+	return e
+}
+
+func TestExperiment_PickIsStable(t *testing.T) {
+	e := newExperiment(t)
+	for i := range 50 {
+		key := fmt.Sprintf("user-%d", i)
+		first := e.Pick(key)
+		if again := e.Pick(key); again.Variant != first.Variant {
+			t.Fatalf("%s got %s then %s", key, first.Variant, again.Variant)
+		}
+		if first.Experiment != "greeting" {
+			t.Errorf("Experiment = %q", first.Experiment)
+		}
+	}
+}
+
+func TestExperiment_PickFollowsWeights(t *testing.T) {
+	e := newExperiment(t)
+	counts := map[string]int{}
+	for i := range 4000 {
+		counts[e.Pick(fmt.Sprintf("user-%d", i)).Variant]++
+	}
+	if counts["off"] != 0 {
+		t.Errorf("zero-weight variant picked %d times", counts["off"])
+	}
+	if counts["warm"] < 2700 || counts["warm"] > 3300 {
+		t.Errorf("warm picked %d of 4000, want about 3000", counts["warm"])
+	}
+}
+
+func TestExperiment_RandomSkipsZeroWeight(t *testing.T) {
+	e := newExperiment(t)
+	for range 200 {
+		if v := e.Random().Variant; v == "off" {
+			t.Fatal("zero-weight variant picked at random")
+		}
+	}
+}
+
+func TestExperiment_GetAndProcess(t *testing.T) {
+	e := newExperiment(t)
+	sel, err := e.Get("warm")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	out, err := sel.Process(map[string]any{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if out != "Hi Ada, great to see you!" {
+		t.Errorf("got %q", out)
+	}
+
+	if _, err := e.Get("missing"); !errors.Is(err, prompt.ErrUnknownVariant) {
+		t.Errorf("err = %v, want ErrUnknownVariant", err)
+	}
+}
+
+func TestExperiment_UsesCache(t *testing.T) {
+	cache := prompt.NewCache()
+	newExperiment(t, prompt.WithCache(cache))
+	if cache.Get("greeting/warm") == nil {
+		t.Error("variant template not cached under greeting/warm")
+	}
+}
+
+func TestNewExperiment_Invalid(t *testing.T) {
+	tests := map[string][]prompt.Variant{
+		"no variants": nil,
+		"no weight":   {{Name: "a", Source: "x"}},
+		"negative":    {{Name: "a", Source: "x", Weight: -1}},
+		"unnamed":     {{Source: "x", Weight: 1}},
+		"duplicate": {
+			{Name: "a", Source: "x", Weight: 1},
+			{Name: "a", Source: "y", Weight: 1},
+		},
+		"parse error": {{Name: "a", Source: "{{.x", Weight: 1}},
+	}
+	for name, variants := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := prompt.NewExperiment("e", variants); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package rerankers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/rerankers"
+)
+
+type lengthReranker struct {
+	calls  int
+	failAt int
+}
+
+func (r *lengthReranker) Rerank(
+	_ context.Context,
+	_ string,
+	documents []string,
+) (*rerankers.RerankerResponse, error) {
+	r.calls++
+	if r.calls == r.failAt {
+		return nil, errors.New("boom")
+	}
+	resp := &rerankers.RerankerResponse{
+		Usage: rerankers.RerankerUsage{TotalTokens: int64(len(documents))},
+	}
+	for i, doc := range documents {
+		resp.Results = append(resp.Results, rerankers.RerankerResult{
+			Index:          i,
+			RelevanceScore: float64(len(doc)),
+			Document:       doc,
+		})
+	}
+	return resp, nil
+}
+
+func (r *lengthReranker) Model() model.RerankerModel {
+	return model.RerankerModel{}
+}
+
+func collect(ch <-chan rerankers.RerankEvent) []rerankers.RerankEvent {
+	var events []rerankers.RerankEvent
+	for evt := range ch {
+		events = append(events, evt)
+	}
+	return events
+}
+
+func TestRerankStream_EmitsRunningTopN(t *testing.T) {
+	docs := []string{"aa", "a", "aaaaa", "aaa", "aaaa"}
+	events := collect(rerankers.RerankStream(
+		context.Background(),
+		&lengthReranker{},
+		"q",
+		docs,
+		rerankers.WithBatchSize(2),
+		rerankers.WithTopN(2),
+	))
+
+	if len(events) != 3 {
+		t.Fatalf("expected one event per batch, got %d", len(events))
+	}
+	wantTop := [][]int{{0, 1}, {2, 3}, {2, 4}}
+	for i, evt := range events {
+		if len(evt.Results) != 2 {
+			t.Fatalf("event %d: expected 2 results, got %+v", i, evt.Results)
+		}
+		for j, idx := range wantTop[i] {
+			if evt.Results[j].Index != idx {
+				t.Errorf("event %d rank %d: expected index %d, got %d",
+					i, j, idx, evt.Results[j].Index)
+			}
+			if evt.Results[j].Document != docs[idx] {
+				t.Errorf("event %d rank %d: document %q does not match index",
+					i, j, evt.Results[j].Document)
+			}
+		}
+	}
+
+	last := events[len(events)-1]
+	if !last.Done || last.Scored != 5 || last.Total != 5 {
+		t.Errorf("expected final event done with 5/5, got %+v", last)
+	}
+	if last.Usage.TotalTokens != 5 {
+		t.Errorf("expected cumulative usage 5, got %d",
+			last.Usage.TotalTokens)
+	}
+	for _, evt := range events[:len(events)-1] {
+		if evt.Done {
+			t.Errorf("expected only the final event done, got %+v", evt)
+		}
+	}
+}
+
+func TestRerankStream_BatchErrorEndsStream(t *testing.T) {
+	events := collect(rerankers.RerankStream(
+		context.Background(),
+		&lengthReranker{failAt: 2},
+		"q",
+		[]string{"a", "bb", "c", "dd"},
+		rerankers.WithBatchSize(2),
+	))
+
+	if len(events) != 2 {
+		t.Fatalf("expected progress then error event, got %d", len(events))
+	}
+	last := events[1]
+	if last.Err == nil || !strings.Contains(last.Err.Error(), "boom") {
+		t.Errorf("expected wrapped batch error, got %v", last.Err)
+	}
+	if last.Done || last.Scored != 2 || len(last.Results) != 2 {
+		t.Errorf("expected partial ranking of first batch, got %+v", last)
+	}
+}
+
+func TestRerankStream_EmptyDocumentsCompletes(t *testing.T) {
+	events := collect(rerankers.RerankStream(
+		context.Background(),
+		&lengthReranker{},
+		"q",
+		nil,
+	))
+
+	if len(events) != 1 || !events[0].Done || events[0].Err != nil {
+		t.Errorf("expected a single done event, got %+v", events)
+	}
+}
+
+func TestRerankStream_CancelCloses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &lengthReranker{}
+	ch := rerankers.RerankStream(ctx, r, "q",
+		[]string{"a", "b", "c", "d"},
+		rerankers.WithBatchSize(1),
+	)
+
+	<-ch
+	cancel()
+	for range ch {
+	}
+	if r.calls > 3 {
+		t.Errorf("expected scoring to stop after cancel, got %d calls",
+			r.calls)
+	}
+}
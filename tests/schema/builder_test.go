@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/schema"
+)
+
+func TestBuilder_Output(t *testing.T) {
+	info := schema.Object().
+		Field("language", schema.String().Desc("Programming language")).
+		Field("complexity", schema.Enum("low", "medium", "high")).
+		Required("language").
+		Output("code_analysis", "Analyze code")
+
+	want := schema.NewStructuredOutputInfo(
+		"code_analysis",
+		"Analyze code",
+		map[string]any{
+			"language": map[string]any{
+				"type":        "string",
+				"description": "Programming language",
+			},
+			"complexity": map[string]any{
+				"type": "string",
+				"enum": []string{"low", "medium", "high"},
+			},
+		},
+		[]string{"language"},
+	)
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("unexpected output info:\n got %#v\nwant %#v", info, want)
+	}
+}
+
+func TestBuilder_NestedComposition(t *testing.T) {
+	point := schema.Object().
+		Field("x", schema.Number()).
+		Field("y", schema.Number()).
+		Required("x", "y")
+	built := schema.Object().
+		Field("points", schema.Array(point).Set("minItems", 1)).
+		Field("label", schema.String().Nullable()).
+		Field("value", schema.OneOf(schema.Integer(), schema.Boolean())).
+		Build()
+
+	assertClosed(t, "root", built)
+	points := path(built, "properties", "points")
+	if points["type"] != "array" || points["minItems"] != 1 {
+		t.Errorf("unexpected array schema: %v", points)
+	}
+	item := path(points, "items")
+	assertClosed(t, "point", item)
+	if !reflect.DeepEqual(item["required"], []string{"x", "y"}) {
+		t.Errorf("unexpected point required: %v", item["required"])
+	}
+	label := path(built, "properties", "label")
+	if !reflect.DeepEqual(label["type"], []string{"string", "null"}) {
+		t.Errorf("expected nullable label, got %v", label["type"])
+	}
+	value := path(built, "properties", "value")
+	want := []any{
+		map[string]any{"type": "integer"},
+		map[string]any{"type": "boolean"},
+	}
+	if !reflect.DeepEqual(value["oneOf"], want) {
+		t.Errorf("unexpected union: %v", value["oneOf"])
+	}
+	if _, ok := built["required"]; ok {
+		t.Error("expected no required list on root")
+	}
+}
+
+func TestBuilder_BuildReturnsFreshMaps(t *testing.T) {
+	b := schema.Object().Field("name", schema.String()).Required("name")
+	first := b.Build()
+	path(first, "properties", "name")["type"] = "integer"
+	first["required"].([]string)[0] = "changed"
+
+	second := b.Field("age", schema.Integer()).Build()
+	if path(second, "properties", "name")["type"] != "string" {
+		t.Error("expected builder unaffected by edits to built schema")
+	}
+	if len(path(second, "properties")) != 2 {
+		t.Errorf("expected field added after build, got %v", second)
+	}
+	if !reflect.DeepEqual(second["required"], []string{"name"}) {
+		t.Errorf("unexpected required: %v", second["required"])
+	}
+}
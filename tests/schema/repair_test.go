@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/schema"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "code fence",
+			raw:  "```json\n{\"a\": 1}\n```",
+			want: `{"a": 1}`,
+		},
+		{
+			name: "surrounding prose",
+			raw:  "Here you go:\n{\"a\": [1, 2]}\nLet me know!",
+			want: `{"a": [1, 2]}`,
+		},
+		{
+			name: "trailing commas",
+			raw:  `{"a": [1, 2,], "b": {"c": true,},}`,
+			want: `{"a": [1, 2], "b": {"c": true}}`,
+		},
+		{
+			name: "unquoted keys",
+			raw:  `{name: "Ada", age_years: 36, nested: {ok: true}}`,
+			want: `{"name": "Ada", "age_years": 36, "nested": {"ok": true}}`,
+		},
+		{
+			name: "single quotes",
+			raw:  `{'title': 'It\'s "fine"'}`,
+			want: `{"title": "It's \"fine\""}`,
+		},
+		{
+			name: "truncated",
+			raw:  `{"items": [{"id": 1}, {"id": 2, "note": "cut off`,
+			want: `{"items": [{"id": 1}, {"id": 2, "note": "cut off"}]}`,
+		},
+		{
+			name: "dangling colon",
+			raw:  `{"a": 1, "b":`,
+			want: `{"a": 1, "b": null}`,
+		},
+		{
+			name: "string content untouched",
+			raw:  `{"text": "a, } b: [c,]",}`,
+			want: `{"text": "a, } b: [c,]"}`,
+		},
+		{
+			name: "valid input unchanged",
+			raw:  `[{"a": null}]`,
+			want: `[{"a": null}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schema.Repair(tt.raw)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("repaired output is not valid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	type result struct {
+		Language string   `json:"language"`
+		Tags     []string `json:"tags"`
+	}
+	raw := "```json\n{language: 'go', tags: ['cli', 'web',],}\n```"
+
+	var plain result
+	if err := schema.Parse(raw, &plain); err == nil {
+		t.Fatal("expected error without auto repair")
+	}
+
+	var repaired result
+	err := schema.Parse(raw, &repaired, schema.WithAutoRepair())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := result{Language: "go", Tags: []string{"cli", "web"}}
+	if !reflect.DeepEqual(repaired, want) {
+		t.Errorf("got %+v, want %+v", repaired, want)
+	}
+
+	var broken result
+	err = schema.Parse(`{"language": go}`, &broken, schema.WithAutoRepair())
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected original syntax error, got %v", err)
+	}
+}
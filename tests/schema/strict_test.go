@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/schema"
+)
+
+const unionSchema = `{
+	"type": "object",
+	"properties": {
+		"title": {"type": "string"},
+		"note": {"type": "string", "enum": ["draft", "final"]},
+		"shape": {"oneOf": [
+			{"$ref": "#/$defs/circle"},
+			{"$ref": "#/$defs/polygon"}
+		]}
+	},
+	"required": ["title", "shape"],
+	"$defs": {
+		"circle": {
+			"type": "object",
+			"properties": {
+				"kind": {"const": "circle"},
+				"radius": {"type": "number"}
+			},
+			"required": ["kind", "radius"]
+		},
+		"polygon": {
+			"type": "object",
+			"properties": {
+				"kind": {"const": "polygon"},
+				"points": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"x": {"type": "number"},
+							"y": {"type": "number"},
+							"label": {"type": "string"}
+						},
+						"required": ["x", "y"]
+					}
+				}
+			},
+			"required": ["kind", "points"]
+		}
+	}
+}`
+
+func decode(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	return out
+}
+
+func path(node map[string]any, keys ...string) map[string]any {
+	for _, key := range keys {
+		node = node[key].(map[string]any)
+	}
+	return node
+}
+
+func assertClosed(t *testing.T, name string, node map[string]any) {
+	t.Helper()
+	if node["additionalProperties"] != false {
+		t.Errorf("%s: expected additionalProperties false, got %v",
+			name, node["additionalProperties"])
+	}
+}
+
+func TestStrict_NestedUnion(t *testing.T) {
+	original := decode(t, unionSchema)
+	info := schema.NewStructuredOutputFromSchema("drawing", "", original)
+
+	strict := info.StrictJSONSchema()
+
+	assertClosed(t, "root", strict)
+	circle := path(strict, "$defs", "circle")
+	assertClosed(t, "circle", circle)
+	point := path(strict, "$defs", "polygon", "properties", "points", "items")
+	assertClosed(t, "point", point)
+
+	if !reflect.DeepEqual(point["required"], []string{"label", "x", "y"}) {
+		t.Errorf("expected every point property required, got %v",
+			point["required"])
+	}
+	label := path(point, "properties", "label")
+	if !reflect.DeepEqual(label["type"], []any{"string", "null"}) {
+		t.Errorf("expected optional label nullable, got %v", label["type"])
+	}
+	note := path(strict, "properties", "note")
+	if !reflect.DeepEqual(note["enum"], []any{"draft", "final", nil}) {
+		t.Errorf("expected null allowed in enum, got %v", note["enum"])
+	}
+
+	shape := path(strict, "properties", "shape")
+	if _, ok := shape["oneOf"]; ok {
+		t.Error("expected oneOf rewritten")
+	}
+	if branches, ok := shape["anyOf"].([]any); !ok || len(branches) != 2 {
+		t.Errorf("expected two anyOf branches, got %v", shape["anyOf"])
+	}
+
+	if !reflect.DeepEqual(original, decode(t, unionSchema)) {
+		t.Error("expected the input schema to be left unchanged")
+	}
+}
+
+func TestStrict_OptionalRefWrappedNullable(t *testing.T) {
+	strict := schema.Strict(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"owner": map[string]any{"$ref": "#/$defs/person"},
+		},
+	})
+
+	owner := path(strict, "properties", "owner")
+	want := []any{
+		map[string]any{"$ref": "#/$defs/person"},
+		map[string]any{"type": "null"},
+	}
+	if !reflect.DeepEqual(owner["anyOf"], want) {
+		t.Errorf("expected $ref wrapped with null branch, got %v", owner)
+	}
+}
+
+func TestStrict_GeneratedSchemaUnchangedSemantics(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Person struct {
+		Name    string   `json:"name"`
+		Age     *int     `json:"age"`
+		Address Address  `json:"address"`
+		Tags    []string `json:"tags,omitempty"`
+	}
+	info := schema.NewStructuredOutputFromStruct("person", "", Person{})
+
+	strict := info.StrictJSONSchema()
+
+	assertClosed(t, "root", strict)
+	assertClosed(t, "address", path(strict, "properties", "address"))
+	if !reflect.DeepEqual(
+		strict["required"],
+		[]string{"address", "age", "name", "tags"},
+	) {
+		t.Errorf("unexpected required list: %v", strict["required"])
+	}
+	age := path(strict, "properties", "age")
+	if !reflect.DeepEqual(age["type"], []string{"integer", "null"}) {
+		t.Errorf("expected generated nullable type kept, got %v", age["type"])
+	}
+}
+
+func TestCloseObjects_KeepsOptionalProperties(t *testing.T) {
+	closed := schema.CloseObjects(decode(t, unionSchema))
+
+	point := path(closed, "$defs", "polygon", "properties", "points", "items")
+	assertClosed(t, "point", point)
+	if !reflect.DeepEqual(point["required"], []any{"x", "y"}) {
+		t.Errorf("expected required list untouched, got %v", point["required"])
+	}
+	if _, ok := path(closed, "properties", "shape")["oneOf"]; !ok {
+		t.Error("expected oneOf preserved")
+	}
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func largeSession() []message.Message {
+	output := strings.Repeat("row,value,status,ok\n", 20_000)
+	msgs := []message.Message{message.NewUserMessage("export the table")}
+	for i := range 5 {
+		msgs = append(msgs,
+			message.NewMessage(message.Tool, []message.ContentPart{
+				message.ToolResult{
+					ToolCallID: "call-" + string(rune('a'+i)),
+					Name:       "export",
+					Content:    output,
+				},
+			}),
+		)
+	}
+	return msgs
+}
+
+func TestFileStore_CompressionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := session.FileStore(dir, session.WithCompression())
+
+	s, err := store.Create(ctx, "big")
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+
+	msgs := largeSession()
+	if err := s.AddMessages(ctx, msgs); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "big.json"))
+	if err != nil {
+		t.Fatalf("stat error: %v", err)
+	}
+	rawSize := len(msgs[1].ToolResults()[0].Content) * 5
+	if info.Size() >= int64(rawSize/10) {
+		t.Errorf("expected compressed file well under %d bytes, got %d",
+			rawSize, info.Size())
+	}
+
+	loaded, err := session.FileStore(dir, session.WithCompression()).
+		Load(ctx, "big")
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	got, err := loaded.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if len(got) != len(msgs) {
+		t.Fatalf("expected %d messages, got %d", len(msgs), len(got))
+	}
+	for i := 1; i < len(msgs); i++ {
+		want := msgs[i].ToolResults()[0]
+		have := got[i].ToolResults()
+		if len(have) != 1 || !reflect.DeepEqual(have[0], want) {
+			t.Errorf("message %d did not round-trip", i)
+		}
+	}
+
+	popped, err := loaded.PopMessage(ctx)
+	if err != nil || popped == nil {
+		t.Fatalf("pop error: %v", err)
+	}
+	remaining, _ := loaded.GetMessages(ctx, nil)
+	if len(remaining) != len(msgs)-1 {
+		t.Errorf("expected %d messages after pop, got %d",
+			len(msgs)-1, len(remaining))
+	}
+}
+
+func TestFileStore_CompressionReadsUncompressedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	plain, _ := session.FileStore(dir).Create(ctx, "legacy")
+	if err := plain.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("before"),
+	}); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	compressed, _ := session.FileStore(dir, session.WithCompression()).
+		Load(ctx, "legacy")
+	if err := compressed.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("after"),
+	}); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	got, err := session.FileStore(dir).Load(ctx, "legacy")
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	msgs, err := got.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatalf("uncompressed store must read compressed file: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Content().Text != "before" ||
+		msgs[1].Content().Text != "after" {
+		t.Errorf("unexpected messages: %+v", msgs)
+	}
+}
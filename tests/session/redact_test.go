@@ -0,0 +1,155 @@
+package session
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/message"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestRegexRedactor_DefaultRules(t *testing.T) {
+	redact := session.RegexRedactor()
+
+	msg := message.NewUserMessage(
+		"mail jane.doe@example.com, card 4111 1111 1111 1111, " +
+			"key sk-ant-REDACTED, order 1234567890123",
+	)
+	redacted := redact(msg)
+	got := redacted.Content().Text
+
+	for _, secret := range []string{
+		"jane.doe@example.com",
+		"4111 1111 1111 1111",
+		"sk-ant-REDACTED",
+	} {
+		if strings.Contains(got, secret) {
+			t.Errorf("expected %q to be redacted, got %q", secret, got)
+		}
+	}
+	for _, marker := range []string{
+		"[REDACTED_EMAIL]",
+		"[REDACTED_CREDIT_CARD]",
+		"[REDACTED_API_KEY]",
+	} {
+		if !strings.Contains(got, marker) {
+			t.Errorf("expected %q in %q", marker, got)
+		}
+	}
+	if !strings.Contains(got, "order 1234567890123") {
+		t.Errorf("expected non-Luhn number to be kept, got %q", got)
+	}
+	if msg.Content().Text == got {
+		t.Error("expected redactor not to mutate the original message")
+	}
+}
+
+func TestRegexRedactor_ToolPartsAndCustomRule(t *testing.T) {
+	ssn := session.RedactionRule{
+		Name:        "ssn",
+		Pattern:     regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		Replacement: "[SSN]",
+	}
+	redact := session.RegexRedactor(ssn)
+
+	call := message.NewMessage(message.Assistant, []message.ContentPart{
+		message.ToolCall{
+			ID:    "1",
+			Name:  "lookup",
+			Input: `{"ssn":"123-45-6789"}`,
+		},
+	})
+	result := message.NewMessage(message.Tool, []message.ContentPart{
+		message.ToolResult{ToolCallID: "1", Content: "found 123-45-6789"},
+	})
+
+	redactedCall := redact(call)
+	if in := redactedCall.ToolCalls()[0].Input; in != `{"ssn":"[SSN]"}` {
+		t.Errorf("unexpected tool call input %q", in)
+	}
+	redactedResult := redact(result)
+	if out := redactedResult.ToolResults()[0].Content; out != "found [SSN]" {
+		t.Errorf("unexpected tool result %q", out)
+	}
+}
+
+func TestWithRedactor_RedactsOnWrite(t *testing.T) {
+	ctx := context.Background()
+	inner := session.MemoryStore()
+	store := session.WithRedactor(inner, session.RegexRedactor())
+
+	sess, err := store.Create(ctx, "s1")
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	if err := sess.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("reach me at bob@example.com"),
+	}); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	raw, err := inner.Load(ctx, "s1")
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	msgs, err := raw.GetMessages(ctx, nil)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if len(msgs) != 1 ||
+		msgs[0].Content().Text != "reach me at [REDACTED_EMAIL]" {
+		t.Errorf("expected redacted message in backend, got %+v", msgs)
+	}
+}
+
+func TestWithRedactor_OnRead(t *testing.T) {
+	ctx := context.Background()
+	inner := session.MemoryStore()
+	raw, _ := inner.Create(ctx, "s1")
+	_ = raw.AddMessages(ctx, []message.Message{
+		message.NewUserMessage("legacy bob@example.com"),
+	})
+
+	plain := session.WithRedactor(inner, session.RegexRedactor())
+	sess, _ := plain.Load(ctx, "s1")
+	msgs, _ := sess.GetMessages(ctx, nil)
+	if msgs[0].Content().Text != "legacy bob@example.com" {
+		t.Errorf("expected reads to pass through, got %q",
+			msgs[0].Content().Text)
+	}
+
+	onRead := session.WithRedactor(
+		inner,
+		session.RegexRedactor(),
+		session.RedactOnRead(),
+	)
+	sess, _ = onRead.Load(ctx, "s1")
+	msgs, _ = sess.GetMessages(ctx, nil)
+	if msgs[0].Content().Text != "legacy [REDACTED_EMAIL]" {
+		t.Errorf("expected redacted read, got %q", msgs[0].Content().Text)
+	}
+
+	popped, err := sess.PopMessage(ctx)
+	if err != nil || popped == nil {
+		t.Fatalf("pop error: %v", err)
+	}
+	if popped.Content().Text != "legacy [REDACTED_EMAIL]" {
+		t.Errorf("expected redacted pop, got %q", popped.Content().Text)
+	}
+}
+
+func TestWithRedactor_LoadMissingSession(t *testing.T) {
+	store := session.WithRedactor(
+		session.MemoryStore(),
+		session.RegexRedactor(),
+	)
+	sess, err := store.Load(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sess != nil {
+		t.Error("expected nil session for missing id")
+	}
+}
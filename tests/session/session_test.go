@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/joakimcarlsson/ai/message"
@@ -305,6 +306,52 @@ func TestFileSession_PersistsAcrossLoads(t *testing.T) {
 	}
 }
 
+func TestFileSession_HTMLAndCodeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	code := "<div class=\"x\">a && b</div>\nif x < 1 && y > 2 {}"
+
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		var store session.Store
+		if compress {
+			store = session.FileStore(dir, session.WithCompression())
+		} else {
+			store = session.FileStore(dir)
+		}
+
+		s, _ := store.Create(ctx, "s1")
+		err := s.AddMessages(ctx, []message.Message{
+			message.NewUserMessage(code),
+			message.NewMessage(message.Tool, []message.ContentPart{
+				message.ToolResult{ToolCallID: "1", Content: code},
+			}),
+		})
+		if err != nil {
+			t.Fatalf("add error: %v", err)
+		}
+
+		if !compress {
+			raw, err := os.ReadFile(filepath.Join(dir, "s1.json"))
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+			if strings.Contains(string(raw), `\u00`) {
+				t.Errorf("expected unescaped file, got %s", raw)
+			}
+		}
+
+		loaded, _ := store.Load(ctx, "s1")
+		got, err := loaded.GetMessages(ctx, nil)
+		if err != nil {
+			t.Fatalf("get error: %v", err)
+		}
+		if got[0].Content().Text != code ||
+			got[1].ToolResults()[0].Content != code {
+			t.Errorf("compress=%v: content did not round-trip", compress)
+		}
+	}
+}
+
 func TestFileSession_GetMessagesWithLimit(t *testing.T) {
 	ctx := context.Background()
 	store := session.FileStore(t.TempDir())
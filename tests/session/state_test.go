@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestSaveState_RoundTrip(t *testing.T) {
+	stores := map[string]session.Store{
+		"memory": session.MemoryStore(),
+		"file":   session.FileStore(t.TempDir()),
+		"redacting": session.WithRedactor(
+			session.MemoryStore(),
+			session.RegexRedactor(),
+		),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			state, err := session.LoadState(ctx, store, "conv")
+			if err != nil || state != nil {
+				t.Fatalf("expected no state yet, got %v, %v", state, err)
+			}
+
+			for _, count := range []int{1, 2} {
+				err := session.SaveState(ctx, store, "conv", map[string]any{
+					"count": count,
+					"prefs": map[string]any{"theme": "dark"},
+				})
+				if err != nil {
+					t.Fatalf("SaveState failed: %v", err)
+				}
+			}
+
+			state, err = session.LoadState(ctx, store, "conv")
+			if err != nil {
+				t.Fatalf("LoadState failed: %v", err)
+			}
+			want := map[string]any{
+				"count": float64(2),
+				"prefs": map[string]any{"theme": "dark"},
+			}
+			if !reflect.DeepEqual(state, want) {
+				t.Errorf("got %v, want %v", state, want)
+			}
+			exists, _ := store.Exists(ctx, "conv")
+			if exists {
+				t.Error("expected state stored apart from the session itself")
+			}
+		})
+	}
+}
+
+func TestSaveState_NotRedacted(t *testing.T) {
+	ctx := context.Background()
+	store := session.WithRedactor(
+		session.MemoryStore(),
+		session.RegexRedactor(),
+	)
+	saved := map[string]any{"email": "jane@example.com"}
+	if err := session.SaveState(ctx, store, "conv", saved); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	state, err := session.LoadState(ctx, store, "conv")
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if !reflect.DeepEqual(state, saved) {
+		t.Errorf("got %v, want %v", state, saved)
+	}
+}
+
+func TestDelete_RemovesReservedRecords(t *testing.T) {
+	stores := map[string]session.Store{
+		"memory": session.MemoryStore(),
+		"file":   session.FileStore(t.TempDir()),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.Create(ctx, "conv"); err != nil {
+				t.Fatal(err)
+			}
+			err := session.SaveState(ctx, store, "conv", map[string]any{
+				"count": 1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, "conv"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			for _, id := range session.ReservedIDs("conv") {
+				if exists, _ := store.Exists(ctx, id); exists {
+					t.Errorf("%s left behind after Delete", id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/session"
+)
+
+func TestRecordUsage_SumsCalls(t *testing.T) {
+	stores := map[string]session.Store{
+		"memory": session.MemoryStore(),
+		"file":   session.FileStore(t.TempDir()),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			usage, err := session.LoadUsage(ctx, store, "conv")
+			if err != nil || usage != (session.Usage{}) {
+				t.Fatalf("expected no usage yet, got %+v, %v", usage, err)
+			}
+
+			calls := []struct {
+				model model.ID
+				usage session.Usage
+			}{
+				{"gpt-4o", session.Usage{
+					InputTokens:  100,
+					OutputTokens: 20,
+					Cost:         0.01,
+				}},
+				{"gpt-4o", session.Usage{
+					InputTokens:     50,
+					OutputTokens:    5,
+					CacheReadTokens: 30,
+					Cost:            0.002,
+				}},
+				{"claude-4.5-haiku", session.Usage{
+					InputTokens:  10,
+					OutputTokens: 1,
+					Cost:         0.001,
+				}},
+			}
+			for _, c := range calls {
+				if err := session.RecordUsage(
+					ctx, store, "conv", c.model, c.usage,
+				); err != nil {
+					t.Fatalf("RecordUsage failed: %v", err)
+				}
+			}
+
+			usage, err = session.LoadUsage(ctx, store, "conv")
+			if err != nil {
+				t.Fatalf("LoadUsage failed: %v", err)
+			}
+			if usage.InputTokens != 160 || usage.OutputTokens != 26 ||
+				usage.CacheReadTokens != 30 || usage.Calls != 3 ||
+				math.Abs(usage.Cost-0.013) > 1e-9 {
+				t.Errorf("unexpected total: %+v", usage)
+			}
+
+			byModel, err := session.LoadUsageByModel(ctx, store, "conv")
+			if err != nil {
+				t.Fatalf("LoadUsageByModel failed: %v", err)
+			}
+			if byModel["gpt-4o"].Calls != 2 ||
+				byModel["claude-4.5-haiku"].InputTokens != 10 {
+				t.Errorf("unexpected per-model totals: %+v", byModel)
+			}
+
+			if err := session.ResetUsage(ctx, store, "conv"); err != nil {
+				t.Fatalf("ResetUsage failed: %v", err)
+			}
+			usage, err = session.LoadUsage(ctx, store, "conv")
+			if err != nil || usage != (session.Usage{}) {
+				t.Errorf("expected usage reset, got %+v, %v", usage, err)
+			}
+		})
+	}
+}
+
+func TestRecordUsage_ConcurrentFirstCalls(t *testing.T) {
+	ctx := context.Background()
+	store := slowExistsStore{Store: session.MemoryStore()}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := session.RecordUsage(ctx, store, "conv", "gpt-4o",
+				session.Usage{InputTokens: 1})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	usage, err := session.LoadUsage(ctx, store, "conv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Calls != 20 || usage.InputTokens != 20 {
+		t.Errorf("usage = %+v, want 20 calls", usage)
+	}
+}
+
+type slowExistsStore struct {
+	session.Store
+}
+
+func (s slowExistsStore) Exists(ctx context.Context, id string) (bool, error) {
+	exists, err := s.Store.Exists(ctx, id)
+	time.Sleep(5 * time.Millisecond)
+	return exists, err
+}
@@ -0,0 +1,234 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/stt"
+)
+
+const testSampleRate = 8000
+
+func makeWAV(t *testing.T, samples []int16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	data := len(samples) * 2
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+data))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(testSampleRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(testSampleRate*2))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(2))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(data))
+	_ = binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+func tone(seconds float64) []int16 {
+	n := int(seconds * testSampleRate)
+	out := make([]int16, n)
+	for i := range out {
+		out[i] = int16(8000 * math.Sin(float64(i)*0.3))
+	}
+	return out
+}
+
+func silence(seconds float64) []int16 {
+	return make([]int16, int(seconds*testSampleRate))
+}
+
+func concat(parts ...[]int16) []int16 {
+	var out []int16
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+type secondsSTT struct {
+	chunks [][]byte
+}
+
+func (s *secondsSTT) Transcribe(
+	_ context.Context,
+	audio []byte,
+	_ ...stt.Option,
+) (*stt.Response, error) {
+	s.chunks = append(s.chunks, audio)
+	duration := float64(len(audio)-44) / (testSampleRate * 2)
+	resp := &stt.Response{
+		Language: "en",
+		Duration: duration,
+		Usage:    stt.Usage{DurationSec: duration},
+	}
+	for sec := 0; float64(sec)+1 <= duration+1e-9; sec++ {
+		resp.Segments = append(resp.Segments, stt.Segment{
+			Start: float64(sec),
+			End:   float64(sec) + 1,
+			Text:  "s",
+		})
+	}
+	return resp, nil
+}
+
+func (s *secondsSTT) Translate(
+	ctx context.Context,
+	audio []byte,
+	opts ...stt.Option,
+) (*stt.Response, error) {
+	return s.Transcribe(ctx, audio, opts...)
+}
+
+func (s *secondsSTT) StreamTranscribe(
+	context.Context,
+	<-chan []byte,
+	...stt.Option,
+) (<-chan stt.StreamResult, error) {
+	return nil, stt.ErrStreamingNotSupported
+}
+
+func (s *secondsSTT) SupportsStreaming() bool { return false }
+
+func (s *secondsSTT) Model() model.TranscriptionModel {
+	return model.TranscriptionModel{}
+}
+
+func TestWithChunking_PassesSmallAudioThrough(t *testing.T) {
+	inner := &secondsSTT{}
+	client := stt.WithChunking(inner, 1<<20, time.Second)
+	audio := makeWAV(t, tone(2))
+
+	if _, err := client.Transcribe(context.Background(), audio); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inner.chunks) != 1 || !bytes.Equal(inner.chunks[0], audio) {
+		t.Fatalf("expected audio passed through unchanged")
+	}
+}
+
+func TestWithChunking_StitchesSegmentsWithOffsets(t *testing.T) {
+	inner := &secondsSTT{}
+	maxBytes := 44 + 4*testSampleRate*2
+	client := stt.WithChunking(inner, maxBytes, time.Second)
+	audio := makeWAV(t, tone(10))
+
+	resp, err := client.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(inner.chunks) < 3 {
+		t.Fatalf("expected audio to be split, got %d chunks", len(inner.chunks))
+	}
+	for i, chunk := range inner.chunks {
+		if len(chunk) > maxBytes {
+			t.Errorf("chunk %d is %d bytes, over %d", i, len(chunk), maxBytes)
+		}
+	}
+	if math.Abs(resp.Duration-10) > 0.01 {
+		t.Errorf("expected duration 10s, got %v", resp.Duration)
+	}
+
+	prevEnd := 0.0
+	for i, seg := range resp.Segments {
+		if seg.ID != i {
+			t.Errorf("expected segment ID %d, got %d", i, seg.ID)
+		}
+		if seg.Start < prevEnd-0.5 {
+			t.Errorf("segment %d at %v overlaps previous end %v",
+				i, seg.Start, prevEnd)
+		}
+		prevEnd = seg.End
+	}
+	if prevEnd < 9 {
+		t.Errorf("expected segments to reach the end, last ends at %v", prevEnd)
+	}
+	if resp.Language != "en" {
+		t.Errorf("expected language en, got %q", resp.Language)
+	}
+}
+
+func TestWAVSplitter_CutsAtSilence(t *testing.T) {
+	samples := concat(tone(2.5), silence(0.4), tone(1))
+	audio := makeWAV(t, samples)
+	maxBytes := 44 + 3*testSampleRate*2
+
+	chunks, err := stt.WAVSplitter(audio, maxBytes, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[0].End < 2.5 || chunks[0].End > 2.9 {
+		t.Errorf("expected cut inside the silence, got %v", chunks[0].End)
+	}
+	if chunks[1].Start != chunks[0].End {
+		t.Errorf("expected contiguous chunks without overlap, got %v and %v",
+			chunks[0].End, chunks[1].Start)
+	}
+}
+
+func TestWAVSplitter_RejectsNonWAV(t *testing.T) {
+	_, err := stt.WAVSplitter([]byte("ID3\x03not a wav file"), 8, 0)
+	if !errors.Is(err, stt.ErrUnsupportedAudioFormat) {
+		t.Fatalf("expected ErrUnsupportedAudioFormat, got %v", err)
+	}
+}
+
+func TestWithChunking_MergesOverlappingText(t *testing.T) {
+	calls := 0
+	texts := []string{
+		"the quick brown fox jumps",
+		"fox jumps over the lazy dog",
+	}
+	splitter := func([]byte, int, time.Duration) ([]stt.AudioChunk, error) {
+		return []stt.AudioChunk{
+			{Audio: []byte("a"), Start: 0, End: 3},
+			{Audio: []byte("b"), Start: 2, End: 5},
+		}, nil
+	}
+	inner := &textSTT{texts: texts, calls: &calls}
+	client := stt.WithChunking(
+		inner,
+		1,
+		time.Second,
+		stt.WithSplitter(splitter),
+	)
+
+	resp, err := client.Transcribe(context.Background(), []byte("large"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "the quick brown fox jumps over the lazy dog"
+	if resp.Text != want {
+		t.Errorf("expected %q, got %q", want, resp.Text)
+	}
+}
+
+type textSTT struct {
+	secondsSTT
+	texts []string
+	calls *int
+}
+
+func (s *textSTT) Transcribe(
+	context.Context,
+	[]byte,
+	...stt.Option,
+) (*stt.Response, error) {
+	text := s.texts[*s.calls]
+	*s.calls++
+	return &stt.Response{Text: text}, nil
+}
@@ -0,0 +1,63 @@
+package stt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/stt"
+)
+
+func TestCheckDiarization(t *testing.T) {
+	var opts stt.Options
+	stt.WithDiarization()(&opts)
+
+	plain := model.TranscriptionModel{APIModel: "whisper-1"}
+	if err := stt.CheckDiarization(plain, opts); !errors.Is(
+		err,
+		stt.ErrDiarizationNotSupported,
+	) {
+		t.Errorf("expected ErrDiarizationNotSupported, got %v", err)
+	}
+
+	diarize := model.TranscriptionModel{SupportsDiarization: true}
+	if err := stt.CheckDiarization(diarize, opts); err != nil {
+		t.Errorf("unexpected error for diarizing model: %v", err)
+	}
+	if err := stt.CheckDiarization(plain, stt.Options{}); err != nil {
+		t.Errorf("unexpected error without diarization: %v", err)
+	}
+}
+
+func TestSegmentsBySpeaker(t *testing.T) {
+	words := []stt.Word{
+		{Word: "hi", Start: 0, End: 0.4, Speaker: "A"},
+		{Word: "there", Start: 0.4, End: 0.8, Speaker: "A"},
+		{Word: "hello", Start: 1, End: 1.5, Speaker: "B"},
+		{Word: "again", Start: 2, End: 2.5, Speaker: "A"},
+	}
+
+	segments := stt.SegmentsBySpeaker(words)
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %+v", segments)
+	}
+	want := []stt.Segment{
+		{ID: 0, Start: 0, End: 0.8, Text: "hi there", Speaker: "A"},
+		{ID: 1, Start: 1, End: 1.5, Text: "hello", Speaker: "B"},
+		{ID: 2, Start: 2, End: 2.5, Text: "again", Speaker: "A"},
+	}
+	for i, seg := range segments {
+		w := want[i]
+		if seg.ID != w.ID || seg.Start != w.Start || seg.End != w.End ||
+			seg.Text != w.Text || seg.Speaker != w.Speaker {
+			t.Errorf("segment %d: expected %+v, got %+v", i, w, seg)
+		}
+	}
+}
+
+func TestSegmentsBySpeaker_Unlabelled(t *testing.T) {
+	words := []stt.Word{{Word: "hi", End: 1}, {Word: "there", End: 2}}
+	if segments := stt.SegmentsBySpeaker(words); segments != nil {
+		t.Errorf("expected nil segments, got %+v", segments)
+	}
+}
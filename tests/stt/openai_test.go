@@ -0,0 +1,95 @@
+package stt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/stt"
+	sttopenai "github.com/joakimcarlsson/ai/stt/openai"
+)
+
+func translationServer(
+	t *testing.T,
+	wantFormat string,
+	body string,
+) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/audio/translations" {
+				t.Errorf("path = %s, want /audio/translations", r.URL.Path)
+			}
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatal(err)
+			}
+			if got := r.FormValue("response_format"); got != wantFormat {
+				t.Errorf("response_format = %q, want %q", got, wantFormat)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		},
+	))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAI_TranslateReturnsVerboseSegments(t *testing.T) {
+	srv := translationServer(t, "verbose_json", `{
+		"text": "Hello there.",
+		"language": "swedish",
+		"duration": 2.5,
+		"segments": [
+			{"id": 0, "start": 0, "end": 1.2, "text": "Hello", "avg_logprob": -0.2},
+			{"id": 1, "start": 1.2, "end": 2.5, "text": " there."}
+		]
+	}`)
+
+	client := sttopenai.NewSpeechToText(
+		sttopenai.WithAPIKey("key"),
+		sttopenai.WithBaseURL(srv.URL),
+		sttopenai.WithModel(model.TranscriptionModel{APIModel: "whisper-1"}),
+	)
+	resp, err := client.Translate(context.Background(), []byte("audio"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Text != "Hello there." || resp.Language != "swedish" ||
+		resp.Duration != 2.5 {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(resp.Segments) != 2 {
+		t.Fatalf("segments = %+v, want 2", resp.Segments)
+	}
+	first, second := resp.Segments[0], resp.Segments[1]
+	if first.Text != "Hello" || first.End != 1.2 || first.AvgLogprob != -0.2 {
+		t.Errorf("first segment = %+v", first)
+	}
+	if second.ID != 1 || second.Start != 1.2 || second.Text != " there." {
+		t.Errorf("second segment = %+v", second)
+	}
+}
+
+func TestOpenAI_TranslateHonorsResponseFormat(t *testing.T) {
+	srv := translationServer(t, "json", `{"text": "Hello there."}`)
+
+	client := sttopenai.NewSpeechToText(
+		sttopenai.WithAPIKey("key"),
+		sttopenai.WithBaseURL(srv.URL),
+		sttopenai.WithModel(model.TranscriptionModel{APIModel: "whisper-1"}),
+	)
+	resp, err := client.Translate(
+		context.Background(),
+		[]byte("audio"),
+		stt.WithResponseFormat("json"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "Hello there." || len(resp.Segments) != 0 {
+		t.Errorf("response = %+v", resp)
+	}
+}
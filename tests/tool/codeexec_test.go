@@ -0,0 +1,222 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/tool/codeexec"
+)
+
+func runCode(
+	t *testing.T,
+	ct *codeexec.Tool,
+	input string,
+) tool.Response {
+	t.Helper()
+	resp, err := ct.Run(context.Background(), tool.Call{Input: input})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return resp
+}
+
+func localTool(t *testing.T, opts ...codeexec.Option) *codeexec.Tool {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no POSIX shell")
+	}
+	opts = append(opts, codeexec.AllowUnsandboxed())
+	ct, err := codeexec.New(codeexec.NewLocalBackend(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ct
+}
+
+func TestCodeExec_RefusesUnsandboxedByDefault(t *testing.T) {
+	_, err := codeexec.New(codeexec.NewLocalBackend())
+	if !errors.Is(err, codeexec.ErrUnsandboxed) {
+		t.Fatalf("err = %v, want ErrUnsandboxed", err)
+	}
+	if _, err := codeexec.New(nil); err == nil {
+		t.Error("expected error for nil backend")
+	}
+	if _, err := codeexec.New(codeexec.NewDockerBackend()); err != nil {
+		t.Errorf("docker backend refused: %v", err)
+	}
+}
+
+func TestCodeExec_LocalShell(t *testing.T) {
+	ct := localTool(t)
+	resp := runCode(t, ct, `{"language":"shell",`+
+		`"code":"echo hello; echo oops >&2; echo $HOME_CHECK; exit 3"}`)
+
+	if !resp.IsError {
+		t.Error("non-zero exit not reported as error")
+	}
+	for _, want := range []string{
+		"exit code: 3",
+		"stdout:\nhello",
+		"stderr:\noops",
+	} {
+		if !strings.Contains(resp.Content, want) {
+			t.Errorf("content missing %q:\n%s", want, resp.Content)
+		}
+	}
+}
+
+func TestCodeExec_LocalEmptyEnvironment(t *testing.T) {
+	t.Setenv("CODEEXEC_SECRET", "leaked")
+	ct := localTool(t)
+	resp := runCode(t, ct, `{"language":"shell",`+
+		`"code":"echo secret=$CODEEXEC_SECRET; pwd"}`)
+
+	if resp.IsError || !strings.Contains(resp.Content, "secret=\n") {
+		t.Errorf("host environment leaked:\n%s", resp.Content)
+	}
+}
+
+func TestCodeExec_LocalPython(t *testing.T) {
+	if _, err := os.Stat("/usr/bin/python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	ct := localTool(t)
+	resp := runCode(t, ct, `{"language":"python","code":"print(6*7)"}`)
+	if resp.IsError || !strings.Contains(resp.Content, "42") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestCodeExec_Timeout(t *testing.T) {
+	ct := localTool(t, codeexec.WithTimeout(200*time.Millisecond))
+	start := time.Now()
+	resp := runCode(t, ct, `{"language":"shell","code":"sleep 5"}`)
+
+	if !resp.IsError || !strings.Contains(resp.Content, "timed out") {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("run took %s after timeout", elapsed)
+	}
+}
+
+func TestCodeExec_OutputTruncated(t *testing.T) {
+	ct := localTool(t, codeexec.WithMaxOutput(10))
+	resp := runCode(t, ct, `{"language":"shell",`+
+		`"code":"echo 0123456789abcdef"}`)
+	if !strings.Contains(resp.Content, "stdout:\n0123456789\n") ||
+		!strings.Contains(resp.Content, "[output truncated]") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestCodeExec_LanguageRestricted(t *testing.T) {
+	ct := localTool(t, codeexec.WithLanguages(codeexec.LanguagePython))
+	resp := runCode(t, ct, `{"language":"shell","code":"echo hi"}`)
+	if !resp.IsError || !strings.Contains(resp.Content, "unsupported") {
+		t.Errorf("content = %q", resp.Content)
+	}
+	enum := ct.Info().Parameters["language"].(map[string]any)["enum"]
+	if got := enum.([]string); len(got) != 1 || got[0] != "python" {
+		t.Errorf("language enum = %v", got)
+	}
+}
+
+func TestCodeExec_DockerArguments(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no POSIX shell")
+	}
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	fake := filepath.Join(dir, "docker")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile +
+		"\ncat\n"
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := codeexec.New(codeexec.NewDockerBackend(
+		codeexec.WithDockerPath(fake),
+		codeexec.WithImage("sandbox:latest"),
+		codeexec.WithMemoryLimit(64<<20),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runCode(t, ct, `{"language":"python","code":"print(1)"}`)
+	if resp.IsError || !strings.Contains(resp.Content, "print(1)") {
+		t.Errorf("code not passed on stdin: %q", resp.Content)
+	}
+
+	raw, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := string(raw)
+	for _, want := range []string{
+		"run\n--rm\n-i\n",
+		"--network\nnone\n",
+		"--memory\n67108864\n",
+		"--read-only\n",
+		"--cap-drop\nALL\n",
+		"sandbox:latest\npython3\n-\n",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("docker args missing %q:\n%s", want, args)
+		}
+	}
+}
+
+func TestCodeExec_DescriptionMatchesIsolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend codeexec.Backend
+		want    string
+		notWant []string
+	}{
+		{
+			name:    "docker",
+			backend: codeexec.NewDockerBackend(),
+			want: "with no network access and no files kept " +
+				"between runs;",
+		},
+		{
+			name: "docker with network",
+			backend: codeexec.NewDockerBackend(
+				codeexec.WithNetwork("bridge"),
+			),
+			want:    "with no files kept between runs;",
+			notWant: []string{"network"},
+		},
+		{
+			name:    "local",
+			backend: codeexec.NewLocalBackend(),
+			want:    "Runs are limited to 30s; print",
+			notWant: []string{"network", "files"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, err := codeexec.New(tt.backend, codeexec.AllowUnsandboxed())
+			if err != nil {
+				t.Fatal(err)
+			}
+			desc := ct.Info().Description
+			if !strings.Contains(desc, tt.want) {
+				t.Errorf("description %q missing %q", desc, tt.want)
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(desc, s) {
+					t.Errorf("description %q mentions %q", desc, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/tool/fetch"
+)
+
+func runFetch(t *testing.T, f *fetch.Tool, input string) tool.Response {
+	t.Helper()
+	resp, err := f.Run(context.Background(), tool.Call{Input: input})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return resp
+}
+
+func localFetch(t *testing.T, opts ...fetch.Option) *fetch.Tool {
+	t.Helper()
+	opts = append(
+		opts,
+		fetch.WithAllowedDomains("127.0.0.1"),
+		fetch.AllowPrivateNetworks(),
+	)
+	f, err := fetch.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFetch_RequiresAllowlist(t *testing.T) {
+	if _, err := fetch.New(); !errors.Is(err, fetch.ErrNoAllowlist) {
+		t.Errorf("err = %v, want ErrNoAllowlist", err)
+	}
+	if _, err := fetch.New(fetch.AllowAnyDomain()); err != nil {
+		t.Errorf("AllowAnyDomain: %v", err)
+	}
+}
+
+func TestFetch_RefusesUnscopedHeadersForAnyDomain(t *testing.T) {
+	secret := map[string]string{"Authorization": "Bearer secret"}
+	_, err := fetch.New(fetch.AllowAnyDomain(), fetch.WithHeaders(secret))
+	if !errors.Is(err, fetch.ErrUnscopedHeaders) {
+		t.Errorf("err = %v, want ErrUnscopedHeaders", err)
+	}
+	if _, err := fetch.New(
+		fetch.AllowAnyDomain(),
+		fetch.WithDomainHeaders("api.example.com", secret),
+	); err != nil {
+		t.Errorf("WithDomainHeaders: %v", err)
+	}
+}
+
+func TestFetch_DomainHeadersStayInScope(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Host+"="+r.Header.Get("X-Api-Key"))
+			if r.URL.Path == "/start" {
+				u := "http://" + strings.Replace(
+					r.Host,
+					"127.0.0.1",
+					"localhost",
+					1,
+				) + "/next"
+				http.Redirect(w, r, u, http.StatusFound)
+			}
+		},
+	))
+	defer srv.Close()
+
+	f := localFetch(
+		t,
+		fetch.WithAllowedDomains("localhost"),
+		fetch.WithDomainHeaders("127.0.0.1", map[string]string{
+			"X-Api-Key": "secret",
+		}),
+	)
+	resp := runFetch(t, f, `{"url":"`+srv.URL+`/start",`+
+		`"headers":{"X-Api-Key":"model"}}`)
+	if resp.IsError {
+		t.Fatalf("error response: %s", resp.Content)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := []string{
+		host + "=secret",
+		strings.Replace(host, "127.0.0.1", "localhost", 1) + "=",
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("requests = %q, want %q", keys, want)
+	}
+}
+
+func TestFetch_Get(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Key", r.Header.Get("X-Api-Key"))
+			w.Header().Set("X-Model", r.Header.Get("X-Model"))
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, r.Method+" "+r.URL.Path)
+		},
+	))
+	defer srv.Close()
+
+	f := localFetch(t, fetch.WithHeaders(map[string]string{
+		"X-Api-Key": "secret",
+	}))
+	resp := runFetch(t, f, `{"url":"`+srv.URL+`/items",`+
+		`"headers":{"X-Api-Key":"model","X-Model":"yes"}}`)
+
+	if resp.IsError {
+		t.Fatalf("error response: %s", resp.Content)
+	}
+	for _, want := range []string{
+		"status: 418 I'm a teapot\n",
+		"X-Key: secret\n",
+		"X-Model: yes\n",
+		"\nGET /items\n",
+	} {
+		if !strings.Contains(resp.Content, want) {
+			t.Errorf("content missing %q:\n%s", want, resp.Content)
+		}
+	}
+}
+
+func TestFetch_PostJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			io.WriteString(w, r.Header.Get("Content-Type")+" "+string(body))
+		},
+	))
+	defer srv.Close()
+
+	resp := runFetch(t, localFetch(t), `{"url":"`+srv.URL+`",`+
+		`"method":"post","body":"{\"a\":1}"}`)
+	if !strings.Contains(resp.Content, `application/json {"a":1}`) {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestFetch_RefusesDomainsOutsideAllowlist(t *testing.T) {
+	f, err := fetch.New(fetch.WithAllowedDomains("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{
+		"http://notexample.com/",
+		"http://example.com.evil.test/",
+		"file:///etc/passwd",
+	} {
+		resp := runFetch(t, f, `{"url":"`+url+`"}`)
+		if !resp.IsError {
+			t.Errorf("%s allowed: %q", url, resp.Content)
+		}
+	}
+}
+
+func TestFetch_BlocksPrivateAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) { hits.Add(1) },
+	))
+	defer srv.Close()
+
+	f, err := fetch.New(fetch.WithAllowedDomains("127.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runFetch(t, f, `{"url":"`+srv.URL+`"}`)
+	if !resp.IsError || !strings.Contains(resp.Content, "not public") {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if hits.Load() != 0 {
+		t.Error("request reached the private server")
+	}
+}
+
+func TestFetch_RefusesRedirectOutsideAllowlist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://elsewhere.test/", http.StatusFound)
+		},
+	))
+	defer srv.Close()
+
+	resp := runFetch(t, localFetch(t), `{"url":"`+srv.URL+`"}`)
+	if !resp.IsError || !strings.Contains(resp.Content, "redirect refused") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestFetch_TruncatesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			io.WriteString(w, "hello world")
+		},
+	))
+	defer srv.Close()
+
+	f := localFetch(t, fetch.WithMaxBodySize(5))
+	resp := runFetch(t, f, `{"url":"`+srv.URL+`"}`)
+	if !strings.Contains(resp.Content, "\nhello\n[body truncated at 5 bytes]") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestFetch_RejectsUnsupportedMethod(t *testing.T) {
+	resp := runFetch(t, localFetch(t),
+		`{"url":"http://127.0.0.1/","method":"DELETE"}`)
+	if !resp.IsError {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
@@ -0,0 +1,252 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/tool/fs"
+)
+
+func newFS(t *testing.T, opts ...fs.Option) (*fs.FS, string) {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := fs.New(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { files.Close() })
+	return files, dir
+}
+
+func fsTool(t *testing.T, files *fs.FS, name string) tool.BaseTool {
+	t.Helper()
+	for _, bt := range files.Tools(context.Background()) {
+		if bt.Info().Name == name {
+			return bt
+		}
+	}
+	t.Fatalf("tool %q not found", name)
+	return nil
+}
+
+func callFS(
+	t *testing.T,
+	files *fs.FS,
+	name, input string,
+) tool.Response {
+	t.Helper()
+	resp, err := fsTool(t, files, name).Run(
+		context.Background(),
+		tool.Call{Input: input},
+	)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return resp
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFS_WriteThenRead(t *testing.T) {
+	files, dir := newFS(t)
+
+	resp := callFS(t, files, "write_file",
+		`{"path":"src/main.go","content":"package main\n"}`)
+	if resp.IsError {
+		t.Fatalf("write: %s", resp.Content)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "src", "main.go"))
+	if err != nil || string(data) != "package main\n" {
+		t.Fatalf("file = %q, %v", data, err)
+	}
+
+	callFS(t, files, "write_file",
+		`{"path":"/src/main.go","content":"func main() {}\n","append":true}`)
+	resp = callFS(t, files, "read_file", `{"path":"src/main.go"}`)
+	if resp.Content != "package main\nfunc main() {}\n" {
+		t.Errorf("read = %q", resp.Content)
+	}
+}
+
+func TestFS_ReadOffsetAndLimit(t *testing.T) {
+	files, dir := newFS(t)
+	writeTestFile(t, filepath.Join(dir, "lines.txt"), "a\nb\nc\nd\n")
+
+	resp := callFS(t, files, "read_file",
+		`{"path":"lines.txt","offset":2,"limit":2}`)
+	if !strings.HasPrefix(resp.Content, "b\nc\n") ||
+		!strings.Contains(resp.Content, "offset=4") {
+		t.Errorf("read = %q", resp.Content)
+	}
+}
+
+func TestFS_ReadTruncatesAtMaxFileSize(t *testing.T) {
+	files, dir := newFS(t, fs.WithMaxFileSize(4))
+	writeTestFile(t, filepath.Join(dir, "big.txt"), "ab\ncd\nef\n")
+
+	resp := callFS(t, files, "read_file", `{"path":"big.txt"}`)
+	if !strings.HasPrefix(resp.Content, "ab\n\n[more lines follow") {
+		t.Errorf("read = %q", resp.Content)
+	}
+}
+
+func TestFS_ReadTruncatesOversizedLine(t *testing.T) {
+	files, dir := newFS(t, fs.WithMaxFileSize(4))
+	writeTestFile(t, filepath.Join(dir, "long.txt"), "abcdefghij\nxy\n")
+
+	resp := callFS(t, files, "read_file", `{"path":"long.txt"}`)
+	if !strings.HasPrefix(resp.Content, "abcd\n[line 1 truncated") ||
+		!strings.Contains(resp.Content, "offset=2") {
+		t.Fatalf("read = %q", resp.Content)
+	}
+
+	resp = callFS(t, files, "read_file", `{"path":"long.txt","offset":2}`)
+	if resp.Content != "xy\n" {
+		t.Errorf("read from offset 2 = %q, want the next line", resp.Content)
+	}
+}
+
+func TestFS_RejectsTraversal(t *testing.T) {
+	files, dir := newFS(t)
+	writeTestFile(t, filepath.Join(filepath.Dir(dir), "secret.txt"), "x")
+
+	for _, path := range []string{"../secret.txt", "a/../../secret.txt"} {
+		resp := callFS(t, files, "read_file", `{"path":"`+path+`"}`)
+		if !resp.IsError {
+			t.Errorf("read %s: %q", path, resp.Content)
+		}
+		resp = callFS(t, files, "write_file",
+			`{"path":"`+path+`","content":"pwned"}`)
+		if !resp.IsError {
+			t.Errorf("write %s: %q", path, resp.Content)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(dir), "secret.txt"))
+	if string(data) != "x" {
+		t.Error("file outside root was modified")
+	}
+}
+
+func TestFS_BlocksSymlinkEscape(t *testing.T) {
+	files, dir := newFS(t)
+	outside := t.TempDir()
+	writeTestFile(t, filepath.Join(outside, "secret.txt"), "secret")
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	err := os.Symlink(
+		filepath.Join(outside, "secret.txt"),
+		filepath.Join(dir, "file-link"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"link/secret.txt", "file-link"} {
+		resp := callFS(t, files, "read_file", `{"path":"`+path+`"}`)
+		if !resp.IsError || strings.Contains(resp.Content, "secret\n") {
+			t.Errorf("read %s: %q", path, resp.Content)
+		}
+	}
+	resp := callFS(t, files, "write_file",
+		`{"path":"link/new.txt","content":"x"}`)
+	if !resp.IsError {
+		t.Errorf("write through symlink: %q", resp.Content)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Error("file created outside root")
+	}
+	resp = callFS(t, files, "list_dir", `{"path":"link"}`)
+	if !resp.IsError {
+		t.Errorf("list through symlink: %q", resp.Content)
+	}
+	resp = callFS(t, files, "search_files", `{"pattern":"secret"}`)
+	if resp.Content != "no matches" {
+		t.Errorf("search followed symlink: %q", resp.Content)
+	}
+}
+
+func TestFS_ReadOnly(t *testing.T) {
+	files, _ := newFS(t, fs.WithReadOnly())
+	for _, bt := range files.Tools(context.Background()) {
+		if bt.Info().Name == "write_file" {
+			t.Fatal("write_file offered in read-only mode")
+		}
+	}
+	if got := len(files.Tools(context.Background())); got != 3 {
+		t.Errorf("tools = %d, want 3", got)
+	}
+}
+
+func TestFS_WriteConfirmation(t *testing.T) {
+	files, _ := newFS(t, fs.WithConfirmation())
+	if !fsTool(t, files, "write_file").Info().RequireConfirmation {
+		t.Error("write_file does not require confirmation")
+	}
+	if fsTool(t, files, "read_file").Info().RequireConfirmation {
+		t.Error("read_file requires confirmation")
+	}
+}
+
+func TestFS_ListDir(t *testing.T) {
+	files, dir := newFS(t)
+	writeTestFile(t, filepath.Join(dir, "a.txt"), "")
+	writeTestFile(t, filepath.Join(dir, "sub", "b.txt"), "")
+	writeTestFile(t, filepath.Join(dir, ".git", "HEAD"), "")
+
+	resp := callFS(t, files, "list_dir", `{}`)
+	if resp.Content != ".git/\na.txt\nsub/" {
+		t.Errorf("list = %q", resp.Content)
+	}
+	resp = callFS(t, files, "list_dir", `{"recursive":true}`)
+	if resp.Content != ".git/\na.txt\nsub/\nsub/b.txt" {
+		t.Errorf("recursive list = %q", resp.Content)
+	}
+	resp = callFS(t, files, "list_dir", `{"path":"a.txt"}`)
+	if !resp.IsError {
+		t.Errorf("list of file = %q", resp.Content)
+	}
+}
+
+func TestFS_SearchFiles(t *testing.T) {
+	files, dir := newFS(t, fs.WithMaxResults(2))
+	writeTestFile(t, filepath.Join(dir, "a.go"), "package a\n// TODO one\n")
+	writeTestFile(t, filepath.Join(dir, "b.md"), "TODO docs\n")
+	writeTestFile(t, filepath.Join(dir, "sub", "c.go"), "// TODO two\n")
+	writeTestFile(t, filepath.Join(dir, ".git", "x"), "TODO hidden\n")
+	writeTestFile(t, filepath.Join(dir, "bin"), "TODO\x00binary")
+
+	resp := callFS(t, files, "search_files",
+		`{"pattern":"TODO \\w+","include":"*.go"}`)
+	if resp.Content != "a.go:2: // TODO one\nsub/c.go:1: // TODO two" {
+		t.Errorf("search = %q", resp.Content)
+	}
+
+	resp = callFS(t, files, "search_files", `{"pattern":"TODO"}`)
+	if !strings.Contains(resp.Content, "[search stopped at 2 matches]") {
+		t.Errorf("search = %q", resp.Content)
+	}
+
+	resp = callFS(t, files, "search_files", `{"pattern":"("}`)
+	if !resp.IsError {
+		t.Errorf("invalid pattern accepted: %q", resp.Content)
+	}
+}
+
+func TestFS_NewRequiresDirectory(t *testing.T) {
+	if _, err := fs.New(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing root")
+	}
+}
@@ -0,0 +1,103 @@
+//go:build unix
+
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const stdioServerEnv = "MCP_TEST_STDIO_SERVER"
+
+func TestMain(m *testing.M) {
+	if pidFile := os.Getenv(stdioServerEnv); pidFile != "" {
+		os.Exit(runStdioServer(pidFile))
+	}
+	os.Exit(m.Run())
+}
+
+func runStdioServer(pidFile string) int {
+	child := exec.Command("sleep", "60")
+	if err := child.Start(); err != nil {
+		return 1
+	}
+	pid := strconv.Itoa(child.Process.Pid)
+	if err := os.WriteFile(pidFile, []byte(pid), 0o600); err != nil {
+		return 1
+	}
+	server := mcp.NewServer(
+		&mcp.Implementation{Name: "stdio", Version: "1.0.0"},
+		nil,
+	)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo text"},
+		func(
+			_ context.Context,
+			_ *mcp.CallToolRequest,
+			in echoInput,
+		) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: in.Text}},
+			}, nil, nil
+		})
+	if err := server.Run(
+		context.Background(),
+		&mcp.StdioTransport{},
+	); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] != "Z"
+}
+
+func TestCloseMCPServer_KillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	t.Cleanup(tool.CloseMCPPool)
+
+	_, err := tool.GetMcpTools(context.Background(), map[string]tool.MCPServer{
+		"stdio_group": {
+			Type:             tool.MCPStdio,
+			Command:          os.Args[0],
+			Env:              []string{stdioServerEnv + "=" + pidFile},
+			TerminateTimeout: 100 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(string(data))
+	t.Cleanup(func() { _ = syscall.Kill(pid, syscall.SIGKILL) })
+
+	if err := tool.CloseMCPServer("stdio_group"); err != nil {
+		t.Logf("CloseMCPServer: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatal("server's child process outlived the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
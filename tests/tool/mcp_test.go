@@ -0,0 +1,350 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type echoInput struct {
+	Text string `json:"text"`
+}
+
+func newMCPServer(t *testing.T) (*mcp.Server, string) {
+	t.Helper()
+	server := mcp.NewServer(
+		&mcp.Implementation{Name: "echo", Version: "1.0.0"},
+		nil,
+	)
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "Echo text"},
+		func(
+			_ context.Context,
+			_ *mcp.CallToolRequest,
+			in echoInput,
+		) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: in.Text}},
+			}, nil, nil
+		})
+	handler := mcp.NewStreamableHTTPHandler(
+		func(*http.Request) *mcp.Server { return server },
+		nil,
+	)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return server, srv.URL
+}
+
+func sessionCount(server *mcp.Server) int {
+	n := 0
+	for range server.Sessions() {
+		n++
+	}
+	return n
+}
+
+func waitForSessions(t *testing.T, server *mcp.Server, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for sessionCount(server) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("sessions = %d, want %d", sessionCount(server), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseMCPServer_ClosesOnlyThatServer(t *testing.T) {
+	ctx := context.Background()
+	first, firstURL := newMCPServer(t)
+	second, secondURL := newMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	tools, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"close_first":  {Type: tool.MCPStreamableHTTP, URL: firstURL},
+		"close_second": {Type: tool.MCPStreamableHTTP, URL: secondURL},
+	})
+	if err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("got %d tools, want 2", len(tools))
+	}
+	waitForSessions(t, first, 1)
+	waitForSessions(t, second, 1)
+
+	if err := tool.CloseMCPServer("close_first"); err != nil {
+		t.Fatalf("CloseMCPServer: %v", err)
+	}
+	waitForSessions(t, first, 0)
+	waitForSessions(t, second, 1)
+
+	if err := tool.CloseMCPServer("close_first"); err != nil {
+		t.Errorf("closing a closed server: %v", err)
+	}
+
+	for _, tl := range tools {
+		if tl.Info().Name != "close_first_echo" {
+			continue
+		}
+		resp, err := tl.Run(ctx, tool.Call{Input: `{"text":"again"}`})
+		if err != nil || resp.Content != "again" {
+			t.Fatalf("Run after close = %+v, %v", resp, err)
+		}
+	}
+	waitForSessions(t, first, 1)
+}
+
+func TestCloseMCPServersWhenDone(t *testing.T) {
+	server, url := newMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"request_scoped": {Type: tool.MCPStreamableHTTP, URL: url},
+	}); err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	tool.CloseMCPServersWhenDone(ctx, "request_scoped")
+	waitForSessions(t, server, 1)
+
+	cancel()
+	waitForSessions(t, server, 0)
+}
+
+func TestCloseMCPServersWhenDone_Stop(t *testing.T) {
+	server, url := newMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"kept_open": {Type: tool.MCPStreamableHTTP, URL: url},
+	}); err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	stop := tool.CloseMCPServersWhenDone(ctx, "kept_open")
+	if !stop() {
+		t.Fatal("stop did not cancel the cleanup")
+	}
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if n := sessionCount(server); n != 1 {
+		t.Errorf("sessions = %d after stop, want 1", n)
+	}
+}
+
+func TestCloseMCPServersWhenDone_SharedServer(t *testing.T) {
+	server, url := newMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+	servers := map[string]tool.MCPServer{
+		"shared": {Type: tool.MCPStreamableHTTP, URL: url},
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	for _, ctx := range []context.Context{first, second} {
+		if _, err := tool.GetMcpTools(ctx, servers); err != nil {
+			t.Fatalf("GetMcpTools: %v", err)
+		}
+		tool.CloseMCPServersWhenDone(ctx, "shared")
+	}
+	waitForSessions(t, server, 1)
+
+	cancelFirst()
+	time.Sleep(50 * time.Millisecond)
+	if n := sessionCount(server); n != 1 {
+		t.Fatalf("sessions = %d after one request ended, want 1", n)
+	}
+
+	cancelSecond()
+	waitForSessions(t, server, 0)
+}
+
+func newSchemaMCPServer(t *testing.T) string {
+	t.Helper()
+	server := mcp.NewServer(
+		&mcp.Implementation{Name: "weather", Version: "1.0.0"},
+		nil,
+	)
+	server.AddTool(&mcp.Tool{
+		Name: "forecast",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"mode": map[string]any{"type": "string"},
+			},
+		},
+		OutputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"temp": map[string]any{"type": "integer"},
+			},
+			"required": []any{"temp"},
+		},
+	}, func(
+		_ context.Context,
+		req *mcp.CallToolRequest,
+	) (*mcp.CallToolResult, error) {
+		var in struct {
+			Mode string `json:"mode"`
+		}
+		_ = json.Unmarshal(req.Params.Arguments, &in)
+		switch in.Mode {
+		case "wrong":
+			return &mcp.CallToolResult{
+				StructuredContent: map[string]any{"temp": "warm"},
+			}, nil
+		case "missing":
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: "warm"}},
+			}, nil
+		}
+		return &mcp.CallToolResult{
+			StructuredContent: map[string]any{"temp": 21},
+		}, nil
+	})
+	server.AddTool(&mcp.Tool{
+		Name:        "remote",
+		InputSchema: map[string]any{"type": "object"},
+		OutputSchema: map[string]any{
+			"type": "object",
+			"$ref": "https://example.com/forecast.json",
+		},
+	}, func(
+		context.Context,
+		*mcp.CallToolRequest,
+	) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: "sunny"}},
+		}, nil
+	})
+	handler := mcp.NewStreamableHTTPHandler(
+		func(*http.Request) *mcp.Server { return server },
+		nil,
+	)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestGetMcpTools_OutputSchema(t *testing.T) {
+	ctx := context.Background()
+	weatherURL := newSchemaMCPServer(t)
+	_, plainURL := newMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	tools, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"weather": {Type: tool.MCPStreamableHTTP, URL: weatherURL},
+		"plain":   {Type: tool.MCPStreamableHTTP, URL: plainURL},
+	})
+	if err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+
+	byName := make(map[string]tool.BaseTool)
+	for _, tl := range tools {
+		byName[tl.Info().Name] = tl
+	}
+	forecast, echo := byName["weather_forecast"], byName["plain_echo"]
+	if forecast == nil || echo == nil {
+		t.Fatalf("tools = %v", byName)
+	}
+	if echo.Info().OutputSchema != nil {
+		t.Errorf("echo OutputSchema = %v, want nil", echo.Info().OutputSchema)
+	}
+	props, _ := forecast.Info().OutputSchema["properties"].(map[string]any)
+	if _, ok := props["temp"]; !ok {
+		t.Fatalf("OutputSchema = %v", forecast.Info().OutputSchema)
+	}
+
+	tests := []struct {
+		mode    string
+		isError bool
+		content string
+	}{
+		{mode: "valid", content: `{"temp":21}`},
+		{mode: "wrong", isError: true},
+		{mode: "missing", isError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			resp, err := forecast.Run(ctx, tool.Call{
+				Input: `{"mode":"` + tt.mode + `"}`,
+			})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if resp.IsError != tt.isError {
+				t.Fatalf("IsError = %v, content %q", resp.IsError, resp.Content)
+			}
+			if tt.content != "" && resp.Content != tt.content {
+				t.Errorf("Content = %q, want %q", resp.Content, tt.content)
+			}
+		})
+	}
+}
+
+func TestGetMcpTools_UnresolvableOutputSchema(t *testing.T) {
+	ctx := context.Background()
+	weatherURL := newSchemaMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	tools, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"weather": {Type: tool.MCPStreamableHTTP, URL: weatherURL},
+	})
+	if err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("got %d tools, want 2", len(tools))
+	}
+
+	for _, tl := range tools {
+		if tl.Info().Name != "weather_remote" {
+			continue
+		}
+		resp, err := tl.Run(ctx, tool.Call{Input: `{}`})
+		if err != nil || resp.IsError || resp.Content != "sunny" {
+			t.Errorf("Run = %+v, %v, want unchecked text", resp, err)
+		}
+		return
+	}
+	t.Fatal("weather_remote not registered")
+}
+
+func TestMcpTool_InfoClonesOutputSchema(t *testing.T) {
+	ctx := context.Background()
+	weatherURL := newSchemaMCPServer(t)
+	t.Cleanup(tool.CloseMCPPool)
+
+	tools, err := tool.GetMcpTools(ctx, map[string]tool.MCPServer{
+		"weather": {Type: tool.MCPStreamableHTTP, URL: weatherURL},
+	})
+	if err != nil {
+		t.Fatalf("GetMcpTools: %v", err)
+	}
+	for _, tl := range tools {
+		if tl.Info().Name != "weather_forecast" {
+			continue
+		}
+		schema := tl.Info().OutputSchema
+		schema["type"] = "string"
+		props, _ := schema["properties"].(map[string]any)
+		delete(props, "temp")
+
+		again := tl.Info().OutputSchema
+		props, _ = again["properties"].(map[string]any)
+		if again["type"] != "object" || props["temp"] == nil {
+			t.Errorf("OutputSchema modified through Info: %v", again)
+		}
+		return
+	}
+	t.Fatal("weather_forecast not registered")
+}
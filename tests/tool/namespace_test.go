@@ -0,0 +1,108 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+type nameRecorder struct {
+	name string
+	seen string
+}
+
+func (r *nameRecorder) Info() tool.Info {
+	return tool.NewInfo(r.name, "records the call name", struct{}{})
+}
+
+func (r *nameRecorder) Run(
+	_ context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	r.seen = params.Name
+	return tool.NewTextResponse("ok"), nil
+}
+
+func toolNames(tools []tool.BaseTool) []string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Info().Name
+	}
+	return names
+}
+
+func TestNamespaced_MapsCallsBack(t *testing.T) {
+	inner := &nameRecorder{name: "search"}
+	ns := tool.Namespaced("github", inner)
+
+	if got := ns.Info().Name; got != "github_search" {
+		t.Fatalf("name = %q, want github_search", got)
+	}
+	if _, err := ns.Run(
+		context.Background(),
+		tool.Call{ID: "1", Name: "github_search", Input: "{}"},
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.seen != "search" {
+		t.Errorf("inner tool saw name %q, want search", inner.seen)
+	}
+}
+
+func TestRegistry_WithNamespace(t *testing.T) {
+	github := &nameRecorder{name: "search"}
+	docs := &nameRecorder{name: "search"}
+	reg := tool.NewRegistry()
+	reg.Register(github, tool.WithNamespace("github"))
+	reg.Register(docs, tool.WithNamespace("context7"))
+
+	if len(reg.List()) != 2 {
+		t.Fatalf("expected 2 tools, got %v", reg.Names())
+	}
+	if _, err := reg.Execute(
+		context.Background(),
+		tool.Call{ID: "1", Name: "context7_search", Input: "{}"},
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if docs.seen != "search" || github.seen != "" {
+		t.Errorf("github saw %q, context7 saw %q", github.seen, docs.seen)
+	}
+}
+
+func TestNamespacedToolset_PrefixesTools(t *testing.T) {
+	ts := tool.NamespacedToolset("files", tool.NewToolset("fs",
+		&stubTool{name: "read"},
+		&stubTool{name: "write"},
+	))
+
+	if ts.Name() != "files" {
+		t.Errorf("name = %q, want files", ts.Name())
+	}
+	got := toolNames(ts.Tools(context.Background()))
+	if len(got) != 2 || got[0] != "files_read" || got[1] != "files_write" {
+		t.Errorf("tools = %v", got)
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	taken := map[string]bool{"search": true, "mcp_search": true}
+	tools := []tool.BaseTool{
+		&stubTool{name: "search"},
+		&stubTool{name: "fetch"},
+		&stubTool{name: "fetch"},
+	}
+
+	got := toolNames(tool.Deduplicate(tools, "mcp", taken))
+	want := []string{"search_2", "fetch", "mcp_fetch"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tools = %v, want %v", got, want)
+			break
+		}
+	}
+	if !taken["search_2"] || !taken["mcp_fetch"] {
+		t.Errorf("taken not updated: %v", taken)
+	}
+}
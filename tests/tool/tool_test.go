@@ -1,7 +1,9 @@
 package tool
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 
@@ -138,6 +140,51 @@ func TestNewImageResponse(t *testing.T) {
 	}
 }
 
+func TestNewImageResponse_DecodesBase64(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nrest")
+	r := tool.NewImageResponse(base64.StdEncoding.EncodeToString(png))
+	if r.MimeType != "image/png" {
+		t.Errorf("expected detected image/png, got %q", r.MimeType)
+	}
+	if !bytes.Equal(r.Data, png) || r.Content != "" {
+		t.Errorf("expected decoded data only, got %+v", r)
+	}
+}
+
+func TestNewImageResponse_DataURL(t *testing.T) {
+	r := tool.NewImageResponse("data:image/webp;base64,AAAA")
+	if r.MimeType != "image/webp" {
+		t.Errorf("expected image/webp from data URL, got %q", r.MimeType)
+	}
+	if len(r.Data) != 3 {
+		t.Errorf("expected 3 decoded bytes, got %d", len(r.Data))
+	}
+}
+
+func TestNewImageResponse_URLKeptAsContent(t *testing.T) {
+	r := tool.NewImageResponse("http://example.com/img.png")
+	if r.Content != "http://example.com/img.png" || r.Data != nil {
+		t.Errorf("expected URL kept as content, got %+v", r)
+	}
+}
+
+func TestNewMultiPartResponse(t *testing.T) {
+	r := tool.NewMultiPartResponse(
+		tool.TextPart("Sales"),
+		tool.ImagePart([]byte("png"), "image/png"),
+		tool.TextPart("Q3 highlighted"),
+	)
+	if r.Type != tool.ResponseTypeParts {
+		t.Errorf("expected type parts, got %s", r.Type)
+	}
+	if r.Content != "Sales\nQ3 highlighted" {
+		t.Errorf("expected joined text content, got %q", r.Content)
+	}
+	if len(r.Parts) != 3 || r.Parts[1].MimeType != "image/png" {
+		t.Errorf("expected parts preserved in order, got %+v", r.Parts)
+	}
+}
+
 func TestNewFileResponse(t *testing.T) {
 	r := tool.NewFileResponse(
 		[]byte("pdf data"),
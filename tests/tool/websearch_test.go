@@ -0,0 +1,196 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/tool"
+	"github.com/joakimcarlsson/ai/tool/websearch"
+)
+
+func runSearch(
+	t *testing.T,
+	st *websearch.Tool,
+	input string,
+) tool.Response {
+	t.Helper()
+	resp, err := st.Run(context.Background(), tool.Call{Input: input})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return resp
+}
+
+func TestWebSearch_Tavily(t *testing.T) {
+	var auth string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			raw, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(raw, &body)
+			_, _ = io.WriteString(w, `{"results":[`+
+				`{"title":"Go","url":"https://go.dev","content":"The Go site"}]}`)
+		}))
+	defer srv.Close()
+
+	st, err := websearch.New(
+		websearch.ProviderTavily,
+		"tvly-key",
+		websearch.WithBaseURL(srv.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runSearch(t, st, `{"query":"golang","max_results":3}`)
+
+	if auth != "Bearer tvly-key" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if body["query"] != "golang" || body["max_results"] != float64(3) {
+		t.Errorf("request body = %v", body)
+	}
+	if resp.IsError ||
+		!strings.Contains(resp.Content, "1. Go\n   URL: https://go.dev") ||
+		!strings.Contains(resp.Content, "The Go site") {
+		t.Errorf("content = %q", resp.Content)
+	}
+	var results []websearch.Result
+	err = json.Unmarshal([]byte(resp.Metadata), &results)
+	if err != nil || len(results) != 1 || results[0].URL != "https://go.dev" {
+		t.Errorf("metadata = %s", resp.Metadata)
+	}
+}
+
+func TestWebSearch_Brave(t *testing.T) {
+	var token, query, count string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			token = r.Header.Get("X-Subscription-Token")
+			query = r.URL.Query().Get("q")
+			count = r.URL.Query().Get("count")
+			_, _ = io.WriteString(w, `{"web":{"results":[`+
+				`{"title":"A","url":"https://a.example","description":"aa"},`+
+				`{"title":"B","url":"https://b.example","description":"bb"}]}}`)
+		}))
+	defer srv.Close()
+
+	st, err := websearch.New(
+		websearch.ProviderBrave,
+		"brave-key",
+		websearch.WithBaseURL(srv.URL),
+		websearch.WithMaxResults(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runSearch(t, st, `{"query":"news"}`)
+
+	if token != "brave-key" || query != "news" || count != "2" {
+		t.Errorf("token = %q, q = %q, count = %q", token, query, count)
+	}
+	if !strings.Contains(resp.Content, "2. B\n   URL: https://b.example") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestWebSearch_SerpAPI(t *testing.T) {
+	var key, engine string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			key = r.URL.Query().Get("api_key")
+			engine = r.URL.Query().Get("engine")
+			_, _ = io.WriteString(w, `{"organic_results":[`+
+				`{"title":"S","link":"https://s.example","snippet":"ss"}]}`)
+		}))
+	defer srv.Close()
+
+	st, err := websearch.New(
+		websearch.ProviderSerpAPI,
+		"serp-key",
+		websearch.WithBaseURL(srv.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runSearch(t, st, `{"query":"q"}`)
+
+	if key != "serp-key" || engine != "google" {
+		t.Errorf("api_key = %q, engine = %q", key, engine)
+	}
+	if !strings.Contains(resp.Content, "URL: https://s.example") {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
+
+func TestWebSearch_HTTPErrorIsToolError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"detail":"bad key"}`)
+		}))
+	defer srv.Close()
+
+	st, err := websearch.New(
+		websearch.ProviderTavily,
+		"bad",
+		websearch.WithBaseURL(srv.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := runSearch(t, st, `{"query":"x"}`)
+	if !resp.IsError || !strings.Contains(resp.Content, "status 401") {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestWebSearch_CustomBackend(t *testing.T) {
+	var gotLimit int
+	backend := websearch.SearchBackendFunc(
+		func(_ context.Context, _ string, n int) ([]websearch.Result, error) {
+			gotLimit = n
+			return nil, nil
+		},
+	)
+	st := websearch.NewWithBackend(backend, websearch.WithName("search"))
+
+	if st.Info().Name != "search" {
+		t.Errorf("name = %q", st.Info().Name)
+	}
+	resp := runSearch(t, st, `{"query":"nothing","max_results":100}`)
+	if gotLimit != 20 {
+		t.Errorf("limit = %d, want capped at 20", gotLimit)
+	}
+	if resp.Content != `No results found for "nothing".` {
+		t.Errorf("content = %q", resp.Content)
+	}
+
+	failing := websearch.NewWithBackend(websearch.SearchBackendFunc(
+		func(context.Context, string, int) ([]websearch.Result, error) {
+			return nil, errors.New("quota exceeded")
+		},
+	))
+	resp = runSearch(t, failing, `{"query":"x"}`)
+	if !resp.IsError || !strings.Contains(resp.Content, "quota exceeded") {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp = runSearch(t, failing, `{"query":"  "}`); !resp.IsError {
+		t.Error("empty query accepted")
+	}
+}
+
+func TestWebSearch_NewValidation(t *testing.T) {
+	if _, err := websearch.New(websearch.ProviderBrave, ""); err == nil {
+		t.Error("expected error for empty API key")
+	}
+	if _, err := websearch.New("bing", "key"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
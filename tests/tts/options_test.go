@@ -0,0 +1,115 @@
+package tts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tts"
+)
+
+func applyOptions(options ...tts.GenerationOption) tts.GenerationOptions {
+	var opts tts.GenerationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+func TestWithPronunciationDictionary_AppendsInOrder(t *testing.T) {
+	opts := applyOptions(
+		tts.WithPronunciationDictionary("brands"),
+		tts.WithPronunciationDictionaryVersion("acronyms", "v2"),
+	)
+
+	want := []tts.PronunciationDictionary{
+		{ID: "brands"},
+		{ID: "acronyms", VersionID: "v2"},
+	}
+	if len(opts.PronunciationDictionaries) != len(want) {
+		t.Fatalf("expected %d dictionaries, got %v",
+			len(want), opts.PronunciationDictionaries)
+	}
+	for i, d := range want {
+		if opts.PronunciationDictionaries[i] != d {
+			t.Errorf("dictionary %d: expected %v, got %v",
+				i, d, opts.PronunciationDictionaries[i])
+		}
+	}
+}
+
+func TestCheckOptions_RejectsSSMLOnUnsupportedModel(t *testing.T) {
+	m := model.ElevenLabsAudioModels[model.ElevenMultilingualV2]
+	err := tts.CheckOptions(m, applyOptions(tts.WithSSML()))
+	if !errors.Is(err, tts.ErrSSMLNotSupported) {
+		t.Fatalf("expected ErrSSMLNotSupported, got %v", err)
+	}
+
+	m = model.ElevenLabsAudioModels[model.ElevenFlashV2]
+	if err := tts.CheckOptions(m, applyOptions(tts.WithSSML())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckOptions_RejectsDictionariesOnUnsupportedModel(t *testing.T) {
+	opts := applyOptions(tts.WithPronunciationDictionary("brands"))
+
+	m := model.OpenAIAudioModels[model.OpenAITTS1]
+	err := tts.CheckOptions(m, opts)
+	if !errors.Is(err, tts.ErrPronunciationDictionaryNotSupported) {
+		t.Fatalf("expected ErrPronunciationDictionaryNotSupported, got %v", err)
+	}
+
+	m = model.ElevenLabsAudioModels[model.ElevenV3]
+	if err := tts.CheckOptions(m, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckOptions_SkipsUnsetModel(t *testing.T) {
+	opts := applyOptions(
+		tts.WithSSML(),
+		tts.WithOutputFormat("riff-24khz-16bit-mono-pcm"),
+	)
+	if err := tts.CheckOptions(model.AudioModel{}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckOptions_AllowsPlainText(t *testing.T) {
+	m := model.OpenAIAudioModels[model.OpenAITTS1]
+	if err := tts.CheckOptions(m, applyOptions()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckOptions_ValidatesOutputFormat(t *testing.T) {
+	m := model.ElevenLabsAudioModels[model.ElevenFlashV2_5]
+	for _, format := range []string{"ulaw_8000", "pcm_16000"} {
+		opts := applyOptions(tts.WithOutputFormat(format))
+		if err := tts.CheckOptions(m, opts); err != nil {
+			t.Errorf("%s: unexpected error: %v", format, err)
+		}
+	}
+
+	opts := applyOptions(tts.WithOutputFormat("wav_44100"))
+	err := tts.CheckOptions(m, opts)
+	if !errors.Is(err, tts.ErrUnsupportedOutputFormat) {
+		t.Fatalf("expected ErrUnsupportedOutputFormat, got %v", err)
+	}
+}
+
+func TestCheckOptions_FormatIgnoresCase(t *testing.T) {
+	m := model.GoogleCloudAudioModels[model.GoogleCloudTTSNeural2]
+	opts := applyOptions(tts.WithOutputFormat("linear16"))
+	if err := tts.CheckOptions(m, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckOptions_SkipsFormatWhenModelListsNone(t *testing.T) {
+	opts := applyOptions(tts.WithOutputFormat("anything"))
+	if err := tts.CheckOptions(model.AudioModel{}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
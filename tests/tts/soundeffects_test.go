@@ -0,0 +1,100 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/ai/model"
+	"github.com/joakimcarlsson/ai/tts"
+)
+
+type soundEffectTTS struct {
+	prompt string
+	opts   tts.SoundEffectOptions
+	err    error
+}
+
+func (s *soundEffectTTS) GenerateAudio(
+	context.Context,
+	string,
+	...tts.GenerationOption,
+) (*tts.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *soundEffectTTS) StreamAudio(
+	context.Context,
+	string,
+	...tts.GenerationOption,
+) (<-chan tts.Chunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *soundEffectTTS) GenerateSoundEffect(
+	_ context.Context,
+	prompt string,
+	options ...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.prompt = prompt
+	for _, opt := range options {
+		opt(&s.opts)
+	}
+	return &tts.Response{
+		AudioData: []byte("fx"),
+		Usage:     tts.Usage{Characters: int64(len(prompt))},
+	}, nil
+}
+
+func (s *soundEffectTTS) ListVoices(context.Context) ([]tts.Voice, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *soundEffectTTS) Model() model.AudioModel { return model.AudioModel{} }
+
+func TestGenerateSoundEffect_TracingForwardsOptions(t *testing.T) {
+	inner := &soundEffectTTS{}
+	client := tts.WithTracing(inner, tts.TracingAttrs{})
+
+	resp, err := client.GenerateSoundEffect(context.Background(),
+		"soft UI click",
+		tts.WithDuration(1500*time.Millisecond),
+		tts.WithPromptInfluence(0.7),
+		tts.WithLoop(),
+		tts.WithSoundEffectFormat("pcm_16000"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.AudioData) != "fx" {
+		t.Errorf("expected inner audio, got %q", resp.AudioData)
+	}
+
+	if inner.prompt != "soft UI click" {
+		t.Errorf("expected prompt forwarded, got %q", inner.prompt)
+	}
+	if inner.opts.Duration == nil ||
+		*inner.opts.Duration != 1500*time.Millisecond {
+		t.Errorf("expected 1.5s duration, got %v", inner.opts.Duration)
+	}
+	if inner.opts.PromptInfluence == nil || *inner.opts.PromptInfluence != 0.7 {
+		t.Errorf("expected influence 0.7, got %v", inner.opts.PromptInfluence)
+	}
+	if !inner.opts.Loop || inner.opts.OutputFormat != "pcm_16000" {
+		t.Errorf("expected loop and pcm_16000, got %+v", inner.opts)
+	}
+}
+
+func TestGenerateSoundEffect_UnsupportedProvider(t *testing.T) {
+	inner := &soundEffectTTS{err: tts.ErrSoundEffectsNotSupported}
+	client := tts.WithTracing(inner, tts.TracingAttrs{})
+
+	_, err := client.GenerateSoundEffect(context.Background(), "rain")
+	if !errors.Is(err, tts.ErrSoundEffectsNotSupported) {
+		t.Fatalf("expected ErrSoundEffectsNotSupported, got %v", err)
+	}
+}
@@ -291,6 +291,14 @@ func (f *fakeTTS) GenerateAudio(
 	return nil, errors.New("not implemented")
 }
 
+func (f *fakeTTS) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 func (f *fakeTTS) ListVoices(context.Context) ([]tts.Voice, error) {
 	return nil, errors.New("not implemented")
 }
@@ -297,6 +297,14 @@ func (n *nonStreamingTTS) GenerateAudio(
 	return nil, errors.New("not implemented")
 }
 
+func (n *nonStreamingTTS) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 func (n *nonStreamingTTS) ListVoices(context.Context) ([]tts.Voice, error) {
 	return nil, errors.New("not implemented")
 }
@@ -361,6 +369,14 @@ func (h *holdingTTS) GenerateAudio(
 	return nil, errors.New("not implemented")
 }
 
+func (h *holdingTTS) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 func (h *holdingTTS) ListVoices(context.Context) ([]tts.Voice, error) {
 	return nil, errors.New("not implemented")
 }
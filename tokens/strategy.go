@@ -17,6 +17,10 @@ type StrategyResult struct {
 	// Messages is the list of messages to send to the LLM.
 	// Summary role messages are converted to User role for LLM compatibility.
 	Messages []message.Message
+	// Summaries holds the indexes in Messages of summary messages that were
+	// converted to the User role, so callers that store Messages can restore
+	// their [message.Summary] role.
+	Summaries []int
 	// SessionUpdate contains messages to add to the session storage.
 	// This is nil for strategies that don't generate new content (truncate, sliding).
 	SessionUpdate *SessionUpdate
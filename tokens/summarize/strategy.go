@@ -69,10 +69,7 @@ func (s *summarizeStrategy) Fit(
 	}
 
 	if count.TotalTokens <= input.MaxTokens {
-		return &tokens.StrategyResult{
-			Messages:      convertSummaryToUser(activeMessages),
-			SessionUpdate: nil,
-		}, nil
+		return unchanged(activeMessages), nil
 	}
 
 	// 3. Needs summary. Identify what to summarize within the active context.
@@ -95,10 +92,7 @@ func (s *summarizeStrategy) Fit(
 	splitPoint := len(convMsgs) - s.config.KeepRecent
 	if splitPoint <= 0 {
 		// Cannot summarize further without violating KeepRecent
-		return &tokens.StrategyResult{
-			Messages:      convertSummaryToUser(activeMessages),
-			SessionUpdate: nil,
-		}, nil
+		return unchanged(activeMessages), nil
 	}
 
 	toSummarize := make([]message.Message, 0, splitPoint+1)
@@ -111,10 +105,7 @@ func (s *summarizeStrategy) Fit(
 	summary, err := s.generateSummary(ctx, toSummarize)
 	if err != nil {
 		// Fallback: return what we have if summary fails
-		return &tokens.StrategyResult{
-			Messages:      convertSummaryToUser(activeMessages),
-			SessionUpdate: nil,
-		}, nil
+		return unchanged(activeMessages), nil
 	}
 
 	summaryContent := "Previous conversation summary:\n" + summary
@@ -131,7 +122,8 @@ func (s *summarizeStrategy) Fit(
 	sessionUpdateMsgs = append(sessionUpdateMsgs, toKeep...)
 
 	return &tokens.StrategyResult{
-		Messages: llmMessages,
+		Messages:  llmMessages,
+		Summaries: []int{len(systemMsgs)},
 		SessionUpdate: &tokens.SessionUpdate{
 			PopCount:    len(toKeep),
 			AddMessages: sessionUpdateMsgs,
@@ -174,8 +166,19 @@ func (s *summarizeStrategy) generateSummary(
 	return resp.Content, nil
 }
 
-func convertSummaryToUser(msgs []message.Message) []message.Message {
+func unchanged(msgs []message.Message) *tokens.StrategyResult {
+	messages, summaries := convertSummaryToUser(msgs)
+	return &tokens.StrategyResult{
+		Messages:  messages,
+		Summaries: summaries,
+	}
+}
+
+func convertSummaryToUser(
+	msgs []message.Message,
+) ([]message.Message, []int) {
 	result := make([]message.Message, len(msgs))
+	var summaries []int
 	for i, msg := range msgs {
 		if msg.Role == message.Summary {
 			result[i] = message.Message{
@@ -184,9 +187,10 @@ func convertSummaryToUser(msgs []message.Message) []message.Message {
 				Model:     msg.Model,
 				CreatedAt: msg.CreatedAt,
 			}
+			summaries = append(summaries, i)
 		} else {
 			result[i] = msg
 		}
 	}
-	return result
+	return result, summaries
 }
@@ -0,0 +1,99 @@
+package codeexec
+
+import "fmt"
+
+type backendConfig struct {
+	python      string
+	shell       string
+	memoryLimit int64
+	env         []string
+	image       string
+	docker      string
+	network     string
+	cpus        float64
+	pids        int
+}
+
+// BackendOption configures [LocalBackend] and [DockerBackend]. Options that
+// only apply to one backend are ignored by the other.
+type BackendOption func(*backendConfig)
+
+// WithPython sets the Python interpreter. Defaults to "python3".
+func WithPython(path string) BackendOption {
+	return func(c *backendConfig) { c.python = path }
+}
+
+// WithShell sets the POSIX shell used for shell code and, for
+// [LocalBackend], to apply rlimits. Defaults to "sh".
+func WithShell(path string) BackendOption {
+	return func(c *backendConfig) { c.shell = path }
+}
+
+// WithMemoryLimit caps the memory of a run in bytes. Defaults to 512 MiB.
+func WithMemoryLimit(bytes int64) BackendOption {
+	return func(c *backendConfig) { c.memoryLimit = bytes }
+}
+
+// WithEnv adds KEY=value environment variables to every run. Nothing from
+// the host environment is passed through otherwise.
+func WithEnv(env ...string) BackendOption {
+	return func(c *backendConfig) { c.env = append(c.env, env...) }
+}
+
+// WithImage sets the [DockerBackend] image. Defaults to python:3.12-slim.
+func WithImage(image string) BackendOption {
+	return func(c *backendConfig) { c.image = image }
+}
+
+// WithDockerPath sets the docker CLI used by [DockerBackend], which may be a
+// compatible CLI such as podman. Defaults to "docker".
+func WithDockerPath(path string) BackendOption {
+	return func(c *backendConfig) { c.docker = path }
+}
+
+// WithNetwork sets the [DockerBackend] container network. Defaults to
+// "none"; only relax it if the code needs to download data.
+func WithNetwork(network string) BackendOption {
+	return func(c *backendConfig) { c.network = network }
+}
+
+// WithCPUs caps the CPUs a [DockerBackend] container may use. Defaults to 1.
+func WithCPUs(cpus float64) BackendOption {
+	return func(c *backendConfig) { c.cpus = cpus }
+}
+
+// WithPidsLimit caps the processes a [DockerBackend] container may run.
+// Defaults to 128.
+func WithPidsLimit(n int) BackendOption {
+	return func(c *backendConfig) { c.pids = n }
+}
+
+func newBackendConfig(opts []BackendOption) backendConfig {
+	c := backendConfig{
+		python:      defaultPython,
+		shell:       defaultShell,
+		memoryLimit: defaultMemoryLimit,
+		image:       defaultImage,
+		docker:      defaultDocker,
+		network:     "none",
+		cpus:        defaultCPUs,
+		pids:        defaultPids,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// interpreter returns the command that runs code of language read from
+// stdin.
+func (c backendConfig) interpreter(language Language) ([]string, error) {
+	switch language {
+	case LanguagePython:
+		return []string{c.python, "-"}, nil
+	case LanguageShell:
+		return []string{c.shell, "-s"}, nil
+	default:
+		return nil, fmt.Errorf("codeexec: unsupported language %q", language)
+	}
+}
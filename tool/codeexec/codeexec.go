@@ -0,0 +1,345 @@
+// Package codeexec provides a tool that lets a model run Python or shell code
+// and read its output, for data-analysis and scripting agents.
+//
+// Code runs through a [Backend]. [DockerBackend] runs each snippet in a
+// throwaway container with no network, a read-only filesystem, and memory,
+// CPU, and process limits. [LocalBackend] runs it as a subprocess of the host
+// with a timeout, rlimits, and an empty environment; it is not a sandbox, so
+// [New] refuses it unless [AllowUnsandboxed] is passed.
+//
+// Example usage:
+//
+//	runner, err := codeexec.New(codeexec.NewDockerBackend())
+//	if err != nil {
+//		return err
+//	}
+//	a := agent.New(llmClient, agent.WithTools(runner))
+package codeexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const (
+	defaultToolName  = "execute_code"
+	defaultTimeout   = 30 * time.Second
+	defaultMaxOutput = 16 * 1024
+)
+
+// Language is a language the tool can run.
+type Language string
+
+// Supported languages.
+const (
+	LanguagePython Language = "python"
+	LanguageShell  Language = "shell"
+)
+
+// ErrUnsandboxed is returned by [New] for a backend that does not isolate
+// code from the host when [AllowUnsandboxed] was not passed.
+var ErrUnsandboxed = errors.New(
+	"codeexec: backend runs code unsandboxed on the host; " +
+		"pass AllowUnsandboxed to use it",
+)
+
+// Request is one snippet to run.
+type Request struct {
+	// Language selects the interpreter.
+	Language Language
+	// Code is the program text, passed to the interpreter on stdin.
+	Code string
+	// MaxOutput caps the bytes kept from each of stdout and stderr.
+	MaxOutput int
+}
+
+// Result is the outcome of running a snippet.
+type Result struct {
+	// Stdout and Stderr hold the captured output, cut at the request's
+	// MaxOutput.
+	Stdout string
+	Stderr string
+	// Truncated reports that some output was dropped.
+	Truncated bool
+	// ExitCode is the process exit status, or -1 if it did not exit on its
+	// own.
+	ExitCode int
+	// TimedOut reports that the run was stopped by the context deadline.
+	TimedOut bool
+	// Duration is the wall-clock run time.
+	Duration time.Duration
+}
+
+// Backend runs code for the tool. Execute stops the run when ctx is done and
+// reports that as Result.TimedOut rather than an error; errors are reserved
+// for failures to start the run at all, such as a missing interpreter.
+type Backend interface {
+	Execute(ctx context.Context, req Request) (Result, error)
+	// Sandboxed reports whether code is isolated from the host. [New]
+	// refuses backends that are not unless [AllowUnsandboxed] is passed.
+	Sandboxed() bool
+}
+
+// NetworkIsolator is implemented by a [Backend] that can report whether the
+// code it runs is cut off from the network. The default tool description
+// only tells the model runs have no network access when the backend reports
+// so.
+type NetworkIsolator interface {
+	NetworkIsolated() bool
+}
+
+// Options configures the code execution tool.
+type Options struct {
+	name                string
+	description         string
+	timeout             time.Duration
+	maxOutput           int
+	languages           []Language
+	allowUnsandboxed    bool
+	requireConfirmation bool
+}
+
+// Option configures [Options].
+type Option func(*Options)
+
+// WithName sets the tool name the model sees. Defaults to "execute_code".
+func WithName(name string) Option {
+	return func(o *Options) { o.name = name }
+}
+
+// WithDescription replaces the tool description the model sees.
+func WithDescription(description string) Option {
+	return func(o *Options) { o.description = description }
+}
+
+// WithTimeout limits how long one run may take. Defaults to 30 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.timeout = d }
+}
+
+// WithMaxOutput caps the bytes of stdout and of stderr returned to the
+// model. Defaults to 16 KiB each.
+func WithMaxOutput(n int) Option {
+	return func(o *Options) { o.maxOutput = n }
+}
+
+// WithLanguages restricts the languages the model may use. Defaults to
+// Python and shell.
+func WithLanguages(languages ...Language) Option {
+	return func(o *Options) { o.languages = languages }
+}
+
+// WithConfirmation marks the tool as requiring human approval before each
+// run. See the agent's WithConfirmationProvider.
+func WithConfirmation() Option {
+	return func(o *Options) { o.requireConfirmation = true }
+}
+
+// AllowUnsandboxed lets [New] accept a backend that runs code directly on
+// the host, such as [LocalBackend]. Only use it where the model's code is
+// trusted as much as the host process itself.
+func AllowUnsandboxed() Option {
+	return func(o *Options) { o.allowUnsandboxed = true }
+}
+
+// Tool is a [tool.BaseTool] that runs model-written code through a
+// [Backend] and returns its output.
+type Tool struct {
+	backend Backend
+	options Options
+}
+
+// New returns a code execution tool that runs code through backend. It
+// returns [ErrUnsandboxed] for a backend that is not sandboxed unless
+// [AllowUnsandboxed] is passed.
+func New(backend Backend, opts ...Option) (*Tool, error) {
+	if backend == nil {
+		return nil, errors.New("codeexec: backend is required")
+	}
+	options := Options{
+		name:      defaultToolName,
+		timeout:   defaultTimeout,
+		maxOutput: defaultMaxOutput,
+		languages: []Language{LanguagePython, LanguageShell},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !backend.Sandboxed() && !options.allowUnsandboxed {
+		return nil, ErrUnsandboxed
+	}
+	if options.timeout <= 0 {
+		options.timeout = defaultTimeout
+	}
+	if options.maxOutput <= 0 {
+		options.maxOutput = defaultMaxOutput
+	}
+	if len(options.languages) == 0 {
+		return nil, errors.New("codeexec: at least one language is required")
+	}
+	if options.description == "" {
+		options.description = defaultDescription(backend, options)
+	}
+	return &Tool{backend: backend, options: options}, nil
+}
+
+// defaultDescription describes the tool to the model, promising only the
+// isolation backend actually provides.
+func defaultDescription(backend Backend, options Options) string {
+	var limits []string
+	if n, ok := backend.(NetworkIsolator); ok && n.NetworkIsolated() {
+		limits = append(limits, "no network access")
+	}
+	if backend.Sandboxed() {
+		limits = append(limits, "no files kept between runs")
+	}
+	description := fmt.Sprintf(
+		"Run a %s program and return its exit code, stdout, and stderr. "+
+			"Runs are limited to %s",
+		languageList(options.languages),
+		options.timeout,
+	)
+	if len(limits) > 0 {
+		description += " with " + strings.Join(limits, " and ")
+	}
+	return description + "; print every result you need."
+}
+
+func languageList(languages []Language) string {
+	names := make([]string, len(languages))
+	for i, l := range languages {
+		names[i] = string(l)
+	}
+	return strings.Join(names, " or ")
+}
+
+// Info describes the tool to the model.
+func (t *Tool) Info() tool.Info {
+	languages := make([]string, len(t.options.languages))
+	for i, l := range t.options.languages {
+		languages[i] = string(l)
+	}
+	return tool.Info{
+		Name:        t.options.name,
+		Description: t.options.description,
+		Parameters: map[string]any{
+			"language": map[string]any{
+				"type":        "string",
+				"enum":        languages,
+				"description": "The language of the code",
+			},
+			"code": map[string]any{
+				"type":        "string",
+				"description": "The program to run",
+			},
+		},
+		Required:            []string{"language", "code"},
+		RequireConfirmation: t.options.requireConfirmation,
+	}
+}
+
+type runParams struct {
+	Language Language `json:"language"`
+	Code     string   `json:"code"`
+}
+
+// Run executes the code in params and returns its output. A non-zero exit,
+// a timeout, or a backend failure is returned to the model as an error
+// response.
+func (t *Tool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input runParams
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+	if !slices.Contains(t.options.languages, input.Language) {
+		return tool.NewTextErrorResponse(fmt.Sprintf(
+			"unsupported language %q, use %s",
+			input.Language,
+			languageList(t.options.languages),
+		)), nil
+	}
+	if strings.TrimSpace(input.Code) == "" {
+		return tool.NewTextErrorResponse("code is required"), nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.options.timeout)
+	defer cancel()
+	result, err := t.backend.Execute(runCtx, Request{
+		Language:  input.Language,
+		Code:      input.Code,
+		MaxOutput: t.options.maxOutput,
+	})
+	if err != nil {
+		return tool.NewTextErrorResponse(
+			"execution failed: " + err.Error(),
+		), nil
+	}
+	if ctx.Err() != nil {
+		return tool.Response{}, ctx.Err()
+	}
+
+	text := FormatResult(result, t.options.timeout)
+	if result.TimedOut || result.ExitCode != 0 {
+		return tool.NewTextErrorResponse(text), nil
+	}
+	return tool.NewTextResponse(text), nil
+}
+
+// FormatResult renders a result as the text the tool returns to the model.
+// timeout is reported when the run timed out.
+func FormatResult(result Result, timeout time.Duration) string {
+	var b strings.Builder
+	switch {
+	case result.TimedOut:
+		fmt.Fprintf(&b, "timed out after %s\n", timeout)
+	default:
+		fmt.Fprintf(&b, "exit code: %d\n", result.ExitCode)
+	}
+	if result.Stdout != "" {
+		fmt.Fprintf(&b, "stdout:\n%s\n", strings.TrimRight(result.Stdout, "\n"))
+	}
+	if result.Stderr != "" {
+		fmt.Fprintf(&b, "stderr:\n%s\n", strings.TrimRight(result.Stderr, "\n"))
+	}
+	if result.Stdout == "" && result.Stderr == "" {
+		b.WriteString("(no output)\n")
+	}
+	if result.Truncated {
+		b.WriteString("[output truncated]\n")
+	}
+	return b.String()
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so a runaway program cannot exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
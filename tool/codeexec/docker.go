@@ -0,0 +1,97 @@
+package codeexec
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultImage  = "python:3.12-slim"
+	defaultDocker = "docker"
+	defaultCPUs   = 1.0
+	defaultPids   = 128
+)
+
+// DockerBackend runs each snippet in a new container started with the
+// docker CLI. The container has no network, a read-only root filesystem
+// with a small writable /tmp, an unprivileged user, no added capabilities,
+// and limits on memory, CPUs, and process count. It is removed after the
+// run, and killed if the run times out.
+type DockerBackend struct {
+	config backendConfig
+}
+
+// NewDockerBackend returns a backend that runs code in Docker containers.
+// The default image is python:3.12-slim; the image must provide the
+// configured interpreters.
+func NewDockerBackend(opts ...BackendOption) *DockerBackend {
+	return &DockerBackend{config: newBackendConfig(opts)}
+}
+
+// Sandboxed reports true.
+func (b *DockerBackend) Sandboxed() bool { return true }
+
+// NetworkIsolated reports whether containers run without a network, which
+// holds unless [WithNetwork] chose one.
+func (b *DockerBackend) NetworkIsolated() bool {
+	return b.config.network == "none"
+}
+
+// Execute runs req in a new container.
+func (b *DockerBackend) Execute(
+	ctx context.Context,
+	req Request,
+) (Result, error) {
+	interpreter, err := b.config.interpreter(req.Language)
+	if err != nil {
+		return Result{}, err
+	}
+	name, err := containerName()
+	if err != nil {
+		return Result{}, err
+	}
+
+	args := []string{
+		"run", "--rm", "-i",
+		"--name", name,
+		"--network", b.config.network,
+		"--memory", strconv.FormatInt(b.config.memoryLimit, 10),
+		"--memory-swap", strconv.FormatInt(b.config.memoryLimit, 10),
+		"--cpus", strconv.FormatFloat(b.config.cpus, 'f', -1, 64),
+		"--pids-limit", strconv.Itoa(b.config.pids),
+		"--read-only",
+		"--tmpfs", "/tmp:rw,size=64m",
+		"--workdir", "/tmp",
+		"--user", "65534:65534",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+	for _, env := range b.config.env {
+		args = append(args, "--env", env)
+	}
+	args = append(args, b.config.image)
+	args = append(args, interpreter...)
+
+	cmd := exec.CommandContext(ctx, b.config.docker, args...)
+	return runCommand(ctx, cmd, req, func() {
+		killCtx, cancel := context.WithTimeout(
+			context.Background(),
+			10*time.Second,
+		)
+		defer cancel()
+		_ = exec.CommandContext(killCtx, b.config.docker, "kill", name).Run()
+	})
+}
+
+func containerName() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("codeexec: container name: %w", err)
+	}
+	return "codeexec-" + hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,120 @@
+package codeexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPython      = "python3"
+	defaultShell       = "sh"
+	defaultMemoryLimit = 512 << 20
+	defaultPath        = "/usr/local/bin:/usr/bin:/bin"
+)
+
+// LocalBackend runs code as a subprocess of the host. Each run gets a fresh
+// temporary working directory, an environment holding only PATH, HOME, and
+// LANG, and rlimits on address space and CPU time. It does not restrict the
+// filesystem or network, so it is not a sandbox: [New] requires
+// [AllowUnsandboxed] to use it. It needs a POSIX shell.
+type LocalBackend struct {
+	config backendConfig
+}
+
+// NewLocalBackend returns a backend that runs code on the host.
+func NewLocalBackend(opts ...BackendOption) *LocalBackend {
+	return &LocalBackend{config: newBackendConfig(opts)}
+}
+
+// Sandboxed reports false: code runs with the host user's permissions.
+func (b *LocalBackend) Sandboxed() bool { return false }
+
+// Execute runs req in a new subprocess.
+func (b *LocalBackend) Execute(
+	ctx context.Context,
+	req Request,
+) (Result, error) {
+	interpreter, err := b.config.interpreter(req.Language)
+	if err != nil {
+		return Result{}, err
+	}
+	dir, err := os.MkdirTemp("", "codeexec-")
+	if err != nil {
+		return Result{}, fmt.Errorf("codeexec: create work dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	limits := fmt.Sprintf(
+		"ulimit -v %d 2>/dev/null; ulimit -t %d 2>/dev/null; exec \"$@\"",
+		b.config.memoryLimit/1024,
+		cpuSeconds(ctx),
+	)
+	args := append([]string{"-c", limits, "sh"}, interpreter...)
+	cmd := exec.CommandContext(ctx, b.config.shell, args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PATH=" + defaultPath,
+		"HOME=" + dir,
+		"LANG=C.UTF-8",
+	}, b.config.env...)
+	return runCommand(ctx, cmd, req, nil)
+}
+
+// cpuSeconds returns the CPU time limit for a run bounded by ctx: the time
+// left until its deadline, rounded up, or 0 for no limit.
+func cpuSeconds(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return max(int(time.Until(deadline).Seconds())+1, 1)
+}
+
+// runCommand runs cmd with req.Code on stdin and collects its output. When
+// ctx ends first, cmd is killed, onTimeout is called if non-nil, and the
+// result is marked TimedOut.
+func runCommand(
+	ctx context.Context,
+	cmd *exec.Cmd,
+	req Request,
+	onTimeout func(),
+) (Result, error) {
+	stdout := &limitedBuffer{max: req.MaxOutput}
+	stderr := &limitedBuffer{max: req.MaxOutput}
+	cmd.Stdin = strings.NewReader(req.Code)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+		Duration:  time.Since(start),
+	}
+
+	if ctx.Err() != nil {
+		if onTimeout != nil {
+			onTimeout()
+		}
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return Result{}, fmt.Errorf("codeexec: run %s: %w", cmd.Path, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,500 @@
+// Package fetch provides a tool that lets a model make ad-hoc HTTP GET and
+// POST requests and read the response.
+//
+// Requests are limited to an allowlist of domains, and by default the tool
+// refuses to connect to loopback, private, and link-local addresses, even
+// when an allowed name resolves to one, so a model cannot be steered into
+// reaching internal services. Response bodies are cut at a size limit to
+// keep them from flooding the context window.
+//
+// Headers configured on the tool, such as API keys, are scoped to domains and
+// are never sent to a host outside their scope, including through redirects.
+//
+// Example usage:
+//
+//	fetcher, err := fetch.New(fetch.WithAllowedDomains("api.github.com"))
+//	if err != nil {
+//		return err
+//	}
+//	a := agent.New(llmClient, agent.WithTools(fetcher))
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const (
+	defaultToolName     = "fetch"
+	defaultTimeout      = 30 * time.Second
+	defaultMaxBodySize  = 64 * 1024
+	defaultMaxRedirects = 5
+)
+
+// ErrNoAllowlist is returned by [New] when no domains were allowed with
+// [WithAllowedDomains] and [AllowAnyDomain] was not passed.
+var ErrNoAllowlist = errors.New(
+	"fetch: no allowed domains; pass WithAllowedDomains or AllowAnyDomain",
+)
+
+// ErrUnscopedHeaders is returned by [New] when [WithHeaders] is combined with
+// [AllowAnyDomain], which would send the headers to any host the model picks.
+// Scope them with [WithDomainHeaders] instead.
+var ErrUnscopedHeaders = errors.New(
+	"fetch: WithHeaders needs an allowlist; use WithDomainHeaders " +
+		"with AllowAnyDomain",
+)
+
+// ErrBlockedAddress is reported when a request would connect to a loopback,
+// private, link-local, or otherwise non-public address.
+var ErrBlockedAddress = errors.New("fetch: address is not public")
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Options configures the fetch tool.
+type Options struct {
+	name                 string
+	description          string
+	allowedDomains       []string
+	allowAnyDomain       bool
+	allowPrivateNetworks bool
+	timeout              time.Duration
+	maxBodySize          int
+	headers              []scopedHeaders
+	requireConfirmation  bool
+}
+
+// scopedHeaders are headers sent to domain and its subdomains, or to every
+// allowed domain when domain is empty.
+type scopedHeaders struct {
+	domain  string
+	headers map[string]string
+}
+
+// Option configures [Options].
+type Option func(*Options)
+
+// WithName sets the tool name the model sees. Defaults to "fetch".
+func WithName(name string) Option {
+	return func(o *Options) { o.name = name }
+}
+
+// WithDescription replaces the tool description the model sees.
+func WithDescription(description string) Option {
+	return func(o *Options) { o.description = description }
+}
+
+// WithAllowedDomains adds domains the tool may request. A domain also allows
+// its subdomains, so "example.com" allows "api.example.com". Redirects are
+// only followed to allowed domains.
+func WithAllowedDomains(domains ...string) Option {
+	return func(o *Options) {
+		for _, d := range domains {
+			if d = normalizeDomain(d); d != "" {
+				o.allowedDomains = append(o.allowedDomains, d)
+			}
+		}
+	}
+}
+
+// AllowAnyDomain lets the tool request any domain. Addresses that are not
+// public stay blocked unless [AllowPrivateNetworks] is also passed.
+func AllowAnyDomain() Option {
+	return func(o *Options) { o.allowAnyDomain = true }
+}
+
+// AllowPrivateNetworks lets the tool connect to loopback, private, and
+// link-local addresses. Only use it when the model should reach internal
+// services, such as in tests against a local server.
+func AllowPrivateNetworks() Option {
+	return func(o *Options) { o.allowPrivateNetworks = true }
+}
+
+// WithTimeout limits how long one request, including reading the body, may
+// take. Defaults to 30 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.timeout = d }
+}
+
+// WithMaxBodySize caps the bytes of the response body returned to the model.
+// Defaults to 64 KiB.
+func WithMaxBodySize(n int) Option {
+	return func(o *Options) { o.maxBodySize = n }
+}
+
+// WithHeaders sets headers sent with every request to an allowed domain, such
+// as an API key. They override headers of the same name supplied by the model
+// and are never shown to it. [New] refuses them together with
+// [AllowAnyDomain]; use [WithDomainHeaders] there.
+func WithHeaders(headers map[string]string) Option {
+	return WithDomainHeaders("", headers)
+}
+
+// WithDomainHeaders sets headers sent only with requests to domain and its
+// subdomains, such as an API key for one service. Like [WithHeaders], they
+// override headers supplied by the model and are never shown to it. They
+// are dropped when a redirect leaves the domain, and headers for a more
+// specific domain win over broader ones.
+func WithDomainHeaders(domain string, headers map[string]string) Option {
+	return func(o *Options) {
+		o.headers = append(o.headers, scopedHeaders{
+			domain:  normalizeDomain(domain),
+			headers: maps.Clone(headers),
+		})
+	}
+}
+
+// WithConfirmation marks the tool as requiring human approval before each
+// request. See the agent's WithConfirmationProvider.
+func WithConfirmation() Option {
+	return func(o *Options) { o.requireConfirmation = true }
+}
+
+// Tool is a [tool.BaseTool] that makes HTTP requests for the model.
+type Tool struct {
+	client  *http.Client
+	options Options
+}
+
+// New returns a fetch tool. It returns [ErrNoAllowlist] unless
+// [WithAllowedDomains] or [AllowAnyDomain] is passed, and
+// [ErrUnscopedHeaders] when [WithHeaders] is combined with [AllowAnyDomain].
+func New(opts ...Option) (*Tool, error) {
+	options := Options{
+		name:        defaultToolName,
+		timeout:     defaultTimeout,
+		maxBodySize: defaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.allowedDomains) == 0 && !options.allowAnyDomain {
+		return nil, ErrNoAllowlist
+	}
+	if options.allowAnyDomain && slices.ContainsFunc(
+		options.headers,
+		func(h scopedHeaders) bool { return h.domain == "" },
+	) {
+		return nil, ErrUnscopedHeaders
+	}
+	slices.SortStableFunc(options.headers, func(a, b scopedHeaders) int {
+		return len(a.domain) - len(b.domain)
+	})
+	if options.timeout <= 0 {
+		options.timeout = defaultTimeout
+	}
+	if options.maxBodySize <= 0 {
+		options.maxBodySize = defaultMaxBodySize
+	}
+	if options.description == "" {
+		options.description = "Make an HTTP GET or POST request and return " +
+			"the response status, headers, and body. Long bodies are " +
+			"truncated."
+		if !options.allowAnyDomain {
+			options.description += " Only these domains (and their " +
+				"subdomains) can be requested: " +
+				strings.Join(options.allowedDomains, ", ") + "."
+		}
+	}
+
+	t := &Tool{options: options}
+	dialer := &net.Dialer{Timeout: options.timeout}
+	if !options.allowPrivateNetworks {
+		dialer.Control = guardAddress
+	}
+	t.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: t.checkRedirect,
+	}
+	return t, nil
+}
+
+// Info describes the tool to the model.
+func (t *Tool) Info() tool.Info {
+	return tool.Info{
+		Name:        t.options.name,
+		Description: t.options.description,
+		Parameters: map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The http or https URL to request",
+			},
+			"method": map[string]any{
+				"type":        "string",
+				"enum":        []string{http.MethodGet, http.MethodPost},
+				"description": "The HTTP method, defaults to GET",
+			},
+			"headers": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+				"description":          "Request headers",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Request body for POST",
+			},
+		},
+		Required:            []string{"url"},
+		RequireConfirmation: t.options.requireConfirmation,
+	}
+}
+
+type fetchParams struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// Run makes the request in params and returns the response. Refused
+// requests and transport failures are returned to the model as error
+// responses; HTTP error statuses are returned like any other response.
+func (t *Tool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input fetchParams
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+	method := strings.ToUpper(input.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodPost {
+		return tool.NewTextErrorResponse(fmt.Sprintf(
+			"unsupported method %q, use GET or POST",
+			input.Method,
+		)), nil
+	}
+	target, err := url.Parse(input.URL)
+	if err != nil {
+		return tool.NewTextErrorResponse("invalid url: " + err.Error()), nil
+	}
+	if err := t.checkURL(target); err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, t.options.timeout)
+	defer cancel()
+	var body io.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(input.Body)
+	}
+	req, err := http.NewRequestWithContext(
+		reqCtx,
+		method,
+		target.String(),
+		body,
+	)
+	if err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid request: " + err.Error(),
+		), nil
+	}
+	for k, v := range input.Headers {
+		req.Header.Set(k, v)
+	}
+	t.scopeHeaders(req)
+	if body != nil && req.Header.Get("Content-Type") == "" &&
+		json.Valid([]byte(input.Body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return tool.Response{}, ctx.Err()
+		}
+		return tool.NewTextErrorResponse(
+			"request failed: " + err.Error(),
+		), nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(
+		io.LimitReader(resp.Body, int64(t.options.maxBodySize)+1),
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return tool.Response{}, ctx.Err()
+		}
+		return tool.NewTextErrorResponse(
+			"reading response failed: " + err.Error(),
+		), nil
+	}
+	return tool.NewTextResponse(
+		FormatResponse(resp, data, t.options.maxBodySize),
+	), nil
+}
+
+// FormatResponse renders a response and up to maxBody bytes of its body as
+// the text the tool returns to the model. body may hold one byte more than
+// maxBody to signal that the body was cut.
+func FormatResponse(resp *http.Response, body []byte, maxBody int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "status: %s\n", resp.Status)
+	if len(resp.Header) > 0 {
+		b.WriteString("headers:\n")
+		for _, k := range slices.Sorted(maps.Keys(resp.Header)) {
+			fmt.Fprintf(&b, "%s: %s\n", k, strings.Join(resp.Header[k], ", "))
+		}
+	}
+
+	truncated := len(body) > maxBody
+	if truncated {
+		body = trimPartialRune(body[:maxBody])
+	}
+	switch {
+	case len(body) == 0:
+		b.WriteString("\n(empty body)\n")
+	case !utf8.Valid(body) || slices.Contains(body, 0):
+		fmt.Fprintf(
+			&b,
+			"\n(binary body, %s)\n",
+			resp.Header.Get("Content-Type"),
+		)
+	default:
+		b.WriteString("\n")
+		b.Write(body)
+		b.WriteString("\n")
+	}
+	if truncated {
+		fmt.Fprintf(&b, "[body truncated at %d bytes]\n", maxBody)
+	}
+	return b.String()
+}
+
+func trimPartialRune(b []byte) []byte {
+	for range utf8.UTFMax - 1 {
+		r, size := utf8.DecodeLastRune(b)
+		if len(b) == 0 || r != utf8.RuneError || size != 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+func (t *Tool) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf(
+			"unsupported url scheme %q, use http or https",
+			u.Scheme,
+		)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("url has no host")
+	}
+	if t.options.allowAnyDomain || t.domainAllowed(host) {
+		return nil
+	}
+	return fmt.Errorf("domain %q is not in the allowlist", host)
+}
+
+func (t *Tool) domainAllowed(host string) bool {
+	for _, d := range t.options.allowedDomains {
+		if inDomain(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeHeaders sets the configured headers whose domain covers the request's
+// host and removes the others, which a redirect may have carried over.
+func (t *Tool) scopeHeaders(req *http.Request) {
+	host := strings.TrimSuffix(strings.ToLower(req.URL.Hostname()), ".")
+	for _, h := range t.options.headers {
+		if h.domain == "" || inDomain(host, h.domain) {
+			continue
+		}
+		for k := range h.headers {
+			req.Header.Del(k)
+		}
+	}
+	for _, h := range t.options.headers {
+		if h.domain != "" && !inDomain(host, h.domain) {
+			continue
+		}
+		for k, v := range h.headers {
+			req.Header.Set(k, v)
+		}
+	}
+}
+
+func inDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func normalizeDomain(d string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+}
+
+func (t *Tool) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= defaultMaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", defaultMaxRedirects)
+	}
+	if err := t.checkURL(req.URL); err != nil {
+		return fmt.Errorf("redirect refused: %w", err)
+	}
+	t.scopeHeaders(req)
+	return nil
+}
+
+func guardAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	if !isPublic(addr.Unmap()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+	}
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+// Package fs provides file-system tools for coding agents: read_file,
+// write_file, list_dir, and search_files, all confined to a single root
+// directory.
+//
+// Paths from the model are resolved relative to the root through an
+// [os.Root], so neither ".." components nor symlinks can reach files outside
+// it. [WithReadOnly] leaves out the write tool entirely.
+//
+// Example usage:
+//
+//	files, err := fs.New("./workspace")
+//	if err != nil {
+//		return err
+//	}
+//	defer files.Close()
+//	a := agent.New(llmClient, agent.WithToolsets(files))
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const (
+	defaultToolsetName = "fs"
+	defaultMaxFileSize = 256 * 1024
+	defaultMaxResults  = 200
+)
+
+// Options configures the file-system tools.
+type Options struct {
+	name                string
+	readOnly            bool
+	maxFileSize         int64
+	maxResults          int
+	requireConfirmation bool
+}
+
+// Option configures [Options].
+type Option func(*Options)
+
+// WithName sets the toolset name. Defaults to "fs".
+func WithName(name string) Option {
+	return func(o *Options) { o.name = name }
+}
+
+// WithReadOnly leaves out the write_file tool so the model can only inspect
+// the root.
+func WithReadOnly() Option {
+	return func(o *Options) { o.readOnly = true }
+}
+
+// WithMaxFileSize caps the bytes read_file returns and the size of files
+// search_files looks inside. Defaults to 256 KiB.
+func WithMaxFileSize(n int64) Option {
+	return func(o *Options) { o.maxFileSize = n }
+}
+
+// WithMaxResults caps the entries list_dir returns and the matches
+// search_files returns. Defaults to 200.
+func WithMaxResults(n int) Option {
+	return func(o *Options) { o.maxResults = n }
+}
+
+// WithConfirmation marks write_file as requiring human approval before each
+// write. See the agent's WithConfirmationProvider.
+func WithConfirmation() Option {
+	return func(o *Options) { o.requireConfirmation = true }
+}
+
+// FS is a [tool.Toolset] of file-system tools confined to a root directory.
+// Close it when done to release the root.
+type FS struct {
+	root    *os.Root
+	options Options
+}
+
+// New opens dir as the root for the file-system tools. dir must exist and
+// be a directory.
+func New(dir string, opts ...Option) (*FS, error) {
+	options := Options{
+		name:        defaultToolsetName,
+		maxFileSize: defaultMaxFileSize,
+		maxResults:  defaultMaxResults,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.maxFileSize <= 0 {
+		options.maxFileSize = defaultMaxFileSize
+	}
+	if options.maxResults <= 0 {
+		options.maxResults = defaultMaxResults
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fs: open root: %w", err)
+	}
+	return &FS{root: root, options: options}, nil
+}
+
+// Root returns the directory the tools are confined to.
+func (f *FS) Root() string {
+	return f.root.Name()
+}
+
+// Close releases the root directory.
+func (f *FS) Close() error {
+	return f.root.Close()
+}
+
+// Name returns the toolset name.
+func (f *FS) Name() string {
+	return f.options.name
+}
+
+// Tools returns read_file, list_dir, search_files, and, unless
+// [WithReadOnly] was passed, write_file.
+func (f *FS) Tools(_ context.Context) []tool.BaseTool {
+	tools := []tool.BaseTool{
+		&readFileTool{fs: f},
+		&listDirTool{fs: f},
+		&searchFilesTool{fs: f},
+	}
+	if !f.options.readOnly {
+		tools = append(tools, &writeFileTool{fs: f})
+	}
+	return tools
+}
+
+// resolve turns a model-supplied path into a slash-separated path relative
+// to the root. Absolute paths are taken as relative to the root; paths that
+// climb out of it are rejected. The root itself also enforces this, including
+// for symlinks, when the path is opened.
+func resolve(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimLeft(filepath.ToSlash(path), "/")
+	if path == "" {
+		return ".", nil
+	}
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("path %q is outside the root directory", path)
+	}
+	return filepath.ToSlash(filepath.Clean(path)), nil
+}
+
+// describeError turns a file-system error into text for the model, without
+// the host path of the root.
+func describeError(path string, err error) string {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Sprintf("%s: no such file or directory", path)
+	case errors.Is(err, os.ErrPermission):
+		return fmt.Sprintf("%s: permission denied", path)
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return fmt.Sprintf("%s: %v", path, pathErr.Err)
+	}
+	return fmt.Sprintf("%s: %v", path, err)
+}
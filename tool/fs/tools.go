@@ -0,0 +1,501 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const maxMatchLineLength = 200
+
+var (
+	errResultLimit = errors.New("result limit reached")
+	errNotDir      = errors.New("not a directory")
+)
+
+func parseParams(params tool.Call, v any) *tool.Response {
+	if err := json.Unmarshal([]byte(params.Input), v); err != nil {
+		resp := tool.NewTextErrorResponse("invalid parameters: " + err.Error())
+		return &resp
+	}
+	return nil
+}
+
+func skipDir(name string) bool {
+	return name == ".git"
+}
+
+type readFileTool struct {
+	fs *FS
+}
+
+type readFileParams struct {
+	Path   string `json:"path"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+func (t *readFileTool) Info() tool.Info {
+	return tool.Info{
+		Name: "read_file",
+		Description: fmt.Sprintf(
+			"Read a text file under the working directory. Returns at "+
+				"most %d bytes; use offset and limit to read a long file "+
+				"in parts.",
+			t.fs.options.maxFileSize,
+		),
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to the working directory",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "First line to read, starting at 1",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of lines to read",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *readFileTool) Run(
+	_ context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input readFileParams
+	if resp := parseParams(params, &input); resp != nil {
+		return *resp, nil
+	}
+	name, err := resolve(input.Path)
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+
+	file, err := t.fs.root.Open(name)
+	if err != nil {
+		return tool.NewTextErrorResponse(describeError(name, err)), nil
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return tool.NewTextErrorResponse(
+			name + ": is a directory, use list_dir",
+		), nil
+	}
+
+	r := bufio.NewReader(file)
+	if head, _ := r.Peek(512); bytes.IndexByte(head, 0) >= 0 {
+		return tool.NewTextErrorResponse(name + ": binary file"), nil
+	}
+
+	offset := max(input.Offset, 1)
+	var b strings.Builder
+	lineNo, taken := 0, 0
+	more := false
+	for {
+		budget := t.fs.options.maxFileSize - int64(b.Len())
+		if lineNo+1 < offset {
+			budget = 0
+		}
+		line, n, err := readLine(r, budget)
+		if n > 0 {
+			lineNo++
+			if lineNo >= offset {
+				if input.Limit > 0 && taken >= input.Limit ||
+					n > budget && taken > 0 {
+					more = true
+					break
+				}
+				if n > budget {
+					line = trimPartialRune(line)
+					fmt.Fprintf(
+						&b,
+						"%s\n[line %d truncated to %d of %d bytes]\n",
+						line,
+						lineNo,
+						len(line),
+						n,
+					)
+				} else {
+					b.Write(line)
+				}
+				taken++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return tool.NewTextErrorResponse(describeError(name, err)), nil
+		}
+	}
+
+	if more {
+		fmt.Fprintf(
+			&b,
+			"\n[more lines follow; read from offset=%d]\n",
+			offset+taken,
+		)
+	}
+	if b.Len() == 0 {
+		return tool.NewTextResponse("(empty)"), nil
+	}
+	return tool.NewTextResponse(b.String()), nil
+}
+
+// readLine reads the next line from r, keeping at most limit bytes of it and
+// discarding the rest, so an oversized line is never held in memory. n is
+// the full length of the line.
+func readLine(r *bufio.Reader, limit int64) (line []byte, n int64, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		n += int64(len(chunk))
+		if keep := limit - int64(len(line)); keep > 0 {
+			line = append(line, chunk[:min(int64(len(chunk)), keep)]...)
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, n, err
+		}
+	}
+}
+
+// trimPartialRune drops a rune cut off at the end of line.
+func trimPartialRune(line []byte) []byte {
+	for i := len(line) - 1; i >= 0 && i >= len(line)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(line[i]) {
+			if !utf8.FullRune(line[i:]) {
+				return line[:i]
+			}
+			break
+		}
+	}
+	return line
+}
+
+type writeFileTool struct {
+	fs *FS
+}
+
+type writeFileParams struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Append  bool   `json:"append"`
+}
+
+func (t *writeFileTool) Info() tool.Info {
+	return tool.Info{
+		Name: "write_file",
+		Description: "Write a text file under the working directory, " +
+			"creating it and any missing parent directories. Replaces " +
+			"the file's contents unless append is true.",
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to the working directory",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The text to write",
+			},
+			"append": map[string]any{
+				"type":        "boolean",
+				"description": "Append to the file instead of replacing it",
+			},
+		},
+		Required:            []string{"path", "content"},
+		RequireConfirmation: t.fs.options.requireConfirmation,
+	}
+}
+
+func (t *writeFileTool) Run(
+	_ context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input writeFileParams
+	if resp := parseParams(params, &input); resp != nil {
+		return *resp, nil
+	}
+	name, err := resolve(input.Path)
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+	if name == "." {
+		return tool.NewTextErrorResponse("path is required"), nil
+	}
+
+	if dir := path.Dir(name); dir != "." {
+		if err := t.fs.root.MkdirAll(dir, 0o755); err != nil {
+			return tool.NewTextErrorResponse(describeError(dir, err)), nil
+		}
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if input.Append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := t.fs.root.OpenFile(name, flag, 0o644)
+	if err != nil {
+		return tool.NewTextErrorResponse(describeError(name, err)), nil
+	}
+	_, err = file.WriteString(input.Content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return tool.NewTextErrorResponse(describeError(name, err)), nil
+	}
+	return tool.NewTextResponse(fmt.Sprintf(
+		"wrote %d bytes to %s",
+		len(input.Content),
+		name,
+	)), nil
+}
+
+type listDirTool struct {
+	fs *FS
+}
+
+type listDirParams struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+func (t *listDirTool) Info() tool.Info {
+	return tool.Info{
+		Name: "list_dir",
+		Description: "List a directory under the working directory. " +
+			"Directories end in \"/\" and symlinks in \"@\". Paths are " +
+			"relative to the working directory.",
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory path, defaults to the working directory",
+			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "List subdirectories too, skipping .git",
+			},
+		},
+	}
+}
+
+func (t *listDirTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input listDirParams
+	if resp := parseParams(params, &input); resp != nil {
+		return *resp, nil
+	}
+	name, err := resolve(input.Path)
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+
+	var entries []string
+	limit := t.fs.options.maxResults
+	err = iofs.WalkDir(
+		t.fs.root.FS(),
+		name,
+		func(p string, d iofs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if p == name {
+				if !d.IsDir() {
+					return errNotDir
+				}
+				return nil
+			}
+			if len(entries) >= limit {
+				return errResultLimit
+			}
+			entries = append(entries, formatEntry(p, d))
+			if d.IsDir() && (!input.Recursive || skipDir(d.Name())) {
+				return iofs.SkipDir
+			}
+			return nil
+		},
+	)
+	truncated := errors.Is(err, errResultLimit)
+	if err != nil && !truncated {
+		if ctx.Err() != nil {
+			return tool.Response{}, ctx.Err()
+		}
+		return tool.NewTextErrorResponse(describeError(name, err)), nil
+	}
+
+	if len(entries) == 0 {
+		return tool.NewTextResponse("(empty directory)"), nil
+	}
+	text := strings.Join(entries, "\n")
+	if truncated {
+		text += fmt.Sprintf("\n[listing stopped at %d entries]", limit)
+	}
+	return tool.NewTextResponse(text), nil
+}
+
+func formatEntry(p string, d iofs.DirEntry) string {
+	switch {
+	case d.IsDir():
+		return p + "/"
+	case d.Type()&iofs.ModeSymlink != 0:
+		return p + "@"
+	}
+	return p
+}
+
+type searchFilesTool struct {
+	fs *FS
+}
+
+type searchFilesParams struct {
+	Pattern string `json:"pattern"`
+	Path    string `json:"path"`
+	Include string `json:"include"`
+}
+
+func (t *searchFilesTool) Info() tool.Info {
+	return tool.Info{
+		Name: "search_files",
+		Description: "Search the text files under a directory for lines " +
+			"matching a regular expression. Returns matches as " +
+			"path:line: text. Skips .git, symlinks, and binary files.",
+		Parameters: map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Regular expression (RE2 syntax) to search for",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory or file to search, defaults to the working directory",
+			},
+			"include": map[string]any{
+				"type":        "string",
+				"description": "Only search files whose name matches this glob, such as *.go",
+			},
+		},
+		Required: []string{"pattern"},
+	}
+}
+
+func (t *searchFilesTool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input searchFilesParams
+	if resp := parseParams(params, &input); resp != nil {
+		return *resp, nil
+	}
+	re, err := regexp.Compile(input.Pattern)
+	if err != nil {
+		return tool.NewTextErrorResponse("invalid pattern: " + err.Error()), nil
+	}
+	if _, err := path.Match(input.Include, ""); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid include glob: " + err.Error(),
+		), nil
+	}
+	name, err := resolve(input.Path)
+	if err != nil {
+		return tool.NewTextErrorResponse(err.Error()), nil
+	}
+
+	fsys := t.fs.root.FS()
+	var matches []string
+	limit := t.fs.options.maxResults
+	err = iofs.WalkDir(
+		fsys,
+		name,
+		func(p string, d iofs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if p != name && skipDir(d.Name()) {
+					return iofs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if input.Include != "" {
+				if ok, _ := path.Match(input.Include, d.Name()); !ok {
+					return nil
+				}
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() > t.fs.options.maxFileSize {
+				return nil
+			}
+			data, err := iofs.ReadFile(fsys, p)
+			if err != nil || bytes.IndexByte(data, 0) >= 0 {
+				return nil
+			}
+			for i, line := range strings.Split(string(data), "\n") {
+				if !re.MatchString(line) {
+					continue
+				}
+				if len(matches) >= limit {
+					return errResultLimit
+				}
+				matches = append(matches, fmt.Sprintf(
+					"%s:%d: %s",
+					p,
+					i+1,
+					truncateLine(line),
+				))
+			}
+			return nil
+		},
+	)
+	truncated := errors.Is(err, errResultLimit)
+	if err != nil && !truncated {
+		if ctx.Err() != nil {
+			return tool.Response{}, ctx.Err()
+		}
+		return tool.NewTextErrorResponse(describeError(name, err)), nil
+	}
+
+	if len(matches) == 0 {
+		return tool.NewTextResponse("no matches"), nil
+	}
+	text := strings.Join(matches, "\n")
+	if truncated {
+		text += fmt.Sprintf("\n[search stopped at %d matches]", limit)
+	}
+	return tool.NewTextResponse(text), nil
+}
+
+func truncateLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if len(line) <= maxMatchLineLength {
+		return line
+	}
+	cut := maxMatchLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
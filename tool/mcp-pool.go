@@ -36,12 +36,14 @@ func (s *sessionWrapper) Close() error {
 type mcpClientPool struct {
 	clients map[string]MCPClient
 	configs map[string]MCPServer
+	leases  map[string]int
 	mu      sync.RWMutex
 }
 
 var pool = &mcpClientPool{
 	clients: make(map[string]MCPClient),
 	configs: make(map[string]MCPServer),
+	leases:  make(map[string]int),
 }
 
 func (p *mcpClientPool) getClient(
@@ -77,7 +79,13 @@ func (p *mcpClientPool) getClient(
 		if len(config.Env) > 0 {
 			cmd.Env = append(os.Environ(), config.Env...)
 		}
-		transport = &mcp.CommandTransport{Command: cmd}
+		startProcessGroup(cmd)
+		transport = &processGroupTransport{
+			CommandTransport: &mcp.CommandTransport{
+				Command:           cmd,
+				TerminateDuration: config.TerminateTimeout,
+			},
+		}
 	case MCPSse:
 		httpClient := &http.Client{}
 		if len(config.Headers) > 0 {
@@ -131,6 +139,34 @@ func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return h.base.RoundTrip(req)
 }
 
+// processGroupTransport starts a stdio server in its own process group and
+// kills the group once the connection is closed, so processes the server
+// started, such as the package a launcher like npx runs, exit with it.
+type processGroupTransport struct {
+	*mcp.CommandTransport
+}
+
+func (t *processGroupTransport) Connect(
+	ctx context.Context,
+) (mcp.Connection, error) {
+	conn, err := t.CommandTransport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &processGroupConn{Connection: conn, cmd: t.Command}, nil
+}
+
+type processGroupConn struct {
+	mcp.Connection
+	cmd *exec.Cmd
+}
+
+func (c *processGroupConn) Close() error {
+	err := c.Connection.Close()
+	killProcessGroup(c.cmd)
+	return err
+}
+
 func (p *mcpClientPool) closeAll() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -142,7 +178,96 @@ func (p *mcpClientPool) closeAll() {
 	p.configs = make(map[string]MCPServer)
 }
 
+// acquire takes a lease on each named server, keeping it open until the
+// lease is released.
+func (p *mcpClientPool) acquire(names []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, name := range names {
+		p.leases[name]++
+	}
+}
+
+// release gives up a lease on name, closing the server when it was the
+// last one. With closeIdle false the server stays open either way.
+func (p *mcpClientPool) release(name string, closeIdle bool) error {
+	p.mu.Lock()
+	if n := p.leases[name]; n > 1 {
+		p.leases[name] = n - 1
+		p.mu.Unlock()
+		return nil
+	}
+	delete(p.leases, name)
+	p.mu.Unlock()
+	if !closeIdle {
+		return nil
+	}
+	return p.close(name)
+}
+
+func (p *mcpClientPool) close(name string) error {
+	p.mu.Lock()
+	client, exists := p.clients[name]
+	delete(p.clients, name)
+	delete(p.configs, name)
+	p.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	if err := client.Close(); err != nil {
+		return fmt.Errorf("failed to close MCP server %s: %w", name, err)
+	}
+	return nil
+}
+
 // CloseMCPPool closes all pooled MCP clients and clears cached connections and configs.
 func CloseMCPPool() {
 	pool.closeAll()
 }
+
+// CloseMCPServer closes the pooled connection to the named MCP server and
+// leaves the others open, even while requests hold it with
+// [CloseMCPServersWhenDone]. For a stdio server the subprocess's stdin is
+// closed and it is given [MCPServer.TerminateTimeout] to exit before it is
+// sent SIGTERM, then killed if it still has not exited after the same
+// period again. On Unix the rest of its process group is then killed. It
+// does nothing when no connection to name is open. Tools from the server
+// reconnect on their next call.
+func CloseMCPServer(name string) error {
+	return pool.close(name)
+}
+
+// CloseMCPServersWhenDone holds the named MCP servers open for ctx and
+// releases them once ctx is done. Concurrent requests share the pooled
+// servers, so each server is closed, as [CloseMCPServer] does, only when the
+// last context holding it is done; a request ending never closes a server
+// another request still uses. Calling the returned stop function before then
+// releases the servers without closing them; it reports whether it stopped
+// the cleanup.
+//
+//	tools, err := tool.GetMcpTools(ctx, servers)
+//	if err != nil {
+//		return err
+//	}
+//	tool.CloseMCPServersWhenDone(ctx, "filesystem")
+func CloseMCPServersWhenDone(
+	ctx context.Context,
+	names ...string,
+) (stop func() bool) {
+	pool.acquire(names)
+	stopCleanup := context.AfterFunc(ctx, func() {
+		for _, name := range names {
+			_ = pool.release(name, true)
+		}
+	})
+	return func() bool {
+		if !stopCleanup() {
+			return false
+		}
+		for _, name := range names {
+			_ = pool.release(name, false)
+		}
+		return true
+	}
+}
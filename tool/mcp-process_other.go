@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tool
+
+import "os/exec"
+
+func startProcessGroup(*exec.Cmd) {}
+
+func killProcessGroup(*exec.Cmd) {}
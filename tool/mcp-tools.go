@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -19,9 +22,10 @@ const (
 )
 
 type mcpTool struct {
-	mcpName   string
-	tool      *mcp.Tool
-	mcpConfig MCPServer
+	mcpName      string
+	tool         *mcp.Tool
+	mcpConfig    MCPServer
+	outputSchema *jsonschema.Resolved
 }
 
 // MCPClient is the subset of MCP session operations needed to list and invoke tools.
@@ -45,6 +49,10 @@ type MCPServer struct {
 	Type    MCPType           `json:"type"`
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers"`
+	// TerminateTimeout is how long a stdio server gets to exit after its
+	// stdin is closed, and again after SIGTERM, before it is killed. Zero
+	// means five seconds.
+	TerminateTimeout time.Duration `json:"terminate_timeout"`
 }
 
 func (b *mcpTool) Info() Info {
@@ -66,12 +74,50 @@ func (b *mcpTool) Info() Info {
 		}
 	}
 
+	outputSchema, _ := cloneJSON(b.tool.OutputSchema).(map[string]any)
+
 	return Info{
-		Name:        fmt.Sprintf("%s_%s", b.mcpName, b.tool.Name),
-		Description: b.tool.Description,
-		Parameters:  params,
-		Required:    required,
+		Name:         fmt.Sprintf("%s_%s", b.mcpName, b.tool.Name),
+		Description:  b.tool.Description,
+		Parameters:   params,
+		Required:     required,
+		OutputSchema: outputSchema,
+	}
+}
+
+// cloneJSON deep-copies the maps and slices of a decoded JSON value, so callers
+// cannot modify the tool definition shared by the pool.
+func cloneJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = cloneJSON(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = cloneJSON(e)
+		}
+		return out
+	}
+	return v
+}
+
+func resolveOutputSchema(raw any) (*jsonschema.Resolved, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
 	}
+	return s.Resolve(nil)
 }
 
 func runTool(
@@ -79,6 +125,7 @@ func runTool(
 	c MCPClient,
 	toolName string,
 	input string,
+	outputSchema *jsonschema.Resolved,
 ) (Response, error) {
 	var args map[string]any
 	if err := json.Unmarshal([]byte(input), &args); err != nil {
@@ -97,6 +144,22 @@ func runTool(
 		return NewTextErrorResponse(err.Error()), nil
 	}
 
+	if outputSchema != nil && !result.IsError {
+		if result.StructuredContent == nil {
+			return NewTextErrorResponse(fmt.Sprintf(
+				"tool %s declares an output schema but returned no structured content",
+				toolName,
+			)), nil
+		}
+		if err := outputSchema.Validate(result.StructuredContent); err != nil {
+			return NewTextErrorResponse(fmt.Sprintf(
+				"tool %s returned structured content that does not match its output schema: %s",
+				toolName,
+				err,
+			)), nil
+		}
+	}
+
 	output := ""
 	for _, content := range result.Content {
 		if textContent, ok := content.(*mcp.TextContent); ok {
@@ -106,6 +169,9 @@ func runTool(
 		}
 	}
 
+	if output == "" && result.StructuredContent != nil {
+		return NewJSONResponse(result.StructuredContent), nil
+	}
 	return NewTextResponse(output), nil
 }
 
@@ -117,18 +183,31 @@ func (b *mcpTool) Run(
 	if err != nil {
 		return NewTextErrorResponse(err.Error()), nil
 	}
-	return runTool(ctx, c, b.tool.Name, params.Input)
+	return runTool(ctx, c, b.tool.Name, params.Input, b.outputSchema)
 }
 
+// newMcpTool wraps an MCP tool. An output schema that cannot be resolved,
+// such as one with a remote $ref, is logged and the tool's results are not
+// validated, so one such tool does not make the rest of the server unusable.
 func newMcpTool(
+	ctx context.Context,
 	name string,
 	tool *mcp.Tool,
 	mcpConfig MCPServer,
 ) BaseTool {
+	outputSchema, err := resolveOutputSchema(tool.OutputSchema)
+	if err != nil {
+		slog.WarnContext(ctx, "mcp output schema not validated",
+			slog.String("server", name),
+			slog.String("tool", tool.Name),
+			slog.String("error", err.Error()),
+		)
+	}
 	return &mcpTool{
-		mcpName:   name,
-		tool:      tool,
-		mcpConfig: mcpConfig,
+		mcpName:      name,
+		tool:         tool,
+		mcpConfig:    mcpConfig,
+		outputSchema: outputSchema,
 	}
 }
 
@@ -149,12 +228,16 @@ func getTools(
 		return nil, fmt.Errorf("error listing tools for %s: %w", name, err)
 	}
 	for _, t := range tools.Tools {
-		stdioTools = append(stdioTools, newMcpTool(name, t, m))
+		stdioTools = append(stdioTools, newMcpTool(ctx, name, t, m))
 	}
 	return stdioTools, nil
 }
 
-// GetMcpTools connects to MCP servers and returns available tools
+// GetMcpTools connects to MCP servers and returns available tools. A tool
+// that declares an output schema reports it as [Info.OutputSchema], and its
+// results are checked against it: structured content that is missing or does
+// not match is returned as an error response. An output schema that cannot be
+// resolved is logged and that tool's results are returned unchecked.
 func GetMcpTools(
 	ctx context.Context,
 	servers map[string]MCPServer,
@@ -0,0 +1,100 @@
+package tool
+
+import (
+	"context"
+	"strconv"
+)
+
+// NamespaceSeparator joins a namespace and a tool name, as in
+// "github_search". Provider APIs only accept letters, digits, underscores,
+// and hyphens in tool names, so a dot cannot be used. MCP tools are named
+// the same way after their server.
+const NamespaceSeparator = "_"
+
+// Renamed returns t exposed to the model under name. Calls made under the new
+// name reach t with [Call].Name set back to its own name, so the tool never
+// sees the rename.
+func Renamed(t BaseTool, name string) BaseTool {
+	return &renamedTool{inner: t, name: name}
+}
+
+// Namespaced returns t exposed under prefix and its own name joined by
+// [NamespaceSeparator], so tools from different sources that share a name,
+// such as two search tools, stay distinct.
+func Namespaced(prefix string, t BaseTool) BaseTool {
+	return Renamed(t, prefix+NamespaceSeparator+t.Info().Name)
+}
+
+// NamespacedToolset wraps a toolset so that every tool it returns is
+// [Namespaced] under prefix. The toolset takes prefix as its name.
+func NamespacedToolset(prefix string, inner Toolset) Toolset {
+	return &namespacedToolset{prefix: prefix, inner: inner}
+}
+
+// Deduplicate returns tools with every name made unique. The first tool with
+// a name keeps it; each later one is renamed to namespace_name when
+// namespace is set and that name is free, or else gets a numeric suffix
+// ("search_2"). taken holds names already in use alongside tools and is
+// updated with the names handed out; pass nil to start fresh.
+func Deduplicate(
+	tools []BaseTool,
+	namespace string,
+	taken map[string]bool,
+) []BaseTool {
+	if taken == nil {
+		taken = make(map[string]bool, len(tools))
+	}
+	out := make([]BaseTool, len(tools))
+	for i, t := range tools {
+		name := t.Info().Name
+		if !taken[name] {
+			taken[name] = true
+			out[i] = t
+			continue
+		}
+		unique := namespace + NamespaceSeparator + name
+		if namespace == "" || taken[unique] {
+			for n := 2; ; n++ {
+				unique = name + "_" + strconv.Itoa(n)
+				if !taken[unique] {
+					break
+				}
+			}
+		}
+		taken[unique] = true
+		out[i] = Renamed(t, unique)
+	}
+	return out
+}
+
+type renamedTool struct {
+	inner BaseTool
+	name  string
+}
+
+func (r *renamedTool) Info() Info {
+	info := r.inner.Info()
+	info.Name = r.name
+	return info
+}
+
+func (r *renamedTool) Run(ctx context.Context, params Call) (Response, error) {
+	params.Name = r.inner.Info().Name
+	return r.inner.Run(ctx, params)
+}
+
+type namespacedToolset struct {
+	prefix string
+	inner  Toolset
+}
+
+func (n *namespacedToolset) Name() string { return n.prefix }
+
+func (n *namespacedToolset) Tools(ctx context.Context) []BaseTool {
+	tools := n.inner.Tools(ctx)
+	wrapped := make([]BaseTool, len(tools))
+	for i, t := range tools {
+		wrapped[i] = Namespaced(n.prefix, t)
+	}
+	return wrapped
+}
@@ -0,0 +1,68 @@
+package tool
+
+import "context"
+
+// Progress is an intermediate status update from a running tool, such as
+// "compiling" at 40 percent. It is shown to the caller, not to the model;
+// the model only sees the tool's final [Response].
+type Progress struct {
+	// Message describes the current step.
+	Message string
+	// Percent is the completion from 0 to 100, or negative when unknown.
+	Percent float64
+	// Data carries optional tool-specific detail for the UI.
+	Data any
+}
+
+// ProgressEmitter receives progress updates from a running tool. The agent
+// layer installs one in the context passed to Run while streaming; tools
+// report through [ReportProgress] rather than calling it directly.
+// Implementations must be safe for concurrent use, as parallel tool calls
+// share one emitter, and should not block for long, as the tool waits for
+// Emit to return.
+type ProgressEmitter interface {
+	Emit(ctx context.Context, p Progress)
+}
+
+// ProgressEmitterFunc adapts a function to the [ProgressEmitter] interface.
+type ProgressEmitterFunc func(ctx context.Context, p Progress)
+
+// Emit calls f.
+func (f ProgressEmitterFunc) Emit(ctx context.Context, p Progress) {
+	f(ctx, p)
+}
+
+type progressEmitterKey struct{}
+
+// WithProgressEmitter returns a new context carrying the given emitter.
+// The agent layer uses this to inject an emitter before calling tool.Run().
+func WithProgressEmitter(
+	ctx context.Context,
+	emitter ProgressEmitter,
+) context.Context {
+	return context.WithValue(ctx, progressEmitterKey{}, emitter)
+}
+
+// ReportProgress sends a progress update from within a tool's Run() method.
+// The final result is still returned from Run as usual.
+//
+// If no emitter is installed, for example when the agent runs with Chat
+// rather than ChatStream, this is a no-op, so tools can report progress
+// unconditionally.
+//
+// Example usage inside a tool's Run():
+//
+//	for i, step := range steps {
+//	    tool.ReportProgress(ctx, tool.Progress{
+//	        Message: step.Name,
+//	        Percent: float64(i) / float64(len(steps)) * 100,
+//	    })
+//	    // run the step
+//	}
+func ReportProgress(ctx context.Context, p Progress) {
+	emitter, ok := ctx.Value(progressEmitterKey{}).(ProgressEmitter)
+	if !ok || emitter == nil {
+		return
+	}
+	emitter.Emit(ctx, p)
+}
@@ -40,7 +40,10 @@ package tool
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"strings"
 )
 
 // BaseTool defines the interface that all tools must implement.
@@ -66,6 +69,9 @@ type Info struct {
 	// When set and a ConfirmationProvider is configured on the agent, the provider
 	// is consulted before Run() is called.
 	RequireConfirmation bool `json:"-"`
+	// OutputSchema is the JSON Schema the tool's structured results conform
+	// to, when the tool declares one, as MCP tools may.
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
 }
 
 // NewInfo builds registration metadata from a name, description, and a struct type used for schema generation.
@@ -89,7 +95,7 @@ type Call struct {
 	Input string `json:"input"`
 }
 
-// ResponseType discriminates how tool output is encoded (text, image, file, or JSON).
+// ResponseType discriminates how tool output is encoded (text, image, file, JSON, or multi-part).
 type ResponseType string
 
 // Standard values for Response.Type when returning results to the model.
@@ -98,16 +104,36 @@ const (
 	ResponseTypeImage ResponseType = "image"
 	ResponseTypeFile  ResponseType = "file"
 	ResponseTypeJSON  ResponseType = "json"
+	ResponseTypeParts ResponseType = "parts"
 )
 
+// ContentPart is one piece of a multi-part tool response: either text or an
+// image with its MIME type.
+type ContentPart struct {
+	Text     string `json:"text,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Text: text}
+}
+
+// ImagePart returns an image content part carrying raw image bytes.
+func ImagePart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Data: data, MimeType: mimeType}
+}
+
 // Response holds the result of executing a tool: payload type, content or raw data, and optional error flag.
 type Response struct {
-	Type     ResponseType `json:"type"`
-	Content  string       `json:"content"`
-	Data     []byte       `json:"data,omitempty"`
-	MimeType string       `json:"mime_type,omitempty"`
-	Metadata string       `json:"metadata,omitempty"`
-	IsError  bool         `json:"is_error"`
+	Type     ResponseType  `json:"type"`
+	Content  string        `json:"content"`
+	Data     []byte        `json:"data,omitempty"`
+	MimeType string        `json:"mime_type,omitempty"`
+	Parts    []ContentPart `json:"parts,omitempty"`
+	Metadata string        `json:"metadata,omitempty"`
+	IsError  bool          `json:"is_error"`
 }
 
 // NewTextResponse creates a successful text response.
@@ -128,13 +154,42 @@ func NewTextErrorResponse(content string) Response {
 	}
 }
 
-// NewImageResponse creates a successful image response.
+// NewImageResponse creates a successful image response. Content may be
+// base64-encoded image data, a base64 data URL, or an image URL. Encoded data
+// is decoded into Data with its MIME type taken from the data URL or detected
+// from the bytes, so providers that accept images in tool results (such as
+// Anthropic) can show the image to the model. Anything that does not decode
+// to an image is kept in Content and sent as text.
 func NewImageResponse(content string) Response {
-	return Response{
+	resp := Response{
 		Type:    ResponseTypeImage,
 		Content: content,
 		IsError: false,
 	}
+
+	encoded, mimeType := content, ""
+	if rest, ok := strings.CutPrefix(content, "data:"); ok {
+		header, payload, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return resp
+		}
+		encoded, mimeType = payload, strings.TrimSuffix(header, ";base64")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return resp
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return resp
+	}
+	resp.Content = ""
+	resp.Data = data
+	resp.MimeType = mimeType
+	return resp
 }
 
 // NewFileResponse returns a non-error response carrying raw bytes and a MIME type for file-style tool output.
@@ -161,6 +216,26 @@ func NewJSONResponse(v any) Response {
 	}
 }
 
+// NewMultiPartResponse returns a successful response made of text and image
+// parts, such as a chart with a caption. Content holds the text parts joined by
+// newlines so text-only consumers still see them. Providers that accept images
+// in tool results (such as Anthropic) receive the images; others get a note in
+// their place.
+func NewMultiPartResponse(parts ...ContentPart) Response {
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return Response{
+		Type:    ResponseTypeParts,
+		Content: strings.Join(texts, "\n"),
+		Parts:   parts,
+		IsError: false,
+	}
+}
+
 // WithResponseMetadata adds JSON metadata to a tool response.
 func WithResponseMetadata(response Response, metadata any) Response {
 	if metadata != nil {
@@ -185,8 +260,30 @@ func NewRegistry() *Registry {
 	}
 }
 
-// Register adds a tool to the registry.
-func (r *Registry) Register(tool BaseTool) {
+// RegisterOption configures how [Registry.Register] adds a tool.
+type RegisterOption func(*registerOptions)
+
+type registerOptions struct {
+	namespace string
+}
+
+// WithNamespace registers the tool under prefix, as [Namespaced] does, so
+// tools from several sources can share a name. Execute maps calls to the
+// namespaced name back to the tool.
+func WithNamespace(prefix string) RegisterOption {
+	return func(o *registerOptions) { o.namespace = prefix }
+}
+
+// Register adds a tool to the registry, replacing any tool already
+// registered under the same name.
+func (r *Registry) Register(tool BaseTool, opts ...RegisterOption) {
+	var o registerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.namespace != "" {
+		tool = Namespaced(o.namespace, tool)
+	}
 	r.tools[tool.Info().Name] = tool
 }
 
@@ -0,0 +1,225 @@
+package websearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	tavilyBaseURL  = "https://api.tavily.com"
+	braveBaseURL   = "https://api.search.brave.com/res/v1"
+	serpAPIBaseURL = "https://serpapi.com"
+)
+
+type tavilyBackend struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newTavily(apiKey, baseURL string, client *http.Client) *tavilyBackend {
+	if baseURL == "" {
+		baseURL = tavilyBaseURL
+	}
+	return &tavilyBackend{apiKey: apiKey, baseURL: baseURL, client: client}
+}
+
+func (b *tavilyBackend) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+) ([]Result, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":       query,
+		"max_results": maxResults,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tavily: encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		b.baseURL+"/search",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tavily: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.apiKey)
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doJSON(b.client, req, "tavily", &resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{
+			Title:   r.Title,
+			URL:     r.URL,
+			Snippet: r.Content,
+		})
+	}
+	return results, nil
+}
+
+type braveBackend struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newBrave(apiKey, baseURL string, client *http.Client) *braveBackend {
+	if baseURL == "" {
+		baseURL = braveBaseURL
+	}
+	return &braveBackend{apiKey: apiKey, baseURL: baseURL, client: client}
+}
+
+func (b *braveBackend) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(maxResults))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		b.baseURL+"/web/search?"+params.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("brave: create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", b.apiKey)
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := doJSON(b.client, req, "brave", &resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, Result{
+			Title:   r.Title,
+			URL:     r.URL,
+			Snippet: r.Description,
+		})
+	}
+	return results, nil
+}
+
+type serpAPIBackend struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newSerpAPI(apiKey, baseURL string, client *http.Client) *serpAPIBackend {
+	if baseURL == "" {
+		baseURL = serpAPIBaseURL
+	}
+	return &serpAPIBackend{apiKey: apiKey, baseURL: baseURL, client: client}
+}
+
+func (b *serpAPIBackend) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+) ([]Result, error) {
+	params := url.Values{}
+	params.Set("engine", "google")
+	params.Set("q", query)
+	params.Set("num", strconv.Itoa(maxResults))
+	params.Set("api_key", b.apiKey)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		b.baseURL+"/search.json?"+params.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("serpapi: create request: %w", err)
+	}
+
+	var resp struct {
+		Error          string `json:"error"`
+		OrganicResults []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"organic_results"`
+	}
+	if err := doJSON(b.client, req, "serpapi", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" && len(resp.OrganicResults) == 0 {
+		if resp.Error == "Google hasn't returned any results for this query." {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("serpapi: %s", resp.Error)
+	}
+	results := make([]Result, 0, len(resp.OrganicResults))
+	for _, r := range resp.OrganicResults {
+		results = append(results, Result{
+			Title:   r.Title,
+			URL:     r.Link,
+			Snippet: r.Snippet,
+		})
+	}
+	return results, nil
+}
+
+// doJSON sends req and decodes a successful JSON response into out. The
+// response body is included in the error for non-2xx statuses.
+func doJSON(
+	client *http.Client,
+	req *http.Request,
+	name string,
+	out any,
+) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: request failed: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: read response: %w", name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(
+			"%s: request failed with status %d: %s",
+			name,
+			resp.StatusCode,
+			bytes.TrimSpace(body),
+		)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", name, err)
+	}
+	return nil
+}
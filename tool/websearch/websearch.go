@@ -0,0 +1,231 @@
+// Package websearch provides a ready-made web search tool for agents whose
+// provider has no native search. Searches go through a [SearchBackend]:
+// Tavily, Brave, and SerpAPI are built in, and any other search API can be
+// plugged in by implementing the interface.
+//
+// Example usage:
+//
+//	search, err := websearch.New(websearch.ProviderTavily, os.Getenv("TAVILY_API_KEY"))
+//	if err != nil {
+//		return err
+//	}
+//	a := agent.New(llmClient, agent.WithTools(search))
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/ai/tool"
+)
+
+const (
+	defaultToolName   = "web_search"
+	defaultMaxResults = 5
+	maxMaxResults     = 20
+	defaultTimeout    = 30 * time.Second
+)
+
+// Result is a single search hit.
+type Result struct {
+	// Title is the page title.
+	Title string `json:"title"`
+	// URL is the page address, which the model can cite.
+	URL string `json:"url"`
+	// Snippet is a short extract of the page relevant to the query.
+	Snippet string `json:"snippet"`
+}
+
+// SearchBackend runs web searches for the tool. Implement it to use a search
+// API that is not built in.
+type SearchBackend interface {
+	// Search returns up to maxResults results for query, best first.
+	Search(
+		ctx context.Context,
+		query string,
+		maxResults int,
+	) ([]Result, error)
+}
+
+// SearchBackendFunc adapts a function to the [SearchBackend] interface.
+type SearchBackendFunc func(
+	ctx context.Context,
+	query string,
+	maxResults int,
+) ([]Result, error)
+
+// Search calls f.
+func (f SearchBackendFunc) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+) ([]Result, error) {
+	return f(ctx, query, maxResults)
+}
+
+// Provider names a built-in search backend.
+type Provider string
+
+// Built-in search backends.
+const (
+	ProviderTavily  Provider = "tavily"
+	ProviderBrave   Provider = "brave"
+	ProviderSerpAPI Provider = "serpapi"
+)
+
+// Options configures the web search tool and its built-in backend.
+type Options struct {
+	name        string
+	description string
+	maxResults  int
+	baseURL     string
+	httpClient  *http.Client
+}
+
+// Option configures [Options].
+type Option func(*Options)
+
+// WithName sets the tool name the model sees. Defaults to "web_search".
+func WithName(name string) Option {
+	return func(o *Options) { o.name = name }
+}
+
+// WithDescription replaces the tool description the model sees.
+func WithDescription(description string) Option {
+	return func(o *Options) { o.description = description }
+}
+
+// WithMaxResults sets how many results a search returns when the model does
+// not ask for a number. Defaults to 5; the model may ask for up to 20.
+func WithMaxResults(n int) Option {
+	return func(o *Options) { o.maxResults = n }
+}
+
+// WithBaseURL overrides the API endpoint of a built-in backend, for proxies
+// and tests.
+func WithBaseURL(url string) Option {
+	return func(o *Options) { o.baseURL = strings.TrimSuffix(url, "/") }
+}
+
+// WithHTTPClient sets the HTTP client used by a built-in backend. Defaults
+// to a client with a 30 second timeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *Options) { o.httpClient = c }
+}
+
+// Tool is a [tool.BaseTool] that searches the web and returns titles, URLs,
+// and snippets the model can cite.
+type Tool struct {
+	backend SearchBackend
+	options Options
+}
+
+// New returns a web search tool backed by a built-in provider, authenticated
+// with apiKey. It returns an error for an unknown provider or an empty key.
+func New(provider Provider, apiKey string, opts ...Option) (*Tool, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("websearch: %s: API key is required", provider)
+	}
+	t := NewWithBackend(nil, opts...)
+	client := t.options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	switch provider {
+	case ProviderTavily:
+		t.backend = newTavily(apiKey, t.options.baseURL, client)
+	case ProviderBrave:
+		t.backend = newBrave(apiKey, t.options.baseURL, client)
+	case ProviderSerpAPI:
+		t.backend = newSerpAPI(apiKey, t.options.baseURL, client)
+	default:
+		return nil, fmt.Errorf("websearch: unknown provider %q", provider)
+	}
+	return t, nil
+}
+
+// NewWithBackend returns a web search tool that searches through backend.
+// WithBaseURL and WithHTTPClient do not apply.
+func NewWithBackend(backend SearchBackend, opts ...Option) *Tool {
+	options := Options{
+		name:       defaultToolName,
+		maxResults: defaultMaxResults,
+		description: "Search the web for current information. Returns " +
+			"titles, URLs, and snippets of matching pages. Cite the URLs " +
+			"of the results you use.",
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.maxResults <= 0 {
+		options.maxResults = defaultMaxResults
+	}
+	return &Tool{backend: backend, options: options}
+}
+
+type searchParams struct {
+	Query      string `json:"query"       desc:"The search query"`
+	MaxResults int    `json:"max_results" desc:"Number of results to return, up to 20" required:"false"`
+}
+
+// Info describes the tool to the model.
+func (t *Tool) Info() tool.Info {
+	return tool.NewInfo(t.options.name, t.options.description, searchParams{})
+}
+
+// Run searches for the query in params and returns the results as numbered
+// text, with the []Result attached as JSON response metadata. Search
+// failures are returned to the model as error responses.
+func (t *Tool) Run(
+	ctx context.Context,
+	params tool.Call,
+) (tool.Response, error) {
+	var input searchParams
+	if err := json.Unmarshal([]byte(params.Input), &input); err != nil {
+		return tool.NewTextErrorResponse(
+			"invalid parameters: " + err.Error(),
+		), nil
+	}
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return tool.NewTextErrorResponse("query is required"), nil
+	}
+	limit := input.MaxResults
+	if limit <= 0 {
+		limit = t.options.maxResults
+	}
+	limit = min(limit, maxMaxResults)
+
+	results, err := t.backend.Search(ctx, query, limit)
+	if err != nil {
+		return tool.NewTextErrorResponse("search failed: " + err.Error()), nil
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return tool.WithResponseMetadata(
+		tool.NewTextResponse(FormatResults(query, results)),
+		results,
+	), nil
+}
+
+// FormatResults renders results as the numbered list the tool returns to
+// the model.
+func FormatResults(query string, results []Result) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No results found for %q.", query)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Search results for %q:\n", query)
+	for i, r := range results {
+		fmt.Fprintf(&b, "\n%d. %s\n   URL: %s\n", i+1, r.Title, r.URL)
+		if snippet := strings.TrimSpace(r.Snippet); snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", snippet)
+		}
+	}
+	return b.String()
+}
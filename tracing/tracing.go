@@ -224,6 +224,26 @@ func StartFIMSpan(
 	)
 }
 
+// StartCompletionSpan creates a span for a plain text completion call.
+func StartCompletionSpan(
+	ctx context.Context,
+	modelName string,
+	system string,
+	extra ...Attr,
+) (context.Context, Span) {
+	attrs := []Attr{
+		AttrOperationName.String("text_completion"),
+		AttrSystem.String(system),
+		AttrRequestModel.String(modelName),
+	}
+	attrs = append(attrs, extra...)
+	return StartSpan(
+		ctx,
+		fmt.Sprintf("text_completion %s", modelName),
+		attrs...,
+	)
+}
+
 // StartAgentSpan creates a span for an agent invocation.
 func StartAgentSpan(
 	ctx context.Context,
@@ -6,9 +6,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
@@ -112,17 +115,16 @@ func (c *Client) GenerateAudio(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := tts.CheckOptions(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	outputFormat := c.options.outputFormat
 	if opts.OutputFormat != "" {
 		outputFormat = opts.OutputFormat
 	}
 
-	ssml := fmt.Sprintf(
-		`<speak version='1.0' xml:lang='en-US'><voice name='%s'>%s</voice></speak>`,
-		c.options.voiceName,
-		text,
-	)
+	ssml := c.buildSSML(text, opts.SSML)
 
 	ttsURL := fmt.Sprintf(
 		"https://%s.tts.speech.microsoft.com/cognitiveservices/v1",
@@ -162,13 +164,47 @@ func (c *Client) GenerateAudio(
 	}
 
 	return &tts.Response{
-		AudioData:   body,
-		ContentType: resp.Header.Get("Content-Type"),
-		Usage:       tts.Usage{Characters: int64(len(text))},
-		Model:       c.options.model.APIModel,
+		AudioData:    body,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Usage:        tts.Usage{Characters: int64(len(text))},
+		Model:        c.options.model.APIModel,
+		OutputFormat: outputFormat,
+		SampleRate:   sampleRateForFormat(outputFormat),
 	}, nil
 }
 
+func sampleRateForFormat(format string) int {
+	for _, part := range strings.Split(format, "-") {
+		if khz, ok := strings.CutSuffix(part, "khz"); ok {
+			if rate, err := strconv.Atoi(khz); err == nil {
+				return rate * 1000
+			}
+		}
+		if hz, ok := strings.CutSuffix(part, "hz"); ok {
+			if rate, err := strconv.Atoi(hz); err == nil {
+				return rate
+			}
+		}
+	}
+	return 0
+}
+
+func (c *Client) buildSSML(text string, markup bool) string {
+	if markup && strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		return text
+	}
+	if !markup {
+		var escaped strings.Builder
+		_ = xml.EscapeText(&escaped, []byte(text))
+		text = escaped.String()
+	}
+	return fmt.Sprintf(
+		`<speak version='1.0' xml:lang='en-US'><voice name='%s'>%s</voice></speak>`,
+		c.options.voiceName,
+		text,
+	)
+}
+
 // StreamAudio buffers Azure's non-streaming response into a single chunk for API parity.
 func (c *Client) StreamAudio(
 	ctx context.Context,
@@ -189,6 +225,16 @@ func (c *Client) StreamAudio(
 	return ch, nil
 }
 
+// GenerateSoundEffect returns [tts.ErrSoundEffectsNotSupported]; Azure has no
+// sound-effects endpoint.
+func (c *Client) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 // ListVoices retrieves the list of available voices from Azure.
 func (c *Client) ListVoices(ctx context.Context) ([]tts.Voice, error) {
 	voicesURL := fmt.Sprintf(
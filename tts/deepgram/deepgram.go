@@ -151,17 +151,17 @@ type errorResponse struct {
 	ErrMsg  string `json:"err_msg"`
 }
 
-func (c *Client) buildURL() string {
+func (c *Client) buildURL(opts tts.GenerationOptions) string {
 	q := url.Values{}
 	q.Set("model", c.resolved)
-	if c.options.encoding != "" {
-		q.Set("encoding", c.options.encoding)
+	if encoding := c.encoding(opts); encoding != "" {
+		q.Set("encoding", encoding)
 	}
 	if c.options.container != "" {
 		q.Set("container", c.options.container)
 	}
-	if c.options.sampleRate != 0 {
-		q.Set("sample_rate", strconv.Itoa(c.options.sampleRate))
+	if rate := c.sampleRate(opts); rate != 0 {
+		q.Set("sample_rate", strconv.Itoa(rate))
 	}
 	if c.options.bitRate != 0 {
 		q.Set("bit_rate", strconv.Itoa(c.options.bitRate))
@@ -172,6 +172,7 @@ func (c *Client) buildURL() string {
 func (c *Client) newRequest(
 	ctx context.Context,
 	text string,
+	opts tts.GenerationOptions,
 ) (*http.Request, error) {
 	body, err := json.Marshal(ttsRequest{Text: text})
 	if err != nil {
@@ -180,7 +181,7 @@ func (c *Client) newRequest(
 	req, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		c.buildURL(),
+		c.buildURL(opts),
 		bytes.NewBuffer(body),
 	)
 	if err != nil {
@@ -191,13 +192,41 @@ func (c *Client) newRequest(
 	return req, nil
 }
 
+func (c *Client) resolveOptions(
+	options []tts.GenerationOption,
+) (tts.GenerationOptions, error) {
+	opts := tts.GenerationOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts, tts.CheckOptions(c.options.model, opts)
+}
+
+func (c *Client) encoding(opts tts.GenerationOptions) string {
+	if opts.OutputFormat != "" {
+		return opts.OutputFormat
+	}
+	return c.options.encoding
+}
+
+func (c *Client) sampleRate(opts tts.GenerationOptions) int {
+	if opts.SampleRate != 0 {
+		return opts.SampleRate
+	}
+	return c.options.sampleRate
+}
+
 // GenerateAudio creates audio from text and returns the complete audio data.
 func (c *Client) GenerateAudio(
 	ctx context.Context,
 	text string,
-	_ ...tts.GenerationOption,
+	options ...tts.GenerationOption,
 ) (*tts.Response, error) {
-	req, err := c.newRequest(ctx, text)
+	opts, err := c.resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, text, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -229,11 +258,18 @@ func (c *Client) GenerateAudio(
 		contentType = "audio/mpeg"
 	}
 
+	encoding := c.encoding(opts)
+	if encoding == "" {
+		encoding = "mp3"
+	}
+
 	return &tts.Response{
-		AudioData:   audioData,
-		ContentType: contentType,
-		Usage:       tts.Usage{Characters: charCount},
-		Model:       c.resolved,
+		AudioData:    audioData,
+		ContentType:  contentType,
+		Usage:        tts.Usage{Characters: charCount},
+		Model:        c.resolved,
+		OutputFormat: encoding,
+		SampleRate:   c.sampleRate(opts),
 	}, nil
 }
 
@@ -246,9 +282,13 @@ func (c *Client) GenerateAudio(
 func (c *Client) StreamAudio(
 	ctx context.Context,
 	text string,
-	_ ...tts.GenerationOption,
+	options ...tts.GenerationOption,
 ) (<-chan tts.Chunk, error) {
-	conn, send, err := c.dialStreamWS(ctx)
+	opts, err := c.resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	conn, send, err := c.dialStreamWS(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -276,8 +316,9 @@ func (c *Client) StreamAudio(
 // connection along with a goroutine-safe send function.
 func (c *Client) dialStreamWS(
 	ctx context.Context,
+	opts tts.GenerationOptions,
 ) (*websocket.Conn, func([]byte) error, error) {
-	wsURL, err := c.buildStreamURL()
+	wsURL, err := c.buildStreamURL(opts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to build ws url: %w", err)
 	}
@@ -319,9 +360,13 @@ func (c *Client) dialStreamWS(
 func (c *Client) StreamAudioFromText(
 	ctx context.Context,
 	textIn <-chan string,
-	_ ...tts.GenerationOption,
+	options ...tts.GenerationOption,
 ) (<-chan tts.Chunk, error) {
-	conn, send, err := c.dialStreamWS(ctx)
+	opts, err := c.resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	conn, send, err := c.dialStreamWS(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -392,7 +437,9 @@ type serverFrame struct {
 	Description string `json:"description"`
 }
 
-func (c *Client) buildStreamURL() (string, error) {
+func (c *Client) buildStreamURL(
+	opts tts.GenerationOptions,
+) (string, error) {
 	base, err := url.Parse(c.options.baseURL)
 	if err != nil {
 		return "", err
@@ -404,15 +451,15 @@ func (c *Client) buildStreamURL() (string, error) {
 	q := url.Values{}
 	q.Set("model", c.resolved)
 
-	encoding := c.options.encoding
+	encoding := c.encoding(opts)
 	if encoding == "" {
 		encoding = "linear16"
 	}
 	q.Set("encoding", encoding)
 	q.Set("container", "none")
 
-	if c.options.sampleRate != 0 {
-		q.Set("sample_rate", strconv.Itoa(c.options.sampleRate))
+	if rate := c.sampleRate(opts); rate != 0 {
+		q.Set("sample_rate", strconv.Itoa(rate))
 	}
 	if c.options.bitRate != 0 {
 		q.Set("bit_rate", strconv.Itoa(c.options.bitRate))
@@ -517,6 +564,16 @@ func isCleanWSClose(err error) bool {
 	)
 }
 
+// GenerateSoundEffect returns [tts.ErrSoundEffectsNotSupported]; Deepgram has
+// no sound-effects endpoint.
+func (c *Client) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 // ListVoices returns the static set of Aura voices known to deps/ai. Deepgram
 // does not expose a public list-voices endpoint.
 func (c *Client) ListVoices(_ context.Context) ([]tts.Voice, error) {
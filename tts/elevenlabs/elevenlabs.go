@@ -124,17 +124,17 @@ func NewGeneration(opts ...Option) tts.Generation {
 	if options.outputFormat != "" {
 		outputFormat = options.outputFormat
 	}
-	modelID := defaultModelID
-	if options.model.APIModel != "" {
-		modelID = options.model.APIModel
+	ttsModel := options.model
+	if ttsModel.APIModel == "" {
+		ttsModel = model.ElevenLabsAudioModels[defaultModelID]
 	}
 
 	return tts.WithTracing(&Client{
 		apiKey:       options.apiKey,
-		model:        options.model,
+		model:        ttsModel,
 		baseURL:      baseURL,
 		httpClient:   &http.Client{Timeout: timeout},
-		modelID:      modelID,
+		modelID:      ttsModel.APIModel,
 		voiceID:      voiceID,
 		outputFormat: outputFormat,
 	}, tts.TracingAttrs{
@@ -147,10 +147,16 @@ func NewGeneration(opts ...Option) tts.Generation {
 func (c *Client) Model() model.AudioModel { return c.model }
 
 type ttsRequest struct {
-	Text          string         `json:"text"`
-	ModelID       string         `json:"model_id"`
-	VoiceSettings *voiceSettings `json:"voice_settings,omitempty"`
-	OutputFormat  string         `json:"output_format,omitempty"`
+	Text               string              `json:"text"`
+	ModelID            string              `json:"model_id"`
+	VoiceSettings      *voiceSettings      `json:"voice_settings,omitempty"`
+	OutputFormat       string              `json:"output_format,omitempty"`
+	DictionaryLocators []dictionaryLocator `json:"pronunciation_dictionary_locators,omitempty"`
+}
+
+type dictionaryLocator struct {
+	DictionaryID string `json:"pronunciation_dictionary_id"`
+	VersionID    string `json:"version_id,omitempty"`
 }
 
 type voiceSettings struct {
@@ -212,6 +218,17 @@ type forcedAlignmentResponse struct {
 	Loss       float64             `json:"loss"`
 }
 
+func dictionaryLocators(opts *tts.GenerationOptions) []dictionaryLocator {
+	var locators []dictionaryLocator
+	for _, d := range opts.PronunciationDictionaries {
+		locators = append(locators, dictionaryLocator{
+			DictionaryID: d.ID,
+			VersionID:    d.VersionID,
+		})
+	}
+	return locators
+}
+
 func (c *Client) buildVoiceSettings(
 	opts *tts.GenerationOptions,
 ) *voiceSettings {
@@ -246,6 +263,9 @@ func (c *Client) GenerateAudio(
 	for _, opt := range options {
 		opt(opts)
 	}
+	if err := tts.CheckOptions(c.model, *opts); err != nil {
+		return nil, err
+	}
 
 	if opts.EnableAlignment {
 		return c.generateWithTimestamps(ctx, text, opts)
@@ -264,9 +284,10 @@ func (c *Client) generateStandard(
 	}
 
 	reqBody := ttsRequest{
-		Text:          text,
-		ModelID:       c.modelID,
-		VoiceSettings: c.buildVoiceSettings(opts),
+		Text:               text,
+		ModelID:            c.modelID,
+		VoiceSettings:      c.buildVoiceSettings(opts),
+		DictionaryLocators: dictionaryLocators(opts),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -326,10 +347,12 @@ func (c *Client) generateStandard(
 	}
 
 	return &tts.Response{
-		AudioData:   audioData,
-		ContentType: contentType,
-		Usage:       tts.Usage{Characters: charCount},
-		Model:       c.modelID,
+		AudioData:    audioData,
+		ContentType:  contentType,
+		Usage:        tts.Usage{Characters: charCount},
+		Model:        c.modelID,
+		OutputFormat: outputFormat,
+		SampleRate:   sampleRateForFormat(outputFormat),
 	}, nil
 }
 
@@ -344,9 +367,10 @@ func (c *Client) generateWithTimestamps(
 	}
 
 	reqBody := ttsRequest{
-		Text:          text,
-		ModelID:       c.modelID,
-		VoiceSettings: c.buildVoiceSettings(opts),
+		Text:               text,
+		ModelID:            c.modelID,
+		VoiceSettings:      c.buildVoiceSettings(opts),
+		DictionaryLocators: dictionaryLocators(opts),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -402,11 +426,13 @@ func (c *Client) generateWithTimestamps(
 	contentType := contentTypeForFormat(outputFormat)
 
 	return &tts.Response{
-		AudioData:   audioData,
-		ContentType: contentType,
-		Usage:       tts.Usage{Characters: int64(len(text))},
-		Model:       c.modelID,
-		Alignment:   toAlignmentData(timestampsResp.Alignment),
+		AudioData:    audioData,
+		ContentType:  contentType,
+		Usage:        tts.Usage{Characters: int64(len(text))},
+		Model:        c.modelID,
+		OutputFormat: outputFormat,
+		SampleRate:   sampleRateForFormat(outputFormat),
+		Alignment:    toAlignmentData(timestampsResp.Alignment),
 		NormalizedAlignment: toAlignmentData(
 			timestampsResp.NormalizedAlignment,
 		),
@@ -429,6 +455,9 @@ func (c *Client) StreamAudio(
 	for _, opt := range options {
 		opt(opts)
 	}
+	if err := tts.CheckOptions(c.model, *opts); err != nil {
+		return nil, err
+	}
 	return c.streamWS(ctx, text, opts)
 }
 
@@ -439,8 +468,9 @@ const (
 )
 
 type wsBeginMessage struct {
-	Text          string         `json:"text"`
-	VoiceSettings *voiceSettings `json:"voice_settings,omitempty"`
+	Text               string              `json:"text"`
+	VoiceSettings      *voiceSettings      `json:"voice_settings,omitempty"`
+	DictionaryLocators []dictionaryLocator `json:"pronunciation_dictionary_locators,omitempty"`
 }
 
 type wsTextMessage struct {
@@ -509,7 +539,7 @@ func (c *Client) dialStreamWS(
 		outputFormat = opts.OutputFormat
 	}
 
-	wsURL, err := c.buildStreamURL(outputFormat, opts.EnableAlignment)
+	wsURL, err := c.buildStreamURL(outputFormat, opts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to build ws url: %w", err)
 	}
@@ -540,8 +570,9 @@ func (c *Client) dialStreamWS(
 	}
 
 	bos, err := json.Marshal(wsBeginMessage{
-		Text:          " ",
-		VoiceSettings: c.buildVoiceSettings(opts),
+		Text:               " ",
+		VoiceSettings:      c.buildVoiceSettings(opts),
+		DictionaryLocators: dictionaryLocators(opts),
 	})
 	if err != nil {
 		_ = conn.Close()
@@ -569,6 +600,9 @@ func (c *Client) StreamAudioFromText(
 	for _, opt := range options {
 		opt(opts)
 	}
+	if err := tts.CheckOptions(c.model, *opts); err != nil {
+		return nil, err
+	}
 
 	conn, send, err := c.dialStreamWS(ctx, opts)
 	if err != nil {
@@ -622,7 +656,7 @@ func (c *Client) StreamAudioFromText(
 
 func (c *Client) buildStreamURL(
 	outputFormat string,
-	syncAlignment bool,
+	opts *tts.GenerationOptions,
 ) (string, error) {
 	base, err := url.Parse(c.baseURL)
 	if err != nil {
@@ -639,9 +673,12 @@ func (c *Client) buildStreamURL(
 	}
 	q.Set("inactivity_timeout", "20")
 	q.Set("auto_mode", "false")
-	if syncAlignment {
+	if opts.EnableAlignment {
 		q.Set("sync_alignment", "true")
 	}
+	if opts.SSML {
+		q.Set("enable_ssml_parsing", "true")
+	}
 	u := url.URL{
 		Scheme:   scheme,
 		Host:     base.Host,
@@ -759,6 +796,95 @@ func isCleanWSClose(err error) bool {
 	)
 }
 
+const soundEffectModelID = "eleven_text_to_sound_v2"
+
+type soundEffectRequest struct {
+	Text            string   `json:"text"`
+	ModelID         string   `json:"model_id"`
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	PromptInfluence *float64 `json:"prompt_influence,omitempty"`
+	Loop            bool     `json:"loop,omitempty"`
+}
+
+// GenerateSoundEffect creates a sound effect from a text description using
+// the ElevenLabs sound-generation endpoint. Durations must be between 0.5 and
+// 30 seconds.
+func (c *Client) GenerateSoundEffect(
+	ctx context.Context,
+	prompt string,
+	options ...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	opts := &tts.SoundEffectOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	outputFormat := c.outputFormat
+	if opts.OutputFormat != "" {
+		outputFormat = opts.OutputFormat
+	}
+
+	reqBody := soundEffectRequest{
+		Text:            prompt,
+		ModelID:         soundEffectModelID,
+		PromptInfluence: opts.PromptInfluence,
+		Loop:            opts.Loop,
+	}
+	if opts.Duration != nil {
+		seconds := opts.Duration.Seconds()
+		reqBody.DurationSeconds = &seconds
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/sound-generation?output_format=%s",
+		c.baseURL, outputFormat)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		url,
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("xi-api-key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = contentTypeForFormat(outputFormat)
+	}
+
+	return &tts.Response{
+		AudioData:    audioData,
+		ContentType:  contentType,
+		Usage:        tts.Usage{Characters: int64(len(prompt))},
+		Model:        soundEffectModelID,
+		OutputFormat: outputFormat,
+		SampleRate:   sampleRateForFormat(outputFormat),
+	}, nil
+}
+
 // ListVoices retrieves the list of available voices from ElevenLabs.
 func (c *Client) ListVoices(ctx context.Context) ([]tts.Voice, error) {
 	url := fmt.Sprintf("%s/voices", c.baseURL)
@@ -905,15 +1031,30 @@ func toAlignmentData(a alignmentResponse) *tts.AlignmentData {
 }
 
 func contentTypeForFormat(format string) string {
-	if len(format) >= 3 {
-		switch format[:3] {
-		case "mp3":
-			return "audio/mpeg"
-		case "pcm":
-			return "audio/pcm"
-		case "wav":
-			return "audio/wav"
-		}
+	codec, _, _ := strings.Cut(format, "_")
+	switch codec {
+	case "pcm":
+		return "audio/pcm"
+	case "wav":
+		return "audio/wav"
+	case "ulaw":
+		return "audio/basic"
+	case "alaw":
+		return "audio/x-alaw-basic"
+	case "opus":
+		return "audio/opus"
 	}
 	return "audio/mpeg"
 }
+
+func sampleRateForFormat(format string) int {
+	parts := strings.Split(format, "_")
+	if len(parts) < 2 {
+		return 0
+	}
+	rate, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0
+	}
+	return rate
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
@@ -120,7 +121,8 @@ type ttsRequest struct {
 }
 
 type ttsInput struct {
-	Text string `json:"text"`
+	Text string `json:"text,omitempty"`
+	SSML string `json:"ssml,omitempty"`
 }
 
 type ttsVoice struct {
@@ -130,7 +132,8 @@ type ttsVoice struct {
 }
 
 type ttsAudioConfig struct {
-	AudioEncoding string `json:"audioEncoding"`
+	AudioEncoding   string `json:"audioEncoding"`
+	SampleRateHertz int    `json:"sampleRateHertz,omitempty"`
 }
 
 type synthesizeResponse struct {
@@ -156,13 +159,16 @@ func (c *Client) GenerateAudio(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := tts.CheckOptions(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	encoding := "MP3"
 	if c.options.outputFormat != "" {
 		encoding = c.options.outputFormat
 	}
 	if opts.OutputFormat != "" {
-		encoding = opts.OutputFormat
+		encoding = strings.ToUpper(opts.OutputFormat)
 	}
 
 	voice := ttsVoice{LanguageCode: c.options.languageCode}
@@ -173,10 +179,18 @@ func (c *Client) GenerateAudio(
 		voice.SSMLGender = c.options.ssmlGender
 	}
 
+	input := ttsInput{Text: text}
+	if opts.SSML {
+		input = ttsInput{SSML: wrapSpeak(text)}
+	}
+
 	reqBody := ttsRequest{
-		Input:       ttsInput{Text: text},
-		Voice:       voice,
-		AudioConfig: ttsAudioConfig{AudioEncoding: encoding},
+		Input: input,
+		Voice: voice,
+		AudioConfig: ttsAudioConfig{
+			AudioEncoding:   encoding,
+			SampleRateHertz: opts.SampleRate,
+		},
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -230,13 +244,22 @@ func (c *Client) GenerateAudio(
 	}
 
 	return &tts.Response{
-		AudioData:   audioData,
-		ContentType: contentTypeForEncoding(encoding),
-		Usage:       tts.Usage{Characters: int64(len(text))},
-		Model:       c.options.model.APIModel,
+		AudioData:    audioData,
+		ContentType:  contentTypeForEncoding(encoding),
+		Usage:        tts.Usage{Characters: int64(len(text))},
+		Model:        c.options.model.APIModel,
+		OutputFormat: encoding,
+		SampleRate:   opts.SampleRate,
 	}, nil
 }
 
+func wrapSpeak(ssml string) string {
+	if strings.HasPrefix(strings.TrimSpace(ssml), "<speak") {
+		return ssml
+	}
+	return "<speak>" + ssml + "</speak>"
+}
+
 // StreamAudio buffers Google's non-streaming response into a single chunk for API parity.
 func (c *Client) StreamAudio(
 	ctx context.Context,
@@ -257,6 +280,16 @@ func (c *Client) StreamAudio(
 	return ch, nil
 }
 
+// GenerateSoundEffect returns [tts.ErrSoundEffectsNotSupported]; Google Cloud
+// TTS has no sound-effects endpoint.
+func (c *Client) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 // ListVoices retrieves the list of available voices from Google Cloud TTS.
 func (c *Client) ListVoices(ctx context.Context) ([]tts.Voice, error) {
 	reqURL := fmt.Sprintf("%s/voices?key=%s", c.baseURL, c.options.apiKey)
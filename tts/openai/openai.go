@@ -14,6 +14,8 @@ import (
 	"github.com/openai/openai-go/v3/packages/param"
 )
 
+const outputSampleRate = 24000
+
 // Options configures the OpenAI TTS client.
 type Options struct {
 	apiKey       string
@@ -124,6 +126,9 @@ func (c *Client) GenerateAudio(
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if err := tts.CheckOptions(c.options.model, opts); err != nil {
+		return nil, err
+	}
 
 	voice := c.options.voice
 	if voice == "" {
@@ -139,11 +144,12 @@ func (c *Client) GenerateAudio(
 	if opts.OutputFormat != "" {
 		outputFormat = opts.OutputFormat
 	}
-	if outputFormat != "" {
-		params.ResponseFormat = openaisdk.AudioSpeechNewParamsResponseFormat(
-			outputFormat,
-		)
+	if outputFormat == "" {
+		outputFormat = "mp3"
 	}
+	params.ResponseFormat = openaisdk.AudioSpeechNewParamsResponseFormat(
+		outputFormat,
+	)
 	if c.options.speed != nil {
 		params.Speed = param.NewOpt(*c.options.speed)
 	}
@@ -160,10 +166,12 @@ func (c *Client) GenerateAudio(
 	}
 
 	return &tts.Response{
-		AudioData:   audioData,
-		ContentType: resp.Header.Get("Content-Type"),
-		Usage:       tts.Usage{Characters: int64(len(text))},
-		Model:       c.options.model.APIModel,
+		AudioData:    audioData,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Usage:        tts.Usage{Characters: int64(len(text))},
+		Model:        c.options.model.APIModel,
+		OutputFormat: outputFormat,
+		SampleRate:   outputSampleRate,
 	}, nil
 }
 
@@ -188,6 +196,16 @@ func (c *Client) StreamAudio(
 	return ch, nil
 }
 
+// GenerateSoundEffect returns [tts.ErrSoundEffectsNotSupported]; OpenAI has no
+// sound-effects endpoint.
+func (c *Client) GenerateSoundEffect(
+	context.Context,
+	string,
+	...tts.SoundEffectOption,
+) (*tts.Response, error) {
+	return nil, tts.ErrSoundEffectsNotSupported
+}
+
 // ListVoices returns the OpenAI voice catalogue (static list — OpenAI does not
 // expose a list-voices endpoint).
 func (c *Client) ListVoices(_ context.Context) ([]tts.Voice, error) {
@@ -0,0 +1,52 @@
+package tts
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSoundEffectsNotSupported is returned by [Generation.GenerateSoundEffect]
+// when the provider has no sound-effects endpoint.
+var ErrSoundEffectsNotSupported = errors.New(
+	"tts: sound effects not supported by this provider",
+)
+
+// SoundEffectOptions contains parameters for customizing sound-effect
+// generation requests.
+type SoundEffectOptions struct {
+	// Duration is the length of the generated sound. When nil the provider
+	// picks a length that suits the prompt.
+	Duration *time.Duration
+	// PromptInfluence controls how closely the output follows the prompt,
+	// from 0.0 (more variation) to 1.0 (literal).
+	PromptInfluence *float64
+	// Loop requests a sound that repeats seamlessly.
+	Loop bool
+	// OutputFormat is the provider format to encode the audio in.
+	OutputFormat string
+}
+
+// SoundEffectOption configures SoundEffectOptions.
+type SoundEffectOption func(*SoundEffectOptions)
+
+// WithDuration sets the length of the generated sound effect.
+func WithDuration(d time.Duration) SoundEffectOption {
+	return func(o *SoundEffectOptions) { o.Duration = &d }
+}
+
+// WithPromptInfluence sets how closely the sound follows the prompt (0.0 to
+// 1.0).
+func WithPromptInfluence(influence float64) SoundEffectOption {
+	return func(o *SoundEffectOptions) { o.PromptInfluence = &influence }
+}
+
+// WithLoop requests a sound effect that loops seamlessly, for ambiance and
+// background beds.
+func WithLoop() SoundEffectOption {
+	return func(o *SoundEffectOptions) { o.Loop = true }
+}
+
+// WithSoundEffectFormat sets the audio format for the generated sound effect.
+func WithSoundEffectFormat(format string) SoundEffectOption {
+	return func(o *SoundEffectOptions) { o.OutputFormat = format }
+}
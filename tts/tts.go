@@ -22,12 +22,68 @@ package tts
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/joakimcarlsson/ai/model"
 	"github.com/joakimcarlsson/ai/tracing"
 )
 
+// ErrSSMLNotSupported is returned when [WithSSML] is used with a model that
+// does not accept SSML. Detect ahead of time via model.AudioModel.SupportsSSML.
+var ErrSSMLNotSupported = errors.New("tts: SSML not supported by this model")
+
+// ErrPronunciationDictionaryNotSupported is returned when
+// [WithPronunciationDictionary] is used with a model that cannot apply
+// pronunciation dictionaries.
+var ErrPronunciationDictionaryNotSupported = errors.New(
+	"tts: pronunciation dictionaries not supported by this model",
+)
+
+// ErrUnsupportedOutputFormat is returned when [WithOutputFormat] names a
+// format that is not in the model's SupportedFormats.
+var ErrUnsupportedOutputFormat = errors.New(
+	"tts: output format not supported by this model",
+)
+
+// CheckOptions reports whether m can honour the output format, SSML and
+// pronunciation dictionary settings in opts. Vendor packages call it before
+// sending a request so unsupported settings fail fast instead of being
+// ignored or read aloud. Formats are only checked when the model lists its
+// SupportedFormats. Nothing is checked for a zero model, as when a client
+// is built without WithModel, since nothing is known about what it supports.
+func CheckOptions(m model.AudioModel, opts GenerationOptions) error {
+	if m.ID == "" {
+		return nil
+	}
+	if opts.OutputFormat != "" && len(m.SupportedFormats) > 0 &&
+		!slices.ContainsFunc(m.SupportedFormats, func(f string) bool {
+			return strings.EqualFold(f, opts.OutputFormat)
+		}) {
+		return fmt.Errorf(
+			"%w: %s does not support %q",
+			ErrUnsupportedOutputFormat,
+			m.APIModel,
+			opts.OutputFormat,
+		)
+	}
+	if opts.SSML && !m.SupportsSSML {
+		return fmt.Errorf("%w: %s", ErrSSMLNotSupported, m.APIModel)
+	}
+	if len(opts.PronunciationDictionaries) > 0 &&
+		!m.SupportsPronunciationDictionaries {
+		return fmt.Errorf(
+			"%w: %s",
+			ErrPronunciationDictionaryNotSupported,
+			m.APIModel,
+		)
+	}
+	return nil
+}
+
 // Usage tracks the resource consumption for audio generation operations.
 type Usage struct {
 	// Characters is the number of characters processed.
@@ -73,6 +129,12 @@ type Response struct {
 	Usage Usage
 	// Model identifies which audio generation model was used.
 	Model string
+	// OutputFormat is the provider format the audio was encoded in, such as
+	// "pcm_16000" or "ulaw_8000".
+	OutputFormat string
+	// SampleRate is the sample rate of the audio in Hz, or 0 when the
+	// provider does not report it.
+	SampleRate int
 	// Alignment contains character-level timing information aligned to the original input text.
 	Alignment *AlignmentData
 	// NormalizedAlignment contains character-level timing information aligned to normalized text.
@@ -119,6 +181,16 @@ type Generation interface {
 		options ...GenerationOption,
 	) (<-chan Chunk, error)
 
+	// GenerateSoundEffect creates a non-speech sound, such as a UI click or
+	// room ambiance, from a text description. Returns
+	// ErrSoundEffectsNotSupported when the provider has no sound-effects
+	// endpoint.
+	GenerateSoundEffect(
+		ctx context.Context,
+		prompt string,
+		options ...SoundEffectOption,
+	) (*Response, error)
+
 	// ListVoices retrieves the list of available voices from the provider.
 	ListVoices(ctx context.Context) ([]Voice, error)
 
@@ -164,16 +236,40 @@ type GenerationOptions struct {
 	SpeakerBoost             *bool
 	OptimizeStreamingLatency *int
 	EnableAlignment          bool
+	// SampleRate is the requested sample rate in Hz for providers that take
+	// it separately from the output format.
+	SampleRate int
+	// SSML marks the input text as SSML markup rather than plain text.
+	SSML bool
+	// PronunciationDictionaries are applied, in order, to the input text.
+	PronunciationDictionaries []PronunciationDictionary
+}
+
+// PronunciationDictionary references a pronunciation dictionary stored with
+// the provider. An empty VersionID selects the latest version.
+type PronunciationDictionary struct {
+	ID        string
+	VersionID string
 }
 
 // GenerationOption configures GenerationOptions.
 type GenerationOption func(*GenerationOptions)
 
-// WithOutputFormat sets the audio format for the generated audio.
+// WithOutputFormat sets the audio format for the generated audio, using the
+// provider's format names (e.g. "pcm_16000" or "ulaw_8000" for ElevenLabs).
+// Returns [ErrUnsupportedOutputFormat] when the format is not in the model's
+// SupportedFormats.
 func WithOutputFormat(format string) GenerationOption {
 	return func(o *GenerationOptions) { o.OutputFormat = format }
 }
 
+// WithSampleRate sets the sample rate in Hz for providers that take it
+// separately from the format (Google Cloud, Deepgram). ElevenLabs and Azure
+// encode the rate in the [WithOutputFormat] name instead.
+func WithSampleRate(hz int) GenerationOption {
+	return func(o *GenerationOptions) { o.SampleRate = hz }
+}
+
 // WithStability sets the voice stability (0.0 to 1.0).
 func WithStability(stability float64) GenerationOption {
 	return func(o *GenerationOptions) { o.Stability = &stability }
@@ -204,6 +300,34 @@ func WithAlignmentEnabled(enabled bool) GenerationOption {
 	return func(o *GenerationOptions) { o.EnableAlignment = enabled }
 }
 
+// WithSSML marks the input text as SSML, allowing tags such as <phoneme> and
+// <break> to control pronunciation and pacing. Returns [ErrSSMLNotSupported]
+// when the model does not accept SSML.
+func WithSSML() GenerationOption {
+	return func(o *GenerationOptions) { o.SSML = true }
+}
+
+// WithPronunciationDictionary applies the latest version of a pronunciation
+// dictionary stored with the provider. Repeat the option to apply several
+// dictionaries. Returns [ErrPronunciationDictionaryNotSupported] when the
+// model cannot use dictionaries.
+func WithPronunciationDictionary(id string) GenerationOption {
+	return WithPronunciationDictionaryVersion(id, "")
+}
+
+// WithPronunciationDictionaryVersion applies a specific version of a
+// pronunciation dictionary stored with the provider.
+func WithPronunciationDictionaryVersion(
+	id, versionID string,
+) GenerationOption {
+	return func(o *GenerationOptions) {
+		o.PronunciationDictionaries = append(
+			o.PronunciationDictionaries,
+			PronunciationDictionary{ID: id, VersionID: versionID},
+		)
+	}
+}
+
 // TracingAttrs are construction-time attributes vendor packages forward to the
 // [WithTracing] wrapper so they appear on every span produced for the wrapped
 // client.
@@ -363,6 +487,39 @@ func (t *tracingGeneration) StreamAudio(
 	return outCh, nil
 }
 
+func (t *tracingGeneration) GenerateSoundEffect(
+	ctx context.Context,
+	prompt string,
+	options ...SoundEffectOption,
+) (*Response, error) {
+	m := t.inner.Model()
+	start := time.Now()
+	ctx, span := tracing.StartAudioSpan(
+		ctx, m.APIModel, string(m.Provider), t.spanAttrs()...,
+	)
+	defer span.End()
+	span.SetAttributes(tracing.AttrInputCount.Int(len(prompt)))
+
+	resp, err := t.inner.GenerateSoundEffect(ctx, prompt, options...)
+	if err != nil {
+		tracing.SetError(span, err)
+		tracing.RecordMetrics(
+			ctx, "generate_sound_effect", m.APIModel, string(m.Provider),
+			time.Since(start), 0, 0, err,
+		)
+		return nil, err
+	}
+
+	tracing.SetResponseAttrs(span,
+		tracing.AttrUsageCharacters.Int64(int64(resp.Usage.Characters)),
+	)
+	tracing.RecordMetrics(
+		ctx, "generate_sound_effect", m.APIModel, string(m.Provider),
+		time.Since(start), 0, 0, nil,
+	)
+	return resp, nil
+}
+
 // tracingGenerationWithForcedAlignment is the tracing wrapper used when the inner
 // Generation client also implements ForcedAlignmentProvider. The type-assertion
 // `c.(tts.ForcedAlignmentProvider)` against the wrapper returned from NewGeneration
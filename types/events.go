@@ -33,10 +33,18 @@ const (
 	EventComplete EventType = "complete"
 	// EventError indicates an error occurred during streaming.
 	EventError EventType = "error"
+	// EventEmptyResponse indicates the model ended the turn with no content
+	// and no tool calls.
+	EventEmptyResponse EventType = "empty_response"
 	// EventWarning indicates a warning occurred during streaming.
 	EventWarning EventType = "warning"
+	// EventUsageUpdate carries running token usage totals while a response
+	// is still streaming.
+	EventUsageUpdate EventType = "usage_update"
 	// EventHandoff indicates control is being transferred to a different agent.
 	EventHandoff EventType = "handoff"
+	// EventToolProgress indicates a running tool reported intermediate progress.
+	EventToolProgress EventType = "tool_progress"
 	// EventConfirmationRequired indicates a tool is requesting user confirmation before proceeding.
 	EventConfirmationRequired EventType = "confirmation_required"
 	// EventTeammateSpawned indicates a new teammate has been spawned in a team.
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiItalic = "\x1b[3m"
+	ansiCyan   = "\x1b[36m"
+)
+
+var (
+	mdBold       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic     = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+	mdInlineCode = regexp.MustCompile("`([^`]+)`")
+	mdHeading    = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	mdBullet     = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// MarkdownWriter renders streamed markdown for an ANSI terminal. Text is
+// buffered until each newline and the completed line is written with
+// headings and bold in bold, italics in italic, inline code in cyan, fenced
+// code blocks dimmed without their fences, and list bullets as "•". Call
+// [MarkdownWriter.Flush] once the stream ends to write a final line that has
+// no trailing newline.
+type MarkdownWriter struct {
+	w       io.Writer
+	line    bytes.Buffer
+	inFence bool
+}
+
+// NewMarkdownWriter returns a MarkdownWriter that writes rendered lines to w.
+func NewMarkdownWriter(w io.Writer) *MarkdownWriter {
+	return &MarkdownWriter{w: w}
+}
+
+// Write buffers p and renders every line it completes. It reports len(p) on
+// success, as the rendered output differs in length from the input.
+func (m *MarkdownWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			m.line.WriteByte(b)
+			continue
+		}
+		if err := m.writeLine(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush renders any buffered partial line.
+func (m *MarkdownWriter) Flush() error {
+	if m.line.Len() == 0 {
+		return nil
+	}
+	return m.writeLine(false)
+}
+
+func (m *MarkdownWriter) writeLine(newline bool) error {
+	line := m.line.String()
+	m.line.Reset()
+
+	var out string
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(line), "```"):
+		m.inFence = !m.inFence
+		return nil
+	case m.inFence:
+		out = ansiDim + line + ansiReset
+	case mdHeading.MatchString(line):
+		out = ansiBold + mdHeading.ReplaceAllString(line, "$1") + ansiReset
+	default:
+		out = renderInline(mdBullet.ReplaceAllString(line, "$1• "))
+	}
+	if newline {
+		out += "\n"
+	}
+	_, err := io.WriteString(m.w, out)
+	return err
+}
+
+func renderInline(line string) string {
+	line = mdInlineCode.ReplaceAllString(line, ansiCyan+"$1"+ansiReset)
+	line = mdBold.ReplaceAllString(line, ansiBold+"$1$2"+ansiReset)
+	return mdItalic.ReplaceAllString(line, "$1"+ansiItalic+"$2"+ansiReset)
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// maxThrottleEmitsPerSec caps how many content events [ThrottleStream] emits
+// per second; higher rates are reached by emitting several characters at once.
+const maxThrottleEmitsPerSec = 60
+
+// ThrottleStream re-times the text of a streaming event channel to a steady
+// charsPerSec, for a typewriter effect over bursty model output. It is a pure
+// post-processing step: content reports whether an event is a content delta
+// and returns its text, and withContent returns a copy of an event carrying a
+// slice of that text.
+//
+// Content deltas are buffered and re-emitted at the configured pace. Any other
+// event first flushes the buffered text in one piece and is then forwarded
+// without delay, so order is preserved. Once in closes, the remaining text is
+// paced out before the returned channel closes.
+//
+// Cancelling ctx stops emission, drains in so its producer can finish, and
+// closes the returned channel. A charsPerSec of zero or less returns in
+// unchanged. Most callers use the typed wrappers agent.ThrottleStream and
+// llm.ThrottleStream.
+func ThrottleStream[E any](
+	ctx context.Context,
+	in <-chan E,
+	charsPerSec int,
+	content func(E) (string, bool),
+	withContent func(E, string) E,
+) <-chan E {
+	if charsPerSec <= 0 {
+		return in
+	}
+
+	chunkSize := max(1, charsPerSec/maxThrottleEmitsPerSec)
+	perChar := time.Second / time.Duration(charsPerSec)
+
+	out := make(chan E)
+	go func() {
+		defer close(out)
+
+		var (
+			pending  string
+			template E
+			next     time.Time
+			timer    *time.Timer
+			tick     <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		send := func(evt E) bool {
+			select {
+			case out <- evt:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		schedule := func() {
+			delay := time.Until(next)
+			if timer == nil {
+				timer = time.NewTimer(delay)
+			} else {
+				timer.Reset(delay)
+			}
+			tick = timer.C
+		}
+
+		for in != nil || pending != "" {
+			select {
+			case <-ctx.Done():
+				drainChannel(in)
+				return
+
+			case evt, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				text, isContent := content(evt)
+				if !isContent {
+					if pending != "" {
+						if !send(withContent(template, pending)) {
+							drainChannel(in)
+							return
+						}
+						pending = ""
+						tick = nil
+					}
+					if !send(evt) {
+						drainChannel(in)
+						return
+					}
+					continue
+				}
+				if text == "" {
+					continue
+				}
+				template = evt
+				if pending == "" {
+					if now := time.Now(); next.Before(now) {
+						next = now
+					}
+					schedule()
+				}
+				pending += text
+
+			case <-tick:
+				chunk, rest := splitRunes(pending, chunkSize)
+				if !send(withContent(template, chunk)) {
+					drainChannel(in)
+					return
+				}
+				pending = rest
+				next = time.Now().Add(
+					perChar * time.Duration(utf8.RuneCountInString(chunk)),
+				)
+				tick = nil
+				if pending != "" {
+					schedule()
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// splitRunes returns the first n runes of s and the remainder.
+func splitRunes(s string, n int) (head, tail string) {
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos], s[pos:]
+		}
+		i++
+	}
+	return s, ""
+}
+
+// drainChannel consumes ch until it closes so a producer blocked on a send can
+// finish. A nil channel returns immediately.
+func drainChannel[E any](ch <-chan E) {
+	if ch == nil {
+		return
+	}
+	for {
+		if _, ok := <-ch; !ok {
+			return
+		}
+	}
+}
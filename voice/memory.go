@@ -20,12 +20,36 @@ func (v *Agent) getMemoryLLM() llm.LLM {
 	return v.llm
 }
 
+// scheduleExtraction starts extraction in a background goroutine once
+// memory.WithExtractInterval user turns have completed since the last one.
+// The runner calls it with turnEnded after each user turn, and without it
+// when the conversation ends so pending turns are not lost.
+func (v *Agent) scheduleExtraction(turnEnded bool) {
+	if !v.autoExtract || v.session == nil || v.memory == nil ||
+		v.memoryID == "" {
+		return
+	}
+	v.extractMu.Lock()
+	if turnEnded {
+		v.extractPending++
+	}
+	due := v.extractPending > 0 &&
+		(!turnEnded || v.extractPending >= v.extractInterval)
+	if due {
+		v.extractPending = 0
+	}
+	v.extractMu.Unlock()
+	if due {
+		go func() { _ = v.extractAndStoreMemories(context.Background()) }()
+	}
+}
+
 // extractAndStoreMemories pulls the session's full message history,
 // extracts facts via memory.ExtractFacts, and stores each (with dedup if
 // configured). Mirrors agent.extractAndStoreMemories. Intended to be
-// invoked in a background goroutine after each user turn ends — the runner
-// fires it with context.Background() so an extraction outlives the
-// conversation cancellation.
+// invoked in a background goroutine by scheduleExtraction, with
+// context.Background() so an extraction outlives the conversation
+// cancellation.
 func (v *Agent) extractAndStoreMemories(ctx context.Context) error {
 	if v.memory == nil || !v.autoExtract || v.memoryID == "" ||
 		v.session == nil {
@@ -47,35 +71,53 @@ func (v *Agent) extractAndStoreMemories(ctx context.Context) error {
 			"source":     "auto_extract",
 			"created_at": time.Now().Format(time.RFC3339),
 		}
-		if v.autoDedup {
-			_ = v.storeWithDedup(ctx, fact, metadata)
-		} else {
-			_ = v.memory.Store(ctx, v.memoryID, fact, metadata)
+		var resolution *memory.Resolution
+		if v.conflictResolution {
+			resolution, _ = memory.ResolveConflicts(
+				ctx,
+				v.memory,
+				v.getMemoryLLM(),
+				v.memoryID,
+				fact,
+				metadata,
+			)
+			metadata = resolution.Metadata
+		}
+		kept, handled, err := v.storeWithDedup(ctx, fact, metadata)
+		if err == nil && kept && resolution != nil {
+			_ = resolution.Apply(ctx, v.memory, handled...)
 		}
 	}
 	return nil
 }
 
-// storeWithDedup runs memory.Deduplicate against the top-5 nearest
+// storeWithDedup runs memory.DeduplicateWith against the top-5 nearest
 // memories before storing the new fact. Apply each Add/Update/Delete
-// decision; on any dedup error, fall back to a plain Store.
+// decision; on any dedup error, fall back to a plain Store. Reports whether
+// the fact was kept and the IDs of the memories updated or deleted for it.
 func (v *Agent) storeWithDedup(
 	ctx context.Context,
 	fact string,
 	metadata map[string]any,
-) error {
-	if !v.autoDedup || v.memory == nil || v.memoryID == "" {
-		return v.memory.Store(ctx, v.memoryID, fact, metadata)
+) (kept bool, handled []string, err error) {
+	if !v.autoDedup {
+		return true, nil, v.memory.Store(ctx, v.memoryID, fact, metadata)
 	}
 
 	existing, err := v.memory.Search(ctx, v.memoryID, fact, 5)
 	if err != nil {
-		return v.memory.Store(ctx, v.memoryID, fact, metadata)
+		return true, nil, v.memory.Store(ctx, v.memoryID, fact, metadata)
 	}
 
-	result, err := memory.Deduplicate(ctx, v.getMemoryLLM(), fact, existing)
+	result, err := memory.DeduplicateWith(
+		ctx,
+		v.getMemoryLLM(),
+		fact,
+		existing,
+		v.dedup,
+	)
 	if err != nil {
-		return v.memory.Store(ctx, v.memoryID, fact, metadata)
+		return true, nil, v.memory.Store(ctx, v.memoryID, fact, metadata)
 	}
 
 	for _, decision := range result.Decisions {
@@ -87,8 +129,9 @@ func (v *Agent) storeWithDedup(
 				decision.Text,
 				metadata,
 			); err != nil {
-				return err
+				return false, nil, err
 			}
+			kept = true
 		case memory.DedupEventUpdate:
 			if err := v.memory.Update(
 				ctx,
@@ -96,16 +139,19 @@ func (v *Agent) storeWithDedup(
 				decision.Text,
 				metadata,
 			); err != nil {
-				return err
+				return false, nil, err
 			}
+			kept = true
+			handled = append(handled, decision.MemoryID)
 		case memory.DedupEventDelete:
 			if err := v.memory.Delete(ctx, decision.MemoryID); err != nil {
-				return err
+				return false, nil, err
 			}
+			handled = append(handled, decision.MemoryID)
 		case memory.DedupEventNone:
 		}
 	}
-	return nil
+	return kept, handled, nil
 }
 
 // recallMemoriesContext searches the configured memory store for the
@@ -162,8 +162,15 @@ func WithContextStrategy(
 //     session messages and persists the results to the store. Requires
 //     a session (WithSession) so there's something to extract from.
 //   - memory.AutoDedup() — before storing each extracted fact, run
-//     memory.Deduplicate against the top-5 nearest existing memories and
-//     apply the resulting Add/Update/Delete decisions.
+//     memory.DeduplicateWith against the top-5 nearest existing memories and
+//     apply the resulting Add/Update/Delete decisions. Its options tune the
+//     similarity threshold and the strategy for near-duplicates.
+//   - memory.WithConflictResolution() — store each extracted fact with
+//     links to the existing memories it contradicts, found via
+//     memory.ResolveConflicts, then delete those memories.
+//   - memory.WithExtractInterval(n) — extract once every n user turns
+//     instead of after each one. Turns still pending when a conversation
+//     ends are extracted then.
 //   - memory.LLM(separate) — use a different LLM for extraction/dedup
 //     than the conversation LLM. If unset, uses the agent's main LLM.
 //
@@ -184,7 +191,10 @@ func WithMemory(
 		v.memory = store
 		cfg := memory.Apply(opts...)
 		v.autoExtract = cfg.AutoExtract
+		v.extractInterval = cfg.ExtractInterval
 		v.autoDedup = cfg.AutoDedup
+		v.dedup = cfg.Dedup
+		v.conflictResolution = cfg.ConflictResolution
 		if cfg.LLM != nil {
 			v.memoryLLM = cfg.LLM
 		}
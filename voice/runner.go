@@ -171,6 +171,7 @@ func (c *Conversation) run(
 		}
 
 		activeAgent := v
+		defer func() { activeAgent.scheduleExtraction(false) }()
 
 		if v.initialMessage != "" && !historyHasNonSystem(history) {
 			drainAudio(ttsAudio)
@@ -277,11 +278,7 @@ func (c *Conversation) run(
 				}
 				state.memorySearched.Store(false)
 				state.memoryContext.Store(nil)
-				if activeAgent.autoExtract && activeAgent.session != nil &&
-					activeAgent.memory != nil && activeAgent.memoryID != "" {
-					ag := activeAgent
-					go func() { _ = ag.extractAndStoreMemories(context.Background()) }()
-				}
+				activeAgent.scheduleExtraction(true)
 			}
 		}
 	})
@@ -2,6 +2,7 @@ package voice
 
 import (
 	"context"
+	"sync"
 
 	"github.com/joakimcarlsson/ai/llm"
 	"github.com/joakimcarlsson/ai/memory"
@@ -15,27 +16,32 @@ import (
 // Agent is the configured, reusable definition of a voice agent. One
 // Agent can power any number of concurrent Conversations.
 type Agent struct {
-	llm               llm.LLM
-	stt               stt.SpeechToText
-	tts               tts.Generation
-	systemPrompt      string
-	initialMessage    string
-	tools             []tool.BaseTool
-	toolsets          []tool.Toolset
-	maxToolIterations int
-	filler            FillerConfig
-	toolSound         ToolSoundConfig
-	bargeIn           BargeInPolicy
-	session           session.Session
-	contextStrategy   tokens.Strategy
-	maxContextTokens  int64
-	hooks             []Hooks
-	handoffs          []HandoffConfig
-	memory            memory.Store
-	memoryID          string
-	autoExtract       bool
-	autoDedup         bool
-	memoryLLM         llm.LLM
+	llm                llm.LLM
+	stt                stt.SpeechToText
+	tts                tts.Generation
+	systemPrompt       string
+	initialMessage     string
+	tools              []tool.BaseTool
+	toolsets           []tool.Toolset
+	maxToolIterations  int
+	filler             FillerConfig
+	toolSound          ToolSoundConfig
+	bargeIn            BargeInPolicy
+	session            session.Session
+	contextStrategy    tokens.Strategy
+	maxContextTokens   int64
+	hooks              []Hooks
+	handoffs           []HandoffConfig
+	memory             memory.Store
+	memoryID           string
+	autoExtract        bool
+	autoDedup          bool
+	dedup              memory.DedupConfig
+	conflictResolution bool
+	extractInterval    int
+	extractMu          sync.Mutex
+	extractPending     int
+	memoryLLM          llm.LLM
 }
 
 // toolsForContext returns the union of static tools, toolset-resolved